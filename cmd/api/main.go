@@ -3,92 +3,243 @@ package main
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/iago/extensao-whatsapp-back/internal/ai"
-	"github.com/iago/extensao-whatsapp-back/internal/cache"
-	"github.com/iago/extensao-whatsapp-back/internal/config"
-	contextbuilder "github.com/iago/extensao-whatsapp-back/internal/context"
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+	"github.com/iago/extensao-whatsapp-back/internal/bootstrap"
+	"github.com/iago/extensao-whatsapp-back/internal/health"
 	httpserver "github.com/iago/extensao-whatsapp-back/internal/http"
 	"github.com/iago/extensao-whatsapp-back/internal/http/handlers"
-	"github.com/iago/extensao-whatsapp-back/internal/queue"
-	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
 	"github.com/iago/extensao-whatsapp-back/internal/service"
+	"github.com/iago/extensao-whatsapp-back/internal/slo"
 	"github.com/iago/extensao-whatsapp-back/internal/worker"
 )
 
 func main() {
-	logger := log.New(os.Stdout, "[wa-back] ", log.LstdFlags|log.LUTC|log.Lmicroseconds)
-	if err := config.LoadDotEnv(".env", ".env.local"); err != nil {
-		logger.Printf("failed loading .env files: %v", err)
-	}
-	cfg := config.Load()
+	cfg, logger := bootstrap.Load()
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
+	readiness := health.NewReadiness()
 
-	repo, repoCloser := setupRepository(ctx, cfg, logger)
-	defer repoCloser()
+	defer bootstrap.StartTracing(cfg, logger)()
+	bootstrap.LoadPolicies(ctx, cfg, logger)
 
-	producer, consumer, queueCloser := setupQueue(ctx, cfg, logger)
-	defer queueCloser()
+	runtime := bootstrap.New(ctx, cfg, logger)
+	defer runtime.Close()
+	repos, repo, registry := runtime.Repos, runtime.Jobs, runtime.Registry
+	aiGeneration := runtime.AIGeneration
 
-	modelRouter := ai.NewModelRouter(ai.ModelRouterConfig{
-		SuggestionPrimary:  cfg.OpenRouterModelSuggestionPrimary,
-		SuggestionFallback: cfg.OpenRouterModelSuggestionFallback,
-		SummaryPrimary:     cfg.OpenRouterModelSummaryPrimary,
-		SummaryFallback:    cfg.OpenRouterModelSummaryFallback,
-		ReportPrimary:      cfg.OpenRouterModelReportPrimary,
-		ReportFallback:     cfg.OpenRouterModelReportFallback,
-	})
-	aiClient := ai.NewOpenRouterClient(ai.OpenRouterClientConfig{
-		APIKey:     cfg.OpenRouterAPIKey,
-		BaseURL:    cfg.OpenRouterBaseURL,
-		Timeout:    time.Duration(cfg.OpenRouterTimeoutMS) * time.Millisecond,
-		MaxRetries: cfg.OpenRouterMaxRetries,
-		SiteURL:    cfg.OpenRouterSiteURL,
-		AppName:    cfg.OpenRouterAppName,
-	})
-	contextBuilder := contextbuilder.NewBuilder(contextbuilder.NewBasicRetriever())
-	semanticCache := cache.NewSemanticCache(cache.Config{
-		TTL:        time.Duration(cfg.SemanticCacheTTLSeconds) * time.Second,
-		MaxEntries: cfg.SemanticCacheMaxEntries,
-	})
-	aiGeneration := service.NewAIGenerationService(service.AIGenerationDependencies{
-		Router:     modelRouter,
-		Client:     aiClient,
-		Builder:    contextBuilder,
-		Cache:      semanticCache,
-		PromptsDir: cfg.PromptsDir,
-		Logger:     logger,
+	jobsService := service.NewJobsService(repo, runtime.Producer)
+	jobsService.RequireApproval(cfg.HITLApprovalTenants)
+	jobsService.UseTenantSettings(runtime.TenantSettings)
+	suggestionsService := service.NewSuggestionsService(aiGeneration)
+	pseudonymsService := service.NewPseudonymsService(repos.Pseudonyms)
+	erasureService := service.NewErasureService(repo, repos.Messages, repos.Pseudonyms, repos.Idempotency, repos.Audit, aiGeneration)
+	messagesService := service.NewMessagesService(repos.Messages, runtime.ContextBuilder, pseudonymsService)
+	analysisService := service.NewAnalysisService(aiGeneration)
+	questionsService := service.NewQuestionsService(aiGeneration)
+	actionItemsService := service.NewActionItemsService(aiGeneration)
+	composeService := service.NewComposeService(aiGeneration)
+	digestsService := service.NewDigestsService(jobsService, repos.Messages)
+	insightsService := service.NewInsightsService(aiGeneration, repos.Messages)
+	hitlService := service.NewHITLService(repos.HITL, repo)
+	hitlService.UseRecentReplies(runtime.RecentReplies)
+	templatesService := service.NewTemplatesService(repos.Templates)
+	policyViolationsService := service.NewPolicyViolationsService(repos.PolicyViolations)
+	policyViolationsService.UseMetrics(policy.NewMetrics(registry))
+	apiKeysService := service.NewAPIKeysService(repos.APIKeys, repos.Audit)
+	sloTracker := slo.NewTracker(slo.DefaultObjectives(), time.Duration(cfg.SLOWindowHours)*time.Hour)
+	slo.RegisterMetrics(registry, sloTracker)
+
+	// WORKER_ENABLED runs the worker in this process (combined mode); cmd/worker runs it on
+	// its own.
+	var (
+		workerControl handlers.WorkerControl
+		processor     *worker.Processor
+	)
+	if cfg.WorkerEnabled {
+		processor = runtime.NewProcessor()
+		workerControl = processor
+		runtime.StartDLQAlerts(ctx)
+		go processor.Start(ctx)
+		logger.Info("worker enabled and started")
+	} else {
+		logger.Info("worker disabled by configuration")
+	}
+	runtime.StartScheduler(ctx, digestsService)
+
+	routeLimits, err := middleware.ParseRouteLimits(cfg.RateLimitRoutes)
+	if err != nil {
+		bootstrap.Fatal(logger, "invalid RATE_LIMIT_ROUTES", err)
+	}
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst, routeLimits...)
+	ipRateLimiter := middleware.NewIPRateLimiter(cfg.RateLimitIPRPS, cfg.RateLimitIPBurst)
+	reloader := runtime.NewReloader()
+	bootstrap.HandleRateLimitReload(reloader, rateLimiter, ipRateLimiter)
+	go reloader.WatchSignal(ctx)
+	go runtime.LogLevels.WatchSignal(ctx, logger)
+	go runtime.WatchRemoteConfig(ctx, reloader)
+	api := handlers.NewAPI(handlers.APIDependencies{
+		Jobs:             jobsService,
+		Suggestions:      suggestionsService,
+		Erasure:          erasureService,
+		Analysis:         analysisService,
+		Questions:        questionsService,
+		ActionItems:      actionItemsService,
+		Compose:          composeService,
+		Digests:          digestsService,
+		Insights:         insightsService,
+		Messages:         messagesService,
+		Pseudonyms:       pseudonymsService,
+		PolicyViolations: policyViolationsService,
+		HITL:             hitlService,
+		Templates:        templatesService,
+		TenantSettings:   runtime.TenantSettings,
+		Metering:         runtime.Metering,
+		Health:           runtime.Health(),
+		Readiness:        readiness,
+		Admin: handlers.AdminDependencies{
+			Cache:         runtime.Cache,
+			Config:        cfg.Snapshot(),
+			ConfigSources: cfg.Sources(),
+			RateLimits:    rateLimiter,
+			Worker:        workerControl,
+			APIKeys:       apiKeysService,
+			Reloader:      reloader,
+			SLO:           sloTracker,
+			LogLevels:     runtime.LogLevels,
+		},
 	})
 
-	jobsService := service.NewJobsService(repo, producer)
-	suggestionsService := service.NewSuggestionsService(aiGeneration)
-	api := handlers.NewAPI(jobsService, suggestionsService)
+	var jwtVerifier *auth.JWTVerifier
+	if cfg.JWTJWKSURL != "" {
+		jwtVerifier, err = auth.NewJWTVerifier(auth.JWTConfig{
+			Issuer:       cfg.JWTIssuer,
+			Audience:     cfg.JWTAudience,
+			JWKSURL:      cfg.JWTJWKSURL,
+			JWKSCacheTTL: time.Duration(cfg.JWTJWKSCacheSeconds) * time.Second,
+		})
+		if err != nil {
+			bootstrap.Fatal(logger, "invalid JWT configuration", err)
+		}
+		logger.Info("jwt authentication enabled", slog.String("jwks", cfg.JWTJWKSURL))
+	}
+
+	var signatureVerifier *middleware.SignatureVerifier
+	if cfg.RequestSigningSecrets != "" || cfg.RequestSigningRequired {
+		secrets, err := middleware.ParseSignatureSecrets(cfg.RequestSigningSecrets)
+		if err != nil {
+			bootstrap.Fatal(logger, "invalid request signing configuration", err)
+		}
+		signatureVerifier = middleware.NewSignatureVerifier(middleware.SignatureConfig{
+			Secrets:   secrets,
+			Tolerance: time.Duration(cfg.RequestSigningToleranceSeconds) * time.Second,
+			Required:  cfg.RequestSigningRequired,
+		})
+		logger.Info("request signing enabled", slog.Int("tenants", len(secrets)), slog.Bool("required", cfg.RequestSigningRequired))
+	}
+
+	timeoutRoutes, err := middleware.ParseRouteTimeouts(cfg.RequestTimeoutRoutes)
+	if err != nil {
+		bootstrap.Fatal(logger, "invalid REQUEST_TIMEOUT_ROUTES", err)
+	}
+	bodyLimitRoutes, err := middleware.ParseBodyLimitRoutes(cfg.MaxBodyRoutes)
+	if err != nil {
+		bootstrap.Fatal(logger, "invalid MAX_BODY_ROUTES", err)
+	}
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		bootstrap.Fatal(logger, "invalid TRUSTED_PROXIES", err)
+	}
+	ipAllowlist, err := middleware.ParseIPList(cfg.IPAllowlist)
+	if err != nil {
+		bootstrap.Fatal(logger, "invalid IP_ALLOWLIST", err)
+	}
+	ipDenylist, err := middleware.ParseIPList(cfg.IPDenylist)
+	if err != nil {
+		bootstrap.Fatal(logger, "invalid IP_DENYLIST", err)
+	}
+	ipTenantAllowlists, err := middleware.ParseTenantIPAllowlists(cfg.IPTenantAllowlists)
+	if err != nil {
+		bootstrap.Fatal(logger, "invalid IP_TENANT_ALLOWLISTS", err)
+	}
+	var ipFilter *middleware.IPFilter
+	if filter := middleware.NewIPFilter(middleware.IPFilterConfig{
+		Allow:   ipAllowlist,
+		Deny:    ipDenylist,
+		Tenants: ipTenantAllowlists,
+	}); filter.Enabled() {
+		ipFilter = filter
+	}
+
+	var (
+		httpMetrics    *middleware.HTTPMetrics
+		metricsHandler http.Handler
+		metricsServer  *http.Server
+	)
+	if registry != nil {
+		httpMetrics = middleware.NewHTTPMetrics(registry)
+		if cfg.MetricsPort != "" {
+			metricsMux := http.NewServeMux()
+			metricsMux.Handle("/metrics", registry.Handler())
+			metricsServer = &http.Server{
+				Addr:              ":" + cfg.MetricsPort,
+				Handler:           metricsMux,
+				ReadHeaderTimeout: 5 * time.Second,
+			}
+		} else {
+			metricsHandler = registry.Handler()
+		}
+	}
 
 	handler := httpserver.NewRouter(httpserver.RouterDependencies{
 		API:            api,
 		Logger:         logger,
-		AuthToken:      cfg.AuthToken,
+		AuthTokenFunc:  runtime.AuthToken,
+		JWT:            jwtVerifier,
+		APIKeys:        apiKeysService,
+		Signature:      signatureVerifier,
+		AdminToken:     cfg.AdminToken,
 		CORSOrigins:    cfg.CORSAllowedOrigins,
-		RateLimitRPS:   cfg.RateLimitRPS,
-		RateLimitBurst: cfg.RateLimitBurst,
+		TrustedProxies: trustedProxies,
+		IPFilter:       ipFilter,
+		ErrorReporter:  runtime.ErrorReporter,
+		Idempotency: middleware.IdempotencyConfig{
+			Store: repos.Idempotency,
+			TTL:   time.Duration(cfg.IdempotencyTTLHours) * time.Hour,
+		},
+		SecurityHeaders: middleware.SecurityHeadersConfig{
+			ReferrerPolicy: cfg.ReferrerPolicy,
+			HSTSMaxAge:     time.Duration(cfg.HSTSMaxAgeSeconds) * time.Second,
+		},
+		Concurrency: middleware.NewConcurrencyLimiter(middleware.ConcurrencyConfig{
+			MaxInFlight:       cfg.ConcurrencyMaxInFlight,
+			TenantMaxInFlight: cfg.ConcurrencyTenantMaxInFlight,
+			QueueWait:         time.Duration(cfg.ConcurrencyQueueWaitMS) * time.Millisecond,
+		}),
+		Timeout: middleware.TimeoutConfig{
+			Timeout: time.Duration(cfg.RequestTimeoutMS) * time.Millisecond,
+			Routes:  timeoutRoutes,
+		},
+		BodyLimit: middleware.BodyLimitConfig{
+			MaxBytes: cfg.MaxBodyBytes,
+			Routes:   bodyLimitRoutes,
+		},
+		RateLimiter:    rateLimiter,
+		IPRateLimiter:  ipRateLimiter,
+		Audit:          repos.Audit,
+		SLO:            sloTracker,
+		Metrics:        httpMetrics,
+		MetricsHandler: metricsHandler,
 	})
 
-	if cfg.WorkerEnabled {
-		processor := worker.NewProcessor(consumer, repo, aiGeneration, logger)
-		go processor.Start(ctx)
-		logger.Printf("worker enabled and started")
-	} else {
-		logger.Printf("worker disabled by configuration")
-	}
-
 	server := &http.Server{
 		Addr:              ":" + cfg.Port,
 		Handler:           handler,
@@ -97,115 +248,65 @@ func main() {
 		WriteTimeout:      15 * time.Second,
 		IdleTimeout:       60 * time.Second,
 	}
+	tlsSettings := httpserver.TLSConfig{
+		CertFile:     cfg.TLSCertFile,
+		KeyFile:      cfg.TLSKeyFile,
+		ClientCAFile: cfg.TLSClientCAFile,
+		ClientAuth:   cfg.TLSClientAuth,
+	}
+	if tlsSettings.Enabled() || tlsSettings.ClientCAFile != "" {
+		server.TLSConfig, err = httpserver.NewTLSConfig(tlsSettings)
+		if err != nil {
+			bootstrap.Fatal(logger, "invalid TLS configuration", err)
+		}
+	}
 
 	errChan := make(chan error, 1)
 	go func() {
-		logger.Printf("api listening on :%s", cfg.Port)
+		logger.Info("api listening",
+			slog.String("addr", ":"+cfg.Port),
+			slog.Bool("tls", server.TLSConfig != nil),
+			slog.Bool("client_certs", server.TLSConfig != nil && server.TLSConfig.ClientCAs != nil),
+		)
+		if server.TLSConfig != nil {
+			errChan <- server.ListenAndServeTLS("", "")
+			return
+		}
 		errChan <- server.ListenAndServe()
 	}()
+	if metricsServer != nil {
+		go func() {
+			logger.Info("metrics listening", slog.String("addr", ":"+cfg.MetricsPort))
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("metrics server failed", slog.Any("error", err))
+			}
+		}()
+	}
+	readiness.MarkReady()
 
 	select {
 	case <-ctx.Done():
-		logger.Printf("shutdown signal received")
+		logger.Info("shutdown signal received")
+		readiness.MarkNotReady("draining")
+		if drain := time.Duration(cfg.ShutdownDrainSeconds) * time.Second; drain > 0 {
+			logger.Info("draining before shutdown", slog.String("drain", drain.String()))
+			time.Sleep(drain)
+		}
 	case err := <-errChan:
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logger.Printf("server failed: %v", err)
+			logger.Error("server failed", slog.Any("error", err))
 		}
 	}
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		logger.Printf("graceful shutdown failed: %v", err)
+		logger.Error("graceful shutdown failed", slog.Any("error", err))
 	}
-}
-
-func setupRepository(
-	ctx context.Context,
-	cfg config.Config,
-	logger *log.Logger,
-) (repository.JobsRepository, func()) {
-	if cfg.DatabaseURL == "" {
-		logger.Printf("DATABASE_URL not configured, using in-memory repository")
-		return repository.NewMemoryJobsRepository(), func() {}
+	if metricsServer != nil {
+		_ = metricsServer.Shutdown(shutdownCtx)
 	}
-
-	pgRepo, err := repository.NewPostgresJobsRepository(ctx, cfg.DatabaseURL)
-	if err != nil {
-		logger.Printf("failed to initialize postgres repository, fallback to memory: %v", err)
-		return repository.NewMemoryJobsRepository(), func() {}
-	}
-	logger.Printf("postgres repository initialized")
-	return pgRepo, func() {
-		pgRepo.Close()
-	}
-}
-
-func setupQueue(
-	ctx context.Context,
-	cfg config.Config,
-	logger *log.Logger,
-) (queue.Producer, queue.Consumer, func()) {
-	var (
-		baseProducer queue.Producer
-		consumer     queue.Consumer
-		baseCloser   = func() {}
-	)
-
-	if cfg.RedisAddr == "" {
-		logger.Printf("REDIS_ADDR not configured, using local queue fallback")
-		local := queue.NewLocalQueue(512, 3, logger)
-		baseProducer = local
-		consumer = local
-	} else {
-		streams, err := queue.NewStreamsQueue(ctx, queue.StreamsConfig{
-			Addr:        cfg.RedisAddr,
-			Password:    cfg.RedisPassword,
-			DB:          cfg.RedisDB,
-			Stream:      cfg.RedisStream,
-			DLQStream:   cfg.RedisDLQ,
-			Group:       cfg.RedisGroup,
-			Consumer:    cfg.RedisConsumer,
-			MaxAttempts: 3,
-		})
-		if err != nil {
-			logger.Printf("failed to initialize redis streams queue, fallback to local: %v", err)
-			local := queue.NewLocalQueue(512, 3, logger)
-			baseProducer = local
-			consumer = local
-		} else {
-			logger.Printf("redis streams queue initialized")
-			baseProducer = streams
-			consumer = streams
-			baseCloser = func() {
-				_ = streams.Close()
-			}
-		}
-	}
-
-	producer := baseProducer
-	batchingCloser := func() {}
-	if cfg.QueueBatchingEnabled {
-		batching := queue.NewBatchingProducer(ctx, baseProducer, queue.BatchingConfig{
-			MaxBatchSize:       cfg.QueueBatchSize,
-			FlushInterval:      time.Duration(cfg.QueueBatchFlushMS) * time.Millisecond,
-			FlushTimeout:       time.Duration(cfg.QueueBatchFlushTimeoutMS) * time.Millisecond,
-			QueueCapacity:      cfg.QueueBatchQueueCapacity,
-			MaxInFlightBatches: cfg.QueueBatchMaxInFlight,
-		})
-		producer = batching
-		batchingCloser = batching.Close
-		logger.Printf(
-			"queue batching enabled size=%d flush_ms=%d queue_capacity=%d max_in_flight=%d",
-			cfg.QueueBatchSize,
-			cfg.QueueBatchFlushMS,
-			cfg.QueueBatchQueueCapacity,
-			cfg.QueueBatchMaxInFlight,
-		)
-	}
-
-	return producer, consumer, func() {
-		batchingCloser()
-		baseCloser()
+	if processor != nil {
+		runtime.DrainProcessor(processor)
 	}
 }
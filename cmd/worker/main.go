@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/bootstrap"
+	"github.com/iago/extensao-whatsapp-back/internal/health"
+	"github.com/iago/extensao-whatsapp-back/internal/queue"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+// The worker binary consumes jobs from Redis Streams so workers scale apart from the API.
+// It serves health checks and metrics on WORKER_PORT.
+func main() {
+	cfg, logger := bootstrap.Load()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	readiness := health.NewReadiness()
+
+	defer bootstrap.StartTracing(cfg, logger)()
+	bootstrap.LoadPolicies(ctx, cfg, logger)
+
+	runtime := bootstrap.New(ctx, cfg, logger)
+	defer runtime.Close()
+	// The local queue only holds jobs enqueued by its own process, so a standalone worker
+	// would never receive any.
+	if _, local := runtime.Consumer.(*queue.LocalQueue); local {
+		bootstrap.Fatal(logger, "worker requires a Redis queue", errors.New("REDIS_ADDR not configured or unreachable"))
+	}
+
+	processor := runtime.NewProcessor()
+	runtime.StartDLQAlerts(ctx)
+	go processor.Start(ctx)
+	jobsService := service.NewJobsService(runtime.Jobs, runtime.Producer)
+	jobsService.UseTenantSettings(runtime.TenantSettings)
+	runtime.StartScheduler(ctx, service.NewDigestsService(jobsService, runtime.Repos.Messages))
+	reloader := runtime.NewReloader()
+	go reloader.WatchSignal(ctx)
+	go runtime.LogLevels.WatchSignal(ctx, logger)
+	go runtime.WatchRemoteConfig(ctx, reloader)
+
+	checker := runtime.Health()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, checker.Run(r.Context()))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if ready, reason := readiness.State(); !ready {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "not_ready", "reason": reason})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ready"})
+	})
+	if runtime.Registry != nil {
+		mux.Handle("/metrics", runtime.Registry.Handler())
+	}
+	server := &http.Server{
+		Addr:              ":" + cfg.WorkerPort,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		logger.Info("worker started", slog.String("addr", ":"+cfg.WorkerPort))
+		errChan <- server.ListenAndServe()
+	}()
+	readiness.MarkReady()
+
+	select {
+	case <-ctx.Done():
+		logger.Info("shutdown signal received")
+		readiness.MarkNotReady("draining")
+		runtime.DrainProcessor(processor)
+	case err := <-errChan:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("worker server failed", slog.Any("error", err))
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("graceful shutdown failed", slog.Any("error", err))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,11 @@
+BEGIN;
+
+-- Results older than the retention window move to object storage; the row keeps a pointer.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS result_archive_key TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS jobs_archivable_idx
+  ON jobs (updated_at)
+  WHERE status = 'done' AND result_archive_key IS NULL;
+
+COMMIT;
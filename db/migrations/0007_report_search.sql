@@ -0,0 +1,19 @@
+BEGIN;
+
+-- Full-text search over report results. Maintained by the repository on UpdateJob
+-- (not a generated column) so it survives result archiving.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS result_search TSVECTOR;
+
+UPDATE jobs
+SET result_search =
+  setweight(to_tsvector('portuguese', COALESCE(result->>'title', '')), 'A') ||
+  setweight(jsonb_to_tsvector('portuguese', COALESCE(result->'sections', '[]'::jsonb), '["string"]'), 'B')
+WHERE kind = 'report'
+  AND result IS NOT NULL
+  AND result_search IS NULL;
+
+CREATE INDEX IF NOT EXISTS jobs_report_search_idx
+  ON jobs USING gin (result_search)
+  WHERE kind = 'report';
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+-- Row-level tenant isolation. The API sets app.tenant_id on every pooled connection
+-- from the request scope; an empty setting (workers, maintenance) sees all rows.
+-- FORCE makes the policies apply to the table owner used by the application too.
+CREATE OR REPLACE FUNCTION app_tenant_allows(row_tenant TEXT) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+  SELECT COALESCE(current_setting('app.tenant_id', true), '') = ''
+      OR row_tenant = current_setting('app.tenant_id', true)
+$$;
+
+DO $$
+DECLARE
+  table_name TEXT;
+BEGIN
+  FOREACH table_name IN ARRAY ARRAY['jobs', 'summaries', 'reports', 'messages', 'conversations', 'audit_log'] LOOP
+    EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', table_name);
+    EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', table_name);
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', table_name);
+    EXECUTE format(
+      'CREATE POLICY tenant_isolation ON %I USING (app_tenant_allows(tenant_id)) WITH CHECK (app_tenant_allows(tenant_id))',
+      table_name
+    );
+  END LOOP;
+END
+$$;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Lifecycle timestamps exposed by the job status endpoint.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS finished_at TIMESTAMPTZ;
+
+COMMIT;
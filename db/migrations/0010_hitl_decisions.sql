@@ -0,0 +1,35 @@
+BEGIN;
+
+-- Append-only record of human review (HITL) of generated content, kept for compliance.
+-- References a job (summary/report) or a suggestion response; content is not stored.
+CREATE TABLE IF NOT EXISTS hitl_decisions (
+  id UUID PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  conversation_id TEXT NOT NULL,
+  job_id UUID,
+  suggestion_request_id TEXT NOT NULL DEFAULT '',
+  suggestion_rank INT NOT NULL DEFAULT 0,
+  action TEXT NOT NULL CHECK (action IN ('approved', 'edited', 'rejected')),
+  reviewer_id TEXT NOT NULL,
+  final_text_checksum TEXT NOT NULL DEFAULT '',
+  actor TEXT NOT NULL,
+  request_id TEXT NOT NULL DEFAULT '',
+  decided_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CHECK (job_id IS NOT NULL OR suggestion_request_id <> '')
+);
+
+CREATE INDEX IF NOT EXISTS hitl_decisions_tenant_decided_idx
+  ON hitl_decisions (tenant_id, decided_at DESC);
+CREATE INDEX IF NOT EXISTS hitl_decisions_job_idx
+  ON hitl_decisions (job_id)
+  WHERE job_id IS NOT NULL;
+
+ALTER TABLE hitl_decisions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE hitl_decisions FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON hitl_decisions;
+CREATE POLICY tenant_isolation ON hitl_decisions
+  USING (app_tenant_allows(tenant_id))
+  WITH CHECK (app_tenant_allows(tenant_id));
+
+COMMIT;
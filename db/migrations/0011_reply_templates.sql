@@ -0,0 +1,26 @@
+BEGIN;
+
+-- Tenant canned responses ("respostas prontas"). Placeholders are the {{name}} variables
+-- extracted from content when the template is saved.
+CREATE TABLE IF NOT EXISTS reply_templates (
+  id UUID PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  content TEXT NOT NULL,
+  locale TEXT NOT NULL DEFAULT 'pt-BR',
+  placeholders TEXT[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS reply_templates_tenant_name_uidx
+  ON reply_templates (tenant_id, lower(name));
+
+ALTER TABLE reply_templates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reply_templates FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON reply_templates;
+CREATE POLICY tenant_isolation ON reply_templates
+  USING (app_tenant_allows(tenant_id))
+  WITH CHECK (app_tenant_allows(tenant_id));
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- Free-form tags (client, campaign) used to organize and filter reports.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS jobs_report_tags_idx
+  ON jobs USING GIN (tags)
+  WHERE kind = 'report';
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Digests summarize a tenant's conversations for a period; they are stored as jobs with
+-- an empty conversation_id.
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_kind_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_kind_check CHECK (kind IN ('summary', 'report', 'digest'));
+
+CREATE INDEX IF NOT EXISTS jobs_digest_tenant_created_idx
+  ON jobs (tenant_id, created_at DESC)
+  WHERE kind = 'digest';
+
+COMMIT;
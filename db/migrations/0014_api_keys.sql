@@ -0,0 +1,23 @@
+BEGIN;
+
+-- Per-tenant API keys. Only the SHA-256 of the secret is stored; prefix is the public
+-- head ("wak_xxxxxxxx") shown in listings. Keys are looked up by hash before the request
+-- is tenant scoped, so the table has no row-level tenant policy.
+CREATE TABLE IF NOT EXISTS api_keys (
+  id UUID PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  prefix TEXT NOT NULL,
+  key_hash TEXT NOT NULL,
+  scopes TEXT[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  rotated_from UUID REFERENCES api_keys (id),
+  CONSTRAINT api_keys_scopes_check CHECK (scopes <@ ARRAY['suggestions', 'reports', 'admin']::TEXT[])
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_hash_uidx ON api_keys (key_hash);
+CREATE INDEX IF NOT EXISTS api_keys_tenant_created_idx ON api_keys (tenant_id, created_at DESC);
+
+COMMIT;
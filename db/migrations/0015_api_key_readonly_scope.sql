@@ -0,0 +1,8 @@
+BEGIN;
+
+-- readonly keys only read jobs and reports.
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_scopes_check;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_scopes_check
+  CHECK (scopes <@ ARRAY['suggestions', 'reports', 'admin', 'readonly']::TEXT[]);
+
+COMMIT;
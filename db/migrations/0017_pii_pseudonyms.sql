@@ -0,0 +1,25 @@
+BEGIN;
+
+-- Reversible PII tokens ("[TEL_1]") of each conversation, for tenants whose PII rules
+-- pseudonymize instead of masking. value holds the original text, sealed when encryption
+-- at rest is enabled; value_hash finds the token of a value seen before.
+CREATE TABLE IF NOT EXISTS pii_pseudonyms (
+  tenant_id TEXT NOT NULL DEFAULT '',
+  conversation_id TEXT NOT NULL,
+  token TEXT NOT NULL,
+  category TEXT NOT NULL,
+  value_hash TEXT NOT NULL,
+  value BYTEA NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (tenant_id, conversation_id, token),
+  UNIQUE (tenant_id, conversation_id, category, value_hash)
+);
+
+ALTER TABLE pii_pseudonyms ENABLE ROW LEVEL SECURITY;
+ALTER TABLE pii_pseudonyms FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON pii_pseudonyms;
+CREATE POLICY tenant_isolation ON pii_pseudonyms
+  USING (app_tenant_allows(tenant_id))
+  WITH CHECK (app_tenant_allows(tenant_id));
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+-- Requests blocked by the content policy, kept to tune the keyword lists against real
+-- false positives. rule is the keyword entry or limit that fired.
+CREATE TABLE IF NOT EXISTS policy_violations (
+  id UUID PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  conversation_id TEXT NOT NULL DEFAULT '',
+  route TEXT NOT NULL,
+  codes TEXT[] NOT NULL,
+  rule TEXT NOT NULL DEFAULT '',
+  request_id TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS policy_violations_tenant_created_idx
+  ON policy_violations (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS policy_violations_created_idx
+  ON policy_violations (created_at DESC);
+
+ALTER TABLE policy_violations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE policy_violations FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON policy_violations;
+CREATE POLICY tenant_isolation ON policy_violations
+  USING (app_tenant_allows(tenant_id))
+  WITH CHECK (app_tenant_allows(tenant_id));
+
+COMMIT;
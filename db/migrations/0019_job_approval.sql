@@ -0,0 +1,11 @@
+BEGIN;
+
+-- Human sign-off on generated results for regulated tenants; empty when not required.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS approval TEXT NOT NULL DEFAULT ''
+  CHECK (approval IN ('', 'pending', 'approved', 'rejected'));
+
+CREATE INDEX IF NOT EXISTS jobs_pending_approval_idx
+  ON jobs (tenant_id, created_at DESC)
+  WHERE approval = 'pending';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Classifies failed jobs (processing_error, job_timeout); empty otherwise.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS error_code TEXT NOT NULL DEFAULT '';
+
+COMMIT;
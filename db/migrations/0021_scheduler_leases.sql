@@ -0,0 +1,11 @@
+BEGIN;
+
+-- Leases elect the replica that runs recurring jobs. A holder keeps its lease by renewing
+-- it before expires_at; an expired lease can be taken by any replica.
+CREATE TABLE IF NOT EXISTS scheduler_leases (
+  name TEXT PRIMARY KEY,
+  holder TEXT NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL
+);
+
+COMMIT;
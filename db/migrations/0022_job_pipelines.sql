@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Pipeline steps wait for the job they depend on (a report on a fresh summary). Kinds are
+-- no longer listed here: the worker handler registry decides which ones run.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS depends_on TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_kind_check;
+
+CREATE INDEX IF NOT EXISTS jobs_depends_on_idx
+  ON jobs (depends_on)
+  WHERE depends_on <> '';
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- Queue lane of a job, kept so retries and pipeline steps are enqueued with the priority
+-- they were requested with.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'high';
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_priority_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_priority_check CHECK (priority IN ('high', 'low'));
+
+COMMIT;
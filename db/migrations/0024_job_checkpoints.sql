@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Partial output of a running job (the report sections generated so far), so a retry
+-- resumes from it instead of starting over. Cleared once the job is done.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS checkpoint JSONB;
+
+COMMIT;
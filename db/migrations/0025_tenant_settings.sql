@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Per-tenant behavior (default locale and tone, model overrides, quotas, policy profile).
+-- Empty values fall back to the process-wide defaults.
+CREATE TABLE IF NOT EXISTS tenant_settings (
+  tenant_id TEXT PRIMARY KEY,
+  locale TEXT NOT NULL DEFAULT '',
+  tone TEXT NOT NULL DEFAULT '',
+  models JSONB NOT NULL DEFAULT '{}',
+  quotas JSONB NOT NULL DEFAULT '{}',
+  policy_profile TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE tenant_settings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tenant_settings FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON tenant_settings;
+CREATE POLICY tenant_isolation ON tenant_settings
+  USING (app_tenant_allows(tenant_id))
+  WITH CHECK (app_tenant_allows(tenant_id));
+
+COMMIT;
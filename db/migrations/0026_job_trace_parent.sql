@@ -0,0 +1,7 @@
+BEGIN;
+
+-- W3C trace context of the request that created the job, so its status can point to the
+-- distributed trace covering the enqueue, the queue wait and the worker.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS trace_parent TEXT NOT NULL DEFAULT '';
+
+COMMIT;
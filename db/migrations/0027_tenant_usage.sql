@@ -0,0 +1,24 @@
+BEGIN;
+
+-- Metered usage per tenant and UTC day, incremented by the metering service of each
+-- replica. cost_usd only covers models with a configured price.
+CREATE TABLE IF NOT EXISTS tenant_usage (
+  tenant_id TEXT NOT NULL,
+  day DATE NOT NULL,
+  requests BIGINT NOT NULL DEFAULT 0,
+  cache_hits BIGINT NOT NULL DEFAULT 0,
+  input_tokens BIGINT NOT NULL DEFAULT 0,
+  output_tokens BIGINT NOT NULL DEFAULT 0,
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (tenant_id, day)
+);
+
+ALTER TABLE tenant_usage ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tenant_usage FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON tenant_usage;
+CREATE POLICY tenant_isolation ON tenant_usage
+  USING (app_tenant_allows(tenant_id))
+  WITH CHECK (app_tenant_allows(tenant_id));
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- X-Request-Id of the request that created the job, so a request reported by a user can be
+-- followed to its job, the worker logs and the AI provider calls.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS request_id TEXT NOT NULL DEFAULT '';
+
+COMMIT;
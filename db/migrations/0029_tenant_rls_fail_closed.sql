@@ -0,0 +1,13 @@
+BEGIN;
+
+-- Row-level tenant isolation fails closed: an empty app.tenant_id no longer sees every
+-- tenant's rows. Cross-tenant work (workers, archiver, scheduler, admin routes) sets
+-- app.rls_bypass = 'on' explicitly; maintenance sessions do the same with
+-- SET app.rls_bypass = 'on', or connect with a role that has BYPASSRLS.
+CREATE OR REPLACE FUNCTION app_tenant_allows(row_tenant TEXT) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+  SELECT COALESCE(current_setting('app.rls_bypass', true), '') = 'on'
+      OR COALESCE(row_tenant = NULLIF(current_setting('app.tenant_id', true), ''), false)
+$$;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+-- Conversation the cached response belongs to, so erasing a conversation also drops the
+-- responses replayed for its requests.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS conversation_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_conversation_idx ON idempotency_keys (tenant_id, conversation_id);
+
+COMMIT;
@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLoggedErrorBytes bounds the provider error kept in a call event; provider bodies can
+// echo the whole prompt.
+const maxLoggedErrorBytes = 256
+
+// CallEvent is one provider call, or one generation answered from the semantic cache
+// without a call.
+type CallEvent struct {
+	Task          string
+	TenantID      string
+	Model         string
+	PromptVersion string
+	Usage         TokenUsage
+	Latency       time.Duration
+	CacheHit      bool
+	// Fallback marks a call to the fallback model after the primary failed.
+	Fallback bool
+	Err      error
+}
+
+// CallLogger writes call events as "ai call" records with fixed attribute names, so they
+// can be aggregated. Successful events are sampled at the configured rate and carry it as
+// sample_rate, for reweighting; failed calls are always logged. A nil *CallLogger logs
+// nothing.
+type CallLogger struct {
+	logger     *slog.Logger
+	sampleRate float64
+	sample     func() float64
+}
+
+// NewCallLogger keeps sampleRate of the successful events, clamped to [0, 1].
+func NewCallLogger(logger *slog.Logger, sampleRate float64) *CallLogger {
+	if logger == nil {
+		return nil
+	}
+	return &CallLogger{logger: logger, sampleRate: min(max(sampleRate, 0), 1), sample: rand.Float64}
+}
+
+func (l *CallLogger) Log(ctx context.Context, event CallEvent) {
+	if l == nil {
+		return
+	}
+	level := slog.LevelInfo
+	if event.Err != nil {
+		level = slog.LevelWarn
+	} else if l.sampleRate < 1 && l.sample() >= l.sampleRate {
+		return
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("task", event.Task),
+		slog.String("tenant_id", event.TenantID),
+		slog.String("model_id", event.Model),
+		slog.String("prompt_version", event.PromptVersion),
+		slog.Int("input_tokens", event.Usage.InputTokens),
+		slog.Int("output_tokens", event.Usage.OutputTokens),
+		slog.Int64("latency_ms", event.Latency.Milliseconds()),
+		slog.Bool("cache_hit", event.CacheHit),
+		slog.Bool("fallback", event.Fallback),
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.String("error", truncateError(event.Err.Error())))
+	} else {
+		attrs = append(attrs, slog.Float64("sample_rate", l.sampleRate))
+	}
+	l.logger.LogAttrs(ctx, level, "ai call", attrs...)
+}
+
+func truncateError(message string) string {
+	if len(message) <= maxLoggedErrorBytes {
+		return message
+	}
+	cut := maxLoggedErrorBytes
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut] + "..."
+}
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallLoggerSamplesSuccessesAndKeepsFailures(t *testing.T) {
+	output := bytes.Buffer{}
+	logger := NewCallLogger(slog.New(slog.NewJSONHandler(&output, nil)), 0.25)
+	draws := []float64{0.1, 0.9, 0.5}
+	logger.sample = func() float64 {
+		draw := draws[0]
+		draws = draws[1:]
+		return draw
+	}
+
+	event := CallEvent{
+		Task:          "summary",
+		TenantID:      "tenant-a",
+		Model:         "openai/gpt-4o-mini",
+		PromptVersion: "summary_v1",
+		Usage:         TokenUsage{InputTokens: 120, OutputTokens: 40},
+		Latency:       850 * time.Millisecond,
+	}
+	ctx := context.Background()
+	logger.Log(ctx, event) // kept: 0.1 < 0.25
+	logger.Log(ctx, event) // dropped
+	logger.Log(ctx, event) // dropped
+	failed := event
+	failed.Fallback = true
+	failed.Err = errors.New("provider status 500: " + strings.Repeat("x", 400))
+	logger.Log(ctx, failed) // failures skip sampling
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one sampled success and one failure, got %d lines:\n%s", len(lines), output.String())
+	}
+	var success, failure map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &success); err != nil {
+		t.Fatalf("decode success: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &failure); err != nil {
+		t.Fatalf("decode failure: %v", err)
+	}
+	if success["msg"] != "ai call" || success["level"] != "INFO" || success["model_id"] != "openai/gpt-4o-mini" ||
+		success["prompt_version"] != "summary_v1" || success["input_tokens"] != float64(120) ||
+		success["latency_ms"] != float64(850) || success["cache_hit"] != false || success["sample_rate"] != 0.25 {
+		t.Fatalf("unexpected success event: %+v", success)
+	}
+	message, _ := failure["error"].(string)
+	if failure["level"] != "WARN" || failure["fallback"] != true || len(message) > maxLoggedErrorBytes+3 ||
+		!strings.HasSuffix(message, "...") {
+		t.Fatalf("unexpected failure event: %+v", failure)
+	}
+
+	var disabled *CallLogger
+	disabled.Log(ctx, event)
+}
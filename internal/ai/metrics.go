@@ -0,0 +1,50 @@
+package ai
+
+import (
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
+)
+
+// latencyBuckets cover provider calls from short completions to the request timeout.
+var latencyBuckets = []float64{0.25, 0.5, 1, 2, 3, 5, 8, 13, 20, 30, 60}
+
+// Metrics records provider calls per model: outcome, latency, tokens and, for models with
+// a price, cost. A nil *Metrics records nothing.
+type Metrics struct {
+	calls    *metrics.CounterVec
+	duration *metrics.HistogramVec
+	tokens   *metrics.CounterVec
+	cost     *metrics.CounterVec
+}
+
+// NewMetrics registers the provider call metrics; a nil registry disables them.
+func NewMetrics(registry *metrics.Registry) *Metrics {
+	if registry == nil {
+		return nil
+	}
+	return &Metrics{
+		calls:    registry.Counter("ai_requests_total", "Provider calls by model and outcome (ok or error).", "model", "status"),
+		duration: registry.Histogram("ai_request_duration_seconds", "Provider call latency by model.", latencyBuckets, "model"),
+		tokens:   registry.Counter("ai_tokens_total", "Tokens billed by the provider by model and direction (input or output).", "model", "direction"),
+		cost:     registry.Counter("ai_cost_usd_total", "Estimated provider cost in USD by model; only models in OPENROUTER_MODEL_PRICES.", "model"),
+	}
+}
+
+// Call records one provider call; cost counts only when priced is set.
+func (m *Metrics) Call(model string, elapsed time.Duration, usage TokenUsage, cost float64, priced bool, err error) {
+	if m == nil {
+		return
+	}
+	m.duration.Observe(elapsed.Seconds(), model)
+	if err != nil {
+		m.calls.Inc(model, "error")
+		return
+	}
+	m.calls.Inc(model, "ok")
+	m.tokens.Add(float64(usage.InputTokens), model, "input")
+	m.tokens.Add(float64(usage.OutputTokens), model, "output")
+	if priced {
+		m.cost.Add(cost, model)
+	}
+}
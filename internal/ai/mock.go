@@ -0,0 +1,239 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrMockFailure is returned by the calls MockConfig.ErrorRate turns into failures.
+	ErrMockFailure = errors.New("mock generator failure")
+	// ErrMockNoResponse is returned when no scripted response matches and there is no
+	// default text.
+	ErrMockNoResponse = errors.New("mock generator has no response for the request")
+)
+
+// MockResponse is one scripted answer of a MockGenerator.
+type MockResponse struct {
+	// Match is a substring of the prompt (GenerateRequest.Input) selecting the requests this
+	// response answers; empty matches every request.
+	Match string
+	// Text is the model output; Err, when set, fails the call instead.
+	Text string
+	Err  error
+	// Times limits how many calls this response answers, after which the next match is
+	// used; zero answers every call. It scripts sequences such as a failure then a success.
+	Times int
+	// Usage is reported as the call's tokens; zero estimates them from the prompt and text.
+	Usage TokenUsage
+}
+
+// MockLatency is a log-normal latency distribution, the usual shape of model latency: most
+// calls near Median and a long tail.
+type MockLatency struct {
+	// Median is the typical latency; zero answers at once.
+	Median time.Duration
+	// Spread is the sigma of the distribution: 0 always waits Median, 0.5 puts p95 near
+	// 2.3x Median and 1 near 5x.
+	Spread float64
+	// Max caps a single call; zero leaves the tail uncapped.
+	Max time.Duration
+}
+
+// MockConfig scripts a MockGenerator.
+type MockConfig struct {
+	// Responses are tried in order; the first one matching the prompt answers. Use
+	// DefaultMockResponses for valid outputs of every built-in prompt.
+	Responses []MockResponse
+	// Default answers requests no response matches; empty returns ErrMockNoResponse.
+	Default string
+	Latency MockLatency
+	// ErrorRate is the fraction of calls, 0..1, failing with ErrMockFailure after the latency.
+	ErrorRate float64
+	// ModelID is reported as the answering model; empty echoes the requested model.
+	ModelID string
+	// Seed makes latencies and failures repeatable; zero seeds from the clock.
+	Seed int64
+}
+
+// MockGenerator is a TextGenerator answering from a script, with simulated latency and
+// failures, so tests and load runs exercise the generation path without a provider.
+type MockGenerator struct {
+	config MockConfig
+
+	mu     sync.Mutex
+	random *rand.Rand
+	used   []int
+	calls  []GenerateRequest
+}
+
+func NewMockGenerator(config MockConfig) *MockGenerator {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &MockGenerator{
+		config: config,
+		random: rand.New(rand.NewSource(seed)),
+		used:   make([]int, len(config.Responses)),
+	}
+}
+
+func (m *MockGenerator) Available() bool {
+	return true
+}
+
+func (m *MockGenerator) Generate(ctx context.Context, request GenerateRequest) (GenerateResult, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, request)
+	delay := m.delay()
+	failed := m.config.ErrorRate > 0 && m.random.Float64() < m.config.ErrorRate
+	response, found := m.match(request.Input)
+	m.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return GenerateResult{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if failed {
+		return GenerateResult{}, ErrMockFailure
+	}
+	if !found {
+		return GenerateResult{}, ErrMockNoResponse
+	}
+	if response.Err != nil {
+		return GenerateResult{}, response.Err
+	}
+
+	usage := response.Usage
+	if usage.TotalTokens == 0 {
+		usage.InputTokens = estimateMockTokens(request.Instructions) + estimateMockTokens(request.Input)
+		usage.OutputTokens = estimateMockTokens(response.Text)
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	}
+	modelID := m.config.ModelID
+	if modelID == "" {
+		modelID = request.Model
+	}
+	return GenerateResult{Text: response.Text, ModelID: modelID, Usage: usage}, nil
+}
+
+// Calls returns the requests received so far, oldest first.
+func (m *MockGenerator) Calls() []GenerateRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]GenerateRequest(nil), m.calls...)
+}
+
+// match picks the scripted response for prompt; m.mu must be held.
+func (m *MockGenerator) match(prompt string) (MockResponse, bool) {
+	for index, response := range m.config.Responses {
+		if response.Match != "" && !strings.Contains(prompt, response.Match) {
+			continue
+		}
+		if response.Times > 0 && m.used[index] >= response.Times {
+			continue
+		}
+		m.used[index]++
+		return response, true
+	}
+	if m.config.Default != "" {
+		return MockResponse{Text: m.config.Default}, true
+	}
+	return MockResponse{}, false
+}
+
+// delay draws a latency; m.mu must be held.
+func (m *MockGenerator) delay() time.Duration {
+	latency := m.config.Latency
+	if latency.Median <= 0 {
+		return 0
+	}
+	delay := time.Duration(float64(latency.Median) * math.Exp(latency.Spread*m.random.NormFloat64()))
+	if latency.Max > 0 && delay > latency.Max {
+		delay = latency.Max
+	}
+	return delay
+}
+
+// estimateMockTokens uses the usual four characters per token.
+func estimateMockTokens(text string) int {
+	return (len([]rune(text)) + 3) / 4
+}
+
+// DefaultMockResponses answers each built-in prompt (prompts/*.tmpl) with a valid output,
+// matched by the objective line of the template. Prompts overridden in PROMPTS_DIR need
+// their own responses.
+func DefaultMockResponses() []MockResponse {
+	return []MockResponse{
+		{
+			Match: "gerar exatamente 3 respostas rapidas",
+			Text: `{"suggestions": [` +
+				`{"content": "Perfeito, obrigado pela confirmacao.", "rationale": "Confirma o recebimento."},` +
+				`{"content": "Combinado, fico no aguardo.", "rationale": "Reconhece o combinado."},` +
+				`{"content": "Certo, ja estou verificando.", "rationale": "Indica que o pedido esta em andamento."}]}`,
+		},
+		{
+			Match: "gerar exatamente 3 sugestoes de resposta",
+			Text: `{"suggestions": [` +
+				`{"content": "Obrigado pelo contato! Vou verificar o status do seu pedido e retorno ainda hoje.", "rationale": "Assume o acompanhamento com prazo."},` +
+				`{"content": "Entendo a urgencia. Pode me confirmar o numero do pedido para eu agilizar?", "rationale": "Pede o dado que falta."},` +
+				`{"content": "Ja encaminhei sua solicitacao para a equipe responsavel e aviso assim que tiver novidades.", "rationale": "Informa o encaminhamento."}]}`,
+		},
+		{
+			Match: "sintetizar os pontos principais da conversa",
+			Text: `{"summary": "Cliente pediu retorno sobre o status da entrega e o atendente prometeu verificar com a equipe.",` +
+				` "action_items": ["Verificar o status da entrega", "Retornar ao cliente ainda hoje"]}`,
+		},
+		{
+			Match: "escrever uma unica secao de um relatorio",
+			Text:  `{"content": "O cliente aguarda retorno sobre a entrega; o atendente se comprometeu a responder ainda hoje."}`,
+		},
+		{
+			Match: "gerar um relatorio estruturado com secoes claras",
+			Text: `{"title": "Relatorio da conversa", "sections": [` +
+				`{"heading": "Visao geral", "content": "Cliente pediu retorno sobre a entrega."},` +
+				`{"heading": "Pendencias", "content": "Confirmar o prazo com a equipe de logistica."},` +
+				`{"heading": "Proximos passos", "content": "Retornar ao cliente ainda hoje com o novo prazo."}]}`,
+		},
+		{
+			Match: "identificar o sentimento do cliente",
+			Text: `{"sentiment": "neutro", "sentiment_score": 0.1, "urgency": "media", "intent": "suporte",` +
+				` "intent_confidence": 0.8, "labels": ["acompanhamento de entrega"], "rationale": "O cliente pede informacao sobre a entrega sem reclamar."}`,
+		},
+		{
+			Match: "sugerir perguntas de esclarecimento",
+			Text: `{"questions": ["Pode me informar o numero do pedido?", "Qual endereco foi usado na entrega?"],` +
+				` "rationale": "Faltam dados para localizar a entrega."}`,
+		},
+		{
+			Match: "extrair os itens de acao pendentes",
+			Text: `{"action_items": [{"description": "Verificar o status da entrega", "owner": "agent",` +
+				` "due_hint": "hoje", "source_message": "Cliente pediu retorno ainda hoje sobre o status da entrega."}]}`,
+		},
+		{
+			Match: "sugerir ate 3 continuacoes para o rascunho",
+			Text:  `{"completions": [" e retorno ainda hoje.", " assim que tiver novidades.", " com a equipe responsavel."]}`,
+		},
+		{
+			Match: "consolidar as conversas do periodo",
+			Text: `{"title": "Resumo do dia", "open_items": [{"conversation_id": "chat-1", "description": "Confirmar prazo de entrega"}],` +
+				` "highlights": ["Pedidos de status de entrega concentraram o atendimento"]}`,
+		},
+		{
+			Match: "reunir fatos do historico para a barra lateral",
+			Text: `{"preferred_tone": "neutro", "commitments": [{"description": "Retornar sobre a entrega", "made_by": "agent", "due_hint": "hoje"}],` +
+				` "open_complaints": [{"description": "Atraso na entrega", "severity": "media"}]}`,
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMockGeneratorFollowsItsScript(t *testing.T) {
+	generator := NewMockGenerator(MockConfig{
+		Responses: []MockResponse{
+			{Match: "summary", Err: errors.New("overloaded"), Times: 1},
+			{Match: "summary", Text: `{"summary": "ok"}`},
+		},
+		Default: `{"fallback": true}`,
+	})
+	ctx := context.Background()
+
+	if _, err := generator.Generate(ctx, GenerateRequest{Model: "model-a", Input: "write a summary"}); err == nil || err.Error() != "overloaded" {
+		t.Fatalf("expected the scripted failure first, got %v", err)
+	}
+	result, err := generator.Generate(ctx, GenerateRequest{Model: "model-a", Input: "write a summary"})
+	if err != nil || result.Text != `{"summary": "ok"}` || result.ModelID != "model-a" || result.Usage.TotalTokens == 0 {
+		t.Fatalf("expected the scripted summary with estimated usage, got %+v %v", result, err)
+	}
+	if result, _ := generator.Generate(ctx, GenerateRequest{Input: "anything else"}); result.Text != `{"fallback": true}` {
+		t.Fatalf("expected the default text, got %+v", result)
+	}
+	if calls := generator.Calls(); len(calls) != 3 || calls[2].Input != "anything else" {
+		t.Fatalf("expected every call recorded, got %+v", calls)
+	}
+
+	empty := NewMockGenerator(MockConfig{})
+	if _, err := empty.Generate(ctx, GenerateRequest{Input: "x"}); !errors.Is(err, ErrMockNoResponse) {
+		t.Fatalf("expected ErrMockNoResponse, got %v", err)
+	}
+}
+
+func TestMockGeneratorErrorRateIsRepeatable(t *testing.T) {
+	failures := func() []int {
+		generator := NewMockGenerator(MockConfig{Default: "{}", ErrorRate: 0.3, Seed: 42})
+		var failed []int
+		for index := 0; index < 1000; index++ {
+			if _, err := generator.Generate(context.Background(), GenerateRequest{Input: "x"}); errors.Is(err, ErrMockFailure) {
+				failed = append(failed, index)
+			}
+		}
+		return failed
+	}
+	first, second := failures(), failures()
+	if len(first) < 250 || len(first) > 350 {
+		t.Fatalf("expected about 30%% of the calls to fail, got %d", len(first))
+	}
+	if len(first) != len(second) || first[0] != second[0] || first[len(first)-1] != second[len(second)-1] {
+		t.Fatal("expected the same seed to fail the same calls")
+	}
+}
+
+func TestMockGeneratorLatency(t *testing.T) {
+	generator := NewMockGenerator(MockConfig{Latency: MockLatency{Median: 10 * time.Millisecond, Spread: 0.5, Max: 40 * time.Millisecond}, Seed: 7})
+	delays := make([]time.Duration, 2000)
+	for index := range delays {
+		delays[index] = generator.delay()
+	}
+	sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+	median, p95 := delays[len(delays)/2], delays[len(delays)*95/100]
+	if median < 9*time.Millisecond || median > 11*time.Millisecond || p95 < 18*time.Millisecond || p95 > 28*time.Millisecond {
+		t.Fatalf("expected a log-normal spread around 10ms, got median %s p95 %s", median, p95)
+	}
+	if delays[len(delays)-1] > 40*time.Millisecond {
+		t.Fatalf("expected Max to cap the tail, got %s", delays[len(delays)-1])
+	}
+
+	slow := NewMockGenerator(MockConfig{Default: "{}", Latency: MockLatency{Median: time.Minute}})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := slow.Generate(ctx, GenerateRequest{Input: "x"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the call to honor ctx, got %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package ai
 
-import "strings"
+import (
+	"strings"
+	"sync"
+)
 
 type TaskKind string
 
@@ -8,8 +11,20 @@ const (
 	TaskSuggestion TaskKind = "suggestion"
 	TaskSummary    TaskKind = "summary"
 	TaskReport     TaskKind = "report"
+	TaskAnalysis   TaskKind = "analysis"
+	TaskQuestions  TaskKind = "questions"
+	TaskActions    TaskKind = "action_items"
+	TaskCompose    TaskKind = "compose"
+	TaskDigest     TaskKind = "digest"
+	TaskInsights   TaskKind = "insights"
 )
 
+// TaskKinds lists every task a model can be selected for.
+var TaskKinds = []TaskKind{
+	TaskSuggestion, TaskSummary, TaskReport, TaskAnalysis, TaskQuestions,
+	TaskActions, TaskCompose, TaskDigest, TaskInsights,
+}
+
 type ModelProfile struct {
 	PrimaryModel    string
 	FallbackModel   string
@@ -29,10 +44,18 @@ type ModelRouterConfig struct {
 }
 
 type ModelRouter struct {
+	mu     sync.RWMutex
 	config ModelRouterConfig
 }
 
 func NewModelRouter(config ModelRouterConfig) *ModelRouter {
+	router := &ModelRouter{}
+	router.Reconfigure(config)
+	return router
+}
+
+// Reconfigure replaces the models; calls to Select made afterwards use them.
+func (r *ModelRouter) Reconfigure(config ModelRouterConfig) {
 	if strings.TrimSpace(config.SuggestionPrimary) == "" {
 		config.SuggestionPrimary = "openai/gpt-4o-mini"
 	}
@@ -52,36 +75,85 @@ func NewModelRouter(config ModelRouterConfig) *ModelRouter {
 		config.ReportFallback = "openai/gpt-4o-mini"
 	}
 
-	return &ModelRouter{config: config}
+	r.mu.Lock()
+	r.config = config
+	r.mu.Unlock()
 }
 
 func (r *ModelRouter) Select(task TaskKind) ModelProfile {
+	r.mu.RLock()
+	config := r.config
+	r.mu.RUnlock()
+
 	switch task {
 	case TaskSuggestion:
 		return ModelProfile{
-			PrimaryModel:    r.config.SuggestionPrimary,
-			FallbackModel:   r.config.SuggestionFallback,
+			PrimaryModel:    config.SuggestionPrimary,
+			FallbackModel:   config.SuggestionFallback,
 			Temperature:     0.4,
 			MaxOutputTokens: 500,
 		}
 	case TaskSummary:
 		return ModelProfile{
-			PrimaryModel:    r.config.SummaryPrimary,
-			FallbackModel:   r.config.SummaryFallback,
+			PrimaryModel:    config.SummaryPrimary,
+			FallbackModel:   config.SummaryFallback,
 			Temperature:     0.2,
 			MaxOutputTokens: 700,
 		}
 	case TaskReport:
 		return ModelProfile{
-			PrimaryModel:    r.config.ReportPrimary,
-			FallbackModel:   r.config.ReportFallback,
+			PrimaryModel:    config.ReportPrimary,
+			FallbackModel:   config.ReportFallback,
 			Temperature:     0.2,
 			MaxOutputTokens: 1400,
 		}
+	case TaskInsights:
+		return ModelProfile{
+			PrimaryModel:    config.SummaryPrimary,
+			FallbackModel:   config.SummaryFallback,
+			Temperature:     0.1,
+			MaxOutputTokens: 600,
+		}
+	case TaskDigest:
+		return ModelProfile{
+			PrimaryModel:    config.ReportPrimary,
+			FallbackModel:   config.ReportFallback,
+			Temperature:     0.2,
+			MaxOutputTokens: 1200,
+		}
+	case TaskAnalysis:
+		// Classification is short and latency sensitive: reuse the suggestion models.
+		return ModelProfile{
+			PrimaryModel:    config.SuggestionPrimary,
+			FallbackModel:   config.SuggestionFallback,
+			Temperature:     0.1,
+			MaxOutputTokens: 300,
+		}
+	case TaskQuestions:
+		return ModelProfile{
+			PrimaryModel:    config.SuggestionPrimary,
+			FallbackModel:   config.SuggestionFallback,
+			Temperature:     0.3,
+			MaxOutputTokens: 300,
+		}
+	case TaskCompose:
+		return ModelProfile{
+			PrimaryModel:    config.SuggestionPrimary,
+			FallbackModel:   config.SuggestionFallback,
+			Temperature:     0.4,
+			MaxOutputTokens: 300,
+		}
+	case TaskActions:
+		return ModelProfile{
+			PrimaryModel:    config.SummaryPrimary,
+			FallbackModel:   config.SummaryFallback,
+			Temperature:     0.1,
+			MaxOutputTokens: 500,
+		}
 	default:
 		return ModelProfile{
-			PrimaryModel:    r.config.SummaryPrimary,
-			FallbackModel:   r.config.SummaryFallback,
+			PrimaryModel:    config.SummaryPrimary,
+			FallbackModel:   config.SummaryFallback,
 			Temperature:     0.2,
 			MaxOutputTokens: 700,
 		}
@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
 )
 
 var ErrOpenAIUnavailable = errors.New("openai client unavailable")
@@ -153,6 +155,10 @@ func (c *OpenAIClient) callResponsesAPI(
 	if c.organization != "" {
 		httpRequest.Header.Set("OpenAI-Organization", c.organization)
 	}
+	// OpenAI keeps X-Client-Request-Id with the request, so support can find it by our ID.
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		httpRequest.Header.Set("X-Client-Request-Id", requestID)
+	}
 
 	httpResponse, err := c.httpClient.Do(httpRequest)
 	if err != nil {
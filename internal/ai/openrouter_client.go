@@ -9,7 +9,10 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
 )
 
 var ErrOpenRouterUnavailable = ErrOpenAIUnavailable
@@ -25,7 +28,8 @@ type OpenRouterClientConfig struct {
 }
 
 type OpenRouterClient struct {
-	apiKey     string
+	// apiKey is swapped by SetAPIKey when the key is rotated.
+	apiKey     atomic.Pointer[string]
 	baseURL    string
 	timeout    time.Duration
 	maxRetries int
@@ -51,8 +55,7 @@ func NewOpenRouterClient(config OpenRouterClientConfig) *OpenRouterClient {
 		config.AppName = "WA Copilot"
 	}
 
-	return &OpenRouterClient{
-		apiKey:     strings.TrimSpace(config.APIKey),
+	client := &OpenRouterClient{
 		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
 		timeout:    config.Timeout,
 		maxRetries: config.MaxRetries,
@@ -60,10 +63,52 @@ func NewOpenRouterClient(config OpenRouterClientConfig) *OpenRouterClient {
 		siteURL:    strings.TrimSpace(config.SiteURL),
 		appName:    strings.TrimSpace(config.AppName),
 	}
+	client.SetAPIKey(config.APIKey)
+	return client
+}
+
+// SetAPIKey replaces the key used by the next requests.
+func (c *OpenRouterClient) SetAPIKey(key string) {
+	key = strings.TrimSpace(key)
+	c.apiKey.Store(&key)
+}
+
+func (c *OpenRouterClient) key() string {
+	return *c.apiKey.Load()
 }
 
 func (c *OpenRouterClient) Available() bool {
-	return c.apiKey != ""
+	return c.key() != ""
+}
+
+// Ping checks that the provider is reachable and accepts the API key by listing models.
+func (c *OpenRouterClient) Ping(ctx context.Context) error {
+	if !c.Available() {
+		return ErrOpenRouterUnavailable
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("create openrouter request: %w", err)
+	}
+	httpRequest.Header.Set("Authorization", "Bearer "+c.key())
+	httpRequest.Header.Set("Accept", "application/json")
+
+	httpResponse, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("openrouter transport error: %w", err)
+	}
+	defer httpResponse.Body.Close()
+	_, _ = io.Copy(io.Discard, httpResponse.Body)
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+		return &providerHTTPError{
+			Provider:   "openrouter",
+			StatusCode: httpResponse.StatusCode,
+			Message:    http.StatusText(httpResponse.StatusCode),
+		}
+	}
+	return nil
 }
 
 func (c *OpenRouterClient) Generate(ctx context.Context, request GenerateRequest) (GenerateResult, error) {
@@ -143,7 +188,7 @@ func (c *OpenRouterClient) callChatCompletionsAPI(
 	if err != nil {
 		return GenerateResult{}, fmt.Errorf("create openrouter request: %w", err)
 	}
-	httpRequest.Header.Set("Authorization", "Bearer "+c.apiKey)
+	httpRequest.Header.Set("Authorization", "Bearer "+c.key())
 	httpRequest.Header.Set("Content-Type", "application/json")
 	httpRequest.Header.Set("Accept", "application/json")
 	if c.siteURL != "" {
@@ -152,6 +197,9 @@ func (c *OpenRouterClient) callChatCompletionsAPI(
 	if c.appName != "" {
 		httpRequest.Header.Set("X-Title", c.appName)
 	}
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		httpRequest.Header.Set("X-Request-Id", requestID)
+	}
 
 	httpResponse, err := c.httpClient.Do(httpRequest)
 	if err != nil {
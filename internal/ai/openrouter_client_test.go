@@ -8,6 +8,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
 )
 
 func TestOpenRouterClientGenerateSuccess(t *testing.T) {
@@ -162,6 +164,11 @@ func TestOpenRouterClientSendsOptionalHeaders(t *testing.T) {
 			_, _ = w.Write([]byte(fmt.Sprintf(`{"error":"unexpected title %q"}`, got)))
 			return
 		}
+		if got := r.Header.Get("X-Request-Id"); got != "req-123" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"error":"unexpected request id %q"}`, got)))
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte(`{
 			"model":"openai/gpt-4.1-mini",
@@ -179,7 +186,7 @@ func TestOpenRouterClientSendsOptionalHeaders(t *testing.T) {
 		SiteURL:    "https://example.com",
 		AppName:    "WA Copilot",
 	})
-	_, err := client.Generate(context.Background(), GenerateRequest{
+	_, err := client.Generate(logging.WithRequestID(context.Background(), "req-123"), GenerateRequest{
 		Model:           "openai/gpt-4.1-mini",
 		Instructions:    "Return JSON only",
 		Input:           "test",
@@ -190,3 +197,24 @@ func TestOpenRouterClientSendsOptionalHeaders(t *testing.T) {
 		t.Fatalf("expected success with optional headers, got err=%v", err)
 	}
 }
+
+func TestOpenRouterClientPingReportsRejectedKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || r.Header.Get("Authorization") != "Bearer good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenRouterClient(OpenRouterClientConfig{APIKey: "good-key", BaseURL: server.URL})
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("expected ping to succeed, got %v", err)
+	}
+
+	rejected := NewOpenRouterClient(OpenRouterClientConfig{APIKey: "bad-key", BaseURL: server.URL})
+	if err := rejected.Ping(context.Background()); err == nil {
+		t.Fatalf("expected ping to fail for rejected key")
+	}
+}
@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ModelPrice is the USD price of a model per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// PriceTable maps model IDs to their prices.
+type PriceTable map[string]ModelPrice
+
+// ParsePriceTable parses entries in the "model=input/output" format, prices in USD per
+// million tokens (e.g. "openai/gpt-4o-mini=0.15/0.60").
+func ParsePriceTable(entries []string) (PriceTable, error) {
+	table := make(PriceTable, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, prices, ok := strings.Cut(entry, "=")
+		model = strings.TrimSpace(model)
+		if !ok || model == "" {
+			return nil, fmt.Errorf("invalid model price %q: expected model=input/output", entry)
+		}
+		inputRaw, outputRaw, ok := strings.Cut(prices, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid model price %q: expected model=input/output", entry)
+		}
+		input, err := strconv.ParseFloat(strings.TrimSpace(inputRaw), 64)
+		if err != nil || input < 0 {
+			return nil, fmt.Errorf("invalid input price for %s", model)
+		}
+		output, err := strconv.ParseFloat(strings.TrimSpace(outputRaw), 64)
+		if err != nil || output < 0 {
+			return nil, fmt.Errorf("invalid output price for %s", model)
+		}
+		table[model] = ModelPrice{InputPerMillion: input, OutputPerMillion: output}
+	}
+	return table, nil
+}
+
+// Cost returns the USD cost of usage on modelID. ok is false when the model has no price.
+func (t PriceTable) Cost(modelID string, usage TokenUsage) (cost float64, ok bool) {
+	price, ok := t[strings.TrimSpace(modelID)]
+	if !ok {
+		return 0, false
+	}
+	cost = float64(usage.InputTokens)*price.InputPerMillion/1e6 +
+		float64(usage.OutputTokens)*price.OutputPerMillion/1e6
+	return cost, true
+}
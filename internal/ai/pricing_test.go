@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
+)
+
+func TestParsePriceTableAndCost(t *testing.T) {
+	table, err := ParsePriceTable([]string{"openai/gpt-4o-mini=0.15/0.60", " "})
+	if err != nil {
+		t.Fatalf("parse price table: %v", err)
+	}
+
+	cost, ok := table.Cost("openai/gpt-4o-mini", TokenUsage{InputTokens: 2000, OutputTokens: 500})
+	if !ok {
+		t.Fatalf("expected configured model to be priced")
+	}
+	if want := 0.0006; math.Abs(cost-want) > 1e-12 {
+		t.Fatalf("expected cost %f, got %f", want, cost)
+	}
+	if _, ok := table.Cost("unknown/model", TokenUsage{InputTokens: 10}); ok {
+		t.Fatalf("expected unknown model to be unpriced")
+	}
+
+	for _, invalid := range []string{"gpt", "gpt=1", "=1/2", "gpt=a/2", "gpt=1/-2"} {
+		if _, err := ParsePriceTable([]string{invalid}); err == nil {
+			t.Fatalf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestMetricsRecordCallsTokensAndCost(t *testing.T) {
+	registry := metrics.NewRegistry()
+	recorder := NewMetrics(registry)
+	prices := PriceTable{"openai/gpt-4o-mini": {InputPerMillion: 0.15, OutputPerMillion: 0.60}}
+
+	usage := TokenUsage{InputTokens: 1000, OutputTokens: 500}
+	cost, priced := prices.Cost("openai/gpt-4o-mini", usage)
+	recorder.Call("openai/gpt-4o-mini", 1200*time.Millisecond, usage, cost, priced, nil)
+	recorder.Call("openai/gpt-4o-mini", 3*time.Second, TokenUsage{}, 0, true, errors.New("timeout"))
+	recorder.Call("unpriced/model", time.Second, usage, 0, false, nil)
+
+	var exposition strings.Builder
+	if err := registry.WriteText(&exposition); err != nil {
+		t.Fatalf("write metrics: %v", err)
+	}
+	for _, series := range []string{
+		`ai_requests_total{model="openai/gpt-4o-mini",status="ok"} 1`,
+		`ai_requests_total{model="openai/gpt-4o-mini",status="error"} 1`,
+		`ai_tokens_total{model="openai/gpt-4o-mini",direction="input"} 1000`,
+		`ai_tokens_total{model="openai/gpt-4o-mini",direction="output"} 500`,
+		`ai_cost_usd_total{model="openai/gpt-4o-mini"} 0.00045`,
+		`ai_request_duration_seconds_count{model="openai/gpt-4o-mini"} 2`,
+	} {
+		if !strings.Contains(exposition.String(), series) {
+			t.Fatalf("expected %q in:\n%s", series, exposition.String())
+		}
+	}
+	if strings.Contains(exposition.String(), `ai_cost_usd_total{model="unpriced/model"}`) {
+		t.Fatalf("expected no cost for a model without a price:\n%s", exposition.String())
+	}
+	(*Metrics)(nil).Call("any", time.Second, usage, 0, false, nil)
+}
@@ -0,0 +1,144 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+func TestNotifiersPostToTheirWebhooks(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests = map[string]*http.Request{}
+		bodies   = map[string][]byte{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests[r.URL.Path], bodies[r.URL.Path] = r, body
+		mu.Unlock()
+		if r.URL.Path == "/broken" {
+			http.Error(w, "invalid_token", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	alert := Alert{Kind: KindDeadLetter, Summary: "summary job moved to the dead-letter queue", Environment: "production",
+		JobID: "job-1", JobKind: "summary", TenantID: "tenant-a", Attempt: 3, Suppressed: 2, At: time.Now().UTC()}
+	notifier := Multi{
+		NewSlackNotifier(server.URL+"/slack", nil),
+		NewWebhookNotifier(server.URL+"/hook", "secret", nil),
+	}
+	if err := notifier.Notify(context.Background(), alert); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	var slack struct{ Text string }
+	if err := json.Unmarshal(bodies["/slack"], &slack); err != nil {
+		t.Fatalf("decode slack payload: %v", err)
+	}
+	for _, part := range []string{"summary job moved", "(production)", "`job-1`", "tenant `tenant-a`", "2 more dead letters"} {
+		if !strings.Contains(slack.Text, part) {
+			t.Fatalf("expected %q in the slack text, got %q", part, slack.Text)
+		}
+	}
+	var posted Alert
+	if err := json.Unmarshal(bodies["/hook"], &posted); err != nil {
+		t.Fatalf("decode webhook payload: %v", err)
+	}
+	if posted.JobID != "job-1" || posted.Kind != KindDeadLetter || posted.Suppressed != 2 {
+		t.Fatalf("expected the alert as JSON, got %+v", posted)
+	}
+	if got := requests["/hook"].Header.Get("Authorization"); got != "Bearer secret" {
+		t.Fatalf("expected the bearer token, got %q", got)
+	}
+
+	err := NewWebhookNotifier(server.URL+"/broken", "", nil).Notify(context.Background(), alert)
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected the status in the error, got %v", err)
+	}
+}
+
+type recordingNotifier struct {
+	alerts chan Alert
+}
+
+func (n recordingNotifier) Notify(_ context.Context, alert Alert) error {
+	n.alerts <- alert
+	return nil
+}
+
+type fixedDLQ struct {
+	mu   sync.Mutex
+	size int64
+}
+
+func (f *fixedDLQ) DLQDepth(context.Context) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.size, nil
+}
+
+func TestDLQMonitorCoalescesDeadLettersWithinTheCooldown(t *testing.T) {
+	notifier := recordingNotifier{alerts: make(chan Alert, 4)}
+	monitor := NewDLQMonitor(notifier, nil, DLQConfig{Cooldown: time.Minute}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	monitor.now = func() time.Time { return now }
+
+	message := domain.QueueMessage{JobID: "job-1", Kind: domain.JobKindSummary, TenantID: "tenant-a", Attempt: 3}
+	monitor.OnFailure(message, true)
+	monitor.OnFailure(message, false)
+	first := <-notifier.alerts
+	if first.Kind != KindDeadLetter || first.JobID != "job-1" || first.Suppressed != 0 {
+		t.Fatalf("expected an alert for the first dead letter, got %+v", first)
+	}
+
+	now = now.Add(10 * time.Second)
+	monitor.OnFailure(domain.QueueMessage{JobID: "job-2"}, false)
+	monitor.OnFailure(domain.QueueMessage{JobID: "job-3"}, false)
+	now = now.Add(time.Minute)
+	monitor.OnFailure(domain.QueueMessage{JobID: "job-4"}, false)
+	second := <-notifier.alerts
+	if second.JobID != "job-4" || second.Suppressed != 2 {
+		t.Fatalf("expected the next alert to count the suppressed dead letters, got %+v", second)
+	}
+	select {
+	case extra := <-notifier.alerts:
+		t.Fatalf("expected no alert for retried or suppressed messages, got %+v", extra)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDLQMonitorAlertsOncePerThresholdCrossing(t *testing.T) {
+	notifier := recordingNotifier{alerts: make(chan Alert, 4)}
+	sizes := &fixedDLQ{size: 3}
+	monitor := NewDLQMonitor(notifier, sizes, DLQConfig{Threshold: 5}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	for _, size := range []int64{3, 5, 9, 2, 6} {
+		sizes.mu.Lock()
+		sizes.size = size
+		sizes.mu.Unlock()
+		monitor.Check(ctx)
+	}
+	close(notifier.alerts)
+	var crossings []int64
+	for alert := range notifier.alerts {
+		if alert.Kind != KindDLQThreshold || alert.Threshold != 5 {
+			t.Fatalf("expected threshold alerts, got %+v", alert)
+		}
+		crossings = append(crossings, alert.DLQSize)
+	}
+	if len(crossings) != 2 || crossings[0] != 5 || crossings[1] != 6 {
+		t.Fatalf("expected alerts at sizes 5 and 6, got %v", crossings)
+	}
+}
@@ -0,0 +1,138 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/queue"
+)
+
+// notifyTimeout bounds the delivery of one alert to every notifier.
+const notifyTimeout = 15 * time.Second
+
+// DLQConfig tunes a DLQMonitor.
+type DLQConfig struct {
+	// Environment is copied to every alert, to tell staging from production.
+	Environment string
+	// Cooldown is the least time between two dead letter alerts; the dead letters in
+	// between are counted in the next one. Zero alerts on every dead letter.
+	Cooldown time.Duration
+	// Threshold alerts once when the dead-letter queue holds this many messages, and again
+	// only after it drops below it. Zero disables the size checks.
+	Threshold int64
+	// Interval is how often Start reads the dead-letter queue size; one minute when zero.
+	Interval time.Duration
+}
+
+// DLQMonitor alerts when messages land in the dead-letter queue and when it grows past a
+// threshold. Register OnFailure as a failure hook of the consumer and run Start.
+type DLQMonitor struct {
+	notifier Notifier
+	sizes    queue.DLQReporter
+	config   DLQConfig
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu         sync.Mutex
+	lastSent   time.Time
+	suppressed int
+	above      bool
+}
+
+// NewDLQMonitor builds a monitor sending to notifier; sizes may be nil when the queue does
+// not report its dead-letter queue size.
+func NewDLQMonitor(notifier Notifier, sizes queue.DLQReporter, cfg DLQConfig, logger *slog.Logger) *DLQMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	return &DLQMonitor{notifier: notifier, sizes: sizes, config: cfg, logger: logger, now: time.Now}
+}
+
+// OnFailure is a queue.FailureHook; it alerts on the messages that were not retried. The
+// alert is sent in the background so the consumer is not held up by a slow webhook.
+func (m *DLQMonitor) OnFailure(message domain.QueueMessage, retried bool) {
+	if retried {
+		return
+	}
+	now := m.now()
+	m.mu.Lock()
+	if !m.lastSent.IsZero() && now.Sub(m.lastSent) < m.config.Cooldown {
+		m.suppressed++
+		m.mu.Unlock()
+		return
+	}
+	suppressed := m.suppressed
+	m.lastSent, m.suppressed = now, 0
+	m.mu.Unlock()
+
+	go m.send(Alert{
+		Kind:        KindDeadLetter,
+		Summary:     fmt.Sprintf("%s job moved to the dead-letter queue", message.Kind),
+		Environment: m.config.Environment,
+		JobID:       message.JobID,
+		JobKind:     string(message.Kind),
+		TenantID:    message.TenantID,
+		Attempt:     message.Attempt,
+		Suppressed:  suppressed,
+		At:          now.UTC(),
+	})
+}
+
+// Check reads the dead-letter queue size once and alerts when it crossed the threshold
+// since the previous check.
+func (m *DLQMonitor) Check(ctx context.Context) {
+	if m.sizes == nil || m.config.Threshold <= 0 {
+		return
+	}
+	size, err := m.sizes.DLQDepth(ctx)
+	if err != nil {
+		m.logger.WarnContext(ctx, "read dead-letter queue size failed", slog.Any("error", err))
+		return
+	}
+	m.mu.Lock()
+	crossed := size >= m.config.Threshold && !m.above
+	m.above = size >= m.config.Threshold
+	m.mu.Unlock()
+	if !crossed {
+		return
+	}
+	m.send(Alert{
+		Kind:        KindDLQThreshold,
+		Summary:     "dead-letter queue reached its alert threshold",
+		Environment: m.config.Environment,
+		DLQSize:     size,
+		Threshold:   m.config.Threshold,
+		At:          m.now().UTC(),
+	})
+}
+
+// Start checks the dead-letter queue size every Interval until ctx is cancelled; it
+// returns right away when size checks are disabled.
+func (m *DLQMonitor) Start(ctx context.Context) {
+	if m.sizes == nil || m.config.Threshold <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+	m.Check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+func (m *DLQMonitor) send(alert Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := m.notifier.Notify(ctx, alert); err != nil {
+		m.logger.Warn("dead-letter alert failed", slog.String("kind", string(alert.Kind)), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,155 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kind says what an alert is about.
+type Kind string
+
+const (
+	// KindDeadLetter is a job that exhausted its attempts and went to the dead-letter queue.
+	KindDeadLetter Kind = "dead_letter"
+	// KindDLQThreshold is the dead-letter queue growing to the configured size.
+	KindDLQThreshold Kind = "dlq_threshold"
+)
+
+// Alert is what notifiers deliver. The job fields are set for dead letters, DLQSize and
+// Threshold for threshold crossings.
+type Alert struct {
+	Kind        Kind   `json:"kind"`
+	Summary     string `json:"summary"`
+	Environment string `json:"environment,omitempty"`
+	JobID       string `json:"job_id,omitempty"`
+	JobKind     string `json:"job_kind,omitempty"`
+	TenantID    string `json:"tenant_id,omitempty"`
+	Attempt     int    `json:"attempt,omitempty"`
+	// Suppressed counts the dead letters since the previous alert that were not sent on
+	// their own because of the cooldown.
+	Suppressed int       `json:"suppressed,omitempty"`
+	DLQSize    int64     `json:"dlq_size,omitempty"`
+	Threshold  int64     `json:"threshold,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// Notifier delivers alerts to people. Notify must honor ctx cancellation.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Multi sends every alert to all of its notifiers and joins their errors.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SlackNotifier posts alerts as messages to a Slack incoming webhook.
+type SlackNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackNotifier builds a notifier for webhookURL; a nil client gets a 10 second timeout.
+func NewSlackNotifier(webhookURL string, client *http.Client) *SlackNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &SlackNotifier{webhookURL: webhookURL, client: client}
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, n.client, n.webhookURL, nil, map[string]string{"text": slackText(alert)})
+}
+
+// slackText renders alert as a Slack mrkdwn message.
+func slackText(alert Alert) string {
+	var text strings.Builder
+	text.WriteString(":rotating_light: *")
+	text.WriteString(alert.Summary)
+	text.WriteString("*")
+	if alert.Environment != "" {
+		fmt.Fprintf(&text, " (%s)", alert.Environment)
+	}
+	switch alert.Kind {
+	case KindDeadLetter:
+		fmt.Fprintf(&text, "\njob `%s` kind `%s` tenant `%s`, %d attempts", alert.JobID, alert.JobKind, alert.TenantID, alert.Attempt)
+		if alert.Suppressed > 0 {
+			fmt.Fprintf(&text, "\n%d more dead letters since the last alert", alert.Suppressed)
+		}
+	case KindDLQThreshold:
+		fmt.Fprintf(&text, "\n%d messages in the dead-letter queue (threshold %d)", alert.DLQSize, alert.Threshold)
+	}
+	return text.String()
+}
+
+// WebhookNotifier posts alerts as JSON to any HTTP endpoint, with its token as a bearer
+// token when set.
+type WebhookNotifier struct {
+	target string
+	token  string
+	client *http.Client
+}
+
+// NewWebhookNotifier builds a notifier for target; a nil client gets a 10 second timeout.
+func NewWebhookNotifier(target, token string, client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookNotifier{target: target, token: token, client: client}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	var headers http.Header
+	if n.token != "" {
+		headers = http.Header{"Authorization": []string{"Bearer " + n.token}}
+	}
+	return postJSON(ctx, n.client, n.target, headers, alert)
+}
+
+func postJSON(ctx context.Context, client *http.Client, target string, headers http.Header, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode alert: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create alert request: %w", err)
+	}
+	for key, values := range headers {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		// The URL may hold the webhook secret; keep it out of the error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("alert request: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("alert status %d: %s", response.StatusCode, strings.TrimSpace(string(message)))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+	return nil
+}
@@ -0,0 +1,185 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+type ArchiverConfig struct {
+	// After is the minimum age (since last update) of a done job before its result is archived.
+	After     time.Duration
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Archiver periodically moves old job results to the object store, leaving a pointer in the row.
+type Archiver struct {
+	repo   repository.JobsRepository
+	store  ObjectStore
+	cipher repository.PayloadCipher
+	config ArchiverConfig
+	logger *slog.Logger
+}
+
+func NewArchiver(
+	repo repository.JobsRepository,
+	store ObjectStore,
+	cfg ArchiverConfig,
+	logger *slog.Logger,
+) *Archiver {
+	if cfg.After <= 0 {
+		cfg.After = 30 * 24 * time.Hour
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &Archiver{repo: repo, store: store, config: cfg, logger: logger}
+}
+
+// UseCipher seals results before they are uploaded, so archiving keeps the encryption at
+// rest of the rows. The resolving repository needs the same cipher to read them back.
+func (a *Archiver) UseCipher(cipher repository.PayloadCipher) {
+	a.cipher = cipher
+}
+
+func (a *Archiver) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		archived, err := a.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			a.log(ctx, slog.LevelError, "archiver run failed", slog.Int("archived", archived), slog.Any("error", err))
+		} else if archived > 0 {
+			a.log(ctx, slog.LevelInfo, "archiver moved results", slog.Int("count", archived))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce archives eligible results until no more candidates remain and returns how many moved.
+func (a *Archiver) RunOnce(ctx context.Context) (int, error) {
+	// Results of every tenant are archived.
+	ctx = tenant.WithBypass(ctx)
+	cutoff := time.Now().UTC().Add(-a.config.After)
+	archived := 0
+	for {
+		jobs, err := a.repo.ListArchivableJobs(ctx, cutoff, a.config.BatchSize)
+		if err != nil {
+			return archived, fmt.Errorf("list archivable jobs: %w", err)
+		}
+		if len(jobs) == 0 {
+			return archived, nil
+		}
+
+		for _, job := range jobs {
+			if err := a.archiveJob(ctx, job); err != nil {
+				return archived, err
+			}
+			archived++
+		}
+		if len(jobs) < a.config.BatchSize {
+			return archived, nil
+		}
+	}
+}
+
+func (a *Archiver) archiveJob(ctx context.Context, job domain.Job) error {
+	key := ResultKey(job.TenantID, job.ConversationID, job.ID)
+	body := []byte(job.Result)
+	if a.cipher != nil {
+		sealed, err := a.cipher.Seal(ctx, body, archiveAAD(key))
+		if err != nil {
+			return fmt.Errorf("encrypt result job_id=%s: %w", job.ID, err)
+		}
+		body = sealed
+	}
+	if err := a.store.Put(ctx, key, body); err != nil {
+		return fmt.Errorf("upload result job_id=%s: %w", job.ID, err)
+	}
+	if err := a.repo.MarkJobArchived(ctx, job.ID, key, time.Now().UTC()); err != nil {
+		return fmt.Errorf("mark archived job_id=%s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (a *Archiver) log(ctx context.Context, level slog.Level, message string, attrs ...slog.Attr) {
+	if a.logger == nil {
+		return
+	}
+	a.logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+// ResolvingRepository transparently loads archived results when jobs are read
+// and erases archived objects together with the conversation rows.
+type ResolvingRepository struct {
+	repository.JobsRepository
+	store  ObjectStore
+	cipher repository.PayloadCipher
+}
+
+func NewResolvingRepository(base repository.JobsRepository, store ObjectStore) *ResolvingRepository {
+	return &ResolvingRepository{JobsRepository: base, store: store}
+}
+
+// UseCipher opens results sealed by Archiver.UseCipher. Objects archived before
+// encryption was enabled are still read as plaintext.
+func (r *ResolvingRepository) UseCipher(cipher repository.PayloadCipher) {
+	r.cipher = cipher
+}
+
+func (r *ResolvingRepository) GetJob(ctx context.Context, jobID string) (*domain.Job, error) {
+	job, err := r.JobsRepository.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.ResultArchiveKey == "" || len(job.Result) > 0 {
+		return job, nil
+	}
+
+	body, err := r.store.Get(ctx, job.ResultArchiveKey)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return job, nil
+		}
+		return nil, fmt.Errorf("fetch archived result: %w", err)
+	}
+	if r.cipher != nil {
+		if body, err = r.cipher.Open(ctx, body, archiveAAD(job.ResultArchiveKey)); err != nil {
+			return nil, fmt.Errorf("decrypt archived result: %w", err)
+		}
+	}
+	job.Result = body
+	return job, nil
+}
+
+func (r *ResolvingRepository) DeleteConversationJobs(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) (int, error) {
+	if _, err := r.store.DeletePrefix(ctx, ConversationPrefix(tenantID, conversationID)); err != nil {
+		return 0, fmt.Errorf("delete archived results: %w", err)
+	}
+	return r.JobsRepository.DeleteConversationJobs(ctx, tenantID, conversationID)
+}
+
+// archiveAAD binds a sealed result to its object key so objects cannot be swapped.
+func archiveAAD(key string) []byte {
+	return []byte("archive:" + key)
+}
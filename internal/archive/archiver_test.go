@@ -0,0 +1,151 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/envelope"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+func TestArchiverMovesOldResultsAndResolvesOnRead(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+
+	base := repository.NewMemoryJobsRepository()
+	now := time.Now().UTC()
+	old := &domain.Job{
+		ID:             "job-old",
+		Kind:           domain.JobKindSummary,
+		TenantID:       "tenant-1",
+		ConversationID: "conv-1",
+		Status:         domain.JobStatusDone,
+		Result:         json.RawMessage(`{"summary":"antigo"}`),
+		CreatedAt:      now.Add(-40 * 24 * time.Hour),
+		UpdatedAt:      now.Add(-40 * 24 * time.Hour),
+	}
+	recent := &domain.Job{
+		ID:             "job-recent",
+		Kind:           domain.JobKindSummary,
+		TenantID:       "tenant-1",
+		ConversationID: "conv-1",
+		Status:         domain.JobStatusDone,
+		Result:         json.RawMessage(`{"summary":"recente"}`),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	for _, job := range []*domain.Job{old, recent} {
+		if err := base.CreateJob(ctx, job); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+	}
+
+	repo := NewResolvingRepository(base, store)
+	archiver := NewArchiver(repo, store, ArchiverConfig{After: 30 * 24 * time.Hour, BatchSize: 1}, nil)
+	archived, err := archiver.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+	if archived != 1 {
+		t.Fatalf("expected 1 archived job, got %d", archived)
+	}
+
+	raw, err := base.GetJob(ctx, "job-old")
+	if err != nil {
+		t.Fatalf("get raw job: %v", err)
+	}
+	if len(raw.Result) != 0 || raw.ResultArchiveKey == "" || raw.ArchivedAt == nil {
+		t.Fatalf("expected result moved out of the row, got %+v", raw)
+	}
+
+	resolved, err := repo.GetJob(ctx, "job-old")
+	if err != nil {
+		t.Fatalf("get resolved job: %v", err)
+	}
+	if string(resolved.Result) != `{"summary":"antigo"}` {
+		t.Fatalf("unexpected resolved result: %s", resolved.Result)
+	}
+
+	if _, err := repo.DeleteConversationJobs(ctx, "tenant-1", "conv-1"); err != nil {
+		t.Fatalf("delete conversation: %v", err)
+	}
+	if _, err := store.Get(ctx, raw.ResultArchiveKey); err != ErrObjectNotFound {
+		t.Fatalf("expected archived object erased, got %v", err)
+	}
+}
+
+func TestArchiverSealsResultsWhenEncryptionIsEnabled(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	keyring, err := envelope.ParseKeyring("k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	if err != nil {
+		t.Fatalf("parse keyring: %v", err)
+	}
+	cipher := envelope.NewCipher(keyring)
+
+	base := repository.NewMemoryJobsRepository()
+	old := time.Now().UTC().Add(-40 * 24 * time.Hour)
+	if err := base.CreateJob(ctx, &domain.Job{
+		ID:             "job-secret",
+		Kind:           domain.JobKindSummary,
+		TenantID:       "tenant-1",
+		ConversationID: "conv-1",
+		Status:         domain.JobStatusDone,
+		Result:         json.RawMessage(`{"summary":"cliente pediu reembolso"}`),
+		CreatedAt:      old,
+		UpdatedAt:      old,
+	}); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	repo := NewResolvingRepository(base, store)
+	repo.UseCipher(cipher)
+	archiver := NewArchiver(repo, store, ArchiverConfig{After: 30 * 24 * time.Hour}, nil)
+	archiver.UseCipher(cipher)
+	if archived, err := archiver.RunOnce(ctx); err != nil || archived != 1 {
+		t.Fatalf("run once: archived=%d err=%v", archived, err)
+	}
+
+	raw, err := base.GetJob(ctx, "job-secret")
+	if err != nil {
+		t.Fatalf("get raw job: %v", err)
+	}
+	stored, err := store.Get(ctx, raw.ResultArchiveKey)
+	if err != nil {
+		t.Fatalf("get archived object: %v", err)
+	}
+	if bytes.Contains(stored, []byte("reembolso")) || !envelope.IsSealed(stored) {
+		t.Fatalf("expected a sealed archive, got %s", stored)
+	}
+
+	resolved, err := repo.GetJob(ctx, "job-secret")
+	if err != nil {
+		t.Fatalf("get resolved job: %v", err)
+	}
+	if string(resolved.Result) != `{"summary":"cliente pediu reembolso"}` {
+		t.Fatalf("unexpected resolved result: %s", resolved.Result)
+	}
+
+	// An object sealed for another key must not open in its place.
+	other := ResultKey("tenant-1", "conv-1", "job-other")
+	if err := store.Put(ctx, other, stored); err != nil {
+		t.Fatalf("put swapped object: %v", err)
+	}
+	if err := base.MarkJobArchived(ctx, "job-secret", other, time.Now().UTC()); err != nil {
+		t.Fatalf("repoint archive key: %v", err)
+	}
+	if _, err := repo.GetJob(ctx, "job-secret"); err == nil {
+		t.Fatal("expected a swapped archive to fail decryption")
+	}
+}
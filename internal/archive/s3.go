@@ -0,0 +1,200 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/awsauth"
+)
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket in the path (MinIO, GCS interoperability).
+	PathStyle  bool
+	HTTPClient *http.Client
+}
+
+// S3Store talks to any S3-compatible API (AWS S3, GCS interoperability, MinIO).
+type S3Store struct {
+	endpoint    *url.URL
+	region      string
+	bucket      string
+	pathStyle   bool
+	credentials awsauth.Credentials
+	httpClient  *http.Client
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if strings.TrimSpace(cfg.Bucket) == "" {
+		return nil, errors.New("archive bucket is required")
+	}
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		cfg.Endpoint = "https://s3.amazonaws.com"
+	}
+	if strings.TrimSpace(cfg.Region) == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid archive endpoint %q", cfg.Endpoint)
+	}
+
+	return &S3Store{
+		endpoint:  endpoint,
+		region:    cfg.Region,
+		bucket:    cfg.Bucket,
+		pathStyle: cfg.PathStyle,
+		credentials: awsauth.Credentials{
+			AccessKeyID:     cfg.AccessKey,
+			SecretAccessKey: cfg.SecretKey,
+		},
+		httpClient: cfg.HTTPClient,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body []byte) error {
+	response, err := s.do(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return s.statusError("put", response)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	response, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, s.statusError("get", response)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read archive object: %w", err)
+	}
+	return body, nil
+}
+
+func (s *S3Store) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	deleted := 0
+	continuation := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuation != "" {
+			query.Set("continuation-token", continuation)
+		}
+
+		listing, err := s.list(ctx, query)
+		if err != nil {
+			return deleted, err
+		}
+		for _, object := range listing.Contents {
+			response, err := s.do(ctx, http.MethodDelete, object.Key, nil, nil)
+			if err != nil {
+				return deleted, err
+			}
+			_ = response.Body.Close()
+			if response.StatusCode >= 300 && response.StatusCode != http.StatusNotFound {
+				return deleted, s.statusError("delete", response)
+			}
+			deleted++
+		}
+		if !listing.IsTruncated || listing.NextContinuationToken == "" {
+			return deleted, nil
+		}
+		continuation = listing.NextContinuationToken
+	}
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3Store) list(ctx context.Context, query url.Values) (listBucketResult, error) {
+	response, err := s.do(ctx, http.MethodGet, "", query, nil)
+	if err != nil {
+		return listBucketResult{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return listBucketResult{}, s.statusError("list", response)
+	}
+
+	var result listBucketResult
+	if err := xml.NewDecoder(response.Body).Decode(&result); err != nil {
+		return listBucketResult{}, fmt.Errorf("decode archive listing: %w", err)
+	}
+	return result, nil
+}
+
+func (s *S3Store) do(
+	ctx context.Context,
+	method string,
+	key string,
+	query url.Values,
+	body []byte,
+) (*http.Response, error) {
+	target := *s.endpoint
+	escapedKey := awsauth.EscapePath(strings.TrimPrefix(key, "/"))
+	if s.pathStyle {
+		target.Path = "/" + s.bucket + "/" + strings.TrimPrefix(key, "/")
+		target.RawPath = "/" + awsauth.EscapePath(s.bucket) + "/" + escapedKey
+	} else {
+		target.Host = s.bucket + "." + target.Host
+		target.Path = "/" + strings.TrimPrefix(key, "/")
+		target.RawPath = "/" + escapedKey
+	}
+	if query != nil {
+		target.RawQuery = query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create archive request: %w", err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	awsauth.SignRequest(request, body, s.credentials, s.region, "s3", time.Now())
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("archive %s request: %w", strings.ToLower(method), err)
+	}
+	return response, nil
+}
+
+func (s *S3Store) statusError(operation string, response *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("archive %s status %d: %s", operation, response.StatusCode, strings.TrimSpace(string(message)))
+}
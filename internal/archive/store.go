@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrObjectNotFound = errors.New("archived object not found")
+
+// ObjectStore abstracts the blob backend used for archived job results.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+}
+
+// ResultKey builds the object key for an archived job result.
+func ResultKey(tenantID, conversationID, jobID string) string {
+	return ConversationPrefix(tenantID, conversationID) + url.PathEscape(jobID) + ".json"
+}
+
+// ConversationPrefix groups archived objects of a conversation so they can be erased together.
+func ConversationPrefix(tenantID, conversationID string) string {
+	return "results/" + url.PathEscape(tenantID) + "/" + url.PathEscape(conversationID) + "/"
+}
+
+// FileStore keeps archived objects on the local filesystem (development and single-node setups).
+type FileStore struct {
+	root string
+}
+
+func NewFileStore(root string) (*FileStore, error) {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return nil, errors.New("archive directory is required")
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("create archive directory: %w", err)
+	}
+	return &FileStore{root: root}, nil
+}
+
+func (s *FileStore) Put(_ context.Context, key string, body []byte) error {
+	path, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("create archive object dir: %w", err)
+	}
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, body, 0o640); err != nil {
+		return fmt.Errorf("write archive object: %w", err)
+	}
+	if err := os.Rename(temporary, path); err != nil {
+		return fmt.Errorf("commit archive object: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("read archive object: %w", err)
+	}
+	return body, nil
+}
+
+func (s *FileStore) DeletePrefix(_ context.Context, prefix string) (int, error) {
+	dir, err := s.resolve(prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	walkErr := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		deleted++
+		return nil
+	})
+	if walkErr != nil {
+		return deleted, fmt.Errorf("delete archive prefix: %w", walkErr)
+	}
+	_ = os.RemoveAll(dir)
+	return deleted, nil
+}
+
+func (s *FileStore) resolve(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" {
+		return "", errors.New("archive key is required")
+	}
+	return filepath.Join(s.root, cleaned), nil
+}
@@ -0,0 +1,85 @@
+// Package audit lets code deep in a request describe the operation being audited (which
+// resource, which tenant, extra details) for the audit middleware to record once the
+// request succeeds.
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// Recorder is the audit sink; repository.AuditRepository implementations satisfy it.
+type Recorder interface {
+	RecordAudit(ctx context.Context, record domain.AuditRecord) error
+}
+
+// Annotation is what the request learned about the audited operation.
+type Annotation struct {
+	TenantID   string
+	ResourceID string
+	Metadata   map[string]any
+}
+
+type annotationKey struct{}
+
+type holder struct {
+	mu         sync.Mutex
+	annotation Annotation
+}
+
+// WithAnnotations prepares ctx to collect annotations for one request.
+func WithAnnotations(ctx context.Context) context.Context {
+	return context.WithValue(ctx, annotationKey{}, &holder{})
+}
+
+// Note records the tenant and resource of the audited operation. Empty values keep what
+// was noted before. Without WithAnnotations it does nothing.
+func Note(ctx context.Context, tenantID, resourceID string) {
+	current, ok := ctx.Value(annotationKey{}).(*holder)
+	if !ok {
+		return
+	}
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	if tenantID != "" {
+		current.annotation.TenantID = tenantID
+	}
+	if resourceID != "" {
+		current.annotation.ResourceID = resourceID
+	}
+}
+
+// AddMetadata attaches a detail to the audit record.
+func AddMetadata(ctx context.Context, key string, value any) {
+	current, ok := ctx.Value(annotationKey{}).(*holder)
+	if !ok {
+		return
+	}
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	if current.annotation.Metadata == nil {
+		current.annotation.Metadata = make(map[string]any)
+	}
+	current.annotation.Metadata[key] = value
+}
+
+// Annotations returns a copy of what was noted in ctx.
+func Annotations(ctx context.Context) Annotation {
+	current, ok := ctx.Value(annotationKey{}).(*holder)
+	if !ok {
+		return Annotation{}
+	}
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	annotation := current.annotation
+	if annotation.Metadata != nil {
+		metadata := make(map[string]any, len(annotation.Metadata))
+		for key, value := range annotation.Metadata {
+			metadata[key] = value
+		}
+		annotation.Metadata = metadata
+	}
+	return annotation
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultJWTLeeway = time.Minute
+
+// ErrInvalidToken wraps every token rejection; the cause is kept for logs only.
+var ErrInvalidToken = errors.New("invalid token")
+
+type JWTConfig struct {
+	// Issuer and Audience are enforced when set.
+	Issuer   string
+	Audience string
+	JWKSURL  string
+	// JWKSCacheTTL defaults to 5 minutes.
+	JWKSCacheTTL time.Duration
+	// Leeway tolerates clock skew on exp/nbf; defaults to 1 minute.
+	Leeway     time.Duration
+	HTTPClient *http.Client
+}
+
+// JWTVerifier validates RS256/384/512 and ES256/384 tokens signed by keys from a JWKS.
+type JWTVerifier struct {
+	issuer   string
+	audience string
+	leeway   time.Duration
+	keys     *JWKSCache
+	now      func() time.Time
+}
+
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if strings.TrimSpace(cfg.JWKSURL) == "" {
+		return nil, errors.New("jwks url is required")
+	}
+	leeway := cfg.Leeway
+	if leeway <= 0 {
+		leeway = defaultJWTLeeway
+	}
+	return &JWTVerifier{
+		issuer:   strings.TrimSpace(cfg.Issuer),
+		audience: strings.TrimSpace(cfg.Audience),
+		leeway:   leeway,
+		keys:     NewJWKSCache(strings.TrimSpace(cfg.JWKSURL), cfg.JWKSCacheTTL, cfg.HTTPClient),
+		now:      time.Now,
+	}, nil
+}
+
+// LooksLikeJWT reports whether token has the three dot-separated JWS segments.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtPayload struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	TenantID  string          `json:"tenant_id"`
+	Roles     json.RawMessage `json:"roles"`
+}
+
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Claims, error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return Claims{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(segments[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	key, err := v.keys.Key(ctx, header.Kid)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := verifySignature(header.Alg, key, segments[0]+"."+segments[1], signature); err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var payload jwtPayload
+	if err := decodeSegment(segments[1], &payload); err != nil {
+		return Claims{}, fmt.Errorf("%w: payload: %v", ErrInvalidToken, err)
+	}
+
+	now := v.now()
+	if payload.ExpiresAt == nil {
+		return Claims{}, fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	expiresAt := unixTime(*payload.ExpiresAt)
+	if now.After(expiresAt.Add(v.leeway)) {
+		return Claims{}, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if payload.NotBefore != nil && now.Add(v.leeway).Before(unixTime(*payload.NotBefore)) {
+		return Claims{}, fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+	}
+	if v.issuer != "" && payload.Issuer != v.issuer {
+		return Claims{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	audience, err := stringOrList(payload.Audience, false)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: aud: %v", ErrInvalidToken, err)
+	}
+	if v.audience != "" && !containsString(audience, v.audience) {
+		return Claims{}, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	roles, err := stringOrList(payload.Roles, true)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: roles: %v", ErrInvalidToken, err)
+	}
+
+	return Claims{
+		Method:    MethodJWT,
+		Subject:   payload.Subject,
+		Issuer:    payload.Issuer,
+		Audience:  audience,
+		TenantID:  strings.TrimSpace(payload.TenantID),
+		Roles:     roles,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		// "none" and HMAC algorithms are rejected: only the provider's keys may sign.
+		return fmt.Errorf("unsupported alg %q", alg)
+	}
+	digest := hashInput(hash, signingInput)
+
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return errors.New("alg does not match rsa key")
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, hash, digest, signature); err != nil {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return errors.New("alg does not match ec key")
+		}
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid ecdsa signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, digest, r, s) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key type")
+	}
+}
+
+func hashInput(hash crypto.Hash, input string) []byte {
+	switch hash {
+	case crypto.SHA384:
+		sum := sha512.Sum384([]byte(input))
+		return sum[:]
+	case crypto.SHA512:
+		sum := sha512.Sum512([]byte(input))
+		return sum[:]
+	default:
+		sum := sha256.Sum256([]byte(input))
+		return sum[:]
+	}
+}
+
+func decodeSegment(segment string, target any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("invalid base64url")
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0).UTC()
+}
+
+// stringOrList decodes claims that may be a single string or a list of strings. With
+// split, a single string is read as a space-separated list, as OAuth scopes are.
+func stringOrList(raw json.RawMessage, split bool) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err != nil {
+		return nil, errors.New("expected string or list of strings")
+	}
+	if split {
+		return strings.Fields(single), nil
+	}
+	return []string{single}, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
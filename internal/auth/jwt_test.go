@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testIssuer struct {
+	key     *rsa.PrivateKey
+	kid     atomic.Value
+	fetches atomic.Int32
+	server  *httptest.Server
+}
+
+func newTestIssuer(t *testing.T, kid string) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	issuer := &testIssuer{key: key}
+	issuer.kid.Store(kid)
+	issuer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuer.fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kid": issuer.kid.Load().(string),
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, header, payload map[string]any) string {
+	t.Helper()
+	encode := func(value map[string]any) string {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("marshal token segment: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	signingInput := encode(header) + "." + encode(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTVerifierAcceptsValidToken(t *testing.T) {
+	issuer := newTestIssuer(t, "key-1")
+	verifier, err := NewJWTVerifier(JWTConfig{
+		Issuer:   "https://idp.example.com",
+		Audience: "wa-back",
+		JWKSURL:  issuer.server.URL,
+	})
+	if err != nil {
+		t.Fatalf("build verifier: %v", err)
+	}
+
+	token := issuer.sign(t, map[string]any{"alg": "RS256", "kid": "key-1"}, map[string]any{
+		"sub":       "agent-42",
+		"iss":       "https://idp.example.com",
+		"aud":       []string{"other", "wa-back"},
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"tenant_id": "tenant-a",
+		"roles":     "agent supervisor",
+	})
+	claims, err := verifier.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatalf("expected token to verify: %v", err)
+	}
+	if claims.Subject != "agent-42" || claims.TenantID != "tenant-a" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if !claims.HasRole("supervisor") || claims.HasRole("admin") {
+		t.Fatalf("unexpected roles: %+v", claims.Roles)
+	}
+
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Fatalf("expected cached key to verify: %v", err)
+	}
+	if fetches := issuer.fetches.Load(); fetches != 1 {
+		t.Fatalf("expected jwks to be fetched once, got %d", fetches)
+	}
+}
+
+func TestJWTVerifierRejectsInvalidTokens(t *testing.T) {
+	issuer := newTestIssuer(t, "key-1")
+	verifier, err := NewJWTVerifier(JWTConfig{
+		Issuer:   "https://idp.example.com",
+		Audience: "wa-back",
+		JWKSURL:  issuer.server.URL,
+	})
+	if err != nil {
+		t.Fatalf("build verifier: %v", err)
+	}
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss": "https://idp.example.com",
+			"aud": "wa-back",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	with := func(key string, value any) map[string]any {
+		payload := valid()
+		if value == nil {
+			delete(payload, key)
+		} else {
+			payload[key] = value
+		}
+		return payload
+	}
+	header := map[string]any{"alg": "RS256", "kid": "key-1"}
+
+	tampered := issuer.sign(t, header, valid())
+	tampered = tampered[:len(tampered)-4] + "AAAA"
+
+	cases := map[string]string{
+		"expired":         issuer.sign(t, header, with("exp", time.Now().Add(-time.Hour).Unix())),
+		"missing exp":     issuer.sign(t, header, with("exp", nil)),
+		"not yet valid":   issuer.sign(t, header, with("nbf", time.Now().Add(time.Hour).Unix())),
+		"wrong issuer":    issuer.sign(t, header, with("iss", "https://evil.example.com")),
+		"wrong audience":  issuer.sign(t, header, with("aud", "other")),
+		"unknown kid":     issuer.sign(t, map[string]any{"alg": "RS256", "kid": "key-2"}, valid()),
+		"alg none":        issuer.sign(t, map[string]any{"alg": "none", "kid": "key-1"}, valid()),
+		"bad signature":   tampered,
+		"malformed token": "not-a-jwt",
+	}
+	for name, token := range cases {
+		if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestJWKSCacheRefreshesOnUnknownKid(t *testing.T) {
+	issuer := newTestIssuer(t, "key-1")
+	cache := NewJWKSCache(issuer.server.URL, time.Hour, nil)
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	if _, err := cache.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("expected key-1: %v", err)
+	}
+
+	issuer.kid.Store("key-2")
+	if _, err := cache.Key(context.Background(), "key-2"); !errors.Is(err, errUnknownKey) {
+		t.Fatalf("expected refresh to be throttled, got %v", err)
+	}
+
+	now = now.Add(jwksMinRefresh)
+	if _, err := cache.Key(context.Background(), "key-2"); err != nil {
+		t.Fatalf("expected rotated key after refresh: %v", err)
+	}
+	if fetches := issuer.fetches.Load(); fetches != 2 {
+		t.Fatalf("expected two jwks fetches, got %d", fetches)
+	}
+}
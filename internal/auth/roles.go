@@ -0,0 +1,27 @@
+package auth
+
+// Roles enforced per route. Admin reaches every tenant route, including data erasure, and
+// a JWT with it also reaches /admin; agent reaches the tenant API except data erasure;
+// readonly may only read jobs and reports.
+const (
+	RoleAdmin    = "admin"
+	RoleAgent    = "agent"
+	RoleReadOnly = "readonly"
+)
+
+// Role folds the token roles (or API key scopes) into the strongest route role. The
+// suggestions and reports scopes act as agent; tokens naming no known role also act as
+// agent, so identity providers that predate roles keep their access. The admin scope of an
+// API key makes it admin on /v1 and /v2 only: AdminAuth never accepts API keys, since a
+// key belongs to one tenant and /admin acts on all of them.
+func (c Claims) Role() string {
+	switch {
+	case c.HasRole(RoleAdmin):
+		return RoleAdmin
+	case c.HasRole(RoleAgent), c.HasRole("suggestions"), c.HasRole("reports"):
+		return RoleAgent
+	case c.HasRole(RoleReadOnly):
+		return RoleReadOnly
+	}
+	return RoleAgent
+}
@@ -0,0 +1,332 @@
+// Package bootstrap wires the dependencies shared by the API and worker binaries:
+// configuration, policies, repositories, the queue and the AI generation service.
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/ai"
+	"github.com/iago/extensao-whatsapp-back/internal/cache"
+	"github.com/iago/extensao-whatsapp-back/internal/config"
+	contextbuilder "github.com/iago/extensao-whatsapp-back/internal/context"
+	"github.com/iago/extensao-whatsapp-back/internal/errorreport"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/quality"
+	"github.com/iago/extensao-whatsapp-back/internal/queue"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
+)
+
+// Load reads .env files and the environment, resolves secrets backend references and
+// builds the process logger. Invalid settings exit the process; suspicious ones are
+// logged as warnings, and the effective configuration is logged with secrets redacted.
+func Load() (config.Config, *slog.Logger) {
+	dotEnvErr := config.LoadDotEnv(".env", ".env.local")
+	cfg := config.Load()
+	logger, err := logging.New(logging.Config{Level: cfg.LogLevel, Format: cfg.LogFormat, Output: os.Stdout})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logging configuration: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	if dotEnvErr != nil {
+		logger.Warn("failed loading .env files", slog.Any("error", dotEnvErr))
+	}
+	if cfg.RemoteConfigURL != "" {
+		cfg = applyRemoteConfig(cfg, logger)
+	}
+	resolveCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := resolveSecrets(resolveCtx, &cfg); err != nil {
+		Fatal(logger, "resolve secrets failed", err)
+	}
+	warnings, err := cfg.Validate()
+	for _, warning := range warnings {
+		logger.Warn("configuration warning", slog.String("warning", warning))
+	}
+	if err != nil {
+		Fatal(logger, "invalid configuration", err)
+	}
+	logger.Info("effective configuration", slog.Any("config", cfg.Snapshot()), slog.Any("sources", cfg.Sources()))
+	return cfg, logger
+}
+
+// Fatal logs a startup configuration error and exits.
+func Fatal(logger *slog.Logger, message string, err error) {
+	logger.Error(message, slog.Any("error", err))
+	os.Exit(1)
+}
+
+// StartTracing installs the OTLP tracer when an exporter is configured and returns the
+// function flushing it on shutdown.
+func StartTracing(cfg config.Config, logger *slog.Logger) func() {
+	if cfg.OTelExporterEndpoint == "" {
+		return func() {}
+	}
+	tracer := tracing.NewTracer(tracing.Config{
+		Endpoint:    cfg.OTelExporterEndpoint,
+		ServiceName: cfg.OTelServiceName,
+		SampleRatio: cfg.OTelTracesSampleRatio,
+		Logger:      logger,
+	})
+	tracing.SetTracer(tracer)
+	logger.Info("tracing enabled", slog.String("exporter", cfg.OTelExporterEndpoint), slog.Float64("sample_ratio", cfg.OTelTracesSampleRatio))
+	return func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		tracer.Shutdown(flushCtx)
+	}
+}
+
+// LoadPolicies loads the policy files and keeps reloading them until ctx ends.
+func LoadPolicies(ctx context.Context, cfg config.Config, logger *slog.Logger) {
+	reload := time.Duration(cfg.PolicyReloadSeconds) * time.Second
+	for _, file := range policyFiles(cfg) {
+		if file.path == "" {
+			continue
+		}
+		if err := file.load(file.path); err != nil {
+			Fatal(logger, "invalid "+file.setting, err)
+		}
+		go file.watch(ctx, file.path, reload, logger)
+		logger.Info(file.name+" loaded from file", slog.String("path", file.path))
+	}
+}
+
+type policyFile struct {
+	name    string
+	setting string
+	field   string
+	path    string
+	// load reads the file and puts it in use.
+	load  func(path string) error
+	watch func(ctx context.Context, path string, interval time.Duration, logger *slog.Logger)
+}
+
+func policyFiles(cfg config.Config) []policyFile {
+	return []policyFile{
+		{
+			name: "blocked keywords", setting: "BLOCKED_KEYWORDS_FILE", field: "BlockedKeywordsFile", path: cfg.BlockedKeywordsFile,
+			load: func(path string) error {
+				keywords, err := policy.LoadKeywordFile(path)
+				if err == nil {
+					policy.SetBlockedKeywords(keywords)
+				}
+				return err
+			},
+			watch: policy.WatchKeywordFile,
+		},
+		{
+			name: "pii rules", setting: "PII_RULES_FILE", field: "PIIRulesFile", path: cfg.PIIRulesFile,
+			load: func(path string) error {
+				rules, err := policy.LoadPIIRulesFile(path)
+				if err == nil {
+					policy.SetPIIRules(rules)
+				}
+				return err
+			},
+			watch: policy.WatchPIIRulesFile,
+		},
+		{
+			name: "tone lexicons", setting: "TONE_LEXICONS_FILE", field: "ToneLexiconsFile", path: cfg.ToneLexiconsFile,
+			load: func(path string) error {
+				lexicons, err := policy.LoadToneLexiconsFile(path)
+				if err == nil {
+					policy.SetToneLexicons(lexicons)
+				}
+				return err
+			},
+			watch: policy.WatchToneLexiconsFile,
+		},
+		{
+			name: "profanity filter", setting: "PROFANITY_FILE", field: "ProfanityFile", path: cfg.ProfanityFile,
+			load: func(path string) error {
+				profanity, err := policy.LoadProfanityFile(path)
+				if err == nil {
+					policy.SetProfanity(profanity)
+				}
+				return err
+			},
+			watch: policy.WatchProfanityFile,
+		},
+	}
+}
+
+// Runtime holds the dependencies both binaries build the same way.
+type Runtime struct {
+	Config config.Config
+	Logger *slog.Logger
+	// LogLevels changes the level of Logger at runtime (admin API, SIGUSR2, reloads).
+	LogLevels *logging.Levels
+	// ErrorReporter receives handler 5xx responses, panics and failed jobs; errorreport.Nop
+	// when SENTRY_DSN is empty.
+	ErrorReporter errorreport.Reporter
+
+	Repos Repositories
+	// Jobs is Repos.Jobs, resolving archived results on read when archiving is enabled.
+	Jobs     repository.JobsRepository
+	Producer queue.Producer
+	Consumer queue.Consumer
+
+	AIClient       *ai.OpenRouterClient
+	Models         *ai.ModelRouter
+	ContextBuilder *contextbuilder.Builder
+	Cache          *cache.SemanticCache
+	// Registry is nil when metrics are disabled.
+	Registry      *metrics.Registry
+	RecentReplies *quality.RecentReplies
+	// TenantSettings serves the per-tenant locale, tone, models, quotas and policy profile.
+	TenantSettings *service.TenantSettingsService
+	// Metering counts the usage of every generation per tenant and day.
+	Metering     *service.MeteringService
+	AIGeneration *service.AIGenerationService
+
+	authToken atomic.Pointer[string]
+	closers   []func()
+}
+
+// New builds the runtime; configuration errors exit the process.
+func New(ctx context.Context, cfg config.Config, logger *slog.Logger) *Runtime {
+	runtime := &Runtime{Config: cfg, Logger: logger, LogLevels: logging.LevelsOf(logger)}
+	runtime.authToken.Store(&cfg.AuthToken)
+	runtime.ErrorReporter = runtime.setupErrorReporter()
+
+	repos, repoCloser := setupRepositories(ctx, cfg, logger)
+	runtime.closers = append(runtime.closers, repoCloser)
+	if cfg.AuditLogFile != "" {
+		fileAudit, err := repository.NewFileAuditRepository(cfg.AuditLogFile)
+		if err != nil {
+			Fatal(logger, "invalid AUDIT_LOG_FILE", err)
+		}
+		runtime.closers = append(runtime.closers, func() { _ = fileAudit.Close() })
+		repos.Audit = fileAudit
+		logger.Info("audit trail written to file", slog.String("path", cfg.AuditLogFile))
+	}
+	runtime.Repos = repos
+	runtime.Jobs = setupArchive(ctx, cfg, repos.Jobs, repos.Cipher, logger)
+
+	producer, consumer, queueCloser := setupQueue(ctx, cfg, logger)
+	runtime.closers = append(runtime.closers, queueCloser)
+	runtime.Producer = producer
+	runtime.Consumer = consumer
+
+	runtime.Models = ai.NewModelRouter(modelRouterConfig(cfg))
+	runtime.AIClient = ai.NewOpenRouterClient(ai.OpenRouterClientConfig{
+		APIKey:     cfg.OpenRouterAPIKey,
+		BaseURL:    cfg.OpenRouterBaseURL,
+		Timeout:    time.Duration(cfg.OpenRouterTimeoutMS) * time.Millisecond,
+		MaxRetries: cfg.OpenRouterMaxRetries,
+		SiteURL:    cfg.OpenRouterSiteURL,
+		AppName:    cfg.OpenRouterAppName,
+	})
+	runtime.ContextBuilder = contextbuilder.NewBuilder(
+		contextbuilder.NewHistoryRetriever(repos.Messages, contextbuilder.NewBasicRetriever()),
+	)
+	runtime.Cache = cache.NewSemanticCache(semanticCacheConfig(cfg))
+	modelPrices, err := ai.ParsePriceTable(cfg.OpenRouterModelPrices)
+	if err != nil {
+		Fatal(logger, "invalid OPENROUTER_MODEL_PRICES", err)
+	}
+	// The registry is created before the services that record into it.
+	if cfg.MetricsEnabled {
+		runtime.Registry = metrics.NewRegistry()
+		metrics.RegisterProcessMetrics(runtime.Registry)
+		if depth, ok := consumer.(queue.DepthReporter); ok {
+			queue.RegisterDepthGauge(runtime.Registry, depth)
+		}
+	}
+
+	validator := quality.NewOutputValidator()
+	validator.UseJudge(quality.NewJudge(quality.JudgeConfig{
+		Client:  runtime.AIClient,
+		Model:   cfg.QualityJudgeModel,
+		Weight:  cfg.QualityJudgeWeight,
+		Timeout: time.Duration(cfg.QualityJudgeTimeoutMS) * time.Millisecond,
+	}))
+	validator.UseToxicityModel(quality.NewToxicityModel(quality.ToxicityConfig{
+		Client:  runtime.AIClient,
+		Model:   cfg.QualityToxicityModel,
+		Timeout: time.Duration(cfg.QualityToxicityTimeoutMS) * time.Millisecond,
+	}))
+	runtime.RecentReplies = quality.NewRecentReplies(cfg.SuggestionRecentReplies)
+	runtime.TenantSettings = service.NewTenantSettingsService(repos.TenantSettings, time.Duration(cfg.TenantSettingsCacheSeconds)*time.Second)
+	if loaded, err := runtime.TenantSettings.Preload(ctx); err != nil {
+		logger.Warn("failed to preload tenant settings", slog.Any("error", err))
+	} else if loaded > 0 {
+		logger.Info("tenant settings loaded", slog.Int("tenants", loaded))
+	}
+	runtime.Metering = service.NewMeteringService(repos.Usage, logger)
+	go runtime.Metering.Start(ctx, time.Duration(cfg.UsageFlushSeconds)*time.Second)
+	// Runs before the repositories close, so the last counts are not lost.
+	runtime.closers = append(runtime.closers, func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := runtime.Metering.Flush(flushCtx); err != nil {
+			logger.Error("final usage flush failed", slog.Any("error", err))
+		}
+	})
+	runtime.AIGeneration = service.NewAIGenerationService(service.AIGenerationDependencies{
+		Router:         runtime.Models,
+		Client:         runtime.AIClient,
+		Builder:        runtime.ContextBuilder,
+		Cache:          runtime.Cache,
+		Validator:      validator,
+		QualityMetrics: quality.NewMetrics(runtime.Registry),
+		AIMetrics:      ai.NewMetrics(runtime.Registry),
+		CacheMetrics:   cache.NewMetrics(runtime.Registry, runtime.Cache),
+		RecentReplies:  runtime.RecentReplies,
+		TenantSettings: runtime.TenantSettings,
+		Metering:       runtime.Metering,
+		CallLogger:     ai.NewCallLogger(logger, cfg.AICallLogSampleRate),
+		Prices:         modelPrices,
+		PromptsDir:     cfg.PromptsDir,
+		Logger:         logger,
+	})
+	if cfg.SecretsRefreshSeconds > 0 && len(cfg.SecretRefs) > 0 {
+		go runtime.refreshSecrets(ctx)
+	}
+	return runtime
+}
+
+// setupErrorReporter builds the Sentry reporter when SENTRY_DSN is set. Its closer is the
+// first, so events reported while the rest shuts down are still sent.
+func (r *Runtime) setupErrorReporter() errorreport.Reporter {
+	cfg := r.Config
+	if cfg.SentryDSN == "" {
+		return errorreport.Nop{}
+	}
+	hostname, _ := os.Hostname()
+	reporter, err := errorreport.NewSentry(errorreport.SentryConfig{
+		DSN:         cfg.SentryDSN,
+		Environment: cfg.SentryEnvironment,
+		Release:     cfg.SentryRelease,
+		ServerName:  hostname,
+		Logger:      r.Logger,
+	})
+	if err != nil {
+		Fatal(r.Logger, "invalid SENTRY_DSN", err)
+	}
+	r.closers = append(r.closers, func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		reporter.Shutdown(flushCtx)
+	})
+	r.Logger.Info("error reporting enabled", slog.String("environment", cfg.SentryEnvironment))
+	return reporter
+}
+
+// Close flushes the metered usage and releases the queue and repositories, in reverse
+// order of creation.
+func (r *Runtime) Close() {
+	for index := len(r.closers) - 1; index >= 0; index-- {
+		r.closers[index]()
+	}
+}
@@ -0,0 +1,85 @@
+package bootstrap
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/config"
+	"github.com/iago/extensao-whatsapp-back/internal/queue"
+)
+
+func setupQueue(
+	ctx context.Context,
+	cfg config.Config,
+	logger *slog.Logger,
+) (queue.Producer, queue.Consumer, func()) {
+	var (
+		baseProducer queue.Producer
+		consumer     queue.Consumer
+		baseCloser   = func() {}
+	)
+
+	if cfg.RedisAddr == "" {
+		logger.Info("REDIS_ADDR not configured, using local queue fallback")
+		local := queue.NewLocalQueue(512, 3, logger)
+		local.UsePriorityWeight(cfg.QueuePriorityWeight)
+		baseProducer = local
+		consumer = local
+	} else {
+		streams, err := queue.NewStreamsQueue(ctx, queue.StreamsConfig{
+			Addr:           cfg.RedisAddr,
+			Password:       cfg.RedisPassword,
+			DB:             cfg.RedisDB,
+			Stream:         cfg.RedisStream,
+			DLQStream:      cfg.RedisDLQ,
+			Group:          cfg.RedisGroup,
+			Consumer:       cfg.RedisConsumer,
+			MaxAttempts:    3,
+			PriorityWeight: cfg.QueuePriorityWeight,
+			ClaimIdle:      time.Duration(cfg.RedisClaimIdleSeconds) * time.Second,
+		})
+		if err != nil {
+			if cfg.Production() {
+				Fatal(logger, "failed to initialize redis streams queue", err)
+			}
+			logger.Error("failed to initialize redis streams queue, fallback to local", slog.Any("error", err))
+			local := queue.NewLocalQueue(512, 3, logger)
+			local.UsePriorityWeight(cfg.QueuePriorityWeight)
+			baseProducer = local
+			consumer = local
+		} else {
+			logger.Info("redis streams queue initialized")
+			baseProducer = streams
+			consumer = streams
+			baseCloser = func() {
+				_ = streams.Close()
+			}
+		}
+	}
+
+	producer := baseProducer
+	batchingCloser := func() {}
+	if cfg.QueueBatchingEnabled {
+		batching := queue.NewBatchingProducer(ctx, baseProducer, queue.BatchingConfig{
+			MaxBatchSize:       cfg.QueueBatchSize,
+			FlushInterval:      time.Duration(cfg.QueueBatchFlushMS) * time.Millisecond,
+			FlushTimeout:       time.Duration(cfg.QueueBatchFlushTimeoutMS) * time.Millisecond,
+			QueueCapacity:      cfg.QueueBatchQueueCapacity,
+			MaxInFlightBatches: cfg.QueueBatchMaxInFlight,
+		})
+		producer = batching
+		batchingCloser = batching.Close
+		logger.Info("queue batching enabled",
+			slog.Int("size", cfg.QueueBatchSize),
+			slog.Int("flush_ms", cfg.QueueBatchFlushMS),
+			slog.Int("queue_capacity", cfg.QueueBatchQueueCapacity),
+			slog.Int("max_in_flight", cfg.QueueBatchMaxInFlight),
+		)
+	}
+
+	return producer, consumer, func() {
+		batchingCloser()
+		baseCloser()
+	}
+}
@@ -0,0 +1,180 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/ai"
+	"github.com/iago/extensao-whatsapp-back/internal/cache"
+	"github.com/iago/extensao-whatsapp-back/internal/config"
+	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+)
+
+func modelRouterConfig(cfg config.Config) ai.ModelRouterConfig {
+	return ai.ModelRouterConfig{
+		SuggestionPrimary:  cfg.OpenRouterModelSuggestionPrimary,
+		SuggestionFallback: cfg.OpenRouterModelSuggestionFallback,
+		SummaryPrimary:     cfg.OpenRouterModelSummaryPrimary,
+		SummaryFallback:    cfg.OpenRouterModelSummaryFallback,
+		ReportPrimary:      cfg.OpenRouterModelReportPrimary,
+		ReportFallback:     cfg.OpenRouterModelReportFallback,
+	}
+}
+
+func semanticCacheConfig(cfg config.Config) cache.Config {
+	return cache.Config{
+		TTL:        time.Duration(cfg.SemanticCacheTTLSeconds) * time.Second,
+		MaxEntries: cfg.SemanticCacheMaxEntries,
+	}
+}
+
+// NewReloader builds the reloader of the settings both binaries can change without a
+// restart: model names, the prompts directory, the semantic cache and the policy files.
+func (r *Runtime) NewReloader() *config.Reloader {
+	reloader := config.NewReloader(r.Logger, ".env", ".env.local")
+	reloader.Handle("models", []string{
+		"OpenRouterModelSuggestionPrimary", "OpenRouterModelSuggestionFallback",
+		"OpenRouterModelSummaryPrimary", "OpenRouterModelSummaryFallback",
+		"OpenRouterModelReportPrimary", "OpenRouterModelReportFallback",
+	}, func(previous, next config.Config) (bool, error) {
+		if modelRouterConfig(previous) == modelRouterConfig(next) {
+			return false, nil
+		}
+		r.Models.Reconfigure(modelRouterConfig(next))
+		return true, nil
+	})
+	// Prompt templates are read again on every reload, so edited files apply too.
+	reloader.Handle("prompts", []string{"PromptsDir"}, func(previous, next config.Config) (bool, error) {
+		if err := r.AIGeneration.UsePromptsDir(next.PromptsDir); err != nil {
+			return false, err
+		}
+		return previous.PromptsDir != next.PromptsDir, nil
+	})
+	reloader.Handle("cache", []string{"SemanticCacheTTLSeconds", "SemanticCacheMaxEntries"}, func(previous, next config.Config) (bool, error) {
+		if semanticCacheConfig(previous) == semanticCacheConfig(next) {
+			return false, nil
+		}
+		r.Cache.Reconfigure(semanticCacheConfig(next))
+		return true, nil
+	})
+	reloader.Handle("log_level", []string{"LogLevel"}, func(previous, next config.Config) (bool, error) {
+		level, err := logging.ParseLevel(next.LogLevel)
+		if err != nil || r.LogLevels == nil {
+			return false, err
+		}
+		r.LogLevels.SetConfigured(level)
+		return previous.LogLevel != next.LogLevel, nil
+	})
+	r.handlePolicyReload(reloader)
+	return reloader
+}
+
+// handlePolicyReload reads the policy files again right away instead of waiting for the
+// watchers; a file whose path changed needs a restart, as the watcher keeps the old one.
+func (r *Runtime) handlePolicyReload(reloader *config.Reloader) {
+	fields := make([]string, 0, 4)
+	for _, file := range policyFiles(r.Config) {
+		fields = append(fields, file.field)
+	}
+	reloader.Handle("policies", fields, func(previous, next config.Config) (bool, error) {
+		var problems []error
+		before := policyFiles(previous)
+		for index, file := range policyFiles(next) {
+			if file.path != before[index].path {
+				problems = append(problems, fmt.Errorf("%s changed, restart to use it", file.setting))
+				continue
+			}
+			if file.path == "" {
+				continue
+			}
+			if err := file.load(file.path); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", file.setting, err))
+			}
+		}
+		return false, errors.Join(problems...)
+	})
+}
+
+// HandleRateLimitReload lets reloader apply RATE_LIMIT_RPS, RATE_LIMIT_BURST and
+// RATE_LIMIT_ROUTES to limiter, and RATE_LIMIT_IP_RPS and RATE_LIMIT_IP_BURST to
+// ipLimiter.
+func HandleRateLimitReload(reloader *config.Reloader, limiter, ipLimiter *middleware.RateLimiter) {
+	fields := []string{"RateLimitRPS", "RateLimitBurst", "RateLimitRoutes", "RateLimitIPRPS", "RateLimitIPBurst"}
+	reloader.Handle("rate_limits", fields, func(previous, next config.Config) (bool, error) {
+		clientChanged := previous.RateLimitRPS != next.RateLimitRPS || previous.RateLimitBurst != next.RateLimitBurst ||
+			!slices.Equal(previous.RateLimitRoutes, next.RateLimitRoutes)
+		ipChanged := previous.RateLimitIPRPS != next.RateLimitIPRPS || previous.RateLimitIPBurst != next.RateLimitIPBurst
+		if !clientChanged && !ipChanged {
+			return false, nil
+		}
+		routes, err := middleware.ParseRouteLimits(next.RateLimitRoutes)
+		if err != nil {
+			return false, err
+		}
+		if clientChanged {
+			limiter.Reconfigure(next.RateLimitRPS, next.RateLimitBurst, routes...)
+		}
+		if ipChanged {
+			ipLimiter.Reconfigure(next.RateLimitIPRPS, next.RateLimitIPBurst)
+		}
+		return true, nil
+	})
+}
+
+func remoteConfig(cfg config.Config) config.RemoteConfig {
+	return config.RemoteConfig{
+		URL:        cfg.RemoteConfigURL,
+		AuthToken:  cfg.RemoteConfigAuthToken,
+		S3Endpoint: cfg.RemoteConfigS3Endpoint,
+		S3Region:   cfg.RemoteConfigS3Region,
+		AccessKey:  cfg.AWSAccessKeyID,
+		SecretKey:  cfg.AWSSecretAccessKey,
+	}
+}
+
+// applyRemoteConfig loads cfg again with the REMOTE_CONFIG_URL overlay, so it also covers
+// the settings that need a restart. An unreachable or invalid overlay is logged and the
+// process starts on its local configuration; the poller keeps trying.
+func applyRemoteConfig(cfg config.Config, logger *slog.Logger) config.Config {
+	source, err := config.NewRemoteSource(remoteConfig(cfg))
+	if err != nil {
+		Fatal(logger, "invalid REMOTE_CONFIG_URL", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	values, _, err := source.Fetch(ctx)
+	if err != nil {
+		logger.Warn("remote config unavailable, starting with the local configuration", slog.Any("error", err))
+		return cfg
+	}
+	config.SetRemoteOverlay(values)
+	overlaid := config.Load()
+	if _, err := overlaid.Validate(); err != nil {
+		config.SetRemoteOverlay(nil)
+		logger.Error("remote config rejected, starting with the local configuration", slog.Any("error", err))
+		return cfg
+	}
+	logger.Info("remote config applied", slog.Int("settings", len(values)))
+	return overlaid
+}
+
+// WatchRemoteConfig polls REMOTE_CONFIG_URL every REMOTE_CONFIG_INTERVAL_SECONDS and
+// reloads through reloader until ctx ends; it returns at once when no URL is set.
+func (r *Runtime) WatchRemoteConfig(ctx context.Context, reloader *config.Reloader) {
+	if r.Config.RemoteConfigURL == "" {
+		return
+	}
+	source, err := config.NewRemoteSource(remoteConfig(r.Config))
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "remote config disabled", slog.Any("error", err))
+		return
+	}
+	interval := time.Duration(r.Config.RemoteConfigIntervalSeconds) * time.Second
+	r.Logger.InfoContext(ctx, "polling remote config", slog.String("url", r.Config.RemoteConfigURL), slog.Duration("interval", interval))
+	reloader.WatchRemote(ctx, source, interval)
+}
@@ -0,0 +1,194 @@
+package bootstrap
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/archive"
+	"github.com/iago/extensao-whatsapp-back/internal/config"
+	"github.com/iago/extensao-whatsapp-back/internal/envelope"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+// Repositories are the storage backends, in Postgres when DATABASE_URL is set and in
+// memory otherwise. Outside production an unreachable database also falls back to memory.
+type Repositories struct {
+	Jobs      repository.JobsRepository
+	Messages  repository.MessagesRepository
+	Audit     repository.AuditRepository
+	HITL      repository.HITLRepository
+	Templates repository.TemplatesRepository
+	APIKeys   repository.APIKeysRepository
+	// Idempotency stores replayable POST responses.
+	Idempotency repository.IdempotencyRepository
+	// Pseudonyms maps PII tokens back to their values for pseudonymizing tenants.
+	Pseudonyms repository.PseudonymsRepository
+	// PolicyViolations keeps the requests blocked by the content policy.
+	PolicyViolations repository.PolicyViolationsRepository
+	// Leases elect the replica that fires recurring jobs.
+	Leases repository.LeasesRepository
+	// TenantSettings keeps the per-tenant defaults, model overrides and quotas.
+	TenantSettings repository.TenantSettingsRepository
+	// Usage accumulates the metered usage per tenant and day.
+	Usage repository.UsageRepository
+	// Ping checks the database connection; nil for in-memory repositories.
+	Ping func(ctx context.Context) error
+	// Cipher seals job data at rest; nil when encryption is not configured.
+	Cipher repository.PayloadCipher
+}
+
+func memoryRepositories() Repositories {
+	return Repositories{
+		Jobs:             repository.NewMemoryJobsRepository(),
+		Messages:         repository.NewMemoryMessagesRepository(),
+		Audit:            repository.NewMemoryAuditRepository(),
+		HITL:             repository.NewMemoryHITLRepository(),
+		Templates:        repository.NewMemoryTemplatesRepository(),
+		APIKeys:          repository.NewMemoryAPIKeysRepository(),
+		Idempotency:      repository.NewMemoryIdempotencyRepository(),
+		Pseudonyms:       repository.NewMemoryPseudonymsRepository(),
+		PolicyViolations: repository.NewMemoryPolicyViolationsRepository(),
+		Leases:           repository.NewMemoryLeasesRepository(),
+		TenantSettings:   repository.NewMemoryTenantSettingsRepository(),
+		Usage:            repository.NewMemoryUsageRepository(),
+	}
+}
+
+func setupRepositories(
+	ctx context.Context,
+	cfg config.Config,
+	logger *slog.Logger,
+) (Repositories, func()) {
+	if cfg.DatabaseURL == "" {
+		logger.Info("DATABASE_URL not configured, using in-memory repository")
+		return memoryRepositories(), func() {}
+	}
+
+	pgRepo, err := repository.NewPostgresJobsRepository(ctx, cfg.DatabaseURL)
+	if err != nil {
+		if cfg.Production() {
+			Fatal(logger, "failed to initialize postgres repository", err)
+		}
+		logger.Error("failed to initialize postgres repository, fallback to memory", slog.Any("error", err))
+		return memoryRepositories(), func() {}
+	}
+	logger.Info("postgres repository initialized")
+
+	cipher, err := setupCipher(cfg)
+	if err != nil {
+		pgRepo.Close()
+		Fatal(logger, "invalid encryption configuration", err)
+	}
+	pseudonymsRepo := repository.NewPostgresPseudonymsRepository(pgRepo.Pool())
+	idempotencyRepo := repository.NewPostgresIdempotencyRepository(pgRepo.Pool())
+	var payloadCipher repository.PayloadCipher
+	if cipher != nil {
+		payloadCipher = cipher
+		pgRepo.UseCipher(cipher)
+		pseudonymsRepo.UseCipher(cipher)
+		idempotencyRepo.UseCipher(cipher)
+		logger.Info("job payload/result encryption at rest enabled")
+	}
+
+	return Repositories{
+		Jobs:             pgRepo,
+		Messages:         repository.NewPostgresMessagesRepository(pgRepo.Pool()),
+		Audit:            repository.NewPostgresAuditRepository(pgRepo.Pool()),
+		HITL:             repository.NewPostgresHITLRepository(pgRepo.Pool()),
+		Templates:        repository.NewPostgresTemplatesRepository(pgRepo.Pool()),
+		APIKeys:          repository.NewPostgresAPIKeysRepository(pgRepo.Pool()),
+		Idempotency:      idempotencyRepo,
+		Pseudonyms:       pseudonymsRepo,
+		PolicyViolations: repository.NewPostgresPolicyViolationsRepository(pgRepo.Pool()),
+		Leases:           repository.NewPostgresLeasesRepository(pgRepo.Pool()),
+		TenantSettings:   repository.NewPostgresTenantSettingsRepository(pgRepo.Pool()),
+		Usage:            repository.NewPostgresUsageRepository(pgRepo.Pool()),
+		Ping:             pgRepo.Pool().Ping,
+		Cipher:           payloadCipher,
+	}, func() {
+		pgRepo.Close()
+	}
+}
+
+// setupCipher returns nil when no encryption key is configured.
+func setupCipher(cfg config.Config) (*envelope.Cipher, error) {
+	if cfg.EncryptionKMSKeyID != "" {
+		wrapper, err := envelope.NewKMSWrapper(envelope.KMSConfig{
+			KeyID:     cfg.EncryptionKMSKeyID,
+			Region:    cfg.EncryptionKMSRegion,
+			Endpoint:  cfg.EncryptionKMSEndpoint,
+			AccessKey: cfg.AWSAccessKeyID,
+			SecretKey: cfg.AWSSecretAccessKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return envelope.NewCipher(wrapper), nil
+	}
+	if cfg.EncryptionKeys != "" {
+		keyring, err := envelope.ParseKeyring(cfg.EncryptionKeys)
+		if err != nil {
+			return nil, err
+		}
+		return envelope.NewCipher(keyring), nil
+	}
+	return nil, nil
+}
+
+// setupArchive wraps the jobs repository so archived results are resolved on read
+// and starts the background archiver when ARCHIVE_ENABLED is set.
+func setupArchive(
+	ctx context.Context,
+	cfg config.Config,
+	jobs repository.JobsRepository,
+	cipher repository.PayloadCipher,
+	logger *slog.Logger,
+) repository.JobsRepository {
+	if !cfg.ArchiveEnabled {
+		return jobs
+	}
+
+	var store archive.ObjectStore
+	switch cfg.ArchiveBackend {
+	case "s3":
+		s3Store, err := archive.NewS3Store(archive.S3Config{
+			Endpoint:  cfg.ArchiveS3Endpoint,
+			Region:    cfg.ArchiveS3Region,
+			Bucket:    cfg.ArchiveS3Bucket,
+			AccessKey: cfg.ArchiveS3AccessKey,
+			SecretKey: cfg.ArchiveS3SecretKey,
+			PathStyle: cfg.ArchiveS3PathStyle,
+		})
+		if err != nil {
+			logger.Error("failed to initialize s3 archive store, archiving disabled", slog.Any("error", err))
+			return jobs
+		}
+		store = s3Store
+	default:
+		fileStore, err := archive.NewFileStore(cfg.ArchiveDir)
+		if err != nil {
+			logger.Error("failed to initialize file archive store, archiving disabled", slog.Any("error", err))
+			return jobs
+		}
+		store = fileStore
+	}
+
+	resolving := archive.NewResolvingRepository(jobs, store)
+	archiver := archive.NewArchiver(resolving, store, archive.ArchiverConfig{
+		After:     time.Duration(cfg.ArchiveAfterDays) * 24 * time.Hour,
+		Interval:  time.Duration(cfg.ArchiveIntervalSeconds) * time.Second,
+		BatchSize: cfg.ArchiveBatchSize,
+	}, logger)
+	if cipher != nil {
+		resolving.UseCipher(cipher)
+		archiver.UseCipher(cipher)
+	}
+	go archiver.Start(ctx)
+	logger.Info("result archiving enabled",
+		slog.String("backend", cfg.ArchiveBackend),
+		slog.Int("after_days", cfg.ArchiveAfterDays),
+		slog.Int("interval_seconds", cfg.ArchiveIntervalSeconds),
+	)
+	return resolving
+}
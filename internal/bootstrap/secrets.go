@@ -0,0 +1,110 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/config"
+	"github.com/iago/extensao-whatsapp-back/internal/secrets"
+)
+
+type secretSetting struct {
+	name  string
+	value *string
+}
+
+// secretSettings are the settings that may be a secrets backend reference.
+func secretSettings(cfg *config.Config) []secretSetting {
+	return []secretSetting{
+		{"OPENROUTER_API_KEY", &cfg.OpenRouterAPIKey},
+		{"DATABASE_URL", &cfg.DatabaseURL},
+		{"API_AUTH_TOKEN", &cfg.AuthToken},
+	}
+}
+
+func newSecretsResolver(cfg config.Config) *secrets.Resolver {
+	return secrets.NewResolver(secrets.Config{
+		VaultAddr:      cfg.VaultAddr,
+		VaultToken:     cfg.VaultToken,
+		VaultNamespace: cfg.VaultNamespace,
+		AWSRegion:      cfg.SecretsAWSRegion,
+		AWSEndpoint:    cfg.SecretsAWSEndpoint,
+		AWSAccessKey:   cfg.AWSAccessKeyID,
+		AWSSecretKey:   cfg.AWSSecretAccessKey,
+	})
+}
+
+// resolveSecrets replaces the references in cfg with the values they point to and keeps
+// the references in cfg.SecretRefs.
+func resolveSecrets(ctx context.Context, cfg *config.Config) error {
+	resolver := newSecretsResolver(*cfg)
+	var problems []error
+	for _, setting := range secretSettings(cfg) {
+		reference := *setting.value
+		if !secrets.IsReference(reference) {
+			continue
+		}
+		value, err := resolver.Resolve(ctx, reference)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", setting.name, err))
+			continue
+		}
+		if cfg.SecretRefs == nil {
+			cfg.SecretRefs = make(map[string]string)
+		}
+		cfg.SecretRefs[setting.name] = reference
+		*setting.value = value
+	}
+	return errors.Join(problems...)
+}
+
+// AuthToken is the current API_AUTH_TOKEN, which changes when the secret is rotated.
+func (r *Runtime) AuthToken() string {
+	return *r.authToken.Load()
+}
+
+// refreshSecrets resolves the references again every SECRETS_REFRESH_SECONDS. A rotated
+// OpenRouter key or API token applies to the next request; the database pool keeps the
+// credentials it connected with until the process restarts.
+func (r *Runtime) refreshSecrets(ctx context.Context) {
+	resolver := newSecretsResolver(r.Config)
+	ticker := time.NewTicker(time.Duration(r.Config.SecretsRefreshSeconds) * time.Second)
+	defer ticker.Stop()
+	current := make(map[string]string, len(r.Config.SecretRefs))
+	for _, setting := range secretSettings(&r.Config) {
+		current[setting.name] = *setting.value
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for name, reference := range r.Config.SecretRefs {
+			value, err := resolver.Resolve(ctx, reference)
+			if err != nil {
+				r.Logger.WarnContext(ctx, "refresh secret failed, keeping the current value",
+					slog.String("setting", name), slog.Any("error", err))
+				continue
+			}
+			if value == current[name] {
+				continue
+			}
+			switch name {
+			case "OPENROUTER_API_KEY":
+				r.AIClient.SetAPIKey(value)
+			case "API_AUTH_TOKEN":
+				r.authToken.Store(&value)
+			default:
+				r.Logger.WarnContext(ctx, "secret rotated, restart to apply it", slog.String("setting", name))
+				current[name] = value
+				continue
+			}
+			current[name] = value
+			r.Logger.InfoContext(ctx, "secret rotated", slog.String("setting", name))
+		}
+	}
+}
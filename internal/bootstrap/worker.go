@@ -0,0 +1,141 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/alerting"
+	"github.com/iago/extensao-whatsapp-back/internal/health"
+	"github.com/iago/extensao-whatsapp-back/internal/queue"
+	"github.com/iago/extensao-whatsapp-back/internal/scheduler"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+	"github.com/iago/extensao-whatsapp-back/internal/worker"
+)
+
+// NewProcessor builds the job processor with the configured timeouts, retry policies and
+// pool bounds.
+func (r *Runtime) NewProcessor() *worker.Processor {
+	processor := worker.NewProcessor(r.Consumer, r.Jobs, r.AIGeneration, r.Logger)
+	jobTimeouts, err := worker.ParseJobTimeouts(r.Config.JobTimeouts)
+	if err != nil {
+		Fatal(r.Logger, "invalid JOB_TIMEOUTS", err)
+	}
+	processor.UseTimeouts(jobTimeouts)
+	retryPolicies, err := worker.ParseRetryPolicies(r.Config.JobRetryPolicies)
+	if err != nil {
+		Fatal(r.Logger, "invalid JOB_RETRY_POLICIES", err)
+	}
+	processor.UseRetryPolicies(retryPolicies)
+	processor.UseProducer(r.Producer)
+	processor.UseMetrics(worker.NewMetrics(r.Registry))
+	processor.UseErrorReporter(r.ErrorReporter)
+	processor.UseConcurrency(worker.ConcurrencyConfig{
+		Min:            r.Config.WorkerConcurrencyMin,
+		Max:            r.Config.WorkerConcurrencyMax,
+		Interval:       time.Duration(r.Config.WorkerConcurrencyIntervalSeconds) * time.Second,
+		DepthPerWorker: int64(r.Config.WorkerConcurrencyDepthPerWorker),
+		TargetLatency:  time.Duration(r.Config.WorkerConcurrencyTargetLatencyMS) * time.Millisecond,
+	})
+	return processor
+}
+
+// StartDLQAlerts sends dead letters and DLQ_ALERT_THRESHOLD crossings of the consumer to
+// the configured webhooks; it does nothing when none is set. Call it before the processor
+// starts consuming.
+func (r *Runtime) StartDLQAlerts(ctx context.Context) {
+	cfg := r.Config
+	var notifiers alerting.Multi
+	if cfg.DLQAlertSlackWebhookURL != "" {
+		notifiers = append(notifiers, alerting.NewSlackNotifier(cfg.DLQAlertSlackWebhookURL, nil))
+	}
+	if cfg.DLQAlertWebhookURL != "" {
+		notifiers = append(notifiers, alerting.NewWebhookNotifier(cfg.DLQAlertWebhookURL, cfg.DLQAlertWebhookToken, nil))
+	}
+	if len(notifiers) == 0 {
+		return
+	}
+	sizes, _ := r.Consumer.(queue.DLQReporter)
+	monitor := alerting.NewDLQMonitor(notifiers, sizes, alerting.DLQConfig{
+		Environment: cfg.Environment,
+		Cooldown:    time.Duration(cfg.DLQAlertCooldownSeconds) * time.Second,
+		Threshold:   int64(cfg.DLQAlertThreshold),
+		Interval:    time.Duration(cfg.DLQAlertIntervalSeconds) * time.Second,
+	}, r.Logger)
+	if reporter, ok := r.Consumer.(queue.FailureReporter); ok {
+		reporter.OnFailure(monitor.OnFailure)
+	}
+	go monitor.Start(ctx)
+	r.Logger.Info("dead-letter alerts enabled", slog.Int("notifiers", len(notifiers)), slog.Int("threshold", cfg.DLQAlertThreshold))
+}
+
+// DrainProcessor gives the running jobs of processor WORKER_SHUTDOWN_GRACE_SECONDS to
+// finish; the context given to its Start must be cancelled already.
+func (r *Runtime) DrainProcessor(processor *worker.Processor) {
+	grace := time.Duration(r.Config.WorkerShutdownGraceSeconds) * time.Second
+	r.Logger.Info("draining worker", slog.String("grace", grace.String()))
+	if !processor.Drain(grace) {
+		r.Logger.Warn("worker grace period ended, running jobs released to the queue")
+	}
+}
+
+// Health checks the database, the queue and the AI provider.
+func (r *Runtime) Health() *health.Checker {
+	checks := make([]health.Check, 0, 4)
+	if r.Repos.Ping != nil {
+		checks = append(checks, health.Ping("postgres", r.Repos.Ping))
+	} else {
+		checks = append(checks, health.Disabled("postgres", "in-memory repository"))
+	}
+	if pinger, ok := r.Consumer.(interface{ Ping(context.Context) error }); ok {
+		checks = append(checks, health.Ping("redis", pinger.Ping))
+	} else {
+		checks = append(checks, health.Disabled("redis", "local queue"))
+	}
+	if depth, ok := r.Consumer.(queue.DepthReporter); ok {
+		checks = append(checks, health.QueueDepth("queue", depth.Depth, int64(r.Config.HealthQueueDepthWarn)))
+	}
+	if r.AIClient.Available() {
+		checks = append(checks, health.Ping("ai_provider", r.AIClient.Ping))
+	} else {
+		checks = append(checks, health.Disabled("ai_provider", "api key not configured, static fallbacks only"))
+	}
+	return health.NewChecker(health.CheckerConfig{}, checks...)
+}
+
+// StartScheduler starts firing the recurring jobs of SCHEDULES_FILE, if set. Any binary
+// may run it; the lease keeps a single replica firing.
+func (r *Runtime) StartScheduler(ctx context.Context, digests *service.DigestsService) {
+	cfg, logger := r.Config, r.Logger
+	if cfg.SchedulesFile == "" {
+		return
+	}
+	entries, err := scheduler.LoadFile(cfg.SchedulesFile)
+	if err != nil {
+		Fatal(logger, "invalid SCHEDULES_FILE", err)
+	}
+
+	hostname, _ := os.Hostname()
+	holder := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	recurring := scheduler.NewScheduler(r.Repos.Leases, holder, scheduler.Config{
+		LeaseTTL: time.Duration(cfg.SchedulerLeaseSeconds) * time.Second,
+		Interval: time.Duration(cfg.SchedulerIntervalSeconds) * time.Second,
+	}, logger)
+	recurring.RegisterTask("digest", func(ctx context.Context, run scheduler.Run) error {
+		locale := run.Locale
+		if locale == "" {
+			locale = "pt-BR"
+		}
+		_, err := digests.Request(ctx, service.DigestInput{TenantID: run.TenantID, From: run.From, To: run.At, Locale: locale})
+		return err
+	})
+	for _, entry := range entries {
+		if err := recurring.Add(entry); err != nil {
+			Fatal(logger, "invalid SCHEDULES_FILE", err)
+		}
+	}
+	go recurring.Start(ctx)
+	logger.Info("scheduler started", slog.Int("schedules", len(entries)), slog.String("holder", holder))
+}
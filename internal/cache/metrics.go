@@ -0,0 +1,35 @@
+package cache
+
+import "github.com/iago/extensao-whatsapp-back/internal/metrics"
+
+// Metrics records semantic cache lookups per task, so the hit rate is
+// hits / (hits + misses). A nil *Metrics records nothing.
+type Metrics struct {
+	lookups *metrics.CounterVec
+}
+
+// NewMetrics registers the lookup counter and the size of cache; a nil registry disables
+// them.
+func NewMetrics(registry *metrics.Registry, cache *SemanticCache) *Metrics {
+	if registry == nil {
+		return nil
+	}
+	registry.GaugeFunc("semantic_cache_entries", "Entries held by the semantic cache, expired ones included until read or evicted.", func() float64 {
+		return float64(cache.Len())
+	})
+	return &Metrics{
+		lookups: registry.Counter("semantic_cache_lookups_total", "Semantic cache lookups by task and result (hit or miss).", "task", "result"),
+	}
+}
+
+// Lookup records one cache lookup.
+func (m *Metrics) Lookup(task string, hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.lookups.Inc(task, result)
+}
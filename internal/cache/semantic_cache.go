@@ -14,8 +14,10 @@ type Entry struct {
 	Value         json.RawMessage
 	ModelID       string
 	PromptVersion string
-	CreatedAt     time.Time
-	ExpiresAt     time.Time
+	// Scope groups entries that must be purged together (e.g. a tenant conversation).
+	Scope     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
 }
 
 type Config struct {
@@ -31,19 +33,36 @@ type SemanticCache struct {
 }
 
 func NewSemanticCache(config Config) *SemanticCache {
+	cache := &SemanticCache{entries: make(map[string]Entry)}
+	cache.Reconfigure(config)
+	return cache
+}
+
+// Reconfigure changes the TTL and size of the cache. Entries already stored keep their
+// expiry; the oldest ones are dropped to fit a smaller size.
+func (c *SemanticCache) Reconfigure(config Config) {
 	if config.TTL <= 0 {
 		config.TTL = 15 * time.Minute
 	}
 	if config.MaxEntries <= 0 {
 		config.MaxEntries = 2000
 	}
-	return &SemanticCache{
-		entries:    make(map[string]Entry),
-		ttl:        config.TTL,
-		maxEntries: config.MaxEntries,
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = config.TTL
+	c.maxEntries = config.MaxEntries
+	if len(c.entries) > c.maxEntries {
+		c.evictOldest(len(c.entries) - c.maxEntries)
 	}
 }
 
+// Len returns how many entries are stored, counting expired ones not yet removed.
+func (c *SemanticCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
 func (c *SemanticCache) Get(signature string) (Entry, bool) {
 	c.mu.RLock()
 	entry, exists := c.entries[signature]
@@ -64,18 +83,54 @@ func (c *SemanticCache) Get(signature string) (Entry, bool) {
 func (c *SemanticCache) Set(signature string, entry Entry) {
 	now := time.Now().UTC()
 	entry.CreatedAt = now
-	entry.ExpiresAt = now.Add(c.ttl)
 	entry.Value = append([]byte(nil), entry.Value...)
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	entry.ExpiresAt = now.Add(c.ttl)
+
 	if len(c.entries) >= c.maxEntries {
-		c.evictOldest()
+		c.evictOldest(len(c.entries) - c.maxEntries + 1)
 	}
 	c.entries[signature] = entry
 }
 
+// DeleteScope removes every entry stored under the given scope and returns how many were purged.
+func (c *SemanticCache) DeleteScope(scope string) int {
+	if strings.TrimSpace(scope) == "" {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := 0
+	for signature, entry := range c.entries {
+		if entry.Scope != scope {
+			continue
+		}
+		delete(c.entries, signature)
+		purged++
+	}
+	return purged
+}
+
+// Flush removes every entry and returns how many were purged.
+func (c *SemanticCache) Flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := len(c.entries)
+	c.entries = make(map[string]Entry)
+	return purged
+}
+
+// ConversationScope builds the scope key used for entries derived from a single conversation.
+func ConversationScope(tenantID, conversationID string) string {
+	return strings.TrimSpace(tenantID) + "|" + strings.TrimSpace(conversationID)
+}
+
 func (c *SemanticCache) BuildSignature(parts ...string) string {
 	normalized := make([]string, 0, len(parts))
 	for _, part := range parts {
@@ -87,8 +142,9 @@ func (c *SemanticCache) BuildSignature(parts ...string) string {
 	return hex.EncodeToString(sum[:])
 }
 
-func (c *SemanticCache) evictOldest() {
-	if len(c.entries) == 0 {
+// evictOldest removes the count oldest entries.
+func (c *SemanticCache) evictOldest(count int) {
+	if len(c.entries) == 0 || count <= 0 {
 		return
 	}
 
@@ -103,7 +159,9 @@ func (c *SemanticCache) evictOldest() {
 	sort.Slice(pairs, func(i, j int) bool {
 		return pairs[i].value.CreatedAt.Before(pairs[j].value.CreatedAt)
 	})
-	delete(c.entries, pairs[0].key)
+	for _, oldest := range pairs[:min(count, len(pairs))] {
+		delete(c.entries, oldest.key)
+	}
 }
 
 func cloneEntry(entry Entry) Entry {
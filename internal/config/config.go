@@ -1,16 +1,38 @@
 package config
 
 import (
+	"fmt"
+	"net/url"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Config centralizes runtime settings for the API and workers.
 type Config struct {
+	// Environment is development, staging or production. It picks the profile defaults
+	// of unset variables, and production turns some Validate warnings into errors and
+	// fails instead of falling back to the in-memory repositories or local queue.
+	Environment string
+
 	Port string
 
 	AuthToken string
+	// JWTJWKSURL enables JWT authentication next to AuthToken; issuer and audience are
+	// enforced when set.
+	JWTJWKSURL          string
+	JWTIssuer           string
+	JWTAudience         string
+	JWTJWKSCacheSeconds int
+	// AdminToken protects the /admin namespace; with neither it nor JWT admins it is disabled.
+	AdminToken string
+	// RequestSigningSecrets is "tenant:secret,..."; those tenants must send X-Signature.
+	RequestSigningSecrets          string
+	RequestSigningToleranceSeconds int
+	RequestSigningRequired         bool
 
 	DatabaseURL string
 
@@ -26,6 +48,26 @@ type Config struct {
 	OpenRouterModelSummaryFallback    string
 	OpenRouterModelReportPrimary      string
 	OpenRouterModelReportFallback     string
+	OpenRouterModelPrices             []string
+
+	// QualityJudgeModel enables the LLM-as-judge stage with a cheap model; its grade
+	// weighs QualityJudgeWeight (0..1) of quality_score.
+	QualityJudgeModel     string
+	QualityJudgeWeight    float64
+	QualityJudgeTimeoutMS int
+	// QualityToxicityModel adds a model score to the toxicity wordlists of suggestions.
+	QualityToxicityModel     string
+	QualityToxicityTimeoutMS int
+	// SuggestionRecentReplies is how many accepted replies per conversation new
+	// suggestions must not repeat; 0 disables the check.
+	SuggestionRecentReplies int
+	// TenantSettingsCacheSeconds is how long a replica serves tenant settings before
+	// reading them again; changes made through this replica apply at once.
+	TenantSettingsCacheSeconds int
+	// UsageFlushSeconds is how often a replica adds its metered tenant usage to storage.
+	UsageFlushSeconds int
+	// SLOWindowHours is the rolling window of the latency objectives and their error budgets.
+	SLOWindowHours int
 
 	SemanticCacheTTLSeconds int
 	SemanticCacheMaxEntries int
@@ -38,11 +80,97 @@ type Config struct {
 	RedisDLQ      string
 	RedisGroup    string
 	RedisConsumer string
+	// RedisClaimIdleSeconds is how long an entry may stay unacked by a consumer before
+	// another one takes it over; running jobs renew their entries well within it.
+	RedisClaimIdleSeconds int
 
 	RateLimitRPS   float64
 	RateLimitBurst int
+	// RateLimitIPRPS and RateLimitIPBurst bound each client IP before authentication, so
+	// credential guessing is throttled too.
+	RateLimitIPRPS   float64
+	RateLimitIPBurst int
+	// RateLimitRoutes gives expensive routes their own budget: "POST /v1/suggestions=5:10".
+	RateLimitRoutes []string
 
 	CORSAllowedOrigins []string
+	// ConcurrencyMaxInFlight and ConcurrencyTenantMaxInFlight cap in-flight API requests
+	// (zero disables each); ConcurrencyQueueWaitMS is how long a request waits for a slot.
+	ConcurrencyMaxInFlight       int
+	ConcurrencyTenantMaxInFlight int
+	ConcurrencyQueueWaitMS       int
+	// RequestTimeoutMS bounds handler time; RequestTimeoutRoutes overrides it per route
+	// ("POST /v1/suggestions=8s"). Keep both below the server write timeout (15s).
+	RequestTimeoutMS     int
+	RequestTimeoutRoutes []string
+	// MaxBodyBytes caps request bodies; MaxBodyRoutes overrides it per route
+	// ("POST /v1/conversations/=4194304").
+	MaxBodyBytes  int64
+	MaxBodyRoutes []string
+	// TrustedProxies (IPs or CIDRs) may set the client IP via X-Forwarded-For/X-Real-IP.
+	TrustedProxies []string
+	// IPAllowlist/IPDenylist (IPs or CIDRs) gate every route but the health probes;
+	// IPTenantAllowlists narrows API key callers per tenant ("tenant-a=203.0.113.0/24|198.51.100.7").
+	IPAllowlist        []string
+	IPDenylist         []string
+	IPTenantAllowlists []string
+	// IdempotencyTTLHours is how long POST responses can be replayed by Idempotency-Key.
+	IdempotencyTTLHours int
+	// HSTSMaxAgeSeconds is sent on HTTPS responses; zero disables HSTS.
+	HSTSMaxAgeSeconds int
+	ReferrerPolicy    string
+
+	// OTelExporterEndpoint is the OTLP/HTTP collector base URL; empty disables tracing.
+	// OTelTracesSampleRatio samples new traces (0..1); continued traces keep their flag.
+	OTelExporterEndpoint  string
+	OTelServiceName       string
+	OTelTracesSampleRatio float64
+
+	// SentryDSN sends handler 5xx responses, panics and failed jobs to a Sentry-compatible
+	// tracker; empty disables error reporting. SentryEnvironment defaults to APP_ENV.
+	SentryDSN         string
+	SentryEnvironment string
+	SentryRelease     string
+
+	// AuditLogFile sends the audit trail to an append-only JSON lines file instead of the
+	// audit_log table (or memory).
+	AuditLogFile string
+
+	// LogLevel is debug, info, warn or error; LogFormat is json or text.
+	LogLevel  string
+	LogFormat string
+	// AICallLogSampleRate is the fraction (0..1) of successful provider calls and cache
+	// hits logged as "ai call" events; failed calls are always logged.
+	AICallLogSampleRate float64
+
+	// BlockedKeywordsFile is a JSON keyword list ({"default": [...], "tenants": {...}})
+	// replacing the built-in one; policy files are re-read every PolicyReloadSeconds.
+	BlockedKeywordsFile string
+	// PIIRulesFile sets the PII categories to mask and the allowlisted email domains and
+	// phones, by default and per tenant.
+	PIIRulesFile string
+	// ToneLexiconsFile sets formal and informal terms and forbidden and required phrases
+	// checked in generated replies, by default and per tenant.
+	ToneLexiconsFile string
+	// ProfanityFile adds words to the built-in profanity dictionaries and picks replace or
+	// reject, by default and per tenant.
+	ProfanityFile       string
+	PolicyReloadSeconds int
+	// HITLApprovalTenants are regulated tenants whose generated job results wait for a
+	// human approve, edit or reject decision.
+	HITLApprovalTenants []string
+
+	// TLSCertFile/TLSKeyFile make the API serve HTTPS itself; TLSClientCAFile turns on
+	// client certificate verification (TLSClientAuth: require or optional).
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+	TLSClientAuth   string
+
+	// MetricsEnabled serves Prometheus metrics at /metrics; MetricsPort moves them to their
+	// own listener so they are not exposed on the public port.
+	MetricsEnabled bool
+	MetricsPort    string
 
 	QueueBatchingEnabled     bool
 	QueueBatchSize           int
@@ -50,21 +178,169 @@ type Config struct {
 	QueueBatchFlushTimeoutMS int
 	QueueBatchQueueCapacity  int
 	QueueBatchMaxInFlight    int
+	// QueuePriorityWeight is how many high priority jobs workers take for each low
+	// priority one while both are waiting.
+	QueuePriorityWeight int
 
+	// WorkerEnabled runs the worker inside the API process (combined mode). cmd/worker runs
+	// it on its own and serves health checks and metrics on WorkerPort.
 	WorkerEnabled bool
+	WorkerPort    string
+	// WorkerShutdownGraceSeconds is how long running jobs may finish on shutdown before
+	// they are cancelled and released back to the queue.
+	WorkerShutdownGraceSeconds int
+	// WorkerConcurrencyMin and WorkerConcurrencyMax bound how many jobs a worker runs at
+	// once. Between them the pool grows while the queue holds more than
+	// WorkerConcurrencyDepthPerWorker jobs per running worker, and shrinks when it is
+	// empty or jobs take longer than WorkerConcurrencyTargetLatencyMS on average.
+	WorkerConcurrencyMin             int
+	WorkerConcurrencyMax             int
+	WorkerConcurrencyIntervalSeconds int
+	WorkerConcurrencyDepthPerWorker  int
+	WorkerConcurrencyTargetLatencyMS int
+	// JobTimeouts are "kind=duration" deadlines per job kind (summary=30s, report=60s and
+	// digest=90s by default); timed-out attempts are retried.
+	JobTimeouts []string
+	// JobRetryPolicies are "kind=base/max" exponential backoffs for failed jobs
+	// (default=500ms/30s and digest=2s/2m by default).
+	JobRetryPolicies []string
+
+	// HealthQueueDepthWarn marks the queue as degraded in /healthz once this many messages
+	// are waiting.
+	HealthQueueDepthWarn int
+	// DLQAlertSlackWebhookURL and DLQAlertWebhookURL receive an alert when a job moves to
+	// the dead-letter queue (at most one per DLQAlertCooldownSeconds, counting the rest)
+	// and when it reaches DLQAlertThreshold messages, checked every
+	// DLQAlertIntervalSeconds; a threshold of zero turns the size checks off.
+	DLQAlertSlackWebhookURL string
+	DLQAlertWebhookURL      string
+	DLQAlertWebhookToken    string
+	DLQAlertCooldownSeconds int
+	DLQAlertThreshold       int
+	DLQAlertIntervalSeconds int
+	// ShutdownDrainSeconds keeps serving after /readyz turns not ready on shutdown, giving
+	// load balancers time to stop routing traffic here.
+	ShutdownDrainSeconds int
+
+	// SchedulesFile lists recurring jobs (cron, task, tenants); empty disables the
+	// scheduler. Replicas elect the one that fires them through a lease.
+	SchedulesFile            string
+	SchedulerLeaseSeconds    int
+	SchedulerIntervalSeconds int
+
+	ArchiveEnabled         bool
+	ArchiveAfterDays       int
+	ArchiveIntervalSeconds int
+	ArchiveBatchSize       int
+	ArchiveBackend         string
+	ArchiveDir             string
+	ArchiveS3Endpoint      string
+	ArchiveS3Region        string
+	ArchiveS3Bucket        string
+	ArchiveS3AccessKey     string
+	ArchiveS3SecretKey     string
+	ArchiveS3PathStyle     bool
+
+	// EncryptionKeys is a keyring "kid:base64key,..." (first is primary); EncryptionKMSKeyID
+	// takes precedence and wraps data keys with AWS KMS instead.
+	EncryptionKeys        string
+	EncryptionKMSKeyID    string
+	EncryptionKMSRegion   string
+	EncryptionKMSEndpoint string
+	AWSAccessKeyID        string
+	AWSSecretAccessKey    string
+
+	// OPENROUTER_API_KEY, DATABASE_URL and API_AUTH_TOKEN may be a secrets backend
+	// reference (vault:<mount>/<path>#<key> or awssm:<secret-id>[#<key>]), resolved at
+	// startup and again every SecretsRefreshSeconds when positive.
+	VaultAddr             string
+	VaultToken            string
+	VaultNamespace        string
+	SecretsAWSRegion      string
+	SecretsAWSEndpoint    string
+	SecretsRefreshSeconds int
+	// SecretRefs holds the references resolved at startup, keyed by variable name.
+	SecretRefs map[string]string
+
+	// RemoteConfigURL (http(s):// or s3://bucket/key) serves an overlay of variables that
+	// is polled every RemoteConfigIntervalSeconds and outranks the environment.
+	RemoteConfigURL             string
+	RemoteConfigIntervalSeconds int
+	RemoteConfigAuthToken       string
+	RemoteConfigS3Endpoint      string
+	RemoteConfigS3Region        string
+
+	// unparsed lists the variables Load could not parse and replaced with their default;
+	// Validate reports them.
+	unparsed []string
+	// sources maps each variable Load read to where its value came from.
+	sources map[string]string
+}
+
+// SourceEnv, SourceProfile, SourceDefault and SourceRemote are where a setting came from when not from
+// a .env file, which is reported by its path.
+const (
+	SourceEnv     = "env"
+	SourceProfile = "profile"
+	SourceDefault = "default"
+	// SourceRemote marks the settings taken from the REMOTE_CONFIG_URL overlay.
+	SourceRemote = "remote"
+)
+
+// profiles replace the built-in defaults of unset variables per APP_ENV. Staging runs on
+// the built-in defaults; production also tightens Validate.
+var profiles = map[string]map[string]string{
+	"development": {
+		"LOG_LEVEL": "debug",
+	},
+	"production": {
+		"OTEL_TRACES_SAMPLER_ARG": "0.1",
+	},
 }
 
+var (
+	// loadMu serializes Load, which collects unparsable variables in unparsed and where
+	// each variable came from in sources.
+	loadMu   sync.Mutex
+	unparsed []string
+	sources  map[string]string
+	// profile holds the defaults of the APP_ENV being loaded.
+	profile map[string]string
+)
+
+// Load reads the settings from the environment. Values that do not parse fall back to
+// their default; call Validate to reject them.
 func Load() Config {
-	return Config{
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	unparsed = nil
+	sources = make(map[string]string)
+	profile = nil
+	environment := strings.ToLower(strings.TrimSpace(getEnv("APP_ENV", "development")))
+	profile = profiles[environment]
+
+	cfg := Config{
+		Environment: environment,
+
 		Port: getEnv("PORT", "8080"),
 
-		AuthToken: getEnv("API_AUTH_TOKEN", ""),
+		AuthToken:  getEnv("API_AUTH_TOKEN", ""),
+		AdminToken: getEnv("ADMIN_AUTH_TOKEN", ""),
+
+		JWTJWKSURL:          getEnv("JWT_JWKS_URL", ""),
+		JWTIssuer:           getEnv("JWT_ISSUER", ""),
+		JWTAudience:         getEnv("JWT_AUDIENCE", ""),
+		JWTJWKSCacheSeconds: getEnvDuration("JWT_JWKS_CACHE_SECONDS", 300, time.Second),
+
+		RequestSigningSecrets:          getEnv("REQUEST_SIGNING_SECRETS", ""),
+		RequestSigningToleranceSeconds: getEnvDuration("REQUEST_SIGNING_TOLERANCE_SECONDS", 300, time.Second),
+		RequestSigningRequired:         getEnvBool("REQUEST_SIGNING_REQUIRED", false),
 
 		DatabaseURL: getEnv("DATABASE_URL", ""),
 
 		OpenRouterAPIKey:                  getEnvOr("OPENROUTER_API_KEY", getEnv("OPENAI_API_KEY", "")),
 		OpenRouterBaseURL:                 getEnvOr("OPENROUTER_BASE_URL", getEnv("OPENAI_BASE_URL", "https://openrouter.ai/api/v1")),
-		OpenRouterTimeoutMS:               getEnvIntOr("OPENROUTER_TIMEOUT_MS", getEnvInt("OPENAI_TIMEOUT_MS", 15000)),
+		OpenRouterTimeoutMS:               getEnvDuration("OPENROUTER_TIMEOUT_MS", getEnvDuration("OPENAI_TIMEOUT_MS", 15000, time.Millisecond), time.Millisecond),
 		OpenRouterMaxRetries:              getEnvIntOr("OPENROUTER_MAX_RETRIES", getEnvInt("OPENAI_MAX_RETRIES", 2)),
 		OpenRouterSiteURL:                 getEnv("OPENROUTER_SITE_URL", ""),
 		OpenRouterAppName:                 getEnv("OPENROUTER_APP_NAME", "WA Copilot"),
@@ -74,37 +350,266 @@ func Load() Config {
 		OpenRouterModelSummaryFallback:    getEnvOr("OPENROUTER_MODEL_SUMMARY_FALLBACK", getEnv("OPENAI_MODEL_SUMMARY_FALLBACK", "openai/gpt-4o-mini")),
 		OpenRouterModelReportPrimary:      getEnvOr("OPENROUTER_MODEL_REPORT_PRIMARY", getEnv("OPENAI_MODEL_REPORT_PRIMARY", "openai/gpt-4o-mini")),
 		OpenRouterModelReportFallback:     getEnvOr("OPENROUTER_MODEL_REPORT_FALLBACK", getEnv("OPENAI_MODEL_REPORT_FALLBACK", "openai/gpt-4o-mini")),
+		OpenRouterModelPrices:             getEnvCSV("OPENROUTER_MODEL_PRICES", nil),
+
+		QualityJudgeModel:        getEnv("QUALITY_JUDGE_MODEL", ""),
+		QualityJudgeWeight:       getEnvFloat("QUALITY_JUDGE_WEIGHT", 0.3),
+		QualityJudgeTimeoutMS:    getEnvDuration("QUALITY_JUDGE_TIMEOUT_MS", 4000, time.Millisecond),
+		QualityToxicityModel:     getEnv("QUALITY_TOXICITY_MODEL", ""),
+		QualityToxicityTimeoutMS: getEnvDuration("QUALITY_TOXICITY_TIMEOUT_MS", 3000, time.Millisecond),
+		SuggestionRecentReplies:  getEnvInt("SUGGESTION_RECENT_REPLIES", 5),
 
-		SemanticCacheTTLSeconds: getEnvInt("SEMANTIC_CACHE_TTL_SECONDS", 900),
+		TenantSettingsCacheSeconds: getEnvDuration("TENANT_SETTINGS_CACHE_SECONDS", 60, time.Second),
+		UsageFlushSeconds:          getEnvDuration("USAGE_FLUSH_SECONDS", 30, time.Second),
+		SLOWindowHours:             getEnvDuration("SLO_WINDOW_HOURS", 24, time.Hour),
+
+		SemanticCacheTTLSeconds: getEnvDuration("SEMANTIC_CACHE_TTL_SECONDS", 900, time.Second),
 		SemanticCacheMaxEntries: getEnvInt("SEMANTIC_CACHE_MAX_ENTRIES", 2000),
 		PromptsDir:              getEnv("PROMPTS_DIR", "prompts"),
 
-		RedisAddr:     getEnv("REDIS_ADDR", ""),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-		RedisDB:       getEnvInt("REDIS_DB", 0),
-		RedisStream:   getEnv("REDIS_STREAM", "wa_jobs"),
-		RedisDLQ:      getEnv("REDIS_DLQ_STREAM", "wa_jobs_dlq"),
-		RedisGroup:    getEnv("REDIS_GROUP", "wa_workers"),
-		RedisConsumer: getEnv("REDIS_CONSUMER", "api-1"),
+		RedisAddr:             getEnv("REDIS_ADDR", ""),
+		RedisPassword:         getEnv("REDIS_PASSWORD", ""),
+		RedisDB:               getEnvInt("REDIS_DB", 0),
+		RedisStream:           getEnv("REDIS_STREAM", "wa_jobs"),
+		RedisDLQ:              getEnv("REDIS_DLQ_STREAM", "wa_jobs_dlq"),
+		RedisGroup:            getEnv("REDIS_GROUP", "wa_workers"),
+		RedisConsumer:         getEnv("REDIS_CONSUMER", "api-1"),
+		RedisClaimIdleSeconds: getEnvDuration("REDIS_CLAIM_IDLE_SECONDS", 60, time.Second),
+
+		RateLimitRPS:     getEnvFloat("RATE_LIMIT_RPS", 20),
+		RateLimitBurst:   getEnvInt("RATE_LIMIT_BURST", 40),
+		RateLimitIPRPS:   getEnvFloat("RATE_LIMIT_IP_RPS", 50),
+		RateLimitIPBurst: getEnvInt("RATE_LIMIT_IP_BURST", 100),
+		RateLimitRoutes: getEnvCSV("RATE_LIMIT_ROUTES", []string{
+			"POST /v1/suggestions=5:10",
+			"POST /v2/suggestions=5:10",
+			"POST /v1/analysis=5:10",
+			"POST /v2/analysis=5:10",
+			"POST /v1/questions=5:10",
+			"POST /v1/action-items=5:10",
+			"POST /v1/compose=5:10",
+			"POST /v1/summaries=1:5",
+			"POST /v1/reports=1:5",
+			"POST /v1/digests=0.2:3",
+		}),
+
+		CORSAllowedOrigins:  getEnvCSV("CORS_ALLOWED_ORIGINS", []string{"https://web.whatsapp.com"}),
+		TrustedProxies:      getEnvCSV("TRUSTED_PROXIES", nil),
+		IPAllowlist:         getEnvCSV("IP_ALLOWLIST", nil),
+		IPDenylist:          getEnvCSV("IP_DENYLIST", nil),
+		IPTenantAllowlists:  getEnvCSV("IP_TENANT_ALLOWLISTS", nil),
+		IdempotencyTTLHours: getEnvDuration("IDEMPOTENCY_TTL_HOURS", 24, time.Hour),
+		HSTSMaxAgeSeconds:   getEnvDuration("HSTS_MAX_AGE_SECONDS", 31536000, time.Second),
+		ReferrerPolicy:      getEnv("REFERRER_POLICY", "no-referrer"),
+
+		ConcurrencyMaxInFlight:       getEnvInt("CONCURRENCY_MAX_IN_FLIGHT", 128),
+		ConcurrencyTenantMaxInFlight: getEnvInt("CONCURRENCY_TENANT_MAX_IN_FLIGHT", 16),
+		ConcurrencyQueueWaitMS:       getEnvDuration("CONCURRENCY_QUEUE_WAIT_MS", 250, time.Millisecond),
+
+		RequestTimeoutMS: getEnvDuration("REQUEST_TIMEOUT_MS", 12000, time.Millisecond),
+		RequestTimeoutRoutes: getEnvCSV("REQUEST_TIMEOUT_ROUTES", []string{
+			"POST /v1/suggestions=8s",
+			"POST /v2/suggestions=8s",
+			"GET /v1/jobs/=3s",
+		}),
+
+		MaxBodyBytes:  int64(getEnvInt("MAX_BODY_BYTES", 1<<20)),
+		MaxBodyRoutes: getEnvCSV("MAX_BODY_ROUTES", []string{"POST /v1/conversations/=4194304"}),
+
+		OTelExporterEndpoint:  getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTelServiceName:       getEnv("OTEL_SERVICE_NAME", "wa-copilot-api"),
+		OTelTracesSampleRatio: getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+
+		SentryDSN:         getEnv("SENTRY_DSN", ""),
+		SentryEnvironment: getEnv("SENTRY_ENVIRONMENT", environment),
+		SentryRelease:     getEnv("SENTRY_RELEASE", ""),
+
+		AuditLogFile: getEnv("AUDIT_LOG_FILE", ""),
 
-		RateLimitRPS:   getEnvFloat("RATE_LIMIT_RPS", 20),
-		RateLimitBurst: getEnvInt("RATE_LIMIT_BURST", 40),
+		LogLevel:            getEnv("LOG_LEVEL", "info"),
+		LogFormat:           getEnv("LOG_FORMAT", "json"),
+		AICallLogSampleRate: getEnvFloat("AI_CALL_LOG_SAMPLE_RATE", 1),
 
-		CORSAllowedOrigins: getEnvCSV("CORS_ALLOWED_ORIGINS", []string{"https://web.whatsapp.com"}),
+		BlockedKeywordsFile: getEnv("BLOCKED_KEYWORDS_FILE", ""),
+		PIIRulesFile:        getEnv("PII_RULES_FILE", ""),
+		ToneLexiconsFile:    getEnv("TONE_LEXICONS_FILE", ""),
+		ProfanityFile:       getEnv("PROFANITY_FILE", ""),
+		PolicyReloadSeconds: getEnvDuration("POLICY_RELOAD_SECONDS", 30, time.Second),
+		HITLApprovalTenants: getEnvCSV("HITL_APPROVAL_TENANTS", nil),
+
+		TLSCertFile:     getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:      getEnv("TLS_KEY_FILE", ""),
+		TLSClientCAFile: getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSClientAuth:   getEnv("TLS_CLIENT_AUTH", ""),
+
+		MetricsEnabled: getEnvBool("METRICS_ENABLED", true),
+		MetricsPort:    getEnv("METRICS_PORT", ""),
 
 		QueueBatchingEnabled:     getEnvBool("QUEUE_BATCHING_ENABLED", true),
 		QueueBatchSize:           getEnvInt("QUEUE_BATCH_SIZE", 32),
-		QueueBatchFlushMS:        getEnvInt("QUEUE_BATCH_FLUSH_MS", 25),
-		QueueBatchFlushTimeoutMS: getEnvInt("QUEUE_BATCH_FLUSH_TIMEOUT_MS", 3000),
+		QueueBatchFlushMS:        getEnvDuration("QUEUE_BATCH_FLUSH_MS", 25, time.Millisecond),
+		QueueBatchFlushTimeoutMS: getEnvDuration("QUEUE_BATCH_FLUSH_TIMEOUT_MS", 3000, time.Millisecond),
 		QueueBatchQueueCapacity:  getEnvInt("QUEUE_BATCH_QUEUE_CAPACITY", 2048),
 		QueueBatchMaxInFlight:    getEnvInt("QUEUE_BATCH_MAX_IN_FLIGHT", 4),
+		QueuePriorityWeight:      getEnvInt("QUEUE_PRIORITY_WEIGHT", 4),
+
+		WorkerEnabled:                    getEnvBool("WORKER_ENABLED", true),
+		WorkerPort:                       getEnv("WORKER_PORT", "8081"),
+		WorkerShutdownGraceSeconds:       getEnvDuration("WORKER_SHUTDOWN_GRACE_SECONDS", 25, time.Second),
+		WorkerConcurrencyMin:             getEnvInt("WORKER_CONCURRENCY_MIN", 1),
+		WorkerConcurrencyMax:             getEnvInt("WORKER_CONCURRENCY_MAX", 1),
+		WorkerConcurrencyIntervalSeconds: getEnvDuration("WORKER_CONCURRENCY_INTERVAL_SECONDS", 5, time.Second),
+		WorkerConcurrencyDepthPerWorker:  getEnvInt("WORKER_CONCURRENCY_DEPTH_PER_WORKER", 10),
+		WorkerConcurrencyTargetLatencyMS: getEnvDuration("WORKER_CONCURRENCY_TARGET_LATENCY_MS", 20000, time.Millisecond),
+		JobTimeouts:                      getEnvCSV("JOB_TIMEOUTS", nil),
+		JobRetryPolicies:                 getEnvCSV("JOB_RETRY_POLICIES", nil),
+
+		HealthQueueDepthWarn: getEnvInt("HEALTH_QUEUE_DEPTH_WARN", 1000),
+		ShutdownDrainSeconds: getEnvDuration("SHUTDOWN_DRAIN_SECONDS", 5, time.Second),
+
+		DLQAlertSlackWebhookURL: getEnv("DLQ_ALERT_SLACK_WEBHOOK_URL", ""),
+		DLQAlertWebhookURL:      getEnv("DLQ_ALERT_WEBHOOK_URL", ""),
+		DLQAlertWebhookToken:    getEnv("DLQ_ALERT_WEBHOOK_TOKEN", ""),
+		DLQAlertCooldownSeconds: getEnvDuration("DLQ_ALERT_COOLDOWN_SECONDS", 300, time.Second),
+		DLQAlertThreshold:       getEnvInt("DLQ_ALERT_THRESHOLD", 100),
+		DLQAlertIntervalSeconds: getEnvDuration("DLQ_ALERT_INTERVAL_SECONDS", 60, time.Second),
+
+		SchedulesFile:            getEnv("SCHEDULES_FILE", ""),
+		SchedulerLeaseSeconds:    getEnvDuration("SCHEDULER_LEASE_SECONDS", 60, time.Second),
+		SchedulerIntervalSeconds: getEnvDuration("SCHEDULER_INTERVAL_SECONDS", 15, time.Second),
 
-		WorkerEnabled: getEnvBool("WORKER_ENABLED", true),
+		ArchiveEnabled:         getEnvBool("ARCHIVE_ENABLED", false),
+		ArchiveAfterDays:       getEnvDuration("ARCHIVE_AFTER_DAYS", 30, day),
+		ArchiveIntervalSeconds: getEnvDuration("ARCHIVE_INTERVAL_SECONDS", 3600, time.Second),
+		ArchiveBatchSize:       getEnvInt("ARCHIVE_BATCH_SIZE", 100),
+		ArchiveBackend:         getEnv("ARCHIVE_BACKEND", "fs"),
+		ArchiveDir:             getEnv("ARCHIVE_DIR", "data/archive"),
+		ArchiveS3Endpoint:      getEnv("ARCHIVE_S3_ENDPOINT", ""),
+		ArchiveS3Region:        getEnv("ARCHIVE_S3_REGION", "us-east-1"),
+		ArchiveS3Bucket:        getEnv("ARCHIVE_S3_BUCKET", ""),
+		ArchiveS3AccessKey:     getEnv("ARCHIVE_S3_ACCESS_KEY", ""),
+		ArchiveS3SecretKey:     getEnv("ARCHIVE_S3_SECRET_KEY", ""),
+		ArchiveS3PathStyle:     getEnvBool("ARCHIVE_S3_PATH_STYLE", true),
+
+		EncryptionKeys:        getEnv("ENCRYPTION_KEYS", ""),
+		EncryptionKMSKeyID:    getEnv("ENCRYPTION_KMS_KEY_ID", ""),
+		EncryptionKMSRegion:   getEnv("ENCRYPTION_KMS_REGION", getEnv("AWS_REGION", "us-east-1")),
+		EncryptionKMSEndpoint: getEnv("ENCRYPTION_KMS_ENDPOINT", ""),
+		AWSAccessKeyID:        getEnv("AWS_ACCESS_KEY_ID", ""),
+		AWSSecretAccessKey:    getEnv("AWS_SECRET_ACCESS_KEY", ""),
+
+		VaultAddr:             getEnv("VAULT_ADDR", ""),
+		VaultToken:            getEnv("VAULT_TOKEN", ""),
+		VaultNamespace:        getEnv("VAULT_NAMESPACE", ""),
+		SecretsAWSRegion:      getEnv("SECRETS_AWS_REGION", getEnv("AWS_REGION", "us-east-1")),
+		SecretsAWSEndpoint:    getEnv("SECRETS_AWS_ENDPOINT", ""),
+		SecretsRefreshSeconds: getEnvDuration("SECRETS_REFRESH_SECONDS", 0, time.Second),
+
+		RemoteConfigURL:             getEnv("REMOTE_CONFIG_URL", ""),
+		RemoteConfigIntervalSeconds: getEnvDuration("REMOTE_CONFIG_INTERVAL_SECONDS", 60, time.Second),
+		RemoteConfigAuthToken:       getEnv("REMOTE_CONFIG_AUTH_TOKEN", ""),
+		RemoteConfigS3Endpoint:      getEnv("REMOTE_CONFIG_S3_ENDPOINT", ""),
+		RemoteConfigS3Region:        getEnv("REMOTE_CONFIG_S3_REGION", getEnv("AWS_REGION", "us-east-1")),
 	}
+	cfg.unparsed = unparsed
+	cfg.sources = sources
+	return cfg
 }
 
-func getEnv(key, fallback string) string {
+// Sources returns, keyed by variable name, whether each setting came from the process
+// environment, a .env file (by path) or the default. Alias variables such as
+// OPENAI_API_KEY are listed next to the ones they stand in for.
+func (c Config) Sources() map[string]string {
+	result := make(map[string]string, len(c.sources))
+	for key, source := range c.sources {
+		result[key] = source
+	}
+	return result
+}
+
+// Production reports whether APP_ENV is production.
+func (c Config) Production() bool {
+	return c.Environment == "production"
+}
+
+const redactedValue = "[redacted]"
+
+// Snapshot returns the settings keyed by field name with credentials redacted, for the
+// admin API.
+func (c Config) Snapshot() map[string]any {
+	value := reflect.ValueOf(c)
+	fields := value.Type()
+	snapshot := make(map[string]any, fields.NumField())
+	for index := 0; index < fields.NumField(); index++ {
+		if !fields.Field(index).IsExported() {
+			continue
+		}
+		name := fields.Field(index).Name
+		field := value.Field(index).Interface()
+		switch {
+		case name == "DatabaseURL":
+			snapshot[name] = redactURL(c.DatabaseURL)
+		case name == "SecretRefs":
+			// References name where a secret lives, not the secret itself.
+			snapshot[name] = c.SecretRefs
+		case isSecretSetting(name):
+			if text, ok := field.(string); ok && text == "" {
+				snapshot[name] = ""
+			} else {
+				snapshot[name] = redactedValue
+			}
+		default:
+			snapshot[name] = field
+		}
+	}
+	return snapshot
+}
+
+func isSecretSetting(name string) bool {
+	// Webhook URLs carry their credential in the path, DSNs in the user.
+	for _, marker := range []string{"Token", "Key", "Secret", "Password", "Webhook", "DSN"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme == "" {
+		// Keyword/value DSNs ("host=... password=...") are not URLs.
+		return redactedValue
+	}
+	return parsed.Redacted()
+}
+
+// lookupEnv reads key, from the remote overlay when it sets it, and records its source;
+// blank values count as unset and take the profile default when there is one.
+func lookupEnv(key string) string {
+	if value, ok := remoteOverlay[key]; ok {
+		sources[key] = SourceRemote
+		return value
+	}
 	value := os.Getenv(key)
+	source := SourceEnv
+	if strings.TrimSpace(value) == "" {
+		source = SourceDefault
+		if preset, ok := profile[key]; ok {
+			value, source = preset, SourceProfile
+		}
+	} else if file := dotEnvFile(key); file != "" {
+		source = file
+	}
+	sources[key] = source
+	return value
+}
+
+func getEnv(key, fallback string) string {
+	value := lookupEnv(key)
 	if value == "" {
 		return fallback
 	}
@@ -112,63 +617,111 @@ func getEnv(key, fallback string) string {
 }
 
 func getEnvInt(key string, fallback int) int {
-	value := os.Getenv(key)
+	value := lookupEnv(key)
 	if value == "" {
 		return fallback
 	}
-	parsed, err := strconv.Atoi(value)
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
 	if err != nil {
+		invalidSetting(key, value, "an integer")
 		return fallback
 	}
 	return parsed
 }
 
 func getEnvFloat(key string, fallback float64) float64 {
-	value := os.Getenv(key)
+	value := lookupEnv(key)
 	if value == "" {
 		return fallback
 	}
-	parsed, err := strconv.ParseFloat(value, 64)
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
 	if err != nil {
+		invalidSetting(key, value, "a number")
 		return fallback
 	}
 	return parsed
 }
 
 func getEnvBool(key string, fallback bool) bool {
-	value := os.Getenv(key)
+	value := lookupEnv(key)
 	if value == "" {
 		return fallback
 	}
-	parsed, err := strconv.ParseBool(value)
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
 	if err != nil {
+		invalidSetting(key, value, "a boolean")
 		return fallback
 	}
 	return parsed
 }
 
 func getEnvOr(primary string, fallback string) string {
-	value := strings.TrimSpace(os.Getenv(primary))
+	value := strings.TrimSpace(lookupEnv(primary))
 	if value != "" {
 		return value
 	}
 	return fallback
 }
 
+const day = 24 * time.Hour
+
+// getEnvDuration reads a time setting kept as a count of unit (the _MS, _SECONDS, _HOURS
+// or _DAYS suffix of key): a Go duration such as "15s" or "1m30s", or a bare integer
+// in unit as before.
+func getEnvDuration(key string, fallback int, unit time.Duration) int {
+	value := strings.TrimSpace(lookupEnv(key))
+	if value == "" {
+		return fallback
+	}
+	if parsed, err := strconv.Atoi(value); err == nil {
+		return parsed
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		invalidSetting(key, value, "a duration (e.g. 15s, 250ms) or an integer of "+unitName(unit))
+		return fallback
+	}
+	if duration%unit != 0 {
+		invalidSetting(key, value, "a whole number of "+unitName(unit))
+		return fallback
+	}
+	return int(duration / unit)
+}
+
+func unitName(unit time.Duration) string {
+	switch unit {
+	case time.Millisecond:
+		return "milliseconds"
+	case time.Second:
+		return "seconds"
+	case time.Hour:
+		return "hours"
+	case day:
+		return "days"
+	default:
+		return unit.String()
+	}
+}
+
 func getEnvIntOr(primary string, fallback int) int {
-	value := strings.TrimSpace(os.Getenv(primary))
+	value := strings.TrimSpace(lookupEnv(primary))
 	if value == "" {
 		return fallback
 	}
 	parsed, err := strconv.Atoi(value)
 	if err != nil {
+		invalidSetting(primary, value, "an integer")
 		return fallback
 	}
 	return parsed
 }
 
+func invalidSetting(key, value, expected string) {
+	unparsed = append(unparsed, fmt.Sprintf("%s=%q is not %s", key, value, expected))
+}
+
 func getEnvCSV(key string, fallback []string) []string {
-	value := strings.TrimSpace(os.Getenv(key))
+	value := strings.TrimSpace(lookupEnv(key))
 	if value == "" {
 		return append([]string(nil), fallback...)
 	}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateAcceptsTheDefaults(t *testing.T) {
+	warnings, err := Load().Validate()
+	if err != nil || len(warnings) > 0 {
+		t.Fatalf("expected the defaults to be valid, got %v %v", warnings, err)
+	}
+}
+
+func TestValidateRejectsInvalidSettings(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("OPENROUTER_TIMEOUT_MS", "15 seconds")
+	t.Setenv("REDIS_CLAIM_IDLE_SECONDS", "1500ms")
+	t.Setenv("OPENROUTER_BASE_URL", "openrouter.ai/api/v1")
+	t.Setenv("REQUEST_TIMEOUT_MS", "-1")
+	t.Setenv("REDIS_DLQ_STREAM", "wa_jobs")
+	t.Setenv("WORKER_ENABLED", "false")
+	t.Setenv("WORKER_CONCURRENCY_MIN", "4")
+	t.Setenv("WORKER_CONCURRENCY_MAX", "2")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://web.whatsapp.com,*")
+	t.Setenv("REMOTE_CONFIG_URL", "s3://config-bucket")
+	t.Setenv("AI_CALL_LOG_SAMPLE_RATE", "1.5")
+	t.Setenv("SENTRY_DSN", "https://o1.ingest.sentry.io/42")
+
+	warnings, err := Load().Validate()
+	if err == nil {
+		t.Fatal("expected invalid settings to be rejected")
+	}
+	for _, expected := range []string{
+		`OPENROUTER_TIMEOUT_MS="15 seconds" is not a duration (e.g. 15s, 250ms) or an integer of milliseconds`,
+		`REDIS_CLAIM_IDLE_SECONDS="1500ms" is not a whole number of seconds`,
+		"OPENROUTER_BASE_URL must be an absolute http(s) URL",
+		"REQUEST_TIMEOUT_MS must be positive, got -1",
+		"REDIS_STREAM and REDIS_DLQ_STREAM must differ",
+		"WORKER_ENABLED=false requires REDIS_ADDR",
+		"WORKER_CONCURRENCY_MIN must be at least 1 and at most WORKER_CONCURRENCY_MAX",
+		"API_AUTH_TOKEN or JWT_JWKS_URL is required in production",
+		"DATABASE_URL is not set; jobs and messages are kept in memory; not allowed in production",
+		"REDIS_ADDR is not set; jobs go to the local in-process queue; not allowed in production",
+		"CORS_ALLOWED_ORIGINS allows any origin (*); not allowed in production",
+		`REMOTE_CONFIG_URL must be an http(s):// URL or s3://bucket/key, got "s3://config-bucket"`,
+		"AI_CALL_LOG_SAMPLE_RATE must be between 0 and 1, got 1.5",
+		"SENTRY_DSN must look like https://<key>@<host>/<project>",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected %q in:\n%v", expected, err)
+		}
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected the public /metrics and missing admin token warnings, got %v", warnings)
+	}
+}
+
+func TestProfilesSetDefaultsAndStagingOnlyWarns(t *testing.T) {
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	warnings, err := Load().Validate()
+	if err != nil || len(warnings) != 3 {
+		t.Fatalf("expected staging to warn about memory storage, the local queue and CORS, got %v %v", warnings, err)
+	}
+
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("LOG_LEVEL", "warn")
+	cfg := Load()
+	if cfg.OTelTracesSampleRatio != 0.1 || cfg.LogLevel != "warn" || cfg.Sources()["OTEL_TRACES_SAMPLER_ARG"] != SourceProfile {
+		t.Fatalf("expected the production profile under explicit settings, got ratio=%g level=%q sources=%v",
+			cfg.OTelTracesSampleRatio, cfg.LogLevel, cfg.Sources())
+	}
+
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("LOG_LEVEL", "")
+	if cfg := Load(); cfg.LogLevel != "debug" || cfg.OTelTracesSampleRatio != 1 {
+		t.Fatalf("expected the development profile, got level=%q ratio=%g", cfg.LogLevel, cfg.OTelTracesSampleRatio)
+	}
+}
+
+func TestDurationSettingsAcceptGoDurationsAndIntegers(t *testing.T) {
+	t.Setenv("OPENROUTER_TIMEOUT_MS", "15s")
+	t.Setenv("REQUEST_TIMEOUT_MS", "9000")
+	t.Setenv("REDIS_CLAIM_IDLE_SECONDS", "1m30s")
+	t.Setenv("IDEMPOTENCY_TTL_HOURS", "48h")
+	t.Setenv("ARCHIVE_AFTER_DAYS", "168h")
+
+	cfg := Load()
+	if _, err := cfg.Validate(); err != nil {
+		t.Fatalf("expected durations to be accepted, got %v", err)
+	}
+	for name, got := range map[string][2]int{
+		"OPENROUTER_TIMEOUT_MS":    {cfg.OpenRouterTimeoutMS, 15000},
+		"REQUEST_TIMEOUT_MS":       {cfg.RequestTimeoutMS, 9000},
+		"REDIS_CLAIM_IDLE_SECONDS": {cfg.RedisClaimIdleSeconds, 90},
+		"IDEMPOTENCY_TTL_HOURS":    {cfg.IdempotencyTTLHours, 48},
+		"ARCHIVE_AFTER_DAYS":       {cfg.ArchiveAfterDays, 7},
+	} {
+		if got[0] != got[1] {
+			t.Fatalf("%s: expected %d, got %d", name, got[1], got[0])
+		}
+	}
+}
+
+func TestSourcesReportWhereEachSettingCameFrom(t *testing.T) {
+	dotEnv := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(dotEnv, []byte("REDIS_STREAM=from_file\nPORT=9999\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PORT", "9090")
+	t.Setenv("OPENROUTER_API_KEY", "sk-secret")
+	if err := LoadDotEnv(dotEnv); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = LoadDotEnv() })
+
+	cfg := Load()
+	sources := cfg.Sources()
+	for key, expected := range map[string]string{
+		"PORT":               SourceEnv,
+		"REDIS_STREAM":       dotEnv,
+		"OPENROUTER_API_KEY": SourceEnv,
+		"REDIS_GROUP":        SourceDefault,
+	} {
+		if sources[key] != expected {
+			t.Fatalf("%s: expected source %q, got %q", key, expected, sources[key])
+		}
+	}
+	if snapshot := cfg.Snapshot(); snapshot["OpenRouterAPIKey"] != redactedValue || snapshot["RedisStream"] != "from_file" {
+		t.Fatalf("expected a redacted snapshot of the effective values, got %+v", snapshot)
+	}
+}
+
+func TestReloaderRetriesAFailedTarget(t *testing.T) {
+	t.Setenv("SEMANTIC_CACHE_TTL_SECONDS", "900")
+	reloader := NewReloader(nil)
+	var applied []int
+	failing := true
+	reloader.Handle("cache", []string{"SemanticCacheTTLSeconds"}, func(previous, next Config) (bool, error) {
+		if previous.SemanticCacheTTLSeconds == next.SemanticCacheTTLSeconds {
+			return false, nil
+		}
+		if failing {
+			return false, errors.New("cache unavailable")
+		}
+		applied = append(applied, next.SemanticCacheTTLSeconds)
+		return true, nil
+	})
+
+	t.Setenv("SEMANTIC_CACHE_TTL_SECONDS", "60")
+	report := reloader.Reload("signal")
+	if !report.OK || len(report.Results) != 1 || report.Results[0].Error != "cache unavailable" {
+		t.Fatalf("expected the target error in the report, got %+v", report)
+	}
+
+	failing = false
+	report = reloader.Reload("signal")
+	if len(report.Results) != 1 || !report.Results[0].Changed || len(applied) != 1 || applied[0] != 60 {
+		t.Fatalf("expected the failed change to be applied on the next reload, got %+v applied=%v", report, applied)
+	}
+	if last, ok := reloader.Last(); !ok || last.Results[0].Error != "" {
+		t.Fatalf("expected the latest report, got %+v", last)
+	}
+}
+
+func TestRemoteOverlayIsPolledAndRolledBack(t *testing.T) {
+	t.Setenv("SEMANTIC_CACHE_TTL_SECONDS", "900")
+	t.Cleanup(func() { SetRemoteOverlay(nil) })
+	var etag, body string
+	notModified := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer remote-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	source, err := NewRemoteSource(RemoteConfig{URL: server.URL + "/config", AuthToken: "remote-token"})
+	if err != nil {
+		t.Fatalf("new remote source: %v", err)
+	}
+
+	reloader := NewReloader(nil)
+	var applied []int
+	reloader.Handle("cache", []string{"SemanticCacheTTLSeconds"}, func(previous, next Config) (bool, error) {
+		if previous.SemanticCacheTTLSeconds == next.SemanticCacheTTLSeconds {
+			return false, nil
+		}
+		applied = append(applied, next.SemanticCacheTTLSeconds)
+		return true, nil
+	})
+	reloader.Handle("rate_limits", []string{"RateLimitBurst"}, func(previous, next Config) (bool, error) {
+		if next.RateLimitBurst == 13 {
+			return false, errors.New("unlucky burst")
+		}
+		return previous.RateLimitBurst != next.RateLimitBurst, nil
+	})
+	ctx := context.Background()
+
+	etag, body = `"v1"`, `{"SEMANTIC_CACHE_TTL_SECONDS": 120, "REMOTE_CONFIG_URL": "http://elsewhere"}`
+	if report, ok := reloader.PollRemote(ctx, source); !ok || !report.OK || report.Trigger != "remote" {
+		t.Fatalf("expected the overlay applied, got %+v", report)
+	}
+	cfg := Load()
+	if cfg.SemanticCacheTTLSeconds != 120 || cfg.Sources()["SEMANTIC_CACHE_TTL_SECONDS"] != SourceRemote {
+		t.Fatalf("expected the remote TTL, got %d from %q", cfg.SemanticCacheTTLSeconds, cfg.Sources()["SEMANTIC_CACHE_TTL_SECONDS"])
+	}
+	if cfg.RemoteConfigURL != "" {
+		t.Fatalf("expected the overlay not to set REMOTE_CONFIG_URL, got %q", cfg.RemoteConfigURL)
+	}
+	if _, ok := reloader.PollRemote(ctx, source); ok || notModified != 1 {
+		t.Fatalf("expected an unchanged overlay to cost a 304, got ok=%v notModified=%d", ok, notModified)
+	}
+
+	etag, body = `"v2"`, "RATE_LIMIT_RPS=-1\nSEMANTIC_CACHE_TTL_SECONDS=300\n"
+	if report, ok := reloader.PollRemote(ctx, source); !ok || report.OK || !strings.Contains(report.Error, "RATE_LIMIT_RPS") {
+		t.Fatalf("expected the invalid overlay rejected, got %+v", report)
+	}
+	if ttl := Load().SemanticCacheTTLSeconds; ttl != 120 {
+		t.Fatalf("expected the previous overlay restored, got TTL %d", ttl)
+	}
+
+	etag, body = `"v3"`, `{"SEMANTIC_CACHE_TTL_SECONDS": "600", "RATE_LIMIT_BURST": 13}`
+	if report, ok := reloader.PollRemote(ctx, source); !ok || !report.failed() {
+		t.Fatalf("expected a target to fail, got %+v", report)
+	}
+	if ttl := Load().SemanticCacheTTLSeconds; ttl != 120 || len(applied) != 3 || applied[1] != 600 || applied[2] != 120 {
+		t.Fatalf("expected the applied targets rolled back, got TTL %d applied=%v", ttl, applied)
+	}
+	if last, _ := reloader.Last(); last.Trigger != "remote" || !last.failed() {
+		t.Fatalf("expected the rejected overlay as the last report, got %+v", last)
+	}
+}
@@ -5,27 +5,53 @@ import (
 	"errors"
 	"os"
 	"strings"
+	"sync"
+)
+
+var (
+	// fromDotEnv holds the variables set by an earlier LoadDotEnv and the file each came
+	// from; a later call may replace them so a reload picks up edited files.
+	dotEnvMu   sync.Mutex
+	fromDotEnv = make(map[string]string)
 )
 
 // LoadDotEnv loads environment variables from .env-like files.
-// Existing process environment variables keep precedence.
+// Existing process environment variables keep precedence, and the first file setting a
+// variable wins.
 func LoadDotEnv(paths ...string) error {
+	dotEnvMu.Lock()
+	defer dotEnvMu.Unlock()
+	loaded := make(map[string]bool)
 	for _, path := range paths {
 		trimmed := strings.TrimSpace(path)
 		if trimmed == "" {
 			continue
 		}
-		if err := loadDotEnvFile(trimmed); err != nil {
+		if err := loadDotEnvFile(trimmed, loaded); err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				continue
 			}
 			return err
 		}
 	}
+	// A variable removed from the files since the last call goes back to unset.
+	for key := range fromDotEnv {
+		if !loaded[key] {
+			_ = os.Unsetenv(key)
+			delete(fromDotEnv, key)
+		}
+	}
 	return nil
 }
 
-func loadDotEnvFile(path string) error {
+// dotEnvFile returns the .env file that set key, or "" when it did not come from one.
+func dotEnvFile(key string) string {
+	dotEnvMu.Lock()
+	defer dotEnvMu.Unlock()
+	return fromDotEnv[key]
+}
+
+func loadDotEnvFile(path string, loaded map[string]bool) error {
 	file, err := os.Open(path)
 	if err != nil {
 		return err
@@ -34,32 +60,38 @@ func loadDotEnvFile(path string) error {
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		if strings.HasPrefix(line, "export ") {
-			line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
-		}
-
-		key, value, ok := strings.Cut(line, "=")
+		key, value, ok := parseDotEnvLine(scanner.Text())
 		if !ok {
 			continue
 		}
-		key = strings.TrimSpace(key)
-		if key == "" {
-			continue
-		}
-		if _, exists := os.LookupEnv(key); exists {
+		if _, exists := os.LookupEnv(key); loaded[key] || (exists && fromDotEnv[key] == "") {
 			continue
 		}
-
-		value = parseDotEnvValue(value)
 		_ = os.Setenv(key, value)
+		loaded[key] = true
+		fromDotEnv[key] = path
 	}
 	return scanner.Err()
 }
 
+// parseDotEnvLine splits a KEY=VALUE line; ok is false for blanks, comments and lines
+// without a key.
+func parseDotEnvLine(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	if strings.HasPrefix(line, "export ") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+	}
+	key, value, ok = strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", false
+	}
+	return key, parseDotEnvValue(value), true
+}
+
 func parseDotEnvValue(raw string) string {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
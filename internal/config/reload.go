@@ -0,0 +1,241 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ReloadFunc applies the reloadable settings of next and reports whether they differ
+// from previous, the configuration the function last applied without error (or the
+// startup one).
+type ReloadFunc func(previous, next Config) (changed bool, err error)
+
+// ReloadReport is the outcome of one reload. When the new configuration is invalid
+// nothing is applied and Error says why.
+type ReloadReport struct {
+	Trigger  string         `json:"trigger"`
+	At       time.Time      `json:"at"`
+	OK       bool           `json:"ok"`
+	Error    string         `json:"error,omitempty"`
+	Warnings []string       `json:"warnings,omitempty"`
+	Results  []ReloadResult `json:"results"`
+	// RestartRequired lists the settings that changed but only apply on restart.
+	RestartRequired []string `json:"restart_required"`
+}
+
+// ReloadResult is the outcome of one group of settings.
+type ReloadResult struct {
+	Name    string `json:"name"`
+	Changed bool   `json:"changed"`
+	Error   string `json:"error,omitempty"`
+}
+
+type reloadTarget struct {
+	name    string
+	fields  []string
+	apply   ReloadFunc
+	applied Config
+}
+
+// Reloader reads the .env files and the environment again on demand and hands the new
+// settings to the registered targets; the rest of the process keeps its startup values.
+type Reloader struct {
+	dotEnvPaths []string
+	logger      *slog.Logger
+
+	mu       sync.Mutex
+	previous Config
+	targets  []*reloadTarget
+	last     *ReloadReport
+}
+
+// NewReloader takes the current environment as the baseline, so call it after the
+// startup Load.
+func NewReloader(logger *slog.Logger, dotEnvPaths ...string) *Reloader {
+	return &Reloader{dotEnvPaths: dotEnvPaths, logger: logger, previous: Load()}
+}
+
+// Handle registers a group of settings, named by their Config field names, and the
+// function applying them.
+func (r *Reloader) Handle(name string, fields []string, apply ReloadFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.targets = append(r.targets, &reloadTarget{name: name, fields: fields, apply: apply, applied: r.previous})
+}
+
+// Reload applies the current settings to every target; a target that fails keeps its
+// previous settings, is retried on the next reload and does not stop the others.
+func (r *Reloader) Reload(trigger string) ReloadReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reload(trigger)
+}
+
+func (r *Reloader) reload(trigger string) ReloadReport {
+	report := ReloadReport{Trigger: trigger, At: time.Now().UTC(), Results: []ReloadResult{}, RestartRequired: []string{}}
+	if err := LoadDotEnv(r.dotEnvPaths...); err != nil {
+		report.Error = "load .env files: " + err.Error()
+		return r.finish(report)
+	}
+	next := Load()
+	warnings, err := next.Validate()
+	report.Warnings = warnings
+	if err != nil {
+		report.Error = err.Error()
+		return r.finish(report)
+	}
+
+	covered := make(map[string]bool)
+	for _, target := range r.targets {
+		for _, field := range target.fields {
+			covered[field] = true
+		}
+		result := ReloadResult{Name: target.name}
+		changed, err := target.apply(target.applied, next)
+		result.Changed = changed
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			target.applied = next
+		}
+		report.Results = append(report.Results, result)
+	}
+	for _, field := range changedFields(r.previous, next) {
+		if !covered[field] {
+			report.RestartRequired = append(report.RestartRequired, field)
+		}
+	}
+	report.OK = true
+	r.previous = next
+	return r.finish(report)
+}
+
+func (r *Reloader) finish(report ReloadReport) ReloadReport {
+	r.last = &report
+	if r.logger == nil {
+		return report
+	}
+	if report.Error != "" {
+		r.logger.Error("configuration reload rejected", slog.String("trigger", report.Trigger), slog.String("error", report.Error))
+		return report
+	}
+	for _, result := range report.Results {
+		if result.Error != "" {
+			r.logger.Error("configuration reload failed", slog.String("settings", result.Name), slog.String("error", result.Error))
+		} else if result.Changed {
+			r.logger.Info("configuration reloaded", slog.String("settings", result.Name))
+		}
+	}
+	if len(report.RestartRequired) > 0 {
+		r.logger.Warn("configuration changes need a restart", slog.Any("settings", report.RestartRequired))
+	}
+	return report
+}
+
+// Last returns the report of the latest reload, if any.
+func (r *Reloader) Last() (ReloadReport, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last == nil {
+		return ReloadReport{}, false
+	}
+	return *r.last, true
+}
+
+// WatchSignal reloads on every SIGHUP until ctx ends.
+func (r *Reloader) WatchSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			r.Reload("signal")
+		}
+	}
+}
+
+// WatchRemote polls source every interval until ctx ends; see PollRemote.
+func (r *Reloader) WatchRemote(ctx context.Context, source *RemoteSource, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.PollRemote(ctx, source)
+		}
+	}
+}
+
+// PollRemote fetches the remote overlay and, when it changed, reloads with it. An overlay
+// that fails validation or that any target cannot apply is rolled back as a whole: the
+// previous overlay is restored and the targets that took the new one reload the old
+// values, so the process keeps running on the last good overlay. The same document is
+// not tried again until it changes. applied is false when nothing new was fetched.
+func (r *Reloader) PollRemote(ctx context.Context, source *RemoteSource) (report ReloadReport, applied bool) {
+	values, changed, err := source.Fetch(ctx)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.WarnContext(ctx, "remote config fetch failed, keeping the current overlay", slog.Any("error", err))
+		}
+		return ReloadReport{}, false
+	}
+	if !changed {
+		return ReloadReport{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous := SetRemoteOverlay(values)
+	report = r.reload("remote")
+	if report.OK && !report.failed() {
+		return report, true
+	}
+	SetRemoteOverlay(previous)
+	if report.OK {
+		r.reload("remote_rollback")
+	}
+	// The rollback report replaces the rejected one in Last; keep the reason visible.
+	r.last = &report
+	if r.logger != nil {
+		r.logger.ErrorContext(ctx, "remote config rolled back")
+	}
+	return report, true
+}
+
+func (report ReloadReport) failed() bool {
+	for _, result := range report.Results {
+		if result.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// changedFields lists the exported fields that differ between two configurations.
+func changedFields(previous, next Config) []string {
+	before, after := reflect.ValueOf(previous), reflect.ValueOf(next)
+	fields := before.Type()
+	var changed []string
+	for index := 0; index < fields.NumField(); index++ {
+		if !fields.Field(index).IsExported() {
+			continue
+		}
+		if !reflect.DeepEqual(before.Field(index).Interface(), after.Field(index).Interface()) {
+			changed = append(changed, fields.Field(index).Name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/awsauth"
+)
+
+// maxRemoteConfigBytes bounds the overlay document.
+const maxRemoteConfigBytes = 1 << 20
+
+// remoteOverlay holds the variables of the last applied remote overlay; it is guarded by
+// loadMu, as Load reads it.
+var remoteOverlay map[string]string
+
+// SetRemoteOverlay replaces the remote overlay read by the next Load and returns the one
+// it replaced, so a caller can put it back.
+func SetRemoteOverlay(values map[string]string) (previous map[string]string) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	previous, remoteOverlay = remoteOverlay, values
+	return previous
+}
+
+// ParseOverlay reads an overlay document: a JSON object of scalar values or .env lines.
+// REMOTE_CONFIG_* variables are ignored, so an overlay cannot move or stop its own polling.
+func ParseOverlay(body []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		var document map[string]any
+		if err := decoder.Decode(&document); err != nil {
+			return nil, fmt.Errorf("decode overlay: %w", err)
+		}
+		for key, raw := range document {
+			switch value := raw.(type) {
+			case nil:
+				values[key] = ""
+			case string:
+				values[key] = value
+			case json.Number, bool:
+				values[key] = fmt.Sprint(value)
+			default:
+				return nil, fmt.Errorf("overlay value of %s must be a string, number or boolean", key)
+			}
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		for scanner.Scan() {
+			if key, value, ok := parseDotEnvLine(scanner.Text()); ok {
+				values[key] = value
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read overlay: %w", err)
+		}
+	}
+	for key := range values {
+		if strings.HasPrefix(key, "REMOTE_CONFIG_") {
+			delete(values, key)
+		}
+	}
+	return values, nil
+}
+
+// RemoteConfig says where the overlay lives. AuthToken is sent as a bearer token to
+// http(s) URLs; s3:// URLs are signed with the access keys.
+type RemoteConfig struct {
+	URL        string
+	AuthToken  string
+	S3Endpoint string
+	S3Region   string
+	AccessKey  string
+	SecretKey  string
+	HTTPClient *http.Client
+}
+
+// RemoteSource fetches the overlay, sending the last ETag so an unchanged document costs
+// a 304; servers without ETags are compared by content.
+type RemoteSource struct {
+	config RemoteConfig
+	target *url.URL
+
+	mu     sync.Mutex
+	etag   string
+	digest [sha256.Size]byte
+}
+
+// NewRemoteSource validates cfg.URL and builds the source.
+func NewRemoteSource(cfg RemoteConfig) (*RemoteSource, error) {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if strings.TrimSpace(cfg.S3Region) == "" {
+		cfg.S3Region = "us-east-1"
+	}
+	if bucket, key, err := parseS3URL(cfg.URL); err == nil {
+		endpoint := strings.TrimSuffix(cfg.S3Endpoint, "/")
+		if endpoint == "" {
+			endpoint = "https://s3." + cfg.S3Region + ".amazonaws.com"
+		}
+		target, err := url.Parse(endpoint)
+		if err != nil || target.Host == "" {
+			return nil, fmt.Errorf("invalid remote config S3 endpoint %q", cfg.S3Endpoint)
+		}
+		target.Path = "/" + bucket + "/" + key
+		target.RawPath = "/" + awsauth.EscapePath(bucket) + "/" + awsauth.EscapePath(key)
+		return &RemoteSource{config: cfg, target: target}, nil
+	}
+	if !validHTTPURL(cfg.URL) {
+		return nil, fmt.Errorf("remote config URL must be http(s):// or s3://bucket/key, got %q", cfg.URL)
+	}
+	target, _ := url.Parse(cfg.URL)
+	return &RemoteSource{config: cfg, target: target}, nil
+}
+
+// S3 reports whether the overlay is read from an S3 bucket.
+func (s *RemoteSource) S3() bool {
+	return strings.HasPrefix(s.config.URL, "s3://")
+}
+
+// Fetch downloads the overlay; changed is false, with nil values, when it is the same
+// document as the last successful fetch.
+func (s *RemoteSource) Fetch(ctx context.Context) (values map[string]string, changed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.target.String(), nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("create remote config request: %w", err)
+	}
+	if s.etag != "" {
+		request.Header.Set("If-None-Match", s.etag)
+	}
+	if s.S3() {
+		credentials := awsauth.Credentials{AccessKeyID: s.config.AccessKey, SecretAccessKey: s.config.SecretKey}
+		awsauth.SignRequest(request, nil, credentials, s.config.S3Region, "s3", time.Now())
+	} else if s.config.AuthToken != "" {
+		request.Header.Set("Authorization", "Bearer "+s.config.AuthToken)
+	}
+
+	response, err := s.config.HTTPClient.Do(request)
+	if err != nil {
+		return nil, false, fmt.Errorf("remote config request: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return nil, false, fmt.Errorf("remote config status %d: %s", response.StatusCode, strings.TrimSpace(string(message)))
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxRemoteConfigBytes+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("read remote config: %w", err)
+	}
+	if len(body) > maxRemoteConfigBytes {
+		return nil, false, errors.New("remote config is larger than 1 MiB")
+	}
+	digest := sha256.Sum256(body)
+	if digest == s.digest {
+		s.etag = response.Header.Get("ETag")
+		return nil, false, nil
+	}
+	values, err = ParseOverlay(body)
+	if err != nil {
+		return nil, false, err
+	}
+	s.etag, s.digest = response.Header.Get("ETag"), digest
+	return values, true, nil
+}
+
+// parseS3URL splits s3://bucket/key.
+func parseS3URL(raw string) (bucket, key string, err error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", "", err
+	}
+	key = strings.TrimPrefix(parsed.Path, "/")
+	if parsed.Scheme != "s3" || parsed.Host == "" || key == "" {
+		return "", "", fmt.Errorf("not an s3://bucket/key URL: %q", raw)
+	}
+	return parsed.Host, key, nil
+}
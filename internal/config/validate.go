@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Validate checks the settings before anything is built from them. The error joins every
+// problem that would make the process misbehave (unparsable values, malformed URLs,
+// non-positive timeouts, conflicting queue settings, an open API in production); the
+// warnings are settings that work but are likely a mistake.
+func (c Config) Validate() (warnings []string, err error) {
+	var problems []error
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+	for _, setting := range c.unparsed {
+		fail("%s", setting)
+	}
+
+	production := c.Production()
+	switch c.Environment {
+	case "development", "staging", "production":
+	default:
+		fail("APP_ENV must be development, staging or production, got %q", c.Environment)
+	}
+
+	for _, setting := range []struct {
+		name, value string
+		required    bool
+	}{
+		{"OPENROUTER_BASE_URL", c.OpenRouterBaseURL, true},
+		{"OPENROUTER_SITE_URL", c.OpenRouterSiteURL, false},
+		{"JWT_JWKS_URL", c.JWTJWKSURL, false},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT", c.OTelExporterEndpoint, false},
+		{"ARCHIVE_S3_ENDPOINT", c.ArchiveS3Endpoint, false},
+		{"ENCRYPTION_KMS_ENDPOINT", c.EncryptionKMSEndpoint, false},
+		{"VAULT_ADDR", c.VaultAddr, false},
+		{"SECRETS_AWS_ENDPOINT", c.SecretsAWSEndpoint, false},
+		{"REMOTE_CONFIG_S3_ENDPOINT", c.RemoteConfigS3Endpoint, false},
+		{"DLQ_ALERT_SLACK_WEBHOOK_URL", c.DLQAlertSlackWebhookURL, false},
+		{"DLQ_ALERT_WEBHOOK_URL", c.DLQAlertWebhookURL, false},
+	} {
+		if setting.value == "" && !setting.required {
+			continue
+		}
+		if !validHTTPURL(setting.value) {
+			fail("%s must be an absolute http(s) URL, got %q", setting.name, setting.value)
+		}
+	}
+	// Keyword/value DSNs ("host=... dbname=...") are accepted as they are.
+	if strings.Contains(c.DatabaseURL, "://") {
+		if parsed, err := url.Parse(c.DatabaseURL); err != nil || (parsed.Scheme != "postgres" && parsed.Scheme != "postgresql") {
+			fail("DATABASE_URL must be a postgres:// URL or a keyword/value DSN")
+		}
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value int
+	}{
+		{"OPENROUTER_TIMEOUT_MS", c.OpenRouterTimeoutMS},
+		{"QUALITY_JUDGE_TIMEOUT_MS", c.QualityJudgeTimeoutMS},
+		{"QUALITY_TOXICITY_TIMEOUT_MS", c.QualityToxicityTimeoutMS},
+		{"REQUEST_TIMEOUT_MS", c.RequestTimeoutMS},
+		{"REQUEST_SIGNING_TOLERANCE_SECONDS", c.RequestSigningToleranceSeconds},
+		{"REDIS_CLAIM_IDLE_SECONDS", c.RedisClaimIdleSeconds},
+		{"IDEMPOTENCY_TTL_HOURS", c.IdempotencyTTLHours},
+		{"POLICY_RELOAD_SECONDS", c.PolicyReloadSeconds},
+		{"TENANT_SETTINGS_CACHE_SECONDS", c.TenantSettingsCacheSeconds},
+		{"USAGE_FLUSH_SECONDS", c.UsageFlushSeconds},
+		{"SLO_WINDOW_HOURS", c.SLOWindowHours},
+		{"SCHEDULER_LEASE_SECONDS", c.SchedulerLeaseSeconds},
+		{"SCHEDULER_INTERVAL_SECONDS", c.SchedulerIntervalSeconds},
+		{"ARCHIVE_INTERVAL_SECONDS", c.ArchiveIntervalSeconds},
+		{"REMOTE_CONFIG_INTERVAL_SECONDS", c.RemoteConfigIntervalSeconds},
+		{"QUEUE_BATCH_FLUSH_MS", c.QueueBatchFlushMS},
+		{"QUEUE_BATCH_FLUSH_TIMEOUT_MS", c.QueueBatchFlushTimeoutMS},
+		{"WORKER_CONCURRENCY_INTERVAL_SECONDS", c.WorkerConcurrencyIntervalSeconds},
+		{"WORKER_CONCURRENCY_TARGET_LATENCY_MS", c.WorkerConcurrencyTargetLatencyMS},
+		{"DLQ_ALERT_INTERVAL_SECONDS", c.DLQAlertIntervalSeconds},
+		{"RATE_LIMIT_BURST", c.RateLimitBurst},
+		{"RATE_LIMIT_IP_BURST", c.RateLimitIPBurst},
+	} {
+		if setting.value <= 0 {
+			fail("%s must be positive, got %d", setting.name, setting.value)
+		}
+	}
+	for _, setting := range []struct {
+		name  string
+		value int
+	}{
+		{"OPENROUTER_MAX_RETRIES", c.OpenRouterMaxRetries},
+		{"JWT_JWKS_CACHE_SECONDS", c.JWTJWKSCacheSeconds},
+		{"SEMANTIC_CACHE_TTL_SECONDS", c.SemanticCacheTTLSeconds},
+		{"CONCURRENCY_QUEUE_WAIT_MS", c.ConcurrencyQueueWaitMS},
+		{"WORKER_SHUTDOWN_GRACE_SECONDS", c.WorkerShutdownGraceSeconds},
+		{"SHUTDOWN_DRAIN_SECONDS", c.ShutdownDrainSeconds},
+		{"HSTS_MAX_AGE_SECONDS", c.HSTSMaxAgeSeconds},
+		{"SECRETS_REFRESH_SECONDS", c.SecretsRefreshSeconds},
+		{"DLQ_ALERT_COOLDOWN_SECONDS", c.DLQAlertCooldownSeconds},
+		{"DLQ_ALERT_THRESHOLD", c.DLQAlertThreshold},
+	} {
+		if setting.value < 0 {
+			fail("%s must not be negative, got %d", setting.name, setting.value)
+		}
+	}
+	if c.MaxBodyBytes <= 0 {
+		fail("MAX_BODY_BYTES must be positive, got %d", c.MaxBodyBytes)
+	}
+	if c.RateLimitRPS <= 0 {
+		fail("RATE_LIMIT_RPS must be positive, got %g", c.RateLimitRPS)
+	}
+	if c.RateLimitIPRPS <= 0 {
+		fail("RATE_LIMIT_IP_RPS must be positive, got %g", c.RateLimitIPRPS)
+	}
+	if c.QualityJudgeWeight < 0 || c.QualityJudgeWeight > 1 {
+		fail("QUALITY_JUDGE_WEIGHT must be between 0 and 1, got %g", c.QualityJudgeWeight)
+	}
+	if c.AICallLogSampleRate < 0 || c.AICallLogSampleRate > 1 {
+		fail("AI_CALL_LOG_SAMPLE_RATE must be between 0 and 1, got %g", c.AICallLogSampleRate)
+	}
+	if c.OTelTracesSampleRatio < 0 || c.OTelTracesSampleRatio > 1 {
+		fail("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1, got %g", c.OTelTracesSampleRatio)
+	}
+
+	// Queue settings that contradict each other.
+	if c.RedisStream == c.RedisDLQ {
+		fail("REDIS_STREAM and REDIS_DLQ_STREAM must differ, both are %q", c.RedisStream)
+	}
+	if !c.WorkerEnabled && c.RedisAddr == "" {
+		fail("WORKER_ENABLED=false requires REDIS_ADDR: the local queue is only consumed by its own process")
+	}
+	if c.QueuePriorityWeight < 1 {
+		fail("QUEUE_PRIORITY_WEIGHT must be at least 1, got %d", c.QueuePriorityWeight)
+	}
+	if c.WorkerConcurrencyMin < 1 || c.WorkerConcurrencyMax < c.WorkerConcurrencyMin {
+		fail("WORKER_CONCURRENCY_MIN must be at least 1 and at most WORKER_CONCURRENCY_MAX, got %d and %d",
+			c.WorkerConcurrencyMin, c.WorkerConcurrencyMax)
+	}
+	if c.WorkerConcurrencyDepthPerWorker < 1 {
+		fail("WORKER_CONCURRENCY_DEPTH_PER_WORKER must be at least 1, got %d", c.WorkerConcurrencyDepthPerWorker)
+	}
+	if c.QueueBatchingEnabled {
+		if c.QueueBatchSize < 1 || c.QueueBatchMaxInFlight < 1 {
+			fail("QUEUE_BATCH_SIZE and QUEUE_BATCH_MAX_IN_FLIGHT must be at least 1")
+		}
+		if c.QueueBatchQueueCapacity < c.QueueBatchSize {
+			fail("QUEUE_BATCH_QUEUE_CAPACITY (%d) must hold at least one batch of QUEUE_BATCH_SIZE (%d)",
+				c.QueueBatchQueueCapacity, c.QueueBatchSize)
+		}
+	}
+	if c.RemoteConfigURL != "" {
+		if _, _, err := parseS3URL(c.RemoteConfigURL); err != nil && !validHTTPURL(c.RemoteConfigURL) {
+			fail("REMOTE_CONFIG_URL must be an http(s):// URL or s3://bucket/key, got %q", c.RemoteConfigURL)
+		}
+	}
+	if c.SentryDSN != "" && !validSentryDSN(c.SentryDSN) {
+		fail("SENTRY_DSN must look like https://<key>@<host>/<project>")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	// Production needs durable storage and a shared queue, and an explicit CORS
+	// allowlist; staging only warns about them.
+	var deployment []string
+	if c.DatabaseURL == "" {
+		deployment = append(deployment, "DATABASE_URL is not set; jobs and messages are kept in memory")
+	}
+	if c.RedisAddr == "" {
+		deployment = append(deployment, "REDIS_ADDR is not set; jobs go to the local in-process queue")
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin == "*" {
+			deployment = append(deployment, "CORS_ALLOWED_ORIGINS allows any origin (*)")
+			break
+		}
+	}
+	for _, problem := range deployment {
+		switch c.Environment {
+		case "production":
+			fail("%s; not allowed in production", problem)
+		case "staging":
+			warnings = append(warnings, problem)
+		}
+	}
+
+	if production {
+		if c.AuthToken == "" && c.JWTJWKSURL == "" {
+			fail("API_AUTH_TOKEN or JWT_JWKS_URL is required in production; without them /v1 and /v2 are open")
+		}
+		if c.AuthToken == "dev-token" {
+			fail("API_AUTH_TOKEN must not be the example dev-token in production")
+		}
+		if c.RedisAddr != "" && c.RedisConsumer == "api-1" {
+			warnings = append(warnings, "REDIS_CONSUMER is the default api-1; each replica needs its own consumer name")
+		}
+		if c.MetricsEnabled && c.MetricsPort == "" {
+			warnings = append(warnings, "/metrics is served on the public port; set METRICS_PORT")
+		}
+		if c.AdminToken == "" && c.JWTJWKSURL == "" {
+			warnings = append(warnings, "ADMIN_AUTH_TOKEN is not set; the /admin routes are disabled")
+		}
+	}
+	return warnings, errors.Join(problems...)
+}
+
+// validSentryDSN checks the shape of a DSN without echoing it, as it holds the project key.
+func validSentryDSN(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && validHTTPURL(raw) && parsed.User != nil && parsed.User.Username() != "" && strings.Trim(parsed.Path, "/") != ""
+}
+
+func validHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
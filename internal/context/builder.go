@@ -8,6 +8,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
 )
 
 type BuildInput struct {
@@ -27,8 +29,10 @@ type BuildOutput struct {
 }
 
 type cachedBuild struct {
-	output    BuildOutput
-	expiresAt time.Time
+	output         BuildOutput
+	tenantID       string
+	conversationID string
+	expiresAt      time.Time
 }
 
 type Builder struct {
@@ -50,6 +54,18 @@ func NewBuilder(retriever Retriever) *Builder {
 }
 
 func (b *Builder) Build(ctx context.Context, input BuildInput) (BuildOutput, error) {
+	ctx, span := tracing.Start(ctx, "context.build", tracing.KindInternal)
+	defer span.End()
+	span.SetAttribute("context.task", input.Task)
+
+	output, err := b.build(ctx, input)
+	span.RecordError(err)
+	span.SetAttribute("context.chunks", len(output.Chunks))
+	span.SetAttribute("context.tokens", output.TokenCount)
+	return output, err
+}
+
+func (b *Builder) build(ctx context.Context, input BuildInput) (BuildOutput, error) {
 	if b.retriever == nil {
 		return BuildOutput{}, fmt.Errorf("retriever is required")
 	}
@@ -113,7 +129,7 @@ func (b *Builder) Build(ctx context.Context, input BuildInput) (BuildOutput, err
 		Chunks:      selected,
 		TokenCount:  totalTokens,
 	}
-	b.cachePut(cacheKey, output)
+	b.cachePut(cacheKey, input, output)
 	return cloneBuildOutput(output), nil
 }
 
@@ -178,15 +194,17 @@ func (b *Builder) cacheGet(key uint64) (BuildOutput, bool) {
 	return entry.output, true
 }
 
-func (b *Builder) cachePut(key uint64, output BuildOutput) {
+func (b *Builder) cachePut(key uint64, input BuildInput, output BuildOutput) {
 	if b.cacheLimit <= 0 {
 		return
 	}
 
 	now := time.Now()
 	entry := cachedBuild{
-		output:    cloneBuildOutput(output),
-		expiresAt: now.Add(b.cacheTTL),
+		output:         cloneBuildOutput(output),
+		tenantID:       strings.TrimSpace(input.TenantID),
+		conversationID: strings.TrimSpace(input.ConversationID),
+		expiresAt:      now.Add(b.cacheTTL),
 	}
 
 	b.cacheMu.Lock()
@@ -219,6 +237,25 @@ func (b *Builder) cachePut(key uint64, output BuildOutput) {
 	b.cache[key] = entry
 }
 
+// Invalidate drops cached builds for a tenant conversation and returns how many were removed.
+func (b *Builder) Invalidate(tenantID, conversationID string) int {
+	tenantID = strings.TrimSpace(tenantID)
+	conversationID = strings.TrimSpace(conversationID)
+
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
+
+	removed := 0
+	for key, entry := range b.cache {
+		if entry.tenantID != tenantID || entry.conversationID != conversationID {
+			continue
+		}
+		delete(b.cache, key)
+		removed++
+	}
+	return removed
+}
+
 func cloneBuildOutput(value BuildOutput) BuildOutput {
 	cloned := BuildOutput{
 		ContextText: value.ContextText,
@@ -0,0 +1,86 @@
+package contextbuilder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+const emptyPayloadFragment = "Nao ha historico detalhado no payload; use contexto recente da conversa quando disponivel."
+
+// MessageSource exposes the persisted conversation history.
+type MessageSource interface {
+	ListRecentMessages(ctx context.Context, tenantID, conversationID string, limit int) ([]domain.Message, error)
+}
+
+// HistoryRetriever merges persisted messages with the payload fragments of the base retriever.
+// Recent messages score higher; history errors degrade to payload-only retrieval.
+type HistoryRetriever struct {
+	source MessageSource
+	base   Retriever
+}
+
+func NewHistoryRetriever(source MessageSource, base Retriever) *HistoryRetriever {
+	if base == nil {
+		base = NewBasicRetriever()
+	}
+	return &HistoryRetriever{source: source, base: base}
+}
+
+func (r *HistoryRetriever) Retrieve(ctx context.Context, input RetrievalInput) ([]Chunk, error) {
+	chunks, err := r.base.Retrieve(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if r.source == nil || strings.TrimSpace(input.TenantID) == "" || strings.TrimSpace(input.ConversationID) == "" {
+		return chunks, nil
+	}
+
+	messages, err := r.source.ListRecentMessages(
+		ctx,
+		input.TenantID,
+		input.ConversationID,
+		deriveFragmentLimit(input.Task, input.ContextWindow),
+	)
+	if err != nil || len(messages) == 0 {
+		return chunks, nil
+	}
+
+	merged := make([]Chunk, 0, len(chunks)+len(messages))
+	for _, chunk := range chunks {
+		if chunk.Text == emptyPayloadFragment {
+			continue
+		}
+		merged = append(merged, chunk)
+	}
+	for index := len(messages) - 1; index >= 0; index-- {
+		message := messages[index]
+		text := strings.TrimSpace(message.Text)
+		if text == "" {
+			continue
+		}
+		if len(text) > 520 {
+			text = text[:520]
+		}
+		fromNewest := len(messages) - 1 - index
+		merged = append(merged, Chunk{
+			ID:    fmt.Sprintf("history-%03d", fromNewest+1),
+			Text:  authorLabel(message.AuthorRole) + ": " + text,
+			Score: computeScore(input.Task, fromNewest, text),
+		})
+	}
+	return merged, nil
+}
+
+func authorLabel(role domain.MessageAuthorRole) string {
+	switch role {
+	case domain.MessageAuthorAgent:
+		return "atendente"
+	case domain.MessageAuthorCustomer:
+		return "cliente"
+	default:
+		return string(role)
+	}
+}
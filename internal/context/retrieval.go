@@ -51,9 +51,7 @@ func (r *BasicRetriever) Retrieve(_ context.Context, input RetrievalInput) ([]Ch
 	}
 
 	if len(fragments) == 0 {
-		fragments = append(fragments,
-			"Nao ha historico detalhado no payload; use contexto recente da conversa quando disponivel.",
-		)
+		fragments = append(fragments, emptyPayloadFragment)
 	}
 
 	uniqueFragments := dedupeFragments(fragments, fragmentLimit)
@@ -201,6 +199,8 @@ func deriveFragmentLimit(task string, contextWindow int) int {
 		baseLimit = 30
 	case "report":
 		baseLimit = 42
+	case "digest", "insights":
+		baseLimit = 40
 	}
 
 	if contextWindow > 0 {
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
 )
 
 func TestBasicRetrieverPrioritizesMessagesAndSkipsConversationMetadata(t *testing.T) {
@@ -99,3 +102,46 @@ func containsChunkText(chunks []Chunk, expected string) bool {
 	}
 	return false
 }
+
+type staticMessageSource struct {
+	messages []domain.Message
+}
+
+func (s staticMessageSource) ListRecentMessages(_ context.Context, _, _ string, limit int) ([]domain.Message, error) {
+	if limit > 0 && len(s.messages) > limit {
+		return s.messages[len(s.messages)-limit:], nil
+	}
+	return s.messages, nil
+}
+
+func TestHistoryRetrieverPrefersRecentPersistedMessages(t *testing.T) {
+	now := time.Now().UTC()
+	retriever := NewHistoryRetriever(staticMessageSource{messages: []domain.Message{
+		{AuthorRole: domain.MessageAuthorCustomer, Text: "Oi, tudo bem?", CreatedAt: now.Add(-2 * time.Minute)},
+		{AuthorRole: domain.MessageAuthorAgent, Text: "Tudo sim, pode falar.", CreatedAt: now.Add(-time.Minute)},
+		{AuthorRole: domain.MessageAuthorCustomer, Text: "Quero cancelar o pedido 123", CreatedAt: now},
+	}}, nil)
+
+	chunks, err := retriever.Retrieve(context.Background(), RetrievalInput{
+		Task:           "summary",
+		TenantID:       "default",
+		ConversationID: "chat-1",
+		Payload:        json.RawMessage(`{}`),
+	})
+	if err != nil {
+		t.Fatalf("retrieve failed: %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected only history chunks when payload is empty, got %+v", chunks)
+	}
+
+	best := chunks[0]
+	for _, chunk := range chunks[1:] {
+		if chunk.Score > best.Score {
+			best = chunk
+		}
+	}
+	if best.Text != "cliente: Quero cancelar o pedido 123" {
+		t.Fatalf("expected newest message to score highest, got %q", best.Text)
+	}
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// APIKeyPrefix marks API key secrets so they can be told apart from other bearer tokens.
+const APIKeyPrefix = "wak_"
+
+// API key scopes. ScopeAdmin grants every tenant route, including data erasure, but not
+// the /admin namespace; ScopeReadOnly only reads jobs and reports.
+const (
+	APIKeyScopeSuggestions = "suggestions"
+	APIKeyScopeReports     = "reports"
+	APIKeyScopeAdmin       = "admin"
+	APIKeyScopeReadOnly    = "readonly"
+)
+
+// APIKey is a per-tenant credential. Only the SHA-256 of the secret is stored; Prefix is
+// the non-secret head shown to operators to tell keys apart.
+type APIKey struct {
+	ID         string
+	TenantID   string
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	// RotatedFrom is the key this one replaced, if any.
+	RotatedFrom string
+}
+
+func (k APIKey) Active() bool {
+	return k.RevokedAt == nil
+}
+
+// HasScope reports whether the key grants scope; admin implies every scope and readonly
+// implies reports (the role check then limits it to reads).
+func (k APIKey) HasScope(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == APIKeyScopeAdmin || (granted == APIKeyScopeReadOnly && scope == APIKeyScopeReports) {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyListFilter struct {
+	TenantID       string
+	IncludeRevoked bool
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditRecord captures who performed a sensitive operation and on which resource.
+type AuditRecord struct {
+	ID           string
+	TenantID     string
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	RequestID    string
+	Metadata     json.RawMessage
+	CreatedAt    time.Time
+}
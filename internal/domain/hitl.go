@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+type HITLDecisionAction string
+
+const (
+	HITLDecisionApproved HITLDecisionAction = "approved"
+	HITLDecisionEdited   HITLDecisionAction = "edited"
+	HITLDecisionRejected HITLDecisionAction = "rejected"
+)
+
+// HITLDecision records that a human reviewed generated content before any message was sent.
+// It references either a job (summary/report) or a suggestion response (its request id and rank).
+type HITLDecision struct {
+	ID                  string
+	TenantID            string
+	ConversationID      string
+	JobID               string
+	SuggestionRequestID string
+	SuggestionRank      int
+	Action              HITLDecisionAction
+	ReviewerID          string
+	// FinalTextChecksum fingerprints the text the reviewer ended up with; the text itself
+	// is not retained so the record survives conversation erasure without holding content.
+	FinalTextChecksum string
+	Actor             string
+	RequestID         string
+	DecidedAt         time.Time
+	CreatedAt         time.Time
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord keeps the response to a POST sent with an Idempotency-Key so retries
+// replay it instead of running the request again. StatusCode is zero while the first
+// request is still running.
+type IdempotencyRecord struct {
+	TenantID string
+	Key      string
+	// RequestHash fingerprints method, path and body; reusing a key for a different
+	// request is a conflict.
+	RequestHash string
+	// ConversationID is the conversation the request was about, if any, so erasing the
+	// conversation also drops its cached responses.
+	ConversationID string
+	StatusCode     int
+	Header         map[string]string
+	Body           []byte
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
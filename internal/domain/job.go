@@ -10,6 +10,8 @@ type JobKind string
 const (
 	JobKindSummary JobKind = "summary"
 	JobKindReport  JobKind = "report"
+	// JobKindDigest spans a tenant's conversations for a period; ConversationID is empty.
+	JobKindDigest JobKind = "digest"
 )
 
 type JobStatus string
@@ -21,6 +23,48 @@ const (
 	JobStatusFailed     JobStatus = "failed"
 )
 
+// JobApproval is the human sign-off regulated tenants need on a generated result before it
+// counts as approved. Jobs of other tenants leave it empty.
+type JobApproval string
+
+const (
+	JobApprovalPending  JobApproval = "pending"
+	JobApprovalApproved JobApproval = "approved"
+	JobApprovalRejected JobApproval = "rejected"
+)
+
+// JobPriority orders the queue: workers take high priority messages first, with low
+// priority ones still served at a fixed ratio so they never starve.
+type JobPriority string
+
+const (
+	JobPriorityHigh JobPriority = "high"
+	JobPriorityLow  JobPriority = "low"
+)
+
+// DefaultJobPriority is the priority of jobs enqueued without one: interactive kinds are
+// high, tenant-wide digests are low.
+func DefaultJobPriority(kind JobKind) JobPriority {
+	if kind == JobKindDigest {
+		return JobPriorityLow
+	}
+	return JobPriorityHigh
+}
+
+// Error codes of failed jobs, returned as error.code by the job status endpoint.
+const (
+	JobErrorProcessing = "processing_error"
+	JobErrorTimeout    = "job_timeout"
+	JobErrorPanic      = "job_panic"
+	// JobErrorDependency fails the later steps of a pipeline whose earlier step failed.
+	JobErrorDependency = "dependency_failed"
+)
+
+// Terminal reports whether a job in this status will no longer change.
+func (s JobStatus) Terminal() bool {
+	return s == JobStatusDone || s == JobStatusFailed
+}
+
 // Job is the canonical async unit processed by worker pipelines.
 type Job struct {
 	ID             string
@@ -31,9 +75,38 @@ type Job struct {
 	Status         JobStatus
 	Result         json.RawMessage
 	ErrorMessage   string
-	Attempts       int
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	// ErrorCode classifies the failure of the latest attempt; empty unless the job failed.
+	ErrorCode string
+	Attempts  int
+	// Tags organize reports (client, campaign); they are only changed through
+	// JobsRepository.SetJobTags.
+	Tags []string
+	// Approval only changes through a recorded HITL decision.
+	Approval JobApproval
+	// DependsOn is the previous step of a pipeline. The job is only enqueued once that
+	// job is done, and its handler receives that job's result.
+	DependsOn string
+	Priority  JobPriority
+	// TraceParent is the W3C trace context of the span that created and enqueued the job,
+	// so the job can be found in the trace of the request that asked for it.
+	TraceParent string
+	// RequestID is the X-Request-Id of the API request that created the job; empty for
+	// scheduled jobs.
+	RequestID string
+	// Checkpoint is the partial output saved while the job runs, so a retry resumes from
+	// it. It only changes through JobsRepository.SaveJobCheckpoint and is cleared once
+	// the job is done.
+	Checkpoint json.RawMessage
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	// StartedAt is set when a worker picks the job up (latest attempt); FinishedAt when it
+	// reaches a terminal status.
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+
+	// ResultArchiveKey points to the object store copy once Result has been archived.
+	ResultArchiveKey string
+	ArchivedAt       *time.Time
 }
 
 // QueueMessage is the transport format sent to queue backends.
@@ -45,6 +118,19 @@ type QueueMessage struct {
 	Payload        json.RawMessage `json:"payload"`
 	Attempt        int             `json:"attempt"`
 	RequestedAt    time.Time       `json:"requested_at"`
+	// TraceParent carries the W3C trace context of the enqueuing request to the worker.
+	TraceParent string `json:"traceparent,omitempty"`
+	// RequestID carries the ID of the request that created the job, for the worker's logs
+	// and provider calls.
+	RequestID string `json:"request_id,omitempty"`
+	// Priority selects the queue lane; empty is read as high.
+	Priority JobPriority `json:"priority,omitempty"`
+	// Upstream is the result of the job this one depends on. The processor loads it from
+	// the repository; it is not sent through the queue.
+	Upstream json.RawMessage `json:"-"`
+	// Checkpoint is the partial output saved by an earlier attempt; like Upstream it is
+	// loaded from the repository.
+	Checkpoint json.RawMessage `json:"-"`
 }
 
 type ReportListItem struct {
@@ -53,6 +139,7 @@ type ReportListItem struct {
 	Status         JobStatus
 	CreatedAt      time.Time
 	Title          string
+	Tags           []string
 }
 
 type ReportListFilter struct {
@@ -62,4 +149,59 @@ type ReportListFilter struct {
 	From     *time.Time
 	To       *time.Time
 	Topic    string
+	// Query is a free-text search over generated report titles and sections.
+	Query string
+	// Tags keeps reports carrying all of the given tags.
+	Tags []string
+}
+
+type SummaryListItem struct {
+	SummaryID      string
+	ConversationID string
+	Status         JobStatus
+	CreatedAt      time.Time
+	// Preview is the beginning of the generated summary, empty until the job is done.
+	Preview string
+}
+
+type SummaryListFilter struct {
+	TenantID       string
+	ConversationID string
+	Page           int
+	PageSize       int
+	From           *time.Time
+	To             *time.Time
+}
+
+type DigestListItem struct {
+	DigestID  string
+	Status    JobStatus
+	CreatedAt time.Time
+	// Title is empty until the job is done.
+	Title string
+}
+
+type DigestListFilter struct {
+	TenantID string
+	Page     int
+	PageSize int
+	From     *time.Time
+	To       *time.Time
+}
+
+type JobStatsFilter struct {
+	TenantID string
+	From     *time.Time
+	To       *time.Time
+}
+
+// JobStatsBucket aggregates jobs created on the same UTC day with the same kind and status.
+// Latency is measured from creation to the last update and only for finished jobs.
+type JobStatsBucket struct {
+	Day          time.Time
+	Kind         JobKind
+	Status       JobStatus
+	Count        int
+	AvgLatencyMS float64
+	MaxLatencyMS int64
 }
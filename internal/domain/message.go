@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+type MessageAuthorRole string
+
+const (
+	MessageAuthorCustomer MessageAuthorRole = "customer"
+	MessageAuthorAgent    MessageAuthorRole = "agent"
+)
+
+// Message is a conversation message pushed by the extension and kept as retrieval history.
+type Message struct {
+	ID              string
+	TenantID        string
+	ConversationID  string
+	SourceMessageID string
+	AuthorRole      MessageAuthorRole
+	Text            string
+	Checksum        string
+	CreatedAt       time.Time
+	IngestedAt      time.Time
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// PolicyViolationRecord is one request blocked by the content policy.
+type PolicyViolationRecord struct {
+	ID             string
+	TenantID       string
+	ConversationID string
+	Route          string
+	Codes          []string
+	// Rule is the keyword entry or limit that fired, empty for the auto-send guard.
+	Rule      string
+	RequestID string
+	CreatedAt time.Time
+}
+
+type PolicyViolationFilter struct {
+	TenantID string
+	From     *time.Time
+	To       *time.Time
+}
+
+// PolicyViolationCount aggregates the blocks of a tenant with the same code and rule. A
+// record with several codes counts once for each.
+type PolicyViolationCount struct {
+	TenantID   string
+	Code       string
+	Rule       string
+	Count      int
+	LastSeenAt time.Time
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Pseudonym maps a token such as "[TEL_1]" back to the PII it replaced in a conversation.
+type Pseudonym struct {
+	TenantID       string
+	ConversationID string
+	Token          string
+	Category       string
+	// ValueHash identifies the normalized value, so "+55 11 99999-8888" and
+	// "11999998888" share a token.
+	ValueHash string
+	// Value is the text first seen for the token, used to re-hydrate replies.
+	Value     string
+	CreatedAt time.Time
+}
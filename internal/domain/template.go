@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// ReplyTemplate is a tenant-scoped canned response. Placeholders are the {{name}}
+// variables found in Content, in order of first appearance.
+type ReplyTemplate struct {
+	ID           string
+	TenantID     string
+	Name         string
+	Content      string
+	Locale       string
+	Placeholders []string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type TemplateListFilter struct {
+	TenantID string
+	Page     int
+	PageSize int
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// TenantSettings is the per-tenant behavior kept in storage instead of environment
+// variables. Empty fields fall back to the process-wide defaults.
+type TenantSettings struct {
+	TenantID string
+	// Locale and Tone apply when a request or job does not set its own.
+	Locale string
+	Tone   string
+	// Models replaces the primary model per task ("suggestion", "summary", ...).
+	Models map[string]string
+	Quotas TenantQuotas
+	// PolicyProfile is the policy files entry the tenant uses when it has none of its own.
+	PolicyProfile string
+	UpdatedAt     time.Time
+}
+
+// TenantQuotas caps tenant usage; zero means unlimited.
+type TenantQuotas struct {
+	// DailyJobs caps the jobs created per UTC day.
+	DailyJobs int `json:"daily_jobs"`
+}
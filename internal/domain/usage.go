@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// TenantUsage is the metered usage of a tenant on one UTC day. Requests counts the
+// generations served, cache hits included; CostUSD only covers models with a price.
+type TenantUsage struct {
+	TenantID     string
+	Day          time.Time
+	Requests     int64
+	CacheHits    int64
+	InputTokens  int64
+	OutputTokens int64
+	CostUSD      float64
+}
+
+// TenantUsageFilter selects the days from From through To, both inclusive and truncated
+// to the day; nil bounds are open.
+type TenantUsageFilter struct {
+	TenantID string
+	From     *time.Time
+	To       *time.Time
+}
+
+// UsageDay truncates t to its UTC day.
+func UsageDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Add adds the counters of delta to u.
+func (u *TenantUsage) Add(delta TenantUsage) {
+	u.Requests += delta.Requests
+	u.CacheHits += delta.CacheHits
+	u.InputTokens += delta.InputTokens
+	u.OutputTokens += delta.OutputTokens
+	u.CostUSD += delta.CostUSD
+}
+
+// Matches reports whether usage is in the tenant and days selected by f.
+func (f TenantUsageFilter) Matches(usage TenantUsage) bool {
+	if f.TenantID != "" && usage.TenantID != f.TenantID {
+		return false
+	}
+	if f.From != nil && usage.Day.Before(UsageDay(*f.From)) {
+		return false
+	}
+	if f.To != nil && usage.Day.After(UsageDay(*f.To)) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+// Package envelope implements AES-256-GCM envelope encryption for sensitive columns.
+// Each value is sealed with a fresh data key, which is in turn wrapped by a key
+// encryption key (KEK) from a KeyWrapper (static keyring or an external KMS).
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Algorithm tags sealed values; it is stored with every envelope.
+const Algorithm = "aes-256-gcm/v1"
+
+var ErrUnknownKey = errors.New("envelope: unknown key id")
+
+// KeyWrapper protects data keys with a key encryption key.
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// sealed is the JSON representation persisted in place of the plaintext, so it still
+// fits JSONB columns.
+type sealed struct {
+	Enc   string `json:"enc"`
+	KeyID string `json:"kid"`
+	DEK   []byte `json:"dek"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"ct"`
+}
+
+type Cipher struct {
+	wrapper KeyWrapper
+}
+
+func NewCipher(wrapper KeyWrapper) *Cipher {
+	return &Cipher{wrapper: wrapper}
+}
+
+// Seal encrypts plaintext bound to aad (e.g. the row id). Empty input is returned untouched.
+func (c *Cipher) Seal(ctx context.Context, plaintext []byte, aad []byte) ([]byte, error) {
+	if len(plaintext) == 0 {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("envelope: generate data key: %w", err)
+	}
+	nonce, ciphertext, err := gcmSeal(dataKey, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	keyID, wrapped, err := c.wrapper.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: wrap data key: %w", err)
+	}
+
+	return json.Marshal(sealed{
+		Enc:   Algorithm,
+		KeyID: keyID,
+		DEK:   wrapped,
+		Nonce: nonce,
+		Data:  ciphertext,
+	})
+}
+
+// Open decrypts a value produced by Seal. Values that are not envelopes are returned
+// as-is so rows written before encryption was enabled stay readable.
+func (c *Cipher) Open(ctx context.Context, stored []byte, aad []byte) ([]byte, error) {
+	envelope, ok := parseSealed(stored)
+	if !ok {
+		return stored, nil
+	}
+
+	dataKey, err := c.wrapper.UnwrapKey(ctx, envelope.KeyID, envelope.DEK)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: unwrap data key: %w", err)
+	}
+	return gcmOpen(dataKey, envelope.Nonce, envelope.Data, aad)
+}
+
+// IsSealed reports whether value is an envelope produced by Seal.
+func IsSealed(value []byte) bool {
+	_, ok := parseSealed(value)
+	return ok
+}
+
+func parseSealed(value []byte) (sealed, bool) {
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) == 0 || trimmed[0] != '{' || !bytes.Contains(trimmed, []byte(`"enc"`)) {
+		return sealed{}, false
+	}
+	var envelope sealed
+	if err := json.Unmarshal(trimmed, &envelope); err != nil {
+		return sealed{}, false
+	}
+	if envelope.Enc != Algorithm || len(envelope.Data) == 0 {
+		return sealed{}, false
+	}
+	return envelope, true
+}
+
+func gcmSeal(key, plaintext, aad []byte) ([]byte, []byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("envelope: generate nonce: %w", err)
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+func gcmOpen(key, nonce, ciphertext, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("envelope: invalid nonce size")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: init aes: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: init gcm: %w", err)
+	}
+	return aead, nil
+}
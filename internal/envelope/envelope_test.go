@@ -0,0 +1,72 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testKeyring(t *testing.T, spec string) *Keyring {
+	t.Helper()
+	ring, err := ParseKeyring(spec)
+	if err != nil {
+		t.Fatalf("parse keyring: %v", err)
+	}
+	return ring
+}
+
+func TestCipherRoundTripAndRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	newKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	legacy := NewCipher(testKeyring(t, "k1:"+oldKey))
+	plaintext := []byte(`{"summary":"cliente pediu reembolso"}`)
+	sealedValue, err := legacy.Seal(ctx, plaintext, []byte("jobs:1"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if !IsSealed(sealedValue) || bytes.Contains(sealedValue, []byte("reembolso")) {
+		t.Fatalf("expected opaque envelope, got %s", sealedValue)
+	}
+
+	rotated := NewCipher(testKeyring(t, "k2:"+newKey+",k1:"+oldKey))
+	opened, err := rotated.Open(ctx, sealedValue, []byte("jobs:1"))
+	if err != nil {
+		t.Fatalf("open with rotated keyring: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Fatalf("unexpected plaintext: %s", opened)
+	}
+
+	if _, err := rotated.Open(ctx, sealedValue, []byte("jobs:2")); err == nil {
+		t.Fatalf("expected aad mismatch to fail")
+	}
+
+	withoutOld := NewCipher(testKeyring(t, "k2:"+newKey))
+	if _, err := withoutOld.Open(ctx, sealedValue, []byte("jobs:1")); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestCipherOpenPassesThroughPlaintext(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, 32))
+	cipher := NewCipher(testKeyring(t, "k1:"+key))
+
+	legacy := []byte(`{"enc":"gzip","summary":"texto antigo"}`)
+	opened, err := cipher.Open(context.Background(), legacy, nil)
+	if err != nil {
+		t.Fatalf("open plaintext: %v", err)
+	}
+	if !bytes.Equal(opened, legacy) {
+		t.Fatalf("expected plaintext passthrough, got %s", opened)
+	}
+}
+
+func TestParseKeyringRejectsShortKeys(t *testing.T) {
+	if _, err := ParseKeyring("k1:" + base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Fatalf("expected short key to be rejected")
+	}
+}
@@ -0,0 +1,73 @@
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Keyring is a static set of 256-bit KEKs loaded from configuration. New data keys are
+// wrapped with the primary key; older keys remain available to unwrap during rotation.
+type Keyring struct {
+	primaryID string
+	keys      map[string][]byte
+}
+
+// ParseKeyring reads "kid:base64key[,kid:base64key...]"; the first entry is the primary.
+func ParseKeyring(spec string) (*Keyring, error) {
+	ring := &Keyring{keys: make(map[string][]byte)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		keyID, encoded, ok := strings.Cut(entry, ":")
+		keyID = strings.TrimSpace(keyID)
+		if !ok || keyID == "" {
+			return nil, fmt.Errorf("envelope: invalid keyring entry %q", keyID)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("envelope: decode key %s: %w", keyID, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("envelope: key %s must have 32 bytes, got %d", keyID, len(key))
+		}
+		if _, exists := ring.keys[keyID]; exists {
+			return nil, fmt.Errorf("envelope: duplicated key id %s", keyID)
+		}
+		ring.keys[keyID] = key
+		if ring.primaryID == "" {
+			ring.primaryID = keyID
+		}
+	}
+	if ring.primaryID == "" {
+		return nil, errors.New("envelope: keyring is empty")
+	}
+	return ring, nil
+}
+
+func (k *Keyring) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	nonce, wrapped, err := gcmSeal(k.keys[k.primaryID], dataKey, []byte(k.primaryID))
+	if err != nil {
+		return "", nil, err
+	}
+	return k.primaryID, append(nonce, wrapped...), nil
+}
+
+func (k *Keyring) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("envelope: wrapped key too short")
+	}
+	return gcmOpen(key, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+}
@@ -0,0 +1,149 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/awsauth"
+)
+
+type KMSConfig struct {
+	KeyID     string
+	Region    string
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	// CacheSize bounds how many unwrapped data keys are kept to avoid a KMS call per read.
+	CacheSize  int
+	HTTPClient *http.Client
+}
+
+// KMSWrapper wraps data keys with AWS KMS Encrypt/Decrypt.
+type KMSWrapper struct {
+	config      KMSConfig
+	credentials awsauth.Credentials
+	httpClient  *http.Client
+
+	mu    sync.Mutex
+	cache map[[32]byte][]byte
+	order [][32]byte
+}
+
+func NewKMSWrapper(cfg KMSConfig) (*KMSWrapper, error) {
+	if strings.TrimSpace(cfg.KeyID) == "" {
+		return nil, errors.New("envelope: kms key id is required")
+	}
+	if strings.TrimSpace(cfg.Region) == "" {
+		cfg.Region = "us-east-1"
+	}
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		cfg.Endpoint = "https://kms." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.CacheSize <= 0 {
+		cfg.CacheSize = 1024
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &KMSWrapper{
+		config: cfg,
+		credentials: awsauth.Credentials{
+			AccessKeyID:     cfg.AccessKey,
+			SecretAccessKey: cfg.SecretKey,
+		},
+		httpClient: cfg.HTTPClient,
+		cache:      make(map[[32]byte][]byte),
+	}, nil
+}
+
+func (w *KMSWrapper) WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	var response struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	if err := w.call(ctx, "Encrypt", map[string]any{
+		"KeyId":     w.config.KeyID,
+		"Plaintext": dataKey,
+	}, &response); err != nil {
+		return "", nil, err
+	}
+	return w.config.KeyID, response.CiphertextBlob, nil
+}
+
+func (w *KMSWrapper) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	cacheKey := sha256.Sum256(wrapped)
+	if key, ok := w.cached(cacheKey); ok {
+		return key, nil
+	}
+
+	var response struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	if err := w.call(ctx, "Decrypt", map[string]any{
+		"KeyId":          keyID,
+		"CiphertextBlob": wrapped,
+	}, &response); err != nil {
+		return nil, err
+	}
+	w.remember(cacheKey, response.Plaintext)
+	return response.Plaintext, nil
+}
+
+func (w *KMSWrapper) call(ctx context.Context, operation string, input any, output any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("envelope: encode kms %s: %w", operation, err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.Endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("envelope: build kms request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "TrentService."+operation)
+	awsauth.SignRequest(request, body, w.credentials, w.config.Region, "kms", time.Now().UTC())
+
+	response, err := w.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("envelope: kms %s: %w", operation, err)
+	}
+	defer response.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("envelope: kms %s status=%d body=%s", operation, response.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	if err := json.Unmarshal(raw, output); err != nil {
+		return fmt.Errorf("envelope: decode kms %s: %w", operation, err)
+	}
+	return nil
+}
+
+func (w *KMSWrapper) cached(key [32]byte) ([]byte, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	value, ok := w.cache[key]
+	return value, ok
+}
+
+func (w *KMSWrapper) remember(key [32]byte, value []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, exists := w.cache[key]; exists {
+		return
+	}
+	if len(w.order) >= w.config.CacheSize {
+		oldest := w.order[0]
+		w.order = w.order[1:]
+		delete(w.cache, oldest)
+	}
+	w.cache[key] = value
+	w.order = append(w.order, key)
+}
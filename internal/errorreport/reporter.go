@@ -0,0 +1,134 @@
+// Package errorreport sends unexpected failures (handler 5xx, panics, failed jobs) to an
+// error tracker, with the request or job context they happened in.
+package errorreport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
+)
+
+// Level is the severity of an event.
+type Level string
+
+const (
+	LevelError Level = "error"
+	// LevelFatal marks panics.
+	LevelFatal Level = "fatal"
+)
+
+// Event is one failure to report.
+type Event struct {
+	Err   error
+	Level Level
+	// Type groups events in the tracker; empty uses the type of the innermost error.
+	Type string
+	// Tags are indexed by the tracker (route, job_kind, status...). The scope fields of the
+	// context (request_id, tenant_id, job_id...) and trace_id are added by Report.
+	Tags map[string]string
+	// Request is the HTTP request that failed; only its method and path are sent.
+	Request *http.Request
+	// Stack is the stack of a panic recovered away from where it is reported.
+	Stack []byte
+}
+
+// Reporter sends events. Report must not block the caller on the network.
+type Reporter interface {
+	Report(ctx context.Context, event Event)
+}
+
+// Nop drops every event; it is the reporter when no tracker is configured.
+type Nop struct{}
+
+func (Nop) Report(context.Context, Event) {}
+
+// Frame is one stack frame, outermost call first.
+type Frame struct {
+	Function string
+	Module   string
+	File     string
+	Line     int
+}
+
+// captured is an event with the context and stack read at Report time, before it is
+// handed to the background sender.
+type captured struct {
+	Event
+	at     time.Time
+	tags   map[string]string
+	frames []Frame
+}
+
+// capture reads the scope fields, trace and stack of the Report caller; skip counts the
+// frames above Report that belong to the reporter itself.
+func capture(ctx context.Context, event Event, skip int) captured {
+	if event.Level == "" {
+		event.Level = LevelError
+	}
+	if event.Type == "" {
+		event.Type = errorType(event.Err)
+	}
+	tags := make(map[string]string, len(event.Tags)+6)
+	for _, attr := range logging.Fields(ctx) {
+		tags[attr.Key] = attr.Value.String()
+	}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		tags["trace_id"] = traceID
+	}
+	for key, value := range event.Tags {
+		tags[key] = value
+	}
+	return captured{Event: event, at: time.Now().UTC(), tags: tags, frames: callers(skip + 1)}
+}
+
+// errorType names the innermost error of err's chain, the most specific one.
+func errorType(err error) string {
+	if err == nil {
+		return "error"
+	}
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return strings.TrimPrefix(fmt.Sprintf("%T", err), "*")
+		}
+		err = next
+	}
+}
+
+func callers(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	count := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:count])
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		module, function := splitFunction(frame.Function)
+		stack = append(stack, Frame{Function: function, Module: module, File: frame.File, Line: frame.Line})
+		if !more {
+			break
+		}
+	}
+	// Trackers list the outermost call first.
+	for left, right := 0, len(stack)-1; left < right; left, right = left+1, right-1 {
+		stack[left], stack[right] = stack[right], stack[left]
+	}
+	return stack
+}
+
+// splitFunction splits "github.com/a/b/pkg.(*T).Method" into its package path and
+// "(*T).Method".
+func splitFunction(name string) (module, function string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+1+dot+1:]
+}
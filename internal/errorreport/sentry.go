@@ -0,0 +1,265 @@
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sentryClient    = "wa-copilot-errorreport/1.0"
+	sentryQueueSize = 256
+	sendTimeout     = 10 * time.Second
+	// appModule marks the frames of this service as in_app, so the tracker groups events by
+	// our code rather than the standard library.
+	appModule = "github.com/iago/extensao-whatsapp-back"
+)
+
+var droppedEvents = expvar.NewInt("error_reports_dropped_total")
+
+// SentryConfig configures a Sentry reporter. Any Sentry-compatible tracker accepting
+// envelopes (GlitchTip, self-hosted Sentry) works.
+type SentryConfig struct {
+	// DSN is the project DSN, https://<key>@<host>/<project>.
+	DSN         string
+	Environment string
+	Release     string
+	ServerName  string
+	HTTPClient  *http.Client
+	Logger      *slog.Logger
+}
+
+// Sentry sends events to Sentry's envelope endpoint from a background goroutine; events
+// are dropped when its queue is full rather than blocking requests or jobs.
+type Sentry struct {
+	endpoint    string
+	auth        string
+	dsn         string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+	logger      *slog.Logger
+
+	queue    chan captured
+	flush    chan chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// ParseDSN splits a Sentry DSN into its envelope endpoint and public key.
+func ParseDSN(dsn string) (endpoint, key string, err error) {
+	parsed, err := url.Parse(strings.TrimSpace(dsn))
+	if err != nil {
+		return "", "", errors.New("invalid Sentry DSN")
+	}
+	project := strings.Trim(parsed.Path, "/")
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.User == nil || parsed.User.Username() == "" || project == "" {
+		return "", "", errors.New("Sentry DSN must look like https://<key>@<host>/<project>")
+	}
+	// A DSN for a tracker under a path prefix keeps the prefix before /api.
+	prefix, projectID := "", project
+	if slash := strings.LastIndex(project, "/"); slash >= 0 {
+		prefix, projectID = "/"+project[:slash], project[slash+1:]
+	}
+	endpoint = parsed.Scheme + "://" + parsed.Host + prefix + "/api/" + projectID + "/envelope/"
+	return endpoint, parsed.User.Username(), nil
+}
+
+// NewSentry validates cfg.DSN and starts the background sender.
+func NewSentry(cfg SentryConfig) (*Sentry, error) {
+	endpoint, key, err := ParseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: sendTimeout}
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	reporter := &Sentry{
+		endpoint:    endpoint,
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_key=%s, sentry_client=%s", key, sentryClient),
+		dsn:         strings.TrimSpace(cfg.DSN),
+		environment: cfg.Environment,
+		release:     cfg.Release,
+		serverName:  cfg.ServerName,
+		client:      client,
+		logger:      logger,
+		queue:       make(chan captured, sentryQueueSize),
+		flush:       make(chan chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go reporter.run()
+	return reporter, nil
+}
+
+// Report captures the context and stack of the caller and queues the event.
+func (s *Sentry) Report(ctx context.Context, event Event) {
+	select {
+	case s.queue <- capture(ctx, event, 1):
+	default:
+		droppedEvents.Add(1)
+	}
+}
+
+// Shutdown sends the queued events and stops the background sender.
+func (s *Sentry) Shutdown(ctx context.Context) {
+	s.stopOnce.Do(func() {
+		flushed := make(chan struct{})
+		select {
+		case s.flush <- flushed:
+			select {
+			case <-flushed:
+			case <-ctx.Done():
+			}
+		case <-ctx.Done():
+		}
+		close(s.done)
+	})
+}
+
+func (s *Sentry) run() {
+	for {
+		select {
+		case event := <-s.queue:
+			s.deliver(event)
+		case flushed := <-s.flush:
+			for drained := false; !drained; {
+				select {
+				case event := <-s.queue:
+					s.deliver(event)
+				default:
+					drained = true
+				}
+			}
+			close(flushed)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Sentry) deliver(event captured) {
+	if err := s.send(event); err != nil {
+		s.logger.Warn("error report failed", slog.String("type", event.Type), slog.Any("error", err))
+	}
+}
+
+func (s *Sentry) send(event captured) error {
+	body, err := s.envelope(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create error report request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-sentry-envelope")
+	request.Header.Set("X-Sentry-Auth", s.auth)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("post error report: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("error tracker status %d: %s", response.StatusCode, strings.TrimSpace(string(message)))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+	return nil
+}
+
+// envelope encodes event as a Sentry envelope: a header line, an item header line and the
+// event payload.
+func (s *Sentry) envelope(event captured) ([]byte, error) {
+	eventID := newEventID()
+	payload, err := json.Marshal(s.payload(eventID, event))
+	if err != nil {
+		return nil, fmt.Errorf("encode error report: %w", err)
+	}
+	header, _ := json.Marshal(map[string]string{
+		"event_id": eventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      s.dsn,
+	})
+	item, _ := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+
+	var body bytes.Buffer
+	body.Write(header)
+	body.WriteByte('\n')
+	body.Write(item)
+	body.WriteByte('\n')
+	body.Write(payload)
+	body.WriteByte('\n')
+	return body.Bytes(), nil
+}
+
+func (s *Sentry) payload(eventID string, event captured) map[string]any {
+	message := "unknown error"
+	if event.Err != nil {
+		message = event.Err.Error()
+	}
+	frames := make([]map[string]any, 0, len(event.frames))
+	for _, frame := range event.frames {
+		frames = append(frames, map[string]any{
+			"function": frame.Function,
+			"module":   frame.Module,
+			"abs_path": frame.File,
+			"lineno":   frame.Line,
+			"in_app":   strings.HasPrefix(frame.Module, appModule),
+		})
+	}
+	payload := map[string]any{
+		"event_id":  eventID,
+		"timestamp": event.at.Format(time.RFC3339Nano),
+		"platform":  "go",
+		"level":     string(event.Level),
+		"logger":    "errorreport",
+		"exception": map[string]any{
+			"values": []map[string]any{{
+				"type":       event.Type,
+				"value":      message,
+				"stacktrace": map[string]any{"frames": frames},
+			}},
+		},
+		"tags": event.tags,
+	}
+	for key, value := range map[string]string{"environment": s.environment, "release": s.release, "server_name": s.serverName} {
+		if value != "" {
+			payload[key] = value
+		}
+	}
+	if event.Request != nil {
+		// The query string may hold tokens; only the method and path are sent.
+		payload["request"] = map[string]any{"method": event.Request.Method, "url": event.Request.URL.Path}
+	}
+	if len(event.Stack) > 0 {
+		payload["extra"] = map[string]any{"panic_stack": string(event.Stack)}
+	}
+	return payload
+}
+
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,131 @@
+package errorreport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+)
+
+func TestParseDSN(t *testing.T) {
+	endpoint, key, err := ParseDSN("https://public@o1.ingest.sentry.io/42")
+	if err != nil || endpoint != "https://o1.ingest.sentry.io/api/42/envelope/" || key != "public" {
+		t.Fatalf("unexpected parse: %q %q %v", endpoint, key, err)
+	}
+	endpoint, _, err = ParseDSN("http://key@errors.internal/tracker/7")
+	if err != nil || endpoint != "http://errors.internal/tracker/api/7/envelope/" {
+		t.Fatalf("expected the path prefix kept, got %q %v", endpoint, err)
+	}
+	for _, dsn := range []string{"", "https://o1.ingest.sentry.io/42", "https://key@o1.ingest.sentry.io", "ftp://key@host/1"} {
+		if _, _, err := ParseDSN(dsn); err == nil {
+			t.Fatalf("expected %q to be rejected", dsn)
+		}
+	}
+}
+
+type missingRowError struct{}
+
+func (missingRowError) Error() string { return "row not found" }
+
+func TestSentrySendsEnvelopeWithContext(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		auth    string
+		path    string
+		payload []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		auth, path, payload = r.Header.Get("X-Sentry-Auth"), r.URL.Path, body
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "://", "://public@", 1) + "/42"
+	reporter, err := NewSentry(SentryConfig{DSN: dsn, Environment: "staging", Release: "v1.2.3", ServerName: "api-1"})
+	if err != nil {
+		t.Fatalf("new sentry: %v", err)
+	}
+	ctx := logging.WithScope(context.Background(), slog.String("request_id", "req-1"), slog.String("tenant_id", "tenant-a"))
+	request := httptest.NewRequest(http.MethodPost, "/v1/analysis?token=secret", nil)
+	reporter.Report(ctx, Event{
+		Err:     fmt.Errorf("analyze: %w", missingRowError{}),
+		Tags:    map[string]string{"route": "/v1/analysis"},
+		Request: request,
+	})
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reporter.Shutdown(shutdownCtx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if path != "/api/42/envelope/" || !strings.Contains(auth, "sentry_key=public") || !strings.Contains(auth, "sentry_version=7") {
+		t.Fatalf("unexpected envelope request: %s %q", path, auth)
+	}
+	lines := bufio.NewScanner(bytes.NewReader(payload))
+	var documents []map[string]any
+	for lines.Scan() {
+		var document map[string]any
+		if err := json.Unmarshal(lines.Bytes(), &document); err != nil {
+			t.Fatalf("decode envelope line %q: %v", lines.Text(), err)
+		}
+		documents = append(documents, document)
+	}
+	if len(documents) != 3 || documents[1]["type"] != "event" || documents[0]["event_id"] != documents[2]["event_id"] {
+		t.Fatalf("expected header, item and event lines, got %v", documents)
+	}
+	event := documents[2]
+	if event["level"] != "error" || event["environment"] != "staging" || event["release"] != "v1.2.3" || event["server_name"] != "api-1" {
+		t.Fatalf("unexpected event attributes: %v", event)
+	}
+	tags, _ := event["tags"].(map[string]any)
+	if tags["request_id"] != "req-1" || tags["tenant_id"] != "tenant-a" || tags["route"] != "/v1/analysis" {
+		t.Fatalf("expected scope fields and tags, got %v", tags)
+	}
+	if requestInfo, _ := event["request"].(map[string]any); requestInfo["url"] != "/v1/analysis" {
+		t.Fatalf("expected the path without its query, got %v", event["request"])
+	}
+	exception := event["exception"].(map[string]any)["values"].([]any)[0].(map[string]any)
+	if exception["type"] != "errorreport.missingRowError" || exception["value"] != "analyze: row not found" {
+		t.Fatalf("unexpected exception: %v", exception)
+	}
+	frames := exception["stacktrace"].(map[string]any)["frames"].([]any)
+	last := frames[len(frames)-1].(map[string]any)
+	if last["function"] != "TestSentrySendsEnvelopeWithContext" || last["in_app"] != true {
+		t.Fatalf("expected the caller as the innermost frame, got %v", last)
+	}
+}
+
+func TestSentryDropsEventsWhenQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	reporter, err := NewSentry(SentryConfig{DSN: strings.Replace(server.URL, "://", "://key@", 1) + "/1"})
+	if err != nil {
+		t.Fatalf("new sentry: %v", err)
+	}
+	before := droppedEvents.Value()
+	for range sentryQueueSize + 2 {
+		reporter.Report(context.Background(), Event{Err: errors.New("boom")})
+	}
+	if droppedEvents.Value() <= before {
+		t.Fatal("expected events beyond the queue to be dropped")
+	}
+}
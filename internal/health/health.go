@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+	// StatusDisabled marks dependencies that are not configured; they do not affect the
+	// overall status.
+	StatusDisabled Status = "disabled"
+)
+
+// Result is the outcome of probing a single dependency.
+type Result struct {
+	Status    Status `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// Check probes one dependency. Run must honor ctx cancellation.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) Result
+}
+
+// Report aggregates every check. Status is degraded when any dependency is not ok.
+type Report struct {
+	Status    Status            `json:"status"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checked_at"`
+}
+
+type CheckerConfig struct {
+	// Timeout bounds each check; defaults to 2s.
+	Timeout time.Duration
+	// CacheTTL reuses the last report so frequent probes do not hammer dependencies;
+	// defaults to 5s.
+	CacheTTL time.Duration
+}
+
+// Checker runs dependency checks concurrently and caches the latest report.
+type Checker struct {
+	checks   []Check
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	cached *Report
+}
+
+func NewChecker(cfg CheckerConfig, checks ...Check) *Checker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 5 * time.Second
+	}
+	return &Checker{checks: checks, timeout: cfg.Timeout, cacheTTL: cfg.CacheTTL}
+}
+
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UTC()
+	if c.cached != nil && now.Sub(c.cached.CheckedAt) < c.cacheTTL {
+		return *c.cached
+	}
+
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for index, check := range c.checks {
+		wg.Add(1)
+		go func(index int, check Check) {
+			defer wg.Done()
+			results[index] = c.runCheck(ctx, check)
+		}(index, check)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusOK,
+		Checks:    make(map[string]Result, len(c.checks)),
+		CheckedAt: now,
+	}
+	for index, check := range c.checks {
+		result := results[index]
+		report.Checks[check.Name] = result
+		if result.Status == StatusDown || result.Status == StatusDegraded {
+			report.Status = StatusDegraded
+		}
+	}
+	c.cached = &report
+	return report
+}
+
+func (c *Checker) runCheck(ctx context.Context, check Check) Result {
+	checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	done := make(chan Result, 1)
+	go func() {
+		done <- check.Run(checkCtx)
+	}()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-checkCtx.Done():
+		result = Result{Status: StatusDown, Detail: "check timed out"}
+	}
+	result.LatencyMS = time.Since(startedAt).Milliseconds()
+	return result
+}
+
+// Ping reports down when ping fails.
+func Ping(name string, ping func(ctx context.Context) error) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) Result {
+			if err := ping(ctx); err != nil {
+				return Result{Status: StatusDown, Detail: err.Error()}
+			}
+			return Result{Status: StatusOK}
+		},
+	}
+}
+
+// Disabled reports a dependency that is intentionally not configured.
+func Disabled(name, detail string) Check {
+	return Check{
+		Name: name,
+		Run: func(context.Context) Result {
+			return Result{Status: StatusDisabled, Detail: detail}
+		},
+	}
+}
+
+// QueueDepth reports degraded once the backlog reaches warnAt messages.
+func QueueDepth(name string, depth func(ctx context.Context) (int64, error), warnAt int64) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) Result {
+			value, err := depth(ctx)
+			if err != nil {
+				return Result{Status: StatusDown, Detail: err.Error()}
+			}
+			detail := fmt.Sprintf("%d messages waiting", value)
+			if warnAt > 0 && value >= warnAt {
+				return Result{Status: StatusDegraded, Detail: detail}
+			}
+			return Result{Status: StatusOK, Detail: detail}
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckerAggregatesDependencyStatus(t *testing.T) {
+	checker := NewChecker(CheckerConfig{Timeout: 50 * time.Millisecond},
+		Ping("postgres", func(context.Context) error { return nil }),
+		Ping("redis", func(context.Context) error { return errors.New("connection refused") }),
+		Disabled("ai_provider", "api key not configured"),
+	)
+
+	report := checker.Run(context.Background())
+	if report.Status != StatusDegraded {
+		t.Fatalf("expected degraded overall status, got %s", report.Status)
+	}
+	if got := report.Checks["postgres"].Status; got != StatusOK {
+		t.Fatalf("expected postgres ok, got %s", got)
+	}
+	if got := report.Checks["redis"]; got.Status != StatusDown || got.Detail != "connection refused" {
+		t.Fatalf("expected redis down with detail, got %+v", got)
+	}
+	if got := report.Checks["ai_provider"].Status; got != StatusDisabled {
+		t.Fatalf("expected ai_provider disabled, got %s", got)
+	}
+}
+
+func TestCheckerIgnoresDisabledAndTimesOutSlowChecks(t *testing.T) {
+	checker := NewChecker(CheckerConfig{Timeout: 20 * time.Millisecond},
+		Disabled("postgres", "in-memory repository"),
+	)
+	if report := checker.Run(context.Background()); report.Status != StatusOK {
+		t.Fatalf("expected disabled dependencies to keep status ok, got %s", report.Status)
+	}
+
+	slow := NewChecker(CheckerConfig{Timeout: 20 * time.Millisecond}, Check{
+		Name: "slow",
+		Run: func(ctx context.Context) Result {
+			time.Sleep(200 * time.Millisecond)
+			return Result{Status: StatusOK}
+		},
+	})
+	if got := slow.Run(context.Background()).Checks["slow"].Status; got != StatusDown {
+		t.Fatalf("expected timed out check to be down, got %s", got)
+	}
+}
+
+func TestQueueDepthDegradesAboveThreshold(t *testing.T) {
+	check := QueueDepth("queue", func(context.Context) (int64, error) { return 1500, nil }, 1000)
+	if result := check.Run(context.Background()); result.Status != StatusDegraded {
+		t.Fatalf("expected degraded for deep queue, got %+v", result)
+	}
+}
@@ -0,0 +1,36 @@
+package health
+
+import "sync"
+
+// Readiness tracks whether the process should receive traffic. It starts not ready and
+// is flipped once initialization finishes, then back while draining on shutdown.
+type Readiness struct {
+	mu     sync.RWMutex
+	ready  bool
+	reason string
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{reason: "starting"}
+}
+
+func (r *Readiness) MarkReady() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = true
+	r.reason = ""
+}
+
+func (r *Readiness) MarkNotReady(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = false
+	r.reason = reason
+}
+
+// State returns whether the process is ready and, when it is not, why.
+func (r *Readiness) State() (bool, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ready, r.reason
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+// ActionItems extracts the conversation's pending tasks (owner, due hint, source
+// message) without generating a summary.
+func (api *API) ActionItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.actionItemsService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "action items are not configured")
+		return
+	}
+
+	var request actionItemsRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+
+	errs := validateConversation(request.Conversation, "conversation")
+	request.Locale = api.resolveTenantLocale(r, request.Conversation.TenantID, request.Locale)
+	if request.ContextWindow == 0 {
+		request.ContextWindow = 20
+	}
+	if request.ContextWindow < 5 || request.ContextWindow > 80 {
+		errs.add("context_window", fieldCodeOutOfRange, "context_window must be between 5 and 80")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	if !authorizeConversation(w, r, request.Conversation) {
+		return
+	}
+
+	// The context builder reads structured messages, same as /v1/analysis.
+	rawPayload, _ := json.Marshal(analysisRequestV2{
+		Conversation:  request.Conversation,
+		Locale:        request.Locale,
+		ContextWindow: request.ContextWindow,
+		Messages:      sanitizeConversationMessages(upgradeLegacyMessages(request.Messages), request.ContextWindow),
+	})
+	policyWarnings, err := policy.CheckContentPolicy(request.Conversation.TenantID, rawPayload)
+	if err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
+		message := "request blocked by policy"
+		var violation *policy.PolicyViolationError
+		if errors.As(err, &violation) && len(violation.Violations) > 0 {
+			message = violation.Violations[0].Message
+		}
+		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", message)
+		return
+	}
+	rawPayload, err = api.pseudonymsService.ProtectJSON(
+		r.Context(),
+		request.Conversation.TenantID,
+		request.Conversation.ConversationID,
+		rawPayload,
+	)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to protect personal data")
+		return
+	}
+
+	output, err := api.actionItemsService.Extract(r.Context(), service.ActionItemsInput{
+		TenantID:       request.Conversation.TenantID,
+		ConversationID: request.Conversation.ConversationID,
+		Locale:         request.Locale,
+		Payload:        rawPayload,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to extract action items")
+		return
+	}
+
+	w.Header().Set("Content-Language", request.Locale)
+	writeJSON(w, http.StatusOK, withPolicyWarnings(map[string]any{
+		"request_id":     middleware.GetRequestID(r.Context()),
+		"locale":         request.Locale,
+		"model_id":       output.ModelID,
+		"prompt_version": output.PromptVersion,
+		"action_items":   output.ActionItems,
+		"quality_score":  output.QualityScore,
+		"hitl":           policy.DefaultHITLMetadata(),
+	}, policyWarnings))
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/audit"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+)
+
+// AdminCacheFlush drops every semantic cache entry.
+func (api *API) AdminCacheFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.admin.Cache == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "cache is not configured")
+		return
+	}
+	flushed := api.admin.Cache.Flush()
+	audit.AddMetadata(r.Context(), "flushed", flushed)
+	writeJSON(w, http.StatusOK, map[string]any{"flushed": flushed})
+}
+
+// AdminConfig returns the runtime configuration with credentials redacted, and where each
+// variable came from.
+func (api *API) AdminConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.admin.Config == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "config snapshot is not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"config": api.admin.Config, "sources": api.admin.ConfigSources})
+}
+
+// AdminRateLimits lists the per-client rate limit buckets.
+func (api *API) AdminRateLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.admin.RateLimits == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "rate limit state is not available")
+		return
+	}
+	writeJSON(w, http.StatusOK, api.admin.RateLimits.Snapshot())
+}
+
+// AdminReload applies the reloadable settings (POST), like SIGHUP does, or returns the
+// report of the latest reload (GET).
+func (api *API) AdminReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.admin.Reloader == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "configuration reload is not configured")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		report, ok := api.admin.Reloader.Last()
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "not_found", "configuration was not reloaded yet")
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+		return
+	}
+
+	report := api.admin.Reloader.Reload("admin")
+	audit.AddMetadata(r.Context(), "ok", report.OK)
+	status := http.StatusOK
+	if !report.OK {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, report)
+}
+
+// AdminWorker reports (GET) and toggles (PUT {"enabled": bool}) job processing, along
+// with the current worker pool size.
+func (api *API) AdminWorker(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.admin.Worker == nil {
+		writeError(w, r, http.StatusConflict, "conflict", "worker is not running in this process")
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var request adminWorkerRequest
+		if err := decodeJSON(r, &request); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+			return
+		}
+		if request.Enabled == nil {
+			var errs fieldErrors
+			errs.add("enabled", fieldCodeRequired, "enabled is required")
+			writeValidationErrors(w, r, errs)
+			return
+		}
+		audit.AddMetadata(r.Context(), "enabled", *request.Enabled)
+		if *request.Enabled {
+			api.admin.Worker.Resume()
+		} else {
+			api.admin.Worker.Pause()
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"enabled":     !api.admin.Worker.Paused(),
+		"concurrency": api.admin.Worker.Concurrency(),
+	})
+}
+
+// Debug filters need an expiry, so a forgotten one does not flood the logs.
+const (
+	defaultLogFilterTTL = 15 * time.Minute
+	maxLogLevelTTL      = 24 * time.Hour
+)
+
+// AdminLogLevel reads (GET), changes (PUT) and resets (DELETE) the log level of this
+// process. A PUT with tenant_id or conversation_id only lowers the level for their records.
+func (api *API) AdminLogLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	levels := api.admin.LogLevels
+	if levels == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "runtime log levels are not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var request adminLogLevelRequest
+		if err := decodeJSON(r, &request); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+			return
+		}
+		request.TenantID = strings.TrimSpace(request.TenantID)
+		request.ConversationID = strings.TrimSpace(request.ConversationID)
+		var errs fieldErrors
+		level, err := logging.ParseLevel(request.Level)
+		if strings.TrimSpace(request.Level) == "" {
+			errs.add("level", fieldCodeRequired, "level is required")
+		} else if err != nil {
+			errs.add("level", fieldCodeInvalidValue, "level must be debug, info, warn or error")
+		}
+		if len(request.TenantID) > 64 {
+			errs.add("tenant_id", fieldCodeTooLong, "tenant_id must have at most 64 chars")
+		}
+		if len(request.ConversationID) > 128 {
+			errs.add("conversation_id", fieldCodeTooLong, "conversation_id must have at most 128 chars")
+		}
+		ttl := time.Duration(request.TTLSeconds) * time.Second
+		if request.TTLSeconds < 0 || ttl > maxLogLevelTTL {
+			errs.add("ttl_seconds", fieldCodeOutOfRange, "ttl_seconds must be between 0 and 86400")
+		}
+		if len(errs) > 0 {
+			writeValidationErrors(w, r, errs)
+			return
+		}
+
+		audit.AddMetadata(r.Context(), "level", levelName(level))
+		if request.TenantID == "" && request.ConversationID == "" {
+			levels.SetLevel(level, ttl)
+			break
+		}
+		if ttl == 0 {
+			ttl = defaultLogFilterTTL
+		}
+		audit.AddMetadata(r.Context(), "tenant_id", request.TenantID)
+		audit.AddMetadata(r.Context(), "conversation_id", request.ConversationID)
+		levels.SetDebugFilter(logging.DebugFilter{
+			Level:          level,
+			TenantID:       request.TenantID,
+			ConversationID: request.ConversationID,
+			ExpiresAt:      time.Now().UTC().Add(ttl),
+		})
+	case http.MethodDelete:
+		levels.Reset()
+	}
+
+	response := map[string]any{
+		"level":            levelName(levels.Level()),
+		"configured_level": levelName(levels.Configured()),
+	}
+	if until := levels.Until(); !until.IsZero() {
+		response["expires_at"] = until.UTC()
+	}
+	if filter, ok := levels.DebugFilter(); ok {
+		response["filter"] = map[string]any{
+			"level":           levelName(filter.Level),
+			"tenant_id":       filter.TenantID,
+			"conversation_id": filter.ConversationID,
+			"expires_at":      filter.ExpiresAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// levelName spells a level the way LOG_LEVEL does.
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// AdminSLO reports the latency objectives over their rolling window, with the error
+// budgets and burn rates.
+func (api *API) AdminSLO(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.admin.SLO == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "slo tracking is not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"objectives": api.admin.SLO.Report()})
+}
+
+// AdminPolicyViolations aggregates blocked requests by tenant, code and matched rule, so
+// rules with many false positives stand out. tenant_id narrows it to one tenant.
+func (api *API) AdminPolicyViolations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.policyViolations == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "policy violation trail is not configured")
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := parseOptionalDateTime(query.Get("from"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid from date")
+		return
+	}
+	to, err := parseOptionalDateTime(query.Get("to"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid to date")
+		return
+	}
+	if to == nil {
+		now := time.Now().UTC()
+		to = &now
+	}
+	if from == nil {
+		start := to.Add(-defaultStatsWindow)
+		from = &start
+	}
+	if from.After(*to) {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "from must be before to")
+		return
+	}
+
+	counts, err := api.policyViolations.Counts(r.Context(), domain.PolicyViolationFilter{
+		TenantID: strings.TrimSpace(query.Get("tenant_id")),
+		From:     from,
+		To:       to,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to load policy violations")
+		return
+	}
+
+	byCode := make(map[string]int)
+	byTenant := make(map[string]int)
+	items := make([]map[string]any, 0, len(counts))
+	for _, count := range counts {
+		byCode[count.Code] += count.Count
+		byTenant[count.TenantID] += count.Count
+		items = append(items, map[string]any{
+			"tenant_id":    count.TenantID,
+			"code":         count.Code,
+			"rule":         count.Rule,
+			"count":        count.Count,
+			"last_seen_at": count.LastSeenAt.Format(time.RFC3339Nano),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"from":  from.Format(time.RFC3339Nano),
+		"to":    to.Format(time.RFC3339Nano),
+		"items": items,
+		"totals": map[string]any{
+			"by_code":   byCode,
+			"by_tenant": byTenant,
+		},
+	})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+func (api *API) Analysis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.analysisService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "analysis is not configured")
+		return
+	}
+
+	var request analysisRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+	api.analyzeConversation(w, r, analysisRequestV2{
+		Conversation:  request.Conversation,
+		Locale:        request.Locale,
+		ContextWindow: request.ContextWindow,
+		Messages:      upgradeLegacyMessages(request.Messages),
+	}, nil)
+}
+
+func (api *API) AnalysisV2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.analysisService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "analysis is not configured")
+		return
+	}
+
+	var request analysisRequestV2
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+	api.analyzeConversation(w, r, request, validateConversationMessages(request.Messages))
+}
+
+func (api *API) analyzeConversation(
+	w http.ResponseWriter,
+	r *http.Request,
+	request analysisRequestV2,
+	errs fieldErrors,
+) {
+	errs = append(errs, validateConversation(request.Conversation, "conversation")...)
+	request.Locale = api.resolveTenantLocale(r, request.Conversation.TenantID, request.Locale)
+	if request.ContextWindow == 0 {
+		request.ContextWindow = 20
+	}
+	if request.ContextWindow < 5 || request.ContextWindow > 80 {
+		errs.add("context_window", fieldCodeOutOfRange, "context_window must be between 5 and 80")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	if !authorizeConversation(w, r, request.Conversation) {
+		return
+	}
+	request.Messages = sanitizeConversationMessages(request.Messages, request.ContextWindow)
+
+	rawPayload, _ := json.Marshal(request)
+	policyWarnings, err := policy.CheckContentPolicy(request.Conversation.TenantID, rawPayload)
+	if err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
+		message := "request blocked by policy"
+		var violation *policy.PolicyViolationError
+		if errors.As(err, &violation) && len(violation.Violations) > 0 {
+			message = violation.Violations[0].Message
+		}
+		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", message)
+		return
+	}
+	rawPayload, err = api.pseudonymsService.ProtectJSON(
+		r.Context(),
+		request.Conversation.TenantID,
+		request.Conversation.ConversationID,
+		rawPayload,
+	)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to protect personal data")
+		return
+	}
+
+	output, err := api.analysisService.Analyze(r.Context(), service.AnalysisInput{
+		TenantID:       request.Conversation.TenantID,
+		ConversationID: request.Conversation.ConversationID,
+		Locale:         request.Locale,
+		Payload:        rawPayload,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to analyze conversation")
+		return
+	}
+
+	w.Header().Set("Content-Language", request.Locale)
+	writeJSON(w, http.StatusOK, withPolicyWarnings(map[string]any{
+		"request_id":        middleware.GetRequestID(r.Context()),
+		"locale":            request.Locale,
+		"model_id":          output.ModelID,
+		"prompt_version":    output.PromptVersion,
+		"sentiment":         output.Sentiment,
+		"sentiment_score":   output.SentimentScore,
+		"urgency":           output.Urgency,
+		"intent":            output.Intent,
+		"intent_confidence": output.IntentConfidence,
+		"labels":            output.Labels,
+		"rationale":         output.Rationale,
+		"quality_score":     output.QualityScore,
+		"hitl":              policy.DefaultHITLMetadata(),
+	}, policyWarnings))
+}
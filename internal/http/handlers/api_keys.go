@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+const maxAPIKeyNameRunes = 80
+
+// AdminAPIKeys lists (GET ?tenant_id=) and creates (POST) tenant API keys.
+func (api *API) AdminAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.admin.APIKeys == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "api keys are not configured")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		keys, err := api.admin.APIKeys.List(r.Context(), domain.APIKeyListFilter{
+			TenantID:       strings.TrimSpace(query.Get("tenant_id")),
+			IncludeRevoked: query.Get("include_revoked") == "true",
+		})
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to list api keys")
+			return
+		}
+		items := make([]map[string]any, 0, len(keys))
+		for _, key := range keys {
+			items = append(items, apiKeyResponse(key))
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": items})
+		return
+	}
+
+	var request apiKeyCreateRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+	var errs fieldErrors
+	request.TenantID = strings.TrimSpace(request.TenantID)
+	switch {
+	case request.TenantID == "":
+		errs.add("tenant_id", fieldCodeRequired, "tenant_id is required")
+	case len(request.TenantID) > 64:
+		errs.add("tenant_id", fieldCodeTooLong, "tenant_id must have at most 64 chars")
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	if len([]rune(request.Name)) > maxAPIKeyNameRunes {
+		errs.add("name", fieldCodeTooLong, "name must have at most 80 characters")
+	}
+	scopes, scopeErrs := normalizeAPIKeyScopes(request.Scopes)
+	errs = append(errs, scopeErrs...)
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	issued, err := api.admin.APIKeys.Create(r.Context(), service.CreateAPIKeyInput{
+		TenantID:  request.TenantID,
+		Name:      request.Name,
+		Scopes:    scopes,
+		Actor:     "admin_token",
+		RequestID: middleware.GetRequestID(r.Context()),
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to create api key")
+		return
+	}
+	writeJSON(w, http.StatusCreated, issuedAPIKeyResponse(issued))
+}
+
+// AdminAPIKeyDetail serves GET and DELETE (revoke) on /admin/api-keys/{id} and
+// POST /admin/api-keys/{id}/rotate.
+func (api *API) AdminAPIKeyDetail(w http.ResponseWriter, r *http.Request) {
+	if api.admin.APIKeys == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "api keys are not configured")
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/api-keys/"), "/")
+	keyID, action, _ := strings.Cut(path, "/")
+	if keyID == "" || (action != "" && action != "rotate") {
+		writeError(w, r, http.StatusNotFound, "not_found", "api key not found")
+		return
+	}
+	requestID := middleware.GetRequestID(r.Context())
+
+	switch {
+	case action == "rotate" && r.Method == http.MethodPost:
+		issued, err := api.admin.APIKeys.Rotate(r.Context(), keyID, "admin_token", requestID)
+		if err != nil {
+			writeAPIKeyError(w, r, err, "failed to rotate api key")
+			return
+		}
+		writeJSON(w, http.StatusCreated, issuedAPIKeyResponse(issued))
+	case action == "" && r.Method == http.MethodGet:
+		key, err := api.admin.APIKeys.Get(r.Context(), keyID)
+		if err != nil {
+			writeAPIKeyError(w, r, err, "failed to load api key")
+			return
+		}
+		writeJSON(w, http.StatusOK, apiKeyResponse(*key))
+	case action == "" && r.Method == http.MethodDelete:
+		key, err := api.admin.APIKeys.Revoke(r.Context(), keyID, "admin_token", requestID)
+		if err != nil {
+			writeAPIKeyError(w, r, err, "failed to revoke api key")
+			return
+		}
+		writeJSON(w, http.StatusOK, apiKeyResponse(*key))
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+func writeAPIKeyError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, http.StatusNotFound, "not_found", "api key not found or already revoked")
+		return
+	}
+	writeError(w, r, http.StatusInternalServerError, "internal_error", message)
+}
+
+// normalizeAPIKeyScopes lowercases and deduplicates scopes, keeping request order.
+func normalizeAPIKeyScopes(raw []string) ([]string, fieldErrors) {
+	var errs fieldErrors
+	if len(raw) == 0 {
+		errs.add("scopes", fieldCodeRequired, "scopes is required")
+		return nil, errs
+	}
+	allowed := make(map[string]struct{}, len(service.APIKeyScopes))
+	for _, scope := range service.APIKeyScopes {
+		allowed[scope] = struct{}{}
+	}
+
+	scopes := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for index, value := range raw {
+		scope := strings.ToLower(strings.TrimSpace(value))
+		if _, ok := allowed[scope]; !ok {
+			errs.add(indexedPath("scopes", index), fieldCodeInvalidValue, "scope must be suggestions, reports or admin")
+			continue
+		}
+		if _, duplicated := seen[scope]; duplicated {
+			continue
+		}
+		seen[scope] = struct{}{}
+		scopes = append(scopes, scope)
+	}
+	return scopes, errs
+}
+
+func apiKeyResponse(key domain.APIKey) map[string]any {
+	response := map[string]any{
+		"key_id":     key.ID,
+		"tenant_id":  key.TenantID,
+		"name":       key.Name,
+		"prefix":     key.Prefix,
+		"scopes":     nonNilTags(key.Scopes),
+		"created_at": key.CreatedAt.Format(time.RFC3339Nano),
+		"active":     key.Active(),
+	}
+	if key.LastUsedAt != nil {
+		response["last_used_at"] = key.LastUsedAt.Format(time.RFC3339Nano)
+	}
+	if key.RevokedAt != nil {
+		response["revoked_at"] = key.RevokedAt.Format(time.RFC3339Nano)
+	}
+	if key.RotatedFrom != "" {
+		response["rotated_from"] = key.RotatedFrom
+	}
+	return response
+}
+
+// issuedAPIKeyResponse is the only response carrying the secret; it cannot be recovered
+// afterwards.
+func issuedAPIKeyResponse(issued service.IssuedAPIKey) map[string]any {
+	response := apiKeyResponse(issued.Key)
+	response["api_key"] = issued.Secret
+	return response
+}
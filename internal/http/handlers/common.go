@@ -3,32 +3,145 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
-	"hash/fnv"
+	"log/slog"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+	"github.com/iago/extensao-whatsapp-back/internal/config"
+	"github.com/iago/extensao-whatsapp-back/internal/health"
 	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
 	"github.com/iago/extensao-whatsapp-back/internal/service"
+	"github.com/iago/extensao-whatsapp-back/internal/slo"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+	"github.com/iago/extensao-whatsapp-back/internal/worker"
 )
 
 var errInvalidPayload = errors.New("invalid payload")
 
+type APIDependencies struct {
+	Jobs        *service.JobsService
+	Suggestions *service.SuggestionsService
+	Erasure     *service.ErasureService
+	Analysis    *service.AnalysisService
+	Questions   *service.QuestionsService
+	ActionItems *service.ActionItemsService
+	Compose     *service.ComposeService
+	Digests     *service.DigestsService
+	Insights    *service.InsightsService
+	Messages    *service.MessagesService
+	Pseudonyms  *service.PseudonymsService
+	// PolicyViolations records blocked requests; nil skips the trail.
+	PolicyViolations *service.PolicyViolationsService
+	HITL             *service.HITLService
+	Templates        *service.TemplatesService
+	// TenantSettings supplies tenant default locale and tone; nil uses the built-in ones.
+	TenantSettings *service.TenantSettingsService
+	// Metering serves GET /v1/usage; nil answers 501.
+	Metering  *service.MeteringService
+	Health    *health.Checker
+	Readiness *health.Readiness
+	Admin     AdminDependencies
+}
+
+// AdminDependencies backs the /admin namespace. Missing members answer 501, except
+// Worker which answers 409 when this process runs no worker.
+type AdminDependencies struct {
+	Cache  CacheFlusher
+	Config map[string]any
+	// ConfigSources maps each variable to env, the .env file that set it or default.
+	ConfigSources map[string]string
+	RateLimits    *middleware.RateLimiter
+	Worker        WorkerControl
+	APIKeys       *service.APIKeysService
+	Reloader      ConfigReloader
+	// SLO reports the latency objectives and their error budgets.
+	SLO *slo.Tracker
+	// LogLevels changes the log level of this process at runtime.
+	LogLevels *logging.Levels
+}
+
+type CacheFlusher interface {
+	Flush() int
+}
+
+// ConfigReloader applies the settings that can change without a restart.
+type ConfigReloader interface {
+	Reload(trigger string) config.ReloadReport
+	Last() (config.ReloadReport, bool)
+}
+
+type WorkerControl interface {
+	Pause()
+	Resume()
+	Paused() bool
+	Concurrency() worker.ConcurrencySnapshot
+}
+
 type API struct {
 	jobsService        *service.JobsService
 	suggestionsService *service.SuggestionsService
-	idempotency        *idempotencyStore
+	erasureService     *service.ErasureService
+	analysisService    *service.AnalysisService
+	questionsService   *service.QuestionsService
+	actionItemsService *service.ActionItemsService
+	composeService     *service.ComposeService
+	digestsService     *service.DigestsService
+	insightsService    *service.InsightsService
+	messagesService    *service.MessagesService
+	pseudonymsService  *service.PseudonymsService
+	policyViolations   *service.PolicyViolationsService
+	hitlService        *service.HITLService
+	templatesService   *service.TemplatesService
+	tenantSettings     *service.TenantSettingsService
+	metering           *service.MeteringService
+	health             *health.Checker
+	readiness          *health.Readiness
+	admin              AdminDependencies
 }
 
-func NewAPI(jobsService *service.JobsService, suggestionsService *service.SuggestionsService) *API {
+func NewAPI(deps APIDependencies) *API {
 	return &API{
-		jobsService:        jobsService,
-		suggestionsService: suggestionsService,
-		idempotency:        newIdempotencyStore(),
+		jobsService:        deps.Jobs,
+		suggestionsService: deps.Suggestions,
+		erasureService:     deps.Erasure,
+		analysisService:    deps.Analysis,
+		questionsService:   deps.Questions,
+		actionItemsService: deps.ActionItems,
+		composeService:     deps.Compose,
+		digestsService:     deps.Digests,
+		insightsService:    deps.Insights,
+		messagesService:    deps.Messages,
+		pseudonymsService:  deps.Pseudonyms,
+		policyViolations:   deps.PolicyViolations,
+		hitlService:        deps.HITL,
+		templatesService:   deps.Templates,
+		tenantSettings:     deps.TenantSettings,
+		metering:           deps.Metering,
+		health:             deps.Health,
+		readiness:          deps.Readiness,
+		admin:              deps.Admin,
 	}
 }
 
+// recordPolicyViolation keeps a policy block for rule tuning. A failed write does not
+// change the 422 answer.
+func (api *API) recordPolicyViolation(r *http.Request, tenantID, conversationID string, err error) {
+	if api.policyViolations == nil {
+		return
+	}
+	_ = api.policyViolations.Record(r.Context(), service.RecordPolicyViolationInput{
+		TenantID:       tenantID,
+		ConversationID: conversationID,
+		Route:          r.Method + " " + r.URL.Path,
+		RequestID:      middleware.GetRequestID(r.Context()),
+		Err:            err,
+	})
+}
+
 type conversationRef struct {
 	TenantID       string `json:"tenant_id"`
 	ConversationID string `json:"conversation_id"`
@@ -39,18 +152,89 @@ type suggestionRequest struct {
 	Conversation           conversationRef `json:"conversation"`
 	Locale                 string          `json:"locale"`
 	Tone                   string          `json:"tone"`
+	Mode                   string          `json:"mode,omitempty"`
 	ContextWindow          int             `json:"context_window"`
 	Messages               []string        `json:"messages,omitempty"`
 	MaxCandidates          int             `json:"max_candidates,omitempty"`
 	IncludeLastUserMessage bool            `json:"include_last_user_message,omitempty"`
 }
 
+type analysisRequest struct {
+	Conversation  conversationRef `json:"conversation"`
+	Locale        string          `json:"locale"`
+	ContextWindow int             `json:"context_window"`
+	Messages      []string        `json:"messages,omitempty"`
+}
+
+type questionsRequest struct {
+	Conversation  conversationRef `json:"conversation"`
+	Locale        string          `json:"locale"`
+	ContextWindow int             `json:"context_window"`
+	Messages      []string        `json:"messages,omitempty"`
+}
+
+type actionItemsRequest struct {
+	Conversation  conversationRef `json:"conversation"`
+	Locale        string          `json:"locale"`
+	ContextWindow int             `json:"context_window"`
+	Messages      []string        `json:"messages,omitempty"`
+}
+
+type composeRequest struct {
+	Conversation  conversationRef `json:"conversation"`
+	Locale        string          `json:"locale"`
+	Tone          string          `json:"tone"`
+	ContextWindow int             `json:"context_window"`
+	Messages      []string        `json:"messages,omitempty"`
+	Draft         string          `json:"draft"`
+}
+
+type digestRequest struct {
+	TenantID string `json:"tenant_id"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+type suggestionRequestV2 struct {
+	Conversation           conversationRef       `json:"conversation"`
+	Locale                 string                `json:"locale"`
+	Tone                   string                `json:"tone"`
+	Mode                   string                `json:"mode,omitempty"`
+	ContextWindow          int                   `json:"context_window"`
+	Messages               []conversationMessage `json:"messages,omitempty"`
+	MaxCandidates          int                   `json:"max_candidates,omitempty"`
+	IncludeLastUserMessage bool                  `json:"include_last_user_message,omitempty"`
+}
+
+type analysisRequestV2 struct {
+	Conversation  conversationRef       `json:"conversation"`
+	Locale        string                `json:"locale"`
+	ContextWindow int                   `json:"context_window"`
+	Messages      []conversationMessage `json:"messages,omitempty"`
+}
+
+type messagesIngestRequest struct {
+	TenantID string                 `json:"tenant_id"`
+	Channel  string                 `json:"channel,omitempty"`
+	Messages []ingestMessageRequest `json:"messages"`
+}
+
+type ingestMessageRequest struct {
+	MessageID  string `json:"message_id"`
+	AuthorRole string `json:"author_role"`
+	Text       string `json:"text"`
+	SentAt     string `json:"sent_at"`
+}
+
 type summaryRequest struct {
 	Conversation   conversationRef `json:"conversation"`
 	SummaryType    string          `json:"summary_type"`
 	IncludeActions bool            `json:"include_actions"`
 	From           string          `json:"from,omitempty"`
 	To             string          `json:"to,omitempty"`
+	// Priority is high or low; backfills use low so they never delay interactive jobs.
+	Priority string `json:"priority,omitempty"`
 }
 
 type reportRequest struct {
@@ -61,6 +245,68 @@ type reportRequest struct {
 	To           string          `json:"to,omitempty"`
 	Page         int             `json:"page,omitempty"`
 	PageSize     int             `json:"page_size,omitempty"`
+	Tags         []string        `json:"tags,omitempty"`
+	// WithSummary runs a fresh summary first and builds the report on it.
+	WithSummary bool   `json:"with_summary,omitempty"`
+	Priority    string `json:"priority,omitempty"`
+	// Sections are the headings of a report generated one section at a time; the
+	// sections done are kept if the job is retried.
+	Sections []string `json:"sections,omitempty"`
+}
+
+type reportPatchRequest struct {
+	Tags *[]string `json:"tags"`
+}
+
+type hitlDecisionRequest struct {
+	TenantID            string `json:"tenant_id"`
+	ConversationID      string `json:"conversation_id"`
+	JobID               string `json:"job_id,omitempty"`
+	SuggestionRequestID string `json:"suggestion_request_id,omitempty"`
+	SuggestionRank      int    `json:"suggestion_rank,omitempty"`
+	Decision            string `json:"decision"`
+	ReviewerID          string `json:"reviewer_id"`
+	FinalText           string `json:"final_text,omitempty"`
+	DecidedAt           string `json:"decided_at,omitempty"`
+}
+
+type jobDecisionRequest struct {
+	ReviewerID string          `json:"reviewer_id"`
+	Result     json.RawMessage `json:"result,omitempty"`
+}
+
+type templateRequest struct {
+	TenantID string `json:"tenant_id"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+type apiKeyCreateRequest struct {
+	TenantID string   `json:"tenant_id"`
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes"`
+}
+
+type tenantSettingsRequest struct {
+	Locale string            `json:"locale"`
+	Tone   string            `json:"tone"`
+	Models map[string]string `json:"models"`
+	Quotas struct {
+		DailyJobs int `json:"daily_jobs"`
+	} `json:"quotas"`
+	PolicyProfile string `json:"policy_profile"`
+}
+
+type adminWorkerRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type adminLogLevelRequest struct {
+	Level          string `json:"level"`
+	TenantID       string `json:"tenant_id"`
+	ConversationID string `json:"conversation_id"`
+	TTLSeconds     int    `json:"ttl_seconds"`
 }
 
 type errorPayload struct {
@@ -68,7 +314,8 @@ type errorPayload struct {
 		Code    string `json:"code"`
 		Message string `json:"message"`
 	} `json:"error"`
-	RequestID string `json:"request_id"`
+	Errors    fieldErrors `json:"errors,omitempty"`
+	RequestID string      `json:"request_id"`
 }
 
 func writeJSON(w http.ResponseWriter, statusCode int, value any) {
@@ -78,12 +325,26 @@ func writeJSON(w http.ResponseWriter, statusCode int, value any) {
 }
 
 func writeError(w http.ResponseWriter, r *http.Request, statusCode int, code, message string) {
-	payload := errorPayload{RequestID: middleware.GetRequestID(r.Context())}
+	writeErrorPayload(w, r, statusCode, code, message, nil)
+}
+
+func writeErrorPayload(w http.ResponseWriter, r *http.Request, statusCode int, code, message string, errs fieldErrors) {
+	payload := errorPayload{RequestID: middleware.GetRequestID(r.Context()), Errors: errs}
 	payload.Error.Code = code
 	payload.Error.Message = message
 	writeJSON(w, statusCode, payload)
 }
 
+// writeEnqueueError answers a failed enqueue: 429 once the tenant used its daily job
+// quota, 500 otherwise.
+func writeEnqueueError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		writeError(w, r, http.StatusTooManyRequests, "quota_exceeded", "tenant daily job quota exceeded")
+		return
+	}
+	writeError(w, r, http.StatusInternalServerError, "internal_error", message)
+}
+
 func decodeJSON(r *http.Request, value any) error {
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
@@ -93,23 +354,43 @@ func decodeJSON(r *http.Request, value any) error {
 	return nil
 }
 
-func validateConversation(conversation conversationRef) error {
-	tenantID := strings.TrimSpace(conversation.TenantID)
-	conversationID := strings.TrimSpace(conversation.ConversationID)
-
-	if tenantID == "" || len(tenantID) > 64 {
-		return errInvalidPayload
+// authorizeTenant rejects requests addressing a tenant other than the one the request
+// is scoped to (X-Tenant-ID). It writes the 403 response and returns false on mismatch.
+func authorizeTenant(w http.ResponseWriter, r *http.Request, tenantID string) bool {
+	if tenant.Allows(r.Context(), strings.TrimSpace(tenantID)) {
+		logging.Set(r.Context(), slog.String("tenant_id", strings.TrimSpace(tenantID)))
+		return true
 	}
-	if conversationID == "" || len(conversationID) > 128 {
-		return errInvalidPayload
+	writeError(w, r, http.StatusForbidden, "forbidden", "tenant not allowed for this request")
+	return false
+}
+
+// inScope reports whether the request is scoped to tenantID. Unlike authorizeTenant it
+// fails closed for unscoped requests; callers answer 404, so a resource of another tenant
+// cannot be told apart from a missing one.
+func inScope(r *http.Request, tenantID string) bool {
+	scoped, ok := tenant.FromContext(r.Context())
+	return ok && scoped == tenantID
+}
+
+// authorizeConversation is authorizeTenant for the conversation of a request; the
+// conversation also joins the log fields, so a debug filter can select it.
+func authorizeConversation(w http.ResponseWriter, r *http.Request, conversation conversationRef) bool {
+	if !authorizeTenant(w, r, conversation.TenantID) {
+		return false
 	}
-	if conversation.Channel == "" {
-		conversation.Channel = "whatsapp_web"
+	if conversation.ConversationID != "" {
+		logging.Set(r.Context(), slog.String("conversation_id", conversation.ConversationID))
 	}
-	if conversation.Channel != "whatsapp_web" {
-		return errInvalidPayload
+	return true
+}
+
+// requestActor identifies who issued the request for audit purposes.
+func requestActor(r *http.Request) string {
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok && claims.Subject != "" {
+		return claims.Method + ":" + claims.Subject
 	}
-	return nil
+	return "api_token"
 }
 
 func parseOptionalDateTime(value string) (*time.Time, error) {
@@ -123,43 +404,13 @@ func parseOptionalDateTime(value string) (*time.Time, error) {
 	return &parsed, nil
 }
 
-type idempotencyEntry struct {
-	PayloadHash uint64
-	JobID       string
-	CreatedAt   time.Time
-}
-
-type idempotencyStore struct {
-	mu      sync.Mutex
-	entries map[string]idempotencyEntry
-}
-
-func newIdempotencyStore() *idempotencyStore {
-	return &idempotencyStore{
-		entries: make(map[string]idempotencyEntry),
+// withPolicyWarnings flags response with the warn-level violations of the request, so the
+// extension can show them to the agent before anything is sent.
+func withPolicyWarnings(response map[string]any, warnings []policy.Violation) map[string]any {
+	if len(warnings) == 0 {
+		return response
 	}
-}
-
-func (s *idempotencyStore) Get(key string) (idempotencyEntry, bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	entry, ok := s.entries[key]
-	return entry, ok
-}
-
-func (s *idempotencyStore) Put(key string, payloadHash uint64, jobID string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.entries[key] = idempotencyEntry{
-		PayloadHash: payloadHash,
-		JobID:       jobID,
-		CreatedAt:   time.Now().UTC(),
-	}
-}
-
-func hashPayload(value any) uint64 {
-	payload, _ := json.Marshal(value)
-	hasher := fnv.New64a()
-	_, _ = hasher.Write(payload)
-	return hasher.Sum64()
+	response["policy_warning"] = true
+	response["policy_warnings"] = warnings
+	return response
 }
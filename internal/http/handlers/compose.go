@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+const maxComposeDraftRunes = 1000
+
+// Compose suggests continuations for the message the agent is typing. The draft is
+// never replaced, and like every generation the result must be reviewed before sending.
+func (api *API) Compose(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.composeService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "compose is not configured")
+		return
+	}
+
+	var request composeRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+
+	errs := validateConversation(request.Conversation, "conversation")
+	request.Locale = api.resolveTenantLocale(r, request.Conversation.TenantID, request.Locale)
+
+	switch {
+	case strings.TrimSpace(request.Draft) == "":
+		errs.add("draft", fieldCodeRequired, "draft is required")
+	case utf8.RuneCountInString(request.Draft) > maxComposeDraftRunes:
+		errs.add("draft", fieldCodeTooLong, "draft must have at most 1000 chars")
+	}
+
+	request.Tone = strings.TrimSpace(strings.ToLower(request.Tone))
+	if request.Tone == "" {
+		request.Tone = api.tenantSettings.Resolve(r.Context(), request.Conversation.TenantID).Tone
+	}
+	if request.Tone == "" {
+		request.Tone = "neutro"
+	}
+	errs = append(errs, validateTone(request.Conversation.TenantID, request.Tone)...)
+
+	if request.ContextWindow == 0 {
+		request.ContextWindow = 20
+	}
+	if request.ContextWindow < 5 || request.ContextWindow > 80 {
+		errs.add("context_window", fieldCodeOutOfRange, "context_window must be between 5 and 80")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	if !authorizeConversation(w, r, request.Conversation) {
+		return
+	}
+
+	rawPayload, _ := json.Marshal(map[string]any{
+		"conversation":   request.Conversation,
+		"locale":         request.Locale,
+		"tone":           request.Tone,
+		"context_window": request.ContextWindow,
+		"draft":          request.Draft,
+		"messages":       sanitizeConversationMessages(upgradeLegacyMessages(request.Messages), request.ContextWindow),
+	})
+	if err := policy.ValidateManualOnlyPayload(rawPayload); err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
+		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "automatic send is not allowed")
+		return
+	}
+	policyWarnings, err := policy.CheckContentPolicy(request.Conversation.TenantID, rawPayload)
+	if err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
+		message := "request blocked by policy"
+		var violation *policy.PolicyViolationError
+		if errors.As(err, &violation) && len(violation.Violations) > 0 {
+			message = violation.Violations[0].Message
+		}
+		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", message)
+		return
+	}
+	rawPayload, err = api.pseudonymsService.ProtectJSON(
+		r.Context(),
+		request.Conversation.TenantID,
+		request.Conversation.ConversationID,
+		rawPayload,
+	)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to protect personal data")
+		return
+	}
+	draft, err := api.pseudonymsService.ProtectString(
+		r.Context(),
+		request.Conversation.TenantID,
+		request.Conversation.ConversationID,
+		request.Draft,
+	)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to protect personal data")
+		return
+	}
+
+	output, err := api.composeService.Complete(r.Context(), service.ComposeInput{
+		TenantID:       request.Conversation.TenantID,
+		ConversationID: request.Conversation.ConversationID,
+		Locale:         request.Locale,
+		Tone:           request.Tone,
+		Draft:          draft,
+		Payload:        rawPayload,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to complete draft")
+		return
+	}
+
+	w.Header().Set("Content-Language", request.Locale)
+	writeJSON(w, http.StatusOK, withPolicyWarnings(map[string]any{
+		"request_id":     middleware.GetRequestID(r.Context()),
+		"locale":         request.Locale,
+		"model_id":       output.ModelID,
+		"prompt_version": output.PromptVersion,
+		"completions":    output.Completions,
+		"quality_score":  output.QualityScore,
+		"hitl_required":  true,
+		"hitl":           policy.DefaultHITLMetadata(),
+	}, policyWarnings))
+}
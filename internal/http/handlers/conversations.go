@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+func (api *API) Conversations(w http.ResponseWriter, r *http.Request) {
+	conversationID, resource := splitConversationPath(r.URL.Path)
+	if conversationID == "" {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "conversation id is required")
+		return
+	}
+
+	switch resource {
+	case "data":
+		if r.Method != http.MethodDelete {
+			writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+			return
+		}
+		api.eraseConversationData(w, r, conversationID)
+	case "messages":
+		if r.Method != http.MethodPost {
+			writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+			return
+		}
+		api.ingestMessages(w, r, conversationID)
+	case "insights":
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+			return
+		}
+		api.conversationInsights(w, r, conversationID)
+	case "pseudonyms":
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+			return
+		}
+		api.conversationPseudonyms(w, r, conversationID)
+	default:
+		writeError(w, r, http.StatusNotFound, "not_found", "resource not found")
+	}
+}
+
+func (api *API) eraseConversationData(w http.ResponseWriter, r *http.Request, conversationID string) {
+	if api.erasureService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "data erasure is not configured")
+		return
+	}
+
+	tenantID := strings.TrimSpace(r.URL.Query().Get("tenant_id"))
+	if tenantID == "" || len(tenantID) > 64 {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "tenant_id query parameter is required")
+		return
+	}
+	if !authorizeTenant(w, r, tenantID) {
+		return
+	}
+	if len(conversationID) > 128 {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "conversation id is too long")
+		return
+	}
+
+	output, err := api.erasureService.EraseConversation(r.Context(), service.EraseConversationInput{
+		TenantID:       tenantID,
+		ConversationID: conversationID,
+		Actor:          requestActor(r),
+		RequestID:      middleware.GetRequestID(r.Context()),
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to erase conversation data")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, output)
+}
+
+// conversationInsights serves the sidebar contact profile built from stored history.
+func (api *API) conversationInsights(w http.ResponseWriter, r *http.Request, conversationID string) {
+	if api.insightsService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "contact insights are not configured")
+		return
+	}
+
+	query := r.URL.Query()
+	errs := validateConversation(conversationRef{
+		TenantID:       query.Get("tenant_id"),
+		ConversationID: conversationID,
+	}, "")
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	tenantID := strings.TrimSpace(query.Get("tenant_id"))
+	if !authorizeTenant(w, r, tenantID) {
+		return
+	}
+	locale := api.resolveTenantLocale(r, tenantID, query.Get("locale"))
+
+	output, err := api.insightsService.Insights(r.Context(), service.InsightsInput{
+		TenantID:       tenantID,
+		ConversationID: conversationID,
+		Locale:         locale,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, http.StatusNotFound, "not_found", "conversation has no stored history")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to build contact insights")
+		return
+	}
+
+	w.Header().Set("Content-Language", locale)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"request_id":        middleware.GetRequestID(r.Context()),
+		"conversation_id":   conversationID,
+		"locale":            locale,
+		"message_count":     output.MessageCount,
+		"customer_messages": output.CustomerMessages,
+		"agent_messages":    output.AgentMessages,
+		"first_message_at":  output.FirstMessageAt.Format(time.RFC3339),
+		"last_message_at":   output.LastMessageAt.Format(time.RFC3339),
+		"last_author":       output.LastAuthor,
+		"preferred_tone":    output.PreferredTone,
+		"commitments":       output.Commitments,
+		"open_complaints":   output.OpenComplaints,
+		"model_id":          output.ModelID,
+		"prompt_version":    output.PromptVersion,
+		"quality_score":     output.QualityScore,
+		"hitl":              policy.DefaultHITLMetadata(),
+	})
+}
+
+// conversationPseudonyms returns the token mapping the extension uses to re-hydrate
+// generated replies of a pseudonymizing tenant before the agent sends them.
+func (api *API) conversationPseudonyms(w http.ResponseWriter, r *http.Request, conversationID string) {
+	if api.pseudonymsService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "pseudonymization is not configured")
+		return
+	}
+
+	query := r.URL.Query()
+	errs := validateConversation(conversationRef{
+		TenantID:       query.Get("tenant_id"),
+		ConversationID: conversationID,
+	}, "")
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	tenantID := strings.TrimSpace(query.Get("tenant_id"))
+	if !authorizeTenant(w, r, tenantID) {
+		return
+	}
+
+	pseudonyms, err := api.pseudonymsService.ListPseudonyms(r.Context(), tenantID, conversationID)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to list pseudonyms")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(pseudonyms))
+	for _, pseudonym := range pseudonyms {
+		items = append(items, map[string]any{
+			"token":      pseudonym.Token,
+			"category":   pseudonym.Category,
+			"value":      pseudonym.Value,
+			"created_at": pseudonym.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"request_id":      middleware.GetRequestID(r.Context()),
+		"conversation_id": conversationID,
+		"pseudonyms":      items,
+	})
+}
+
+const (
+	maxIngestMessages     = 200
+	maxIngestMessageRunes = 4000
+)
+
+func (api *API) ingestMessages(w http.ResponseWriter, r *http.Request, conversationID string) {
+	if api.messagesService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "message ingestion is not configured")
+		return
+	}
+
+	var request messagesIngestRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+	errs := validateConversation(conversationRef{
+		TenantID:       request.TenantID,
+		ConversationID: conversationID,
+		Channel:        request.Channel,
+	}, "")
+	if len(request.Messages) == 0 || len(request.Messages) > maxIngestMessages {
+		errs.add("messages", fieldCodeOutOfRange, "messages must contain between 1 and 200 items")
+	}
+
+	messages := make([]service.IngestMessage, 0, len(request.Messages))
+	for index, item := range request.Messages {
+		path := indexedPath("messages", index)
+		messageID := strings.TrimSpace(item.MessageID)
+		switch {
+		case messageID == "":
+			errs.add(path+".message_id", fieldCodeRequired, "message_id is required")
+		case len(messageID) > 128:
+			errs.add(path+".message_id", fieldCodeTooLong, "message_id must have at most 128 chars")
+		}
+		role := domain.MessageAuthorRole(strings.ToLower(strings.TrimSpace(item.AuthorRole)))
+		if role != domain.MessageAuthorCustomer && role != domain.MessageAuthorAgent {
+			errs.add(path+".author_role", fieldCodeInvalidValue, "author_role must be customer or agent")
+		}
+		text := strings.TrimSpace(item.Text)
+		if text == "" {
+			errs.add(path+".text", fieldCodeRequired, "text is required")
+		}
+		sentAt, err := time.Parse(time.RFC3339, strings.TrimSpace(item.SentAt))
+		if err != nil {
+			errs.add(path+".sent_at", fieldCodeInvalidFmt, "sent_at must be an RFC3339 timestamp")
+		}
+		messages = append(messages, service.IngestMessage{
+			MessageID:  messageID,
+			AuthorRole: role,
+			Text:       truncateRunes(text, maxIngestMessageRunes),
+			SentAt:     sentAt,
+		})
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	if !authorizeTenant(w, r, request.TenantID) {
+		return
+	}
+
+	output, err := api.messagesService.Ingest(r.Context(), service.IngestMessagesInput{
+		TenantID:       request.TenantID,
+		ConversationID: conversationID,
+		Messages:       messages,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to ingest messages")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, output)
+}
+
+// splitConversationPath parses /v1/conversations/{id}/{resource}.
+func splitConversationPath(path string) (string, string) {
+	rest := strings.TrimPrefix(path, "/v1/conversations/")
+	conversationID, resource, _ := strings.Cut(rest, "/")
+	return strings.TrimSpace(conversationID), strings.Trim(resource, "/")
+}
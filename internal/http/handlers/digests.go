@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+const (
+	defaultDigestPeriod = 24 * time.Hour
+	maxDigestPeriod     = 31 * 24 * time.Hour
+)
+
+func (api *API) Digests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		api.createDigest(w, r)
+	case http.MethodGet:
+		api.listDigests(w, r)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+func (api *API) createDigest(w http.ResponseWriter, r *http.Request) {
+	if api.digestsService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "digests are not configured")
+		return
+	}
+
+	// Replays are served by the Idempotency middleware; these routes only insist on a key.
+	if len(strings.TrimSpace(r.Header.Get("Idempotency-Key"))) < 16 {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "Idempotency-Key header is required")
+		return
+	}
+
+	var request digestRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+
+	var errs fieldErrors
+	request.TenantID = strings.TrimSpace(request.TenantID)
+	switch {
+	case request.TenantID == "":
+		errs.add("tenant_id", fieldCodeRequired, "tenant_id is required")
+	case len(request.TenantID) > 64:
+		errs.add("tenant_id", fieldCodeTooLong, "tenant_id must have at most 64 chars")
+	}
+	from, err := parseOptionalDateTime(request.From)
+	if err != nil {
+		errs.add("from", fieldCodeInvalidFmt, "from must be an RFC3339 timestamp")
+	}
+	to, err := parseOptionalDateTime(request.To)
+	if err != nil {
+		errs.add("to", fieldCodeInvalidFmt, "to must be an RFC3339 timestamp")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	periodEnd := time.Now().UTC()
+	if to != nil {
+		periodEnd = to.UTC()
+	}
+	periodStart := periodEnd.Add(-defaultDigestPeriod)
+	if from != nil {
+		periodStart = from.UTC()
+	}
+	switch {
+	case !periodStart.Before(periodEnd):
+		errs.add("from", fieldCodeOutOfRange, "from must be before to")
+	case periodEnd.Sub(periodStart) > maxDigestPeriod:
+		errs.add("from", fieldCodeOutOfRange, "digest period must be at most 31 days")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	if !authorizeTenant(w, r, request.TenantID) {
+		return
+	}
+
+	job, err := api.digestsService.Request(r.Context(), service.DigestInput{
+		TenantID: request.TenantID,
+		From:     periodStart,
+		To:       periodEnd,
+		Locale:   api.resolveTenantLocale(r, request.TenantID, request.Locale),
+	})
+	if err != nil {
+		writeEnqueueError(w, r, err, "failed to enqueue digest job")
+		return
+	}
+
+	response := map[string]any{
+		"job_id":      job.ID,
+		"digest_id":   job.ID,
+		"status":      "pending",
+		"status_url":  "/v1/jobs/" + job.ID,
+		"accepted_at": job.CreatedAt.Format(time.RFC3339Nano),
+		"period": map[string]string{
+			"from": periodStart.Format(time.RFC3339),
+			"to":   periodEnd.Format(time.RFC3339),
+		},
+		"hitl": policy.DefaultHITLMetadata(),
+	}
+	w.Header().Set("Retry-After", "2")
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+func (api *API) listDigests(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	from, err := parseOptionalDateTime(query.Get("from"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid from date")
+		return
+	}
+	to, err := parseOptionalDateTime(query.Get("to"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid to date")
+		return
+	}
+
+	tenantID := strings.TrimSpace(query.Get("tenant_id"))
+	if tenantID != "" && !authorizeTenant(w, r, tenantID) {
+		return
+	}
+
+	items, total, err := api.jobsService.ListDigests(r.Context(), domain.DigestListFilter{
+		TenantID: tenantID,
+		Page:     page,
+		PageSize: pageSize,
+		From:     from,
+		To:       to,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to list digests")
+		return
+	}
+
+	payloadItems := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		payloadItems = append(payloadItems, map[string]any{
+			"digest_id":  item.DigestID,
+			"status":     item.Status,
+			"created_at": item.CreatedAt.Format(time.RFC3339Nano),
+			"title":      item.Title,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":     payloadItems,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"has_next":  page*pageSize < total,
+	})
+}
+
+// DigestDetail serves GET /v1/digests/{id} with the generated digest.
+func (api *API) DigestDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	digestID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/digests/"), "/")
+	if digestID == "" || strings.Contains(digestID, "/") {
+		writeError(w, r, http.StatusNotFound, "not_found", "digest not found")
+		return
+	}
+
+	job, err := api.jobsService.GetJob(r.Context(), digestID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, http.StatusNotFound, "not_found", "digest not found")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to load digest")
+		return
+	}
+	if job.Kind != domain.JobKindDigest {
+		writeError(w, r, http.StatusNotFound, "not_found", "digest not found")
+		return
+	}
+	if tenantID := strings.TrimSpace(r.URL.Query().Get("tenant_id")); tenantID != "" && tenantID != job.TenantID {
+		writeError(w, r, http.StatusForbidden, "forbidden", "digest belongs to another tenant")
+		return
+	}
+
+	response := map[string]any{
+		"digest_id":  job.ID,
+		"tenant_id":  job.TenantID,
+		"status":     job.Status,
+		"created_at": job.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at": job.UpdatedAt.Format(time.RFC3339Nano),
+		"hitl":       policy.DefaultHITLMetadata(),
+	}
+
+	if job.Status != domain.JobStatusDone {
+		response["status_url"] = "/v1/jobs/" + job.ID
+		if job.Status == domain.JobStatusFailed && strings.TrimSpace(job.ErrorMessage) != "" {
+			response["error"] = jobError(job)
+		} else {
+			w.Header().Set("Retry-After", "2")
+		}
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	var result struct {
+		Title             string            `json:"title"`
+		Period            json.RawMessage   `json:"period"`
+		ConversationCount int               `json:"conversation_count"`
+		OpenItems         []json.RawMessage `json:"open_items"`
+		WaitingOnCustomer []string          `json:"waiting_on_customer"`
+		Highlights        []string          `json:"highlights"`
+		QualityScore      *float64          `json:"quality_score"`
+		PromptVersion     string            `json:"prompt_version"`
+		ModelID           string            `json:"model_id"`
+		Degraded          bool              `json:"degraded"`
+		Usage             json.RawMessage   `json:"usage"`
+	}
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "stored digest is malformed")
+		return
+	}
+
+	if result.OpenItems == nil {
+		result.OpenItems = []json.RawMessage{}
+	}
+	if result.WaitingOnCustomer == nil {
+		result.WaitingOnCustomer = []string{}
+	}
+	if result.Highlights == nil {
+		result.Highlights = []string{}
+	}
+	response["title"] = result.Title
+	if len(result.Period) > 0 {
+		response["period"] = result.Period
+	}
+	response["conversation_count"] = result.ConversationCount
+	response["open_items"] = result.OpenItems
+	response["waiting_on_customer"] = result.WaitingOnCustomer
+	response["highlights"] = result.Highlights
+	response["quality_score"] = result.QualityScore
+	metadata := map[string]any{
+		"model_id":       result.ModelID,
+		"prompt_version": result.PromptVersion,
+		"degraded":       result.Degraded,
+	}
+	if len(result.Usage) > 0 {
+		metadata["usage"] = result.Usage
+	}
+	response["metadata"] = metadata
+
+	writeJSON(w, http.StatusOK, response)
+}
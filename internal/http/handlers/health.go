@@ -2,10 +2,34 @@ package handlers
 
 import "net/http"
 
+// Health reports per-dependency status. It always answers 200 so liveness probes do not
+// restart the process because of a failing dependency; "status" turns "degraded" instead.
 func (api *API) Health(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+	if api.health == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+		return
+	}
+	writeJSON(w, http.StatusOK, api.health.Run(r.Context()))
+}
+
+// Ready answers 200 once repositories, queue and worker are initialized and 503 while
+// starting or draining, so load balancers only route traffic to ready instances.
+func (api *API) Ready(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.readiness == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ready"})
+		return
+	}
+	if ready, reason := api.readiness.State(); !ready {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "not_ready", "reason": reason})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ready"})
 }
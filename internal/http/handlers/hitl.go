@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/audit"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+// maxHITLClockSkew tolerates extension clocks slightly ahead of the server.
+const maxHITLClockSkew = 5 * time.Minute
+
+// HITLDecisions records that a human reviewed generated content before sending it.
+func (api *API) HITLDecisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.hitlService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "hitl decisions are not configured")
+		return
+	}
+
+	var request hitlDecisionRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+
+	errs := validateConversation(conversationRef{
+		TenantID:       request.TenantID,
+		ConversationID: request.ConversationID,
+	}, "")
+	request.JobID = strings.TrimSpace(request.JobID)
+	request.SuggestionRequestID = strings.TrimSpace(request.SuggestionRequestID)
+	if request.JobID == "" && request.SuggestionRequestID == "" {
+		errs.add("job_id", fieldCodeRequired, "job_id or suggestion_request_id is required")
+	}
+	if len(request.SuggestionRequestID) > 128 {
+		errs.add("suggestion_request_id", fieldCodeTooLong, "suggestion_request_id must have at most 128 chars")
+	}
+	if request.SuggestionRank < 0 {
+		errs.add("suggestion_rank", fieldCodeOutOfRange, "suggestion_rank must not be negative")
+	}
+
+	action := domain.HITLDecisionAction(strings.ToLower(strings.TrimSpace(request.Decision)))
+	switch action {
+	case domain.HITLDecisionApproved, domain.HITLDecisionRejected:
+	case domain.HITLDecisionEdited:
+		if strings.TrimSpace(request.FinalText) == "" {
+			errs.add("final_text", fieldCodeRequired, "final_text is required for edited decisions")
+		}
+	default:
+		errs.add("decision", fieldCodeInvalidValue, "decision must be approved, edited or rejected")
+	}
+
+	request.ReviewerID = strings.TrimSpace(request.ReviewerID)
+	switch {
+	case request.ReviewerID == "":
+		errs.add("reviewer_id", fieldCodeRequired, "reviewer_id is required")
+	case len(request.ReviewerID) > 128:
+		errs.add("reviewer_id", fieldCodeTooLong, "reviewer_id must have at most 128 chars")
+	}
+
+	decidedAt, err := parseOptionalDateTime(request.DecidedAt)
+	switch {
+	case err != nil:
+		errs.add("decided_at", fieldCodeInvalidFmt, "decided_at must be an RFC3339 timestamp")
+	case decidedAt != nil && decidedAt.After(time.Now().Add(maxHITLClockSkew)):
+		errs.add("decided_at", fieldCodeOutOfRange, "decided_at must not be in the future")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	if !authorizeTenant(w, r, request.TenantID) {
+		return
+	}
+
+	rawPayload, _ := json.Marshal(request)
+	if err := policy.ValidateManualOnlyPayload(rawPayload); err != nil {
+		api.recordPolicyViolation(r, request.TenantID, request.ConversationID, err)
+		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "automatic send is not allowed")
+		return
+	}
+
+	input := service.RecordHITLDecisionInput{
+		TenantID:            request.TenantID,
+		ConversationID:      request.ConversationID,
+		JobID:               request.JobID,
+		SuggestionRequestID: request.SuggestionRequestID,
+		SuggestionRank:      request.SuggestionRank,
+		Action:              action,
+		ReviewerID:          request.ReviewerID,
+		FinalText:           request.FinalText,
+		Actor:               requestActor(r),
+		RequestID:           middleware.GetRequestID(r.Context()),
+	}
+	if decidedAt != nil {
+		input.DecidedAt = *decidedAt
+	}
+
+	output, err := api.hitlService.RecordDecision(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, http.StatusNotFound, "not_found", "job not found for this conversation")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to record hitl decision")
+		return
+	}
+	audit.Note(r.Context(), request.TenantID, output.DecisionID)
+	audit.AddMetadata(r.Context(), "action", string(output.Action))
+	audit.AddMetadata(r.Context(), "job_id", request.JobID)
+
+	writeJSON(w, http.StatusCreated, output)
+}
+
+// decideJob records an approve, edit or reject decision on a job awaiting approval.
+func (api *API) decideJob(w http.ResponseWriter, r *http.Request, jobID, action string) {
+	var decision domain.HITLDecisionAction
+	switch action {
+	case "approve":
+		decision = domain.HITLDecisionApproved
+	case "edit":
+		decision = domain.HITLDecisionEdited
+	case "reject":
+		decision = domain.HITLDecisionRejected
+	default:
+		writeError(w, r, http.StatusNotFound, "not_found", "route not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.hitlService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "hitl decisions are not configured")
+		return
+	}
+
+	var request jobDecisionRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+	var errs fieldErrors
+	request.ReviewerID = strings.TrimSpace(request.ReviewerID)
+	switch {
+	case request.ReviewerID == "":
+		errs.add("reviewer_id", fieldCodeRequired, "reviewer_id is required")
+	case len(request.ReviewerID) > 128:
+		errs.add("reviewer_id", fieldCodeTooLong, "reviewer_id must have at most 128 chars")
+	}
+	if decision == domain.HITLDecisionEdited && len(request.Result) == 0 {
+		errs.add("result", fieldCodeRequired, "result is required for edit decisions")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	job, err := api.jobsService.GetJob(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, http.StatusNotFound, "not_found", "job not found")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to load job")
+		return
+	}
+	if !authorizeTenant(w, r, job.TenantID) {
+		return
+	}
+	if decision == domain.HITLDecisionEdited {
+		if err := policy.ValidateManualOnlyPayload(request.Result); err != nil {
+			api.recordPolicyViolation(r, job.TenantID, job.ConversationID, err)
+			writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "automatic send is not allowed")
+			return
+		}
+	}
+
+	job, output, err := api.hitlService.DecideJob(r.Context(), service.DecideJobInput{
+		JobID:        jobID,
+		Action:       decision,
+		ReviewerID:   request.ReviewerID,
+		EditedResult: request.Result,
+		Actor:        requestActor(r),
+		RequestID:    middleware.GetRequestID(r.Context()),
+	})
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, "not_found", "job not found")
+		return
+	case errors.Is(err, service.ErrApprovalNotPending):
+		writeError(w, r, http.StatusConflict, "approval_not_pending", "job is not awaiting approval")
+		return
+	case errors.Is(err, service.ErrInvalidEditedResult):
+		var errs fieldErrors
+		errs.add("result", fieldCodeInvalidValue, "result must be a non-empty JSON object")
+		writeValidationErrors(w, r, errs)
+		return
+	case err != nil:
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to record hitl decision")
+		return
+	}
+	audit.Note(r.Context(), job.TenantID, output.DecisionID)
+	audit.AddMetadata(r.Context(), "action", string(output.Action))
+	audit.AddMetadata(r.Context(), "job_id", job.ID)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"job_id":      job.ID,
+		"approval":    job.Approval,
+		"decision_id": output.DecisionID,
+		"action":      output.Action,
+		"decided_at":  output.DecidedAt,
+		"result":      jsonRawOrFallback(job.Result),
+		"masking":     resultMasking(job.Result),
+	})
+}
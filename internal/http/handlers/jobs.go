@@ -1,27 +1,50 @@
 package handlers
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"hash/fnv"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
 	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
 )
 
+// JobStatus serves GET /v1/jobs/{id} and the approval decisions POSTed to
+// /v1/jobs/{id}/approve, /reject and /edit.
 func (api *API) JobStatus(w http.ResponseWriter, r *http.Request) {
+	jobID, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/jobs/"), "/")
+	if action != "" {
+		api.decideJob(w, r, strings.TrimSpace(jobID), action)
+		return
+	}
 	if r.Method != http.MethodGet {
 		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
 		return
 	}
 
-	jobID := strings.TrimPrefix(r.URL.Path, "/v1/jobs/")
 	jobID = strings.TrimSpace(jobID)
 	if jobID == "" {
 		writeError(w, r, http.StatusBadRequest, "invalid_request", "job_id is required")
 		return
 	}
 
-	job, err := api.jobsService.GetJob(r.Context(), jobID)
+	wait, err := parseJobWait(r.URL.Query().Get("wait"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "wait must be a duration up to 30s (e.g. 25s)")
+		return
+	}
+	if wait > 0 {
+		// The server-wide write timeout is shorter than the longest allowed wait.
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + jobWaitWriteSlack))
+	}
+
+	job, err := api.jobsService.WaitForJob(r.Context(), jobID, wait)
 	if err != nil {
 		if err == repository.ErrNotFound {
 			writeError(w, r, http.StatusNotFound, "not_found", "job not found")
@@ -31,25 +54,178 @@ func (api *API) JobStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The extension polls this endpoint aggressively; let unchanged jobs short-circuit
+	// with 304 before the response is encoded.
+	etag := jobETag(job)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-store")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	response := map[string]any{
-		"job_id":     job.ID,
-		"status":     job.Status,
-		"kind":       job.Kind,
-		"updated_at": job.UpdatedAt,
+		"job_id":      job.ID,
+		"status":      job.Status,
+		"kind":        job.Kind,
+		"progress":    jobProgress(job.Status),
+		"attempts":    job.Attempts,
+		"created_at":  job.CreatedAt,
+		"updated_at":  job.UpdatedAt,
+		"started_at":  job.StartedAt,
+		"finished_at": job.FinishedAt,
+	}
+	if job.Approval != "" {
+		response["approval"] = job.Approval
+	}
+	if job.Priority != "" {
+		response["priority"] = job.Priority
+	}
+	if job.DependsOn != "" {
+		response["depends_on"] = job.DependsOn
+	}
+	if job.RequestID != "" {
+		response["request_id"] = job.RequestID
+	}
+	if parent, ok := tracing.ParseTraceParent(job.TraceParent); ok {
+		response["trace_id"] = hex.EncodeToString(parent.TraceID[:])
+	}
+	if job.StartedAt != nil && job.FinishedAt != nil {
+		response["duration_ms"] = job.FinishedAt.Sub(*job.StartedAt).Milliseconds()
 	}
 	if len(job.Result) > 0 {
 		response["result"] = jsonRawOrFallback(job.Result)
+		response["masking"] = resultMasking(job.Result)
+		addGenerationProvenance(response, job.Result)
 	}
 	if strings.TrimSpace(job.ErrorMessage) != "" {
-		response["error"] = map[string]any{
-			"code":    "processing_error",
-			"message": job.ErrorMessage,
-		}
+		response["error"] = jobError(job)
 	}
 
 	writeJSON(w, http.StatusOK, response)
 }
 
+// jobError is the error object of failed jobs; jobs failed before error codes were
+// recorded report processing_error.
+func jobError(job *domain.Job) map[string]any {
+	code := job.ErrorCode
+	if code == "" {
+		code = domain.JobErrorProcessing
+	}
+	return map[string]any{
+		"code":    code,
+		"message": job.ErrorMessage,
+	}
+}
+
+// jobProgress is a coarse completion ratio; workers do not report finer-grained steps.
+func jobProgress(status domain.JobStatus) float64 {
+	switch status {
+	case domain.JobStatusProcessing:
+		return 0.5
+	case domain.JobStatusDone, domain.JobStatusFailed:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// addGenerationProvenance copies the provenance the worker records in job results
+// (model, cache hit, degraded mode, usage, cost and quality) to the top level of the response.
+func addGenerationProvenance(response map[string]any, result []byte) {
+	var provenance struct {
+		ModelID  string `json:"model_id"`
+		CacheHit *bool  `json:"cache_hit"`
+		Degraded *bool  `json:"degraded"`
+		Usage    *struct {
+			InputTokens  int      `json:"input_tokens"`
+			OutputTokens int      `json:"output_tokens"`
+			TotalTokens  int      `json:"total_tokens"`
+			CostUSD      *float64 `json:"cost_usd"`
+		} `json:"usage"`
+		Quality json.RawMessage `json:"quality"`
+	}
+	if err := json.Unmarshal(result, &provenance); err != nil {
+		return
+	}
+	if provenance.ModelID != "" {
+		response["model_id"] = provenance.ModelID
+	}
+	if provenance.CacheHit != nil {
+		response["cache_hit"] = *provenance.CacheHit
+	}
+	if provenance.Degraded != nil {
+		response["degraded"] = *provenance.Degraded
+	}
+	if provenance.Usage != nil {
+		response["usage"] = map[string]any{
+			"input_tokens":  provenance.Usage.InputTokens,
+			"output_tokens": provenance.Usage.OutputTokens,
+			"total_tokens":  provenance.Usage.TotalTokens,
+		}
+		response["cost_usd"] = provenance.Usage.CostUSD
+	}
+	if len(provenance.Quality) > 0 {
+		response["quality"] = provenance.Quality
+	}
+}
+
+// resultMasking reports the PII masks in a job result.
+func resultMasking(result []byte) *policy.MaskingReport {
+	report := policy.NewMaskingReport()
+	report.AddJSON("result", result)
+	return report
+}
+
+const (
+	maxJobWait        = 30 * time.Second
+	jobWaitWriteSlack = 5 * time.Second
+)
+
+// parseJobWait accepts a Go duration ("25s", "1500ms") or a plain number of seconds.
+func parseJobWait(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, errInvalidPayload
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait < 0 || wait > maxJobWait {
+		return 0, errInvalidPayload
+	}
+	return wait, nil
+}
+
+// jobETag identifies a job revision by its status and last update time.
+func jobETag(job *domain.Job) string {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(job.ID))
+	_, _ = hasher.Write([]byte{0})
+	_, _ = hasher.Write([]byte(job.Status))
+	_, _ = hasher.Write([]byte{0})
+	_, _ = hasher.Write([]byte(job.Approval))
+	_, _ = hasher.Write([]byte{0})
+	_, _ = hasher.Write([]byte(job.UpdatedAt.UTC().Format(time.RFC3339Nano)))
+	return `"` + strconv.FormatUint(hasher.Sum64(), 16) + `"`
+}
+
+// etagMatches implements the weak comparison used by If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func jsonRawOrFallback(value []byte) any {
 	var decoded any
 	if err := json.Unmarshal(value, &decoded); err == nil {
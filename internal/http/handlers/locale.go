@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultLocale = "pt-BR"
+
+// supportedLocales lists the locales prompts and quality checks are tuned for. The first
+// locale of each language is used when only the language is known ("pt", "en-AU").
+var supportedLocales = []string{"pt-BR", "pt-PT", "en-US", "en-GB"}
+
+// resolveLocale returns the supported locale matching requested or, when it is omitted or
+// not supported, the best match of the Accept-Language header, falling back to pt-BR.
+func resolveLocale(r *http.Request, requested string) string {
+	if locale, ok := matchLocale(requested); ok {
+		return locale
+	}
+	for _, candidate := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if locale, ok := matchLocale(candidate); ok {
+			return locale
+		}
+	}
+	return defaultLocale
+}
+
+// resolveTenantLocale is resolveLocale with the default locale of tenantID, when it has
+// one, ahead of the Accept-Language header.
+func (api *API) resolveTenantLocale(r *http.Request, tenantID, requested string) string {
+	if locale, ok := matchLocale(requested); ok {
+		return locale
+	}
+	if locale, ok := matchLocale(api.tenantSettings.Resolve(r.Context(), tenantID).Locale); ok {
+		return locale
+	}
+	return resolveLocale(r, "")
+}
+
+func matchLocale(value string) (string, bool) {
+	tag := strings.ReplaceAll(strings.TrimSpace(value), "_", "-")
+	if tag == "" || tag == "*" {
+		return "", false
+	}
+	for _, locale := range supportedLocales {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	for _, locale := range supportedLocales {
+		if strings.EqualFold(locale[:2], language) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// parseAcceptLanguage returns the language ranges of an Accept-Language header ordered
+// by quality, skipping ranges with q=0.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	ranges := make([]weighted, 0, 4)
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		ranges = append(ranges, weighted{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+	tags := make([]string, 0, len(ranges))
+	for _, item := range ranges {
+		tags = append(tags, item.tag)
+	}
+	return tags
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// conversationMessage is the structured message shape accepted by /v2. Requests on /v1
+// carry plain strings and are upgraded to this shape before reaching the services.
+type conversationMessage struct {
+	Author    string `json:"author"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+}
+
+const (
+	messageAuthorCustomer = "customer"
+	messageAuthorAgent    = "agent"
+	messageAuthorUnknown  = "unknown"
+
+	messageTypeText = "text"
+)
+
+var supportedMessageTypes = map[string]struct{}{
+	messageTypeText: {},
+	"image":         {},
+	"audio":         {},
+	"video":         {},
+	"document":      {},
+	"sticker":       {},
+	"location":      {},
+}
+
+func messageTypeNames() []string {
+	names := make([]string, 0, len(supportedMessageTypes))
+	for name := range supportedMessageTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// upgradeLegacyMessages converts /v1 free-form messages into structured messages.
+// The author of a legacy message is not known.
+func upgradeLegacyMessages(messages []string) []conversationMessage {
+	upgraded := make([]conversationMessage, 0, len(messages))
+	for _, message := range messages {
+		upgraded = append(upgraded, conversationMessage{
+			Author: messageAuthorUnknown,
+			Type:   messageTypeText,
+			Text:   message,
+		})
+	}
+	return upgraded
+}
+
+// validateConversationMessages checks /v2 structured messages and normalizes author,
+// type and timestamp in place.
+func validateConversationMessages(messages []conversationMessage) fieldErrors {
+	var errs fieldErrors
+	for index := range messages {
+		message := &messages[index]
+		path := indexedPath("messages", index)
+
+		message.Author = strings.ToLower(strings.TrimSpace(message.Author))
+		if message.Author != messageAuthorCustomer && message.Author != messageAuthorAgent {
+			errs.add(path+".author", fieldCodeInvalidValue, "author must be customer or agent")
+		}
+
+		message.Type = strings.ToLower(strings.TrimSpace(message.Type))
+		if message.Type == "" {
+			message.Type = messageTypeText
+		}
+		if _, ok := supportedMessageTypes[message.Type]; !ok {
+			errs.add(path+".type", fieldCodeInvalidValue, "type is not supported")
+		} else if message.Type == messageTypeText && strings.TrimSpace(message.Text) == "" {
+			errs.add(path+".text", fieldCodeRequired, "text is required for text messages")
+		}
+
+		timestamp, err := time.Parse(time.RFC3339, strings.TrimSpace(message.Timestamp))
+		if err != nil {
+			errs.add(path+".timestamp", fieldCodeInvalidFmt, "timestamp must be an RFC3339 timestamp")
+			continue
+		}
+		message.Timestamp = timestamp.UTC().Format(time.RFC3339)
+	}
+	return errs
+}
+
+const (
+	minSuggestionMessages     = 8
+	maxSuggestionMessages     = 80
+	maxSuggestionMessageRunes = 360
+)
+
+// sanitizeConversationMessages keeps at most contextWindow*2 messages (bounded to
+// [8, 80]) and trims their text. Messages without text, such as media without a
+// caption, carry no context and are dropped.
+func sanitizeConversationMessages(messages []conversationMessage, contextWindow int) []conversationMessage {
+	limit := contextWindow * 2
+	if limit < minSuggestionMessages {
+		limit = minSuggestionMessages
+	}
+	if limit > maxSuggestionMessages {
+		limit = maxSuggestionMessages
+	}
+
+	sanitized := make([]conversationMessage, 0, limit)
+	for _, message := range messages {
+		if len(sanitized) >= limit {
+			break
+		}
+
+		trimmed := strings.TrimSpace(message.Text)
+		if trimmed == "" {
+			continue
+		}
+		message.Text = truncateRunes(trimmed, maxSuggestionMessageRunes)
+		sanitized = append(sanitized, message)
+	}
+	return sanitized
+}
@@ -0,0 +1,1232 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+)
+
+// The OpenAPI document is maintained here, next to the handlers it describes.
+// When adding or changing an endpoint, update its path item and schemas below.
+
+type specObject = map[string]any
+
+var (
+	openAPIOnce     sync.Once
+	openAPIDocument specObject
+)
+
+// OpenAPI serves the OpenAPI 3 specification of the public API.
+func (api *API) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	openAPIOnce.Do(func() {
+		openAPIDocument = buildOpenAPIDocument()
+	})
+	writeJSON(w, http.StatusOK, openAPIDocument)
+}
+
+// Docs serves a Swagger UI page pointing at /openapi.json.
+func (api *API) Docs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(swaggerUIPage))
+}
+
+const swaggerUIPage = `<!doctype html>
+<html lang="pt-BR">
+<head>
+  <meta charset="utf-8">
+  <title>WA Copilot API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });</script>
+</body>
+</html>
+`
+
+func buildOpenAPIDocument() specObject {
+	return specObject{
+		"openapi": "3.0.3",
+		"info": specObject{
+			"title":       "WA Copilot API",
+			"version":     "1.0.0",
+			"description": "Backend da extensao WA Copilot. Toda saida gerada exige confirmacao humana (HITL) antes do envio.",
+		},
+		"servers":  []specObject{{"url": "/"}},
+		"security": []specObject{{"bearerAuth": []string{}}, {"apiKeyAuth": []string{}}},
+		"paths":    openAPIPaths(),
+		"components": specObject{
+			"securitySchemes": specObject{
+				"bearerAuth": specObject{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+					"description":  "API_AUTH_TOKEN ou um JWT validado via JWT_JWKS_URL; o claim tenant_id restringe o tenant da requisicao.",
+				},
+				"apiKeyAuth": specObject{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-API-Key",
+					"description": "API key do tenant (wak_...), emitida em /admin/api-keys; tambem aceita como Bearer. Escopos: suggestions, reports, admin, readonly.",
+				},
+				"adminAuth": specObject{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "ADMIN_AUTH_TOKEN ou JWT com papel admin; sem nenhum dos dois configurado o namespace /admin responde 404.",
+				},
+			},
+			"parameters": specObject{
+				"TenantHeader": specObject{
+					"name":        "X-Tenant-ID",
+					"in":          "header",
+					"required":    false,
+					"description": "Restringe a requisicao a um tenant; dados de outros tenants retornam 403/404.",
+					"schema":      specObject{"type": "string"},
+				},
+				"IdempotencyKey": specObject{
+					"name":        "Idempotency-Key",
+					"in":          "header",
+					"required":    true,
+					"description": "Repetir a chave com o mesmo corpo devolve a resposta original (Idempotent-Replayed: true); aceita em qualquer POST.",
+					"schema":      specObject{"type": "string", "minLength": 16, "maxLength": 255},
+				},
+				"ConversationID": pathParam("id", "Identificador da conversa."),
+			},
+			"responses": specObject{
+				"Error": specObject{
+					"description": "Erro no envelope padrao.",
+					"content":     jsonContent(ref("ErrorEnvelope")),
+				},
+			},
+			"schemas": openAPISchemas(),
+		},
+	}
+}
+
+func openAPIPaths() specObject {
+	tenantHeader := ref("#/components/parameters/TenantHeader")
+	idempotencyKey := ref("#/components/parameters/IdempotencyKey")
+
+	return specObject{
+		"/healthz": specObject{
+			"get": public(operation("Health check com estado das dependencias", nil, nil, specObject{
+				"200": jsonResponse("Servico no ar; status degraded quando alguma dependencia falha.", ref("HealthReport")),
+			})),
+		},
+		"/readyz": specObject{
+			"get": public(operation("Readiness para balanceadores e Kubernetes", nil, nil, specObject{
+				"200": jsonResponse("Pronto para receber trafego.", ref("ReadinessStatus")),
+				"503": jsonResponse("Inicializando ou em drenagem no desligamento.", ref("ReadinessStatus")),
+			})),
+		},
+		"/metrics": specObject{
+			"get": public(operation("Metricas Prometheus (fora daqui quando METRICS_PORT esta definido)", nil, nil, specObject{
+				"200": specObject{
+					"description": "Formato de exposicao texto 0.0.4.",
+					"content":     specObject{"text/plain": specObject{"schema": specObject{"type": "string"}}},
+				},
+			})),
+		},
+		"/v1/suggestions": specObject{
+			"post": operation("Sugestoes de resposta", []any{tenantHeader, queryParam("mode", false)}, ref("SuggestionRequest"), specObject{
+				"200": jsonResponse("Sugestoes geradas.", ref("SuggestionResponse")),
+			}),
+		},
+		"/v1/analysis": specObject{
+			"post": operation("Sentimento, urgencia e intencao da conversa", []any{tenantHeader}, ref("AnalysisRequest"), specObject{
+				"200": jsonResponse("Classificacao da janela recente.", ref("AnalysisResponse")),
+			}),
+		},
+		"/v1/questions": specObject{
+			"post": operation("Perguntas de esclarecimento para o atendente", []any{tenantHeader}, ref("QuestionsRequest"), specObject{
+				"200": jsonResponse("2 a 3 perguntas sugeridas.", ref("QuestionsResponse")),
+			}),
+		},
+		"/v1/action-items": specObject{
+			"post": operation("Itens de acao da conversa para o checklist", []any{tenantHeader}, ref("ActionItemsRequest"), specObject{
+				"200": jsonResponse("Itens de acao extraidos (lista vazia quando nao ha pendencias).", ref("ActionItemsResponse")),
+			}),
+		},
+		"/v1/compose": specObject{
+			"post": operation("Completa o rascunho que o atendente esta digitando", []any{tenantHeader}, ref("ComposeRequest"), specObject{
+				"200": jsonResponse("Continuacoes do rascunho (revisao humana obrigatoria).", ref("ComposeResponse")),
+			}),
+		},
+		"/v1/summaries": specObject{
+			"post": operation("Enfileira um resumo", []any{tenantHeader, idempotencyKey}, ref("SummaryRequest"), specObject{
+				"202": jsonResponse("Job aceito.", ref("JobAccepted")),
+			}),
+			"get": operation("Lista resumos", append([]any{tenantHeader, queryParam("tenant_id", true), queryParam("conversation_id", false)}, pageParams()...), nil, specObject{
+				"200": jsonResponse("Pagina de resumos.", ref("SummaryListResponse")),
+			}),
+		},
+		"/v1/reports": specObject{
+			"post": operation("Enfileira um relatorio", []any{tenantHeader, idempotencyKey}, ref("ReportRequest"), specObject{
+				"202": jsonResponse("Job aceito.", ref("JobAccepted")),
+			}),
+			"get": operation("Lista relatorios", append([]any{
+				tenantHeader,
+				queryParam("tenant_id", false),
+				queryParam("topic", false),
+				queryParam("q", false),
+				specObject{
+					"name":        "tags",
+					"in":          "query",
+					"description": "Tags separadas por virgula; retorna relatorios com todas elas.",
+					"schema":      specObject{"type": "string"},
+				},
+			}, pageParams()...), nil, specObject{
+				"200": jsonResponse("Pagina de relatorios.", ref("ReportListResponse")),
+			}),
+		},
+		"/v1/reports/{id}": specObject{
+			"get": operation("Relatorio completo", []any{tenantHeader, pathParam("id", "Identificador do relatorio (job)."), queryParam("tenant_id", false)}, nil, specObject{
+				"200": jsonResponse("Relatorio.", ref("ReportDetail")),
+			}),
+			"patch": operation("Substitui as tags do relatorio", []any{tenantHeader, pathParam("id", "Identificador do relatorio (job)."), queryParam("tenant_id", false)}, ref("ReportTagsRequest"), specObject{
+				"200": jsonResponse("Tags atualizadas.", ref("ReportTagsResponse")),
+			}),
+		},
+		"/v1/digests": specObject{
+			"post": operation("Enfileira o digest diario do tenant", []any{tenantHeader, idempotencyKey}, ref("DigestRequest"), specObject{
+				"202": jsonResponse("Job aceito.", ref("DigestAccepted")),
+			}),
+			"get": operation("Lista digests", append([]any{tenantHeader, queryParam("tenant_id", false)}, pageParams()...), nil, specObject{
+				"200": jsonResponse("Pagina de digests.", ref("DigestListResponse")),
+			}),
+		},
+		"/v1/digests/{id}": specObject{
+			"get": operation("Digest completo", []any{tenantHeader, pathParam("id", "Identificador do digest (job)."), queryParam("tenant_id", false)}, nil, specObject{
+				"200": jsonResponse("Digest.", ref("DigestDetail")),
+			}),
+		},
+		"/v1/jobs/{id}": specObject{
+			"get": operation("Status de um job", []any{
+				tenantHeader,
+				pathParam("id", "Identificador do job."),
+				specObject{"name": "If-None-Match", "in": "header", "schema": specObject{"type": "string"}},
+				specObject{
+					"name":        "wait",
+					"in":          "query",
+					"description": "Long-poll: segura a resposta ate o job terminar ou o tempo expirar (ex.: 25s, max 30s).",
+					"schema":      specObject{"type": "string"},
+				},
+			}, nil, specObject{
+				"200": jsonResponse("Status atual; o header ETag identifica a revisao.", ref("JobStatus")),
+				"304": specObject{"description": "Job inalterado desde o ETag informado."},
+			}),
+		},
+		"/v1/jobs/{id}/approve": specObject{
+			"post": operation("Aprova o resultado de um job pendente de aprovacao", []any{tenantHeader, pathParam("id", "Identificador do job.")}, ref("JobDecisionRequest"), specObject{
+				"200": jsonResponse("Resultado aprovado.", ref("JobDecisionResponse")),
+				"409": ref("#/components/responses/Error"),
+			}),
+		},
+		"/v1/jobs/{id}/edit": specObject{
+			"post": operation("Edita e aprova o resultado de um job pendente de aprovacao", []any{tenantHeader, pathParam("id", "Identificador do job.")}, ref("JobEditRequest"), specObject{
+				"200": jsonResponse("Resultado editado e aprovado.", ref("JobDecisionResponse")),
+				"409": ref("#/components/responses/Error"),
+			}),
+		},
+		"/v1/jobs/{id}/reject": specObject{
+			"post": operation("Rejeita o resultado de um job pendente de aprovacao", []any{tenantHeader, pathParam("id", "Identificador do job.")}, ref("JobDecisionRequest"), specObject{
+				"200": jsonResponse("Resultado rejeitado.", ref("JobDecisionResponse")),
+				"409": ref("#/components/responses/Error"),
+			}),
+		},
+		"/v1/conversations/{id}/messages": specObject{
+			"post": operation("Ingestao de mensagens da conversa", []any{tenantHeader, ref("#/components/parameters/ConversationID")}, ref("MessagesIngestRequest"), specObject{
+				"200": jsonResponse("Resultado da ingestao.", ref("MessagesIngestResponse")),
+			}),
+		},
+		"/v1/conversations/{id}/data": specObject{
+			"delete": operation("Apaga os dados derivados da conversa (LGPD)", []any{tenantHeader, ref("#/components/parameters/ConversationID"), queryParam("tenant_id", true)}, nil, specObject{
+				"200": jsonResponse("Dados apagados.", ref("ErasureResponse")),
+			}),
+		},
+		"/v1/conversations/{id}/insights": specObject{
+			"get": operation("Perfil do contato a partir do historico armazenado", []any{tenantHeader, ref("#/components/parameters/ConversationID"), queryParam("tenant_id", true), queryParam("locale", false)}, nil, specObject{
+				"200": jsonResponse("Compromissos, tom preferido e reclamacoes em aberto. 404 quando nao ha historico.", ref("InsightsResponse")),
+			}),
+		},
+		"/v1/conversations/{id}/pseudonyms": specObject{
+			"get": operation("Mapa de pseudonimos da conversa", []any{tenantHeader, ref("#/components/parameters/ConversationID"), queryParam("tenant_id", true)}, nil, specObject{
+				"200": jsonResponse("Tokens ([TEL_1]) e os valores originais, para reidratar respostas antes do envio manual.", ref("PseudonymsResponse")),
+			}),
+		},
+		"/v1/hitl/decisions": specObject{
+			"post": operation("Registra a revisao humana de um conteudo gerado", []any{tenantHeader}, ref("HITLDecisionRequest"), specObject{
+				"201": jsonResponse("Decisao registrada.", ref("HITLDecisionResponse")),
+			}),
+		},
+		"/v1/templates": specObject{
+			"post": operation("Cria uma resposta pronta do tenant", []any{tenantHeader}, ref("TemplateRequest"), specObject{
+				"201": jsonResponse("Template criado.", ref("Template")),
+				"409": ref("#/components/responses/Error"),
+			}),
+			"get": operation("Lista respostas prontas", append([]any{tenantHeader, queryParam("tenant_id", true)}, pageParams()[:2]...), nil, specObject{
+				"200": jsonResponse("Pagina de templates.", ref("TemplateListResponse")),
+			}),
+		},
+		"/v1/templates/{id}": specObject{
+			"get": operation("Resposta pronta", []any{tenantHeader, pathParam("id", "Identificador do template."), queryParam("tenant_id", false)}, nil, specObject{
+				"200": jsonResponse("Template.", ref("Template")),
+			}),
+			"put": operation("Substitui uma resposta pronta", []any{tenantHeader, pathParam("id", "Identificador do template.")}, ref("TemplateRequest"), specObject{
+				"200": jsonResponse("Template atualizado.", ref("Template")),
+				"409": ref("#/components/responses/Error"),
+			}),
+			"delete": operation("Remove uma resposta pronta", []any{tenantHeader, pathParam("id", "Identificador do template."), queryParam("tenant_id", false)}, nil, specObject{
+				"204": specObject{"description": "Template removido."},
+			}),
+		},
+		"/v2/suggestions": specObject{
+			"post": operation("Sugestoes de resposta com mensagens estruturadas", []any{tenantHeader, queryParam("mode", false)}, ref("SuggestionRequestV2"), specObject{
+				"200": jsonResponse("Sugestoes geradas.", ref("SuggestionResponse")),
+			}),
+		},
+		"/v2/analysis": specObject{
+			"post": operation("Analise da conversa com mensagens estruturadas", []any{tenantHeader}, ref("AnalysisRequestV2"), specObject{
+				"200": jsonResponse("Classificacao da janela recente.", ref("AnalysisResponse")),
+			}),
+		},
+		"/admin/cache/flush": specObject{
+			"post": adminOnly(operation("Esvazia o cache semantico", nil, nil, specObject{
+				"200": jsonResponse("Quantidade de entradas removidas.", ref("AdminCacheFlushResponse")),
+			})),
+		},
+		"/admin/config": specObject{
+			"get": adminOnly(operation("Configuracao em execucao com segredos mascarados", nil, nil, specObject{
+				"200": jsonResponse("Snapshot da configuracao.", ref("AdminConfigResponse")),
+			})),
+		},
+		"/admin/rate-limits": specObject{
+			"get": adminOnly(operation("Estado do rate limit por cliente", nil, nil, specObject{
+				"200": jsonResponse("Limites e buckets ativos.", ref("AdminRateLimitsResponse")),
+			})),
+		},
+		"/admin/reload": specObject{
+			"post": adminOnly(operation("Recarrega as configuracoes alteraveis sem reinicio (como o SIGHUP)", nil, nil, specObject{
+				"200": jsonResponse("Resultado por grupo de configuracoes.", ref("AdminReloadReport")),
+				"422": jsonResponse("Configuracao nova invalida; nada foi aplicado.", ref("AdminReloadReport")),
+			})),
+			"get": adminOnly(operation("Resultado do ultimo recarregamento", nil, nil, specObject{
+				"200": jsonResponse("Ultimo recarregamento, por sinal ou pela API.", ref("AdminReloadReport")),
+				"404": ref("#/components/responses/Error"),
+			})),
+		},
+		"/admin/policy-violations": specObject{
+			"get": adminOnly(operation("Bloqueios da politica de conteudo por tenant, codigo e regra", []any{queryParam("tenant_id", false), queryParam("from", false), queryParam("to", false)}, nil, specObject{
+				"200": jsonResponse("Contagens para ajustar regras com falsos positivos.", ref("AdminPolicyViolationsResponse")),
+			})),
+		},
+		"/admin/worker": specObject{
+			"get": adminOnly(operation("Estado do worker de jobs", nil, nil, specObject{
+				"200": jsonResponse("Worker ligado ou pausado e tamanho atual do pool.", ref("AdminWorkerState")),
+				"409": ref("#/components/responses/Error"),
+			})),
+			"put": adminOnly(operation("Liga ou pausa o worker de jobs", nil, ref("AdminWorkerState"), specObject{
+				"200": jsonResponse("Novo estado do worker.", ref("AdminWorkerState")),
+				"409": ref("#/components/responses/Error"),
+			})),
+		},
+		"/admin/vars": specObject{
+			"get": adminOnly(operation("Contadores do processo (expvar)", nil, nil, specObject{
+				"200": jsonResponse("memstats, cmdline e http_panics_recovered_total.", specObject{"type": "object"}),
+			})),
+		},
+		"/admin/api-keys": specObject{
+			"post": adminOnly(operation("Emite uma API key do tenant", nil, ref("APIKeyCreateRequest"), specObject{
+				"201": jsonResponse("Chave emitida; o segredo em api_key so e exibido nesta resposta.", ref("IssuedAPIKey")),
+			})),
+			"get": adminOnly(operation("Lista API keys", []any{queryParam("tenant_id", false), queryParam("include_revoked", false)}, nil, specObject{
+				"200": jsonResponse("Chaves sem o segredo.", ref("APIKeyListResponse")),
+			})),
+		},
+		"/admin/api-keys/{id}": specObject{
+			"get": adminOnly(operation("API key", []any{pathParam("id", "Identificador da chave.")}, nil, specObject{
+				"200": jsonResponse("Chave sem o segredo.", ref("APIKey")),
+			})),
+			"delete": adminOnly(operation("Revoga uma API key", []any{pathParam("id", "Identificador da chave.")}, nil, specObject{
+				"200": jsonResponse("Chave revogada. 404 quando ja revogada.", ref("APIKey")),
+			})),
+		},
+		"/admin/api-keys/{id}/rotate": specObject{
+			"post": adminOnly(operation("Rotaciona uma API key", []any{pathParam("id", "Identificador da chave.")}, nil, specObject{
+				"201": jsonResponse("Nova chave com os mesmos escopos; a anterior e revogada na hora.", ref("IssuedAPIKey")),
+			})),
+		},
+		"/admin/slo": specObject{
+			"get": adminOnly(operation("Objetivos de latencia (QT-001, QT-002), error budget e burn rate", nil, nil, specObject{
+				"200": jsonResponse("Estado de cada objetivo na janela movel (SLO_WINDOW_HOURS).", ref("AdminSLOResponse")),
+			})),
+		},
+		"/admin/log-level": specObject{
+			"get": adminOnly(operation("Nivel de log deste processo", nil, nil, specObject{
+				"200": jsonResponse("Nivel atual, o de LOG_LEVEL e o filtro de debug em vigor.", ref("AdminLogLevel")),
+			})),
+			"put": adminOnly(operation("Muda o nivel de log em tempo de execucao", nil, ref("AdminLogLevelRequest"), specObject{
+				"200": jsonResponse("Novo estado. Com tenant_id ou conversation_id so os logs deles mudam de nivel.", ref("AdminLogLevel")),
+			})),
+			"delete": adminOnly(operation("Volta ao LOG_LEVEL e remove o filtro de debug", nil, nil, specObject{
+				"200": jsonResponse("Estado restaurado.", ref("AdminLogLevel")),
+			})),
+		},
+		"/admin/tenant-settings": specObject{
+			"get": adminOnly(operation("Lista os tenants com configuracoes proprias", nil, nil, specObject{
+				"200": jsonResponse("Configuracoes por tenant.", ref("TenantSettingsListResponse")),
+			})),
+		},
+		"/admin/tenant-settings/{tenant_id}": specObject{
+			"get": adminOnly(operation("Configuracoes do tenant", []any{pathParam("tenant_id", "Identificador do tenant.")}, nil, specObject{
+				"200": jsonResponse("Configuracoes salvas; 404 quando o tenant usa os padroes.", ref("TenantSettings")),
+			})),
+			"put": adminOnly(operation("Substitui as configuracoes do tenant", []any{pathParam("tenant_id", "Identificador do tenant.")}, ref("TenantSettingsRequest"), specObject{
+				"200": jsonResponse("Configuracoes salvas.", ref("TenantSettings")),
+			})),
+			"delete": adminOnly(operation("Volta o tenant aos padroes", []any{pathParam("tenant_id", "Identificador do tenant.")}, nil, specObject{
+				"204": specObject{"description": "Configuracoes removidas."},
+			})),
+		},
+		"/v1/stats/jobs": specObject{
+			"get": operation("Estatisticas de uso por tenant", []any{tenantHeader, queryParam("tenant_id", true), dateParam("from"), dateParam("to")}, nil, specObject{
+				"200": jsonResponse("Agregados por dia, tipo e status.", ref("JobStatsResponse")),
+			}),
+		},
+		"/v1/usage": specObject{
+			"get": operation("Consumo medido por tenant e dia", []any{tenantHeader, queryParam("tenant_id", true), dayParam("from"), dayParam("to")}, nil, specObject{
+				"200": jsonResponse("Requisicoes, cache hits, tokens e custo estimado por dia (UTC).", ref("UsageResponse")),
+			}),
+		},
+	}
+}
+
+func openAPISchemas() specObject {
+	stringType := specObject{"type": "string"}
+	dateTime := specObject{"type": "string", "format": "date-time"}
+	number := specObject{"type": "number"}
+	integer := specObject{"type": "integer"}
+	stringArray := arrayOf(stringType)
+	logLevel := specObject{"type": "string", "enum": []string{"debug", "info", "warn", "error"}}
+	apiKeyScope := specObject{"type": "string", "enum": []string{"suggestions", "reports", "admin", "readonly"}}
+	apiKeyProperties := func() specObject {
+		return specObject{
+			"key_id":       stringType,
+			"tenant_id":    stringType,
+			"name":         stringType,
+			"prefix":       specObject{"type": "string", "description": "Inicio nao secreto da chave, para identifica-la."},
+			"scopes":       arrayOf(apiKeyScope),
+			"active":       specObject{"type": "boolean"},
+			"created_at":   dateTime,
+			"last_used_at": dateTime,
+			"revoked_at":   dateTime,
+			"rotated_from": stringType,
+		}
+	}
+	issuedAPIKey := apiKeyProperties()
+	issuedAPIKey["api_key"] = stringType
+	locale := specObject{
+		"type":        "string",
+		"description": "Omitido ou nao suportado: negociado pelo header Accept-Language (padrao pt-BR).",
+		"example":     defaultLocale,
+	}
+	suggestionMode := specObject{
+		"type":        "string",
+		"enum":        []string{"standard", "quick"},
+		"description": "quick: 3 respostas de ate 80 caracteres para envio com um toque. Tambem aceito como ?mode=quick.",
+	}
+	tags := merge(arrayOf(specObject{"type": "string", "maxLength": maxReportTagRunes}), specObject{"maxItems": maxReportTags})
+	tone := specObject{
+		"type":        "string",
+		"description": "formal, neutro, amigavel ou um tom registrado para o tenant em TONE_LEXICONS_FILE.",
+	}
+	jobStatus := specObject{"type": "string", "enum": []string{"pending", "processing", "done", "failed"}}
+	usageCounters := specObject{
+		"requests":      integer,
+		"cache_hits":    integer,
+		"input_tokens":  integer,
+		"output_tokens": integer,
+		"total_tokens":  integer,
+		"cost_usd":      specObject{"type": "number", "description": "Estimativa; so inclui modelos com preco em OPENROUTER_MODEL_PRICES."},
+	}
+	jobError := objectSchema(specObject{
+		"code": specObject{
+			"type":        "string",
+			"enum":        []string{"processing_error", "job_timeout", "job_panic", "dependency_failed"},
+			"description": "job_timeout: a tentativa passou do prazo do tipo de job (JOB_TIMEOUTS). job_panic: erro inesperado no processamento. dependency_failed: a etapa anterior do pipeline falhou.",
+		},
+		"message": stringType,
+	})
+	jobApproval := specObject{"type": "string", "enum": []string{"pending", "approved", "rejected"}}
+	jobPriority := specObject{
+		"type":        "string",
+		"enum":        []string{"high", "low"},
+		"description": "Fila do job. low para reprocessamentos em massa; o worker atende 4 jobs high para cada low (QUEUE_PRIORITY_WEIGHT). Padrao: high, exceto digest.",
+	}
+	hitl := ref("HITLMetadata")
+	masking := ref("MaskingReport")
+	// Set only when a warn-level policy rule fired; the request was still processed.
+	policyWarnings := specObject{
+		"policy_warning":  specObject{"type": "boolean"},
+		"policy_warnings": arrayOf(ref("PolicyWarning")),
+	}
+	pagination := specObject{
+		"page":      integer,
+		"page_size": integer,
+		"total":     integer,
+		"has_next":  specObject{"type": "boolean"},
+	}
+
+	healthStatus := specObject{"type": "string", "enum": []string{"ok", "degraded", "down", "disabled"}}
+
+	return specObject{
+		"HealthReport": objectSchema(specObject{
+			"status": specObject{"type": "string", "enum": []string{"ok", "degraded"}},
+			"checks": specObject{
+				"type": "object",
+				"additionalProperties": objectSchema(specObject{
+					"status":     healthStatus,
+					"detail":     stringType,
+					"latency_ms": integer,
+				}),
+			},
+			"checked_at": dateTime,
+		}),
+		"ReadinessStatus": objectSchema(specObject{
+			"status": specObject{"type": "string", "enum": []string{"ready", "not_ready"}},
+			"reason": specObject{"type": "string", "example": "draining"},
+		}),
+		"AdminCacheFlushResponse": objectSchema(specObject{
+			"flushed": integer,
+		}),
+		"AdminConfigResponse": objectSchema(specObject{
+			"config":  specObject{"type": "object", "additionalProperties": true},
+			"sources": specObject{"type": "object", "additionalProperties": stringType},
+		}),
+		"AdminPolicyViolationsResponse": objectSchema(specObject{
+			"from": dateTime,
+			"to":   dateTime,
+			"items": arrayOf(objectSchema(specObject{
+				"tenant_id":    stringType,
+				"code":         stringType,
+				"rule":         stringType,
+				"count":        integer,
+				"last_seen_at": dateTime,
+			})),
+			"totals": objectSchema(specObject{
+				"by_code":   specObject{"type": "object", "additionalProperties": integer},
+				"by_tenant": specObject{"type": "object", "additionalProperties": integer},
+			}),
+		}),
+		"AdminRateLimitsResponse": objectSchema(specObject{
+			"rps":   number,
+			"burst": integer,
+			"routes": arrayOf(objectSchema(specObject{
+				"method": stringType,
+				"prefix": stringType,
+				"rps":    number,
+				"burst":  integer,
+			})),
+			"visitors": arrayOf(objectSchema(specObject{
+				"key":       specObject{"type": "string", "description": "api_key:<id>, tenant:<id> ou ip:<endereco>."},
+				"route":     specObject{"type": "string", "description": "Rota com orcamento proprio; vazio para o limite padrao."},
+				"tokens":    number,
+				"last_seen": dateTime,
+			})),
+		}),
+		"AdminReloadReport": objectSchema(specObject{
+			"trigger":  specObject{"type": "string", "enum": []string{"signal", "admin"}},
+			"at":       dateTime,
+			"ok":       specObject{"type": "boolean"},
+			"error":    specObject{"type": "string", "description": "Por que a configuracao nova foi rejeitada."},
+			"warnings": stringArray,
+			"results": arrayOf(objectSchema(specObject{
+				"name":    specObject{"type": "string", "enum": []string{"rate_limits", "models", "prompts", "cache", "policies"}},
+				"changed": specObject{"type": "boolean"},
+				"error":   stringType,
+			}, "name", "changed")),
+			"restart_required": merge(stringArray, specObject{"description": "Campos de /admin/config alterados que so valem apos reiniciar."}),
+		}, "trigger", "at", "ok", "results", "restart_required"),
+		"AdminWorkerState": objectSchema(specObject{
+			"enabled": specObject{"type": "boolean"},
+			"concurrency": merge(objectSchema(specObject{
+				"adaptive":          specObject{"type": "boolean"},
+				"current":           integer,
+				"min":               integer,
+				"max":               integer,
+				"busy":              integer,
+				"queue_depth":       integer,
+				"recent_latency_ms": integer,
+				"updated_at":        dateTime,
+			}), specObject{"readOnly": true, "description": "Jobs que o worker roda ao mesmo tempo e os sinais usados para ajustar esse numero."}),
+		}, "enabled"),
+		"APIKeyCreateRequest": objectSchema(specObject{
+			"tenant_id": stringType,
+			"name":      specObject{"type": "string", "maxLength": 80},
+			"scopes":    arrayOf(apiKeyScope),
+		}, "tenant_id", "scopes"),
+		"APIKey":             objectSchema(apiKeyProperties(), "key_id", "tenant_id", "prefix", "scopes", "active", "created_at"),
+		"IssuedAPIKey":       objectSchema(issuedAPIKey, "key_id", "tenant_id", "prefix", "scopes", "active", "created_at", "api_key"),
+		"APIKeyListResponse": objectSchema(specObject{"items": arrayOf(ref("APIKey"))}, "items"),
+		"TenantSettingsRequest": objectSchema(specObject{
+			"locale":         specObject{"type": "string", "description": "Locale padrao quando a requisicao nao informa um; antes do Accept-Language."},
+			"tone":           specObject{"type": "string", "description": "Tom padrao (embutido ou registrado pelo tenant)."},
+			"models":         specObject{"type": "object", "additionalProperties": stringType, "description": "Modelo primario por tarefa (suggestion, summary, report, ...)."},
+			"quotas":         objectSchema(specObject{"daily_jobs": specObject{"type": "integer", "minimum": 0, "description": "Jobs criados por dia UTC; 0 e ilimitado."}}),
+			"policy_profile": specObject{"type": "string", "description": "Entrada dos arquivos de politica usada quando o tenant nao tem uma propria."},
+		}),
+		"TenantSettings": objectSchema(specObject{
+			"tenant_id":      stringType,
+			"locale":         stringType,
+			"tone":           stringType,
+			"models":         specObject{"type": "object", "additionalProperties": stringType},
+			"quotas":         objectSchema(specObject{"daily_jobs": integer}),
+			"policy_profile": stringType,
+			"updated_at":     dateTime,
+		}, "tenant_id", "models", "quotas", "updated_at"),
+		"TenantSettingsListResponse": objectSchema(specObject{"items": arrayOf(ref("TenantSettings"))}, "items"),
+		"ErrorEnvelope": objectSchema(specObject{
+			"error": objectSchema(specObject{
+				"code":    stringType,
+				"message": stringType,
+			}, "code", "message"),
+			"errors": arrayOf(objectSchema(specObject{
+				"field":   specObject{"type": "string", "example": "conversation.tenant_id"},
+				"code":    specObject{"type": "string", "enum": []string{fieldCodeRequired, fieldCodeTooLong, fieldCodeOutOfRange, fieldCodeInvalidValue, fieldCodeInvalidFmt}},
+				"message": stringType,
+			}, "field", "code", "message")),
+			"request_id": stringType,
+		}, "error", "request_id"),
+		"PolicyWarning": objectSchema(specObject{
+			"code":     stringType,
+			"message":  stringType,
+			"severity": specObject{"type": "string", "enum": []string{"warn"}},
+		}),
+		"MaskingReport": merge(objectSchema(specObject{
+			"categories": stringArray,
+			"counts":     specObject{"type": "object", "additionalProperties": integer},
+			"fields":     merge(stringArray, specObject{"description": "Caminhos dos campos com dados mascarados (ex.: result.summary)."}),
+		}, "categories", "counts", "fields"), specObject{"description": "Categorias de PII mascaradas na resposta, conforme as regras de PII do tenant."}),
+		"HITLMetadata": objectSchema(specObject{
+			"required":           specObject{"type": "boolean"},
+			"allowed_actions":    stringArray,
+			"prohibited_actions": stringArray,
+			"reason":             stringType,
+		}),
+		"ConversationRef": objectSchema(specObject{
+			"tenant_id":       specObject{"type": "string", "maxLength": 64},
+			"conversation_id": specObject{"type": "string", "maxLength": 128},
+			"channel":         specObject{"type": "string", "enum": []string{"whatsapp_web"}},
+		}, "tenant_id", "conversation_id", "channel"),
+		"SuggestionRequest": objectSchema(specObject{
+			"conversation":              ref("ConversationRef"),
+			"locale":                    locale,
+			"tone":                      tone,
+			"mode":                      suggestionMode,
+			"context_window":            specObject{"type": "integer", "minimum": 5, "maximum": 80},
+			"messages":                  stringArray,
+			"max_candidates":            integer,
+			"include_last_user_message": specObject{"type": "boolean"},
+		}, "conversation", "tone", "context_window"),
+		"SuggestionResponse": objectSchema(merge(policyWarnings, specObject{
+			"request_id":     stringType,
+			"locale":         specObject{"type": "string", "enum": supportedLocales},
+			"model_id":       stringType,
+			"prompt_version": stringType,
+			"mode":           specObject{"type": "string", "enum": []string{"standard", "quick"}},
+			"suggestions": arrayOf(objectSchema(specObject{
+				"rank":      integer,
+				"content":   stringType,
+				"rationale": stringType,
+			})),
+			"masking":       masking,
+			"quality_score": number,
+			"hitl_required": specObject{"type": "boolean"},
+			"hitl":          hitl,
+		})),
+		"HITLDecisionRequest": objectSchema(specObject{
+			"tenant_id":             stringType,
+			"conversation_id":       stringType,
+			"job_id":                specObject{"type": "string", "description": "Job (resumo/relatorio) revisado."},
+			"suggestion_request_id": specObject{"type": "string", "description": "request_id da resposta de /v1/suggestions."},
+			"suggestion_rank":       integer,
+			"decision":              specObject{"type": "string", "enum": []string{"approved", "edited", "rejected"}},
+			"reviewer_id":           stringType,
+			"final_text":            specObject{"type": "string", "description": "Obrigatorio para edited; apenas o checksum e armazenado."},
+			"decided_at":            dateTime,
+		}, "tenant_id", "conversation_id", "decision", "reviewer_id"),
+		"JobDecisionRequest": objectSchema(specObject{
+			"reviewer_id": stringType,
+		}, "reviewer_id"),
+		"JobEditRequest": objectSchema(specObject{
+			"reviewer_id": stringType,
+			"result":      specObject{"type": "object", "additionalProperties": true, "description": "Campos revisados; substituem os gerados."},
+		}, "reviewer_id", "result"),
+		"JobDecisionResponse": objectSchema(specObject{
+			"job_id":      stringType,
+			"approval":    jobApproval,
+			"decision_id": stringType,
+			"action":      stringType,
+			"decided_at":  dateTime,
+			"result":      specObject{"type": "object", "additionalProperties": true},
+			"masking":     masking,
+		}),
+		"HITLDecisionResponse": objectSchema(specObject{
+			"decision_id": stringType,
+			"action":      stringType,
+			"decided_at":  dateTime,
+			"recorded_at": dateTime,
+		}),
+		"TemplateRequest": objectSchema(specObject{
+			"tenant_id": stringType,
+			"name":      specObject{"type": "string", "maxLength": maxTemplateNameRunes, "description": "Unico por tenant (sem diferenciar maiusculas)."},
+			"content":   specObject{"type": "string", "maxLength": maxTemplateContentRunes, "description": "Texto com variaveis no formato {{nome}}."},
+			"locale":    locale,
+		}, "tenant_id", "name", "content"),
+		"Template": objectSchema(specObject{
+			"template_id":  stringType,
+			"tenant_id":    stringType,
+			"name":         stringType,
+			"content":      stringType,
+			"locale":       stringType,
+			"placeholders": stringArray,
+			"created_at":   dateTime,
+			"updated_at":   dateTime,
+		}),
+		"TemplateListResponse": objectSchema(merge(pagination, specObject{
+			"items": arrayOf(ref("Template")),
+		})),
+		"ConversationMessage": objectSchema(specObject{
+			"author":    specObject{"type": "string", "enum": []string{messageAuthorCustomer, messageAuthorAgent}},
+			"timestamp": dateTime,
+			"type":      specObject{"type": "string", "enum": messageTypeNames(), "default": messageTypeText},
+			"text":      specObject{"type": "string", "description": "Obrigatorio para mensagens do tipo text; legenda para midias."},
+		}, "author", "timestamp"),
+		"SuggestionRequestV2": objectSchema(specObject{
+			"conversation":              ref("ConversationRef"),
+			"locale":                    locale,
+			"tone":                      tone,
+			"mode":                      suggestionMode,
+			"context_window":            specObject{"type": "integer", "minimum": 5, "maximum": 80},
+			"messages":                  arrayOf(ref("ConversationMessage")),
+			"max_candidates":            integer,
+			"include_last_user_message": specObject{"type": "boolean"},
+		}, "conversation", "tone", "context_window"),
+		"AnalysisRequestV2": objectSchema(specObject{
+			"conversation":   ref("ConversationRef"),
+			"locale":         locale,
+			"context_window": specObject{"type": "integer", "minimum": 5, "maximum": 80},
+			"messages":       arrayOf(ref("ConversationMessage")),
+		}, "conversation"),
+		"AnalysisRequest": objectSchema(specObject{
+			"conversation":   ref("ConversationRef"),
+			"locale":         locale,
+			"context_window": specObject{"type": "integer", "minimum": 5, "maximum": 80},
+			"messages":       stringArray,
+		}, "conversation"),
+		"AnalysisResponse": objectSchema(merge(policyWarnings, specObject{
+			"request_id":        stringType,
+			"locale":            specObject{"type": "string", "enum": supportedLocales},
+			"model_id":          stringType,
+			"prompt_version":    stringType,
+			"sentiment":         specObject{"type": "string", "enum": []string{"positivo", "neutro", "negativo"}},
+			"sentiment_score":   specObject{"type": "number", "minimum": -1, "maximum": 1},
+			"urgency":           specObject{"type": "string", "enum": []string{"baixa", "media", "alta"}},
+			"intent":            stringType,
+			"intent_confidence": number,
+			"labels":            stringArray,
+			"rationale":         stringType,
+			"quality_score":     number,
+			"hitl":              hitl,
+		})),
+		"QuestionsRequest": objectSchema(specObject{
+			"conversation":   ref("ConversationRef"),
+			"locale":         locale,
+			"context_window": specObject{"type": "integer", "minimum": 5, "maximum": 80},
+			"messages":       stringArray,
+		}, "conversation"),
+		"QuestionsResponse": objectSchema(merge(policyWarnings, specObject{
+			"request_id":     stringType,
+			"locale":         specObject{"type": "string", "enum": supportedLocales},
+			"model_id":       stringType,
+			"prompt_version": stringType,
+			"questions":      merge(stringArray, specObject{"minItems": 1, "maxItems": 3}),
+			"rationale":      stringType,
+			"quality_score":  number,
+			"hitl":           hitl,
+		})),
+		"ComposeRequest": objectSchema(specObject{
+			"conversation":   ref("ConversationRef"),
+			"locale":         locale,
+			"tone":           merge(tone, specObject{"default": "neutro"}),
+			"context_window": specObject{"type": "integer", "minimum": 5, "maximum": 80},
+			"messages":       stringArray,
+			"draft":          specObject{"type": "string", "maxLength": maxComposeDraftRunes},
+		}, "conversation", "draft"),
+		"ComposeResponse": objectSchema(merge(policyWarnings, specObject{
+			"request_id":     stringType,
+			"locale":         specObject{"type": "string", "enum": supportedLocales},
+			"model_id":       stringType,
+			"prompt_version": stringType,
+			"completions": arrayOf(objectSchema(specObject{
+				"rank":       integer,
+				"completion": specObject{"type": "string", "description": "Apenas a continuacao do rascunho."},
+				"text":       specObject{"type": "string", "description": "Rascunho com a continuacao aplicada."},
+			})),
+			"quality_score": number,
+			"hitl_required": specObject{"type": "boolean"},
+			"hitl":          hitl,
+		})),
+		"ActionItemsRequest": objectSchema(specObject{
+			"conversation":   ref("ConversationRef"),
+			"locale":         locale,
+			"context_window": specObject{"type": "integer", "minimum": 5, "maximum": 80},
+			"messages":       stringArray,
+		}, "conversation"),
+		"ActionItemsResponse": objectSchema(merge(policyWarnings, specObject{
+			"request_id":     stringType,
+			"locale":         specObject{"type": "string", "enum": supportedLocales},
+			"model_id":       stringType,
+			"prompt_version": stringType,
+			"action_items": merge(arrayOf(objectSchema(specObject{
+				"description":    stringType,
+				"owner":          specObject{"type": "string", "enum": []string{"agent", "customer", "unknown"}},
+				"due_hint":       stringType,
+				"source_message": stringType,
+			}, "description", "owner")), specObject{"maxItems": 10}),
+			"quality_score": number,
+			"hitl":          hitl,
+		})),
+		"InsightsResponse": objectSchema(specObject{
+			"request_id":        stringType,
+			"conversation_id":   stringType,
+			"locale":            specObject{"type": "string", "enum": supportedLocales},
+			"message_count":     integer,
+			"customer_messages": integer,
+			"agent_messages":    integer,
+			"first_message_at":  dateTime,
+			"last_message_at":   dateTime,
+			"last_author":       specObject{"type": "string", "enum": []string{"customer", "agent"}},
+			"preferred_tone":    specObject{"type": "string", "enum": []string{"formal", "neutro", "amigavel"}},
+			"commitments": merge(arrayOf(objectSchema(specObject{
+				"description": stringType,
+				"made_by":     specObject{"type": "string", "enum": []string{"agent", "customer", "unknown"}},
+				"due_hint":    stringType,
+			}, "description", "made_by")), specObject{"maxItems": 10}),
+			"open_complaints": merge(arrayOf(objectSchema(specObject{
+				"description": stringType,
+				"severity":    specObject{"type": "string", "enum": []string{"baixa", "media", "alta"}},
+			}, "description", "severity")), specObject{"maxItems": 10}),
+			"model_id":       stringType,
+			"prompt_version": stringType,
+			"quality_score":  number,
+			"hitl":           hitl,
+		}),
+		"SummaryRequest": objectSchema(specObject{
+			"conversation":    ref("ConversationRef"),
+			"summary_type":    specObject{"type": "string", "enum": []string{"short", "full"}},
+			"include_actions": specObject{"type": "boolean"},
+			"from":            dateTime,
+			"to":              dateTime,
+			"priority":        jobPriority,
+		}, "conversation"),
+		"ReportRequest": objectSchema(specObject{
+			"conversation": ref("ConversationRef"),
+			"report_type":  specObject{"type": "string", "enum": []string{"timeline", "temas", "atendimento"}},
+			"topic_filter": stringType,
+			"from":         dateTime,
+			"to":           dateTime,
+			"page":         integer,
+			"page_size":    integer,
+			"tags":         tags,
+			"with_summary": specObject{"type": "boolean", "description": "Gera antes um resumo novo da conversa e monta o relatorio a partir dele."},
+			"priority":     jobPriority,
+			"sections": specObject{
+				"type":        "array",
+				"maxItems":    8,
+				"items":       specObject{"type": "string", "minLength": 1, "maxLength": 80},
+				"description": "Titulos das secoes; cada secao e gerada e salva separadamente, e uma nova tentativa continua das secoes ja prontas.",
+			},
+		}, "conversation"),
+		"ReportTagsRequest": objectSchema(specObject{
+			"tags": tags,
+		}, "tags"),
+		"ReportTagsResponse": objectSchema(specObject{
+			"report_id": stringType,
+			"tags":      stringArray,
+		}),
+		"DigestRequest": objectSchema(specObject{
+			"tenant_id": stringType,
+			"from":      merge(dateTime, specObject{"description": "Inicio do periodo; padrao: 24h antes de to."}),
+			"to":        merge(dateTime, specObject{"description": "Fim do periodo; padrao: agora. Periodo maximo de 31 dias."}),
+			"locale":    locale,
+		}, "tenant_id"),
+		"DigestAccepted": objectSchema(specObject{
+			"job_id":      stringType,
+			"digest_id":   stringType,
+			"status":      jobStatus,
+			"status_url":  stringType,
+			"accepted_at": dateTime,
+			"period":      ref("DigestPeriod"),
+			"hitl":        hitl,
+		}),
+		"DigestPeriod": objectSchema(specObject{
+			"from": dateTime,
+			"to":   dateTime,
+		}),
+		"JobAccepted": objectSchema(merge(policyWarnings, specObject{
+			"job_id":      stringType,
+			"status":      jobStatus,
+			"status_url":  stringType,
+			"accepted_at": dateTime,
+			"hitl":        hitl,
+			"depends_on":  merge(stringType, specObject{"description": "Job da etapa anterior do pipeline (with_summary); o job so entra na fila quando ele termina."}),
+		})),
+		"JobStatus": objectSchema(specObject{
+			"job_id":      stringType,
+			"status":      jobStatus,
+			"kind":        specObject{"type": "string", "enum": []string{"summary", "report", "digest"}},
+			"progress":    specObject{"type": "number", "minimum": 0, "maximum": 1},
+			"attempts":    integer,
+			"created_at":  dateTime,
+			"updated_at":  dateTime,
+			"started_at":  specObject{"type": "string", "format": "date-time", "nullable": true},
+			"finished_at": specObject{"type": "string", "format": "date-time", "nullable": true},
+			"duration_ms": integer,
+			"model_id":    stringType,
+			"cache_hit":   specObject{"type": "boolean"},
+			"degraded":    specObject{"type": "boolean", "description": "Resultado gerado em modo degradado (fallback local)."},
+			"approval":    merge(jobApproval, specObject{"description": "Apenas para tenants com aprovacao humana obrigatoria."}),
+			"depends_on":  merge(stringType, specObject{"description": "Etapa anterior do pipeline. O resultado lista as etapas anteriores em pipeline.ancestors."}),
+			"priority":    jobPriority,
+			"request_id":  merge(stringType, specObject{"description": "X-Request-Id da requisicao que criou o job; tambem enviado ao provedor de IA."}),
+			"trace_id":    merge(stringType, specObject{"description": "Trace distribuido que cobre o aceite, a espera na fila, o worker, a chamada ao provedor e a gravacao do resultado."}),
+			"usage": objectSchema(specObject{
+				"input_tokens":  integer,
+				"output_tokens": integer,
+				"total_tokens":  integer,
+			}),
+			"cost_usd": specObject{"type": "number", "nullable": true, "description": "Nulo quando o modelo nao tem preco configurado."},
+			"quality": merge(objectSchema(specObject{
+				"score":          specObject{"type": "number"},
+				"corrected":      specObject{"type": "boolean", "description": "O validador corrigiu ou penalizou a saida do modelo."},
+				"rejected":       specObject{"type": "boolean", "description": "A saida do modelo foi rejeitada e o fallback local foi servido."},
+				"prompt_version": stringType,
+			}), specObject{"description": "Ausente em acertos de cache e em fallbacks anteriores a validacao."}),
+			"result":  specObject{"type": "object", "additionalProperties": true},
+			"masking": masking,
+			"error":   jobError,
+		}),
+		"SummaryListResponse": objectSchema(merge(pagination, specObject{
+			"items": arrayOf(objectSchema(specObject{
+				"summary_id":      stringType,
+				"conversation_id": stringType,
+				"status":          jobStatus,
+				"created_at":      dateTime,
+				"preview":         stringType,
+				"status_url":      stringType,
+			})),
+		})),
+		"ReportListResponse": objectSchema(merge(pagination, specObject{
+			"items": arrayOf(objectSchema(specObject{
+				"report_id":       stringType,
+				"conversation_id": stringType,
+				"status":          jobStatus,
+				"created_at":      dateTime,
+				"title":           stringType,
+				"tags":            stringArray,
+			})),
+		})),
+		"ReportDetail": objectSchema(specObject{
+			"report_id":       stringType,
+			"tenant_id":       stringType,
+			"conversation_id": stringType,
+			"status":          jobStatus,
+			"created_at":      dateTime,
+			"updated_at":      dateTime,
+			"tags":            stringArray,
+			"title":           stringType,
+			"sections": arrayOf(objectSchema(specObject{
+				"heading": stringType,
+				"content": stringType,
+			})),
+			"quality_score": specObject{"type": "number", "nullable": true},
+			"metadata": objectSchema(specObject{
+				"model_id":       stringType,
+				"prompt_version": stringType,
+				"usage":          specObject{"type": "object", "additionalProperties": true},
+			}),
+			"status_url": stringType,
+			"error":      jobError,
+			"hitl":       hitl,
+		}),
+		"DigestListResponse": objectSchema(merge(pagination, specObject{
+			"items": arrayOf(objectSchema(specObject{
+				"digest_id":  stringType,
+				"status":     jobStatus,
+				"created_at": dateTime,
+				"title":      stringType,
+			})),
+		})),
+		"DigestDetail": objectSchema(specObject{
+			"digest_id":          stringType,
+			"tenant_id":          stringType,
+			"status":             jobStatus,
+			"created_at":         dateTime,
+			"updated_at":         dateTime,
+			"title":              stringType,
+			"period":             ref("DigestPeriod"),
+			"conversation_count": integer,
+			"open_items": arrayOf(objectSchema(specObject{
+				"conversation_id": stringType,
+				"description":     stringType,
+			})),
+			"waiting_on_customer": merge(stringArray, specObject{"description": "Conversas cuja ultima mensagem foi do atendente."}),
+			"highlights":          stringArray,
+			"quality_score":       specObject{"type": "number", "nullable": true},
+			"metadata": objectSchema(specObject{
+				"model_id":       stringType,
+				"prompt_version": stringType,
+				"degraded":       specObject{"type": "boolean"},
+				"usage":          specObject{"type": "object", "additionalProperties": true},
+			}),
+			"status_url": stringType,
+			"error":      jobError,
+			"hitl":       hitl,
+		}),
+		"MessagesIngestRequest": objectSchema(specObject{
+			"tenant_id": stringType,
+			"channel":   stringType,
+			"messages": specObject{
+				"type":     "array",
+				"minItems": 1,
+				"maxItems": maxIngestMessages,
+				"items": objectSchema(specObject{
+					"message_id":  specObject{"type": "string", "maxLength": 128},
+					"author_role": specObject{"type": "string", "enum": []string{"customer", "agent"}},
+					"text":        stringType,
+					"sent_at":     dateTime,
+				}, "message_id", "author_role", "text", "sent_at"),
+			},
+		}, "tenant_id", "messages"),
+		"MessagesIngestResponse": objectSchema(specObject{
+			"conversation_id": stringType,
+			"received":        integer,
+			"accepted":        integer,
+			"duplicates":      integer,
+		}),
+		"PseudonymsResponse": objectSchema(specObject{
+			"request_id":      stringType,
+			"conversation_id": stringType,
+			"pseudonyms": arrayOf(objectSchema(specObject{
+				"token":      stringType,
+				"category":   stringType,
+				"value":      stringType,
+				"created_at": dateTime,
+			})),
+		}),
+		"ErasureResponse": objectSchema(specObject{
+			"tenant_id":                stringType,
+			"conversation_id":          stringType,
+			"deleted_jobs":             integer,
+			"deleted_messages":         integer,
+			"deleted_pseudonyms":       integer,
+			"deleted_idempotency_keys": integer,
+			"purged_cache_entries":     integer,
+			"erased_at":                dateTime,
+		}),
+		"JobStatsResponse": objectSchema(specObject{
+			"tenant_id": stringType,
+			"from":      dateTime,
+			"to":        dateTime,
+			"items": arrayOf(objectSchema(specObject{
+				"day":            specObject{"type": "string", "format": "date"},
+				"kind":           stringType,
+				"status":         jobStatus,
+				"count":          integer,
+				"avg_latency_ms": number,
+				"max_latency_ms": integer,
+			})),
+			"totals": objectSchema(specObject{
+				"jobs":      integer,
+				"by_kind":   specObject{"type": "object", "additionalProperties": integer},
+				"by_status": specObject{"type": "object", "additionalProperties": integer},
+			}),
+		}),
+		"AdminLogLevelRequest": objectSchema(specObject{
+			"level":           logLevel,
+			"tenant_id":       merge(stringType, specObject{"maxLength": 64}),
+			"conversation_id": merge(stringType, specObject{"maxLength": 128}),
+			"ttl_seconds":     specObject{"type": "integer", "minimum": 0, "maximum": 86400, "description": "Volta ao estado anterior depois desse tempo; 0 mantem o nivel global ate a proxima mudanca e da 900s ao filtro."},
+		}, "level"),
+		"AdminLogLevel": objectSchema(specObject{
+			"level":            logLevel,
+			"configured_level": logLevel,
+			"expires_at":       merge(dateTime, specObject{"description": "Quando level volta a configured_level."}),
+			"filter": objectSchema(specObject{
+				"level":           logLevel,
+				"tenant_id":       stringType,
+				"conversation_id": stringType,
+				"expires_at":      dateTime,
+			}),
+		}, "level", "configured_level"),
+		"AdminSLOResponse": objectSchema(specObject{
+			"objectives": arrayOf(objectSchema(specObject{
+				"name":         stringType,
+				"description":  stringType,
+				"method":       stringType,
+				"routes":       stringArray,
+				"threshold_ms": integer,
+				"target":       number,
+				"window":       stringType,
+				"total":        integer,
+				"good":         integer,
+				"bad":          specObject{"type": "integer", "description": "Respostas 5xx ou acima de threshold_ms."},
+				"compliance":   number,
+				"p95_ms":       specObject{"type": "number", "description": "Estimado por histograma."},
+				"met":          specObject{"type": "boolean"},
+				"error_budget": objectSchema(specObject{
+					"allowed":   number,
+					"spent":     integer,
+					"remaining": specObject{"type": "number", "description": "Fracao nao gasta; 0 ou menos quando esgotado."},
+				}),
+				"burn_rates": specObject{"type": "object", "additionalProperties": number, "description": "Por janela curta (5m, 1h, 6h); 1 gasta o budget exatamente na janela do SLO."},
+			})),
+		}),
+		"UsageResponse": objectSchema(specObject{
+			"tenant_id": stringType,
+			"from":      specObject{"type": "string", "format": "date"},
+			"to":        specObject{"type": "string", "format": "date"},
+			"items":     arrayOf(objectSchema(merge(specObject{"day": specObject{"type": "string", "format": "date"}}, usageCounters))),
+			"totals":    objectSchema(usageCounters),
+		}),
+	}
+}
+
+// public marks an operation as reachable without the bearer token.
+func public(op specObject) specObject {
+	op["security"] = []specObject{}
+	return op
+}
+
+// adminOnly marks an operation as guarded by the admin token instead of the API token.
+func adminOnly(op specObject) specObject {
+	op["security"] = []specObject{{"adminAuth": []string{}}}
+	return op
+}
+
+func operation(summary string, parameters []any, requestBody any, responses specObject) specObject {
+	responses["400"] = ref("#/components/responses/Error")
+	responses["401"] = ref("#/components/responses/Error")
+	responses["403"] = ref("#/components/responses/Error")
+	responses["404"] = ref("#/components/responses/Error")
+	responses["422"] = ref("#/components/responses/Error")
+	responses["429"] = ref("#/components/responses/Error")
+	responses["500"] = ref("#/components/responses/Error")
+
+	op := specObject{
+		"summary":   summary,
+		"responses": responses,
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	if requestBody != nil {
+		op["requestBody"] = specObject{
+			"required": true,
+			"content":  jsonContent(requestBody),
+		}
+	}
+	return op
+}
+
+func ref(name string) specObject {
+	if len(name) > 0 && name[0] == '#' {
+		return specObject{"$ref": name}
+	}
+	return specObject{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonContent(schema any) specObject {
+	return specObject{"application/json": specObject{"schema": schema}}
+}
+
+func jsonResponse(description string, schema any) specObject {
+	return specObject{"description": description, "content": jsonContent(schema)}
+}
+
+func objectSchema(properties specObject, required ...string) specObject {
+	schema := specObject{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func arrayOf(items any) specObject {
+	return specObject{"type": "array", "items": items}
+}
+
+func merge(values ...specObject) specObject {
+	merged := specObject{}
+	for _, value := range values {
+		for key, item := range value {
+			merged[key] = item
+		}
+	}
+	return merged
+}
+
+func pathParam(name, description string) specObject {
+	return specObject{
+		"name":        name,
+		"in":          "path",
+		"required":    true,
+		"description": description,
+		"schema":      specObject{"type": "string"},
+	}
+}
+
+func queryParam(name string, required bool) specObject {
+	return specObject{
+		"name":     name,
+		"in":       "query",
+		"required": required,
+		"schema":   specObject{"type": "string"},
+	}
+}
+
+func dateParam(name string) specObject {
+	return specObject{
+		"name":   name,
+		"in":     "query",
+		"schema": specObject{"type": "string", "format": "date-time"},
+	}
+}
+
+// dayParam accepts a day (2006-01-02) or a date-time, truncated to its UTC day.
+func dayParam(name string) specObject {
+	return specObject{
+		"name":   name,
+		"in":     "query",
+		"schema": specObject{"type": "string", "description": "Dia (2006-01-02) ou date-time; truncado ao dia UTC."},
+	}
+}
+
+func pageParams() []any {
+	return []any{
+		specObject{"name": "page", "in": "query", "schema": specObject{"type": "integer", "minimum": 1}},
+		specObject{"name": "page_size", "in": "query", "schema": specObject{"type": "integer", "minimum": 1, "maximum": 100}},
+		dateParam("from"),
+		dateParam("to"),
+	}
+}
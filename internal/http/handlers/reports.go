@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/iago/extensao-whatsapp-back/internal/domain"
 	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
 )
 
 func (api *API) Reports(w http.ResponseWriter, r *http.Request) {
@@ -23,8 +25,8 @@ func (api *API) Reports(w http.ResponseWriter, r *http.Request) {
 }
 
 func (api *API) createReport(w http.ResponseWriter, r *http.Request) {
-	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
-	if len(idempotencyKey) < 16 {
+	// Replays are served by the Idempotency middleware; these routes only insist on a key.
+	if len(strings.TrimSpace(r.Header.Get("Idempotency-Key"))) < 16 {
 		writeError(w, r, http.StatusBadRequest, "invalid_request", "Idempotency-Key header is required")
 		return
 	}
@@ -34,61 +36,77 @@ func (api *API) createReport(w http.ResponseWriter, r *http.Request) {
 		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
 		return
 	}
-	if err := validateConversation(request.Conversation); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid_request", "conversation fields are required")
-		return
-	}
+	errs := validateConversation(request.Conversation, "conversation")
 	if request.ReportType == "" {
 		request.ReportType = "timeline"
 	}
 	switch request.ReportType {
 	case "timeline", "temas", "atendimento":
 	default:
-		writeError(w, r, http.StatusBadRequest, "invalid_request", "report_type must be timeline, temas or atendimento")
+		errs.add("report_type", fieldCodeInvalidValue, "report_type must be timeline, temas or atendimento")
+	}
+	tags, tagErrs := normalizeTags(request.Tags, "tags")
+	errs = append(errs, tagErrs...)
+	sections, sectionErrs := normalizeSections(request.Sections, "sections")
+	errs = append(errs, sectionErrs...)
+	errs = append(errs, validatePriority(request.Priority)...)
+	request.Tags = tags
+	request.Sections = sections
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
 		return
 	}
-
-	payloadHash := hashPayload(request)
-	if entry, exists := api.idempotency.Get(idempotencyKey); exists {
-		if entry.PayloadHash != payloadHash {
-			writeError(w, r, http.StatusConflict, "idempotency_conflict", "Idempotency-Key already used with different payload")
-			return
-		}
-		response := map[string]any{
-			"job_id":      entry.JobID,
-			"status":      "pending",
-			"status_url":  "/v1/jobs/" + entry.JobID,
-			"accepted_at": entry.CreatedAt.Format(time.RFC3339Nano),
-			"hitl":        policy.DefaultHITLMetadata(),
-		}
-		w.Header().Set("Retry-After", "2")
-		writeJSON(w, http.StatusAccepted, response)
+	if !authorizeConversation(w, r, request.Conversation) {
 		return
 	}
 
 	rawPayload, _ := json.Marshal(request)
 	if err := policy.ValidateManualOnlyPayload(rawPayload); err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
 		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "automatic send is not allowed")
 		return
 	}
-	if err := policy.EnforceContentPolicy(rawPayload); err != nil {
+	policyWarnings, err := policy.CheckContentPolicy(request.Conversation.TenantID, rawPayload)
+	if err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
 		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "request blocked by policy")
 		return
 	}
-	rawPayload = policy.MaskPIIJSON(rawPayload)
-
-	job, err := api.jobsService.EnqueueReport(
+	rawPayload, err = api.pseudonymsService.ProtectJSON(
 		r.Context(),
 		request.Conversation.TenantID,
 		request.Conversation.ConversationID,
 		rawPayload,
 	)
 	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to enqueue report job")
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to protect personal data")
 		return
 	}
 
-	api.idempotency.Put(idempotencyKey, payloadHash, job.ID)
+	var job, summary *domain.Job
+	if request.WithSummary {
+		job, summary, err = api.jobsService.EnqueueReportWithSummary(
+			r.Context(),
+			request.Conversation.TenantID,
+			request.Conversation.ConversationID,
+			rawPayload,
+			request.Tags,
+			domain.JobPriority(request.Priority),
+		)
+	} else {
+		job, err = api.jobsService.EnqueueReport(
+			r.Context(),
+			request.Conversation.TenantID,
+			request.Conversation.ConversationID,
+			rawPayload,
+			request.Tags,
+			domain.JobPriority(request.Priority),
+		)
+	}
+	if err != nil {
+		writeEnqueueError(w, r, err, "failed to enqueue report job")
+		return
+	}
 
 	response := map[string]any{
 		"job_id":      job.ID,
@@ -97,8 +115,11 @@ func (api *API) createReport(w http.ResponseWriter, r *http.Request) {
 		"accepted_at": job.CreatedAt.Format(time.RFC3339Nano),
 		"hitl":        policy.DefaultHITLMetadata(),
 	}
+	if summary != nil {
+		response["depends_on"] = summary.ID
+	}
 	w.Header().Set("Retry-After", "2")
-	writeJSON(w, http.StatusAccepted, response)
+	writeJSON(w, http.StatusAccepted, withPolicyWarnings(response, policyWarnings))
 }
 
 func (api *API) listReports(w http.ResponseWriter, r *http.Request) {
@@ -123,13 +144,32 @@ func (api *API) listReports(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	search := strings.TrimSpace(query.Get("q"))
+	if len(search) > 200 {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "q must have at most 200 characters")
+		return
+	}
+
+	tags, errs := normalizeTags(splitTagsQuery(query["tags"]), "tags")
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	tenantID := strings.TrimSpace(query.Get("tenant_id"))
+	if tenantID != "" && !authorizeTenant(w, r, tenantID) {
+		return
+	}
+
 	filter := domain.ReportListFilter{
-		TenantID: strings.TrimSpace(query.Get("tenant_id")),
+		TenantID: tenantID,
 		Page:     page,
 		PageSize: pageSize,
 		From:     from,
 		To:       to,
 		Topic:    strings.TrimSpace(query.Get("topic")),
+		Query:    search,
+		Tags:     tags,
 	}
 
 	items, total, err := api.jobsService.ListReports(r.Context(), filter)
@@ -146,6 +186,7 @@ func (api *API) listReports(w http.ResponseWriter, r *http.Request) {
 			"status":          item.Status,
 			"created_at":      item.CreatedAt.Format(time.RFC3339Nano),
 			"title":           item.Title,
+			"tags":            nonNilTags(item.Tags),
 		})
 	}
 
@@ -158,3 +199,139 @@ func (api *API) listReports(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusOK, response)
 }
+
+// ReportDetail serves GET /v1/reports/{id} with the full generated report and PATCH to
+// replace its tags.
+func (api *API) ReportDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPatch {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	reportID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/reports/"), "/")
+	if reportID == "" || strings.Contains(reportID, "/") {
+		writeError(w, r, http.StatusNotFound, "not_found", "report not found")
+		return
+	}
+	if r.Method == http.MethodPatch {
+		api.patchReport(w, r, reportID)
+		return
+	}
+
+	job, err := api.jobsService.GetJob(r.Context(), reportID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, http.StatusNotFound, "not_found", "report not found")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to load report")
+		return
+	}
+	tenantID := strings.TrimSpace(r.URL.Query().Get("tenant_id"))
+	if job.Kind != domain.JobKindReport || !inScope(r, job.TenantID) || (tenantID != "" && tenantID != job.TenantID) {
+		writeError(w, r, http.StatusNotFound, "not_found", "report not found")
+		return
+	}
+
+	response := map[string]any{
+		"report_id":       job.ID,
+		"tenant_id":       job.TenantID,
+		"conversation_id": job.ConversationID,
+		"status":          job.Status,
+		"created_at":      job.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at":      job.UpdatedAt.Format(time.RFC3339Nano),
+		"tags":            nonNilTags(job.Tags),
+		"hitl":            policy.DefaultHITLMetadata(),
+	}
+
+	if job.Status != domain.JobStatusDone {
+		response["status_url"] = "/v1/jobs/" + job.ID
+		if job.Status == domain.JobStatusFailed && strings.TrimSpace(job.ErrorMessage) != "" {
+			response["error"] = jobError(job)
+		} else {
+			w.Header().Set("Retry-After", "2")
+		}
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	var result struct {
+		Title         string            `json:"title"`
+		Sections      []json.RawMessage `json:"sections"`
+		QualityScore  *float64          `json:"quality_score"`
+		PromptVersion string            `json:"prompt_version"`
+		ModelID       string            `json:"model_id"`
+		Usage         json.RawMessage   `json:"usage"`
+	}
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "stored report is malformed")
+		return
+	}
+
+	sections := result.Sections
+	if sections == nil {
+		sections = []json.RawMessage{}
+	}
+	response["title"] = result.Title
+	response["sections"] = sections
+	response["quality_score"] = result.QualityScore
+	metadata := map[string]any{
+		"model_id":       result.ModelID,
+		"prompt_version": result.PromptVersion,
+	}
+	if len(result.Usage) > 0 {
+		metadata["usage"] = result.Usage
+	}
+	response["metadata"] = metadata
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (api *API) patchReport(w http.ResponseWriter, r *http.Request, reportID string) {
+	var request reportPatchRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return
+	}
+	if request.Tags == nil {
+		var errs fieldErrors
+		errs.add("tags", fieldCodeRequired, "tags is required")
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	tags, errs := normalizeTags(*request.Tags, "tags")
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+
+	job, err := api.jobsService.GetJob(r.Context(), reportID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, http.StatusNotFound, "not_found", "report not found")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to load report")
+		return
+	}
+	tenantID := strings.TrimSpace(r.URL.Query().Get("tenant_id"))
+	if !inScope(r, job.TenantID) || (tenantID != "" && tenantID != job.TenantID) {
+		writeError(w, r, http.StatusNotFound, "not_found", "report not found")
+		return
+	}
+
+	job, err = api.jobsService.SetReportTags(r.Context(), reportID, tags)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			writeError(w, r, http.StatusNotFound, "not_found", "report not found")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to update report tags")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"report_id": job.ID,
+		"tags":      nonNilTags(job.Tags),
+	})
+}
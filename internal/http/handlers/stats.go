@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+const defaultStatsWindow = 30 * 24 * time.Hour
+
+// JobStats serves per-tenant usage aggregates for the dashboard.
+func (api *API) JobStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	tenantID := strings.TrimSpace(query.Get("tenant_id"))
+	if tenantID == "" {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "tenant_id is required")
+		return
+	}
+	if !authorizeTenant(w, r, tenantID) {
+		return
+	}
+
+	from, err := parseOptionalDateTime(query.Get("from"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid from date")
+		return
+	}
+	to, err := parseOptionalDateTime(query.Get("to"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid to date")
+		return
+	}
+	if to == nil {
+		now := time.Now().UTC()
+		to = &now
+	}
+	if from == nil {
+		start := to.Add(-defaultStatsWindow)
+		from = &start
+	}
+	if from.After(*to) {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "from must be before to")
+		return
+	}
+
+	buckets, err := api.jobsService.JobStats(r.Context(), domain.JobStatsFilter{
+		TenantID: tenantID,
+		From:     from,
+		To:       to,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to load job stats")
+		return
+	}
+
+	total := 0
+	byKind := make(map[string]int)
+	byStatus := make(map[string]int)
+	items := make([]map[string]any, 0, len(buckets))
+	for _, bucket := range buckets {
+		total += bucket.Count
+		byKind[string(bucket.Kind)] += bucket.Count
+		byStatus[string(bucket.Status)] += bucket.Count
+		items = append(items, map[string]any{
+			"day":            bucket.Day.Format("2006-01-02"),
+			"kind":           bucket.Kind,
+			"status":         bucket.Status,
+			"count":          bucket.Count,
+			"avg_latency_ms": bucket.AvgLatencyMS,
+			"max_latency_ms": bucket.MaxLatencyMS,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"tenant_id": tenantID,
+		"from":      from.Format(time.RFC3339Nano),
+		"to":        to.Format(time.RFC3339Nano),
+		"items":     items,
+		"totals": map[string]any{
+			"jobs":      total,
+			"by_kind":   byKind,
+			"by_status": byStatus,
+		},
+	})
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -22,36 +23,83 @@ func (api *API) Suggestions(w http.ResponseWriter, r *http.Request) {
 		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
 		return
 	}
-	if err := validateConversation(request.Conversation); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid_request", "conversation fields are required")
+	api.generateSuggestions(w, r, suggestionRequestV2{
+		Conversation:           request.Conversation,
+		Locale:                 request.Locale,
+		Tone:                   request.Tone,
+		Mode:                   request.Mode,
+		ContextWindow:          request.ContextWindow,
+		Messages:               upgradeLegacyMessages(request.Messages),
+		MaxCandidates:          request.MaxCandidates,
+		IncludeLastUserMessage: request.IncludeLastUserMessage,
+	}, nil)
+}
+
+func (api *API) SuggestionsV2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
 		return
 	}
-	request.Locale = strings.TrimSpace(request.Locale)
-	if request.Locale == "" || len(request.Locale) > 16 {
-		writeError(w, r, http.StatusBadRequest, "invalid_request", "locale is required and must have at most 16 chars")
+
+	var request suggestionRequestV2
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
 		return
 	}
+	api.generateSuggestions(w, r, request, validateConversationMessages(request.Messages))
+}
+
+// generateSuggestions serves both API versions. errs carries validation failures found
+// while decoding version-specific fields.
+func (api *API) generateSuggestions(
+	w http.ResponseWriter,
+	r *http.Request,
+	request suggestionRequestV2,
+	errs fieldErrors,
+) {
+	errs = append(errs, validateConversation(request.Conversation, "conversation")...)
+	request.Locale = api.resolveTenantLocale(r, request.Conversation.TenantID, request.Locale)
 
 	tone := strings.TrimSpace(strings.ToLower(request.Tone))
-	switch tone {
-	case "formal", "neutro", "amigavel":
+	if tone == "" {
+		tone = api.tenantSettings.Resolve(r.Context(), request.Conversation.TenantID).Tone
+	}
+	errs = append(errs, validateTone(request.Conversation.TenantID, tone)...)
+
+	// mode may come in the body or as ?mode=quick; the body wins.
+	if request.Mode == "" {
+		request.Mode = r.URL.Query().Get("mode")
+	}
+	request.Mode = strings.TrimSpace(strings.ToLower(request.Mode))
+	switch request.Mode {
+	case "":
+		request.Mode = service.SuggestionModeStandard
+	case service.SuggestionModeStandard, service.SuggestionModeQuick:
 	default:
-		writeError(w, r, http.StatusBadRequest, "invalid_request", "tone must be formal, neutro or amigavel")
-		return
+		errs.add("mode", fieldCodeInvalidValue, "mode must be standard or quick")
 	}
 
 	if request.ContextWindow < 5 || request.ContextWindow > 80 {
-		writeError(w, r, http.StatusBadRequest, "invalid_request", "context_window must be between 5 and 80")
+		errs.add("context_window", fieldCodeOutOfRange, "context_window must be between 5 and 80")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	if !authorizeConversation(w, r, request.Conversation) {
 		return
 	}
-	request.Messages = sanitizeSuggestionMessages(request.Messages, request.ContextWindow)
+	request.Messages = sanitizeConversationMessages(request.Messages, request.ContextWindow)
 
 	rawPayload, _ := json.Marshal(request)
 	if err := policy.ValidateManualOnlyPayload(rawPayload); err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
 		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "automatic send is not allowed")
 		return
 	}
-	if err := policy.EnforceContentPolicy(rawPayload); err != nil {
+	policyWarnings, err := policy.CheckContentPolicy(request.Conversation.TenantID, rawPayload)
+	if err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
 		statusCode := http.StatusUnprocessableEntity
 		message := "request blocked by policy"
 		var violation *policy.PolicyViolationError
@@ -61,13 +109,23 @@ func (api *API) Suggestions(w http.ResponseWriter, r *http.Request) {
 		writeError(w, r, statusCode, "policy_violation", message)
 		return
 	}
-	rawPayload = policy.MaskPIIJSON(rawPayload)
+	rawPayload, err = api.pseudonymsService.ProtectJSON(
+		r.Context(),
+		request.Conversation.TenantID,
+		request.Conversation.ConversationID,
+		rawPayload,
+	)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to protect personal data")
+		return
+	}
 
 	output, err := api.suggestionsService.Generate(r.Context(), service.SuggestionsInput{
 		TenantID:       request.Conversation.TenantID,
 		ConversationID: request.Conversation.ConversationID,
 		Locale:         request.Locale,
 		Tone:           tone,
+		Mode:           request.Mode,
 		ContextWindow:  request.ContextWindow,
 		Payload:        rawPayload,
 	})
@@ -78,45 +136,18 @@ func (api *API) Suggestions(w http.ResponseWriter, r *http.Request) {
 
 	response := map[string]any{
 		"request_id":     middleware.GetRequestID(r.Context()),
+		"locale":         request.Locale,
 		"model_id":       output.ModelID,
 		"prompt_version": output.PromptVersion,
+		"mode":           request.Mode,
 		"suggestions":    output.Suggestions,
+		"masking":        suggestionsMasking(output.Suggestions),
 		"quality_score":  output.QualityScore,
 		"hitl_required":  true,
 		"hitl":           policy.DefaultHITLMetadata(),
 	}
-	writeJSON(w, http.StatusOK, response)
-}
-
-const (
-	minSuggestionMessages     = 8
-	maxSuggestionMessages     = 80
-	maxSuggestionMessageRunes = 360
-)
-
-func sanitizeSuggestionMessages(messages []string, contextWindow int) []string {
-	limit := contextWindow * 2
-	if limit < minSuggestionMessages {
-		limit = minSuggestionMessages
-	}
-	if limit > maxSuggestionMessages {
-		limit = maxSuggestionMessages
-	}
-
-	sanitized := make([]string, 0, limit)
-	for _, message := range messages {
-		if len(sanitized) >= limit {
-			break
-		}
-
-		trimmed := strings.TrimSpace(message)
-		if trimmed == "" {
-			continue
-		}
-		trimmed = truncateRunes(trimmed, maxSuggestionMessageRunes)
-		sanitized = append(sanitized, trimmed)
-	}
-	return sanitized
+	w.Header().Set("Content-Language", request.Locale)
+	writeJSON(w, http.StatusOK, withPolicyWarnings(response, policyWarnings))
 }
 
 func truncateRunes(value string, maxRunes int) string {
@@ -129,3 +160,13 @@ func truncateRunes(value string, maxRunes int) string {
 	}
 	return string(runes[:maxRunes]) + "..."
 }
+
+// suggestionsMasking reports the PII masks in the generated suggestions.
+func suggestionsMasking(suggestions []service.SuggestionCandidate) *policy.MaskingReport {
+	report := policy.NewMaskingReport()
+	for index, suggestion := range suggestions {
+		report.AddString(fmt.Sprintf("suggestions[%d].content", index), suggestion.Content)
+		report.AddString(fmt.Sprintf("suggestions[%d].rationale", index), suggestion.Rationale)
+	}
+	return report
+}
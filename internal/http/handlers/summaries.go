@@ -3,20 +3,28 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
 	"github.com/iago/extensao-whatsapp-back/internal/policy"
 )
 
 func (api *API) Summaries(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+	switch r.Method {
+	case http.MethodPost:
+		api.createSummary(w, r)
+	case http.MethodGet:
+		api.listSummaries(w, r)
+	default:
 		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
-		return
 	}
+}
 
-	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
-	if len(idempotencyKey) < 16 {
+func (api *API) createSummary(w http.ResponseWriter, r *http.Request) {
+	// Replays are served by the Idempotency middleware; these routes only insist on a key.
+	if len(strings.TrimSpace(r.Header.Get("Idempotency-Key"))) < 16 {
 		writeError(w, r, http.StatusBadRequest, "invalid_request", "Idempotency-Key header is required")
 		return
 	}
@@ -26,60 +34,57 @@ func (api *API) Summaries(w http.ResponseWriter, r *http.Request) {
 		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
 		return
 	}
-	if err := validateConversation(request.Conversation); err != nil {
-		writeError(w, r, http.StatusBadRequest, "invalid_request", "conversation fields are required")
-		return
-	}
+	errs := validateConversation(request.Conversation, "conversation")
 	if request.SummaryType == "" {
 		request.SummaryType = "short"
 	}
 	if request.SummaryType != "short" && request.SummaryType != "full" {
-		writeError(w, r, http.StatusBadRequest, "invalid_request", "summary_type must be short or full")
+		errs.add("summary_type", fieldCodeInvalidValue, "summary_type must be short or full")
+	}
+	errs = append(errs, validatePriority(request.Priority)...)
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
 		return
 	}
-
-	payloadHash := hashPayload(request)
-	if entry, exists := api.idempotency.Get(idempotencyKey); exists {
-		if entry.PayloadHash != payloadHash {
-			writeError(w, r, http.StatusConflict, "idempotency_conflict", "Idempotency-Key already used with different payload")
-			return
-		}
-		response := map[string]any{
-			"job_id":      entry.JobID,
-			"status":      "pending",
-			"status_url":  "/v1/jobs/" + entry.JobID,
-			"accepted_at": entry.CreatedAt.Format(time.RFC3339Nano),
-			"hitl":        policy.DefaultHITLMetadata(),
-		}
-		w.Header().Set("Retry-After", "2")
-		writeJSON(w, http.StatusAccepted, response)
+	if !authorizeConversation(w, r, request.Conversation) {
 		return
 	}
 
 	rawPayload, _ := json.Marshal(request)
 	if err := policy.ValidateManualOnlyPayload(rawPayload); err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
 		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "automatic send is not allowed")
 		return
 	}
-	if err := policy.EnforceContentPolicy(rawPayload); err != nil {
+	policyWarnings, err := policy.CheckContentPolicy(request.Conversation.TenantID, rawPayload)
+	if err != nil {
+		api.recordPolicyViolation(r, request.Conversation.TenantID, request.Conversation.ConversationID, err)
 		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "request blocked by policy")
 		return
 	}
-	rawPayload = policy.MaskPIIJSON(rawPayload)
+	rawPayload, err = api.pseudonymsService.ProtectJSON(
+		r.Context(),
+		request.Conversation.TenantID,
+		request.Conversation.ConversationID,
+		rawPayload,
+	)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to protect personal data")
+		return
+	}
 
 	job, err := api.jobsService.EnqueueSummary(
 		r.Context(),
 		request.Conversation.TenantID,
 		request.Conversation.ConversationID,
 		rawPayload,
+		domain.JobPriority(request.Priority),
 	)
 	if err != nil {
-		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to enqueue summary job")
+		writeEnqueueError(w, r, err, "failed to enqueue summary job")
 		return
 	}
 
-	api.idempotency.Put(idempotencyKey, payloadHash, job.ID)
-
 	response := map[string]any{
 		"job_id":      job.ID,
 		"status":      "pending",
@@ -88,5 +93,70 @@ func (api *API) Summaries(w http.ResponseWriter, r *http.Request) {
 		"hitl":        policy.DefaultHITLMetadata(),
 	}
 	w.Header().Set("Retry-After", "2")
-	writeJSON(w, http.StatusAccepted, response)
+	writeJSON(w, http.StatusAccepted, withPolicyWarnings(response, policyWarnings))
+}
+
+func (api *API) listSummaries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	from, err := parseOptionalDateTime(query.Get("from"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid from date")
+		return
+	}
+	to, err := parseOptionalDateTime(query.Get("to"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid to date")
+		return
+	}
+
+	tenantID := strings.TrimSpace(query.Get("tenant_id"))
+	if tenantID == "" {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "tenant_id is required")
+		return
+	}
+	if !authorizeTenant(w, r, tenantID) {
+		return
+	}
+
+	items, total, err := api.jobsService.ListSummaries(r.Context(), domain.SummaryListFilter{
+		TenantID:       tenantID,
+		ConversationID: strings.TrimSpace(query.Get("conversation_id")),
+		Page:           page,
+		PageSize:       pageSize,
+		From:           from,
+		To:             to,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to list summaries")
+		return
+	}
+
+	payloadItems := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		payloadItems = append(payloadItems, map[string]any{
+			"summary_id":      item.SummaryID,
+			"conversation_id": item.ConversationID,
+			"status":          item.Status,
+			"created_at":      item.CreatedAt.Format(time.RFC3339Nano),
+			"preview":         item.Preview,
+			"status_url":      "/v1/jobs/" + item.SummaryID,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":     payloadItems,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"has_next":  page*pageSize < total,
+	})
 }
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxReportTags     = 10
+	maxReportTagRunes = 32
+
+	maxReportSections     = 8
+	maxReportHeadingRunes = 80
+)
+
+// normalizeTags lowercases, trims and deduplicates tags, keeping their order. Tags hold
+// letters, digits and "-", "_", ":" or ".".
+func normalizeTags(raw []string, field string) ([]string, fieldErrors) {
+	var errs fieldErrors
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for index, value := range raw {
+		tag := strings.ToLower(strings.TrimSpace(value))
+		path := indexedPath(field, index)
+		switch {
+		case tag == "":
+			errs.add(path, fieldCodeRequired, "tags must not be empty")
+			continue
+		case utf8.RuneCountInString(tag) > maxReportTagRunes:
+			errs.add(path, fieldCodeTooLong, "tags must have at most 32 chars")
+			continue
+		case !validTag(tag):
+			errs.add(path, fieldCodeInvalidFmt, "tags may only contain letters, digits, '-', '_', ':' and '.'")
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxReportTags {
+		errs.add(field, fieldCodeOutOfRange, "at most 10 tags are allowed")
+	}
+	return tags, errs
+}
+
+func validTag(tag string) bool {
+	for _, char := range tag {
+		if unicode.IsLetter(char) || unicode.IsDigit(char) || strings.ContainsRune("-_:.", char) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// splitTagsQuery accepts both repeated (?tags=a&tags=b) and comma separated (?tags=a,b)
+// query values.
+func splitTagsQuery(values []string) []string {
+	tags := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if strings.TrimSpace(tag) != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// normalizeSections trims the requested report section headings and drops repeated ones,
+// keeping their order.
+func normalizeSections(raw []string, field string) ([]string, fieldErrors) {
+	var errs fieldErrors
+	headings := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for index, value := range raw {
+		heading := strings.TrimSpace(value)
+		path := indexedPath(field, index)
+		switch {
+		case heading == "":
+			errs.add(path, fieldCodeRequired, "sections must not be empty")
+			continue
+		case utf8.RuneCountInString(heading) > maxReportHeadingRunes:
+			errs.add(path, fieldCodeTooLong, "sections must have at most 80 chars")
+			continue
+		}
+		key := strings.ToLower(heading)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		headings = append(headings, heading)
+	}
+	if len(headings) > maxReportSections {
+		errs.add(field, fieldCodeOutOfRange, "at most 8 sections are allowed")
+	}
+	return headings, errs
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/iago/extensao-whatsapp-back/internal/audit"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+const (
+	maxTemplateNameRunes    = 80
+	maxTemplateContentRunes = 2000
+)
+
+// Templates serves POST (create) and GET (list) on /v1/templates.
+func (api *API) Templates(w http.ResponseWriter, r *http.Request) {
+	if api.templatesService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "templates are not configured")
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		api.createTemplate(w, r)
+	case http.MethodGet:
+		api.listTemplates(w, r)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+// TemplateDetail serves GET, PUT and DELETE on /v1/templates/{id}.
+func (api *API) TemplateDetail(w http.ResponseWriter, r *http.Request) {
+	if api.templatesService == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "templates are not configured")
+		return
+	}
+
+	templateID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/templates/"), "/")
+	if templateID == "" || strings.Contains(templateID, "/") {
+		writeError(w, r, http.StatusNotFound, "not_found", "template not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		api.getTemplate(w, r, templateID)
+	case http.MethodPut:
+		api.updateTemplate(w, r, templateID)
+	case http.MethodDelete:
+		api.deleteTemplate(w, r, templateID)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+func (api *API) createTemplate(w http.ResponseWriter, r *http.Request) {
+	input, policyWarnings, ok := api.decodeTemplateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	template, err := api.templatesService.Create(r.Context(), input)
+	if err != nil {
+		writeTemplateError(w, r, err, "failed to create template")
+		return
+	}
+	audit.Note(r.Context(), template.TenantID, template.ID)
+	writeJSON(w, http.StatusCreated, withPolicyWarnings(templatePayload(template), policyWarnings))
+}
+
+func (api *API) updateTemplate(w http.ResponseWriter, r *http.Request, templateID string) {
+	input, policyWarnings, ok := api.decodeTemplateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	template, err := api.templatesService.Update(r.Context(), templateID, input)
+	if err != nil {
+		writeTemplateError(w, r, err, "failed to update template")
+		return
+	}
+	writeJSON(w, http.StatusOK, withPolicyWarnings(templatePayload(template), policyWarnings))
+}
+
+func (api *API) getTemplate(w http.ResponseWriter, r *http.Request, templateID string) {
+	template, ok := api.loadTemplate(w, r, templateID)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, templatePayload(template))
+}
+
+func (api *API) deleteTemplate(w http.ResponseWriter, r *http.Request, templateID string) {
+	if _, ok := api.loadTemplate(w, r, templateID); !ok {
+		return
+	}
+	if err := api.templatesService.Delete(r.Context(), templateID); err != nil {
+		writeTemplateError(w, r, err, "failed to delete template")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *API) listTemplates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	tenantID := strings.TrimSpace(query.Get("tenant_id"))
+	if tenantID == "" {
+		var errs fieldErrors
+		errs.add("tenant_id", fieldCodeRequired, "tenant_id is required")
+		writeValidationErrors(w, r, errs)
+		return
+	}
+	if !authorizeTenant(w, r, tenantID) {
+		return
+	}
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	items, total, err := api.templatesService.List(r.Context(), domain.TemplateListFilter{
+		TenantID: tenantID,
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to list templates")
+		return
+	}
+
+	payloadItems := make([]map[string]any, 0, len(items))
+	for index := range items {
+		payloadItems = append(payloadItems, templatePayload(&items[index]))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":     payloadItems,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"has_next":  page*pageSize < total,
+	})
+}
+
+// loadTemplate fetches a template and checks the optional tenant_id query parameter.
+func (api *API) loadTemplate(w http.ResponseWriter, r *http.Request, templateID string) (*domain.ReplyTemplate, bool) {
+	template, err := api.templatesService.Get(r.Context(), templateID)
+	if err != nil {
+		writeTemplateError(w, r, err, "failed to load template")
+		return nil, false
+	}
+	if tenantID := strings.TrimSpace(r.URL.Query().Get("tenant_id")); tenantID != "" && tenantID != template.TenantID {
+		writeError(w, r, http.StatusForbidden, "forbidden", "template belongs to another tenant")
+		return nil, false
+	}
+	return template, true
+}
+
+func (api *API) decodeTemplateRequest(w http.ResponseWriter, r *http.Request) (service.SaveTemplateInput, []policy.Violation, bool) {
+	var request templateRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+		return service.SaveTemplateInput{}, nil, false
+	}
+
+	var errs fieldErrors
+	request.TenantID = strings.TrimSpace(request.TenantID)
+	switch {
+	case request.TenantID == "":
+		errs.add("tenant_id", fieldCodeRequired, "tenant_id is required")
+	case len(request.TenantID) > 64:
+		errs.add("tenant_id", fieldCodeTooLong, "tenant_id must have at most 64 chars")
+	}
+
+	request.Name = strings.TrimSpace(request.Name)
+	switch {
+	case request.Name == "":
+		errs.add("name", fieldCodeRequired, "name is required")
+	case utf8.RuneCountInString(request.Name) > maxTemplateNameRunes:
+		errs.add("name", fieldCodeTooLong, "name must have at most 80 chars")
+	}
+
+	request.Content = strings.TrimSpace(request.Content)
+	switch {
+	case request.Content == "":
+		errs.add("content", fieldCodeRequired, "content is required")
+	case utf8.RuneCountInString(request.Content) > maxTemplateContentRunes:
+		errs.add("content", fieldCodeTooLong, "content must have at most 2000 chars")
+	}
+
+	request.Locale = resolveLocale(r, request.Locale)
+	if len(errs) > 0 {
+		writeValidationErrors(w, r, errs)
+		return service.SaveTemplateInput{}, nil, false
+	}
+	if !authorizeTenant(w, r, request.TenantID) {
+		return service.SaveTemplateInput{}, nil, false
+	}
+
+	rawPayload, _ := json.Marshal(request)
+	if err := policy.ValidateManualOnlyPayload(rawPayload); err != nil {
+		api.recordPolicyViolation(r, request.TenantID, "", err)
+		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "automatic send is not allowed")
+		return service.SaveTemplateInput{}, nil, false
+	}
+	policyWarnings, err := policy.CheckContentPolicy(request.TenantID, rawPayload)
+	if err != nil {
+		api.recordPolicyViolation(r, request.TenantID, "", err)
+		writeError(w, r, http.StatusUnprocessableEntity, "policy_violation", "request blocked by policy")
+		return service.SaveTemplateInput{}, nil, false
+	}
+
+	return service.SaveTemplateInput{
+		TenantID: request.TenantID,
+		Name:     request.Name,
+		Content:  request.Content,
+		Locale:   request.Locale,
+	}, policyWarnings, true
+}
+
+func writeTemplateError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, "not_found", "template not found")
+	case errors.Is(err, repository.ErrConflict):
+		writeError(w, r, http.StatusConflict, "conflict", "a template with this name already exists")
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal_error", fallback)
+	}
+}
+
+func templatePayload(template *domain.ReplyTemplate) map[string]any {
+	placeholders := template.Placeholders
+	if placeholders == nil {
+		placeholders = []string{}
+	}
+	return map[string]any{
+		"template_id":  template.ID,
+		"tenant_id":    template.TenantID,
+		"name":         template.Name,
+		"content":      template.Content,
+		"locale":       template.Locale,
+		"placeholders": placeholders,
+		"created_at":   template.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at":   template.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/ai"
+	"github.com/iago/extensao-whatsapp-back/internal/audit"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+// AdminTenantSettings lists the tenants with stored settings.
+func (api *API) AdminTenantSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.tenantSettings == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "tenant settings are not configured")
+		return
+	}
+	items, err := api.tenantSettings.List(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to list tenant settings")
+		return
+	}
+	response := make([]map[string]any, 0, len(items))
+	for _, settings := range items {
+		response = append(response, tenantSettingsResponse(settings))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": response})
+}
+
+// AdminTenantSettingsDetail serves GET, PUT (replace) and DELETE (back to the defaults) on
+// /admin/tenant-settings/{tenant_id}.
+func (api *API) AdminTenantSettingsDetail(w http.ResponseWriter, r *http.Request) {
+	if api.tenantSettings == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "tenant settings are not configured")
+		return
+	}
+	tenantID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/tenant-settings/"), "/")
+	if tenantID == "" || strings.Contains(tenantID, "/") {
+		writeError(w, r, http.StatusNotFound, "not_found", "tenant settings not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		settings, err := api.tenantSettings.Get(r.Context(), tenantID)
+		if err != nil {
+			writeTenantSettingsError(w, r, err, "failed to load tenant settings")
+			return
+		}
+		writeJSON(w, http.StatusOK, tenantSettingsResponse(*settings))
+	case http.MethodPut:
+		var request tenantSettingsRequest
+		if err := decodeJSON(r, &request); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid JSON payload")
+			return
+		}
+		input, errs := validateTenantSettings(tenantID, request)
+		if len(errs) > 0 {
+			writeValidationErrors(w, r, errs)
+			return
+		}
+		settings, err := api.tenantSettings.Save(r.Context(), input)
+		if err != nil {
+			writeTenantSettingsError(w, r, err, "failed to save tenant settings")
+			return
+		}
+		audit.AddMetadata(r.Context(), "tenant_id", tenantID)
+		writeJSON(w, http.StatusOK, tenantSettingsResponse(*settings))
+	case http.MethodDelete:
+		if err := api.tenantSettings.Delete(r.Context(), tenantID); err != nil {
+			writeTenantSettingsError(w, r, err, "failed to delete tenant settings")
+			return
+		}
+		audit.AddMetadata(r.Context(), "tenant_id", tenantID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+func validateTenantSettings(tenantID string, request tenantSettingsRequest) (service.SaveTenantSettingsInput, fieldErrors) {
+	var errs fieldErrors
+	if len(tenantID) > 64 {
+		errs.add("tenant_id", fieldCodeTooLong, "tenant_id must have at most 64 chars")
+	}
+
+	locale := strings.TrimSpace(request.Locale)
+	if locale != "" {
+		matched, ok := matchLocale(locale)
+		if !ok {
+			errs.add("locale", fieldCodeInvalidValue, "locale must be one of "+strings.Join(supportedLocales, ", "))
+		}
+		locale = matched
+	}
+
+	tone := strings.ToLower(strings.TrimSpace(request.Tone))
+	if tone != "" {
+		errs = append(errs, validateTone(tenantID, tone)...)
+	}
+
+	for task, model := range request.Models {
+		if !knownTask(task) {
+			errs.add("models."+task, fieldCodeInvalidValue, "unknown task")
+			continue
+		}
+		switch model = strings.TrimSpace(model); {
+		case model == "":
+			errs.add("models."+task, fieldCodeRequired, "model is required")
+		case len(model) > 128:
+			errs.add("models."+task, fieldCodeTooLong, "model must have at most 128 chars")
+		}
+	}
+
+	if request.Quotas.DailyJobs < 0 {
+		errs.add("quotas.daily_jobs", fieldCodeOutOfRange, "daily_jobs must not be negative")
+	}
+
+	profile := strings.TrimSpace(request.PolicyProfile)
+	if len(profile) > 64 {
+		errs.add("policy_profile", fieldCodeTooLong, "policy_profile must have at most 64 chars")
+	}
+
+	return service.SaveTenantSettingsInput{
+		TenantID:      tenantID,
+		Locale:        locale,
+		Tone:          tone,
+		Models:        request.Models,
+		Quotas:        domain.TenantQuotas{DailyJobs: request.Quotas.DailyJobs},
+		PolicyProfile: profile,
+	}, errs
+}
+
+func knownTask(task string) bool {
+	for _, kind := range ai.TaskKinds {
+		if string(kind) == task {
+			return true
+		}
+	}
+	return false
+}
+
+func writeTenantSettingsError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if errors.Is(err, repository.ErrNotFound) {
+		writeError(w, r, http.StatusNotFound, "not_found", "tenant settings not found")
+		return
+	}
+	writeError(w, r, http.StatusInternalServerError, "internal_error", message)
+}
+
+func tenantSettingsResponse(settings domain.TenantSettings) map[string]any {
+	models := settings.Models
+	if models == nil {
+		models = map[string]string{}
+	}
+	return map[string]any{
+		"tenant_id":      settings.TenantID,
+		"locale":         settings.Locale,
+		"tone":           settings.Tone,
+		"models":         models,
+		"quotas":         map[string]any{"daily_jobs": settings.Quotas.DailyJobs},
+		"policy_profile": settings.PolicyProfile,
+		"updated_at":     settings.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
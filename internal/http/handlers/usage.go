@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+const defaultUsageWindow = 30 * 24 * time.Hour
+
+// Usage serves the metered usage of a tenant per day, for billing and quota displays.
+func (api *API) Usage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	if api.metering == nil {
+		writeError(w, r, http.StatusNotImplemented, "not_implemented", "usage metering is not configured")
+		return
+	}
+
+	query := r.URL.Query()
+	tenantID := strings.TrimSpace(query.Get("tenant_id"))
+	if tenantID == "" {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "tenant_id is required")
+		return
+	}
+	if !authorizeTenant(w, r, tenantID) {
+		return
+	}
+
+	from, err := parseUsageDay(query.Get("from"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid from date")
+		return
+	}
+	to, err := parseUsageDay(query.Get("to"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "invalid to date")
+		return
+	}
+	if to == nil {
+		today := domain.UsageDay(time.Now())
+		to = &today
+	}
+	if from == nil {
+		start := to.Add(-defaultUsageWindow)
+		from = &start
+	}
+	if from.After(*to) {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", "from must be before to")
+		return
+	}
+
+	usage, err := api.metering.Usage(r.Context(), domain.TenantUsageFilter{
+		TenantID: tenantID,
+		From:     from,
+		To:       to,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal_error", "failed to load usage")
+		return
+	}
+
+	var totals domain.TenantUsage
+	items := make([]map[string]any, 0, len(usage))
+	for _, day := range usage {
+		totals.Add(day)
+		items = append(items, usageJSON(day, map[string]any{"day": day.Day.Format("2006-01-02")}))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"tenant_id": tenantID,
+		"from":      from.Format("2006-01-02"),
+		"to":        to.Format("2006-01-02"),
+		"items":     items,
+		"totals":    usageJSON(totals, map[string]any{}),
+	})
+}
+
+func usageJSON(usage domain.TenantUsage, fields map[string]any) map[string]any {
+	fields["requests"] = usage.Requests
+	fields["cache_hits"] = usage.CacheHits
+	fields["input_tokens"] = usage.InputTokens
+	fields["output_tokens"] = usage.OutputTokens
+	fields["total_tokens"] = usage.InputTokens + usage.OutputTokens
+	fields["cost_usd"] = usage.CostUSD
+	return fields
+}
+
+// parseUsageDay accepts a day (2006-01-02) or an RFC 3339 timestamp, truncated to its
+// UTC day.
+func parseUsageDay(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		return &day, nil
+	}
+	parsed, err := parseOptionalDateTime(value)
+	if err != nil {
+		return nil, err
+	}
+	day := domain.UsageDay(*parsed)
+	return &day, nil
+}
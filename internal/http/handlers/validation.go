@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+)
+
+// Field error codes returned in the "errors" array of validation failures.
+const (
+	fieldCodeRequired     = "required"
+	fieldCodeTooLong      = "too_long"
+	fieldCodeOutOfRange   = "out_of_range"
+	fieldCodeInvalidValue = "invalid_value"
+	fieldCodeInvalidFmt   = "invalid_format"
+)
+
+// fieldError points the client at a single invalid input.
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type fieldErrors []fieldError
+
+func (e *fieldErrors) add(field, code, message string) {
+	*e = append(*e, fieldError{Field: field, Code: code, Message: message})
+}
+
+// writeValidationErrors answers 400 with every collected field error. The top-level
+// message repeats the first error so clients that only read error.message still get
+// a useful hint.
+func writeValidationErrors(w http.ResponseWriter, r *http.Request, errs fieldErrors) {
+	message := "request validation failed"
+	if len(errs) > 0 {
+		message = errs[0].Message
+	}
+	writeErrorPayload(w, r, http.StatusBadRequest, "invalid_request", message, errs)
+}
+
+func fieldPath(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return prefix + "." + field
+}
+
+func indexedPath(field string, index int) string {
+	return fmt.Sprintf("%s[%d]", field, index)
+}
+
+// validateConversation checks a conversation reference; prefix is the JSON path of the
+// object holding the fields ("conversation" in request bodies, "" when flattened).
+func validateConversation(conversation conversationRef, prefix string) fieldErrors {
+	var errs fieldErrors
+
+	tenantID := strings.TrimSpace(conversation.TenantID)
+	switch {
+	case tenantID == "":
+		errs.add(fieldPath(prefix, "tenant_id"), fieldCodeRequired, "tenant_id is required")
+	case len(tenantID) > 64:
+		errs.add(fieldPath(prefix, "tenant_id"), fieldCodeTooLong, "tenant_id must have at most 64 chars")
+	}
+
+	conversationID := strings.TrimSpace(conversation.ConversationID)
+	switch {
+	case conversationID == "":
+		errs.add(fieldPath(prefix, "conversation_id"), fieldCodeRequired, "conversation_id is required")
+	case len(conversationID) > 128:
+		errs.add(fieldPath(prefix, "conversation_id"), fieldCodeTooLong, "conversation_id must have at most 128 chars")
+	}
+
+	if conversation.Channel != "" && conversation.Channel != "whatsapp_web" {
+		errs.add(fieldPath(prefix, "channel"), fieldCodeInvalidValue, "channel must be whatsapp_web")
+	}
+	return errs
+}
+
+// validatePriority accepts an empty priority, which keeps the default of the job kind.
+func validatePriority(priority string) fieldErrors {
+	var errs fieldErrors
+	switch domain.JobPriority(priority) {
+	case "", domain.JobPriorityHigh, domain.JobPriorityLow:
+	default:
+		errs.add("priority", fieldCodeInvalidValue, "priority must be high or low")
+	}
+	return errs
+}
+
+// validateTone accepts the built-in tones and the ones registered for the tenant.
+func validateTone(tenantID, tone string) fieldErrors {
+	var errs fieldErrors
+	tenantID = strings.TrimSpace(tenantID)
+	if !policy.ToneRegistered(tenantID, tone) {
+		errs.add("tone", fieldCodeInvalidValue, "tone must be one of "+strings.Join(policy.TenantTones(tenantID), ", "))
+	}
+	return errs
+}
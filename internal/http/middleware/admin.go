@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+)
+
+// AdminAuth guards /admin/ with its own bearer token, independent from the API token.
+// With jwt set, a JWT carrying the admin role is accepted too and its claims are kept for
+// auditing. API keys are never accepted, even with the admin scope: a key belongs to one
+// tenant and /admin acts on all of them. Without either the admin namespace answers 404.
+func AdminAuth(adminToken string, jwt *auth.JWTVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isAdminPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if adminToken == "" && jwt == nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":"not_found","message":"not found"},"request_id":"` + GetRequestID(r.Context()) + `"}`))
+				return
+			}
+
+			authorization := r.Header.Get("Authorization")
+			const prefix = "Bearer "
+			if !strings.HasPrefix(authorization, prefix) {
+				writeUnauthorized(w, r)
+				return
+			}
+			token := strings.TrimSpace(strings.TrimPrefix(authorization, prefix))
+			if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if jwt == nil || !auth.LooksLikeJWT(token) {
+				writeUnauthorized(w, r)
+				return
+			}
+
+			claims, err := jwt.Verify(r.Context(), token)
+			if err != nil {
+				writeUnauthorized(w, r)
+				return
+			}
+			if claims.Role() != auth.RoleAdmin {
+				writeErrorEnvelope(w, r, http.StatusForbidden, "insufficient_role", "role "+claims.Role()+" cannot access this route")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+func isAdminPath(path string) bool {
+	return path == "/admin" || strings.HasPrefix(path, "/admin/")
+}
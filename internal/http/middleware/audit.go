@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iago/extensao-whatsapp-back/internal/audit"
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+// auditedRoute maps a sensitive operation to its audit action. A Path ending in "/" matches
+// by prefix and the next path segment is taken as the resource ID. Conversation erasure and
+// API key management are audited by their services, which know the outcome in detail.
+type auditedRoute struct {
+	Method       string
+	Path         string
+	Action       string
+	ResourceType string
+}
+
+var auditedRoutes = []auditedRoute{
+	{http.MethodPost, "/v1/reports", "report.requested", "job"},
+	{http.MethodPost, "/v1/summaries", "summary.requested", "job"},
+	{http.MethodPost, "/v1/digests", "digest.requested", "job"},
+	{http.MethodPost, "/v1/templates", "template.created", "template"},
+	{http.MethodPut, "/v1/templates/", "template.updated", "template"},
+	{http.MethodDelete, "/v1/templates/", "template.deleted", "template"},
+	{http.MethodPost, "/v1/hitl/decisions", "hitl.decision_recorded", "hitl_decision"},
+	{http.MethodPost, "/v1/jobs/", "hitl.job_decided", "hitl_decision"},
+	{http.MethodPost, "/admin/cache/flush", "cache.flushed", "cache"},
+	{http.MethodPut, "/admin/worker", "worker.toggled", "worker"},
+}
+
+// Audit records successful sensitive operations: who (token subject, API key or admin
+// token), what, on which resource, with the request ID. It runs inside Timeout so an
+// operation that completes after the client got a 504 is still recorded. A failed write is
+// logged; the response has already been sent.
+func Audit(recorder audit.Recorder, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if recorder == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, resourceID, ok := matchAuditedRoute(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := audit.WithAnnotations(r.Context())
+			tracked := &headerTracker{ResponseWriter: w}
+			next.ServeHTTP(tracked, r.WithContext(ctx))
+			status := tracked.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusBadRequest {
+				return
+			}
+
+			annotation := audit.Annotations(ctx)
+			if annotation.ResourceID != "" {
+				resourceID = annotation.ResourceID
+			}
+			var metadata json.RawMessage
+			if len(annotation.Metadata) > 0 {
+				metadata, _ = json.Marshal(annotation.Metadata)
+			}
+			record := domain.AuditRecord{
+				ID:           uuid.NewString(),
+				TenantID:     firstNonEmptyString(annotation.TenantID, auditTenant(r)),
+				Actor:        auditActor(r),
+				Action:       route.Action,
+				ResourceType: route.ResourceType,
+				ResourceID:   resourceID,
+				RequestID:    GetRequestID(r.Context()),
+				Metadata:     metadata,
+				CreatedAt:    time.Now().UTC(),
+			}
+			if err := recorder.RecordAudit(context.WithoutCancel(r.Context()), record); err != nil && logger != nil {
+				logger.ErrorContext(r.Context(), "audit record failed",
+					slog.String("action", record.Action),
+					slog.String("resource_id", record.ResourceID),
+					slog.Any("error", err),
+				)
+			}
+		})
+	}
+}
+
+func matchAuditedRoute(r *http.Request) (auditedRoute, string, bool) {
+	for _, route := range auditedRoutes {
+		if route.Method != r.Method {
+			continue
+		}
+		if !strings.HasSuffix(route.Path, "/") {
+			if r.URL.Path == route.Path {
+				return route, "", true
+			}
+			continue
+		}
+		if rest, found := strings.CutPrefix(r.URL.Path, route.Path); found && rest != "" {
+			resourceID, _, _ := strings.Cut(rest, "/")
+			return route, resourceID, true
+		}
+	}
+	return auditedRoute{}, "", false
+}
+
+// auditActor names the caller: "<method>:<subject>" for JWT and API key callers, the admin
+// token on /admin and the shared API token otherwise.
+func auditActor(r *http.Request) string {
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok && claims.Subject != "" {
+		return claims.Method + ":" + claims.Subject
+	}
+	if r.URL.Path == "/admin" || strings.HasPrefix(r.URL.Path, "/admin/") {
+		return "admin_token"
+	}
+	return "api_token"
+}
+
+func auditTenant(r *http.Request) string {
+	if tenantID, ok := tenant.FromContext(r.Context()); ok {
+		return tenantID
+	}
+	return strings.TrimSpace(r.Header.Get(TenantHeader))
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
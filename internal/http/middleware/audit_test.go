@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+type recordingAudit struct {
+	records []domain.AuditRecord
+}
+
+func (r *recordingAudit) RecordAudit(_ context.Context, record domain.AuditRecord) error {
+	r.records = append(r.records, record)
+	return nil
+}
+
+func TestAuditRecordsSuccessfulSensitiveRoutesOnly(t *testing.T) {
+	recorder := &recordingAudit{}
+	status := http.StatusNoContent
+	handler := RequestID(Audit(recorder, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})))
+
+	send := func(method, path string) {
+		request := httptest.NewRequest(method, path, nil)
+		request.Header.Set("X-Request-Id", "req-audit")
+		request.Header.Set(TenantHeader, "tenant-a")
+		request = request.WithContext(auth.WithClaims(request.Context(), auth.Claims{Method: auth.MethodJWT, Subject: "agent-7"}))
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	send(http.MethodDelete, "/v1/templates/tpl-1")
+	send(http.MethodGet, "/v1/templates/tpl-1")
+	status = http.StatusNotFound
+	send(http.MethodDelete, "/v1/templates/tpl-2")
+
+	if len(recorder.records) != 1 {
+		t.Fatalf("expected one audit record, got %+v", recorder.records)
+	}
+	record := recorder.records[0]
+	if record.Action != "template.deleted" || record.ResourceID != "tpl-1" || record.Actor != "jwt:agent-7" ||
+		record.TenantID != "tenant-a" || record.RequestID != "req-audit" {
+		t.Fatalf("unexpected audit record: %+v", record)
+	}
+}
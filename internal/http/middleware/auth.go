@@ -1,41 +1,145 @@
 package middleware
 
 import (
+	"context"
+	"crypto/subtle"
 	"net/http"
 	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
 )
 
-func Auth(requiredToken string) func(http.Handler) http.Handler {
+// APIKeyHeader carries a per-tenant API key; "Authorization: Bearer wak_..." works too.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator resolves an active API key from its plaintext secret.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, secret string) (domain.APIKey, error)
+}
+
+// AuthConfig selects how /v1 and /v2 callers authenticate. With neither Token nor JWT set
+// the API is open (local development), but a presented API key is still validated.
+type AuthConfig struct {
+	// Token is the static bearer token shared with trusted callers.
+	Token string
+	// TokenFunc, when set, replaces Token and is read on every request so a rotated token
+	// applies at once.
+	TokenFunc func() string
+	// JWT validates bearer JWTs; their claims are exposed through auth.ClaimsFromContext.
+	JWT *auth.JWTVerifier
+	// APIKeys validates per-tenant API keys and their scopes.
+	APIKeys APIKeyAuthenticator
+}
+
+func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !strings.HasPrefix(r.URL.Path, "/v1/") {
+			if !isVersionedAPIPath(r.URL.Path) {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			if requiredToken == "" {
+			authorization := r.Header.Get("Authorization")
+			const prefix = "Bearer "
+			token := ""
+			if strings.HasPrefix(authorization, prefix) {
+				token = strings.TrimSpace(strings.TrimPrefix(authorization, prefix))
+			}
+
+			apiKey := strings.TrimSpace(r.Header.Get(APIKeyHeader))
+			if apiKey == "" && strings.HasPrefix(token, domain.APIKeyPrefix) {
+				apiKey = token
+			}
+			if apiKey != "" {
+				authenticateAPIKey(w, r, next, cfg.APIKeys, apiKey)
+				return
+			}
+
+			shared := cfg.Token
+			if cfg.TokenFunc != nil {
+				shared = cfg.TokenFunc()
+			}
+			if shared == "" && cfg.JWT == nil {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			authorization := r.Header.Get("Authorization")
-			const prefix = "Bearer "
-			if !strings.HasPrefix(authorization, prefix) {
+			if token == "" {
 				writeUnauthorized(w, r)
 				return
 			}
-
-			token := strings.TrimSpace(strings.TrimPrefix(authorization, prefix))
-			if token == "" || token != requiredToken {
+			if shared != "" && subtle.ConstantTimeCompare([]byte(token), []byte(shared)) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if cfg.JWT == nil || !auth.LooksLikeJWT(token) {
 				writeUnauthorized(w, r)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			claims, err := cfg.JWT.Verify(r.Context(), token)
+			if err != nil {
+				writeUnauthorized(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
 		})
 	}
 }
 
+func authenticateAPIKey(
+	w http.ResponseWriter,
+	r *http.Request,
+	next http.Handler,
+	authenticator APIKeyAuthenticator,
+	secret string,
+) {
+	if authenticator == nil {
+		writeUnauthorized(w, r)
+		return
+	}
+	key, err := authenticator.Authenticate(r.Context(), secret)
+	if err != nil {
+		writeUnauthorized(w, r)
+		return
+	}
+	if !key.HasScope(requiredScope(r.URL.Path)) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":{"code":"insufficient_scope","message":"api key lacks the scope required by this route"},"request_id":"` + GetRequestID(r.Context()) + `"}`))
+		return
+	}
+
+	claims := auth.Claims{
+		Method:   auth.MethodAPIKey,
+		Subject:  key.ID,
+		TenantID: key.TenantID,
+		Roles:    append([]string(nil), key.Scopes...),
+	}
+	next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+}
+
+// requiredScope maps a versioned route to the API key scope that grants it. Async
+// generation (summaries, reports, digests) needs reports, data erasure needs admin and
+// the assistance endpoints need suggestions.
+func requiredScope(path string) string {
+	for _, reportPrefix := range []string{"/v1/summaries", "/v1/reports", "/v1/digests", "/v1/jobs/", "/v1/stats/"} {
+		if strings.HasPrefix(path, reportPrefix) {
+			return domain.APIKeyScopeReports
+		}
+	}
+	if isErasurePath(path) {
+		return domain.APIKeyScopeAdmin
+	}
+	return domain.APIKeyScopeSuggestions
+}
+
+// isVersionedAPIPath reports whether path belongs to a versioned (/v1, /v2) API surface.
+func isVersionedAPIPath(path string) bool {
+	return strings.HasPrefix(path, "/v1/") || strings.HasPrefix(path, "/v2/")
+}
+
 func writeUnauthorized(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusUnauthorized)
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+type fakeAPIKeys map[string]domain.APIKey
+
+func (f fakeAPIKeys) Authenticate(_ context.Context, secret string) (domain.APIKey, error) {
+	key, ok := f[secret]
+	if !ok {
+		return domain.APIKey{}, errors.New("invalid api key")
+	}
+	return key, nil
+}
+
+func TestAuthStaticTokenWithoutJWT(t *testing.T) {
+	handler := Auth(AuthConfig{Token: "secret"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	for _, tc := range []struct {
+		path          string
+		authorization string
+		want          int
+	}{
+		{"/v1/suggestions", "", http.StatusUnauthorized},
+		{"/v1/suggestions", "Bearer other", http.StatusUnauthorized},
+		{"/v1/suggestions", "Bearer a.b.c", http.StatusUnauthorized},
+		{"/v1/suggestions", "Bearer secret", http.StatusTeapot},
+		{"/healthz", "", http.StatusTeapot},
+	} {
+		request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.authorization != "" {
+			request.Header.Set("Authorization", tc.authorization)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tc.want {
+			t.Fatalf("%s %q: expected %d, got %d", tc.path, tc.authorization, tc.want, recorder.Code)
+		}
+	}
+}
+
+func TestAuthTokenFuncFollowsRotation(t *testing.T) {
+	current := "old"
+	handler := Auth(AuthConfig{Token: "ignored", TokenFunc: func() string { return current }})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	call := func(token string) int {
+		request := httptest.NewRequest(http.MethodGet, "/v1/suggestions", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	if code := call("old"); code != http.StatusTeapot {
+		t.Fatalf("expected the current token to pass, got %d", code)
+	}
+	current = "new"
+	if code := call("old"); code != http.StatusUnauthorized {
+		t.Fatalf("expected the rotated-out token to be rejected, got %d", code)
+	}
+	if code := call("new"); code != http.StatusTeapot {
+		t.Fatalf("expected the rotated token to pass, got %d", code)
+	}
+}
+
+func TestAuthAPIKeyScopes(t *testing.T) {
+	var claims auth.Claims
+	handler := Auth(AuthConfig{APIKeys: fakeAPIKeys{
+		"wak_suggest": {ID: "key-1", TenantID: "tenant-a", Scopes: []string{domain.APIKeyScopeSuggestions}},
+		"wak_admin":   {ID: "key-2", TenantID: "tenant-a", Scopes: []string{domain.APIKeyScopeAdmin}},
+	}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ = auth.ClaimsFromContext(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	for _, tc := range []struct {
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"/v1/suggestions", "", "", http.StatusTeapot},
+		{"/v1/suggestions", APIKeyHeader, "wak_unknown", http.StatusUnauthorized},
+		{"/v1/suggestions", APIKeyHeader, "wak_suggest", http.StatusTeapot},
+		{"/v1/suggestions", "Authorization", "Bearer wak_suggest", http.StatusTeapot},
+		{"/v1/reports", APIKeyHeader, "wak_suggest", http.StatusForbidden},
+		{"/v1/conversations/chat-1/data", APIKeyHeader, "wak_suggest", http.StatusForbidden},
+		{"/v1/conversations/chat-1/data", APIKeyHeader, "wak_admin", http.StatusTeapot},
+		{"/v1/reports", APIKeyHeader, "wak_admin", http.StatusTeapot},
+	} {
+		claims = auth.Claims{}
+		request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.header != "" {
+			request.Header.Set(tc.header, tc.value)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tc.want {
+			t.Fatalf("%s %s=%q: expected %d, got %d", tc.path, tc.header, tc.value, tc.want, recorder.Code)
+		}
+		if tc.header != "" && tc.want == http.StatusTeapot && (claims.Method != auth.MethodAPIKey || claims.TenantID != "tenant-a") {
+			t.Fatalf("%s %s=%q: expected api key claims, got %+v", tc.path, tc.header, tc.value, claims)
+		}
+	}
+}
+
+func TestTenantScopePrefersTokenTenant(t *testing.T) {
+	var scoped string
+	handler := TenantScope(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scoped, _ = tenant.FromContext(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	for _, tc := range []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusTeapot},
+		{"tenant-a", http.StatusTeapot},
+		{"tenant-b", http.StatusForbidden},
+	} {
+		scoped = ""
+		request := httptest.NewRequest(http.MethodGet, "/v1/reports", nil)
+		request = request.WithContext(auth.WithClaims(request.Context(), auth.Claims{TenantID: "tenant-a"}))
+		if tc.header != "" {
+			request.Header.Set(TenantHeader, tc.header)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tc.want {
+			t.Fatalf("header %q: expected %d, got %d", tc.header, tc.want, recorder.Code)
+		}
+		if tc.want == http.StatusTeapot && scoped != "tenant-a" {
+			t.Fatalf("header %q: expected scope tenant-a, got %q", tc.header, scoped)
+		}
+	}
+}
+
+func TestTenantScopeFailsClosedWithoutTenant(t *testing.T) {
+	var scoped, bypassed bool
+	handler := TenantScope(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, scoped = tenant.FromContext(r.Context())
+		bypassed = tenant.Bypassed(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	for _, tc := range []struct {
+		path         string
+		header       string
+		want         int
+		wantScoped   bool
+		wantBypassed bool
+	}{
+		{"/v1/reports", "", http.StatusBadRequest, false, false},
+		{"/v2/suggestions", "  ", http.StatusBadRequest, false, false},
+		{"/v1/reports", "tenant-a", http.StatusTeapot, true, false},
+		{"/admin/tenants", "", http.StatusTeapot, false, true},
+		{"/admin/tenants", "tenant-a", http.StatusTeapot, true, false},
+		{"/healthz", "", http.StatusTeapot, false, false},
+	} {
+		scoped, bypassed = false, false
+		request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.header != "" {
+			request.Header.Set(TenantHeader, tc.header)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tc.want {
+			t.Fatalf("%s header %q: expected %d, got %d", tc.path, tc.header, tc.want, recorder.Code)
+		}
+		if scoped != tc.wantScoped || bypassed != tc.wantBypassed {
+			t.Fatalf("%s header %q: expected scoped=%v bypassed=%v, got %v %v",
+				tc.path, tc.header, tc.wantScoped, tc.wantBypassed, scoped, bypassed)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultMaxBodyBytes = 1 << 20
+
+// BodyLimitRoute overrides the body cap for requests matching Method and path Prefix.
+// An empty Method matches any method.
+type BodyLimitRoute struct {
+	Method   string
+	Prefix   string
+	MaxBytes int64
+}
+
+type BodyLimitConfig struct {
+	// MaxBytes caps bodies on routes without an override; zero means 1 MiB.
+	MaxBytes int64
+	Routes   []BodyLimitRoute
+}
+
+// ParseBodyLimitRoutes reads entries like "POST /v1/conversations/=4194304" (method optional).
+func ParseBodyLimitRoutes(entries []string) ([]BodyLimitRoute, error) {
+	routes := make([]BodyLimitRoute, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		target, rawBytes, ok := strings.Cut(entry, "=")
+		route := BodyLimitRoute{Prefix: strings.TrimSpace(target)}
+		if method, prefix, hasMethod := strings.Cut(route.Prefix, " "); hasMethod {
+			route.Method = strings.ToUpper(strings.TrimSpace(method))
+			route.Prefix = strings.TrimSpace(prefix)
+		}
+		maxBytes, err := strconv.ParseInt(strings.TrimSpace(rawBytes), 10, 64)
+		if !ok || !strings.HasPrefix(route.Prefix, "/") || err != nil || maxBytes <= 0 {
+			return nil, fmt.Errorf("body limit: invalid route entry %q", entry)
+		}
+		route.MaxBytes = maxBytes
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// BodyLimit rejects bodies above the route cap with 413 before handlers decode them. The
+// body is read through http.MaxBytesReader and handed on buffered, so handlers never see
+// a truncated payload.
+func BodyLimit(cfg BodyLimitConfig) func(http.Handler) http.Handler {
+	defaultLimit := cfg.MaxBytes
+	if defaultLimit <= 0 {
+		defaultLimit = defaultMaxBodyBytes
+	}
+	routes := append([]BodyLimitRoute(nil), cfg.Routes...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].Prefix) > len(routes[j].Prefix)
+	})
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limit := defaultLimit
+			for _, route := range routes {
+				if (route.Method == "" || route.Method == r.Method) && strings.HasPrefix(r.URL.Path, route.Prefix) {
+					limit = route.MaxBytes
+					break
+				}
+			}
+			if r.ContentLength > limit {
+				writePayloadTooLarge(w, r, limit)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writePayloadTooLarge(w, r, limit)
+					return
+				}
+				writeErrorEnvelope(w, r, http.StatusBadRequest, "invalid_request", "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writePayloadTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	w.Header().Set("Connection", "close")
+	writeErrorEnvelope(w, r, http.StatusRequestEntityTooLarge, "payload_too_large", "request body exceeds "+strconv.FormatInt(limit, 10)+" bytes")
+}
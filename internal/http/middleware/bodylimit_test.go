@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitPerRoute(t *testing.T) {
+	routes, err := ParseBodyLimitRoutes([]string{"POST /v1/conversations/=64"})
+	if err != nil {
+		t.Fatalf("parse routes: %v", err)
+	}
+	var received int
+	handler := BodyLimit(BodyLimitConfig{MaxBytes: 16, Routes: routes})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		received = len(raw)
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	for _, tc := range []struct {
+		path  string
+		size  int
+		chunk bool
+		want  int
+	}{
+		{"/v1/suggestions", 16, false, http.StatusTeapot},
+		{"/v1/suggestions", 17, false, http.StatusRequestEntityTooLarge},
+		{"/v1/suggestions", 17, true, http.StatusRequestEntityTooLarge},
+		{"/v1/conversations/chat-1/messages", 64, false, http.StatusTeapot},
+		{"/v1/conversations/chat-1/messages", 65, true, http.StatusRequestEntityTooLarge},
+	} {
+		received = -1
+		request := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(strings.Repeat("a", tc.size)))
+		if tc.chunk {
+			// Without a Content-Length the cap is enforced while reading.
+			request.ContentLength = -1
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tc.want {
+			t.Fatalf("%s size=%d: expected %d, got %d", tc.path, tc.size, tc.want, recorder.Code)
+		}
+		if tc.want == http.StatusTeapot && received != tc.size {
+			t.Fatalf("%s size=%d: handler received %d bytes", tc.path, tc.size, received)
+		}
+		if tc.want == http.StatusRequestEntityTooLarge && !strings.Contains(recorder.Body.String(), `"payload_too_large"`) {
+			t.Fatalf("%s size=%d: expected error envelope, got %s", tc.path, tc.size, recorder.Body.String())
+		}
+	}
+
+	if _, err := ParseBodyLimitRoutes([]string{"POST /v1/x=0"}); err == nil {
+		t.Fatalf("expected zero limit to be rejected")
+	}
+}
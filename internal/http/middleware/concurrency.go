@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"expvar"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+)
+
+var (
+	inFlightRequests      = expvar.NewInt("http_in_flight_requests")
+	concurrencyRejections = expvar.NewMap("http_concurrency_rejections_total")
+)
+
+type ConcurrencyConfig struct {
+	// MaxInFlight caps concurrent /v1 and /v2 requests across all tenants; zero disables it.
+	MaxInFlight int
+	// TenantMaxInFlight caps concurrent requests per tenant; zero disables it.
+	TenantMaxInFlight int
+	// QueueWait is how long a request may wait for a slot before being rejected.
+	QueueWait time.Duration
+}
+
+// ConcurrencyLimiter bounds in-flight API requests globally and per tenant. Requests
+// queue briefly for a slot; a full tenant answers 429 and a full server 503.
+type ConcurrencyLimiter struct {
+	maxInFlight       int
+	tenantMaxInFlight int
+	queueWait         time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	tenants  map[string]int
+	// released is closed and replaced whenever a slot frees up, waking queued requests.
+	released chan struct{}
+}
+
+func NewConcurrencyLimiter(cfg ConcurrencyConfig) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		maxInFlight:       cfg.MaxInFlight,
+		tenantMaxInFlight: cfg.TenantMaxInFlight,
+		queueWait:         cfg.QueueWait,
+		tenants:           make(map[string]int),
+		released:          make(chan struct{}),
+	}
+}
+
+// Middleware must run after Auth so the token tenant selects the per-tenant budget.
+func (l *ConcurrencyLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isVersionedAPIPath(r.URL.Path) || (l.maxInFlight <= 0 && l.tenantMaxInFlight <= 0) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenantID := concurrencyKey(r)
+		switch l.acquire(r, tenantID) {
+		case concurrencyTenantFull:
+			concurrencyRejections.Add("tenant", 1)
+			w.Header().Set("Retry-After", "1")
+			writeErrorEnvelope(w, r, http.StatusTooManyRequests, "too_many_in_flight", "too many concurrent requests for this tenant")
+			return
+		case concurrencyServerFull:
+			concurrencyRejections.Add("global", 1)
+			w.Header().Set("Retry-After", "1")
+			writeErrorEnvelope(w, r, http.StatusServiceUnavailable, "overloaded", "server is at capacity, retry shortly")
+			return
+		case concurrencyCancelled:
+			return
+		}
+		defer l.release(tenantID)
+		next.ServeHTTP(w, r)
+	})
+}
+
+type concurrencyOutcome int
+
+const (
+	concurrencyAcquired concurrencyOutcome = iota
+	concurrencyTenantFull
+	concurrencyServerFull
+	concurrencyCancelled
+)
+
+func (l *ConcurrencyLimiter) acquire(r *http.Request, tenantID string) concurrencyOutcome {
+	var timer *time.Timer
+	for {
+		l.mu.Lock()
+		tenantFull := l.tenantMaxInFlight > 0 && l.tenants[tenantID] >= l.tenantMaxInFlight
+		serverFull := l.maxInFlight > 0 && l.inFlight >= l.maxInFlight
+		if !tenantFull && !serverFull {
+			l.inFlight++
+			l.tenants[tenantID]++
+			l.mu.Unlock()
+			inFlightRequests.Add(1)
+			if timer != nil {
+				timer.Stop()
+			}
+			return concurrencyAcquired
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		outcome := concurrencyServerFull
+		if tenantFull {
+			outcome = concurrencyTenantFull
+		}
+		if timer == nil {
+			if l.queueWait <= 0 {
+				return outcome
+			}
+			timer = time.NewTimer(l.queueWait)
+		}
+		select {
+		case <-released:
+		case <-timer.C:
+			return outcome
+		case <-r.Context().Done():
+			timer.Stop()
+			return concurrencyCancelled
+		}
+	}
+}
+
+func (l *ConcurrencyLimiter) release(tenantID string) {
+	l.mu.Lock()
+	l.inFlight--
+	if l.tenants[tenantID] <= 1 {
+		delete(l.tenants, tenantID)
+	} else {
+		l.tenants[tenantID]--
+	}
+	close(l.released)
+	l.released = make(chan struct{})
+	l.mu.Unlock()
+	inFlightRequests.Add(-1)
+}
+
+// InFlight reports the current number of admitted requests.
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// concurrencyKey groups requests by the verified token tenant, falling back to the client
+// IP. X-Tenant-ID is ignored: it is not verified yet and a caller could rotate it to escape
+// the tenant budget.
+func concurrencyKey(r *http.Request) string {
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok && claims.TenantID != "" {
+		return "tenant:" + claims.TenantID
+	}
+	return "ip:" + extractIP(r.RemoteAddr)
+}
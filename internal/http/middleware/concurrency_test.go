@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+)
+
+func TestConcurrencyLimiterRejectsPerTenantAndGlobally(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyConfig{MaxInFlight: 2, TenantMaxInFlight: 1, QueueWait: 20 * time.Millisecond})
+	release := make(chan struct{})
+	entered := make(chan struct{}, 4)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	send := func(tenantID string) int {
+		request := httptest.NewRequest(http.MethodPost, "/v1/suggestions", nil)
+		request = request.WithContext(auth.WithClaims(request.Context(), auth.Claims{TenantID: tenantID}))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	var wg sync.WaitGroup
+	for _, tenantID := range []string{"tenant-a", "tenant-b"} {
+		wg.Add(1)
+		go func(tenantID string) {
+			defer wg.Done()
+			if status := send(tenantID); status != http.StatusTeapot {
+				t.Errorf("%s: expected admitted request, got %d", tenantID, status)
+			}
+		}(tenantID)
+		<-entered
+	}
+
+	if status := send("tenant-a"); status != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for busy tenant, got %d", status)
+	}
+	if status := send("tenant-c"); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 at global capacity, got %d", status)
+	}
+
+	// A queued request takes the slot freed while it waits.
+	queued := make(chan int, 1)
+	limiter.queueWait = time.Second
+	go func() { queued <- send("tenant-c") }()
+	time.Sleep(10 * time.Millisecond)
+	release <- struct{}{}
+	<-entered
+	close(release)
+	if status := <-queued; status != http.StatusTeapot {
+		t.Fatalf("expected queued request admitted, got %d", status)
+	}
+	wg.Wait()
+	if limiter.InFlight() != 0 {
+		t.Fatalf("expected all slots released, got %d", limiter.InFlight())
+	}
+}
+
+func TestConcurrencyLimiterIgnoresUnverifiedTenantHeader(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyConfig{MaxInFlight: 10, TenantMaxInFlight: 1, QueueWait: 20 * time.Millisecond})
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	send := func(tenantID string) int {
+		request := httptest.NewRequest(http.MethodPost, "/v1/suggestions", nil)
+		request.RemoteAddr = "203.0.113.7:1234"
+		request.Header.Set(TenantHeader, tenantID)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	done := make(chan int, 1)
+	go func() { done <- send("tenant-a") }()
+	<-entered
+	if status := send("tenant-b"); status != http.StatusTooManyRequests {
+		t.Fatalf("expected a rotated X-Tenant-ID to share the IP budget, got %d", status)
+	}
+	close(release)
+	if status := <-done; status != http.StatusTeapot {
+		t.Fatalf("expected the first request admitted, got %d", status)
+	}
+}
@@ -14,6 +14,9 @@ var (
 	defaultCORSAllowedMethods = []string{
 		http.MethodGet,
 		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
 		http.MethodOptions,
 	}
 	defaultCORSAllowedHeaders = []string{
@@ -21,6 +24,21 @@ var (
 		"Authorization",
 		"Content-Type",
 		"Idempotency-Key",
+		"X-API-Key",
+		"If-None-Match",
+		"X-Request-Id",
+		"X-Signature",
+		"X-Signature-Timestamp",
+		"X-Tenant-ID",
+		"traceparent",
+	}
+	defaultCORSExposedHeaders = []string{
+		"ETag",
+		"Idempotent-Replayed",
+		"Retry-After",
+		"X-RateLimit-Limit",
+		"X-RateLimit-Remaining",
+		"X-RateLimit-Reset",
 		"X-Request-Id",
 	}
 )
@@ -29,6 +47,7 @@ type CORSConfig struct {
 	AllowedOrigins []string
 	AllowedMethods []string
 	AllowedHeaders []string
+	ExposedHeaders []string
 	MaxAgeSeconds  int
 }
 
@@ -51,6 +70,11 @@ func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
 		allowedHeaders = append([]string(nil), defaultCORSAllowedHeaders...)
 	}
 
+	exposedHeaders := normalizeStringList(cfg.ExposedHeaders)
+	if len(exposedHeaders) == 0 {
+		exposedHeaders = append([]string(nil), defaultCORSExposedHeaders...)
+	}
+
 	maxAgeSeconds := cfg.MaxAgeSeconds
 	if maxAgeSeconds <= 0 {
 		maxAgeSeconds = defaultCORSMaxAgeSeconds
@@ -58,6 +82,7 @@ func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
 
 	allowMethodsValue := strings.Join(allowedMethods, ", ")
 	allowHeadersValue := strings.Join(allowedHeaders, ", ")
+	exposeHeadersValue := strings.Join(exposedHeaders, ", ")
 	maxAgeValue := strconv.Itoa(maxAgeSeconds)
 
 	return func(next http.Handler) http.Handler {
@@ -90,6 +115,7 @@ func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
 				return
 			}
 
+			w.Header().Set("Access-Control-Expose-Headers", exposeHeadersValue)
 			next.ServeHTTP(w, r)
 		})
 	}
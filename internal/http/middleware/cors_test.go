@@ -60,6 +60,9 @@ func TestCORSAllowsActualRequestFromAllowedOrigin(t *testing.T) {
 	if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "https://web.whatsapp.com" {
 		t.Fatalf("expected allow origin header, got %q", got)
 	}
+	if got := recorder.Header().Get("Access-Control-Expose-Headers"); !strings.Contains(got, "ETag") {
+		t.Fatalf("expected ETag in expose headers, got %q", got)
+	}
 }
 
 func TestCORSIgnoresDisallowedOrigin(t *testing.T) {
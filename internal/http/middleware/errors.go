@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/iago/extensao-whatsapp-back/internal/errorreport"
+)
+
+// maxReportedBody bounds the part of a 5xx response kept to read its error message.
+const maxReportedBody = 2048
+
+// ReportErrors sends 5xx responses and handler panics to reporter, tagged with route, the
+// mux pattern that served the request. It must sit inside Recover: panics are reported
+// with their stack and panicked again for Recover to answer.
+func ReportErrors(reporter errorreport.Reporter, route func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tracked := &errorBodyTracker{headerTracker: headerTracker{ResponseWriter: w}}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					if tracked.status >= http.StatusInternalServerError {
+						reporter.Report(r.Context(), errorreport.Event{
+							Err:     tracked.responseError(r),
+							Type:    "http_" + strconv.Itoa(tracked.status),
+							Tags:    requestTags(r, route, tracked.status),
+							Request: r,
+						})
+					}
+					return
+				}
+				err, ok := recovered.(error)
+				if !ok {
+					err = fmt.Errorf("%v", recovered)
+				}
+				// net/http uses ErrAbortHandler to abort a response on purpose.
+				if !errors.Is(err, http.ErrAbortHandler) {
+					reporter.Report(r.Context(), errorreport.Event{
+						Err:     err,
+						Level:   errorreport.LevelFatal,
+						Type:    "panic",
+						Tags:    requestTags(r, route, http.StatusInternalServerError),
+						Request: r,
+						Stack:   debug.Stack(),
+					})
+				}
+				panic(recovered)
+			}()
+			next.ServeHTTP(tracked, r)
+		})
+	}
+}
+
+func requestTags(r *http.Request, route func(*http.Request) string, status int) map[string]string {
+	name := route(r)
+	if name == "" {
+		name = "unmatched"
+	}
+	return map[string]string{
+		"component": "http",
+		"method":    metricMethod(r.Method),
+		"route":     name,
+		"status":    strconv.Itoa(status),
+	}
+}
+
+// errorBodyTracker keeps the start of 5xx response bodies, to report the message of
+// their error envelope.
+type errorBodyTracker struct {
+	headerTracker
+	body bytes.Buffer
+}
+
+func (t *errorBodyTracker) Write(data []byte) (int, error) {
+	written, err := t.headerTracker.Write(data)
+	if t.status >= http.StatusInternalServerError && t.body.Len() < maxReportedBody {
+		t.body.Write(data[:min(written, maxReportedBody-t.body.Len())])
+	}
+	return written, err
+}
+
+// responseError reads the code and message of the error envelope; responses without one
+// are reported by their status.
+func (t *errorBodyTracker) responseError(r *http.Request) error {
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(t.body.Bytes(), &envelope) == nil && envelope.Error.Message != "" {
+		return fmt.Errorf("%s %s: %s: %s", r.Method, r.URL.Path, envelope.Error.Code, envelope.Error.Message)
+	}
+	return fmt.Errorf("%s %s answered %d", r.Method, r.URL.Path, t.status)
+}
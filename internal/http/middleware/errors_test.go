@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iago/extensao-whatsapp-back/internal/errorreport"
+)
+
+type recordingReporter struct {
+	mu     sync.Mutex
+	events []errorreport.Event
+}
+
+func (r *recordingReporter) Report(_ context.Context, event errorreport.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func TestReportErrorsReportsServerErrors(t *testing.T) {
+	reporter := &recordingReporter{}
+	route := func(*http.Request) string { return "/v1/jobs/" }
+	handler := Recover(nil)(ReportErrors(reporter, route)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/jobs/failed":
+			writeErrorEnvelope(w, r, http.StatusInternalServerError, "internal_error", "failed to load job")
+		case "/v1/jobs/missing":
+			writeErrorEnvelope(w, r, http.StatusNotFound, "not_found", "job not found")
+		default:
+			panic("boom")
+		}
+	})))
+
+	for _, path := range []string{"/v1/jobs/failed", "/v1/jobs/missing", "/v1/jobs/panics"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if len(reporter.events) != 2 {
+		t.Fatalf("expected the 500 and the panic reported, got %+v", reporter.events)
+	}
+	failed := reporter.events[0]
+	if failed.Level != "" || failed.Type != "http_500" || failed.Tags["route"] != "/v1/jobs/" || failed.Tags["status"] != "500" ||
+		!strings.Contains(failed.Err.Error(), "internal_error: failed to load job") {
+		t.Fatalf("unexpected 5xx event: %+v %v", failed, failed.Err)
+	}
+	panicked := reporter.events[1]
+	if panicked.Level != errorreport.LevelFatal || panicked.Type != "panic" || panicked.Err.Error() != "boom" ||
+		!strings.Contains(string(panicked.Stack), "errors_test.go") {
+		t.Fatalf("unexpected panic event: %+v", panicked)
+	}
+}
+
+func TestReportErrorsLetsRecoverAnswerPanics(t *testing.T) {
+	handler := Recover(nil)(ReportErrors(&recordingReporter{}, func(*http.Request) string { return "" })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/suggestions", nil))
+	if recorder.Code != http.StatusInternalServerError || !strings.Contains(recorder.Body.String(), `"internal_error"`) {
+		t.Fatalf("expected Recover to answer 500, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
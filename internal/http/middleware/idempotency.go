@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+// IdempotencyKeyHeader names the client-chosen key that makes a POST safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const (
+	defaultIdempotencyTTL   = 24 * time.Hour
+	minIdempotencyKeyLength = 16
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored with the body and sent on replays.
+var replayedHeaders = []string{"Content-Type", "Location", "Retry-After", "ETag"}
+
+// IdempotencyStore persists responses per tenant and key; see
+// repository.IdempotencyRepository.
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(ctx context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, record domain.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, tenantID, key string) error
+}
+
+type IdempotencyConfig struct {
+	Store IdempotencyStore
+	// TTL is how long a response can be replayed; zero means 24h.
+	TTL time.Duration
+}
+
+// Idempotency replays the stored status, headers and body of a /v1 or /v2 POST when the
+// same tenant retries it with the same Idempotency-Key. The key is reserved before the
+// handler runs, so a concurrent retry gets 409 idempotency_in_progress instead of running
+// twice; reusing a key with a different method, path or body gets 409
+// idempotency_conflict. The conversation of the request is kept with the response so
+// erasure can drop it. 5xx and 429 responses are not stored and free the key for a
+// retry. Requests without the header pass through; routes that require it check that
+// themselves.
+func Idempotency(cfg IdempotencyConfig) func(http.Handler) http.Handler {
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+			if cfg.Store == nil || r.Method != http.MethodPost || key == "" || !isVersionedAPIPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) < minIdempotencyKeyLength || len(key) > maxIdempotencyKeyLength {
+				writeErrorEnvelope(w, r, http.StatusBadRequest, "invalid_request", "Idempotency-Key must have 16 to 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeErrorEnvelope(w, r, http.StatusBadRequest, "invalid_request", "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			tenantID, _ := tenant.FromContext(r.Context())
+			now := time.Now().UTC()
+			record := domain.IdempotencyRecord{
+				TenantID:       tenantID,
+				Key:            key,
+				RequestHash:    idempotencyRequestHash(r, body),
+				ConversationID: idempotencyConversationID(r, body),
+				CreatedAt:      now,
+				ExpiresAt:      now.Add(ttl),
+			}
+			existing, err := cfg.Store.ReserveIdempotencyKey(r.Context(), record)
+			if err != nil {
+				writeErrorEnvelope(w, r, http.StatusInternalServerError, "internal_error", "failed to check Idempotency-Key")
+				return
+			}
+			if existing != nil {
+				replayIdempotent(w, r, record, *existing)
+				return
+			}
+
+			// The outcome is saved even if the client went away or the request timed out, so
+			// the retry sees what actually happened.
+			storeCtx := context.WithoutCancel(r.Context())
+			recorder := &idempotencyRecorder{ResponseWriter: w}
+			saved := false
+			defer func() {
+				if !saved {
+					_ = cfg.Store.ReleaseIdempotencyKey(storeCtx, tenantID, key)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+				return
+			}
+			record.StatusCode = status
+			record.Body = recorder.body.Bytes()
+			record.Header = make(map[string]string, len(replayedHeaders))
+			for _, name := range replayedHeaders {
+				if value := recorder.Header().Get(name); value != "" {
+					record.Header[name] = value
+				}
+			}
+			saved = cfg.Store.CompleteIdempotencyKey(storeCtx, record) == nil
+		})
+	}
+}
+
+func replayIdempotent(w http.ResponseWriter, r *http.Request, request, existing domain.IdempotencyRecord) {
+	if !existing.Completed() {
+		w.Header().Set("Retry-After", "1")
+		writeErrorEnvelope(w, r, http.StatusConflict, "idempotency_in_progress", "a request with this Idempotency-Key is still running")
+		return
+	}
+	if existing.RequestHash != request.RequestHash {
+		writeErrorEnvelope(w, r, http.StatusConflict, "idempotency_conflict", "Idempotency-Key already used with different payload")
+		return
+	}
+	for name, value := range existing.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(existing.StatusCode)
+	_, _ = w.Write(existing.Body)
+}
+
+func idempotencyRequestHash(r *http.Request, body []byte) string {
+	hasher := sha256.New()
+	_, _ = io.WriteString(hasher, r.Method+"\n"+r.URL.RequestURI()+"\n")
+	_, _ = hasher.Write(body)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// idempotencyConversationID finds the conversation a request is about: the
+// /v{n}/conversations/{id} path segment, then conversation.conversation_id or
+// conversation_id in the JSON body.
+func idempotencyConversationID(r *http.Request, body []byte) string {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) >= 3 && segments[1] == "conversations" {
+		return segments[2]
+	}
+	var payload struct {
+		ConversationID string `json:"conversation_id"`
+		Conversation   struct {
+			ConversationID string `json:"conversation_id"`
+		} `json:"conversation"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+	if conversationID := strings.TrimSpace(payload.Conversation.ConversationID); conversationID != "" {
+		return conversationID
+	}
+	return strings.TrimSpace(payload.ConversationID)
+}
+
+// idempotencyRecorder passes the response through while keeping a copy to store.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *idempotencyRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *idempotencyRecorder) Write(payload []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(payload)
+	return r.ResponseWriter.Write(payload)
+}
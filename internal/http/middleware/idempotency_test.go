@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	store := repository.NewMemoryIdempotencyRepository()
+	calls := 0
+	handler := Idempotency(IdempotencyConfig{Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if strings.Contains(r.URL.Path, "fail") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"call":` + string(rune('0'+calls)) + `}`))
+	}))
+
+	send := func(path, key, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.Header.Set(IdempotencyKeyHeader, key)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := send("/v1/templates", "key-0000000000001", `{"a":1}`)
+	replay := send("/v1/templates", "key-0000000000001", `{"a":1}`)
+	if calls != 1 || replay.Code != http.StatusAccepted || replay.Body.String() != first.Body.String() {
+		t.Fatalf("expected replay of first response, calls=%d got %d %s", calls, replay.Code, replay.Body.String())
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" || replay.Header().Get("Retry-After") != "2" {
+		t.Fatalf("expected replay headers, got %v", replay.Header())
+	}
+
+	if conflict := send("/v1/templates", "key-0000000000001", `{"a":2}`); conflict.Code != http.StatusConflict || !strings.Contains(conflict.Body.String(), "idempotency_conflict") {
+		t.Fatalf("expected conflict for different body, got %d %s", conflict.Code, conflict.Body.String())
+	}
+	if short := send("/v1/templates", "short", `{}`); short.Code != http.StatusBadRequest {
+		t.Fatalf("expected short key rejected, got %d", short.Code)
+	}
+
+	send("/v1/fail", "key-0000000000002", `{}`)
+	send("/v1/fail", "key-0000000000002", `{}`)
+	if calls != 3 {
+		t.Fatalf("expected 5xx responses not to be stored, calls=%d", calls)
+	}
+
+	_, _ = store.ReserveIdempotencyKey(context.Background(), domain.IdempotencyRecord{
+		Key:         "key-0000000000003",
+		RequestHash: "pending",
+		ExpiresAt:   time.Now().Add(time.Minute),
+	})
+	if running := send("/v1/templates", "key-0000000000003", `{}`); running.Code != http.StatusConflict || running.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected in-progress conflict, got %d %v", running.Code, running.Header())
+	}
+}
+
+func TestIdempotencyRecordsTheConversation(t *testing.T) {
+	store := repository.NewMemoryIdempotencyRepository()
+	handler := Idempotency(IdempotencyConfig{Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for index, tc := range []struct {
+		path string
+		body string
+	}{
+		{"/v1/summaries", `{"conversation": {"tenant_id": "tenant-a", "conversation_id": "chat-1"}}`},
+		{"/v1/conversations/chat-1/messages", `{"messages": []}`},
+		{"/v1/hitl/decisions", `{"conversation_id": "chat-1"}`},
+		{"/v1/templates", `{"name": "other"}`},
+	} {
+		request := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		request = request.WithContext(tenant.WithScope(request.Context(), "tenant-a"))
+		request.Header.Set(IdempotencyKeyHeader, fmt.Sprintf("key-conversation-%d", index))
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	deleted, err := store.DeleteConversationIdempotencyKeys(tenant.WithScope(context.Background(), "tenant-a"), "tenant-a", "chat-1")
+	if err != nil || deleted != 3 {
+		t.Fatalf("expected the three chat-1 responses deleted, got %d %v", deleted, err)
+	}
+	existing, _ := store.ReserveIdempotencyKey(context.Background(), domain.IdempotencyRecord{
+		TenantID:  "tenant-a",
+		Key:       "key-conversation-3",
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	if existing == nil {
+		t.Fatal("expected the response without a conversation kept")
+	}
+}
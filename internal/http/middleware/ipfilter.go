@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+)
+
+type IPFilterConfig struct {
+	// Allow, when non-empty, is the only set of networks that may call the API.
+	Allow []*net.IPNet
+	// Deny always wins over Allow.
+	Deny []*net.IPNet
+	// Tenants restricts API key callers of a tenant to its egress networks, on top of the
+	// global lists.
+	Tenants map[string][]*net.IPNet
+}
+
+// ParseTenantIPAllowlists reads entries like "tenant-a=203.0.113.0/24|198.51.100.7".
+func ParseTenantIPAllowlists(entries []string) (map[string][]*net.IPNet, error) {
+	tenants := make(map[string][]*net.IPNet)
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tenantID, rawNetworks, ok := strings.Cut(entry, "=")
+		tenantID = strings.TrimSpace(tenantID)
+		if !ok || tenantID == "" {
+			return nil, fmt.Errorf("tenant ip allowlist: invalid entry %q", entry)
+		}
+		networks, err := parseNetworks("tenant ip allowlist", strings.Split(rawNetworks, "|"))
+		if err != nil {
+			return nil, err
+		}
+		if len(networks) == 0 {
+			return nil, fmt.Errorf("tenant ip allowlist: no networks for %q", tenantID)
+		}
+		tenants[tenantID] = append(tenants[tenantID], networks...)
+	}
+	return tenants, nil
+}
+
+// ParseIPList reads the global allow/deny lists (IPs or CIDRs).
+func ParseIPList(entries []string) ([]*net.IPNet, error) {
+	return parseNetworks("ip list", entries)
+}
+
+// IPFilter restricts callers by client address (after RealIP). The global lists run
+// before auth so blocked networks never reach credential checks; tenant lists run after
+// auth, once the API key has named the tenant. Health probes are never filtered.
+type IPFilter struct {
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	tenants map[string][]*net.IPNet
+}
+
+func NewIPFilter(cfg IPFilterConfig) *IPFilter {
+	return &IPFilter{allow: cfg.Allow, deny: cfg.Deny, tenants: cfg.Tenants}
+}
+
+// Enabled reports whether any list is configured.
+func (f *IPFilter) Enabled() bool {
+	return len(f.allow) > 0 || len(f.deny) > 0 || len(f.tenants) > 0
+}
+
+// Middleware applies the global lists; it must run before Auth.
+func (f *IPFilter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isHealthProbe(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ip := net.ParseIP(extractIP(r.RemoteAddr))
+		if isTrustedProxy(f.deny, ip) || (len(f.allow) > 0 && !isTrustedProxy(f.allow, ip)) {
+			writeErrorEnvelope(w, r, http.StatusForbidden, "ip_not_allowed", "client address is not allowed")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TenantMiddleware applies the tenant lists to API key callers; it must run after Auth.
+func (f *IPFilter) TenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.ClaimsFromContext(r.Context())
+		if !ok || claims.Method != auth.MethodAPIKey {
+			next.ServeHTTP(w, r)
+			return
+		}
+		networks, restricted := f.tenants[claims.TenantID]
+		if restricted && !isTrustedProxy(networks, net.ParseIP(extractIP(r.RemoteAddr))) {
+			writeErrorEnvelope(w, r, http.StatusForbidden, "ip_not_allowed", "client address is not allowed for this tenant")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isHealthProbe(path string) bool {
+	return path == "/healthz" || path == "/readyz"
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+)
+
+func TestIPFilterAppliesGlobalLists(t *testing.T) {
+	allow, err := ParseIPList([]string{"203.0.113.0/24", "198.51.100.7"})
+	if err != nil {
+		t.Fatalf("parse allowlist: %v", err)
+	}
+	deny, err := ParseIPList([]string{"203.0.113.66"})
+	if err != nil {
+		t.Fatalf("parse denylist: %v", err)
+	}
+	handler := NewIPFilter(IPFilterConfig{Allow: allow, Deny: deny}).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	for _, tc := range []struct {
+		path   string
+		remote string
+		want   int
+	}{
+		{"/v1/templates", "203.0.113.10:443", http.StatusTeapot},
+		{"/v1/templates", "198.51.100.7:443", http.StatusTeapot},
+		{"/v1/templates", "203.0.113.66:443", http.StatusForbidden},
+		{"/admin/vars", "192.0.2.1:443", http.StatusForbidden},
+		{"/healthz", "192.0.2.1:443", http.StatusTeapot},
+	} {
+		request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		request.RemoteAddr = tc.remote
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tc.want {
+			t.Fatalf("%s from %s: expected %d, got %d", tc.path, tc.remote, tc.want, recorder.Code)
+		}
+	}
+
+	if _, err := ParseIPList([]string{"nope"}); err == nil {
+		t.Fatalf("expected invalid entry to fail")
+	}
+}
+
+func TestIPFilterRestrictsAPIKeyTenants(t *testing.T) {
+	tenants, err := ParseTenantIPAllowlists([]string{"tenant-a=203.0.113.0/24|198.51.100.7"})
+	if err != nil {
+		t.Fatalf("parse tenant allowlists: %v", err)
+	}
+	handler := NewIPFilter(IPFilterConfig{Tenants: tenants}).TenantMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	for _, tc := range []struct {
+		claims *auth.Claims
+		remote string
+		want   int
+	}{
+		{&auth.Claims{Method: auth.MethodAPIKey, TenantID: "tenant-a"}, "198.51.100.7:443", http.StatusTeapot},
+		{&auth.Claims{Method: auth.MethodAPIKey, TenantID: "tenant-a"}, "192.0.2.1:443", http.StatusForbidden},
+		{&auth.Claims{Method: auth.MethodAPIKey, TenantID: "tenant-b"}, "192.0.2.1:443", http.StatusTeapot},
+		{&auth.Claims{Method: auth.MethodJWT, TenantID: "tenant-a"}, "192.0.2.1:443", http.StatusTeapot},
+		{nil, "192.0.2.1:443", http.StatusTeapot},
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/v1/templates", nil)
+		request.RemoteAddr = tc.remote
+		if tc.claims != nil {
+			request = request.WithContext(auth.WithClaims(request.Context(), *tc.claims))
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tc.want {
+			t.Fatalf("claims=%+v from %s: expected %d, got %d", tc.claims, tc.remote, tc.want, recorder.Code)
+		}
+	}
+
+	for _, entry := range []string{"tenant-a", "=10.0.0.0/8", "tenant-a=", "tenant-a=10.0.0.0/33"} {
+		if _, err := ParseTenantIPAllowlists([]string{entry}); err == nil {
+			t.Fatalf("expected %q to fail", entry)
+		}
+	}
+}
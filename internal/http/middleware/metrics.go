@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
+)
+
+// HTTPMetrics records request counts, latencies and in-flight requests per route.
+type HTTPMetrics struct {
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+	inFlight *metrics.GaugeVec
+}
+
+func NewHTTPMetrics(registry *metrics.Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: registry.Counter("http_requests_total", "HTTP requests by method, route and status code.", "method", "route", "status"),
+		duration: registry.Histogram("http_request_duration_seconds", "HTTP request latency by method and route.", nil, "method", "route"),
+		inFlight: registry.Gauge("http_requests_in_flight", "HTTP requests currently being served."),
+	}
+}
+
+// Middleware labels requests with route, the mux pattern that served them, rather than the
+// raw path, so IDs in paths do not explode the series count.
+func (m *HTTPMetrics) Middleware(route func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			m.inFlight.Add(1)
+			tracked := &headerTracker{ResponseWriter: w}
+			defer func() {
+				m.inFlight.Add(-1)
+				status := tracked.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				name := route(r)
+				if name == "" {
+					name = "unmatched"
+				}
+				method := metricMethod(r.Method)
+				m.requests.Inc(method, name, strconv.Itoa(status))
+				m.duration.Observe(time.Since(start).Seconds(), method, name)
+			}()
+			next.ServeHTTP(tracked, r)
+		})
+	}
+}
+
+// metricMethod folds non-standard methods into one label value.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
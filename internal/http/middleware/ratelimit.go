@@ -1,11 +1,17 @@
 package middleware
 
 import (
+	"fmt"
+	"math"
 	"net"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
 	"golang.org/x/time/rate"
 )
 
@@ -14,56 +20,237 @@ type visitor struct {
 	lastSeen time.Time
 }
 
-func RateLimit(rps float64, burst int) func(http.Handler) http.Handler {
-	if rps <= 0 {
-		rps = 20
-	}
-	if burst <= 0 {
-		burst = 40
+// RouteLimit gives requests matching Method and path Prefix their own bucket per client.
+// An empty Method matches any method.
+type RouteLimit struct {
+	Method string  `json:"method,omitempty"`
+	Prefix string  `json:"prefix"`
+	RPS    float64 `json:"rps"`
+	Burst  int     `json:"burst"`
+}
+
+func (route RouteLimit) name() string {
+	if route.Method == "" {
+		return route.Prefix
 	}
+	return route.Method + " " + route.Prefix
+}
+
+// RateLimiter applies token buckets per client (API key, tenant or IP) and route, and
+// exposes its state for operators.
+type RateLimiter struct {
+	rps    float64
+	burst  int
+	routes []RouteLimit
+	// key names the client a request's bucket belongs to.
+	key func(r *http.Request) string
+
+	mu       sync.Mutex
+	visitors map[string]*visitor
+}
 
-	visitors := make(map[string]*visitor)
-	var mu sync.Mutex
+// RateLimitSnapshot is the current limiter state; Tokens is what each client has left.
+type RateLimitSnapshot struct {
+	RPS      float64                `json:"rps"`
+	Burst    int                    `json:"burst"`
+	Routes   []RouteLimit           `json:"routes"`
+	Visitors []RateLimitVisitorInfo `json:"visitors"`
+}
+
+type RateLimitVisitorInfo struct {
+	Key      string    `json:"key"`
+	Route    string    `json:"route,omitempty"`
+	Tokens   float64   `json:"tokens"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+func NewRateLimiter(rps float64, burst int, routes ...RouteLimit) *RateLimiter {
+	return newRateLimiter(clientKey, rps, burst, routes...)
+}
+
+// NewIPRateLimiter keys every bucket on the client IP. It runs before Auth and AdminAuth,
+// so guessed tokens and API keys are throttled before any credential lookup.
+func NewIPRateLimiter(rps float64, burst int) *RateLimiter {
+	return newRateLimiter(ipKey, rps, burst)
+}
+
+func newRateLimiter(key func(r *http.Request) string, rps float64, burst int, routes ...RouteLimit) *RateLimiter {
+	limiter := &RateLimiter{key: key, visitors: make(map[string]*visitor)}
+	limiter.Reconfigure(rps, burst, routes...)
 
 	go func() {
 		ticker := time.NewTicker(60 * time.Second)
 		defer ticker.Stop()
 		for range ticker.C {
-			mu.Lock()
-			for key, item := range visitors {
+			limiter.mu.Lock()
+			for key, item := range limiter.visitors {
 				if time.Since(item.lastSeen) > 3*time.Minute {
-					delete(visitors, key)
+					delete(limiter.visitors, key)
 				}
 			}
-			mu.Unlock()
+			limiter.mu.Unlock()
 		}
 	}()
 
-	getLimiter := func(ip string) *rate.Limiter {
-		mu.Lock()
-		defer mu.Unlock()
-		v, ok := visitors[ip]
-		if !ok {
-			v = &visitor{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
-			visitors[ip] = v
+	return limiter
+}
+
+// Reconfigure replaces the limits. Buckets start over with the new budgets, so every
+// client gets a full burst.
+func (l *RateLimiter) Reconfigure(rps float64, burst int, routes ...RouteLimit) {
+	if rps <= 0 {
+		rps = 20
+	}
+	if burst <= 0 {
+		burst = 40
+	}
+
+	sorted := append([]RouteLimit(nil), routes...)
+	// Longest prefix first so "/v1/reports/" can override "/v1/reports".
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rps = rps
+	l.burst = burst
+	l.routes = sorted
+	clear(l.visitors)
+}
+
+// ParseRouteLimits reads entries like "POST /v1/suggestions=5:10" (method optional).
+func ParseRouteLimits(entries []string) ([]RouteLimit, error) {
+	routes := make([]RouteLimit, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		target, budget, ok := strings.Cut(entry, "=")
+		rawRPS, rawBurst, hasBurst := strings.Cut(budget, ":")
+		if !ok || !hasBurst {
+			return nil, fmt.Errorf("rate limit: invalid route entry %q", entry)
+		}
+		route := RouteLimit{Prefix: strings.TrimSpace(target)}
+		if method, prefix, hasMethod := strings.Cut(route.Prefix, " "); hasMethod {
+			route.Method = strings.ToUpper(strings.TrimSpace(method))
+			route.Prefix = strings.TrimSpace(prefix)
+		}
+		rps, rpsErr := strconv.ParseFloat(strings.TrimSpace(rawRPS), 64)
+		burst, burstErr := strconv.Atoi(strings.TrimSpace(rawBurst))
+		if !strings.HasPrefix(route.Prefix, "/") || rpsErr != nil || burstErr != nil || rps <= 0 || burst <= 0 {
+			return nil, fmt.Errorf("rate limit: invalid route entry %q", entry)
 		}
-		v.lastSeen = time.Now()
-		return v.limiter
+		route.RPS = rps
+		route.Burst = burst
+		routes = append(routes, route)
 	}
+	return routes, nil
+}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ip := extractIP(r.RemoteAddr)
-			if !getLimiter(ip).Allow() {
-				w.Header().Set("Content-Type", "application/json")
-				w.Header().Set("Retry-After", "1")
-				w.WriteHeader(http.StatusTooManyRequests)
-				_, _ = w.Write([]byte(`{"error":{"code":"rate_limited","message":"too many requests"},"request_id":"` + GetRequestID(r.Context()) + `"}`))
-				return
-			}
-			next.ServeHTTP(w, r)
+func RateLimit(rps float64, burst int) func(http.Handler) http.Handler {
+	return NewRateLimiter(rps, burst).Middleware
+}
+
+// Middleware of a NewRateLimiter must run after Auth: callers with verified claims are
+// keyed by API key or tenant, so clients sharing a NAT do not share a bucket. Others fall
+// back to the IP.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, rps, burst := l.routeFor(r)
+		limiter := l.getLimiter(l.key(r), route, rps, burst)
+
+		now := time.Now()
+		allowed := limiter.AllowN(now, 1)
+		tokens := limiter.TokensAt(now)
+		headers := w.Header()
+		headers.Set("X-RateLimit-Limit", strconv.Itoa(burst))
+		headers.Set("X-RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(tokens)))))
+		headers.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil((float64(burst)-tokens)/rps))))
+		if !allowed {
+			retryAfter := int(math.Max(1, math.Ceil((1-tokens)/rps)))
+			headers.Set("Content-Type", "application/json")
+			headers.Set("Retry-After", strconv.Itoa(retryAfter))
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"code":"rate_limited","message":"too many requests"},"request_id":"` + GetRequestID(r.Context()) + `"}`))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Snapshot lists tracked clients, most recently seen first.
+func (l *RateLimiter) Snapshot() RateLimitSnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	visitors := make([]RateLimitVisitorInfo, 0, len(l.visitors))
+	for key, item := range l.visitors {
+		client, route, _ := strings.Cut(key, "|")
+		visitors = append(visitors, RateLimitVisitorInfo{
+			Key:      client,
+			Route:    route,
+			Tokens:   item.limiter.TokensAt(now),
+			LastSeen: item.lastSeen.UTC(),
 		})
 	}
+	sort.Slice(visitors, func(i, j int) bool {
+		return visitors[i].LastSeen.After(visitors[j].LastSeen)
+	})
+	routes := append([]RouteLimit{}, l.routes...)
+	return RateLimitSnapshot{RPS: l.rps, Burst: l.burst, Routes: routes, Visitors: visitors}
+}
+
+func (l *RateLimiter) routeFor(r *http.Request) (string, float64, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, route := range l.routes {
+		if route.Method != "" && route.Method != r.Method {
+			continue
+		}
+		if strings.HasPrefix(r.URL.Path, route.Prefix) {
+			return route.name(), route.RPS, route.Burst
+		}
+	}
+	return "", l.rps, l.burst
+}
+
+func (l *RateLimiter) getLimiter(client, route string, rps float64, burst int) *rate.Limiter {
+	key := client
+	if route != "" {
+		key += "|" + route
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	v, ok := l.visitors[key]
+	if !ok {
+		v = &visitor{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+		l.visitors[key] = v
+	}
+	v.lastSeen = time.Now()
+	return v.limiter
+}
+
+// clientKey identifies who a bucket belongs to: the API key, then the verified token
+// tenant, then the remote IP. X-Tenant-ID is never used: every new value would get a
+// fresh bucket.
+func clientKey(r *http.Request) string {
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		if claims.Method == auth.MethodAPIKey && claims.Subject != "" {
+			return "api_key:" + claims.Subject
+		}
+		if claims.TenantID != "" {
+			return "tenant:" + claims.TenantID
+		}
+	}
+	return ipKey(r)
+}
+
+func ipKey(r *http.Request) string {
+	return "ip:" + extractIP(r.RemoteAddr)
 }
 
 func extractIP(remoteAddr string) string {
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+)
+
+func TestRateLimiterKeysByTenantAndRoute(t *testing.T) {
+	limiter := NewRateLimiter(100, 3, RouteLimit{Method: http.MethodPost, Prefix: "/v1/suggestions", RPS: 0.001, Burst: 1})
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	send := func(method, path, tenantID string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, nil)
+		request.RemoteAddr = "10.0.0.1:5000"
+		if tenantID != "" {
+			request = request.WithContext(auth.WithClaims(request.Context(), auth.Claims{TenantID: tenantID}))
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := send(http.MethodPost, "/v1/suggestions", "tenant-a")
+	if first.Code != http.StatusTeapot || first.Header().Get("X-RateLimit-Limit") != "1" || first.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("expected first suggestion allowed with route headers, got %d %v", first.Code, first.Header())
+	}
+	limited := send(http.MethodPost, "/v1/suggestions", "tenant-a")
+	if limited.Code != http.StatusTooManyRequests || limited.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected route budget exhausted, got %d %v", limited.Code, limited.Header())
+	}
+	if recorder := send(http.MethodPost, "/v1/suggestions", "tenant-b"); recorder.Code != http.StatusTeapot {
+		t.Fatalf("expected other tenant behind the same IP to keep its budget, got %d", recorder.Code)
+	}
+	if recorder := send(http.MethodGet, "/v1/templates", "tenant-a"); recorder.Code != http.StatusTeapot || recorder.Header().Get("X-RateLimit-Limit") != "3" {
+		t.Fatalf("expected default budget for cheap route, got %d %v", recorder.Code, recorder.Header())
+	}
+
+	snapshot := limiter.Snapshot()
+	if len(snapshot.Routes) != 1 || len(snapshot.Visitors) != 3 {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+}
+
+func TestRateLimiterIgnoresUnverifiedTenantHeader(t *testing.T) {
+	for name, limiter := range map[string]*RateLimiter{
+		"client": NewRateLimiter(0.001, 2),
+		"ip":     NewIPRateLimiter(0.001, 2),
+	} {
+		handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		codes := make([]int, 0, 3)
+		for _, tenantID := range []string{"tenant-a", "tenant-b", "tenant-c"} {
+			request := httptest.NewRequest(http.MethodGet, "/v1/reports", nil)
+			request.RemoteAddr = "10.0.0.1:5000"
+			request.Header.Set(TenantHeader, tenantID)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			codes = append(codes, recorder.Code)
+		}
+		if codes[0] != http.StatusTeapot || codes[1] != http.StatusTeapot || codes[2] != http.StatusTooManyRequests {
+			t.Fatalf("%s: expected header changes to share the IP bucket, got %v", name, codes)
+		}
+		if snapshot := limiter.Snapshot(); len(snapshot.Visitors) != 1 || snapshot.Visitors[0].Key != "ip:10.0.0.1" {
+			t.Fatalf("%s: expected a single IP bucket, got %+v", name, snapshot.Visitors)
+		}
+	}
+}
+
+func TestIPRateLimiterIgnoresClaims(t *testing.T) {
+	limiter := NewIPRateLimiter(0.001, 1)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	codes := make([]int, 0, 2)
+	for _, subject := range []string{"key-a", "key-b"} {
+		request := httptest.NewRequest(http.MethodGet, "/v1/reports", nil)
+		request.RemoteAddr = "10.0.0.1:5000"
+		request = request.WithContext(auth.WithClaims(request.Context(), auth.Claims{Method: auth.MethodAPIKey, Subject: subject}))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		codes = append(codes, recorder.Code)
+	}
+	if codes[0] != http.StatusTeapot || codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("expected one bucket per IP whatever the credential, got %v", codes)
+	}
+}
+
+func TestParseRouteLimits(t *testing.T) {
+	routes, err := ParseRouteLimits([]string{"post /v1/suggestions=5:10", "/v1/reports=0.5:2"})
+	if err != nil || len(routes) != 2 {
+		t.Fatalf("unexpected routes %+v err=%v", routes, err)
+	}
+	if routes[0].Method != http.MethodPost || routes[0].Prefix != "/v1/suggestions" || routes[0].RPS != 5 || routes[0].Burst != 10 {
+		t.Fatalf("unexpected first route %+v", routes[0])
+	}
+	if routes[1].Method != "" || routes[1].RPS != 0.5 {
+		t.Fatalf("unexpected second route %+v", routes[1])
+	}
+	for _, entry := range []string{"/v1/suggestions=5", "v1/suggestions=5:10", "POST /v1/x=0:1", "POST /v1/x=a:1"} {
+		if _, err := ParseRouteLimits([]string{entry}); err == nil {
+			t.Fatalf("expected error for %q", entry)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ParseTrustedProxies reads IPs and CIDRs ("10.0.0.0/8", "192.168.1.10").
+func ParseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	return parseNetworks("trusted proxies", entries)
+}
+
+// parseNetworks reads IPs (as single-host networks) and CIDRs; label prefixes errors.
+func parseNetworks(label string, entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%s: invalid address %q", label, entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid network %q", label, entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// RealIP replaces r.RemoteAddr with the client address reported by a trusted proxy.
+// X-Forwarded-For is walked right to left and the first hop outside trusted is the
+// client; X-Real-IP is used when there is no X-Forwarded-For. Headers from untrusted
+// peers are ignored, so clients cannot spoof their address.
+func RealIP(trusted []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(trusted) == 0 || !isTrustedProxy(trusted, net.ParseIP(extractIP(r.RemoteAddr))) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if client := forwardedClientIP(r, trusted); client != "" {
+				r.RemoteAddr = client
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedClientIP(r *http.Request, trusted []*net.IPNet) string {
+	hops := make([]string, 0, 4)
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for index := len(hops) - 1; index >= 0; index-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[index]))
+		if ip == nil {
+			// A malformed hop cannot be trusted to describe anything further left.
+			return ""
+		}
+		if !isTrustedProxy(trusted, ip) || index == 0 {
+			return ip.String()
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+func isTrustedProxy(trusted []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
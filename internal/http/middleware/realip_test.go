@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIPTrustsOnlyConfiguredProxies(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.10"})
+	if err != nil {
+		t.Fatalf("parse trusted proxies: %v", err)
+	}
+	var seen string
+	handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.RemoteAddr
+	}))
+
+	for _, tc := range []struct {
+		remote    string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		{"10.1.2.3:443", "203.0.113.7", "", "203.0.113.7"},
+		{"10.1.2.3:443", "198.51.100.1, 203.0.113.7, 10.9.9.9", "", "203.0.113.7"},
+		{"192.168.1.10:80", "", "203.0.113.8", "203.0.113.8"},
+		{"10.1.2.3:443", "10.2.2.2", "", "10.2.2.2"},
+		{"10.1.2.3:443", "not-an-ip", "", "10.1.2.3:443"},
+		{"203.0.113.50:1234", "1.2.3.4", "5.6.7.8", "203.0.113.50:1234"},
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/v1/templates", nil)
+		request.RemoteAddr = tc.remote
+		if tc.forwarded != "" {
+			request.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		if tc.realIP != "" {
+			request.Header.Set("X-Real-IP", tc.realIP)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		if seen != tc.want {
+			t.Fatalf("remote=%s xff=%q: expected %s, got %s", tc.remote, tc.forwarded, tc.want, seen)
+		}
+	}
+
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatalf("expected invalid CIDR to fail")
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"expvar"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// panicsRecovered counts handler panics turned into 500s; it is published through expvar.
+var panicsRecovered = expvar.NewInt("http_panics_recovered_total")
+
+// PanicsRecovered returns how many handler panics were recovered since start.
+func PanicsRecovered() int64 {
+	return panicsRecovered.Value()
+}
+
+// Recover turns a handler panic into a 500 error envelope and logs the stack with the
+// request ID. When the handler already started the response only the log is written.
+func Recover(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tracked := &headerTracker{ResponseWriter: w}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					// net/http uses this panic to abort a response on purpose.
+					panic(recovered)
+				}
+				panicsRecovered.Add(1)
+				if logger != nil {
+					logger.ErrorContext(r.Context(), "panic recovered",
+						slog.String("method", r.Method),
+						slog.String("path", r.URL.Path),
+						slog.Any("error", recovered),
+						slog.String("stack", string(debug.Stack())),
+					)
+				}
+				if !tracked.wroteHeader {
+					writeErrorEnvelope(w, r, http.StatusInternalServerError, "internal_error", "unexpected server error")
+				}
+			}()
+			next.ServeHTTP(tracked, r)
+		})
+	}
+}
+
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+	status      int
+}
+
+func (t *headerTracker) WriteHeader(status int) {
+	if !t.wroteHeader {
+		t.status = status
+	}
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *headerTracker) Write(data []byte) (int, error) {
+	if !t.wroteHeader {
+		t.status = http.StatusOK
+	}
+	t.wroteHeader = true
+	return t.ResponseWriter.Write(data)
+}
+
+func (t *headerTracker) Flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		if !t.wroteHeader {
+			t.status = http.StatusOK
+		}
+		t.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+func (t *headerTracker) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoverWritesEnvelopeAndCountsPanics(t *testing.T) {
+	var logs bytes.Buffer
+	handler := RequestID(Recover(newTestLogger(t, &logs))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	before := PanicsRecovered()
+	request := httptest.NewRequest(http.MethodPost, "/v1/suggestions", nil)
+	request.Header.Set("X-Request-Id", "req-panic")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusInternalServerError || !strings.Contains(recorder.Body.String(), `"internal_error"`) || !strings.Contains(recorder.Body.String(), "req-panic") {
+		t.Fatalf("expected 500 envelope, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if PanicsRecovered() != before+1 {
+		t.Fatalf("expected panic counter to increase, got %d", PanicsRecovered())
+	}
+	if output := logs.String(); !strings.Contains(output, "request_id=req-panic") || !strings.Contains(output, "recover_test.go") {
+		t.Fatalf("expected log with request id and stack, got %s", output)
+	}
+}
+
+func TestRecoverKeepsStartedResponse(t *testing.T) {
+	handler := Recover(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late")
+	}))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/templates", nil))
+	if recorder.Code != http.StatusAccepted || recorder.Body.Len() != 0 {
+		t.Fatalf("expected started response untouched, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -2,32 +2,56 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
 )
 
-type contextKey string
-
-const requestIDContextKey contextKey = "request_id"
+// maxRequestIDLength bounds a client supplied X-Request-Id; it is forwarded to the AI
+// provider and stored on jobs.
+const maxRequestIDLength = 128
 
 func RequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := r.Header.Get("X-Request-Id")
-		if requestID == "" {
+		if !validRequestID(requestID) {
 			requestID = uuid.NewString()
 		}
 
-		ctx := context.WithValue(r.Context(), requestIDContextKey, requestID)
+		ctx := logging.WithRequestID(r.Context(), requestID)
+		ctx = logging.WithScope(ctx, slog.String("request_id", requestID))
 		w.Header().Set("X-Request-Id", requestID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
 func GetRequestID(ctx context.Context) string {
-	value, _ := ctx.Value(requestIDContextKey).(string)
+	value := logging.RequestID(ctx)
 	if value == "" {
 		return "unknown"
 	}
 	return value
 }
+
+// validRequestID accepts IDs of printable ASCII without quotes or backslashes, which the
+// error envelopes write without escaping.
+func validRequestID(value string) bool {
+	if value == "" || len(value) > maxRequestIDLength {
+		return false
+	}
+	for index := 0; index < len(value); index++ {
+		if c := value[index]; c < 0x21 || c > 0x7e || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// writeErrorEnvelope writes the API error envelope; message must not need JSON escaping.
+func writeErrorEnvelope(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"error":{"code":"` + code + `","message":"` + message + `"},"request_id":"` + GetRequestID(r.Context()) + `"}`))
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+)
+
+// readOnlyPrefixes are the routes a readonly caller may GET.
+var readOnlyPrefixes = []string{"/v1/jobs/", "/v1/reports", "/v1/digests", "/v1/stats/"}
+
+// Authorize enforces the caller role on /v1 and /v2; it must run after Auth. Requests
+// without claims (static token, open mode) are not restricted here.
+func Authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.ClaimsFromContext(r.Context())
+		if !isVersionedAPIPath(r.URL.Path) || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		role := claims.Role()
+		if !roleAllows(role, r.Method, r.URL.Path) {
+			writeErrorEnvelope(w, r, http.StatusForbidden, "insufficient_role", "role "+role+" cannot access this route")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func roleAllows(role, method, path string) bool {
+	switch role {
+	case auth.RoleAdmin:
+		return true
+	case auth.RoleReadOnly:
+		if method != http.MethodGet && method != http.MethodHead {
+			return false
+		}
+		for _, prefix := range readOnlyPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return !isErasurePath(path)
+}
+
+// isErasurePath matches DELETE /v1/conversations/{id}/data.
+func isErasurePath(path string) bool {
+	return strings.HasPrefix(path, "/v1/conversations/") && strings.HasSuffix(strings.TrimRight(path, "/"), "/data")
+}
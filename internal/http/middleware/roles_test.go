@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+)
+
+func TestAuthorizeEnforcesRoles(t *testing.T) {
+	handler := Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	readonly := &auth.Claims{Method: auth.MethodJWT, Roles: []string{"readonly"}}
+	agent := &auth.Claims{Method: auth.MethodJWT, Roles: []string{"supervisor"}}
+	admin := &auth.Claims{Method: auth.MethodAPIKey, Roles: []string{"suggestions", "admin"}}
+	for _, tc := range []struct {
+		claims *auth.Claims
+		method string
+		path   string
+		want   int
+	}{
+		{readonly, http.MethodGet, "/v1/jobs/job-1", http.StatusTeapot},
+		{readonly, http.MethodGet, "/v1/reports/rep-1", http.StatusTeapot},
+		{readonly, http.MethodPost, "/v1/reports", http.StatusForbidden},
+		{readonly, http.MethodPost, "/v1/suggestions", http.StatusForbidden},
+		{agent, http.MethodPost, "/v1/suggestions", http.StatusTeapot},
+		{agent, http.MethodDelete, "/v1/conversations/chat-1/data", http.StatusForbidden},
+		{admin, http.MethodDelete, "/v1/conversations/chat-1/data", http.StatusTeapot},
+		{nil, http.MethodDelete, "/v1/conversations/chat-1/data", http.StatusTeapot},
+	} {
+		request := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.claims != nil {
+			request = request.WithContext(auth.WithClaims(request.Context(), *tc.claims))
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tc.want {
+			t.Fatalf("%+v %s %s: expected %d, got %d", tc.claims, tc.method, tc.path, tc.want, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultReferrerPolicy = "no-referrer"
+
+// defaultNoStorePrefixes cover the responses that carry conversation content.
+var defaultNoStorePrefixes = []string{"/v1/jobs/", "/v1/suggestions", "/v2/suggestions"}
+
+type SecurityHeadersConfig struct {
+	// ReferrerPolicy defaults to no-referrer.
+	ReferrerPolicy string
+	// NoStorePrefixes get Cache-Control: no-store; nil means job and suggestion routes.
+	NoStorePrefixes []string
+	// HSTSMaxAge sends Strict-Transport-Security on HTTPS requests; zero disables it.
+	HSTSMaxAge time.Duration
+}
+
+// SecurityHeaders sets the defaults before the handler runs, so a handler may still
+// override them. HTTPS is detected from the TLS connection or X-Forwarded-Proto, as TLS
+// usually ends at the load balancer; a spoofed header can at most add HSTS to a
+// plain-HTTP response, which browsers ignore.
+func SecurityHeaders(cfg SecurityHeadersConfig) func(http.Handler) http.Handler {
+	referrerPolicy := strings.TrimSpace(cfg.ReferrerPolicy)
+	if referrerPolicy == "" {
+		referrerPolicy = defaultReferrerPolicy
+	}
+	noStorePrefixes := cfg.NoStorePrefixes
+	if noStorePrefixes == nil {
+		noStorePrefixes = defaultNoStorePrefixes
+	}
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(cfg.HSTSMaxAge/time.Second), 10) + "; includeSubDomains"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("Referrer-Policy", referrerPolicy)
+			for _, prefix := range noStorePrefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					header.Set("Cache-Control", "no-store")
+					break
+				}
+			}
+			if hsts != "" && isHTTPS(r) {
+				header.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSecurityHeadersDefaults(t *testing.T) {
+	handler := SecurityHeaders(SecurityHeadersConfig{HSTSMaxAge: 24 * time.Hour})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/templates" {
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		}
+	}))
+
+	for _, tc := range []struct {
+		path  string
+		proto string
+		cache string
+		hsts  string
+	}{
+		{"/v1/jobs/job-1", "", "no-store", ""},
+		{"/v2/suggestions", "https", "no-store", "max-age=86400; includeSubDomains"},
+		{"/v1/templates", "https", "private, max-age=60", "max-age=86400; includeSubDomains"},
+		{"/healthz", "http", "", ""},
+	} {
+		request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.proto != "" {
+			request.Header.Set("X-Forwarded-Proto", tc.proto)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		header := recorder.Header()
+		if header.Get("X-Content-Type-Options") != "nosniff" || header.Get("Referrer-Policy") != "no-referrer" {
+			t.Fatalf("%s: missing base headers: %v", tc.path, header)
+		}
+		if got := header.Get("Cache-Control"); got != tc.cache {
+			t.Fatalf("%s: expected Cache-Control %q, got %q", tc.path, tc.cache, got)
+		}
+		if got := header.Get("Strict-Transport-Security"); got != tc.hsts {
+			t.Fatalf("%s: expected HSTS %q, got %q", tc.path, tc.hsts, got)
+		}
+	}
+}
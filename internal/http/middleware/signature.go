@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+const (
+	// SignatureHeader carries "sha256=<hex HMAC>" over the canonical request.
+	SignatureHeader = "X-Signature"
+	// SignatureTimestampHeader carries the unix time (seconds) the request was signed at.
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+
+	defaultSignatureTolerance = 5 * time.Minute
+	maxSignedBodyBytes        = 4 << 20
+	signatureScheme           = "sha256="
+)
+
+// SignatureConfig enables HMAC request signing for tenants that have a shared secret.
+type SignatureConfig struct {
+	// Secrets maps tenant ID to the secret shared with that tenant's signing proxy.
+	Secrets map[string]string
+	// Tolerance is how far X-Signature-Timestamp may drift from the server clock.
+	Tolerance time.Duration
+	// Required rejects unsigned requests even when they do not name a tenant; otherwise only
+	// tenants with a secret must sign.
+	Required bool
+	Now      func() time.Time
+}
+
+// SignatureVerifier checks X-Signature on /v1 and /v2 and remembers accepted signatures
+// for the tolerance window, so a captured request cannot be replayed.
+type SignatureVerifier struct {
+	secrets   map[string][]byte
+	tolerance time.Duration
+	required  bool
+	now       func() time.Time
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewSignatureVerifier(cfg SignatureConfig) *SignatureVerifier {
+	tolerance := cfg.Tolerance
+	if tolerance <= 0 {
+		tolerance = defaultSignatureTolerance
+	}
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+	secrets := make(map[string][]byte, len(cfg.Secrets))
+	for tenantID, secret := range cfg.Secrets {
+		secrets[tenantID] = []byte(secret)
+	}
+	return &SignatureVerifier{
+		secrets:   secrets,
+		tolerance: tolerance,
+		required:  cfg.Required,
+		now:       now,
+		seen:      make(map[string]time.Time),
+	}
+}
+
+// ParseSignatureSecrets reads "tenant:secret[,tenant:secret...]".
+func ParseSignatureSecrets(spec string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tenantID, secret, ok := strings.Cut(entry, ":")
+		tenantID = strings.TrimSpace(tenantID)
+		secret = strings.TrimSpace(secret)
+		if !ok || tenantID == "" || secret == "" {
+			return nil, fmt.Errorf("signature: invalid secret entry for tenant %q", tenantID)
+		}
+		if _, exists := secrets[tenantID]; exists {
+			return nil, fmt.Errorf("signature: duplicated tenant %s", tenantID)
+		}
+		secrets[tenantID] = secret
+	}
+	return secrets, nil
+}
+
+// SignRequest returns the X-Signature value for a request; proxies and tests use it to
+// produce signatures the verifier accepts.
+func SignRequest(secret []byte, timestamp, method, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = io.WriteString(mac, timestamp+"\n"+method+"\n"+requestURI+"\n")
+	_, _ = mac.Write(body)
+	return signatureScheme + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware must run inside TenantScope: the resolved tenant scope, not the raw
+// X-Tenant-ID header, selects the secret. Without a scope nothing can select one, so the
+// request is rejected whenever any tenant signs.
+func (v *SignatureVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isVersionedAPIPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenantID, scoped := tenant.FromContext(r.Context())
+		if !scoped && (v.required || len(v.secrets) > 0) {
+			writeErrorEnvelope(w, r, http.StatusUnauthorized, "invalid_signature", "a tenant is required to verify the request signature")
+			return
+		}
+		secret, ok := v.secrets[tenantID]
+		if !ok {
+			if v.required {
+				writeErrorEnvelope(w, r, http.StatusUnauthorized, "invalid_signature", "no signing secret for this tenant")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		signature := strings.TrimSpace(r.Header.Get(SignatureHeader))
+		rawTimestamp := strings.TrimSpace(r.Header.Get(SignatureTimestampHeader))
+		if signature == "" || rawTimestamp == "" {
+			writeErrorEnvelope(w, r, http.StatusUnauthorized, "invalid_signature", "request signature is required")
+			return
+		}
+		unix, err := strconv.ParseInt(rawTimestamp, 10, 64)
+		if err != nil {
+			writeErrorEnvelope(w, r, http.StatusUnauthorized, "invalid_signature", "invalid signature timestamp")
+			return
+		}
+		now := v.now()
+		if drift := now.Sub(time.Unix(unix, 0)); drift > v.tolerance || drift < -v.tolerance {
+			writeErrorEnvelope(w, r, http.StatusUnauthorized, "invalid_signature", "signature timestamp outside tolerance")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes+1))
+		if err != nil {
+			writeErrorEnvelope(w, r, http.StatusBadRequest, "invalid_request", "failed to read request body")
+			return
+		}
+		if len(body) > maxSignedBodyBytes {
+			writeErrorEnvelope(w, r, http.StatusRequestEntityTooLarge, "payload_too_large", "signed body exceeds 4 MiB")
+			return
+		}
+		expected := SignRequest(secret, rawTimestamp, r.Method, r.URL.RequestURI(), body)
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			writeErrorEnvelope(w, r, http.StatusUnauthorized, "invalid_signature", "signature does not match request")
+			return
+		}
+		if !v.remember(tenantID+":"+expected, now) {
+			writeErrorEnvelope(w, r, http.StatusUnauthorized, "replayed_request", "signature was already used")
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// remember records a signature and reports false when it was already accepted within
+// the tolerance window.
+func (v *SignatureVerifier) remember(key string, now time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if now.Sub(v.lastSweep) > v.tolerance {
+		for seenKey, expiresAt := range v.seen {
+			if now.After(expiresAt) {
+				delete(v.seen, seenKey)
+			}
+		}
+		v.lastSweep = now
+	}
+	if expiresAt, ok := v.seen[key]; ok && !now.After(expiresAt) {
+		return false
+	}
+	// A timestamp may be up to tolerance in the future, so keep the entry for both halves
+	// of the window.
+	v.seen[key] = now.Add(2 * v.tolerance)
+	return true
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+func TestSignatureVerifierRejectsAlteredAndReplayedRequests(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	verifier := NewSignatureVerifier(SignatureConfig{
+		Secrets:   map[string]string{"tenant-a": "shared-secret"},
+		Tolerance: time.Minute,
+		Now:       func() time.Time { return now },
+	})
+	var received string
+	handler := TenantScope(verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		received = string(raw)
+		w.WriteHeader(http.StatusTeapot)
+	})))
+
+	send := func(tenantID, body string, signedAt time.Time, signature func(timestamp string) string) (int, string) {
+		request := httptest.NewRequest(http.MethodPost, "/v1/suggestions?mode=quick", strings.NewReader(body))
+		request.Header.Set(TenantHeader, tenantID)
+		if signature != nil {
+			timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+			request.Header.Set(SignatureTimestampHeader, timestamp)
+			request.Header.Set(SignatureHeader, signature(timestamp))
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+	sign := func(body string) func(string) string {
+		return func(timestamp string) string {
+			return SignRequest([]byte("shared-secret"), timestamp, http.MethodPost, "/v1/suggestions?mode=quick", []byte(body))
+		}
+	}
+
+	body := `{"messages":["oi"]}`
+	if status, _ := send("tenant-a", body, now, sign(body)); status != http.StatusTeapot || received != body {
+		t.Fatalf("expected signed request to pass with body intact, got %d body=%q", status, received)
+	}
+	if status, response := send("tenant-a", body, now, sign(body)); status != http.StatusUnauthorized || !strings.Contains(response, "replayed_request") {
+		t.Fatalf("expected replay rejected, got %d %s", status, response)
+	}
+	if status, _ := send("tenant-a", `{"messages":["tchau"]}`, now, sign(body)); status != http.StatusUnauthorized {
+		t.Fatalf("expected altered body rejected, got %d", status)
+	}
+	if status, _ := send("tenant-a", body, now.Add(-2*time.Minute), sign(body)); status != http.StatusUnauthorized {
+		t.Fatalf("expected stale timestamp rejected, got %d", status)
+	}
+	if status, _ := send("tenant-a", body, now, nil); status != http.StatusUnauthorized {
+		t.Fatalf("expected unsigned request rejected for signing tenant, got %d", status)
+	}
+	if status, _ := send("tenant-b", body, now, nil); status != http.StatusTeapot {
+		t.Fatalf("expected tenant without secret to pass unsigned, got %d", status)
+	}
+}
+
+func TestSignatureVerifierUsesTheResolvedTenant(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	verifier := NewSignatureVerifier(SignatureConfig{
+		Secrets: map[string]string{"tenant-a": "shared-secret"},
+		Now:     func() time.Time { return now },
+	})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) })
+
+	// A header naming a tenant without a secret does not skip signing when the token is
+	// bound to a signing tenant: the token tenant is the scope.
+	request := httptest.NewRequest(http.MethodGet, "/v1/reports", nil)
+	request.Header.Set(TenantHeader, "tenant-b")
+	request = request.WithContext(tenant.WithScope(request.Context(), "tenant-a"))
+	recorder := httptest.NewRecorder()
+	verifier.Middleware(next).ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the scope tenant to require a signature, got %d", recorder.Code)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/v1/reports", nil)
+	request.Header.Set(TenantHeader, "tenant-a")
+	recorder = httptest.NewRecorder()
+	verifier.Middleware(next).ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized || !strings.Contains(recorder.Body.String(), "invalid_signature") {
+		t.Fatalf("expected an unscoped request rejected while tenants sign, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	unsigned := NewSignatureVerifier(SignatureConfig{})
+	recorder = httptest.NewRecorder()
+	unsigned.Middleware(next).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/reports", nil))
+	if recorder.Code != http.StatusTeapot {
+		t.Fatalf("expected unscoped requests to pass without signing secrets, got %d", recorder.Code)
+	}
+}
+
+func TestParseSignatureSecrets(t *testing.T) {
+	secrets, err := ParseSignatureSecrets(" tenant-a:one , tenant-b:two:with-colon ")
+	if err != nil || secrets["tenant-a"] != "one" || secrets["tenant-b"] != "two:with-colon" {
+		t.Fatalf("unexpected secrets %+v err=%v", secrets, err)
+	}
+	for _, spec := range []string{"tenant-a", "tenant-a:", "tenant-a:x,tenant-a:y"} {
+		if _, err := ParseSignatureSecrets(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
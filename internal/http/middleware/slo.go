@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/slo"
+)
+
+// SLO reports every request to tracker with route, the mux pattern that served it.
+func SLO(tracker *slo.Tracker, route func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			tracked := &headerTracker{ResponseWriter: w}
+			defer func() {
+				status := tracked.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				tracker.Observe(r.Method, route(r), status, time.Since(start))
+			}()
+			next.ServeHTTP(tracked, r)
+		})
+	}
+}
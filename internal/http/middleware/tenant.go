@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+const TenantHeader = "X-Tenant-ID"
+
+// TenantScope binds the request to the tenant announced in X-Tenant-ID, so repositories
+// only see that tenant's rows. A JWT or API key tenant_id claim takes precedence and a
+// conflicting header is rejected. Tenant data routes (/v1 and /v2) fail closed: without a
+// resolved tenant they are rejected instead of running unscoped. Admin routes without a
+// tenant run with tenant.WithBypass, since they operate across tenants.
+func TenantScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID := strings.TrimSpace(r.Header.Get(TenantHeader))
+		if claims, ok := auth.ClaimsFromContext(r.Context()); ok && claims.TenantID != "" {
+			if tenantID != "" && tenantID != claims.TenantID {
+				writeTenantError(w, r, http.StatusForbidden, "forbidden", "tenant not allowed for this token")
+				return
+			}
+			tenantID = claims.TenantID
+		}
+		if tenantID == "" {
+			switch {
+			case tenantDataRoute(r.URL.Path):
+				writeTenantError(w, r, http.StatusBadRequest, "tenant_required", "X-Tenant-ID header is required for this credential")
+			case r.URL.Path == "/admin" || strings.HasPrefix(r.URL.Path, "/admin/"):
+				next.ServeHTTP(w, r.WithContext(tenant.WithBypass(r.Context())))
+			default:
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		logging.Set(r.Context(), slog.String("tenant_id", tenantID))
+		next.ServeHTTP(w, r.WithContext(tenant.WithScope(r.Context(), tenantID)))
+	})
+}
+
+// tenantDataRoute reports whether path reads or writes tenant data.
+func tenantDataRoute(path string) bool {
+	return strings.HasPrefix(path, "/v1/") || strings.HasPrefix(path, "/v2/")
+}
+
+func writeTenantError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"error":{"code":"` + code + `","message":"` + message + `"},"request_id":"` + GetRequestID(r.Context()) + `"}`))
+}
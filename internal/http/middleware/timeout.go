@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRequestTimeout = 12 * time.Second
+	// maxLongPollWait mirrors the cap /v1/jobs/{id}?wait= enforces.
+	maxLongPollWait = 30 * time.Second
+)
+
+// RouteTimeout overrides the request timeout for requests matching Method and path
+// Prefix. An empty Method matches any method.
+type RouteTimeout struct {
+	Method  string
+	Prefix  string
+	Timeout time.Duration
+}
+
+type TimeoutConfig struct {
+	// Timeout applies to routes without an override; zero means 12s.
+	Timeout time.Duration
+	Routes  []RouteTimeout
+}
+
+// ParseRouteTimeouts reads entries like "POST /v1/suggestions=8s" (method optional).
+func ParseRouteTimeouts(entries []string) ([]RouteTimeout, error) {
+	routes := make([]RouteTimeout, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		target, rawTimeout, ok := strings.Cut(entry, "=")
+		route := RouteTimeout{Prefix: strings.TrimSpace(target)}
+		if method, prefix, hasMethod := strings.Cut(route.Prefix, " "); hasMethod {
+			route.Method = strings.ToUpper(strings.TrimSpace(method))
+			route.Prefix = strings.TrimSpace(prefix)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(rawTimeout))
+		if !ok || !strings.HasPrefix(route.Prefix, "/") || err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("timeout: invalid route entry %q", entry)
+		}
+		route.Timeout = timeout
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// Timeout cancels the request context after the route budget and answers 504 with the
+// error envelope if the handler has not finished by then. Long-poll requests (?wait=) get
+// the requested wait on top of the budget. Responses are buffered until the handler
+// returns, so a late handler cannot write after the 504.
+func Timeout(cfg TimeoutConfig) func(http.Handler) http.Handler {
+	defaultTimeout := cfg.Timeout
+	if defaultTimeout <= 0 {
+		defaultTimeout = defaultRequestTimeout
+	}
+	routes := append([]RouteTimeout(nil), cfg.Routes...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].Prefix) > len(routes[j].Prefix)
+	})
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := defaultTimeout
+			for _, route := range routes {
+				if (route.Method == "" || route.Method == r.Method) && strings.HasPrefix(r.URL.Path, route.Prefix) {
+					timeout = route.Timeout
+					break
+				}
+			}
+			timeout += longPollWait(r)
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			buffered := &timeoutWriter{w: w, header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						panicked <- recovered
+					}
+				}()
+				next.ServeHTTP(buffered, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case recovered := <-panicked:
+				// Re-raise on the serving goroutine so Recover can answer it.
+				panic(recovered)
+			case <-done:
+				buffered.flush()
+			case <-ctx.Done():
+				buffered.mu.Lock()
+				defer buffered.mu.Unlock()
+				buffered.timedOut = true
+				writeErrorEnvelope(w, r, http.StatusGatewayTimeout, "timeout", "request exceeded "+timeout.String())
+			}
+		})
+	}
+}
+
+// longPollWait returns the ?wait= duration (Go duration or seconds), capped at 30s.
+func longPollWait(r *http.Request) time.Duration {
+	value := strings.TrimSpace(r.URL.Query().Get("wait"))
+	if value == "" {
+		return 0
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait < 0 {
+		return 0
+	}
+	return min(wait, maxLongPollWait)
+}
+
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (t *timeoutWriter) Header() http.Header {
+	return t.header
+}
+
+func (t *timeoutWriter) WriteHeader(status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timedOut || t.wroteHeader {
+		return
+	}
+	t.status = status
+	t.wroteHeader = true
+}
+
+func (t *timeoutWriter) Write(data []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !t.wroteHeader {
+		t.status = http.StatusOK
+		t.wroteHeader = true
+	}
+	return t.body.Write(data)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to extend the write
+// deadline for long polls.
+func (t *timeoutWriter) Unwrap() http.ResponseWriter {
+	return t.w
+}
+
+func (t *timeoutWriter) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	destination := t.w.Header()
+	for key, values := range t.header {
+		destination[key] = values
+	}
+	if !t.wroteHeader {
+		t.status = http.StatusOK
+	}
+	t.w.WriteHeader(t.status)
+	_, _ = t.w.Write(t.body.Bytes())
+}
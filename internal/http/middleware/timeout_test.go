@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutCancelsSlowRoutes(t *testing.T) {
+	routes, err := ParseRouteTimeouts([]string{"POST /v1/suggestions=20ms"})
+	if err != nil {
+		t.Fatalf("parse routes: %v", err)
+	}
+	cancelled := make(chan struct{}, 1)
+	handler := Timeout(TimeoutConfig{Timeout: time.Second, Routes: routes})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay, _ := time.ParseDuration(r.URL.Query().Get("delay"))
+		select {
+		case <-time.After(delay):
+			w.Header().Set("X-Handler", "done")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		case <-r.Context().Done():
+			cancelled <- struct{}{}
+			_, _ = w.Write([]byte("late"))
+		}
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/suggestions?delay=1s", nil))
+	if recorder.Code != http.StatusGatewayTimeout || !strings.Contains(recorder.Body.String(), `"timeout"`) {
+		t.Fatalf("expected 504 envelope, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatalf("expected handler context to be cancelled")
+	}
+	if strings.Contains(recorder.Body.String(), "late") {
+		t.Fatalf("expected late write to be discarded, got %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/templates?delay=30ms", nil))
+	if recorder.Code != http.StatusCreated || recorder.Header().Get("X-Handler") != "done" || recorder.Body.String() != `{"ok":true}` {
+		t.Fatalf("expected default budget to let handler finish, got %d %v %s", recorder.Code, recorder.Header(), recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/suggestions?delay=40ms&wait=1", nil))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("expected long poll wait to extend the budget, got %d", recorder.Code)
+	}
+}
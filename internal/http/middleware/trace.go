@@ -1,25 +1,57 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
 )
 
-func Trace(logger *log.Logger) func(http.Handler) http.Handler {
+const TraceParentHeader = "traceparent"
+
+// Trace opens the server span for the request, continuing an incoming W3C traceparent,
+// and writes one access log line per request carrying the request scope fields.
+func Trace(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
-			next.ServeHTTP(w, r)
-			if logger != nil {
-				logger.Printf(
-					"trace request_id=%s method=%s path=%s duration_ms=%d",
-					GetRequestID(r.Context()),
-					r.Method,
-					r.URL.Path,
-					time.Since(start).Milliseconds(),
-				)
+			ctx := r.Context()
+			if parent, ok := tracing.ParseTraceParent(r.Header.Get(TraceParentHeader)); ok {
+				ctx = tracing.ContextWithRemoteParent(ctx, parent)
 			}
+			ctx, span := tracing.Start(ctx, "HTTP "+r.Method, tracing.KindServer)
+			span.SetAttribute("http.request.method", r.Method)
+			span.SetAttribute("url.path", r.URL.Path)
+			span.SetAttribute("request_id", GetRequestID(ctx))
+
+			tracked := &headerTracker{ResponseWriter: w}
+			defer func() {
+				status := tracked.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				span.SetAttribute("http.response.status_code", status)
+				if status >= http.StatusInternalServerError {
+					span.RecordError(errorStatus(status))
+				}
+				span.End()
+				if logger != nil {
+					logger.InfoContext(ctx, "request completed",
+						slog.String("method", r.Method),
+						slog.String("path", r.URL.Path),
+						slog.Int("status", status),
+						slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+					)
+				}
+			}()
+			next.ServeHTTP(tracked, r.WithContext(ctx))
 		})
 	}
 }
+
+type errorStatus int
+
+func (s errorStatus) Error() string {
+	return http.StatusText(int(s))
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
+)
+
+func newTestLogger(t *testing.T, output io.Writer) *slog.Logger {
+	t.Helper()
+	logger, err := logging.New(logging.Config{Format: "text", Output: output})
+	if err != nil {
+		t.Fatalf("build logger: %v", err)
+	}
+	return logger
+}
+
+func TestTraceContinuesIncomingTraceParent(t *testing.T) {
+	tracer := tracing.NewTracer(tracing.Config{Endpoint: "http://127.0.0.1:1"})
+	tracing.SetTracer(tracer)
+	defer func() {
+		tracing.SetTracer(nil)
+		tracer.Shutdown(context.Background())
+	}()
+
+	var logs bytes.Buffer
+	var propagated string
+	handler := Trace(newTestLogger(t, &logs))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		propagated = tracing.TraceParent(r.Context())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/summaries", nil)
+	request.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("expected handler status to pass through, got %d", recorder.Code)
+	}
+	if !strings.HasPrefix(propagated, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || strings.Contains(propagated, "00f067aa0ba902b7") {
+		t.Fatalf("expected a child span of the incoming trace, got %q", propagated)
+	}
+	if !strings.Contains(logs.String(), "trace_id=4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Fatalf("expected trace_id in request log, got %s", logs.String())
+	}
+}
@@ -1,37 +1,174 @@
 package httpserver
 
 import (
-	"log"
+	"expvar"
+	"log/slog"
+	"net"
 	"net/http"
 
+	"github.com/iago/extensao-whatsapp-back/internal/audit"
+	"github.com/iago/extensao-whatsapp-back/internal/auth"
+	"github.com/iago/extensao-whatsapp-back/internal/errorreport"
 	"github.com/iago/extensao-whatsapp-back/internal/http/handlers"
 	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/slo"
 )
 
 type RouterDependencies struct {
-	API            *handlers.API
-	Logger         *log.Logger
-	AuthToken      string
+	API       *handlers.API
+	Logger    *slog.Logger
+	AuthToken string
+	// AuthTokenFunc, when set, is read instead of AuthToken on every request.
+	AuthTokenFunc func() string
+	// JWT accepts provider-issued tokens on /v1 and /v2 next to AuthToken; nil disables it.
+	JWT *auth.JWTVerifier
+	// APIKeys validates per-tenant API keys; nil rejects requests that present one.
+	APIKeys middleware.APIKeyAuthenticator
+	// Signature verifies HMAC-signed requests; nil disables signing.
+	Signature      *middleware.SignatureVerifier
+	AdminToken     string
 	CORSOrigins    []string
 	RateLimitRPS   float64
 	RateLimitBurst int
+	// IPFilter applies the allow/deny lists and per-tenant API key overrides; nil disables it.
+	IPFilter *middleware.IPFilter
+	// Concurrency bounds in-flight API requests globally and per tenant; nil disables it.
+	Concurrency *middleware.ConcurrencyLimiter
+	// Timeout bounds handler time per route; the zero value allows 12s everywhere.
+	Timeout middleware.TimeoutConfig
+	// SecurityHeaders sets nosniff, Referrer-Policy, no-store and HSTS; the zero value
+	// applies the defaults without HSTS.
+	SecurityHeaders middleware.SecurityHeadersConfig
+	// Idempotency replays POST responses by Idempotency-Key; without a Store they are kept
+	// in memory.
+	Idempotency middleware.IdempotencyConfig
+	// BodyLimit caps request bodies; the zero value allows 1 MiB on every route.
+	BodyLimit middleware.BodyLimitConfig
+	// TrustedProxies are the load balancers allowed to report the client IP through
+	// X-Forwarded-For/X-Real-IP; empty keeps the TCP peer address.
+	TrustedProxies []*net.IPNet
+	// RateLimiter is shared with the admin API; when nil one is built from
+	// RateLimitRPS/RateLimitBurst.
+	RateLimiter *middleware.RateLimiter
+	// IPRateLimiter throttles each client IP before authentication; when nil one is built
+	// from RateLimitIPRPS/RateLimitIPBurst.
+	IPRateLimiter    *middleware.RateLimiter
+	RateLimitIPRPS   float64
+	RateLimitIPBurst int
+	// Audit receives a record for each successful sensitive operation; nil disables it.
+	Audit audit.Recorder
+	// Metrics records per-route request metrics; nil disables them.
+	Metrics *middleware.HTTPMetrics
+	// SLO tracks the latency objectives served at /admin/slo; nil disables it.
+	SLO *slo.Tracker
+	// ErrorReporter receives handler 5xx responses and panics; nil disables reporting.
+	ErrorReporter errorreport.Reporter
+	// MetricsHandler is served at /metrics; nil when metrics are off or on their own port.
+	MetricsHandler http.Handler
 }
 
 func NewRouter(deps RouterDependencies) http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", deps.API.Health)
+	mux.HandleFunc("/readyz", deps.API.Ready)
+	mux.HandleFunc("/openapi.json", deps.API.OpenAPI)
+	mux.HandleFunc("/docs", deps.API.Docs)
 	mux.HandleFunc("/v1/suggestions", deps.API.Suggestions)
+	mux.HandleFunc("/v1/analysis", deps.API.Analysis)
+	mux.HandleFunc("/v1/questions", deps.API.Questions)
+	mux.HandleFunc("/v1/action-items", deps.API.ActionItems)
+	mux.HandleFunc("/v1/compose", deps.API.Compose)
 	mux.HandleFunc("/v1/summaries", deps.API.Summaries)
 	mux.HandleFunc("/v1/reports", deps.API.Reports)
+	mux.HandleFunc("/v1/reports/", deps.API.ReportDetail)
+	mux.HandleFunc("/v1/digests", deps.API.Digests)
+	mux.HandleFunc("/v1/digests/", deps.API.DigestDetail)
 	mux.HandleFunc("/v1/jobs/", deps.API.JobStatus)
+	mux.HandleFunc("/v1/conversations/", deps.API.Conversations)
+	mux.HandleFunc("/v1/stats/jobs", deps.API.JobStats)
+	mux.HandleFunc("/v1/usage", deps.API.Usage)
+	mux.HandleFunc("/v1/hitl/decisions", deps.API.HITLDecisions)
+	mux.HandleFunc("/v1/templates", deps.API.Templates)
+	mux.HandleFunc("/v1/templates/", deps.API.TemplateDetail)
+	mux.HandleFunc("/v2/suggestions", deps.API.SuggestionsV2)
+	mux.HandleFunc("/v2/analysis", deps.API.AnalysisV2)
+	mux.HandleFunc("/admin/cache/flush", deps.API.AdminCacheFlush)
+	mux.HandleFunc("/admin/config", deps.API.AdminConfig)
+	mux.HandleFunc("/admin/rate-limits", deps.API.AdminRateLimits)
+	mux.HandleFunc("/admin/reload", deps.API.AdminReload)
+	mux.HandleFunc("/admin/worker", deps.API.AdminWorker)
+	mux.HandleFunc("/admin/policy-violations", deps.API.AdminPolicyViolations)
+	mux.HandleFunc("/admin/api-keys", deps.API.AdminAPIKeys)
+	mux.HandleFunc("/admin/api-keys/", deps.API.AdminAPIKeyDetail)
+	mux.HandleFunc("/admin/tenant-settings", deps.API.AdminTenantSettings)
+	mux.HandleFunc("/admin/tenant-settings/", deps.API.AdminTenantSettingsDetail)
+	mux.HandleFunc("/admin/slo", deps.API.AdminSLO)
+	mux.HandleFunc("/admin/log-level", deps.API.AdminLogLevel)
+	mux.Handle("/admin/vars", expvar.Handler())
+	if deps.MetricsHandler != nil {
+		mux.Handle("/metrics", deps.MetricsHandler)
+	}
 
-	handler := http.Handler(mux)
-	handler = middleware.Auth(deps.AuthToken)(handler)
-	handler = middleware.RateLimit(deps.RateLimitRPS, deps.RateLimitBurst)(handler)
+	handler := middleware.Audit(deps.Audit, deps.Logger)(mux)
+	idempotency := deps.Idempotency
+	if idempotency.Store == nil {
+		idempotency.Store = repository.NewMemoryIdempotencyRepository()
+	}
+	handler = middleware.Idempotency(idempotency)(handler)
+	handler = middleware.Timeout(deps.Timeout)(handler)
+	if deps.Signature != nil {
+		handler = deps.Signature.Middleware(handler)
+	}
+	handler = middleware.TenantScope(handler)
+	if deps.Concurrency != nil {
+		handler = deps.Concurrency.Middleware(handler)
+	}
+	handler = middleware.BodyLimit(deps.BodyLimit)(handler)
+	rateLimiter := deps.RateLimiter
+	if rateLimiter == nil {
+		rateLimiter = middleware.NewRateLimiter(deps.RateLimitRPS, deps.RateLimitBurst)
+	}
+	handler = rateLimiter.Middleware(handler)
+	if deps.IPFilter != nil {
+		handler = deps.IPFilter.TenantMiddleware(handler)
+	}
+	handler = middleware.Authorize(handler)
+	handler = middleware.Auth(middleware.AuthConfig{
+		Token:     deps.AuthToken,
+		TokenFunc: deps.AuthTokenFunc,
+		JWT:       deps.JWT,
+		APIKeys:   deps.APIKeys,
+	})(handler)
+	handler = middleware.AdminAuth(deps.AdminToken, deps.JWT)(handler)
+	ipRateLimiter := deps.IPRateLimiter
+	if ipRateLimiter == nil {
+		ipRateLimiter = middleware.NewIPRateLimiter(deps.RateLimitIPRPS, deps.RateLimitIPBurst)
+	}
+	handler = ipRateLimiter.Middleware(handler)
+	if deps.IPFilter != nil {
+		handler = deps.IPFilter.Middleware(handler)
+	}
 	handler = middleware.CORS(middleware.CORSConfig{
 		AllowedOrigins: deps.CORSOrigins,
 	})(handler)
+	handler = middleware.SecurityHeaders(deps.SecurityHeaders)(handler)
+	route := func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+	if deps.ErrorReporter != nil {
+		handler = middleware.ReportErrors(deps.ErrorReporter, route)(handler)
+	}
+	handler = middleware.Recover(deps.Logger)(handler)
+	if deps.SLO != nil {
+		handler = middleware.SLO(deps.SLO, route)(handler)
+	}
+	if deps.Metrics != nil {
+		handler = deps.Metrics.Middleware(route)(handler)
+	}
 	handler = middleware.Trace(deps.Logger)(handler)
+	handler = middleware.RealIP(deps.TrustedProxies)(handler)
 	handler = middleware.RequestID(handler)
 
 	return handler
@@ -0,0 +1,136 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// certificateCheckInterval bounds how often the key pair files are checked for rotation.
+const certificateCheckInterval = 30 * time.Second
+
+// TLSConfig enables native TLS for deployments without a terminating proxy.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables client certificate verification (mTLS) against these CAs.
+	ClientCAFile string
+	// ClientAuth is require (the default with a CA) or optional, which verifies a
+	// certificate only when the client presents one.
+	ClientAuth string
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// NewTLSConfig loads the key pair and client CAs. The key pair is reloaded when its files
+// change, so rotated certificates are picked up without a restart.
+func NewTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls: cert and key files are both required")
+	}
+	reloader := &certificateReloader{certFile: cfg.CertFile, keyFile: cfg.KeyFile}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+
+	mode := strings.ToLower(strings.TrimSpace(cfg.ClientAuth))
+	if cfg.ClientCAFile == "" {
+		if mode != "" {
+			return nil, errors.New("tls: client auth requires a client CA file")
+		}
+		return tlsConfig, nil
+	}
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("tls: client CA file has no certificates")
+	}
+	tlsConfig.ClientCAs = pool
+	switch mode {
+	case "", "require":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case "optional":
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("tls: unknown client auth mode %q", cfg.ClientAuth)
+	}
+	return tlsConfig, nil
+}
+
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time
+	checkedAt   time.Time
+}
+
+func (r *certificateReloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls: load key pair: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.certificate = &certificate
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+	return nil
+}
+
+// getCertificate serves the loaded pair, reloading it when the files changed. A pair that
+// fails to load (e.g. cert written before key) keeps the previous one in use.
+func (r *certificateReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	due := time.Since(r.checkedAt) >= certificateCheckInterval
+	if due {
+		r.checkedAt = time.Now()
+	}
+	current, loadedAt := r.certificate, r.modTime
+	r.mu.Unlock()
+
+	if due {
+		if modTime, err := r.latestModTime(); err == nil && modTime.After(loadedAt) {
+			if r.load() == nil {
+				r.mu.Lock()
+				current = r.certificate
+				r.mu.Unlock()
+			}
+		}
+	}
+	return current, nil
+}
+
+func (r *certificateReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("tls: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
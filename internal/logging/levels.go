@@ -0,0 +1,201 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DebugFilter lowers the level for the records of one tenant and/or conversation, read
+// from the scope fields of their context, until ExpiresAt.
+type DebugFilter struct {
+	Level          slog.Level
+	TenantID       string
+	ConversationID string
+	ExpiresAt      time.Time
+}
+
+func (f DebugFilter) matches(ctx context.Context) bool {
+	tenantMatched, conversationMatched := f.TenantID == "", f.ConversationID == ""
+	for _, attr := range scopeAttrs(ctx) {
+		switch attr.Key {
+		case "tenant_id":
+			tenantMatched = tenantMatched || attr.Value.String() == f.TenantID
+		case "conversation_id":
+			conversationMatched = conversationMatched || attr.Value.String() == f.ConversationID
+		}
+	}
+	return tenantMatched && conversationMatched
+}
+
+// levelState is replaced as a whole on every change, so Enabled reads it without locks.
+type levelState struct {
+	configured slog.Level
+	level      slog.Level
+	// until is when level reverts to configured; zero keeps it.
+	until  time.Time
+	filter *DebugFilter
+}
+
+// Levels is the runtime level of the loggers built by New: records below Level are
+// dropped unless the debug filter matches their context. Changes expire on their own, so
+// a level raised during an incident does not stay on.
+type Levels struct {
+	state atomic.Pointer[levelState]
+	now   func() time.Time
+}
+
+// NewLevels starts at configured, the LOG_LEVEL of the process.
+func NewLevels(configured slog.Level) *Levels {
+	levels := &Levels{now: time.Now}
+	levels.state.Store(&levelState{configured: configured, level: configured})
+	return levels
+}
+
+// LevelsOf returns the levels of a logger built by New, or nil for other loggers.
+func LevelsOf(logger *slog.Logger) *Levels {
+	if handler, ok := logger.Handler().(contextHandler); ok {
+		return handler.levels
+	}
+	return nil
+}
+
+// current returns the state with expired changes dropped.
+func (l *Levels) current() *levelState {
+	state := l.state.Load()
+	now := l.now()
+	if (state.until.IsZero() || now.Before(state.until)) && (state.filter == nil || now.Before(state.filter.ExpiresAt)) {
+		return state
+	}
+	expired := *state
+	if !expired.until.IsZero() && !now.Before(expired.until) {
+		expired.level, expired.until = expired.configured, time.Time{}
+	}
+	if expired.filter != nil && !now.Before(expired.filter.ExpiresAt) {
+		expired.filter = nil
+	}
+	l.state.CompareAndSwap(state, &expired)
+	return &expired
+}
+
+// update applies change to a copy of the current state.
+func (l *Levels) update(change func(state *levelState)) {
+	for {
+		previous := l.state.Load()
+		next := *l.current()
+		change(&next)
+		if l.state.CompareAndSwap(previous, &next) {
+			return
+		}
+	}
+}
+
+// Level is the level every record must reach, unless the debug filter matches it.
+func (l *Levels) Level() slog.Level {
+	return l.current().level
+}
+
+// Configured is the level the process started with or was last reloaded to.
+func (l *Levels) Configured() slog.Level {
+	return l.current().configured
+}
+
+// Until is when the current level reverts to the configured one; zero when it does not.
+func (l *Levels) Until() time.Time {
+	return l.current().until
+}
+
+// SetLevel changes the level for ttl, or until the next change when ttl is zero.
+func (l *Levels) SetLevel(level slog.Level, ttl time.Duration) {
+	l.update(func(state *levelState) {
+		state.level, state.until = level, time.Time{}
+		if ttl > 0 {
+			state.until = l.now().Add(ttl)
+		}
+	})
+}
+
+// SetConfigured replaces the configured level, as a reload of LOG_LEVEL does; a level
+// that was not changed at runtime follows it.
+func (l *Levels) SetConfigured(level slog.Level) {
+	l.update(func(state *levelState) {
+		if state.level == state.configured && state.until.IsZero() {
+			state.level = level
+		}
+		state.configured = level
+	})
+}
+
+// SetDebugFilter replaces the debug filter; filter.ExpiresAt must be set.
+func (l *Levels) SetDebugFilter(filter DebugFilter) {
+	l.update(func(state *levelState) {
+		state.filter = &filter
+	})
+}
+
+// DebugFilter returns the debug filter in force, if any.
+func (l *Levels) DebugFilter() (DebugFilter, bool) {
+	filter := l.current().filter
+	if filter == nil {
+		return DebugFilter{}, false
+	}
+	return *filter, true
+}
+
+// Reset goes back to the configured level and drops the debug filter.
+func (l *Levels) Reset() {
+	l.update(func(state *levelState) {
+		state.level, state.until, state.filter = state.configured, time.Time{}, nil
+	})
+}
+
+// ToggleDebug switches between debug and the configured level and returns the new level.
+func (l *Levels) ToggleDebug() slog.Level {
+	var level slog.Level
+	l.update(func(state *levelState) {
+		if state.level > slog.LevelDebug {
+			state.level = slog.LevelDebug
+		} else {
+			state.level = state.configured
+		}
+		state.until = time.Time{}
+		level = state.level
+	})
+	return level
+}
+
+// WatchSignal toggles debug logging on every SIGUSR2 until ctx ends. A nil Levels
+// returns right away.
+func (l *Levels) WatchSignal(ctx context.Context, logger *slog.Logger) {
+	if l == nil {
+		return
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+	defer signal.Stop(signals)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			level := l.ToggleDebug()
+			logger.Warn("log level changed by signal", slog.String("level", strings.ToLower(level.String())))
+		}
+	}
+}
+
+func (l *Levels) enabled(ctx context.Context, level slog.Level) bool {
+	state := l.current()
+	if level >= state.level {
+		return true
+	}
+	if state.filter == nil || level < state.filter.Level || ctx == nil {
+		return false
+	}
+	return state.filter.matches(ctx)
+}
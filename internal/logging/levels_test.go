@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugFilterOnlyLowersTheLevelOfMatchingScopes(t *testing.T) {
+	var output bytes.Buffer
+	logger, err := New(Config{Level: "info", Format: "text", Output: &output})
+	if err != nil {
+		t.Fatalf("build logger: %v", err)
+	}
+	levels := LevelsOf(logger)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	levels.now = func() time.Time { return now }
+	levels.SetDebugFilter(DebugFilter{Level: slog.LevelDebug, TenantID: "tenant-a", ExpiresAt: now.Add(time.Minute)})
+
+	incident := WithScope(context.Background(), slog.String("tenant_id", "tenant-a"), slog.String("conversation_id", "chat-1"))
+	other := WithScope(context.Background(), slog.String("tenant_id", "tenant-b"))
+	logger.DebugContext(incident, "prompt built")
+	logger.DebugContext(other, "other tenant")
+	logger.Debug("no scope")
+	if text := output.String(); !strings.Contains(text, "prompt built") || strings.Contains(text, "other tenant") || strings.Contains(text, "no scope") {
+		t.Fatalf("expected debug only for tenant-a, got %q", text)
+	}
+
+	output.Reset()
+	now = now.Add(2 * time.Minute)
+	logger.DebugContext(incident, "after expiry")
+	if output.Len() != 0 {
+		t.Fatalf("expected the filter to expire, got %q", output.String())
+	}
+	if _, ok := levels.DebugFilter(); ok {
+		t.Fatalf("expected no filter after expiry")
+	}
+}
+
+func TestLevelsChangeExpireAndToggle(t *testing.T) {
+	levels := NewLevels(slog.LevelInfo)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	levels.now = func() time.Time { return now }
+
+	levels.SetLevel(slog.LevelError, time.Minute)
+	if levels.Level() != slog.LevelError || levels.enabled(context.Background(), slog.LevelWarn) {
+		t.Fatalf("expected error level, got %s", levels.Level())
+	}
+	now = now.Add(time.Minute)
+	if levels.Level() != slog.LevelInfo || !levels.Until().IsZero() {
+		t.Fatalf("expected the configured level back after the ttl, got %s", levels.Level())
+	}
+
+	if level := levels.ToggleDebug(); level != slog.LevelDebug {
+		t.Fatalf("expected the first toggle to turn on debug, got %s", level)
+	}
+	if level := levels.ToggleDebug(); level != slog.LevelInfo {
+		t.Fatalf("expected the second toggle to restore info, got %s", level)
+	}
+
+	levels.SetConfigured(slog.LevelWarn)
+	if levels.Level() != slog.LevelWarn {
+		t.Fatalf("expected an unchanged level to follow the reload, got %s", levels.Level())
+	}
+	levels.SetLevel(slog.LevelDebug, 0)
+	levels.SetConfigured(slog.LevelError)
+	if levels.Level() != slog.LevelDebug || levels.Configured() != slog.LevelError {
+		t.Fatalf("expected a runtime level to survive the reload, got %s/%s", levels.Level(), levels.Configured())
+	}
+	levels.Reset()
+	if levels.Level() != slog.LevelError {
+		t.Fatalf("expected reset to go back to the configured level, got %s", levels.Level())
+	}
+}
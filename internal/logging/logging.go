@@ -0,0 +1,161 @@
+// Package logging builds the process slog logger and carries request-scoped fields
+// (request_id, tenant_id, job_id, ...) in the context so every *Context log call includes
+// them.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
+)
+
+type Config struct {
+	// Level is debug, info, warn or error; empty means info.
+	Level string
+	// Format is json or text; empty means json.
+	Format string
+	Output io.Writer
+}
+
+// New builds a logger whose handler adds the scope fields and trace_id from the context.
+// Its level starts at cfg.Level and can be changed at runtime through LevelsOf.
+func New(cfg Config) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	// The levels decide; the wrapped handler lets every record through.
+	options := &slog.HandlerOptions{Level: slog.LevelDebug}
+
+	var handler slog.Handler
+	switch strings.ToLower(strings.TrimSpace(cfg.Format)) {
+	case "", "json":
+		handler = slog.NewJSONHandler(cfg.Output, options)
+	case "text":
+		handler = slog.NewTextHandler(cfg.Output, options)
+	default:
+		return nil, fmt.Errorf("logging: unknown format %q", cfg.Format)
+	}
+	return slog.New(contextHandler{next: handler, levels: NewLevels(level)}), nil
+}
+
+// Discard returns a logger that drops everything, for tests and tools.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("logging: unknown level %q", value)
+}
+
+type scopeKey struct{}
+
+type requestIDKey struct{}
+
+// WithRequestID records the ID of the API request a unit of work belongs to. The AI
+// clients send it to the provider and created jobs keep it, so a request can be followed
+// from the access log to the worker and the provider dashboard.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the ID recorded by WithRequestID, or "" outside a request.
+func RequestID(ctx context.Context) string {
+	value, _ := ctx.Value(requestIDKey{}).(string)
+	return value
+}
+
+// scope holds the fields of one request or job. It is shared by every context derived from
+// the one WithScope returned, so fields added deep in the handler chain (tenant_id after
+// auth, model_id after generation) also appear in the final access log line.
+type scope struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// WithScope starts a field scope for one unit of work, seeded with attrs.
+func WithScope(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{attrs: append([]slog.Attr(nil), attrs...)})
+}
+
+// Set adds fields to the scope in ctx, replacing earlier values of the same key. Without a
+// scope it does nothing.
+func Set(ctx context.Context, attrs ...slog.Attr) {
+	current, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	for _, attr := range attrs {
+		replaced := false
+		for index := range current.attrs {
+			if current.attrs[index].Key == attr.Key {
+				current.attrs[index] = attr
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			current.attrs = append(current.attrs, attr)
+		}
+	}
+}
+
+// Fields returns a copy of the scope fields in ctx, for sinks other than the logger such as
+// error reports.
+func Fields(ctx context.Context) []slog.Attr {
+	return scopeAttrs(ctx)
+}
+
+func scopeAttrs(ctx context.Context) []slog.Attr {
+	current, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return nil
+	}
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	return append([]slog.Attr(nil), current.attrs...)
+}
+
+type contextHandler struct {
+	next   slog.Handler
+	levels *Levels
+}
+
+func (h contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.levels.enabled(ctx, level) && h.next.Enabled(ctx, level)
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		record.AddAttrs(scopeAttrs(ctx)...)
+		if traceID := tracing.TraceID(ctx); traceID != "" {
+			record.AddAttrs(slog.String("trace_id", traceID))
+		}
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{next: h.next.WithAttrs(attrs), levels: h.levels}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{next: h.next.WithGroup(name), levels: h.levels}
+}
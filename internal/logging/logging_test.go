@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestScopeFieldsAppearOnContextLogs(t *testing.T) {
+	var output bytes.Buffer
+	logger, err := New(Config{Level: "debug", Output: &output})
+	if err != nil {
+		t.Fatalf("build logger: %v", err)
+	}
+
+	ctx := WithScope(context.Background(), slog.String("request_id", "req-1"))
+	inner := context.WithValue(ctx, struct{}{}, "derived")
+	Set(inner, slog.String("tenant_id", "tenant-a"), slog.String("model_id", "first"))
+	Set(inner, slog.String("model_id", "second"))
+	logger.InfoContext(ctx, "request completed", slog.Int64("latency_ms", 12))
+
+	var record map[string]any
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("expected one JSON record, got %q: %v", output.String(), err)
+	}
+	expected := map[string]any{
+		"msg":        "request completed",
+		"request_id": "req-1",
+		"tenant_id":  "tenant-a",
+		"model_id":   "second",
+		"latency_ms": float64(12),
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Fatalf("expected %s=%v, got %v in %v", key, value, record[key], record)
+		}
+	}
+}
+
+func TestNewRejectsUnknownSettings(t *testing.T) {
+	if _, err := New(Config{Level: "verbose", Output: &bytes.Buffer{}}); err == nil {
+		t.Fatalf("expected unknown level to fail")
+	}
+	if _, err := New(Config{Format: "xml", Output: &bytes.Buffer{}}); err == nil {
+		t.Fatalf("expected unknown format to fail")
+	}
+
+	var output bytes.Buffer
+	logger, _ := New(Config{Level: "warn", Format: "text", Output: &output})
+	logger.Info("dropped")
+	if output.Len() != 0 {
+		t.Fatalf("expected info to be filtered at warn level, got %q", output.String())
+	}
+}
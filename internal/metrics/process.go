@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RegisterProcessMetrics adds Go runtime and process gauges. The /proc based ones are
+// only registered where /proc exists.
+func RegisterProcessMetrics(registry *Registry) {
+	startedAt := float64(time.Now().Unix())
+
+	registry.GaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	registry.GaugeFunc("go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.", func() float64 {
+		return float64(readMemStats().HeapAlloc)
+	})
+	registry.GaugeFunc("go_memstats_sys_bytes", "Bytes of memory obtained from the OS.", func() float64 {
+		return float64(readMemStats().Sys)
+	})
+	registry.CounterFunc("go_gc_cycles_total", "Completed garbage collection cycles.", func() float64 {
+		return float64(readMemStats().NumGC)
+	})
+	registry.GaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", func() float64 {
+		return startedAt
+	})
+
+	if _, err := os.Stat("/proc/self/fd"); err == nil {
+		registry.GaugeFunc("process_open_fds", "Number of open file descriptors.", func() float64 {
+			entries, err := os.ReadDir("/proc/self/fd")
+			if err != nil {
+				return 0
+			}
+			return float64(len(entries))
+		})
+	}
+	if _, err := os.Stat("/proc/self/statm"); err == nil {
+		registry.GaugeFunc("process_resident_memory_bytes", "Resident memory size in bytes.", func() float64 {
+			content, err := os.ReadFile("/proc/self/statm")
+			if err != nil {
+				return 0
+			}
+			fields := strings.Fields(string(content))
+			if len(fields) < 2 {
+				return 0
+			}
+			pages, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return 0
+			}
+			return pages * float64(os.Getpagesize())
+		})
+	}
+}
+
+func readMemStats() runtime.MemStats {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats
+}
@@ -0,0 +1,369 @@
+// Package metrics keeps counters, gauges and histograms in memory and renders them in
+// the Prometheus text exposition format (version 0.0.4).
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets suits request latencies in seconds.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds the process metrics. Registering a name twice panics, as metric names are
+// fixed at startup.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.collectors[name]; exists {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.collectors[name] = c
+}
+
+// Counter registers a monotonically increasing value partitioned by labels.
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{family: newFamily(name, help, "counter", labels)}
+	r.register(name, counter)
+	return counter
+}
+
+// Gauge registers a value that can go up and down, partitioned by labels.
+func (r *Registry) Gauge(name, help string, labels ...string) *GaugeVec {
+	gauge := &GaugeVec{family: newFamily(name, help, "gauge", labels)}
+	r.register(name, gauge)
+	return gauge
+}
+
+// Histogram registers a distribution with the given upper bounds (DefBuckets when nil).
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	histogram := &HistogramVec{family: newFamily(name, help, "histogram", labels), buckets: bounds}
+	r.register(name, histogram)
+	return histogram
+}
+
+// GaugeFunc registers a gauge read from fn at scrape time.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &funcCollector{family: newFamily(name, help, "gauge", nil), fn: fn})
+}
+
+// GaugeVecFunc registers labeled gauges computed at scrape time; fn reports each series
+// through emit, with one value per label.
+func (r *Registry) GaugeVecFunc(name, help string, labels []string, fn func(emit func(value float64, labelValues ...string))) {
+	r.register(name, &vecFuncCollector{family: newFamily(name, help, "gauge", labels), fn: fn})
+}
+
+// CounterFunc registers a counter read from fn at scrape time, for totals kept elsewhere.
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.register(name, &funcCollector{family: newFamily(name, help, "counter", nil), fn: fn})
+}
+
+// WriteText renders every metric, sorted by name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]collector, 0, len(names))
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.RUnlock()
+
+	buffered := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(buffered)
+	}
+	return buffered.Flush()
+}
+
+// Handler serves the registry for Prometheus scrapes.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteText(w)
+	})
+}
+
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func newFamily(name, help, kind string, labels []string) family {
+	return family{name: name, help: help, kind: kind, labels: append([]string(nil), labels...)}
+}
+
+func (f family) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+}
+
+// key joins label values; \xff cannot appear in valid UTF-8 label values.
+func (f family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func (f family) labelPairs(values []string, extra ...string) string {
+	if len(f.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	builder := strings.Builder{}
+	builder.WriteByte('{')
+	for index, label := range f.labels {
+		if index > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(label + `="` + escapeLabel(values[index]) + `"`)
+	}
+	for index := 0; index+1 < len(extra); index += 2 {
+		if builder.Len() > 1 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(extra[index] + `="` + escapeLabel(extra[index+1]) + `"`)
+	}
+	builder.WriteByte('}')
+	return builder.String()
+}
+
+type series struct {
+	values []string
+	value  float64
+}
+
+type CounterVec struct {
+	family
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the series; negative deltas are ignored.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.series == nil {
+		c.series = make(map[string]*series)
+	}
+	entry, ok := c.series[key]
+	if !ok {
+		entry = &series{values: append([]string(nil), labelValues...)}
+		c.series[key] = entry
+	}
+	entry.value += delta
+}
+
+// Value returns the current value of one series.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.series[key]; ok {
+		return entry.value
+	}
+	return 0
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		entry := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(entry.values), formatFloat(entry.value))
+	}
+}
+
+type GaugeVec struct {
+	family
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.update(labelValues, func(current float64) float64 { return value })
+}
+
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.update(labelValues, func(current float64) float64 { return current + delta })
+}
+
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	key := g.key(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if entry, ok := g.series[key]; ok {
+		return entry.value
+	}
+	return 0
+}
+
+func (g *GaugeVec) update(labelValues []string, apply func(float64) float64) {
+	key := g.key(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.series == nil {
+		g.series = make(map[string]*series)
+	}
+	entry, ok := g.series[key]
+	if !ok {
+		entry = &series{values: append([]string(nil), labelValues...)}
+		g.series[key] = entry
+	}
+	entry.value = apply(entry.value)
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, key := range sortedKeys(g.series) {
+		entry := g.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelPairs(entry.values), formatFloat(entry.value))
+	}
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type HistogramVec struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.series == nil {
+		h.series = make(map[string]*histogramSeries)
+	}
+	entry, ok := h.series[key]
+	if !ok {
+		entry = &histogramSeries{values: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = entry
+	}
+	if index := sort.SearchFloat64s(h.buckets, value); index < len(h.buckets) {
+		entry.counts[index]++
+	}
+	entry.count++
+	entry.sum += value
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		entry := h.series[key]
+		var cumulative uint64
+		for index, bound := range h.buckets {
+			cumulative += entry.counts[index]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(entry.values, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(entry.values, "le", "+Inf"), entry.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(entry.values), formatFloat(entry.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(entry.values), entry.count)
+	}
+}
+
+type funcCollector struct {
+	family
+	fn func() float64
+}
+
+func (f *funcCollector) write(w *bufio.Writer) {
+	f.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", f.name, formatFloat(f.fn()))
+}
+
+type vecFuncCollector struct {
+	family
+	fn func(emit func(value float64, labelValues ...string))
+}
+
+func (f *vecFuncCollector) write(w *bufio.Writer) {
+	f.writeHeader(w)
+	f.fn(func(value float64, labelValues ...string) {
+		f.key(labelValues) // panics on a wrong number of label values
+		fmt.Fprintf(w, "%s%s %s\n", f.name, f.labelPairs(labelValues), formatFloat(value))
+	})
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeHelp(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(value)
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
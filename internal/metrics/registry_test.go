@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteTextExposition(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.Counter("requests_total", "Requests served.", "route")
+	latency := registry.Histogram("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	registry.GaugeFunc("queue_depth", "Queued jobs.", func() float64 { return 7 })
+	registry.GaugeVecFunc("burn_rate", "Burn rate.", []string{"slo", "window"}, func(emit func(float64, ...string)) {
+		emit(0.5, "QT-001", "5m")
+		emit(2, "QT-001", "1h")
+	})
+
+	requests.Inc(`/v1/"quoted"`)
+	requests.Add(2, "/v1/jobs/")
+	requests.Add(-5, "/v1/jobs/")
+	latency.Observe(0.05, "/v1/jobs/")
+	latency.Observe(0.5, "/v1/jobs/")
+	latency.Observe(3, "/v1/jobs/")
+
+	output := strings.Builder{}
+	if err := registry.WriteText(&output); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	expected := `# HELP burn_rate Burn rate.
+# TYPE burn_rate gauge
+burn_rate{slo="QT-001",window="5m"} 0.5
+burn_rate{slo="QT-001",window="1h"} 2
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/v1/jobs/",le="0.1"} 1
+latency_seconds_bucket{route="/v1/jobs/",le="1"} 2
+latency_seconds_bucket{route="/v1/jobs/",le="+Inf"} 3
+latency_seconds_sum{route="/v1/jobs/"} 3.55
+latency_seconds_count{route="/v1/jobs/"} 3
+# HELP queue_depth Queued jobs.
+# TYPE queue_depth gauge
+queue_depth 7
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/v1/\"quoted\""} 1
+requests_total{route="/v1/jobs/"} 2
+`
+	if output.String() != expected {
+		t.Fatalf("unexpected exposition:\n%s", output.String())
+	}
+}
+
+func TestDuplicateRegistrationPanics(t *testing.T) {
+	registry := NewRegistry()
+	registry.Gauge("in_flight", "In flight.")
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected duplicate metric to panic")
+		}
+	}()
+	registry.Counter("in_flight", "Again.")
+}
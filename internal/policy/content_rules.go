@@ -3,19 +3,37 @@ package policy
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 )
 
 var ErrContentPolicyViolation = errors.New("content policy violation")
 
+const maxPolicyFieldBytes = 4000
+
+// Severity tells whether a violation rejects the request or only flags it.
+type Severity string
+
+const (
+	SeverityBlock Severity = "block"
+	SeverityWarn  Severity = "warn"
+)
+
 type Violation struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+	// Rule is the keyword entry or limit that fired. It is recorded for rule tuning but
+	// never returned to callers, so the blocked lists stay private.
+	Rule string `json:"-"`
 }
 
+// Evaluation holds the blocking violations and, apart, the warnings that let the request
+// through but must be shown to the agent.
 type Evaluation struct {
 	Allowed    bool        `json:"allowed"`
 	Violations []Violation `json:"violations,omitempty"`
+	Warnings   []Violation `json:"warnings,omitempty"`
 }
 
 type PolicyViolationError struct {
@@ -33,15 +51,24 @@ func (e *PolicyViolationError) Unwrap() error {
 	return ErrContentPolicyViolation
 }
 
-func EnforceContentPolicy(payload json.RawMessage) error {
-	evaluation := EvaluateContentPolicy(payload)
+// EnforceContentPolicy checks payload against the size limits and the blocked keywords
+// of tenantID (defaults plus the tenant's own list). Warnings are dropped; callers that
+// surface them use CheckContentPolicy.
+func EnforceContentPolicy(tenantID string, payload json.RawMessage) error {
+	_, err := CheckContentPolicy(tenantID, payload)
+	return err
+}
+
+// CheckContentPolicy is EnforceContentPolicy returning the warnings of an allowed request.
+func CheckContentPolicy(tenantID string, payload json.RawMessage) ([]Violation, error) {
+	evaluation := EvaluateContentPolicy(tenantID, payload)
 	if evaluation.Allowed {
-		return nil
+		return evaluation.Warnings, nil
 	}
-	return &PolicyViolationError{Violations: evaluation.Violations}
+	return nil, &PolicyViolationError{Violations: evaluation.Violations}
 }
 
-func EvaluateContentPolicy(payload json.RawMessage) Evaluation {
+func EvaluateContentPolicy(tenantID string, payload json.RawMessage) Evaluation {
 	trimmed := strings.TrimSpace(string(payload))
 	if trimmed == "" {
 		return Evaluation{Allowed: true}
@@ -57,47 +84,41 @@ func EvaluateContentPolicy(payload json.RawMessage) Evaluation {
 		return Evaluation{Allowed: true}
 	}
 
+	keywords := blockedKeywords.Load()
 	violations := make([]Violation, 0, 2)
 	if hasOversizedField(values) {
+		rule := fmt.Sprintf("max_field_bytes=%d", maxPolicyFieldBytes)
 		violations = append(violations, Violation{
-			Code:    "payload_too_large",
-			Message: "one or more text fields exceed policy size limits",
+			Code:     "payload_too_large",
+			Message:  "one or more text fields exceed policy size limits",
+			Severity: keywords.Severity(tenantID, "payload_too_large", rule),
+			Rule:     rule,
 		})
 	}
 
-	content := strings.ToLower(strings.Join(values, "\n"))
-	for _, token := range blockedKeywords {
-		if strings.Contains(content, token) {
-			violations = append(violations, Violation{
-				Code:    "blocked_operation",
-				Message: "request contains operation blocked by policy",
-			})
-			break
+	for _, rule := range keywords.MatchedRules(tenantID, strings.Join(values, "\n")) {
+		violation := Violation{
+			Code:     "blocked_operation",
+			Message:  "request contains operation blocked by policy",
+			Severity: keywords.Severity(tenantID, "blocked_operation", rule),
+			Rule:     rule,
 		}
+		if violation.Severity == SeverityWarn {
+			violation.Message = "request mentions an operation flagged by policy; review before sending"
+		}
+		violations = append(violations, violation)
 	}
 
-	if len(violations) == 0 {
-		return Evaluation{Allowed: true}
-	}
-
-	return Evaluation{
-		Allowed:    false,
-		Violations: dedupeViolations(violations),
+	evaluation := Evaluation{Allowed: true}
+	for _, violation := range dedupeViolations(violations) {
+		if violation.Severity == SeverityWarn {
+			evaluation.Warnings = append(evaluation.Warnings, violation)
+			continue
+		}
+		evaluation.Allowed = false
+		evaluation.Violations = append(evaluation.Violations, violation)
 	}
-}
-
-var blockedKeywords = []string{
-	"auto send",
-	"automatic send",
-	"envio automatico",
-	"disparo em massa",
-	"bulk messaging",
-	"mass spam",
-	"phishing",
-	"ransomware",
-	"malware",
-	"golpe",
-	"fraude",
+	return evaluation
 }
 
 func collectStringValues(value any, current []string) []string {
@@ -121,7 +142,7 @@ func collectStringValues(value any, current []string) []string {
 
 func hasOversizedField(values []string) bool {
 	for _, value := range values {
-		if len(value) > 4000 {
+		if len(value) > maxPolicyFieldBytes {
 			return true
 		}
 	}
@@ -132,7 +153,7 @@ func dedupeViolations(values []Violation) []Violation {
 	seen := make(map[string]struct{}, len(values))
 	result := make([]Violation, 0, len(values))
 	for _, value := range values {
-		key := value.Code + "|" + value.Message
+		key := value.Code + "|" + string(value.Severity)
 		if _, exists := seen[key]; exists {
 			continue
 		}
@@ -0,0 +1,206 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// KeywordRegexPrefix marks list entries that are regular expressions rather than terms.
+const KeywordRegexPrefix = "re:"
+
+// DefaultBlockedKeywords is the built-in list, used until a keyword file is loaded.
+var DefaultBlockedKeywords = []string{
+	"auto send",
+	"automatic send",
+	"envio automatico",
+	"disparo em massa",
+	"bulk messaging",
+	"mass spam",
+	"phishing",
+	"ransomware",
+	"malware",
+	"golpe",
+	"fraude",
+}
+
+// KeywordList matches blocked operations in request text. Plain terms match whole words,
+// case-insensitively, so "golpe" does not flag "golpear"; entries starting with "re:" are
+// regular expressions. Tenant entries are checked on top of the defaults.
+type KeywordList struct {
+	defaults []keywordRule
+	tenants  map[string][]keywordRule
+	// severities maps a keyword entry or violation code to its severity; tenant maps are
+	// checked before the default one.
+	severities       map[string]Severity
+	tenantSeverities map[string]map[string]Severity
+}
+
+// keywordRule keeps the entry as written, reported as the matched rule of a violation.
+type keywordRule struct {
+	entry      string
+	expression *regexp.Regexp
+}
+
+// KeywordFile is the on-disk format of BLOCKED_KEYWORDS_FILE.
+type KeywordFile struct {
+	Default    []string            `json:"default"`
+	Tenants    map[string][]string `json:"tenants"`
+	Severities SeverityRules       `json:"severities"`
+}
+
+// SeverityRules downgrades (or upgrades back) rules to "warn" or "block". Keys are keyword
+// entries as written in the lists or violation codes such as payload_too_large.
+type SeverityRules struct {
+	Default map[string]Severity            `json:"default"`
+	Tenants map[string]map[string]Severity `json:"tenants"`
+}
+
+func CompileKeywords(file KeywordFile) (*KeywordList, error) {
+	defaults, err := compileKeywordEntries(file.Default)
+	if err != nil {
+		return nil, err
+	}
+	list := &KeywordList{
+		defaults:         defaults,
+		tenants:          make(map[string][]keywordRule, len(file.Tenants)),
+		tenantSeverities: make(map[string]map[string]Severity, len(file.Severities.Tenants)),
+	}
+	for tenantID, entries := range file.Tenants {
+		compiled, err := compileKeywordEntries(entries)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+		list.tenants[strings.TrimSpace(tenantID)] = compiled
+	}
+	if list.severities, err = compileSeverities(file.Severities.Default); err != nil {
+		return nil, err
+	}
+	for tenantID, severities := range file.Severities.Tenants {
+		compiled, err := compileSeverities(severities)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+		list.tenantSeverities[strings.TrimSpace(tenantID)] = compiled
+	}
+	return list, nil
+}
+
+func compileSeverities(values map[string]Severity) (map[string]Severity, error) {
+	compiled := make(map[string]Severity, len(values))
+	for key, severity := range values {
+		severity = Severity(strings.ToLower(strings.TrimSpace(string(severity))))
+		if severity != SeverityBlock && severity != SeverityWarn {
+			return nil, fmt.Errorf("invalid severity %q for %q: use block or warn", severity, key)
+		}
+		compiled[strings.TrimSpace(key)] = severity
+	}
+	return compiled, nil
+}
+
+// LoadKeywordFile reads and compiles a JSON keyword file.
+func LoadKeywordFile(path string) (*KeywordList, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("blocked keywords: %w", err)
+	}
+	var file KeywordFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("blocked keywords: invalid JSON: %w", err)
+	}
+	list, err := CompileKeywords(file)
+	if err != nil {
+		return nil, fmt.Errorf("blocked keywords: %w", err)
+	}
+	return list, nil
+}
+
+func compileKeywordEntries(entries []string) ([]keywordRule, error) {
+	compiled := make([]keywordRule, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern := ""
+		if expression, ok := strings.CutPrefix(entry, KeywordRegexPrefix); ok {
+			pattern = "(?i)" + expression
+		} else {
+			words := strings.Fields(entry)
+			for index := range words {
+				words[index] = regexp.QuoteMeta(words[index])
+			}
+			// RE2 has no lookarounds and \b is ASCII only, so boundaries are spelled out to
+			// treat accented letters as part of the word.
+			pattern = `(?i)(?:^|[^\p{L}\p{N}_])` + strings.Join(words, `\s+`) + `(?:$|[^\p{L}\p{N}_])`
+		}
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", entry, err)
+		}
+		compiled = append(compiled, keywordRule{entry: entry, expression: expression})
+	}
+	return compiled, nil
+}
+
+// Match reports whether content hits the defaults or the tenant's own entries.
+func (l *KeywordList) Match(tenantID, content string) bool {
+	_, matched := l.MatchedRule(tenantID, content)
+	return matched
+}
+
+// MatchedRule returns the first entry content hits, as written in the list.
+func (l *KeywordList) MatchedRule(tenantID, content string) (string, bool) {
+	rules := l.MatchedRules(tenantID, content)
+	if len(rules) == 0 {
+		return "", false
+	}
+	return rules[0], true
+}
+
+// MatchedRules returns every entry content hits, defaults first.
+func (l *KeywordList) MatchedRules(tenantID, content string) []string {
+	var matched []string
+	tenantRules, _ := tenantEntry(l.tenants, tenantID)
+	for _, rules := range [][]keywordRule{l.defaults, tenantRules} {
+		for _, rule := range rules {
+			if rule.expression.MatchString(content) {
+				matched = append(matched, rule.entry)
+			}
+		}
+	}
+	return matched
+}
+
+// Severity resolves a violation of code fired by rule. The tenant's map wins over the
+// default one and, within a map, the rule wins over the code. Unlisted rules block.
+func (l *KeywordList) Severity(tenantID, code, rule string) Severity {
+	tenantSeverities, _ := tenantEntry(l.tenantSeverities, tenantID)
+	for _, severities := range []map[string]Severity{tenantSeverities, l.severities} {
+		if severity, ok := severities[rule]; ok && rule != "" {
+			return severity
+		}
+		if severity, ok := severities[code]; ok {
+			return severity
+		}
+	}
+	return SeverityBlock
+}
+
+var blockedKeywords atomic.Pointer[KeywordList]
+
+func init() {
+	list, err := CompileKeywords(KeywordFile{Default: DefaultBlockedKeywords})
+	if err != nil {
+		panic(err)
+	}
+	blockedKeywords.Store(list)
+}
+
+// SetBlockedKeywords replaces the list used by EvaluateContentPolicy.
+func SetBlockedKeywords(list *KeywordList) {
+	blockedKeywords.Store(list)
+}
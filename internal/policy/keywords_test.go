@@ -0,0 +1,147 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+)
+
+func TestKeywordListMatchesWholeWordsAndTenantEntries(t *testing.T) {
+	list, err := CompileKeywords(KeywordFile{
+		Default: []string{"golpe", "disparo em massa", `re:pix\s+premiado`},
+		Tenants: map[string][]string{"tenant-a": {"cupom falso"}},
+	})
+	if err != nil {
+		t.Fatalf("compile keywords: %v", err)
+	}
+
+	for _, tc := range []struct {
+		tenantID string
+		content  string
+		want     bool
+	}{
+		{"", "isso e um GOLPE!", true},
+		{"", "vou golpear o saco de pancada", false},
+		{"", "golpeado", false},
+		{"", "faça um disparo  em\nmassa", true},
+		{"", "ganhe um PIX   premiado", true},
+		{"tenant-a", "enviar cupom falso", true},
+		{"tenant-b", "enviar cupom falso", false},
+	} {
+		if got := list.Match(tc.tenantID, tc.content); got != tc.want {
+			t.Fatalf("tenant=%q content=%q: expected %v, got %v", tc.tenantID, tc.content, tc.want, got)
+		}
+	}
+
+	if rule, ok := list.MatchedRule("", "ganhe um pix premiado"); !ok || rule != `re:pix\s+premiado` {
+		t.Fatalf("expected matched regex entry, got %q %v", rule, ok)
+	}
+
+	if _, err := CompileKeywords(KeywordFile{Default: []string{"re:("}}); err == nil {
+		t.Fatalf("expected invalid regex to fail")
+	}
+}
+
+func TestTenantsWithoutEntriesUseTheirPolicyProfile(t *testing.T) {
+	list, err := CompileKeywords(KeywordFile{
+		Tenants: map[string][]string{"regulated": {"cupom falso"}, "tenant-a": {"sorteio"}},
+	})
+	if err != nil {
+		t.Fatalf("compile keywords: %v", err)
+	}
+	SetTenantProfile("tenant-a", "regulated")
+	SetTenantProfile("tenant-b", "regulated")
+	defer SetTenantProfile("tenant-a", "")
+	defer SetTenantProfile("tenant-b", "")
+
+	if !list.Match("tenant-b", "enviar cupom falso") {
+		t.Fatal("expected tenant-b to use the regulated profile")
+	}
+	if list.Match("tenant-a", "enviar cupom falso") || !list.Match("tenant-a", "grande sorteio") {
+		t.Fatal("expected tenant-a to keep its own entry over the profile")
+	}
+	SetTenantProfile("tenant-b", "")
+	if list.Match("tenant-b", "enviar cupom falso") {
+		t.Fatal("expected the profile to be removed")
+	}
+}
+
+func TestWatchKeywordFileReloadsOnChange(t *testing.T) {
+	defer SetBlockedKeywords(blockedKeywords.Load())
+
+	path := filepath.Join(t.TempDir(), "keywords.json")
+	write := func(file KeywordFile, modTime time.Time) {
+		content, _ := json.Marshal(file)
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatalf("write keywords: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	write(KeywordFile{Default: []string{"alfa"}}, time.Now().Add(-time.Minute))
+	list, err := LoadKeywordFile(path)
+	if err != nil {
+		t.Fatalf("load keywords: %v", err)
+	}
+	SetBlockedKeywords(list)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchKeywordFile(ctx, path, 10*time.Millisecond, logging.Discard())
+
+	payload := json.RawMessage(`{"prompt":"mensagem beta"}`)
+	if err := EnforceContentPolicy("", payload); err != nil {
+		t.Fatalf("expected beta allowed before reload: %v", err)
+	}
+	write(KeywordFile{Default: []string{"beta"}}, time.Now())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for EnforceContentPolicy("", payload) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected reloaded list to block beta")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSeverityRulesTurnBlocksIntoWarnings(t *testing.T) {
+	list, err := CompileKeywords(KeywordFile{
+		Default: []string{"golpe", "fraude"},
+		Severities: SeverityRules{
+			Default: map[string]Severity{"fraude": SeverityWarn},
+			Tenants: map[string]map[string]Severity{"tenant-a": {"blocked_operation": SeverityWarn, "fraude": SeverityBlock}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("compile keywords: %v", err)
+	}
+	previous := blockedKeywords.Load()
+	SetBlockedKeywords(list)
+	t.Cleanup(func() { SetBlockedKeywords(previous) })
+
+	warnings, err := CheckContentPolicy("", json.RawMessage(`{"prompt":"cliente relatou fraude no boleto"}`))
+	if err != nil || len(warnings) != 1 || warnings[0].Severity != SeverityWarn || warnings[0].Rule != "fraude" {
+		t.Fatalf("expected fraude to warn by default, got %+v err=%v", warnings, err)
+	}
+	if _, err := CheckContentPolicy("", json.RawMessage(`{"prompt":"fraude ou golpe"}`)); err == nil {
+		t.Fatalf("expected a blocking rule to win over a warning")
+	}
+
+	warnings, err = CheckContentPolicy("tenant-a", json.RawMessage(`{"prompt":"isso e golpe"}`))
+	if err != nil || len(warnings) != 1 {
+		t.Fatalf("expected tenant-a to only warn on blocked_operation, got %+v err=%v", warnings, err)
+	}
+	if _, err := CheckContentPolicy("tenant-a", json.RawMessage(`{"prompt":"fraude"}`)); err == nil {
+		t.Fatalf("expected tenant rule to override the code and the default")
+	}
+
+	if _, err := CompileKeywords(KeywordFile{Severities: SeverityRules{Default: map[string]Severity{"golpe": "ignore"}}}); err == nil {
+		t.Fatalf("expected invalid severity to be rejected")
+	}
+}
@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maskMarkers recognize the masks RedactPII leaves in text. The generic marker captures
+// its category in group 1.
+var maskMarkers = []struct {
+	category   string
+	expression *regexp.Regexp
+}{
+	{"", regexp.MustCompile(`\[(` + strings.Join(allPIICategories, "|") + `)_redacted\]`)},
+	{PIICPF, regexp.MustCompile(`\*{3}\.\*{3}\.\*{3}-\*{2}`)},
+	{PIICNPJ, regexp.MustCompile(`\*{2}\.\*{3}\.\*{3}/\*{4}-\*{2}`)},
+	{PIICard, regexp.MustCompile(`\*{4} \*{4} \*{4} \d{4}`)},
+}
+
+// MaskingReport tells which PII categories were masked in a response, how many times, and
+// in which fields, so "[phone_redacted]" in a reply can be traced to the tenant PII rules.
+type MaskingReport struct {
+	Categories []string       `json:"categories"`
+	Counts     map[string]int `json:"counts"`
+	Fields     []string       `json:"fields"`
+}
+
+func NewMaskingReport() *MaskingReport {
+	return &MaskingReport{Categories: []string{}, Counts: map[string]int{}, Fields: []string{}}
+}
+
+// AddString counts the masks in value, found in field.
+func (r *MaskingReport) AddString(field, value string) {
+	found := false
+	for _, marker := range maskMarkers {
+		for _, match := range marker.expression.FindAllStringSubmatch(value, -1) {
+			category := marker.category
+			if category == "" {
+				category = match[1]
+			}
+			if r.Counts[category] == 0 {
+				r.Categories = append(r.Categories, category)
+				sort.Strings(r.Categories)
+			}
+			r.Counts[category]++
+			found = true
+		}
+	}
+	if found && !containsString(r.Fields, field) {
+		r.Fields = append(r.Fields, field)
+	}
+}
+
+// AddJSON counts the masks in every string of payload. Fields are reported as paths under
+// field ("result.sections[0].body").
+func (r *MaskingReport) AddJSON(field string, payload json.RawMessage) {
+	var decoded any
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		r.AddString(field, string(payload))
+		return
+	}
+	r.addValue(field, decoded)
+}
+
+func (r *MaskingReport) addValue(field string, value any) {
+	switch typed := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if field != "" {
+				child = field + "." + key
+			}
+			r.addValue(child, typed[key])
+		}
+	case []any:
+		for index, child := range typed {
+			r.addValue(fmt.Sprintf("%s[%d]", field, index), child)
+		}
+	case string:
+		r.AddString(field, typed)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
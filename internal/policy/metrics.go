@@ -0,0 +1,29 @@
+package policy
+
+import "github.com/iago/extensao-whatsapp-back/internal/metrics"
+
+// Metrics counts requests blocked by the content policy per violation code. A nil
+// *Metrics records nothing.
+type Metrics struct {
+	blocks *metrics.CounterVec
+}
+
+// NewMetrics registers the policy block counter; a nil registry disables it.
+func NewMetrics(registry *metrics.Registry) *Metrics {
+	if registry == nil {
+		return nil
+	}
+	return &Metrics{
+		blocks: registry.Counter("policy_blocks_total", "Requests blocked by the content policy, by violation code.", "code"),
+	}
+}
+
+// Blocked records one blocked request under each of its codes.
+func (m *Metrics) Blocked(codes []string) {
+	if m == nil {
+		return
+	}
+	for _, code := range codes {
+		m.blocks.Inc(code)
+	}
+}
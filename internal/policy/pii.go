@@ -2,32 +2,404 @@ package policy
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
 	"regexp"
 	"strings"
+	"sync/atomic"
 )
 
-var (
-	emailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}\b`)
-	phonePattern = regexp.MustCompile(`(?:\+?\d[\d()\-\s.]{7,}\d)`)
-	cpfPattern   = regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}\-?\d{2}\b`)
-	cnpjPattern  = regexp.MustCompile(`\b\d{2}\.?\d{3}\.?\d{3}/?\d{4}\-?\d{2}\b`)
-	cardPattern  = regexp.MustCompile(`\b(?:\d[ -]*?){13,16}\b`)
+// piiDetector finds one category of PII. Patterns that need a label ("CEP", "RG",
+// "placa", "pix") to tell the value from other numbers capture the label in group 1,
+// which is kept as written.
+type piiDetector struct {
+	category string
+	pattern  *regexp.Regexp
+}
+
+// piiDetectors run in order: the locale-specific ones first, so the phone and card
+// patterns do not swallow their digits.
+var piiDetectors = []piiDetector{
+	{PIIIBAN, regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){3,7}(?: ?[A-Z0-9]{1,3})?\b`)},
+	{PIIPix, regexp.MustCompile(`(?i)(\b(?:chave\s+(?:pix|aleat[oó]ria)|pix)\s*[:=\-]?\s*)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)},
+	{PIIRG, regexp.MustCompile(`\b\d{1,2}\.\d{3}\.\d{3}-[\dXx]\b`)},
+	{PIIRG, regexp.MustCompile(`(?i)(\brg\b\s*(?:n[º°o.]*\s*)?[:\-]?\s*)\d{5,9}-?[\dx]?\b`)},
+	{PIICEP, regexp.MustCompile(`\b\d{5}-\d{3}\b`)},
+	{PIICEP, regexp.MustCompile(`(?i)(\bcep\b\s*:?\s*)\d{8}\b`)},
+	{PIIPlate, regexp.MustCompile(`\b[A-Z]{3}\d[A-Z]\d{2}\b`)},
+	{PIIPlate, regexp.MustCompile(`(?i)(\bplaca\b\s*:?\s*)[a-z]{3}-?\d[a-z0-9]\d{2}\b`)},
+	{PIIEmail, regexp.MustCompile(`(?i)\b[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}\b`)},
+	{PIIPhone, regexp.MustCompile(`(?:\+?\d[\d()\-\s.]{7,}\d)`)},
+	{PIICPF, regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}\-?\d{2}\b`)},
+	{PIICNPJ, regexp.MustCompile(`\b\d{2}\.?\d{3}\.?\d{3}/?\d{4}\-?\d{2}\b`)},
+	{PIICard, regexp.MustCompile(`\b(?:\d[ -]*?){13,16}\b`)},
+}
+
+// replace calls fn with the bounds of every match, outside the label group.
+func (d piiDetector) replace(value string, fn func(start, end int) string) string {
+	matches := d.pattern.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return value
+	}
+	var builder strings.Builder
+	last := 0
+	for _, match := range matches {
+		start := match[0]
+		if len(match) >= 4 && match[3] >= 0 {
+			start = match[3]
+		}
+		builder.WriteString(value[last:start])
+		builder.WriteString(fn(start, match[1]))
+		last = match[1]
+	}
+	builder.WriteString(value[last:])
+	return builder.String()
+}
+
+// PII categories that can be masked.
+const (
+	PIIEmail = "email"
+	PIIPhone = "phone"
+	PIICPF   = "cpf"
+	PIICNPJ  = "cnpj"
+	PIICard  = "card"
+	PIIIBAN  = "iban"
+	PIIRG    = "rg"
+	PIICEP   = "cep"
+	PIIPlate = "plate"
+	PIIPix   = "pix"
 )
 
+var allPIICategories = []string{PIIEmail, PIIPhone, PIICPF, PIICNPJ, PIICard, PIIIBAN, PIIRG, PIICEP, PIIPlate, PIIPix}
+
+// localePIICategories lists the locale-specific categories each supported locale enables;
+// the other categories apply everywhere.
+var localePIICategories = map[string][]string{
+	"pt-br": {PIIRG, PIICEP, PIIPlate, PIIPix},
+	"pt-pt": {PIIIBAN},
+	"en-gb": {PIIIBAN},
+	"en-us": {},
+}
+
+const defaultPIILocale = "pt-br"
+
+// PIIRules selects what a tenant masks. Nil Categories means every category; locale
+// specific ones (IBAN, RG, CEP, plates, PIX keys) only run for the Locales the tenant
+// serves, pt-BR when unset. Allowlisted email domains (subdomains included) and phone
+// numbers, such as the agent's own signature, are kept as written. Mode "pseudonymize"
+// swaps PII for per-conversation tokens instead of masking it. Recognizer "context" checks
+// phone matches against their surroundings before masking them.
+type PIIRules struct {
+	Mode              string   `json:"mode"`
+	Recognizer        string   `json:"recognizer"`
+	Categories        []string `json:"categories"`
+	Locales           []string `json:"locales"`
+	AllowEmailDomains []string `json:"allow_email_domains"`
+	AllowPhones       []string `json:"allow_phones"`
+}
+
+// PIIRulesFile is the on-disk format of PII_RULES_FILE. A tenant entry replaces the
+// default mode, recognizer, categories and locales when it sets them, and adds to the default
+// allowlists.
+type PIIRulesFile struct {
+	Default PIIRules            `json:"default"`
+	Tenants map[string]PIIRules `json:"tenants"`
+}
+
+// PII modes.
+const (
+	PIIModeMask         = "mask"
+	PIIModePseudonymize = "pseudonymize"
+)
+
+type piiMasker struct {
+	pseudonymize bool
+	contextual   bool
+	categories   map[string]bool
+	locales      []string
+	active       map[string]bool
+	emailDomains []string
+	phones       []string
+}
+
+// PIIConfig holds the compiled rules of every tenant.
+type PIIConfig struct {
+	defaults piiMasker
+	tenants  map[string]piiMasker
+}
+
+func CompilePIIRules(file PIIRulesFile) (*PIIConfig, error) {
+	defaults, err := compilePIIRules(file.Default, nil)
+	if err != nil {
+		return nil, err
+	}
+	config := &PIIConfig{defaults: defaults, tenants: make(map[string]piiMasker, len(file.Tenants))}
+	for tenantID, rules := range file.Tenants {
+		masker, err := compilePIIRules(rules, &defaults)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+		config.tenants[strings.TrimSpace(tenantID)] = masker
+	}
+	return config, nil
+}
+
+// LoadPIIRulesFile reads and compiles a JSON PII rules file.
+func LoadPIIRulesFile(path string) (*PIIConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pii rules: %w", err)
+	}
+	var file PIIRulesFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("pii rules: invalid JSON: %w", err)
+	}
+	config, err := CompilePIIRules(file)
+	if err != nil {
+		return nil, fmt.Errorf("pii rules: %w", err)
+	}
+	return config, nil
+}
+
+func compilePIIRules(rules PIIRules, base *piiMasker) (piiMasker, error) {
+	masker := piiMasker{categories: make(map[string]bool, len(allPIICategories))}
+	switch mode := strings.ToLower(strings.TrimSpace(rules.Mode)); mode {
+	case PIIModeMask:
+	case PIIModePseudonymize:
+		masker.pseudonymize = true
+	case "":
+		masker.pseudonymize = base != nil && base.pseudonymize
+	default:
+		return piiMasker{}, fmt.Errorf("unknown mode %q", mode)
+	}
+	switch recognizer := strings.ToLower(strings.TrimSpace(rules.Recognizer)); recognizer {
+	case PIIRecognizerRegex:
+	case PIIRecognizerContext:
+		masker.contextual = true
+	case "":
+		masker.contextual = base != nil && base.contextual
+	default:
+		return piiMasker{}, fmt.Errorf("unknown recognizer %q", recognizer)
+	}
+	switch {
+	case rules.Categories != nil:
+		for _, category := range rules.Categories {
+			category = strings.ToLower(strings.TrimSpace(category))
+			if !containsCategory(category) {
+				return piiMasker{}, fmt.Errorf("unknown category %q", category)
+			}
+			masker.categories[category] = true
+		}
+	case base != nil:
+		for category, enabled := range base.categories {
+			masker.categories[category] = enabled
+		}
+	default:
+		for _, category := range allPIICategories {
+			masker.categories[category] = true
+		}
+	}
+	switch {
+	case rules.Locales != nil:
+		for _, locale := range rules.Locales {
+			locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+			if _, ok := localePIICategories[locale]; !ok {
+				return piiMasker{}, fmt.Errorf("unsupported locale %q", locale)
+			}
+			masker.locales = append(masker.locales, locale)
+		}
+	case base != nil:
+		masker.locales = base.locales
+	default:
+		masker.locales = []string{defaultPIILocale}
+	}
+	masker.active = activePIICategories(masker.categories, masker.locales)
+	if base != nil {
+		masker.emailDomains = append(masker.emailDomains, base.emailDomains...)
+		masker.phones = append(masker.phones, base.phones...)
+	}
+	for _, domain := range rules.AllowEmailDomains {
+		if domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@")); domain != "" {
+			masker.emailDomains = append(masker.emailDomains, domain)
+		}
+	}
+	for _, phone := range rules.AllowPhones {
+		digits := digitsOnly(phone)
+		if len(digits) < 8 {
+			return piiMasker{}, fmt.Errorf("allowlisted phone %q is too short", phone)
+		}
+		masker.phones = append(masker.phones, digits)
+	}
+	return masker, nil
+}
+
+// activePIICategories drops the locale-specific categories none of locales enables.
+func activePIICategories(categories map[string]bool, locales []string) map[string]bool {
+	localeBound := make(map[string]bool)
+	enabled := make(map[string]bool)
+	for locale, list := range localePIICategories {
+		for _, category := range list {
+			localeBound[category] = true
+			for _, selected := range locales {
+				if selected == locale {
+					enabled[category] = true
+				}
+			}
+		}
+	}
+	active := make(map[string]bool, len(categories))
+	for category, on := range categories {
+		if on && (!localeBound[category] || enabled[category]) {
+			active[category] = true
+		}
+	}
+	return active
+}
+
+func containsCategory(category string) bool {
+	for _, known := range allPIICategories {
+		if known == category {
+			return true
+		}
+	}
+	return false
+}
+
+var piiRules atomic.Pointer[PIIConfig]
+
+func init() {
+	config, err := CompilePIIRules(PIIRulesFile{})
+	if err != nil {
+		panic(err)
+	}
+	piiRules.Store(config)
+}
+
+// SetPIIRules replaces the rules used by the masking functions.
+func SetPIIRules(config *PIIConfig) {
+	piiRules.Store(config)
+}
+
+func (c *PIIConfig) masker(tenantID string) piiMasker {
+	if masker, ok := tenantEntry(c.tenants, tenantID); ok {
+		return masker
+	}
+	return c.defaults
+}
+
+// MaskPIIString masks value with the default rules.
 func MaskPIIString(value string) string {
-	masked := emailPattern.ReplaceAllStringFunc(value, func(_ string) string {
-		return "[email_redacted]"
-	})
-	masked = phonePattern.ReplaceAllStringFunc(masked, func(_ string) string {
-		return "[phone_redacted]"
+	return MaskTenantPIIString("", value)
+}
+
+// MaskTenantPIIString masks value with the rules of tenantID.
+func MaskTenantPIIString(tenantID, value string) string {
+	return piiRules.Load().masker(tenantID).mask(value)
+}
+
+// PseudonymizesPII reports whether tenantID swaps PII for conversation pseudonyms.
+func PseudonymizesPII(tenantID string) bool {
+	return piiRules.Load().masker(tenantID).pseudonymize
+}
+
+// ReplaceTenantPIIString swaps each PII match the rules of tenantID select for
+// fn(category, match).
+func ReplaceTenantPIIString(tenantID, value string, fn func(category, match string) string) string {
+	return piiRules.Load().masker(tenantID).replace(value, fn)
+}
+
+// ReplaceTenantPIIJSON applies ReplaceTenantPIIString to every string in payload.
+func ReplaceTenantPIIJSON(tenantID string, payload json.RawMessage, fn func(category, match string) string) json.RawMessage {
+	masker := piiRules.Load().masker(tenantID)
+	return replaceJSON(payload, func(value string) string {
+		return masker.replace(value, fn)
 	})
-	masked = cpfPattern.ReplaceAllString(masked, "***.***.***-**")
-	masked = cnpjPattern.ReplaceAllString(masked, "**.***.***/****-**")
-	masked = cardPattern.ReplaceAllStringFunc(masked, maskCardNumber)
-	return masked
 }
 
+func (m piiMasker) mask(value string) string {
+	return m.replace(value, RedactPII)
+}
+
+// replace swaps every active, not allowlisted match for fn(category, match).
+func (m piiMasker) replace(value string, fn func(category, match string) string) string {
+	replaced := value
+	for _, detector := range piiDetectors {
+		if !m.active[detector.category] {
+			continue
+		}
+		category := detector.category
+		current := replaced
+		replaced = detector.replace(current, func(start, end int) string {
+			match := current[start:end]
+			if (category == PIIEmail && m.allowsEmail(match)) || (category == PIIPhone && m.allowsPhone(match)) {
+				return match
+			}
+			if category == PIIPhone && m.contextual && !isLikelyPhone(current, start, end) {
+				return match
+			}
+			return fn(category, match)
+		})
+	}
+	return replaced
+}
+
+// RedactPII returns the irreversible mask of a category match.
+func RedactPII(category, match string) string {
+	switch category {
+	case PIICPF:
+		return "***.***.***-**"
+	case PIICNPJ:
+		return "**.***.***/****-**"
+	case PIICard:
+		return maskCardNumber(match)
+	default:
+		return "[" + category + "_redacted]"
+	}
+}
+
+func (m piiMasker) allowsEmail(email string) bool {
+	_, domain, _ := strings.Cut(strings.ToLower(email), "@")
+	for _, allowed := range m.emailDomains {
+		if domain == allowed || strings.HasSuffix(domain, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsPhone compares digits only and tolerates a missing country or area code on either
+// side.
+func (m piiMasker) allowsPhone(phone string) bool {
+	digits := digitsOnly(phone)
+	for _, allowed := range m.phones {
+		if strings.HasSuffix(digits, allowed) || (len(digits) >= 8 && strings.HasSuffix(allowed, digits)) {
+			return true
+		}
+	}
+	return false
+}
+
+func digitsOnly(value string) string {
+	digits := make([]rune, 0, len(value))
+	for _, char := range value {
+		if char >= '0' && char <= '9' {
+			digits = append(digits, char)
+		}
+	}
+	return string(digits)
+}
+
+// MaskPIIJSON masks every string in payload with the default rules.
 func MaskPIIJSON(payload json.RawMessage) json.RawMessage {
+	return MaskTenantPIIJSON("", payload)
+}
+
+// MaskTenantPIIJSON masks every string in payload with the rules of tenantID.
+func MaskTenantPIIJSON(tenantID string, payload json.RawMessage) json.RawMessage {
+	return replaceJSON(payload, piiRules.Load().masker(tenantID).mask)
+}
+
+func replaceJSON(payload json.RawMessage, replace func(string) string) json.RawMessage {
 	trimmed := strings.TrimSpace(string(payload))
 	if trimmed == "" {
 		return append(json.RawMessage(nil), payload...)
@@ -35,10 +407,10 @@ func MaskPIIJSON(payload json.RawMessage) json.RawMessage {
 
 	var decoded any
 	if err := json.Unmarshal(payload, &decoded); err != nil {
-		return json.RawMessage(MaskPIIString(string(payload)))
+		return json.RawMessage(replace(string(payload)))
 	}
 
-	sanitized := maskValue(decoded)
+	sanitized := replaceValue(decoded, replace)
 	encoded, err := json.Marshal(sanitized)
 	if err != nil {
 		return append(json.RawMessage(nil), payload...)
@@ -47,38 +419,33 @@ func MaskPIIJSON(payload json.RawMessage) json.RawMessage {
 	return encoded
 }
 
-func maskValue(value any) any {
+func replaceValue(value any, replace func(string) string) any {
 	switch typed := value.(type) {
 	case map[string]any:
 		cloned := make(map[string]any, len(typed))
 		for key, child := range typed {
-			cloned[key] = maskValue(child)
+			cloned[key] = replaceValue(child, replace)
 		}
 		return cloned
 	case []any:
 		cloned := make([]any, 0, len(typed))
 		for _, child := range typed {
-			cloned = append(cloned, maskValue(child))
+			cloned = append(cloned, replaceValue(child, replace))
 		}
 		return cloned
 	case string:
-		return MaskPIIString(typed)
+		return replace(typed)
 	default:
 		return value
 	}
 }
 
 func maskCardNumber(value string) string {
-	digits := make([]rune, 0, len(value))
-	for _, char := range value {
-		if char >= '0' && char <= '9' {
-			digits = append(digits, char)
-		}
-	}
+	digits := digitsOnly(value)
 	if len(digits) < 8 {
 		return "[card_redacted]"
 	}
 
-	last4 := string(digits[len(digits)-4:])
+	last4 := digits[len(digits)-4:]
 	return "**** **** **** " + last4
 }
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PII recognizers. The regex recognizer masks every pattern match; the context one checks
+// the shape of phone candidates and the words around them, so dates, order numbers and
+// protocols stay readable.
+const (
+	PIIRecognizerRegex   = "regex"
+	PIIRecognizerContext = "context"
+)
+
+// piiContextWindow is how many bytes around a match the context recognizer reads.
+const piiContextWindow = 32
+
+var (
+	phoneCuePattern = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(?:tel|telefone|fone|cel|celular|whats|whatsapp|zap|ligar|ligue|liga|contato|ramal|phone|mobile|call)(?:$|[^\p{L}])`)
+	// referenceCuePattern names the numbers the phone regex confuses with phones.
+	referenceCuePattern = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(?:pedido|protocolo|chamado|ticket|order|nota|nf|nfe|boleto|c[oó]digo|rastreio|rastreamento|tracking|fatura|invoice|nsu|transa[cç][aã]o|id|n[º°])(?:$|[^\p{L}])|#\s*$`)
+	phoneDatePattern    = regexp.MustCompile(`^(?:\d{1,2}[.\-]\d{1,2}[.\-]\d{2,4}|\d{4}[.\-]\d{1,2}[.\-]\d{1,2})(?:\s+\d{1,2}(?:[.:h]\d{2})?)?$`)
+	// phoneShapePattern is a number written like a phone: country code, area code in
+	// parentheses, or a 4+4 digit split.
+	phoneShapePattern = regexp.MustCompile(`^(?:\+\d|\d{1,3}\)|\d{2}\s*\)|.*\d{4,5}[\s.\-]\d{4}$)`)
+	documentPattern   = regexp.MustCompile(`^(?:\d{3}\.\d{3}\.\d{3}-\d{2}|\d{2}\.\d{3}\.\d{3}/\d{4}-\d{2})$`)
+)
+
+// isLikelyPhone decides whether the phone match text[start:end] is a phone number. Dates
+// and documents (left to the CPF and CNPJ detectors) never are. Otherwise the nearest cue
+// on the same line before the match wins: a phone word masks it, an order or protocol word
+// keeps it. Without one, a phone word right after the match masks it and digit counts no
+// phone has are kept. Bare numbers of phone length are still masked.
+func isLikelyPhone(text string, start, end int) bool {
+	match := text[start:end]
+	before := lastLine(text[max(0, start-piiContextWindow):start], true)
+	after := lastLine(text[end:min(len(text), end+piiContextWindow)], false)
+
+	if phoneDatePattern.MatchString(match) || documentPattern.MatchString(match) {
+		return false
+	}
+	phoneCue, referenceCue := lastMatch(phoneCuePattern, before), lastMatch(referenceCuePattern, before)
+	switch {
+	case phoneCue > referenceCue:
+		return true
+	case referenceCue > phoneCue:
+		return false
+	case phoneCuePattern.MatchString(after):
+		return true
+	}
+
+	digits := len(digitsOnly(match))
+	switch {
+	case digits < 8 || digits > 13:
+		return false
+	case digits < 10:
+		// Local numbers without area code need the phone shape.
+		return phoneShapePattern.MatchString(match)
+	default:
+		return true
+	}
+}
+
+// lastLine keeps the words around the match: the end of the line before it (tail) or the
+// rest of its sentence.
+func lastLine(value string, tail bool) string {
+	if tail {
+		if index := strings.LastIndexAny(value, "\n;"); index >= 0 {
+			return value[index+1:]
+		}
+		return value
+	}
+	if index := strings.IndexAny(value, "\n;."); index >= 0 {
+		return value[:index]
+	}
+	return value
+}
+
+// lastMatch returns where the last match of pattern in value starts, or -1.
+func lastMatch(pattern *regexp.Regexp, value string) int {
+	matches := pattern.FindAllStringIndex(value, -1)
+	if len(matches) == 0 {
+		return -1
+	}
+	return matches[len(matches)-1][0]
+}
@@ -0,0 +1,153 @@
+package policy
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPIIRulesAllowlistInternalContacts(t *testing.T) {
+	config, err := CompilePIIRules(PIIRulesFile{
+		Default: PIIRules{AllowEmailDomains: []string{"@suporte.com"}},
+		Tenants: map[string]PIIRules{
+			"tenant-a": {AllowEmailDomains: []string{"empresa.com.br"}, AllowPhones: []string{"+55 11 4000-1234"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("compile pii rules: %v", err)
+	}
+	previous := piiRules.Load()
+	SetPIIRules(config)
+	t.Cleanup(func() { SetPIIRules(previous) })
+
+	text := "Att, ana@vendas.empresa.com.br (11) 4000-1234 | cliente joao@gmail.com +55 21 99999-8888 | ajuda@suporte.com"
+	masked := MaskTenantPIIString("tenant-a", text)
+	for _, kept := range []string{"ana@vendas.empresa.com.br", "(11) 4000-1234", "ajuda@suporte.com"} {
+		if !strings.Contains(masked, kept) {
+			t.Fatalf("expected %q to be kept, got %q", kept, masked)
+		}
+	}
+	for _, leaked := range []string{"joao@gmail.com", "99999-8888"} {
+		if strings.Contains(masked, leaked) {
+			t.Fatalf("expected %q to be masked, got %q", leaked, masked)
+		}
+	}
+
+	other := MaskTenantPIIString("tenant-b", text)
+	if strings.Contains(other, "ana@vendas.empresa.com.br") || strings.Contains(other, "4000-1234") {
+		t.Fatalf("expected another tenant to mask tenant-a contacts, got %q", other)
+	}
+	if !strings.Contains(other, "ajuda@suporte.com") {
+		t.Fatalf("expected default allowlist to apply to every tenant, got %q", other)
+	}
+}
+
+func TestPIIRulesSelectCategories(t *testing.T) {
+	config, err := CompilePIIRules(PIIRulesFile{
+		Tenants: map[string]PIIRules{"tenant-a": {Categories: []string{"cpf"}}},
+	})
+	if err != nil {
+		t.Fatalf("compile pii rules: %v", err)
+	}
+	previous := piiRules.Load()
+	SetPIIRules(config)
+	t.Cleanup(func() { SetPIIRules(previous) })
+
+	masked := MaskTenantPIIString("tenant-a", "cpf 123.456.789-00 email ana@gmail.com")
+	if strings.Contains(masked, "123.456.789-00") || !strings.Contains(masked, "ana@gmail.com") {
+		t.Fatalf("expected only cpf to be masked, got %q", masked)
+	}
+
+	if _, err := CompilePIIRules(PIIRulesFile{Default: PIIRules{Categories: []string{"passport"}}}); err == nil {
+		t.Fatalf("expected unknown category to be rejected")
+	}
+}
+
+func TestPIILocaleDetectors(t *testing.T) {
+	config, err := CompilePIIRules(PIIRulesFile{
+		Tenants: map[string]PIIRules{"tenant-pt": {Locales: []string{"pt-PT"}}},
+	})
+	if err != nil {
+		t.Fatalf("compile pii rules: %v", err)
+	}
+	previous := piiRules.Load()
+	SetPIIRules(config)
+	t.Cleanup(func() { SetPIIRules(previous) })
+
+	brazilian := "RG 12.345.678-9, CEP 01310-100, placa BRA2E19, chave pix: 123e4567-e89b-12d3-a456-426614174000, pedido SUP-1234"
+	masked := MaskTenantPIIString("tenant-br", brazilian)
+	for _, leaked := range []string{"12.345.678-9", "01310-100", "BRA2E19", "123e4567-e89b"} {
+		if strings.Contains(masked, leaked) {
+			t.Fatalf("expected %q to be masked, got %q", leaked, masked)
+		}
+	}
+	for _, kept := range []string{"CEP [cep_redacted]", "chave pix: [pix_redacted]", "SUP-1234"} {
+		if !strings.Contains(masked, kept) {
+			t.Fatalf("expected %q in %q", kept, masked)
+		}
+	}
+
+	iban := "IBAN PT50 0002 0123 1234 5678 9015 4"
+	if masked := MaskTenantPIIString("tenant-pt", iban); masked != "IBAN [iban_redacted]" {
+		t.Fatalf("expected iban to be masked for pt-PT, got %q", masked)
+	}
+	if masked := MaskTenantPIIString("tenant-pt", "placa BRA2E19"); masked != "placa BRA2E19" {
+		t.Fatalf("expected brazilian detectors to be off for pt-PT, got %q", masked)
+	}
+
+	if _, err := CompilePIIRules(PIIRulesFile{Default: PIIRules{Locales: []string{"fr-FR"}}}); err == nil {
+		t.Fatalf("expected unsupported locale to be rejected")
+	}
+}
+
+func TestPIIContextRecognizerKeepsDatesAndOrderNumbers(t *testing.T) {
+	config, err := CompilePIIRules(PIIRulesFile{
+		Tenants: map[string]PIIRules{"tenant-ner": {Recognizer: PIIRecognizerContext}},
+	})
+	if err != nil {
+		t.Fatalf("compile pii rules: %v", err)
+	}
+	previous := piiRules.Load()
+	SetPIIRules(config)
+	t.Cleanup(func() { SetPIIRules(previous) })
+
+	text := "Pedido 4455667788 entregue em 12.05.2024; protocolo: 2024-0001-7788. Me chama no whats 11 98888-7777 ou (21) 3333-4444"
+	masked := MaskTenantPIIString("tenant-ner", text)
+	for _, kept := range []string{"Pedido 4455667788", "12.05.2024", "protocolo: 2024-0001-7788"} {
+		if !strings.Contains(masked, kept) {
+			t.Fatalf("expected %q to be kept, got %q", kept, masked)
+		}
+	}
+	for _, leaked := range []string{"98888-7777", "3333-4444"} {
+		if strings.Contains(masked, leaked) {
+			t.Fatalf("expected %q to be masked, got %q", leaked, masked)
+		}
+	}
+	if masked := MaskTenantPIIString("tenant-ner", "cpf 123.456.789-00"); masked != "cpf ***.***.***-**" {
+		t.Fatalf("expected documents to fall through to their detector, got %q", masked)
+	}
+
+	if regex := MaskTenantPIIString("tenant-regex", text); strings.Contains(regex, "4455667788") {
+		t.Fatalf("expected the regex recognizer to keep masking order numbers, got %q", regex)
+	}
+	if _, err := CompilePIIRules(PIIRulesFile{Default: PIIRules{Recognizer: "bert"}}); err == nil {
+		t.Fatalf("expected unknown recognizer to be rejected")
+	}
+}
+
+func TestMaskingReportCountsMasksPerCategoryAndField(t *testing.T) {
+	summary := MaskPIIString("Cliente joao@gmail.com pediu retorno em maria@gmail.com.") + " CPF ***.***.***-**."
+	report := NewMaskingReport()
+	report.AddJSON("result", []byte(`{"summary":`+strconv.Quote(summary)+`,"sections":[{"title":"Pagamento","body":"Cartao **** **** **** 4242"}],"model_id":"local"}`))
+	report.AddString("note", "nada mascarado")
+
+	if strings.Join(report.Categories, ",") != "card,cpf,email" {
+		t.Fatalf("expected sorted categories, got %v", report.Categories)
+	}
+	if report.Counts[PIIEmail] != 2 || report.Counts[PIICPF] != 1 || report.Counts[PIICard] != 1 {
+		t.Fatalf("unexpected counts: %v", report.Counts)
+	}
+	if strings.Join(report.Fields, ",") != "result.sections[0].body,result.summary" {
+		t.Fatalf("unexpected fields: %v", report.Fields)
+	}
+}
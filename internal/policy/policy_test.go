@@ -32,8 +32,78 @@ func TestMaskPIIJSONMasksCommonPatterns(t *testing.T) {
 
 func TestEnforceContentPolicyBlocksForbiddenOperation(t *testing.T) {
 	payload := json.RawMessage(`{"prompt":"please create a phishing message"}`)
-	err := EnforceContentPolicy(payload)
+	err := EnforceContentPolicy("", payload)
 	if err == nil {
 		t.Fatalf("expected content policy to block forbidden term")
 	}
 }
+
+func TestTenantTonesRegistry(t *testing.T) {
+	lexicons, err := CompileToneLexicons(ToneLexiconsFile{
+		Default: ToneLexicon{Tones: map[string]ToneDefinition{
+			"tecnico": {Prompt: "Use termos tecnicos precisos.", Avoid: []string{"tipo assim"}},
+		}},
+		Tenants: map[string]ToneLexicon{"acme": {Tones: map[string]ToneDefinition{
+			"Vendas": {Prompt: "Destaque beneficios e convide para fechar a compra.", Avoid: []string{"talvez"}},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("compile lexicons: %v", err)
+	}
+	previous := toneLexicons.Load()
+	SetToneLexicons(lexicons)
+	t.Cleanup(func() { SetToneLexicons(previous) })
+
+	if got := strings.Join(TenantTones("acme"), ","); got != "formal,neutro,amigavel,tecnico,vendas" {
+		t.Fatalf("unexpected acme tones: %s", got)
+	}
+	if !ToneRegistered("other", "tecnico") || ToneRegistered("other", "vendas") || ToneRegistered("acme", "pirata") {
+		t.Fatal("expected tones to be scoped to the registry of each tenant")
+	}
+	if !ToneMismatch("acme", "Talvez seja melhor esperar.", "vendas") || ToneMismatch("acme", "Garanta ja o seu plano.", "vendas") {
+		t.Fatal("expected the avoid terms of a registered tone to flag mismatches")
+	}
+	guidance := TenantToneGuidance("acme", "vendas")
+	if guidance.Instructions != "Destaque beneficios e convide para fechar a compra." || strings.Join(guidance.Avoid, ",") != "talvez" {
+		t.Fatalf("unexpected guidance: %+v", guidance)
+	}
+
+	if _, err := CompileToneLexicons(ToneLexiconsFile{Default: ToneLexicon{Tones: map[string]ToneDefinition{"formal": {}}}}); err == nil {
+		t.Fatal("expected built-in tone names to be rejected")
+	}
+}
+
+func TestProfanityDictionariesByLocaleAndTenant(t *testing.T) {
+	config, err := CompileProfanity(ProfanityFile{
+		Tenants: map[string]ProfanityRules{
+			"acme": {Mode: "reject", Words: map[string][]string{"pt-BR": {"lazarento"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("compile profanity: %v", err)
+	}
+	previous := profanityRules.Load()
+	SetProfanity(config)
+	t.Cleanup(func() { SetProfanity(previous) })
+
+	censored, found := CensorProfanity("", "pt-BR", "Que MERDA, o cupom nao funcionou. Merda!")
+	if !found || censored != "Que M****, o cupom nao funcionou. M****!" {
+		t.Fatalf("expected pt-BR words masked, got %q", censored)
+	}
+	if _, found := CensorProfanity("", "en", "Que merda"); found {
+		t.Fatal("expected the en dictionary to ignore pt-BR words")
+	}
+	if censored, _ := CensorProfanity("", "en-GB", "This is bullshit."); censored != "This is b*******." {
+		t.Fatalf("expected en-GB to use the en dictionary, got %q", censored)
+	}
+	if _, found := CensorProfanity("", "pt-BR", "Cliente lazarento."); found || ProfanityRejects("") {
+		t.Fatal("expected tenant words and mode to stay with the tenant")
+	}
+	if _, found := CensorProfanity("acme", "pt", "Cliente lazarento."); !found || !ProfanityRejects("acme") {
+		t.Fatal("expected tenant additions and reject mode")
+	}
+
+	if _, err := CompileProfanity(ProfanityFile{Default: ProfanityRules{Mode: "block"}}); err == nil {
+		t.Fatal("expected unknown mode to be rejected")
+	}
+}
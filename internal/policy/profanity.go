@@ -0,0 +1,225 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Profanity modes: replace masks the word ("m****"), reject drops the output.
+const (
+	ProfanityModeReplace = "replace"
+	ProfanityModeReject  = "reject"
+)
+
+// DefaultProfanity are the built-in dictionaries by locale, written without accents.
+var DefaultProfanity = map[string][]string{
+	"pt-BR": {
+		"merda", "porra", "caralho", "puta", "puto", "putaria", "foda", "foder", "fodido", "fdp",
+		"pqp", "vsf", "buceta", "xoxota", "cu", "bosta", "cacete", "arrombado", "piroca", "viado",
+	},
+	"en": {
+		"fuck", "fucking", "fucker", "motherfucker", "wtf", "shit", "shitty", "bullshit", "bitch",
+		"asshole", "bastard", "damn", "crap", "dick", "cunt", "piss",
+	},
+}
+
+// ProfanityRules selects what a tenant does with profanity in model output. Words add to
+// the built-in dictionaries, by locale ("pt-BR", "en"); a dictionary serves every locale
+// of its language. An empty Mode means replace.
+type ProfanityRules struct {
+	Mode  string              `json:"mode"`
+	Words map[string][]string `json:"words"`
+}
+
+// ProfanityFile is the on-disk format of PROFANITY_FILE. Tenant words add to the default
+// ones; an empty tenant Mode keeps the default mode.
+type ProfanityFile struct {
+	Default ProfanityRules            `json:"default"`
+	Tenants map[string]ProfanityRules `json:"tenants"`
+}
+
+type profanityFilter struct {
+	mode string
+	// words maps a language ("pt", "en") to its folded words.
+	words map[string]map[string]struct{}
+}
+
+// ProfanityConfig holds the compiled filters of every tenant.
+type ProfanityConfig struct {
+	defaults profanityFilter
+	tenants  map[string]profanityFilter
+}
+
+func CompileProfanity(file ProfanityFile) (*ProfanityConfig, error) {
+	base := profanityFilter{mode: ProfanityModeReplace, words: map[string]map[string]struct{}{}}
+	base = base.with(ProfanityRules{Words: DefaultProfanity})
+	defaults, err := compileProfanityRules(file.Default, base)
+	if err != nil {
+		return nil, err
+	}
+	config := &ProfanityConfig{defaults: defaults, tenants: make(map[string]profanityFilter, len(file.Tenants))}
+	for tenantID, rules := range file.Tenants {
+		filter, err := compileProfanityRules(rules, defaults)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+		config.tenants[strings.TrimSpace(tenantID)] = filter
+	}
+	return config, nil
+}
+
+// LoadProfanityFile reads and compiles a JSON profanity file.
+func LoadProfanityFile(path string) (*ProfanityConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("profanity: %w", err)
+	}
+	var file ProfanityFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("profanity: invalid JSON: %w", err)
+	}
+	config, err := CompileProfanity(file)
+	if err != nil {
+		return nil, fmt.Errorf("profanity: %w", err)
+	}
+	return config, nil
+}
+
+func compileProfanityRules(rules ProfanityRules, base profanityFilter) (profanityFilter, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(rules.Mode)); mode {
+	case "":
+	case ProfanityModeReplace, ProfanityModeReject:
+		rules.Mode = mode
+	default:
+		return profanityFilter{}, fmt.Errorf("unknown mode %q", rules.Mode)
+	}
+	for _, words := range rules.Words {
+		for _, word := range words {
+			if len(profanityWords(word)) != 1 {
+				return profanityFilter{}, fmt.Errorf("invalid word %q: entries are single words", word)
+			}
+		}
+	}
+	return base.with(rules), nil
+}
+
+// with returns a copy of f with the mode and words of rules added.
+func (f profanityFilter) with(rules ProfanityRules) profanityFilter {
+	merged := profanityFilter{mode: f.mode, words: make(map[string]map[string]struct{}, len(f.words))}
+	if rules.Mode != "" {
+		merged.mode = rules.Mode
+	}
+	for language, words := range f.words {
+		merged.words[language] = make(map[string]struct{}, len(words))
+		for word := range words {
+			merged.words[language][word] = struct{}{}
+		}
+	}
+	for locale, words := range rules.Words {
+		language := localeLanguage(locale)
+		if merged.words[language] == nil {
+			merged.words[language] = make(map[string]struct{}, len(words))
+		}
+		for _, word := range words {
+			merged.words[language][foldProfanity(word)] = struct{}{}
+		}
+	}
+	return merged
+}
+
+var profanityRules atomic.Pointer[ProfanityConfig]
+
+func init() {
+	config, err := CompileProfanity(ProfanityFile{})
+	if err != nil {
+		panic(err)
+	}
+	profanityRules.Store(config)
+}
+
+// SetProfanity replaces the dictionaries and modes used by the output validator.
+func SetProfanity(config *ProfanityConfig) {
+	profanityRules.Store(config)
+}
+
+func (c *ProfanityConfig) filter(tenantID string) profanityFilter {
+	if filter, ok := tenantEntry(c.tenants, tenantID); ok {
+		return filter
+	}
+	return c.defaults
+}
+
+// ProfanityRejects reports whether tenantID drops outputs with profanity instead of
+// masking the words.
+func ProfanityRejects(tenantID string) bool {
+	return profanityRules.Load().filter(tenantID).mode == ProfanityModeReject
+}
+
+// CensorProfanity masks every profane word of text in the dictionary of locale, keeping
+// the first letter, and reports whether it found any. Empty locale means pt-BR.
+func CensorProfanity(tenantID, locale, text string) (string, bool) {
+	words := profanityRules.Load().filter(tenantID).words[localeLanguage(locale)]
+	if len(words) == 0 {
+		return text, false
+	}
+	var builder strings.Builder
+	found := false
+	last := 0
+	for _, span := range profanityWords(text) {
+		if _, profane := words[foldProfanity(text[span[0]:span[1]])]; !profane {
+			continue
+		}
+		found = true
+		builder.WriteString(text[last:span[0]])
+		first, size := utf8.DecodeRuneInString(text[span[0]:span[1]])
+		builder.WriteRune(first)
+		builder.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[span[0]+size:span[1]])))
+		last = span[1]
+	}
+	if !found {
+		return text, false
+	}
+	builder.WriteString(text[last:])
+	return builder.String(), true
+}
+
+// profanityWords returns the byte bounds of the words (letters and digits) of text.
+func profanityWords(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for index, char := range text {
+		isWord := unicode.IsLetter(char) || unicode.IsDigit(char)
+		switch {
+		case isWord && start < 0:
+			start = index
+		case !isWord && start >= 0:
+			spans = append(spans, [2]int{start, index})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
+
+var profanityAccents = strings.NewReplacer("á", "a", "à", "a", "ã", "a", "â", "a", "é", "e", "ê", "e", "í", "i", "ó", "o", "õ", "o", "ô", "o", "ú", "u", "ç", "c")
+
+func foldProfanity(word string) string {
+	return profanityAccents.Replace(strings.ToLower(strings.TrimSpace(word)))
+}
+
+// localeLanguage reduces a locale to its language ("pt-BR" -> "pt"); empty means pt.
+func localeLanguage(locale string) string {
+	language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(locale)), "-")
+	language, _, _ = strings.Cut(language, "_")
+	if language == "" {
+		return "pt"
+	}
+	return language
+}
@@ -0,0 +1,41 @@
+package policy
+
+import (
+	"strings"
+	"sync"
+)
+
+// tenantProfiles maps tenants to the policy profile they share: a key of the "tenants"
+// objects of the policy files whose entries apply to tenants without one of their own.
+var tenantProfiles sync.Map // tenant -> profile
+
+// SetTenantProfile assigns tenantID to profile; an empty profile removes the assignment.
+func SetTenantProfile(tenantID, profile string) {
+	tenantID, profile = strings.TrimSpace(tenantID), strings.TrimSpace(profile)
+	if profile == "" {
+		tenantProfiles.Delete(tenantID)
+		return
+	}
+	tenantProfiles.Store(tenantID, profile)
+}
+
+// TenantProfile returns the policy profile of tenantID, if any.
+func TenantProfile(tenantID string) string {
+	profile, _ := tenantProfiles.Load(tenantID)
+	name, _ := profile.(string)
+	return name
+}
+
+// tenantEntry looks tenantID up in the per-tenant entries of a policy file, falling back
+// to the entry of its profile.
+func tenantEntry[T any](entries map[string]T, tenantID string) (T, bool) {
+	if entry, ok := entries[tenantID]; ok {
+		return entry, true
+	}
+	if profile := TenantProfile(tenantID); profile != "" {
+		entry, ok := entries[profile]
+		return entry, ok
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// WatchKeywordFile reloads the blocked keyword file whenever its modification time changes,
+// checking every interval until ctx ends. A file that fails to load keeps the previous
+// list in use.
+func WatchKeywordFile(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	watchFile(ctx, path, interval, logger, func() error {
+		list, err := LoadKeywordFile(path)
+		if err != nil {
+			return err
+		}
+		SetBlockedKeywords(list)
+		return nil
+	})
+}
+
+// WatchPIIRulesFile reloads the PII rules file the same way.
+func WatchPIIRulesFile(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	watchFile(ctx, path, interval, logger, func() error {
+		rules, err := LoadPIIRulesFile(path)
+		if err != nil {
+			return err
+		}
+		SetPIIRules(rules)
+		return nil
+	})
+}
+
+// WatchToneLexiconsFile reloads the tone lexicons file the same way.
+func WatchToneLexiconsFile(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	watchFile(ctx, path, interval, logger, func() error {
+		lexicons, err := LoadToneLexiconsFile(path)
+		if err != nil {
+			return err
+		}
+		SetToneLexicons(lexicons)
+		return nil
+	})
+}
+
+// WatchProfanityFile reloads the profanity file the same way.
+func WatchProfanityFile(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	watchFile(ctx, path, interval, logger, func() error {
+		config, err := LoadProfanityFile(path)
+		if err != nil {
+			return err
+		}
+		SetProfanity(config)
+		return nil
+	})
+}
+
+func watchFile(ctx context.Context, path string, interval time.Duration, logger *slog.Logger, reload func() error) {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	// The first tick always reloads, covering changes made between the initial load and
+	// the start of the watcher.
+	var lastModTime time.Time
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			logger.WarnContext(ctx, "policy file unavailable", slog.String("path", path), slog.Any("error", err))
+			continue
+		}
+		if !info.ModTime().After(lastModTime) {
+			continue
+		}
+		if err := reload(); err != nil {
+			logger.ErrorContext(ctx, "policy file reload failed", slog.String("path", path), slog.Any("error", err))
+			continue
+		}
+		lastModTime = info.ModTime()
+		logger.InfoContext(ctx, "policy file reloaded", slog.String("path", path))
+	}
+}
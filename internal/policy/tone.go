@@ -0,0 +1,271 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultInformalTerms is the built-in slang that breaks the formal tone.
+var DefaultInformalTerms = []string{"mano", "vlw", "blz", "cara", "bro"}
+
+// BuiltinTones are available to every tenant.
+var BuiltinTones = []string{"formal", "neutro", "amigavel"}
+
+var toneNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// ToneDefinition registers a tenant tone on top of the built-in ones. Prompt is the
+// instruction the model gets for the tone; Avoid lists terms that break it, in the keyword
+// list syntax.
+type ToneDefinition struct {
+	Prompt string   `json:"prompt"`
+	Avoid  []string `json:"avoid"`
+}
+
+// ToneLexicon tunes the tone checks of generated replies. Informal entries break the formal
+// tone and Formal entries the friendly ("amigavel") one. Forbidden phrases, such as
+// competitor names, are never allowed; Required phrases, such as legal disclaimers, must be
+// in every reply suggestion. Entries follow the keyword list syntax: whole words, or "re:"
+// regular expressions. Tones registers extra tones by lowercase name.
+type ToneLexicon struct {
+	Formal    []string                  `json:"formal"`
+	Informal  []string                  `json:"informal"`
+	Forbidden []string                  `json:"forbidden"`
+	Required  []string                  `json:"required"`
+	Tones     map[string]ToneDefinition `json:"tones"`
+}
+
+// ToneLexiconsFile is the on-disk format of TONE_LEXICONS_FILE. Tenant entries add to the
+// default lexicon, and tenant tones replace default tones of the same name; a nil default
+// Informal list keeps the built-in slang.
+type ToneLexiconsFile struct {
+	Default ToneLexicon            `json:"default"`
+	Tenants map[string]ToneLexicon `json:"tenants"`
+}
+
+type toneRules struct {
+	formal    []keywordRule
+	informal  []keywordRule
+	forbidden []keywordRule
+	required  []keywordRule
+	tones     map[string]customTone
+}
+
+type customTone struct {
+	prompt string
+	avoid  []keywordRule
+}
+
+// ToneLexicons holds the compiled lexicons of every tenant.
+type ToneLexicons struct {
+	defaults toneRules
+	tenants  map[string]toneRules
+}
+
+func CompileToneLexicons(file ToneLexiconsFile) (*ToneLexicons, error) {
+	if file.Default.Informal == nil {
+		file.Default.Informal = DefaultInformalTerms
+	}
+	defaults, err := compileToneRules(file.Default, nil)
+	if err != nil {
+		return nil, err
+	}
+	lexicons := &ToneLexicons{defaults: defaults, tenants: make(map[string]toneRules, len(file.Tenants))}
+	for tenantID, lexicon := range file.Tenants {
+		rules, err := compileToneRules(lexicon, &defaults)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+		lexicons.tenants[strings.TrimSpace(tenantID)] = rules
+	}
+	return lexicons, nil
+}
+
+// LoadToneLexiconsFile reads and compiles a JSON tone lexicons file.
+func LoadToneLexiconsFile(path string) (*ToneLexicons, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("tone lexicons: %w", err)
+	}
+	var file ToneLexiconsFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("tone lexicons: invalid JSON: %w", err)
+	}
+	lexicons, err := CompileToneLexicons(file)
+	if err != nil {
+		return nil, fmt.Errorf("tone lexicons: %w", err)
+	}
+	return lexicons, nil
+}
+
+func compileToneRules(lexicon ToneLexicon, base *toneRules) (toneRules, error) {
+	var rules toneRules
+	if base != nil {
+		rules = toneRules{
+			formal:    append([]keywordRule(nil), base.formal...),
+			informal:  append([]keywordRule(nil), base.informal...),
+			forbidden: append([]keywordRule(nil), base.forbidden...),
+			required:  append([]keywordRule(nil), base.required...),
+		}
+	}
+	rules.tones = make(map[string]customTone, len(lexicon.Tones))
+	if base != nil {
+		for name, tone := range base.tones {
+			rules.tones[name] = tone
+		}
+	}
+	for name, definition := range lexicon.Tones {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !toneNamePattern.MatchString(name) || containsString(BuiltinTones, name) {
+			return toneRules{}, fmt.Errorf("tone %q: name must be 2-32 lowercase letters, digits, - or _ and not a built-in tone", name)
+		}
+		avoid, err := compileKeywordEntries(definition.Avoid)
+		if err != nil {
+			return toneRules{}, fmt.Errorf("tone %s: %w", name, err)
+		}
+		rules.tones[name] = customTone{prompt: strings.TrimSpace(definition.Prompt), avoid: avoid}
+	}
+	for _, list := range []struct {
+		name    string
+		entries []string
+		target  *[]keywordRule
+	}{
+		{"formal", lexicon.Formal, &rules.formal},
+		{"informal", lexicon.Informal, &rules.informal},
+		{"forbidden", lexicon.Forbidden, &rules.forbidden},
+		{"required", lexicon.Required, &rules.required},
+	} {
+		compiled, err := compileKeywordEntries(list.entries)
+		if err != nil {
+			return toneRules{}, fmt.Errorf("%s: %w", list.name, err)
+		}
+		*list.target = append(*list.target, compiled...)
+	}
+	return rules, nil
+}
+
+var toneLexicons atomic.Pointer[ToneLexicons]
+
+func init() {
+	lexicons, err := CompileToneLexicons(ToneLexiconsFile{})
+	if err != nil {
+		panic(err)
+	}
+	toneLexicons.Store(lexicons)
+}
+
+// SetToneLexicons replaces the lexicons used by the tone checks.
+func SetToneLexicons(lexicons *ToneLexicons) {
+	toneLexicons.Store(lexicons)
+}
+
+func (l *ToneLexicons) rules(tenantID string) toneRules {
+	if rules, ok := tenantEntry(l.tenants, tenantID); ok {
+		return rules
+	}
+	return l.defaults
+}
+
+// TenantTones lists the tones tenantID may request: the built-in ones, then its registered
+// tones in name order.
+func TenantTones(tenantID string) []string {
+	rules := toneLexicons.Load().rules(tenantID)
+	custom := make([]string, 0, len(rules.tones))
+	for name := range rules.tones {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	return append(append([]string(nil), BuiltinTones...), custom...)
+}
+
+// ToneRegistered reports whether tone is built in or registered for tenantID.
+func ToneRegistered(tenantID, tone string) bool {
+	tone = strings.ToLower(strings.TrimSpace(tone))
+	if containsString(BuiltinTones, tone) {
+		return true
+	}
+	_, ok := toneLexicons.Load().rules(tenantID).tones[tone]
+	return ok
+}
+
+// ToneMismatch reports whether text uses terms that break tone: informal terms in a formal
+// reply, formal terms in a friendly one, or the avoid terms of a registered tone.
+func ToneMismatch(tenantID, text, tone string) bool {
+	rules := toneLexicons.Load().rules(tenantID)
+	switch tone = strings.ToLower(strings.TrimSpace(tone)); tone {
+	case "formal":
+		return firstRuleMatch(rules.informal, text) != ""
+	case "amigavel":
+		return firstRuleMatch(rules.formal, text) != ""
+	}
+	return firstRuleMatch(rules.tones[tone].avoid, text) != ""
+}
+
+// ForbiddenPhrase returns the first forbidden entry text hits, as written in the lexicon.
+func ForbiddenPhrase(tenantID, text string) (string, bool) {
+	entry := firstRuleMatch(toneLexicons.Load().rules(tenantID).forbidden, text)
+	return entry, entry != ""
+}
+
+// MissingRequiredPhrases returns the required entries text lacks, as written in the lexicon.
+func MissingRequiredPhrases(tenantID, text string) []string {
+	var missing []string
+	for _, rule := range toneLexicons.Load().rules(tenantID).required {
+		if !rule.expression.MatchString(text) {
+			missing = append(missing, rule.entry)
+		}
+	}
+	return missing
+}
+
+// ToneGuidance is the lexicon of a tenant as prompt instructions. Regular expressions are
+// left to validation.
+type ToneGuidance struct {
+	Avoid    []string
+	Required []string
+	// Instructions is the prompt of a registered tone; empty for built-in tones.
+	Instructions string
+}
+
+// TenantToneGuidance lists the terms replies in tone must avoid (forbidden phrases and the
+// opposite register, or the avoid terms of a registered tone) and the phrases they must
+// include.
+func TenantToneGuidance(tenantID, tone string) ToneGuidance {
+	rules := toneLexicons.Load().rules(tenantID)
+	avoid := rules.forbidden
+	var instructions string
+	switch tone = strings.ToLower(strings.TrimSpace(tone)); tone {
+	case "formal":
+		avoid = append(append([]keywordRule(nil), avoid...), rules.informal...)
+	case "amigavel":
+		avoid = append(append([]keywordRule(nil), avoid...), rules.formal...)
+	default:
+		custom := rules.tones[tone]
+		avoid = append(append([]keywordRule(nil), avoid...), custom.avoid...)
+		instructions = custom.prompt
+	}
+	return ToneGuidance{Avoid: plainEntries(avoid), Required: plainEntries(rules.required), Instructions: instructions}
+}
+
+func firstRuleMatch(rules []keywordRule, text string) string {
+	for _, rule := range rules {
+		if rule.expression.MatchString(text) {
+			return rule.entry
+		}
+	}
+	return ""
+}
+
+func plainEntries(rules []keywordRule) []string {
+	var entries []string
+	for _, rule := range rules {
+		if !strings.HasPrefix(rule.entry, KeywordRegexPrefix) {
+			entries = append(entries, rule.entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,115 @@
+package quality
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/ai"
+)
+
+const (
+	defaultJudgeWeight  = 0.3
+	defaultJudgeTimeout = 4 * time.Second
+	// maxJudgeContextBytes keeps the judge call cheap; the tail of the context holds the
+	// latest messages.
+	maxJudgeContextBytes = 6000
+)
+
+const judgeInstructions = "You grade the output of a customer support assistant against the conversation context. " +
+	"Return only JSON: {\"relevance\": 0..1, \"groundedness\": 0..1}. relevance: the output addresses the latest " +
+	"customer need. groundedness: every fact, date, price or promise in the output is supported by the context."
+
+// JudgeConfig configures the secondary grading stage. Model should be a cheap model; an
+// empty Model or a nil Client disables the stage.
+type JudgeConfig struct {
+	Client ai.TextGenerator
+	Model  string
+	// Weight is the share of the judge grade in the blended score (0..1); 0 means 0.3.
+	Weight  float64
+	Timeout time.Duration
+}
+
+// Judge asks a model to grade relevance and groundedness of generated text.
+type Judge struct {
+	client  ai.TextGenerator
+	model   string
+	weight  float64
+	timeout time.Duration
+}
+
+func NewJudge(config JudgeConfig) *Judge {
+	if config.Client == nil || strings.TrimSpace(config.Model) == "" {
+		return nil
+	}
+	if config.Weight <= 0 || config.Weight > 1 {
+		config.Weight = defaultJudgeWeight
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultJudgeTimeout
+	}
+	return &Judge{
+		client:  config.Client,
+		model:   strings.TrimSpace(config.Model),
+		weight:  config.Weight,
+		timeout: config.Timeout,
+	}
+}
+
+// JudgeGrade is the judge verdict, each axis in 0..1.
+type JudgeGrade struct {
+	Relevance    float64
+	Groundedness float64
+}
+
+func (g JudgeGrade) score() float64 {
+	return (g.Relevance + g.Groundedness) / 2
+}
+
+// Grade asks the judge model about output, the text shown to the agent, given the
+// context the generation was built from.
+func (j *Judge) Grade(ctx context.Context, task ai.TaskKind, contextText, output string) (JudgeGrade, error) {
+	if len(contextText) > maxJudgeContextBytes {
+		contextText = contextText[len(contextText)-maxJudgeContextBytes:]
+	}
+	ctx, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	result, err := j.client.Generate(ctx, ai.GenerateRequest{
+		Model:           j.model,
+		Instructions:    judgeInstructions,
+		Input:           "Task: " + string(task) + "\n\nContext:\n" + contextText + "\n\nOutput:\n" + output,
+		Temperature:     0,
+		MaxOutputTokens: 60,
+	})
+	if err != nil {
+		return JudgeGrade{}, fmt.Errorf("judge call: %w", err)
+	}
+
+	var verdict struct {
+		Relevance    *float64 `json:"relevance"`
+		Groundedness *float64 `json:"groundedness"`
+	}
+	text := strings.TrimSpace(result.Text)
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		text = text[start : end+1]
+	}
+	if err := json.Unmarshal([]byte(text), &verdict); err != nil {
+		return JudgeGrade{}, fmt.Errorf("judge verdict: %w", err)
+	}
+	if verdict.Relevance == nil || verdict.Groundedness == nil {
+		return JudgeGrade{}, errors.New("judge verdict: missing relevance or groundedness")
+	}
+	return JudgeGrade{
+		Relevance:    clamp01(*verdict.Relevance),
+		Groundedness: clamp01(*verdict.Groundedness),
+	}, nil
+}
+
+// Blend mixes the judge grade into a rule-based score.
+func (j *Judge) Blend(score float64, grade JudgeGrade) float64 {
+	return round2(clamp01(score*(1-j.weight) + grade.score()*j.weight))
+}
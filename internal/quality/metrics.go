@@ -0,0 +1,48 @@
+package quality
+
+import "github.com/iago/extensao-whatsapp-back/internal/metrics"
+
+// scoreBuckets split quality scores in tenths; validation rejects below 0.45.
+var scoreBuckets = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1}
+
+// Metrics records validation outcomes per task and prompt version, so a prompt change that
+// lowers scores or raises corrections shows on dashboards. A nil *Metrics records nothing.
+type Metrics struct {
+	scores    *metrics.HistogramVec
+	outputs   *metrics.CounterVec
+	corrected *metrics.CounterVec
+	rejected  *metrics.CounterVec
+}
+
+// NewMetrics registers the quality metrics; a nil registry disables them.
+func NewMetrics(registry *metrics.Registry) *Metrics {
+	if registry == nil {
+		return nil
+	}
+	return &Metrics{
+		scores:    registry.Histogram("quality_score", "Final quality score of accepted generations by task and prompt version.", scoreBuckets, "task", "prompt_version"),
+		outputs:   registry.Counter("quality_outputs_total", "Generations accepted by the output validator by task and prompt version.", "task", "prompt_version"),
+		corrected: registry.Counter("quality_corrected_total", "Accepted generations the validator had to fix or penalize, by task and prompt version.", "task", "prompt_version"),
+		rejected:  registry.Counter("quality_rejected_total", "Generations rejected by the output validator (fallback served) by task and prompt version.", "task", "prompt_version"),
+	}
+}
+
+// Accepted records a generation that passed validation with its final score.
+func (m *Metrics) Accepted(task, promptVersion string, score float64, corrected bool) {
+	if m == nil {
+		return
+	}
+	m.scores.Observe(score, task, promptVersion)
+	m.outputs.Inc(task, promptVersion)
+	if corrected {
+		m.corrected.Inc(task, promptVersion)
+	}
+}
+
+// Rejected records a generation that failed validation.
+func (m *Metrics) Rejected(task, promptVersion string) {
+	if m == nil {
+		return
+	}
+	m.rejected.Inc(task, promptVersion)
+}
@@ -1,6 +1,7 @@
 package quality
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,32 +17,124 @@ var ErrQualityRejected = errors.New("output failed quality checks")
 const (
 	minSuggestionScore = 0.45
 	minStructuredScore = 0.50
+
+	defaultSuggestionMaxLen = 320
 )
 
 type SuggestionCandidate struct {
 	Rank      int
 	Content   string
 	Rationale string
+	// Toxicity is 0 (courteous) to 1 (abusive). On input it carries the model score, if
+	// any; on output the higher of that and the wordlist score.
+	Toxicity float64
 }
 
 type SuggestionValidationInput struct {
+	// TenantID selects the tenant's PII masking rules.
+	TenantID    string
 	Locale      string
 	Tone        string
 	Suggestions []SuggestionCandidate
+	// MaxContentLen caps each suggestion, terminal punctuation included; 0 means 320.
+	MaxContentLen int
+	// RecentReplies are replies the agent already sent; candidates repeating them are dropped.
+	RecentReplies []string
 }
 
 type SuggestionValidationResult struct {
 	Suggestions []SuggestionCandidate
 	Score       float64
 	Corrected   bool
+	// Toxicity is the highest toxicity among the returned suggestions.
+	Toxicity float64
 }
 
-type OutputValidator struct{}
+type OutputValidator struct {
+	judge    *Judge
+	toxicity *ToxicityModel
+}
 
 func NewOutputValidator() *OutputValidator {
 	return &OutputValidator{}
 }
 
+// UseJudge enables the LLM-as-judge stage; nil turns it off.
+func (v *OutputValidator) UseJudge(judge *Judge) {
+	v.judge = judge
+}
+
+// UseToxicityModel adds a model score to the toxicity wordlists; nil turns it off.
+func (v *OutputValidator) UseToxicityModel(model *ToxicityModel) {
+	v.toxicity = model
+}
+
+// ScoreToxicity rates texts with the toxicity model, for SuggestionCandidate.Toxicity.
+// Without a model it returns nil.
+func (v *OutputValidator) ScoreToxicity(ctx context.Context, texts []string) ([]float64, error) {
+	if v.toxicity == nil || len(texts) == 0 {
+		return nil, nil
+	}
+	return v.toxicity.Score(ctx, texts)
+}
+
+// JudgeSuggestions blends the judge grade of validated suggestions into score. Without a
+// judge the score is returned as is; on a judge failure too, along with the error.
+func (v *OutputValidator) JudgeSuggestions(
+	ctx context.Context,
+	contextText string,
+	suggestions []SuggestionCandidate,
+	score float64,
+) (float64, error) {
+	if v.judge == nil || len(suggestions) == 0 {
+		return score, nil
+	}
+	lines := make([]string, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		lines = append(lines, fmt.Sprintf("%d. %s", suggestion.Rank, suggestion.Content))
+	}
+	grade, err := v.judge.Grade(ctx, ai.TaskSuggestion, contextText, strings.Join(lines, "\n"))
+	if err != nil {
+		return score, err
+	}
+	return v.judge.Blend(score, grade), nil
+}
+
+// JudgeTaskPayload blends the judge grade of a validated report into its quality_score.
+// Other tasks, and every task without a judge, come back unchanged.
+func (v *OutputValidator) JudgeTaskPayload(
+	ctx context.Context,
+	task ai.TaskKind,
+	contextText string,
+	body json.RawMessage,
+) (json.RawMessage, error) {
+	if v.judge == nil || task != ai.TaskReport {
+		return body, nil
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return body, fmt.Errorf("decode report payload: %w", err)
+	}
+	sections, _ := payload["sections"].([]any)
+	lines := make([]string, 0, len(sections))
+	for _, item := range sections {
+		if section, ok := item.(map[string]any); ok {
+			lines = append(lines, fmt.Sprintf("## %v\n%v", section["heading"], section["content"]))
+		}
+	}
+	score, _ := payload["quality_score"].(float64)
+	grade, err := v.judge.Grade(ctx, task, contextText, strings.Join(lines, "\n\n"))
+	if err != nil {
+		return body, err
+	}
+	payload["quality_score"] = v.judge.Blend(score, grade)
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return body, fmt.Errorf("encode report payload: %w", err)
+	}
+	return encoded, nil
+}
+
 func (v *OutputValidator) ValidateSuggestions(
 	input SuggestionValidationInput,
 ) (SuggestionValidationResult, error) {
@@ -54,11 +147,17 @@ func (v *OutputValidator) ValidateSuggestions(
 	if tone == "" {
 		tone = "neutro"
 	}
+	maxLen := input.MaxContentLen
+	if maxLen <= 0 {
+		maxLen = defaultSuggestionMaxLen
+	}
 
 	corrected := false
 	penalty := 0.0
 	seen := make(map[string]struct{}, len(input.Suggestions))
 	output := make([]SuggestionCandidate, 0, 3)
+	// Mildly toxic candidates only fill the slots clean ones leave.
+	demoted := make([]SuggestionCandidate, 0, 3)
 
 	for _, item := range input.Suggestions {
 		content := normalizeText(item.Content)
@@ -68,15 +167,27 @@ func (v *OutputValidator) ValidateSuggestions(
 			continue
 		}
 
-		masked := policy.MaskPIIString(content)
+		masked := policy.MaskTenantPIIString(input.TenantID, content)
 		if masked != content {
 			content = masked
 			corrected = true
 			penalty += 0.05
 		}
+		// Toxicity is scored on the words as the model wrote them.
+		uncensored := content
+		if censored, profane := policy.CensorProfanity(input.TenantID, locale, content); profane {
+			corrected = true
+			if policy.ProfanityRejects(input.TenantID) {
+				penalty += 0.10
+				continue
+			}
+			content = censored
+			penalty += 0.05
+		}
 
-		if len(content) > 320 {
-			content = truncateAtWord(content, 320)
+		if len(content) > maxLen {
+			// Leave room for the terminal punctuation added below.
+			content = truncateAtWord(content, maxLen-1)
 			corrected = true
 			penalty += 0.08
 		}
@@ -85,6 +196,22 @@ func (v *OutputValidator) ValidateSuggestions(
 			corrected = true
 		}
 
+		if _, forbidden := policy.ForbiddenPhrase(input.TenantID, content); forbidden {
+			corrected = true
+			penalty += 0.10
+			continue
+		}
+		withPhrases, ok := withRequiredPhrases(input.TenantID, content, maxLen)
+		if !ok {
+			corrected = true
+			penalty += 0.10
+			continue
+		}
+		if withPhrases != content {
+			content = withPhrases
+			corrected = true
+		}
+
 		key := strings.ToLower(content)
 		if _, exists := seen[key]; exists {
 			corrected = true
@@ -92,8 +219,20 @@ func (v *OutputValidator) ValidateSuggestions(
 			continue
 		}
 		seen[key] = struct{}{}
+		if RepeatsRecentReply(content, input.RecentReplies) {
+			corrected = true
+			penalty += 0.05
+			continue
+		}
+
+		toxicity := math.Max(wordlistToxicity(uncensored), clamp01(item.Toxicity))
+		if toxicity >= toxicityRejectScore {
+			corrected = true
+			penalty += 0.10
+			continue
+		}
 
-		if toneMismatch(content, tone) {
+		if policy.ToneMismatch(input.TenantID, content, tone) {
 			penalty += 0.07
 		}
 		if localeMismatch(content, locale) {
@@ -106,16 +245,35 @@ func (v *OutputValidator) ValidateSuggestions(
 			corrected = true
 		}
 
-		output = append(output, SuggestionCandidate{
-			Rank:      len(output) + 1,
+		candidate := SuggestionCandidate{
 			Content:   content,
 			Rationale: rationale,
-		})
+			Toxicity:  round2(toxicity),
+		}
+		if toxicity >= toxicityDemoteScore {
+			corrected = true
+			demoted = append(demoted, candidate)
+			continue
+		}
+		output = append(output, candidate)
 		if len(output) == 3 {
 			break
 		}
 	}
 
+	maxToxicity := 0.0
+	for _, candidate := range demoted {
+		if len(output) == 3 {
+			break
+		}
+		output = append(output, candidate)
+		penalty += 0.10
+	}
+	for index := range output {
+		output[index].Rank = index + 1
+		maxToxicity = math.Max(maxToxicity, output[index].Toxicity)
+	}
+
 	if len(output) == 0 {
 		return SuggestionValidationResult{}, fmt.Errorf("%w: no valid suggestion candidates", ErrQualityRejected)
 	}
@@ -129,26 +287,63 @@ func (v *OutputValidator) ValidateSuggestions(
 		Suggestions: output,
 		Score:       round2(score),
 		Corrected:   corrected,
+		Toxicity:    maxToxicity,
 	}, nil
 }
 
+// ValidateTaskPayload checks a structured task result, masking PII and profanity with the
+// rules of tenantID. Tenants that reject profanity get ErrQualityRejected instead.
 func (v *OutputValidator) ValidateTaskPayload(
+	tenantID string,
 	task ai.TaskKind,
 	body json.RawMessage,
 	locale string,
 	tone string,
 ) (json.RawMessage, float64, error) {
+	maskPII := func(value string) string {
+		return policy.MaskTenantPIIString(tenantID, value)
+	}
+	profane := false
+	mask := func(value string) string {
+		censored, found := policy.CensorProfanity(tenantID, locale, maskPII(value))
+		profane = profane || found
+		return censored
+	}
+
+	var (
+		result json.RawMessage
+		score  float64
+		err    error
+	)
 	switch task {
 	case ai.TaskSummary:
-		return v.validateSummary(body, locale, tone)
+		result, score, err = v.validateSummary(mask, body, locale, tone)
 	case ai.TaskReport:
-		return v.validateReport(body, locale, tone)
+		result, score, err = v.validateReport(mask, body, locale, tone)
+	case ai.TaskAnalysis:
+		result, score, err = v.validateAnalysis(mask, body)
+	case ai.TaskQuestions:
+		result, score, err = v.validateQuestions(mask, body)
+	case ai.TaskActions:
+		result, score, err = v.validateActionItems(mask, body)
+	case ai.TaskCompose:
+		// Completions are censored or dropped one by one.
+		return v.validateCompletions(tenantID, maskPII, body, locale, tone)
+	case ai.TaskDigest:
+		result, score, err = v.validateDigest(mask, body)
+	case ai.TaskInsights:
+		result, score, err = v.validateInsights(mask, body)
 	default:
 		return nil, 0, fmt.Errorf("%w: unsupported task %s", ErrQualityRejected, task)
 	}
+	if err == nil && profane && policy.ProfanityRejects(tenantID) {
+		return nil, 0, fmt.Errorf("%w: profanity in %s output", ErrQualityRejected, task)
+	}
+	return result, score, err
 }
 
 func (v *OutputValidator) validateSummary(
+	mask func(string) string,
 	body json.RawMessage,
 	locale string,
 	_ string,
@@ -164,7 +359,7 @@ func (v *OutputValidator) validateSummary(
 	}
 
 	penalty := 0.0
-	summary := normalizeText(policy.MaskPIIString(payload.Summary))
+	summary := normalizeText(mask(payload.Summary))
 	if summary == "" {
 		return nil, 0, fmt.Errorf("%w: summary text is empty", ErrQualityRejected)
 	}
@@ -182,7 +377,7 @@ func (v *OutputValidator) validateSummary(
 	actionItems := make([]string, 0, len(payload.ActionItems))
 	seen := make(map[string]struct{}, len(payload.ActionItems))
 	for _, item := range payload.ActionItems {
-		normalized := normalizeText(policy.MaskPIIString(item))
+		normalized := normalizeText(mask(item))
 		if normalized == "" {
 			continue
 		}
@@ -224,6 +419,7 @@ func (v *OutputValidator) validateSummary(
 }
 
 func (v *OutputValidator) validateReport(
+	mask func(string) string,
 	body json.RawMessage,
 	locale string,
 	_ string,
@@ -242,7 +438,7 @@ func (v *OutputValidator) validateReport(
 	}
 
 	penalty := 0.0
-	title := normalizeText(policy.MaskPIIString(payload.Title))
+	title := normalizeText(mask(payload.Title))
 	if title == "" {
 		title = "Relatorio da conversa"
 		penalty += 0.05
@@ -254,8 +450,8 @@ func (v *OutputValidator) validateReport(
 
 	sections := make([]map[string]string, 0, len(payload.Sections))
 	for _, section := range payload.Sections {
-		heading := normalizeText(policy.MaskPIIString(section.Heading))
-		content := normalizeText(policy.MaskPIIString(section.Content))
+		heading := normalizeText(mask(section.Heading))
+		content := normalizeText(mask(section.Content))
 		if heading == "" || content == "" {
 			continue
 		}
@@ -304,6 +500,534 @@ func (v *OutputValidator) validateReport(
 	return encoded, round2(score), nil
 }
 
+var (
+	analysisSentiments = map[string]struct{}{"positivo": {}, "neutro": {}, "negativo": {}}
+	analysisUrgencies  = map[string]struct{}{"baixa": {}, "media": {}, "alta": {}}
+	analysisIntents    = map[string]struct{}{
+		"duvida": {}, "reclamacao": {}, "cancelamento": {}, "compra": {}, "suporte": {},
+		"agendamento": {}, "pagamento": {}, "elogio": {}, "outro": {},
+	}
+)
+
+func (v *OutputValidator) validateAnalysis(mask func(string) string, body json.RawMessage) (json.RawMessage, float64, error) {
+	var payload struct {
+		Sentiment        string   `json:"sentiment"`
+		SentimentScore   float64  `json:"sentiment_score"`
+		Urgency          string   `json:"urgency"`
+		Intent           string   `json:"intent"`
+		IntentConfidence float64  `json:"intent_confidence"`
+		Labels           []string `json:"labels"`
+		Rationale        string   `json:"rationale"`
+		PromptVersion    string   `json:"prompt_version"`
+		ModelID          string   `json:"model_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, 0, fmt.Errorf("%w: decode analysis payload: %v", ErrQualityRejected, err)
+	}
+
+	penalty := 0.0
+	sentiment := normalizeEnum(payload.Sentiment, analysisSentiments, "neutro", &penalty)
+	urgency := normalizeEnum(payload.Urgency, analysisUrgencies, "media", &penalty)
+	intent := normalizeEnum(payload.Intent, analysisIntents, "outro", &penalty)
+
+	sentimentScore := math.Max(-1, math.Min(1, payload.SentimentScore))
+	if (sentiment == "positivo" && sentimentScore < 0) || (sentiment == "negativo" && sentimentScore > 0) {
+		sentimentScore = -sentimentScore
+		penalty += 0.10
+	}
+
+	labels := make([]string, 0, len(payload.Labels))
+	seen := make(map[string]struct{}, len(payload.Labels))
+	for _, label := range payload.Labels {
+		normalized := strings.ToLower(normalizeText(mask(label)))
+		if normalized == "" || len(normalized) > 40 {
+			continue
+		}
+		if _, exists := seen[normalized]; exists {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		labels = append(labels, normalized)
+		if len(labels) >= 5 {
+			break
+		}
+	}
+
+	rationale := normalizeText(mask(payload.Rationale))
+	if len(rationale) > 280 {
+		rationale = truncateAtWord(rationale, 280)
+	}
+
+	score := clamp01(1.0 - penalty)
+	if score < minStructuredScore {
+		return nil, 0, fmt.Errorf("%w: low analysis quality score %.2f", ErrQualityRejected, score)
+	}
+
+	encoded, err := json.Marshal(map[string]any{
+		"sentiment":         sentiment,
+		"sentiment_score":   round2(sentimentScore),
+		"urgency":           urgency,
+		"intent":            intent,
+		"intent_confidence": round2(clamp01(payload.IntentConfidence)),
+		"labels":            labels,
+		"rationale":         rationale,
+		"prompt_version":    payload.PromptVersion,
+		"model_id":          payload.ModelID,
+		"quality_score":     round2(score),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("encode analysis payload: %w", err)
+	}
+	return encoded, round2(score), nil
+}
+
+const (
+	minClarifyingQuestions   = 2
+	maxClarifyingQuestions   = 3
+	maxClarifyingQuestionLen = 200
+)
+
+// validateQuestions keeps 2-3 distinct, PII-masked clarifying questions. A single usable
+// question is accepted with a penalty; none rejects the payload.
+func (v *OutputValidator) validateQuestions(mask func(string) string, body json.RawMessage) (json.RawMessage, float64, error) {
+	var payload struct {
+		Questions     []string `json:"questions"`
+		Rationale     string   `json:"rationale"`
+		PromptVersion string   `json:"prompt_version"`
+		ModelID       string   `json:"model_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, 0, fmt.Errorf("%w: decode questions payload: %v", ErrQualityRejected, err)
+	}
+
+	penalty := 0.0
+	questions := make([]string, 0, maxClarifyingQuestions)
+	seen := make(map[string]struct{}, len(payload.Questions))
+	for _, raw := range payload.Questions {
+		question := normalizeText(mask(raw))
+		if question == "" {
+			continue
+		}
+		if len(question) > maxClarifyingQuestionLen {
+			question = truncateAtWord(question, maxClarifyingQuestionLen)
+			penalty += 0.05
+		}
+		if !strings.HasSuffix(question, "?") {
+			question = strings.TrimRight(question, ".!") + "?"
+			penalty += 0.05
+		}
+		key := strings.ToLower(question)
+		if _, exists := seen[key]; exists {
+			penalty += 0.05
+			continue
+		}
+		seen[key] = struct{}{}
+		questions = append(questions, question)
+		if len(questions) >= maxClarifyingQuestions {
+			break
+		}
+	}
+	if len(questions) == 0 {
+		return nil, 0, fmt.Errorf("%w: no valid clarifying questions", ErrQualityRejected)
+	}
+	if len(questions) < minClarifyingQuestions {
+		penalty += 0.20
+	}
+
+	rationale := normalizeText(mask(payload.Rationale))
+	if len(rationale) > 280 {
+		rationale = truncateAtWord(rationale, 280)
+	}
+
+	score := clamp01(1.0 - penalty)
+	if score < minStructuredScore {
+		return nil, 0, fmt.Errorf("%w: low questions quality score %.2f", ErrQualityRejected, score)
+	}
+
+	encoded, err := json.Marshal(map[string]any{
+		"questions":      questions,
+		"rationale":      rationale,
+		"prompt_version": payload.PromptVersion,
+		"model_id":       payload.ModelID,
+		"quality_score":  round2(score),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("encode questions payload: %w", err)
+	}
+	return encoded, round2(score), nil
+}
+
+const (
+	maxCompletions   = 3
+	maxCompletionLen = 240
+)
+
+// validateCompletions keeps up to 3 distinct, PII-masked draft continuations.
+func (v *OutputValidator) validateCompletions(
+	tenantID string,
+	mask func(string) string,
+	body json.RawMessage,
+	locale string,
+	tone string,
+) (json.RawMessage, float64, error) {
+	var payload struct {
+		Completions   []string `json:"completions"`
+		PromptVersion string   `json:"prompt_version"`
+		ModelID       string   `json:"model_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, 0, fmt.Errorf("%w: decode compose payload: %v", ErrQualityRejected, err)
+	}
+
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	tone = strings.ToLower(strings.TrimSpace(tone))
+	penalty := 0.0
+	completions := make([]string, 0, maxCompletions)
+	seen := make(map[string]struct{}, len(payload.Completions))
+	for _, raw := range payload.Completions {
+		text := normalizeText(mask(raw))
+		if text == "" {
+			penalty += 0.10
+			continue
+		}
+		uncensored := text
+		if censored, profane := policy.CensorProfanity(tenantID, locale, text); profane {
+			if policy.ProfanityRejects(tenantID) {
+				penalty += 0.10
+				continue
+			}
+			text = censored
+			penalty += 0.05
+		}
+		if len(text) > maxCompletionLen {
+			text = truncateAtWord(text, maxCompletionLen)
+			penalty += 0.08
+		}
+		key := strings.ToLower(text)
+		if _, exists := seen[key]; exists {
+			penalty += 0.05
+			continue
+		}
+		seen[key] = struct{}{}
+		switch toxicity := wordlistToxicity(uncensored); {
+		case toxicity >= toxicityRejectScore:
+			penalty += 0.10
+			continue
+		case toxicity >= toxicityDemoteScore:
+			penalty += 0.10
+		}
+		if _, forbidden := policy.ForbiddenPhrase(tenantID, text); forbidden {
+			penalty += 0.10
+			continue
+		}
+		if policy.ToneMismatch(tenantID, text, tone) {
+			penalty += 0.07
+		}
+		if localeMismatch(text, locale) {
+			penalty += 0.07
+		}
+		completions = append(completions, text)
+		if len(completions) >= maxCompletions {
+			break
+		}
+	}
+	if len(completions) == 0 {
+		return nil, 0, fmt.Errorf("%w: no valid completions", ErrQualityRejected)
+	}
+
+	score := clamp01(1.0 - penalty)
+	if score < minSuggestionScore {
+		return nil, 0, fmt.Errorf("%w: low compose quality score %.2f", ErrQualityRejected, score)
+	}
+
+	encoded, err := json.Marshal(map[string]any{
+		"completions":    completions,
+		"prompt_version": payload.PromptVersion,
+		"model_id":       payload.ModelID,
+		"quality_score":  round2(score),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("encode compose payload: %w", err)
+	}
+	return encoded, round2(score), nil
+}
+
+var (
+	insightTones               = map[string]struct{}{"formal": {}, "neutro": {}, "amigavel": {}}
+	insightComplaintSeverities = map[string]struct{}{"baixa": {}, "media": {}, "alta": {}}
+)
+
+const maxInsightEntries = 10
+
+// validateInsights normalizes the contact profile enums and masks PII in commitments and
+// complaints. Empty lists are valid for new or uneventful contacts.
+func (v *OutputValidator) validateInsights(mask func(string) string, body json.RawMessage) (json.RawMessage, float64, error) {
+	var payload struct {
+		PreferredTone string `json:"preferred_tone"`
+		Commitments   []struct {
+			Description string `json:"description"`
+			MadeBy      string `json:"made_by"`
+			DueHint     string `json:"due_hint"`
+		} `json:"commitments"`
+		OpenComplaints []struct {
+			Description string `json:"description"`
+			Severity    string `json:"severity"`
+		} `json:"open_complaints"`
+		PromptVersion string `json:"prompt_version"`
+		ModelID       string `json:"model_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, 0, fmt.Errorf("%w: decode insights payload: %v", ErrQualityRejected, err)
+	}
+
+	penalty := 0.0
+	tone := normalizeEnum(payload.PreferredTone, insightTones, "neutro", &penalty)
+
+	commitments := make([]map[string]string, 0, len(payload.Commitments))
+	seen := make(map[string]struct{}, len(payload.Commitments))
+	for _, item := range payload.Commitments {
+		description := normalizeText(mask(item.Description))
+		if description == "" {
+			penalty += 0.05
+			continue
+		}
+		if len(description) > 200 {
+			description = truncateAtWord(description, 200)
+		}
+		key := strings.ToLower(description)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		dueHint := normalizeText(item.DueHint)
+		if len(dueHint) > 60 {
+			dueHint = truncateAtWord(dueHint, 60)
+		}
+		commitments = append(commitments, map[string]string{
+			"description": description,
+			"made_by":     normalizeEnum(item.MadeBy, actionItemOwners, "unknown", &penalty),
+			"due_hint":    dueHint,
+		})
+		if len(commitments) >= maxInsightEntries {
+			break
+		}
+	}
+
+	complaints := make([]map[string]string, 0, len(payload.OpenComplaints))
+	for _, item := range payload.OpenComplaints {
+		description := normalizeText(mask(item.Description))
+		if description == "" {
+			penalty += 0.05
+			continue
+		}
+		if len(description) > 200 {
+			description = truncateAtWord(description, 200)
+		}
+		complaints = append(complaints, map[string]string{
+			"description": description,
+			"severity":    normalizeEnum(item.Severity, insightComplaintSeverities, "media", &penalty),
+		})
+		if len(complaints) >= maxInsightEntries {
+			break
+		}
+	}
+
+	score := clamp01(1.0 - penalty)
+	if score < minStructuredScore {
+		return nil, 0, fmt.Errorf("%w: low insights quality score %.2f", ErrQualityRejected, score)
+	}
+
+	encoded, err := json.Marshal(map[string]any{
+		"preferred_tone":  tone,
+		"commitments":     commitments,
+		"open_complaints": complaints,
+		"prompt_version":  payload.PromptVersion,
+		"model_id":        payload.ModelID,
+		"quality_score":   round2(score),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("encode insights payload: %w", err)
+	}
+	return encoded, round2(score), nil
+}
+
+const (
+	maxDigestOpenItems  = 20
+	maxDigestHighlights = 8
+)
+
+// validateDigest masks PII and bounds the open items and highlights of a digest.
+func (v *OutputValidator) validateDigest(mask func(string) string, body json.RawMessage) (json.RawMessage, float64, error) {
+	var payload struct {
+		Title     string `json:"title"`
+		OpenItems []struct {
+			ConversationID string `json:"conversation_id"`
+			Description    string `json:"description"`
+		} `json:"open_items"`
+		Highlights    []string `json:"highlights"`
+		PromptVersion string   `json:"prompt_version"`
+		ModelID       string   `json:"model_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, 0, fmt.Errorf("%w: decode digest payload: %v", ErrQualityRejected, err)
+	}
+
+	penalty := 0.0
+	title := normalizeText(mask(payload.Title))
+	if title == "" {
+		title = "Digest do periodo"
+		penalty += 0.05
+	}
+	if len(title) > 120 {
+		title = truncateAtWord(title, 120)
+	}
+
+	openItems := make([]map[string]string, 0, len(payload.OpenItems))
+	for _, item := range payload.OpenItems {
+		description := normalizeText(mask(item.Description))
+		if description == "" {
+			penalty += 0.05
+			continue
+		}
+		if len(description) > 240 {
+			description = truncateAtWord(description, 240)
+		}
+		openItems = append(openItems, map[string]string{
+			"conversation_id": strings.TrimSpace(item.ConversationID),
+			"description":     description,
+		})
+		if len(openItems) >= maxDigestOpenItems {
+			break
+		}
+	}
+
+	highlights := make([]string, 0, len(payload.Highlights))
+	for _, raw := range payload.Highlights {
+		highlight := normalizeText(mask(raw))
+		if highlight == "" {
+			continue
+		}
+		if len(highlight) > 240 {
+			highlight = truncateAtWord(highlight, 240)
+		}
+		highlights = append(highlights, highlight)
+		if len(highlights) >= maxDigestHighlights {
+			break
+		}
+	}
+	if len(openItems) == 0 && len(highlights) == 0 {
+		return nil, 0, fmt.Errorf("%w: empty digest", ErrQualityRejected)
+	}
+
+	score := clamp01(1.0 - penalty)
+	if score < minStructuredScore {
+		return nil, 0, fmt.Errorf("%w: low digest quality score %.2f", ErrQualityRejected, score)
+	}
+
+	encoded, err := json.Marshal(map[string]any{
+		"title":          title,
+		"open_items":     openItems,
+		"highlights":     highlights,
+		"prompt_version": payload.PromptVersion,
+		"model_id":       payload.ModelID,
+		"quality_score":  round2(score),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("encode digest payload: %w", err)
+	}
+	return encoded, round2(score), nil
+}
+
+const maxActionItems = 10
+
+var actionItemOwners = map[string]struct{}{"agent": {}, "customer": {}, "unknown": {}}
+
+// validateActionItems normalizes owners and masks PII in every field. An empty list is
+// valid: not every conversation has pending tasks.
+func (v *OutputValidator) validateActionItems(mask func(string) string, body json.RawMessage) (json.RawMessage, float64, error) {
+	var payload struct {
+		ActionItems []struct {
+			Description   string `json:"description"`
+			Owner         string `json:"owner"`
+			DueHint       string `json:"due_hint"`
+			SourceMessage string `json:"source_message"`
+		} `json:"action_items"`
+		PromptVersion string `json:"prompt_version"`
+		ModelID       string `json:"model_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, 0, fmt.Errorf("%w: decode action items payload: %v", ErrQualityRejected, err)
+	}
+
+	penalty := 0.0
+	items := make([]map[string]string, 0, len(payload.ActionItems))
+	seen := make(map[string]struct{}, len(payload.ActionItems))
+	for _, item := range payload.ActionItems {
+		description := normalizeText(mask(item.Description))
+		if description == "" {
+			penalty += 0.05
+			continue
+		}
+		if len(description) > 200 {
+			description = truncateAtWord(description, 200)
+		}
+		key := strings.ToLower(description)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		dueHint := normalizeText(item.DueHint)
+		if len(dueHint) > 60 {
+			dueHint = truncateAtWord(dueHint, 60)
+		}
+		source := normalizeText(mask(item.SourceMessage))
+		if len(source) > 200 {
+			source = truncateAtWord(source, 200)
+		}
+		items = append(items, map[string]string{
+			"description":    description,
+			"owner":          normalizeEnum(item.Owner, actionItemOwners, "unknown", &penalty),
+			"due_hint":       dueHint,
+			"source_message": source,
+		})
+		if len(items) >= maxActionItems {
+			break
+		}
+	}
+
+	score := clamp01(1.0 - penalty)
+	if score < minStructuredScore {
+		return nil, 0, fmt.Errorf("%w: low action items quality score %.2f", ErrQualityRejected, score)
+	}
+
+	encoded, err := json.Marshal(map[string]any{
+		"action_items":   items,
+		"prompt_version": payload.PromptVersion,
+		"model_id":       payload.ModelID,
+		"quality_score":  round2(score),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("encode action items payload: %w", err)
+	}
+	return encoded, round2(score), nil
+}
+
+var accentFolder = strings.NewReplacer("á", "a", "à", "a", "ã", "a", "â", "a", "é", "e", "ê", "e", "í", "i", "ó", "o", "õ", "o", "ô", "o", "ú", "u", "ç", "c")
+
+// normalizeEnum maps value onto allowed (case/accent-insensitive for common forms),
+// falling back to fallback with a penalty when the model invents a category.
+func normalizeEnum(value string, allowed map[string]struct{}, fallback string, penalty *float64) string {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	normalized = accentFolder.Replace(normalized)
+	if _, ok := allowed[normalized]; ok {
+		return normalized
+	}
+	*penalty += 0.15
+	return fallback
+}
+
 func normalizeText(value string) string {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
@@ -333,23 +1057,19 @@ func hasTerminalPunctuation(value string) bool {
 	return last == '.' || last == '!' || last == '?'
 }
 
-func toneMismatch(value string, tone string) bool {
-	if tone != "formal" {
-		return false
+// withRequiredPhrases appends the tenant's required phrases content lacks. It fails when a
+// missing entry is a regular expression or the result exceeds maxLen.
+func withRequiredPhrases(tenantID, content string, maxLen int) (string, bool) {
+	for _, phrase := range policy.MissingRequiredPhrases(tenantID, content) {
+		if strings.HasPrefix(phrase, policy.KeywordRegexPrefix) {
+			return content, false
+		}
+		if !hasTerminalPunctuation(phrase) {
+			phrase += "."
+		}
+		content += " " + phrase
 	}
-	lowered := strings.ToLower(value)
-	for _, slang := range []string{
-		"mano",
-		"vlw",
-		"blz",
-		"cara",
-		"bro",
-	} {
-		if strings.Contains(lowered, slang) {
-			return true
-		}
-	}
-	return false
+	return content, len(content) <= maxLen
 }
 
 func localeMismatch(value string, locale string) bool {
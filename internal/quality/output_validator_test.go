@@ -1,11 +1,15 @@
 package quality
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
 	"github.com/iago/extensao-whatsapp-back/internal/ai"
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
 )
 
 func TestValidateSuggestionsReturnsScore(t *testing.T) {
@@ -39,7 +43,7 @@ func TestValidateTaskPayloadSummaryMasksPIIAndAddsQualityScore(t *testing.T) {
 		"model_id":"test-model"
 	}`)
 
-	validated, score, err := validator.ValidateTaskPayload(ai.TaskSummary, body, "pt-BR", "neutro")
+	validated, score, err := validator.ValidateTaskPayload("", ai.TaskSummary, body, "pt-BR", "neutro")
 	if err != nil {
 		t.Fatalf("expected summary payload to validate: %v", err)
 	}
@@ -55,3 +59,490 @@ func TestValidateTaskPayloadSummaryMasksPIIAndAddsQualityScore(t *testing.T) {
 		t.Fatalf("expected quality_score in validated payload")
 	}
 }
+
+func TestValidateTaskPayloadAnalysisNormalizesCategories(t *testing.T) {
+	validator := NewOutputValidator()
+	body := json.RawMessage(`{
+		"sentiment":"Negativo",
+		"sentiment_score":0.8,
+		"urgency":"urgentissima",
+		"intent":"cancelamento",
+		"intent_confidence":1.7,
+		"labels":["Cliente irritado","cliente irritado","pedido de cancelamento"],
+		"rationale":"Cliente pediu para cancelar o plano",
+		"prompt_version":"analysis_v1",
+		"model_id":"test-model"
+	}`)
+
+	validated, _, err := validator.ValidateTaskPayload("", ai.TaskAnalysis, body, "pt-BR", "neutro")
+	if err != nil {
+		t.Fatalf("expected analysis payload to validate: %v", err)
+	}
+
+	var decoded struct {
+		Sentiment        string   `json:"sentiment"`
+		SentimentScore   float64  `json:"sentiment_score"`
+		Urgency          string   `json:"urgency"`
+		IntentConfidence float64  `json:"intent_confidence"`
+		Labels           []string `json:"labels"`
+	}
+	if err := json.Unmarshal(validated, &decoded); err != nil {
+		t.Fatalf("decode validated analysis: %v", err)
+	}
+	if decoded.Sentiment != "negativo" || decoded.SentimentScore >= 0 {
+		t.Fatalf("expected negative sentiment with negative score, got %+v", decoded)
+	}
+	if decoded.Urgency != "media" {
+		t.Fatalf("expected unknown urgency to fall back to media, got %q", decoded.Urgency)
+	}
+	if decoded.IntentConfidence != 1 {
+		t.Fatalf("expected intent confidence clamped to 1, got %.2f", decoded.IntentConfidence)
+	}
+	if len(decoded.Labels) != 2 {
+		t.Fatalf("expected deduplicated labels, got %+v", decoded.Labels)
+	}
+}
+
+func TestValidateTaskPayloadQuestionsKeepsDistinctQuestions(t *testing.T) {
+	validator := NewOutputValidator()
+	body := json.RawMessage(`{
+		"questions":["Qual e o numero do pedido","Qual e o numero do pedido?","Quando a compra foi feita?","Qual produto veio errado?","Prefere troca ou reembolso?"],
+		"rationale":"Falta identificar o pedido",
+		"prompt_version":"questions_v1",
+		"model_id":"test-model"
+	}`)
+
+	validated, _, err := validator.ValidateTaskPayload("", ai.TaskQuestions, body, "pt-BR", "neutro")
+	if err != nil {
+		t.Fatalf("expected questions payload to validate: %v", err)
+	}
+
+	var decoded struct {
+		Questions []string `json:"questions"`
+	}
+	if err := json.Unmarshal(validated, &decoded); err != nil {
+		t.Fatalf("decode validated questions: %v", err)
+	}
+	if len(decoded.Questions) != 3 {
+		t.Fatalf("expected 3 distinct questions, got %+v", decoded.Questions)
+	}
+	if decoded.Questions[0] != "Qual e o numero do pedido?" {
+		t.Fatalf("expected question mark appended, got %q", decoded.Questions[0])
+	}
+
+	if _, _, err := validator.ValidateTaskPayload("", ai.TaskQuestions, json.RawMessage(`{"questions":["  "]}`), "pt-BR", "neutro"); err == nil {
+		t.Fatalf("expected empty questions to be rejected")
+	}
+}
+
+func TestValidateTaskPayloadActionItemsNormalizesOwners(t *testing.T) {
+	validator := NewOutputValidator()
+	body := json.RawMessage(`{
+		"action_items":[
+			{"description":"Enviar segunda via do boleto","owner":"Agent","due_hint":"amanha","source_message":"manda o boleto pra joao@example.com"},
+			{"description":"Enviar segunda via do boleto","owner":"agent"},
+			{"description":"","owner":"customer"},
+			{"description":"Confirmar endereco de entrega","owner":"cliente"}
+		],
+		"prompt_version":"action_items_v1",
+		"model_id":"test-model"
+	}`)
+
+	validated, _, err := validator.ValidateTaskPayload("", ai.TaskActions, body, "pt-BR", "neutro")
+	if err != nil {
+		t.Fatalf("expected action items payload to validate: %v", err)
+	}
+
+	var decoded struct {
+		ActionItems []map[string]string `json:"action_items"`
+	}
+	if err := json.Unmarshal(validated, &decoded); err != nil {
+		t.Fatalf("decode validated action items: %v", err)
+	}
+	if len(decoded.ActionItems) != 2 {
+		t.Fatalf("expected 2 deduplicated items, got %+v", decoded.ActionItems)
+	}
+	if decoded.ActionItems[0]["owner"] != "agent" || decoded.ActionItems[1]["owner"] != "unknown" {
+		t.Fatalf("expected normalized owners, got %+v", decoded.ActionItems)
+	}
+	if strings.Contains(decoded.ActionItems[0]["source_message"], "joao@example.com") {
+		t.Fatalf("expected PII masked in source message, got %q", decoded.ActionItems[0]["source_message"])
+	}
+}
+
+func TestValidateSuggestionsHonorsMaxContentLen(t *testing.T) {
+	validator := NewOutputValidator()
+	result, err := validator.ValidateSuggestions(SuggestionValidationInput{
+		Locale:        "pt-BR",
+		Tone:          "neutro",
+		MaxContentLen: 80,
+		Suggestions: []SuggestionCandidate{
+			{Rank: 1, Content: "Recebi sua mensagem e ja estou verificando todos os detalhes do pedido para te dar um retorno completo"},
+			{Rank: 2, Content: "Certo, um momento"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected quick suggestions to validate: %v", err)
+	}
+	for _, suggestion := range result.Suggestions {
+		if len(suggestion.Content) > 80 {
+			t.Fatalf("expected content within 80 chars, got %d: %q", len(suggestion.Content), suggestion.Content)
+		}
+	}
+	if !result.Corrected {
+		t.Fatalf("expected long suggestion to be marked as corrected")
+	}
+}
+
+func TestValidateTaskPayloadComposeDeduplicatesCompletions(t *testing.T) {
+	validator := NewOutputValidator()
+	body := json.RawMessage(`{
+		"completions":["e de 3 dias uteis.","E de 3 dias uteis.","","vai ate sexta-feira."],
+		"prompt_version":"compose_v1",
+		"model_id":"test-model"
+	}`)
+
+	validated, _, err := validator.ValidateTaskPayload("", ai.TaskCompose, body, "pt-BR", "formal")
+	if err != nil {
+		t.Fatalf("expected compose payload to validate: %v", err)
+	}
+
+	var decoded struct {
+		Completions []string `json:"completions"`
+	}
+	if err := json.Unmarshal(validated, &decoded); err != nil {
+		t.Fatalf("decode validated compose: %v", err)
+	}
+	if len(decoded.Completions) != 2 {
+		t.Fatalf("expected 2 distinct completions, got %+v", decoded.Completions)
+	}
+}
+
+func TestValidateTaskPayloadDigestMasksOpenItems(t *testing.T) {
+	validator := NewOutputValidator()
+	body := json.RawMessage(`{
+		"title":"",
+		"open_items":[
+			{"conversation_id":"chat-1","description":"Enviar boleto para joao@example.com"},
+			{"conversation_id":"chat-2","description":""}
+		],
+		"highlights":["Atraso de entregas concentrou as reclamacoes",""],
+		"prompt_version":"digest_v1",
+		"model_id":"test-model"
+	}`)
+
+	validated, _, err := validator.ValidateTaskPayload("", ai.TaskDigest, body, "pt-BR", "neutro")
+	if err != nil {
+		t.Fatalf("expected digest payload to validate: %v", err)
+	}
+
+	var decoded struct {
+		Title      string              `json:"title"`
+		OpenItems  []map[string]string `json:"open_items"`
+		Highlights []string            `json:"highlights"`
+	}
+	if err := json.Unmarshal(validated, &decoded); err != nil {
+		t.Fatalf("decode validated digest: %v", err)
+	}
+	if decoded.Title == "" {
+		t.Fatalf("expected default digest title")
+	}
+	if len(decoded.OpenItems) != 1 || len(decoded.Highlights) != 1 {
+		t.Fatalf("expected empty entries dropped, got %+v", decoded)
+	}
+	if strings.Contains(decoded.OpenItems[0]["description"], "joao@example.com") {
+		t.Fatalf("expected PII masked in open item, got %q", decoded.OpenItems[0]["description"])
+	}
+}
+
+func TestValidateTaskPayloadInsightsNormalizesProfile(t *testing.T) {
+	validator := NewOutputValidator()
+	body := json.RawMessage(`{
+		"preferred_tone":"Amigável",
+		"commitments":[
+			{"description":"Enviar a troca ate sexta","made_by":"Agent","due_hint":"sexta"},
+			{"description":"enviar a troca ate sexta","made_by":"agent"}
+		],
+		"open_complaints":[{"description":"Produto com defeito, contato joao@example.com","severity":"urgente"}],
+		"prompt_version":"insights_v1",
+		"model_id":"test-model"
+	}`)
+
+	validated, _, err := validator.ValidateTaskPayload("", ai.TaskInsights, body, "pt-BR", "neutro")
+	if err != nil {
+		t.Fatalf("expected insights payload to validate: %v", err)
+	}
+
+	var decoded struct {
+		PreferredTone  string              `json:"preferred_tone"`
+		Commitments    []map[string]string `json:"commitments"`
+		OpenComplaints []map[string]string `json:"open_complaints"`
+	}
+	if err := json.Unmarshal(validated, &decoded); err != nil {
+		t.Fatalf("decode validated insights: %v", err)
+	}
+	if decoded.PreferredTone != "amigavel" {
+		t.Fatalf("expected normalized tone, got %q", decoded.PreferredTone)
+	}
+	if len(decoded.Commitments) != 1 || decoded.Commitments[0]["made_by"] != "agent" {
+		t.Fatalf("expected one deduplicated commitment, got %+v", decoded.Commitments)
+	}
+	if decoded.OpenComplaints[0]["severity"] != "media" {
+		t.Fatalf("expected unknown severity mapped to media, got %+v", decoded.OpenComplaints)
+	}
+	if strings.Contains(decoded.OpenComplaints[0]["description"], "joao@example.com") {
+		t.Fatalf("expected PII masked in complaint, got %q", decoded.OpenComplaints[0]["description"])
+	}
+}
+
+type judgeStub struct {
+	text    string
+	request ai.GenerateRequest
+}
+
+func (s *judgeStub) Generate(_ context.Context, request ai.GenerateRequest) (ai.GenerateResult, error) {
+	s.request = request
+	return ai.GenerateResult{Text: s.text}, nil
+}
+
+func (s *judgeStub) Available() bool { return true }
+
+func TestJudgeBlendsGradeIntoQualityScore(t *testing.T) {
+	stub := &judgeStub{text: "```json\n{\"relevance\": 0.2, \"groundedness\": 0.4}\n```"}
+	validator := NewOutputValidator()
+
+	score, err := validator.JudgeSuggestions(context.Background(), "ctx", []SuggestionCandidate{{Rank: 1, Content: "Ok."}}, 0.9)
+	if err != nil || score != 0.9 {
+		t.Fatalf("expected score untouched without a judge, got %.2f err=%v", score, err)
+	}
+
+	validator.UseJudge(NewJudge(JudgeConfig{Client: stub, Model: "cheap-model", Weight: 0.5}))
+	score, err = validator.JudgeSuggestions(context.Background(), "cliente pediu 2a via do boleto", []SuggestionCandidate{{Rank: 1, Content: "Envio a 2a via agora."}}, 0.9)
+	if err != nil || score != 0.6 {
+		t.Fatalf("expected blended score 0.6, got %.2f err=%v", score, err)
+	}
+	if stub.request.Model != "cheap-model" || !strings.Contains(stub.request.Input, "Envio a 2a via agora.") {
+		t.Fatalf("expected judge call with the cheap model and the output, got %+v", stub.request)
+	}
+
+	body := json.RawMessage(`{"title":"t","sections":[{"heading":"h","content":"c"}],"quality_score":0.8}`)
+	judged, err := validator.JudgeTaskPayload(context.Background(), ai.TaskReport, "ctx", body)
+	if err != nil {
+		t.Fatalf("judge report: %v", err)
+	}
+	var decoded map[string]any
+	_ = json.Unmarshal(judged, &decoded)
+	if decoded["quality_score"] != 0.55 {
+		t.Fatalf("expected report score 0.55, got %v", decoded["quality_score"])
+	}
+
+	stub.text = "not json"
+	if _, err := validator.JudgeSuggestions(context.Background(), "ctx", []SuggestionCandidate{{Rank: 1, Content: "Ok."}}, 0.9); err == nil {
+		t.Fatalf("expected invalid verdict to fail")
+	}
+}
+
+func TestValidateSuggestionsDemotesAndRejectsToxicCandidates(t *testing.T) {
+	validator := NewOutputValidator()
+
+	result, err := validator.ValidateSuggestions(SuggestionValidationInput{
+		Locale: "pt-BR",
+		Tone:   "neutro",
+		Suggestions: []SuggestionCandidate{
+			{Rank: 1, Content: "Que pergunta idiota, ja expliquei isso."},
+			{Rank: 2, Content: "Vai se ferrar, nao vou responder."},
+			{Rank: 3, Content: "Claro, vou verificar o pedido agora."},
+			{Rank: 4, Content: "Entendo, ja estou olhando o seu caso.", Toxicity: 0.9},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected suggestions to validate: %v", err)
+	}
+	if len(result.Suggestions) != 2 {
+		t.Fatalf("expected hostile candidates to be dropped, got %+v", result.Suggestions)
+	}
+	if result.Suggestions[0].Content != "Claro, vou verificar o pedido agora." || result.Suggestions[0].Toxicity != 0 {
+		t.Fatalf("expected clean candidate first, got %+v", result.Suggestions)
+	}
+	if result.Suggestions[1].Rank != 2 || result.Suggestions[1].Toxicity != 0.4 || result.Toxicity != 0.4 {
+		t.Fatalf("expected offensive candidate demoted with its score, got %+v", result)
+	}
+	if result.Score >= 0.8 {
+		t.Fatalf("expected toxicity to lower the score, got %.2f", result.Score)
+	}
+}
+
+func TestMetricsRecordScoresCorrectionsAndRejections(t *testing.T) {
+	var disabled *Metrics
+	disabled.Accepted("report", "report_v1", 0.9, true)
+	disabled.Rejected("report", "report_v1")
+
+	registry := metrics.NewRegistry()
+	recorder := NewMetrics(registry)
+	recorder.Accepted("report", "report_v1", 0.92, false)
+	recorder.Accepted("report", "report_v1", 0.64, true)
+	recorder.Rejected("report", "report_v1")
+
+	output := strings.Builder{}
+	if err := registry.WriteText(&output); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	for _, line := range []string{
+		`quality_outputs_total{task="report",prompt_version="report_v1"} 2`,
+		`quality_corrected_total{task="report",prompt_version="report_v1"} 1`,
+		`quality_rejected_total{task="report",prompt_version="report_v1"} 1`,
+		`quality_score_bucket{task="report",prompt_version="report_v1",le="0.7"} 1`,
+		`quality_score_count{task="report",prompt_version="report_v1"} 2`,
+	} {
+		if !strings.Contains(output.String(), line) {
+			t.Fatalf("expected %q in exposition:\n%s", line, output.String())
+		}
+	}
+}
+
+func TestTenantToneLexiconsApplyToSuggestions(t *testing.T) {
+	lexicons, err := policy.CompileToneLexicons(policy.ToneLexiconsFile{
+		Tenants: map[string]policy.ToneLexicon{"acme": {
+			Informal:  []string{"valeu"},
+			Forbidden: []string{"ConcorrenteX"},
+			Required:  []string{"Sujeito aos termos de uso"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("compile lexicons: %v", err)
+	}
+	policy.SetToneLexicons(lexicons)
+	t.Cleanup(func() {
+		defaults, _ := policy.CompileToneLexicons(policy.ToneLexiconsFile{})
+		policy.SetToneLexicons(defaults)
+	})
+
+	candidates := []SuggestionCandidate{
+		{Rank: 1, Content: "Se preferir, fale com a ConcorrenteX."},
+		{Rank: 2, Content: "Valeu pelo contato, vou verificar."},
+		{Rank: 3, Content: "Vou verificar o seu pedido agora."},
+	}
+	result, err := NewOutputValidator().ValidateSuggestions(SuggestionValidationInput{
+		TenantID:    "acme",
+		Locale:      "pt-BR",
+		Tone:        "formal",
+		Suggestions: append([]SuggestionCandidate(nil), candidates...),
+	})
+	if err != nil {
+		t.Fatalf("expected suggestions to validate: %v", err)
+	}
+	if len(result.Suggestions) != 2 || !result.Corrected {
+		t.Fatalf("expected the forbidden phrase to drop a candidate, got %+v", result)
+	}
+	for _, suggestion := range result.Suggestions {
+		if !strings.HasSuffix(suggestion.Content, " Sujeito aos termos de uso.") {
+			t.Fatalf("expected required phrase appended, got %q", suggestion.Content)
+		}
+	}
+	if result.Score != 0.83 {
+		t.Fatalf("expected forbidden phrase and informal term to lower the score, got %.2f", result.Score)
+	}
+
+	other, err := NewOutputValidator().ValidateSuggestions(SuggestionValidationInput{
+		TenantID:    "other",
+		Locale:      "pt-BR",
+		Tone:        "formal",
+		Suggestions: append([]SuggestionCandidate(nil), candidates...),
+	})
+	if err != nil || len(other.Suggestions) != 3 {
+		t.Fatalf("expected other tenants to keep the default lexicon, got %+v (%v)", other, err)
+	}
+
+	guidance := policy.TenantToneGuidance("acme", "formal")
+	if strings.Join(guidance.Avoid, ",") != "ConcorrenteX,mano,vlw,blz,cara,bro,valeu" ||
+		strings.Join(guidance.Required, ",") != "Sujeito aos termos de uso" {
+		t.Fatalf("unexpected prompt guidance: %+v", guidance)
+	}
+}
+
+func TestValidateSuggestionsDropsRecentlySentReplies(t *testing.T) {
+	recent := NewRecentReplies(2)
+	recent.Add("tenant-a", "conv-1", "Ola! Vou verificar o seu pedido agora.")
+	recent.Add("tenant-a", "conv-1", "Seu pedido saiu para entrega.")
+	recent.Add("tenant-a", "conv-1", "Posso ajudar em algo mais?")
+	if got := recent.List("tenant-a", "conv-1"); len(got) != 2 || got[0] != "Seu pedido saiu para entrega." {
+		t.Fatalf("expected the last 2 replies, got %v", got)
+	}
+	if got := recent.List("tenant-b", "conv-1"); len(got) != 0 {
+		t.Fatalf("expected replies scoped to the tenant, got %v", got)
+	}
+
+	result, err := NewOutputValidator().ValidateSuggestions(SuggestionValidationInput{
+		Locale: "pt-BR",
+		Suggestions: []SuggestionCandidate{
+			{Rank: 1, Content: "Seu pedido ja saiu para entrega!"},
+			{Rank: 2, Content: "Posso ajudar em algo mais?"},
+			{Rank: 3, Content: "A previsao de chegada e amanha."},
+		},
+		RecentReplies: recent.List("tenant-a", "conv-1"),
+	})
+	if err != nil {
+		t.Fatalf("expected suggestions to validate: %v", err)
+	}
+	if len(result.Suggestions) != 1 || result.Suggestions[0].Content != "A previsao de chegada e amanha." || !result.Corrected {
+		t.Fatalf("expected exact and near-duplicate replies to be dropped, got %+v", result)
+	}
+
+	if removed := recent.Forget("tenant-a", "conv-1"); removed != 2 {
+		t.Fatalf("expected 2 forgotten replies, got %d", removed)
+	}
+	if NewRecentReplies(0) != nil {
+		t.Fatal("expected a zero limit to disable the tracker")
+	}
+}
+
+func TestProfanityIsReplacedOrRejectedPerTenant(t *testing.T) {
+	config, err := policy.CompileProfanity(policy.ProfanityFile{
+		Tenants: map[string]policy.ProfanityRules{"strict": {Mode: policy.ProfanityModeReject}},
+	})
+	if err != nil {
+		t.Fatalf("compile profanity: %v", err)
+	}
+	policy.SetProfanity(config)
+	t.Cleanup(func() {
+		defaults, _ := policy.CompileProfanity(policy.ProfanityFile{})
+		policy.SetProfanity(defaults)
+	})
+
+	candidates := []SuggestionCandidate{
+		{Rank: 1, Content: "Porra, vou verificar o seu pedido agora."},
+		{Rank: 2, Content: "Vou confirmar o prazo de entrega."},
+	}
+	validator := NewOutputValidator()
+	replaced, err := validator.ValidateSuggestions(SuggestionValidationInput{
+		Locale:      "pt-BR",
+		Suggestions: append([]SuggestionCandidate(nil), candidates...),
+	})
+	if err != nil {
+		t.Fatalf("expected suggestions to validate: %v", err)
+	}
+	if len(replaced.Suggestions) != 2 || replaced.Suggestions[1].Content != "P****, vou verificar o seu pedido agora." {
+		t.Fatalf("expected the profane word to be masked, got %+v", replaced.Suggestions)
+	}
+
+	rejected, err := validator.ValidateSuggestions(SuggestionValidationInput{
+		TenantID:    "strict",
+		Locale:      "pt-BR",
+		Suggestions: append([]SuggestionCandidate(nil), candidates...),
+	})
+	if err != nil {
+		t.Fatalf("expected suggestions to validate: %v", err)
+	}
+	if len(rejected.Suggestions) != 1 || !rejected.Corrected {
+		t.Fatalf("expected the profane candidate to be dropped, got %+v", rejected)
+	}
+
+	summary := []byte(`{"summary":"Cliente disse que o produto e uma merda e pediu reembolso.","action_items":["Abrir reembolso"]}`)
+	body, _, err := validator.ValidateTaskPayload("", ai.TaskSummary, summary, "pt-BR", "neutro")
+	if err != nil || !strings.Contains(string(body), "e uma m****") {
+		t.Fatalf("expected the summary to be censored, got %s err=%v", body, err)
+	}
+	if _, _, err := validator.ValidateTaskPayload("strict", ai.TaskSummary, summary, "pt-BR", "neutro"); !errors.Is(err, ErrQualityRejected) {
+		t.Fatalf("expected the summary to be rejected, got %v", err)
+	}
+}
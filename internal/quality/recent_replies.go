@@ -0,0 +1,169 @@
+package quality
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// recentRepliesTTL forgets conversations nobody replied to in a day.
+	recentRepliesTTL = 24 * time.Hour
+	// maxRecentConversations bounds memory; the stalest conversation goes first.
+	maxRecentConversations = 10000
+	// nearDuplicateSimilarity is the word overlap (Jaccard) from which two replies count as
+	// the same message.
+	nearDuplicateSimilarity = 0.8
+)
+
+// RecentReplies remembers the last replies agents accepted in each conversation, so new
+// suggestions repeating them can be dropped. State is kept in memory only.
+type RecentReplies struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[string]*recentConversation
+	now     func() time.Time
+}
+
+type recentConversation struct {
+	replies   []string
+	updatedAt time.Time
+}
+
+// NewRecentReplies keeps up to limit replies per conversation; limit <= 0 returns nil,
+// which remembers nothing.
+func NewRecentReplies(limit int) *RecentReplies {
+	if limit <= 0 {
+		return nil
+	}
+	return &RecentReplies{limit: limit, entries: make(map[string]*recentConversation), now: time.Now}
+}
+
+func recentKey(tenantID, conversationID string) string {
+	return tenantID + "\x00" + conversationID
+}
+
+// Add records a reply sent in the conversation.
+func (r *RecentReplies) Add(tenantID, conversationID, text string) {
+	text = strings.TrimSpace(text)
+	if r == nil || text == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	key := recentKey(tenantID, conversationID)
+	entry, ok := r.entries[key]
+	if !ok {
+		r.evict(now)
+		entry = &recentConversation{}
+		r.entries[key] = entry
+	}
+	entry.replies = append(entry.replies, text)
+	if len(entry.replies) > r.limit {
+		entry.replies = entry.replies[len(entry.replies)-r.limit:]
+	}
+	entry.updatedAt = now
+}
+
+// evict makes room for a new conversation: expired ones first, then the stalest.
+func (r *RecentReplies) evict(now time.Time) {
+	if len(r.entries) < maxRecentConversations {
+		return
+	}
+	stalestKey, stalestAt := "", now
+	for key, entry := range r.entries {
+		if now.Sub(entry.updatedAt) > recentRepliesTTL {
+			delete(r.entries, key)
+			continue
+		}
+		if entry.updatedAt.Before(stalestAt) {
+			stalestKey, stalestAt = key, entry.updatedAt
+		}
+	}
+	if len(r.entries) >= maxRecentConversations {
+		delete(r.entries, stalestKey)
+	}
+}
+
+// List returns the remembered replies of the conversation, oldest first.
+func (r *RecentReplies) List(tenantID, conversationID string) []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := recentKey(tenantID, conversationID)
+	entry, ok := r.entries[key]
+	if !ok {
+		return nil
+	}
+	if r.now().Sub(entry.updatedAt) > recentRepliesTTL {
+		delete(r.entries, key)
+		return nil
+	}
+	return append([]string(nil), entry.replies...)
+}
+
+// Forget drops the conversation and returns how many replies it held.
+func (r *RecentReplies) Forget(tenantID, conversationID string) int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := recentKey(tenantID, conversationID)
+	entry, ok := r.entries[key]
+	if !ok {
+		return 0
+	}
+	delete(r.entries, key)
+	return len(entry.replies)
+}
+
+// RepeatsRecentReply reports whether content is a near duplicate of one of recent.
+func RepeatsRecentReply(content string, recent []string) bool {
+	if len(recent) == 0 {
+		return false
+	}
+	words := replyWords(content)
+	if len(words) == 0 {
+		return false
+	}
+	for _, reply := range recent {
+		if wordSimilarity(words, replyWords(reply)) >= nearDuplicateSimilarity {
+			return true
+		}
+	}
+	return false
+}
+
+// replyWords folds case and accents and drops punctuation.
+func replyWords(value string) map[string]struct{} {
+	folded := accentFolder.Replace(strings.ToLower(value))
+	fields := strings.FieldsFunc(folded, func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+	})
+	words := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		words[field] = struct{}{}
+	}
+	return words
+}
+
+func wordSimilarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range a {
+		if _, ok := b[word]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
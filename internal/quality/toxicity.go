@@ -0,0 +1,158 @@
+package quality
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/ai"
+)
+
+// Candidates scoring at least toxicityDemoteScore lose rank and score; from
+// toxicityRejectScore on they are dropped.
+const (
+	toxicityDemoteScore = 0.3
+	toxicityRejectScore = 0.7
+
+	defaultToxicityTimeout = 3 * time.Second
+)
+
+// toxicTerms weighs offensive language (insults, profanity) below hostile language
+// (threats, aggression). Terms are written without accents and match whole words.
+var toxicTerms = map[string]float64{
+	"idiota":            0.4,
+	"imbecil":           0.4,
+	"burro":             0.4,
+	"otario":            0.4,
+	"babaca":            0.4,
+	"estupido":          0.4,
+	"retardado":         0.5,
+	"vagabundo":         0.4,
+	"merda":             0.4,
+	"porra":             0.4,
+	"caralho":           0.4,
+	"puta":              0.5,
+	"cala a boca":       0.5,
+	"se vira":           0.3,
+	"idiot":             0.4,
+	"stupid":            0.4,
+	"moron":             0.4,
+	"dumb":              0.3,
+	"shit":              0.4,
+	"fuck":              0.5,
+	"bitch":             0.5,
+	"shut up":           0.5,
+	"vai se foder":      0.8,
+	"vai se ferrar":     0.7,
+	"vai se arrepender": 0.7,
+	"vou te processar":  0.7,
+	"te matar":          1.0,
+	"te pegar":          0.7,
+	"kill you":          1.0,
+	"you will regret":   0.7,
+	"screw you":         0.7,
+}
+
+var toxicPatterns = compileToxicTerms(toxicTerms)
+
+type toxicPattern struct {
+	weight     float64
+	expression *regexp.Regexp
+}
+
+func compileToxicTerms(terms map[string]float64) []toxicPattern {
+	patterns := make([]toxicPattern, 0, len(terms))
+	for term, weight := range terms {
+		words := strings.Fields(term)
+		for index := range words {
+			words[index] = regexp.QuoteMeta(words[index])
+		}
+		patterns = append(patterns, toxicPattern{
+			weight:     weight,
+			expression: regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_])` + strings.Join(words, `\s+`) + `(?:$|[^\p{L}\p{N}_])`),
+		})
+	}
+	return patterns
+}
+
+// wordlistToxicity sums the weights of the toxic terms in value, capped at 1.
+func wordlistToxicity(value string) float64 {
+	folded := accentFolder.Replace(strings.ToLower(value))
+	score := 0.0
+	for _, pattern := range toxicPatterns {
+		if pattern.expression.MatchString(folded) {
+			score += pattern.weight
+		}
+	}
+	return round2(clamp01(score))
+}
+
+// ToxicityConfig configures the optional model that scores toxicity on top of the
+// wordlists. An empty Model or a nil Client disables it.
+type ToxicityConfig struct {
+	Client  ai.TextGenerator
+	Model   string
+	Timeout time.Duration
+}
+
+// ToxicityModel asks a model to rate how offensive or hostile texts are.
+type ToxicityModel struct {
+	client  ai.TextGenerator
+	model   string
+	timeout time.Duration
+}
+
+func NewToxicityModel(config ToxicityConfig) *ToxicityModel {
+	if config.Client == nil || strings.TrimSpace(config.Model) == "" {
+		return nil
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultToxicityTimeout
+	}
+	return &ToxicityModel{client: config.Client, model: strings.TrimSpace(config.Model), timeout: config.Timeout}
+}
+
+const toxicityInstructions = "Rate how offensive, insulting or hostile each numbered text is, from 0 (courteous) " +
+	"to 1 (abusive or threatening). Return only JSON: {\"scores\": [one number per text, in order]}."
+
+// Score returns one score per text, in order.
+func (m *ToxicityModel) Score(ctx context.Context, texts []string) ([]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	lines := make([]string, 0, len(texts))
+	for index, text := range texts {
+		lines = append(lines, fmt.Sprintf("%d. %s", index+1, text))
+	}
+	result, err := m.client.Generate(ctx, ai.GenerateRequest{
+		Model:           m.model,
+		Instructions:    toxicityInstructions,
+		Input:           strings.Join(lines, "\n"),
+		Temperature:     0,
+		MaxOutputTokens: 80,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("toxicity call: %w", err)
+	}
+
+	var verdict struct {
+		Scores []float64 `json:"scores"`
+	}
+	text := strings.TrimSpace(result.Text)
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		text = text[start : end+1]
+	}
+	if err := json.Unmarshal([]byte(text), &verdict); err != nil {
+		return nil, fmt.Errorf("toxicity verdict: %w", err)
+	}
+	if len(verdict.Scores) != len(texts) {
+		return nil, fmt.Errorf("toxicity verdict: expected %d scores, got %d", len(texts), len(verdict.Scores))
+	}
+	for index := range verdict.Scores {
+		verdict.Scores[index] = round2(clamp01(verdict.Scores[index]))
+	}
+	return verdict.Scores, nil
+}
@@ -15,3 +15,23 @@ type Producer interface {
 type Consumer interface {
 	Consume(ctx context.Context, handler func(context.Context, domain.QueueMessage) error) error
 }
+
+// DepthReporter exposes how many messages are waiting to be processed.
+type DepthReporter interface {
+	Depth(ctx context.Context) (int64, error)
+}
+
+// DLQReporter exposes how many messages are in the dead-letter queue.
+type DLQReporter interface {
+	DLQDepth(ctx context.Context) (int64, error)
+}
+
+// FailureHook is called when a consumer settles a message whose handler failed; retried is
+// false when the message went to the dead-letter queue instead.
+type FailureHook func(message domain.QueueMessage, retried bool)
+
+// FailureReporter is implemented by consumers that report how they settle failed
+// messages. Each OnFailure adds a hook, called in order; add them before Consume.
+type FailureReporter interface {
+	OnFailure(hook FailureHook)
+}
@@ -2,24 +2,29 @@ package queue
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/iago/extensao-whatsapp-back/internal/domain"
 )
 
-// LocalQueue is a fallback queue used when Redis is not configured.
+// LocalQueue is a fallback queue used when Redis is not configured. Each priority has its
+// own buffer.
 type LocalQueue struct {
-	ch          chan domain.QueueMessage
-	maxAttempts int
-	logger      *log.Logger
+	high           chan domain.QueueMessage
+	low            chan domain.QueueMessage
+	maxAttempts    int
+	priorityWeight int
+	onFailure      []FailureHook
+	logger         *slog.Logger
 
 	dlqMu sync.Mutex
 	dlq   []domain.QueueMessage
 }
 
-func NewLocalQueue(bufferSize, maxAttempts int, logger *log.Logger) *LocalQueue {
+func NewLocalQueue(bufferSize, maxAttempts int, logger *slog.Logger) *LocalQueue {
 	if bufferSize <= 0 {
 		bufferSize = 512
 	}
@@ -27,18 +32,45 @@ func NewLocalQueue(bufferSize, maxAttempts int, logger *log.Logger) *LocalQueue
 		maxAttempts = 3
 	}
 	return &LocalQueue{
-		ch:          make(chan domain.QueueMessage, bufferSize),
-		maxAttempts: maxAttempts,
-		logger:      logger,
-		dlq:         make([]domain.QueueMessage, 0),
+		high:           make(chan domain.QueueMessage, bufferSize),
+		low:            make(chan domain.QueueMessage, bufferSize),
+		maxAttempts:    maxAttempts,
+		priorityWeight: DefaultPriorityWeight,
+		logger:         logger,
+		dlq:            make([]domain.QueueMessage, 0),
 	}
 }
 
+// UsePriorityWeight sets how many high priority messages are consumed for each low
+// priority one; call it before Consume.
+func (q *LocalQueue) UsePriorityWeight(weight int) {
+	if weight > 0 {
+		q.priorityWeight = weight
+	}
+}
+
+func (q *LocalQueue) OnFailure(hook FailureHook) {
+	q.onFailure = append(q.onFailure, hook)
+}
+
+func (q *LocalQueue) reportFailure(message domain.QueueMessage, retried bool) {
+	for _, hook := range q.onFailure {
+		hook(message, retried)
+	}
+}
+
+func (q *LocalQueue) lane(priority domain.JobPriority) chan domain.QueueMessage {
+	if lane(priority) == domain.JobPriorityLow {
+		return q.low
+	}
+	return q.high
+}
+
 func (q *LocalQueue) Enqueue(ctx context.Context, message domain.QueueMessage) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case q.ch <- message:
+	case q.lane(message.Priority) <- message:
 		return nil
 	}
 }
@@ -48,45 +80,89 @@ func (q *LocalQueue) EnqueueBatch(ctx context.Context, messages []domain.QueueMe
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case q.ch <- message:
+		case q.lane(message.Priority) <- message:
 		}
 	}
 	return nil
 }
 
 func (q *LocalQueue) Consume(ctx context.Context, handler func(context.Context, domain.QueueMessage) error) error {
+	turns := newPriorityTurns(q.priorityWeight)
 	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case message := <-q.ch:
-			err := handler(ctx, message)
-			if err == nil {
-				continue
+		message, err := q.next(ctx, turns)
+		if err != nil {
+			return err
+		}
+		turns.served(lane(message.Priority))
+
+		err = handler(ctx, message)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrReleased) {
+			q.putBack(ctx, message)
+			continue
+		}
+
+		message.Attempt++
+		if message.Attempt >= q.maxAttempts {
+			q.reportFailure(message, false)
+			q.dlqMu.Lock()
+			q.dlq = append(q.dlq, message)
+			q.dlqMu.Unlock()
+			if q.logger != nil {
+				q.logger.WarnContext(ctx, "local queue moved message to DLQ",
+					slog.String("job_id", message.JobID),
+					slog.Int("attempt", message.Attempt),
+					slog.Any("error", err),
+				)
 			}
+			continue
+		}
 
-			message.Attempt++
-			if message.Attempt >= q.maxAttempts {
-				q.dlqMu.Lock()
-				q.dlq = append(q.dlq, message)
-				q.dlqMu.Unlock()
-				if q.logger != nil {
-					q.logger.Printf("local queue moved message to DLQ job_id=%s err=%v", message.JobID, err)
-				}
-				continue
+		q.reportFailure(message, true)
+		delay := retryDelay(err)
+		go func(retryMessage domain.QueueMessage) {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				q.lane(retryMessage.Priority) <- retryMessage
 			}
+		}(message)
+	}
+}
 
-			delay := time.Duration(message.Attempt) * 500 * time.Millisecond
-			go func(retryMessage domain.QueueMessage) {
-				timer := time.NewTimer(delay)
-				defer timer.Stop()
-				select {
-				case <-ctx.Done():
-					return
-				case <-timer.C:
-					q.ch <- retryMessage
-				}
-			}(message)
+// next takes a waiting message from the lane whose turn it is, falling back to the other
+// one, or waits for the first message of either lane.
+func (q *LocalQueue) next(ctx context.Context, turns *priorityTurns) (domain.QueueMessage, error) {
+	for _, priority := range turns.order() {
+		select {
+		case message := <-q.lane(priority):
+			return message, nil
+		default:
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return domain.QueueMessage{}, ctx.Err()
+	case message := <-q.high:
+		return message, nil
+	case message := <-q.low:
+		return message, nil
+	}
+}
+
+// putBack returns a released message to the buffer; when it is full the message is
+// dropped, as a local queue loses its buffer on shutdown anyway.
+func (q *LocalQueue) putBack(ctx context.Context, message domain.QueueMessage) {
+	select {
+	case q.lane(message.Priority) <- message:
+	default:
+		if q.logger != nil {
+			q.logger.WarnContext(ctx, "local queue dropped released message", slog.String("job_id", message.JobID))
 		}
 	}
 }
@@ -96,3 +172,11 @@ func (q *LocalQueue) DLQSize() int {
 	defer q.dlqMu.Unlock()
 	return len(q.dlq)
 }
+
+func (q *LocalQueue) DLQDepth(context.Context) (int64, error) {
+	return int64(q.DLQSize()), nil
+}
+
+func (q *LocalQueue) Depth(context.Context) (int64, error) {
+	return int64(len(q.high) + len(q.low)), nil
+}
@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+func TestLocalQueueWaitsTheRequestedRetryDelay(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	local := NewLocalQueue(8, 3, nil)
+	if err := local.Enqueue(ctx, domain.QueueMessage{JobID: "job-1"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	const delay = 80 * time.Millisecond
+	deliveries := make(chan time.Time, 3)
+	go func() {
+		_ = local.Consume(ctx, func(_ context.Context, message domain.QueueMessage) error {
+			deliveries <- time.Now()
+			if message.Attempt == 0 {
+				return RetryAfter(errors.New("provider unavailable"), delay)
+			}
+			return nil
+		})
+	}()
+
+	first, second := <-deliveries, <-deliveries
+	if gap := second.Sub(first); gap < delay {
+		t.Fatalf("expected the retry after at least %s, got %s", delay, gap)
+	}
+	if local.DLQSize() != 0 {
+		t.Fatalf("expected no DLQ entries, got %d", local.DLQSize())
+	}
+}
+
+func TestLocalQueueWeightsHighPriorityWithoutStarvingLow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	local := NewLocalQueue(16, 3, nil)
+	local.UsePriorityWeight(2)
+	for index := 0; index < 3; index++ {
+		_ = local.Enqueue(ctx, domain.QueueMessage{JobID: "backfill", Priority: domain.JobPriorityLow})
+	}
+	for index := 0; index < 4; index++ {
+		_ = local.Enqueue(ctx, domain.QueueMessage{JobID: "interactive"})
+	}
+
+	var order []domain.JobPriority
+	_ = local.Consume(ctx, func(_ context.Context, message domain.QueueMessage) error {
+		order = append(order, lane(message.Priority))
+		if len(order) == 7 {
+			cancel()
+		}
+		return nil
+	})
+
+	high, low := domain.JobPriorityHigh, domain.JobPriorityLow
+	expected := []domain.JobPriority{high, high, low, high, high, low, low}
+	if len(order) != len(expected) {
+		t.Fatalf("expected %d deliveries, got %v", len(expected), order)
+	}
+	for index := range expected {
+		if order[index] != expected[index] {
+			t.Fatalf("expected %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestLocalQueueCallsEveryFailureHook(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	local := NewLocalQueue(8, 1, nil)
+	settled := make(chan string, 2)
+	local.OnFailure(func(message domain.QueueMessage, retried bool) {
+		if !retried {
+			settled <- "metrics"
+		}
+	})
+	local.OnFailure(func(message domain.QueueMessage, retried bool) {
+		if !retried {
+			settled <- "alerts"
+		}
+	})
+	_ = local.Enqueue(ctx, domain.QueueMessage{JobID: "job-1"})
+	go func() {
+		_ = local.Consume(ctx, func(context.Context, domain.QueueMessage) error {
+			return errors.New("invalid payload")
+		})
+	}()
+
+	if first, second := <-settled, <-settled; first != "metrics" || second != "alerts" {
+		t.Fatalf("expected both hooks in order, got %s and %s", first, second)
+	}
+	if depth, _ := local.DLQDepth(ctx); depth != 1 {
+		t.Fatalf("expected one DLQ entry, got %d", depth)
+	}
+}
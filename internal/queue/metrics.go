@@ -0,0 +1,26 @@
+package queue
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
+)
+
+// RegisterDepthGauge publishes queue_depth, read from reporter at scrape time; a read that
+// fails or takes over two seconds reports NaN. A nil registry registers nothing.
+func RegisterDepthGauge(registry *metrics.Registry, reporter DepthReporter) {
+	if registry == nil {
+		return
+	}
+	registry.GaugeFunc("queue_depth", "Jobs waiting in the queue, both priorities.", func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		depth, err := reporter.Depth(ctx)
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(depth)
+	})
+}
@@ -0,0 +1,46 @@
+package queue
+
+import "github.com/iago/extensao-whatsapp-back/internal/domain"
+
+// DefaultPriorityWeight is how many high priority messages are consumed for each low
+// priority one while both lanes have messages waiting.
+const DefaultPriorityWeight = 4
+
+// priorityTurns decides which lane a consumer reads first. High priority messages go
+// first until weight of them were served in a row; then a waiting low priority message
+// gets its turn. An idle lane never blocks the other.
+type priorityTurns struct {
+	weight     int
+	highStreak int
+}
+
+func newPriorityTurns(weight int) *priorityTurns {
+	if weight <= 0 {
+		weight = DefaultPriorityWeight
+	}
+	return &priorityTurns{weight: weight}
+}
+
+// order returns the lanes in the order they should be read.
+func (t *priorityTurns) order() [2]domain.JobPriority {
+	if t.highStreak >= t.weight {
+		return [2]domain.JobPriority{domain.JobPriorityLow, domain.JobPriorityHigh}
+	}
+	return [2]domain.JobPriority{domain.JobPriorityHigh, domain.JobPriorityLow}
+}
+
+func (t *priorityTurns) served(priority domain.JobPriority) {
+	if priority == domain.JobPriorityLow {
+		t.highStreak = 0
+		return
+	}
+	t.highStreak++
+}
+
+// lane maps a message priority to its lane; anything but low is high.
+func lane(priority domain.JobPriority) domain.JobPriority {
+	if priority == domain.JobPriorityLow {
+		return domain.JobPriorityLow
+	}
+	return domain.JobPriorityHigh
+}
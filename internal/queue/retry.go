@@ -0,0 +1,50 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrReleased marks a message handed back unprocessed, such as a job interrupted by a
+// shutdown. Queues redeliver it without counting an attempt.
+var ErrReleased = errors.New("message released")
+
+// Release wraps err so the queue redelivers the message as is.
+func Release(err error) error {
+	return fmt.Errorf("%w: %w", ErrReleased, err)
+}
+
+// RetryError asks the queue to wait Delay before redelivering a failed message. Handlers
+// own the retry schedule; queues only count attempts and move exhausted messages to the
+// DLQ.
+type RetryError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// RetryAfter wraps err so the message is redelivered after delay.
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryError{Err: err, Delay: delay}
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// retryDelay returns the delay requested by err; failures without one are redelivered
+// right away.
+func retryDelay(err error) time.Duration {
+	var retry *RetryError
+	if errors.As(err, &retry) && retry.Delay > 0 {
+		return retry.Delay
+	}
+	return 0
+}
@@ -2,10 +2,12 @@ package queue
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/iago/extensao-whatsapp-back/internal/domain"
@@ -21,16 +23,38 @@ type StreamsConfig struct {
 	Group       string
 	Consumer    string
 	MaxAttempts int
+	// PriorityWeight is how many high priority messages are read for each low priority
+	// one while both are waiting.
+	PriorityWeight int
+	// ClaimIdle is how long an entry may stay unacked by another consumer before this one
+	// claims it, for consumers that died mid-job. Consumers renew the entries they are
+	// processing well within it, so slow jobs are not claimed twice.
+	ClaimIdle time.Duration
 }
 
-// StreamsQueue implements Producer+Consumer backed by Redis Streams.
+// DefaultClaimIdle is the ClaimIdle of a StreamsConfig that sets none.
+const DefaultClaimIdle = 60 * time.Second
+
+// StreamsQueue implements Producer+Consumer backed by Redis Streams. High priority
+// messages use the configured stream and low priority ones a "<stream>:low" stream.
 type StreamsQueue struct {
-	client      *redis.Client
-	stream      string
-	dlqStream   string
-	group       string
-	consumer    string
-	maxAttempts int
+	client    *redis.Client
+	stream    string
+	lowStream string
+	dlqStream string
+	// delayedSet holds messages waiting for their retry delay, scored by due time.
+	delayedSet     string
+	group          string
+	consumer       string
+	maxAttempts    int
+	priorityWeight int
+	claimIdle      time.Duration
+	onFailure      []FailureHook
+
+	// active holds the entries being handled. Consume may run in several goroutines
+	// sharing the consumer name, and each one reads the pending entries when it starts.
+	activeMu sync.Mutex
+	active   map[string]struct{}
 }
 
 func NewStreamsQueue(ctx context.Context, cfg StreamsConfig) (*StreamsQueue, error) {
@@ -52,6 +76,9 @@ func NewStreamsQueue(ctx context.Context, cfg StreamsConfig) (*StreamsQueue, err
 	if cfg.MaxAttempts <= 0 {
 		cfg.MaxAttempts = 3
 	}
+	if cfg.ClaimIdle <= 0 {
+		cfg.ClaimIdle = DefaultClaimIdle
+	}
 
 	client := redis.NewClient(&redis.Options{
 		Addr:     cfg.Addr,
@@ -64,12 +91,17 @@ func NewStreamsQueue(ctx context.Context, cfg StreamsConfig) (*StreamsQueue, err
 	}
 
 	queue := &StreamsQueue{
-		client:      client,
-		stream:      cfg.Stream,
-		dlqStream:   cfg.DLQStream,
-		group:       cfg.Group,
-		consumer:    cfg.Consumer,
-		maxAttempts: cfg.MaxAttempts,
+		client:         client,
+		stream:         cfg.Stream,
+		lowStream:      cfg.Stream + ":low",
+		dlqStream:      cfg.DLQStream,
+		delayedSet:     cfg.Stream + ":delayed",
+		group:          cfg.Group,
+		consumer:       cfg.Consumer,
+		maxAttempts:    cfg.MaxAttempts,
+		priorityWeight: cfg.PriorityWeight,
+		claimIdle:      cfg.ClaimIdle,
+		active:         make(map[string]struct{}),
 	}
 	if err := queue.ensureGroup(ctx); err != nil {
 		client.Close()
@@ -78,22 +110,79 @@ func NewStreamsQueue(ctx context.Context, cfg StreamsConfig) (*StreamsQueue, err
 	return queue, nil
 }
 
+func (q *StreamsQueue) OnFailure(hook FailureHook) {
+	q.onFailure = append(q.onFailure, hook)
+}
+
+func (q *StreamsQueue) reportFailure(message domain.QueueMessage, retried bool) {
+	for _, hook := range q.onFailure {
+		hook(message, retried)
+	}
+}
+
 func (q *StreamsQueue) Close() error {
 	return q.client.Close()
 }
 
+func (q *StreamsQueue) Ping(ctx context.Context) error {
+	return q.client.Ping(ctx).Err()
+}
+
+// Depth counts stream entries of both priorities; processed messages are deleted, so
+// this is the backlog plus messages being processed. Retries waiting for their delay
+// count too.
+func (q *StreamsQueue) Depth(ctx context.Context) (int64, error) {
+	var depth int64
+	for _, stream := range []string{q.stream, q.lowStream} {
+		length, err := q.client.XLen(ctx, stream).Result()
+		if err != nil {
+			return 0, fmt.Errorf("stream length: %w", err)
+		}
+		depth += length
+	}
+	delayed, err := q.client.ZCard(ctx, q.delayedSet).Result()
+	if err != nil {
+		return 0, fmt.Errorf("delayed retries: %w", err)
+	}
+	return depth + delayed, nil
+}
+
+// DLQDepth returns the length of the dead-letter stream.
+func (q *StreamsQueue) DLQDepth(ctx context.Context) (int64, error) {
+	length, err := q.client.XLen(ctx, q.dlqStream).Result()
+	if err != nil {
+		return 0, fmt.Errorf("dead-letter stream length: %w", err)
+	}
+	return length, nil
+}
+
+// streamFor returns the stream of a message priority.
+func (q *StreamsQueue) streamFor(priority domain.JobPriority) string {
+	if lane(priority) == domain.JobPriorityLow {
+		return q.lowStream
+	}
+	return q.stream
+}
+
+func streamValues(message domain.QueueMessage) map[string]any {
+	return map[string]any{
+		"job_id":          message.JobID,
+		"kind":            string(message.Kind),
+		"tenant_id":       message.TenantID,
+		"conversation_id": message.ConversationID,
+		"payload":         string(message.Payload),
+		"attempt":         message.Attempt,
+		"requested_at":    message.RequestedAt.Format(time.RFC3339Nano),
+		"traceparent":     message.TraceParent,
+		"request_id":      message.RequestID,
+		"priority":        string(lane(message.Priority)),
+	}
+}
+
 func (q *StreamsQueue) Enqueue(ctx context.Context, message domain.QueueMessage) error {
 	_, err := q.client.XAdd(ctx, &redis.XAddArgs{
-		Stream: q.stream,
-		Values: map[string]any{
-			"job_id":          message.JobID,
-			"kind":            string(message.Kind),
-			"tenant_id":       message.TenantID,
-			"conversation_id": message.ConversationID,
-			"payload":         string(message.Payload),
-			"attempt":         message.Attempt,
-			"requested_at":    message.RequestedAt.Format(time.RFC3339Nano),
-		},
+		Stream: q.streamFor(message.Priority),
+		Values: streamValues(message),
 	}).Result()
 	if err != nil {
 		return fmt.Errorf("enqueue to stream: %w", err)
@@ -109,16 +198,8 @@ func (q *StreamsQueue) EnqueueBatch(ctx context.Context, messages []domain.Queue
 	pipeline := q.client.Pipeline()
 	for _, message := range messages {
 		pipeline.XAdd(ctx, &redis.XAddArgs{
-			Stream: q.stream,
-			Values: map[string]any{
-				"job_id":          message.JobID,
-				"kind":            string(message.Kind),
-				"tenant_id":       message.TenantID,
-				"conversation_id": message.ConversationID,
-				"payload":         string(message.Payload),
-				"attempt":         message.Attempt,
-				"requested_at":    message.RequestedAt.Format(time.RFC3339Nano),
-			},
+			Stream: q.streamFor(message.Priority),
+			Values: streamValues(message),
 		})
 	}
 
@@ -132,7 +213,14 @@ func (q *StreamsQueue) Consume(ctx context.Context, handler func(context.Context
 	if err := q.ensureGroup(ctx); err != nil {
 		return err
 	}
+	for _, stream := range []string{q.stream, q.lowStream} {
+		if err := q.consumePending(ctx, stream, handler); err != nil {
+			return err
+		}
+	}
 
+	turns := newPriorityTurns(q.priorityWeight)
+	var nextClaim time.Time
 	for {
 		select {
 		case <-ctx.Done():
@@ -140,14 +228,19 @@ func (q *StreamsQueue) Consume(ctx context.Context, handler func(context.Context
 		default:
 		}
 
-		streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
-			Group:    q.group,
-			Consumer: q.consumer,
-			Streams:  []string{q.stream, ">"},
-			Count:    10,
-			Block:    5 * time.Second,
-		}).Result()
+		if err := q.promoteDue(ctx); err != nil {
+			return err
+		}
+		if now := time.Now(); now.After(nextClaim) {
+			for _, stream := range []string{q.stream, q.lowStream} {
+				if err := q.claimAbandoned(ctx, stream, handler); err != nil {
+					return err
+				}
+			}
+			nextClaim = now.Add(q.claimIdle / 2)
+		}
 
+		streams, err := q.read(ctx, turns)
 		if err != nil {
 			if errors.Is(err, redis.Nil) {
 				continue
@@ -160,51 +253,294 @@ func (q *StreamsQueue) Consume(ctx context.Context, handler func(context.Context
 
 		for _, stream := range streams {
 			for _, item := range stream.Messages {
-				message, parseErr := parseStreamMessage(item)
-				if parseErr != nil {
-					_ = q.sendToDLQ(ctx, domain.QueueMessage{}, item, parseErr.Error())
-					_ = q.ackAndDelete(ctx, item.ID)
-					continue
+				if stream.Stream == q.lowStream {
+					turns.served(domain.JobPriorityLow)
+				} else {
+					turns.served(domain.JobPriorityHigh)
 				}
+				q.handle(ctx, stream.Stream, item, handler)
+			}
+		}
+	}
+}
 
-				handleErr := handler(ctx, message)
-				if handleErr == nil {
-					_ = q.ackAndDelete(ctx, item.ID)
-					continue
-				}
+// read takes one new entry from the stream whose turn it is, falling back to the other
+// one. With both empty it blocks on both for a second, short enough to promote delayed
+// retries close to their due time. Entries are read one at a time so the weighting holds
+// while both streams have a backlog.
+func (q *StreamsQueue) read(ctx context.Context, turns *priorityTurns) ([]redis.XStream, error) {
+	for _, priority := range turns.order() {
+		streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    q.group,
+			Consumer: q.consumer,
+			Streams:  []string{q.streamFor(priority), ">"},
+			Count:    1,
+			Block:    -1,
+		}).Result()
+		if err == nil && len(streams) > 0 && len(streams[0].Messages) > 0 {
+			return streams, nil
+		}
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+	}
+	return q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    q.group,
+		Consumer: q.consumer,
+		Streams:  []string{q.stream, q.lowStream, ">", ">"},
+		Count:    1,
+		Block:    time.Second,
+	}).Result()
+}
 
-				message.Attempt++
-				if message.Attempt >= q.maxAttempts {
-					_ = q.sendToDLQ(ctx, message, item, handleErr.Error())
-					_ = q.ackAndDelete(ctx, item.ID)
-					continue
-				}
+// consumePending handles the entries delivered to this consumer but never acked, left by
+// a process of the same consumer name that stopped mid-job.
+func (q *StreamsQueue) consumePending(
+	ctx context.Context,
+	stream string,
+	handler func(context.Context, domain.QueueMessage) error,
+) error {
+	lastID := "0"
+	for {
+		streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    q.group,
+			Consumer: q.consumer,
+			Streams:  []string{stream, lastID},
+			Count:    10,
+			Block:    -1,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil
+			}
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+			return fmt.Errorf("xreadgroup pending: %w", err)
+		}
+		handled := 0
+		for _, stream := range streams {
+			for _, item := range stream.Messages {
+				q.handle(ctx, stream.Stream, item, handler)
+				lastID = item.ID
+				handled++
+			}
+		}
+		if handled == 0 {
+			return nil
+		}
+	}
+}
 
-				if requeueErr := q.Enqueue(ctx, message); requeueErr != nil {
-					_ = q.sendToDLQ(ctx, message, item, fmt.Sprintf("requeue failed: %v", requeueErr))
-				}
-				_ = q.ackAndDelete(ctx, item.ID)
+// claimAbandoned takes over the entries other consumers left unacked for longer than
+// ClaimIdle, usually because their process died mid-job, and handles them.
+func (q *StreamsQueue) claimAbandoned(
+	ctx context.Context,
+	stream string,
+	handler func(context.Context, domain.QueueMessage) error,
+) error {
+	start := "0-0"
+	for {
+		items, next, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    q.group,
+			Consumer: q.consumer,
+			MinIdle:  q.claimIdle,
+			Start:    start,
+			Count:    10,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil
+			}
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+			return fmt.Errorf("xautoclaim: %w", err)
+		}
+		for _, item := range items {
+			// Redis before 7 returns entries deleted meanwhile without values.
+			if len(item.Values) == 0 {
+				_ = q.ackAndDelete(ctx, stream, item.ID)
+				continue
 			}
+			q.handle(ctx, stream, item, handler)
+		}
+		if next == "0-0" || next == "" {
+			return nil
 		}
+		start = next
 	}
 }
 
-func (q *StreamsQueue) ensureGroup(ctx context.Context) error {
-	err := q.client.XGroupCreateMkStream(ctx, q.stream, q.group, "$").Err()
-	if err == nil {
-		return nil
+// keepClaimed renews this consumer's claim on an entry until stop is closed, resetting
+// its idle time so other consumers do not take over a job that is just slow. The renewal
+// only claims an entry left idle for half a renewal interval: one claimed by another
+// consumer meanwhile, or already acked, comes back without an ID and renewing stops, so
+// the entry is not taken back from its new owner.
+func (q *StreamsQueue) keepClaimed(ctx context.Context, stream string, id string, stop <-chan struct{}) {
+	interval := q.claimIdle / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ids, err := q.client.XClaimJustID(ctx, &redis.XClaimArgs{
+				Stream:   stream,
+				Group:    q.group,
+				Consumer: q.consumer,
+				MinIdle:  interval / 2,
+				Messages: []string{id},
+			}).Result()
+			if err == nil && len(ids) == 0 {
+				return
+			}
+		}
 	}
-	if strings.Contains(err.Error(), "BUSYGROUP") {
-		return nil
+}
+
+// begin marks an entry as being handled; false means another goroutine has it.
+func (q *StreamsQueue) begin(id string) bool {
+	q.activeMu.Lock()
+	defer q.activeMu.Unlock()
+	if _, ok := q.active[id]; ok {
+		return false
+	}
+	q.active[id] = struct{}{}
+	return true
+}
+
+func (q *StreamsQueue) end(id string) {
+	q.activeMu.Lock()
+	delete(q.active, id)
+	q.activeMu.Unlock()
+}
+
+// handle runs handler on item and settles it: acked, requeued or moved to the DLQ. The
+// settling outlives ctx so a shutdown does not leave the entry unacked.
+func (q *StreamsQueue) handle(
+	ctx context.Context,
+	stream string,
+	item redis.XMessage,
+	handler func(context.Context, domain.QueueMessage) error,
+) {
+	if !q.begin(item.ID) {
+		return
 	}
-	return fmt.Errorf("ensure stream group: %w", err)
+	defer q.end(item.ID)
+	settleCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	message, parseErr := parseStreamMessage(item)
+	if parseErr != nil {
+		_ = q.sendToDLQ(settleCtx, domain.QueueMessage{}, item, parseErr.Error())
+		_ = q.ackAndDelete(settleCtx, stream, item.ID)
+		return
+	}
+
+	stopClaim := make(chan struct{})
+	go q.keepClaimed(context.WithoutCancel(ctx), stream, item.ID, stopClaim)
+	handleErr := handler(ctx, message)
+	close(stopClaim)
+	if handleErr == nil {
+		_ = q.ackAndDelete(settleCtx, stream, item.ID)
+		return
+	}
+
+	if errors.Is(handleErr, ErrReleased) {
+		if requeueErr := q.Enqueue(settleCtx, message); requeueErr != nil {
+			// Left unacked, the entry is picked up again when this consumer restarts.
+			return
+		}
+		_ = q.ackAndDelete(settleCtx, stream, item.ID)
+		return
+	}
+
+	message.Attempt++
+	if message.Attempt >= q.maxAttempts {
+		_ = q.sendToDLQ(settleCtx, message, item, handleErr.Error())
+		_ = q.ackAndDelete(settleCtx, stream, item.ID)
+		q.reportFailure(message, false)
+		return
+	}
+
+	if requeueErr := q.requeue(settleCtx, message, retryDelay(handleErr)); requeueErr != nil {
+		_ = q.sendToDLQ(settleCtx, message, item, fmt.Sprintf("requeue failed: %v", requeueErr))
+		q.reportFailure(message, false)
+	} else {
+		q.reportFailure(message, true)
+	}
+	_ = q.ackAndDelete(settleCtx, stream, item.ID)
+}
+
+// requeue sends a failed message back to the stream, right away or, with a delay, through
+// the delayed set.
+func (q *StreamsQueue) requeue(ctx context.Context, message domain.QueueMessage, delay time.Duration) error {
+	if delay <= 0 {
+		return q.Enqueue(ctx, message)
+	}
+	member, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encode delayed message: %w", err)
+	}
+	dueAt := time.Now().Add(delay)
+	if err := q.client.ZAdd(ctx, q.delayedSet, redis.Z{Score: float64(dueAt.UnixMilli()), Member: member}).Err(); err != nil {
+		return fmt.Errorf("schedule retry: %w", err)
+	}
+	return nil
+}
+
+// promoteDue moves delayed messages whose retry delay elapsed back to the stream. ZREM
+// decides which consumer promotes a message when several race for it.
+func (q *StreamsQueue) promoteDue(ctx context.Context) error {
+	due, err := q.client.ZRangeByScore(ctx, q.delayedSet, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: 100,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		return fmt.Errorf("read delayed retries: %w", err)
+	}
+	for _, member := range due {
+		removed, err := q.client.ZRem(ctx, q.delayedSet, member).Result()
+		if err != nil {
+			return fmt.Errorf("claim delayed retry: %w", err)
+		}
+		if removed == 0 {
+			continue
+		}
+		var message domain.QueueMessage
+		if err := json.Unmarshal([]byte(member), &message); err != nil {
+			_ = q.sendToDLQ(ctx, domain.QueueMessage{}, redis.XMessage{}, fmt.Sprintf("invalid delayed retry: %v", err))
+			continue
+		}
+		if err := q.Enqueue(ctx, message); err != nil {
+			_ = q.sendToDLQ(ctx, message, redis.XMessage{}, fmt.Sprintf("requeue failed: %v", err))
+		}
+	}
+	return nil
+}
+
+func (q *StreamsQueue) ensureGroup(ctx context.Context) error {
+	for _, stream := range []string{q.stream, q.lowStream} {
+		err := q.client.XGroupCreateMkStream(ctx, stream, q.group, "$").Err()
+		if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("ensure stream group: %w", err)
+		}
+	}
+	return nil
 }
 
-func (q *StreamsQueue) ackAndDelete(ctx context.Context, streamID string) error {
-	if err := q.client.XAck(ctx, q.stream, q.group, streamID).Err(); err != nil {
+func (q *StreamsQueue) ackAndDelete(ctx context.Context, stream string, streamID string) error {
+	if err := q.client.XAck(ctx, stream, q.group, streamID).Err(); err != nil {
 		return fmt.Errorf("xack: %w", err)
 	}
-	if err := q.client.XDel(ctx, q.stream, streamID).Err(); err != nil {
+	if err := q.client.XDel(ctx, stream, streamID).Err(); err != nil {
 		return fmt.Errorf("xdel: %w", err)
 	}
 	return nil
@@ -224,6 +560,7 @@ func (q *StreamsQueue) sendToDLQ(
 		"conversation_id": message.ConversationID,
 		"payload":         string(message.Payload),
 		"attempt":         message.Attempt,
+		"priority":        string(lane(message.Priority)),
 		"error":           errorMessage,
 		"moved_at":        time.Now().UTC().Format(time.RFC3339Nano),
 	}
@@ -288,6 +625,12 @@ func parseStreamMessage(item redis.XMessage) (domain.QueueMessage, error) {
 	if err != nil {
 		return domain.QueueMessage{}, err
 	}
+	// Messages enqueued before tracing was added carry no traceparent.
+	traceParent, _ := getString("traceparent")
+	// Like traceparent, request_id is missing from older messages and scheduled jobs.
+	requestID, _ := getString("request_id")
+	// Messages enqueued before priorities were added are high priority.
+	priority, _ := getString("priority")
 
 	return domain.QueueMessage{
 		JobID:          jobID,
@@ -297,5 +640,8 @@ func parseStreamMessage(item redis.XMessage) (domain.QueueMessage, error) {
 		Payload:        []byte(payloadString),
 		Attempt:        attempt,
 		RequestedAt:    requestedAt,
+		TraceParent:    traceParent,
+		RequestID:      requestID,
+		Priority:       lane(domain.JobPriority(priority)),
 	}, nil
 }
@@ -0,0 +1,199 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// fakeStreams answers the stream commands of a StreamsQueue in memory through a client
+// hook, so no Redis server is needed.
+type fakeStreams struct {
+	mu    sync.Mutex
+	calls [][]any
+	// claimable is how many XCLAIM renewals still find the entry idle and owned.
+	claimable int
+	// pages are the XAUTOCLAIM replies, in order.
+	pages [][]redis.XMessage
+}
+
+func (f *fakeStreams) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (f *fakeStreams) ProcessHook(redis.ProcessHook) redis.ProcessHook {
+	return func(_ context.Context, cmd redis.Cmder) error {
+		f.process(cmd)
+		return cmd.Err()
+	}
+}
+
+func (f *fakeStreams) ProcessPipelineHook(redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(_ context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			f.process(cmd)
+		}
+		return nil
+	}
+}
+
+func (f *fakeStreams) process(cmd redis.Cmder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, cmd.Args())
+	switch typed := cmd.(type) {
+	case *redis.StringSliceCmd: // XCLAIM ... JUSTID
+		if f.claimable == 0 {
+			typed.SetVal(nil)
+			return
+		}
+		f.claimable--
+		typed.SetVal([]string{fmt.Sprint(cmd.Args()[5])})
+	case *redis.XAutoClaimCmd:
+		if len(f.pages) == 0 {
+			typed.SetVal(nil, "0-0")
+			return
+		}
+		page := f.pages[0]
+		f.pages = f.pages[1:]
+		next := "0-0"
+		if len(f.pages) > 0 {
+			next = page[len(page)-1].ID
+		}
+		typed.SetVal(page, next)
+	case *redis.IntCmd:
+		typed.SetVal(1)
+	case *redis.StringCmd:
+		typed.SetVal("0-1")
+	}
+}
+
+func (f *fakeStreams) commands(name string) [][]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched [][]any
+	for _, args := range f.calls {
+		if args[0] == name {
+			matched = append(matched, args)
+		}
+	}
+	return matched
+}
+
+func newFakeStreamsQueue(t *testing.T, fake *fakeStreams, claimIdle time.Duration) *StreamsQueue {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: "fake-redis:6379"})
+	client.AddHook(fake)
+	t.Cleanup(func() { client.Close() })
+	return &StreamsQueue{
+		client:      client,
+		stream:      "wa_jobs",
+		lowStream:   "wa_jobs:low",
+		dlqStream:   "wa_jobs_dlq",
+		delayedSet:  "wa_jobs:delayed",
+		group:       "wa_workers",
+		consumer:    "worker-1",
+		maxAttempts: 3,
+		claimIdle:   claimIdle,
+		active:      make(map[string]struct{}),
+	}
+}
+
+func TestKeepClaimedStopsOnceTheEntryIsLost(t *testing.T) {
+	fake := &fakeStreams{claimable: 2}
+	streams := newFakeStreamsQueue(t, fake, 60*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		streams.keepClaimed(context.Background(), "wa_jobs", "1-1", make(chan struct{}))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected renewing to stop when the claim returns no ID")
+	}
+
+	claims := fake.commands("xclaim")
+	if len(claims) != 3 {
+		t.Fatalf("expected two renewals and the one that lost the entry, got %v", claims)
+	}
+	for _, args := range claims {
+		// xclaim stream group consumer min-idle-ms id justid
+		if args[4] != int64(10) || args[5] != "1-1" || args[6] != "justid" {
+			t.Fatalf("expected renewals with half the interval as min idle, got %v", args)
+		}
+	}
+}
+
+func TestKeepClaimedStopsWhenHandlingEnds(t *testing.T) {
+	fake := &fakeStreams{claimable: 100}
+	streams := newFakeStreamsQueue(t, fake, 30*time.Millisecond)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		streams.keepClaimed(context.Background(), "wa_jobs", "1-1", stop)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected renewing to stop when stop is closed")
+	}
+	if claims := len(fake.commands("xclaim")); claims == 0 {
+		t.Fatal("expected the entry to be renewed while handled")
+	}
+}
+
+func TestClaimAbandonedHandlesIdleEntries(t *testing.T) {
+	entry := func(id, jobID string) redis.XMessage {
+		return redis.XMessage{ID: id, Values: streamValues(domain.QueueMessage{
+			JobID:       jobID,
+			Kind:        domain.JobKindSummary,
+			TenantID:    "tenant-a",
+			Payload:     []byte(`{}`),
+			RequestedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		})}
+	}
+	fake := &fakeStreams{pages: [][]redis.XMessage{
+		// An entry deleted after its delivery comes back without values.
+		{entry("1-1", "job-1"), {ID: "1-2"}},
+		{entry("1-3", "job-3")},
+	}}
+	streams := newFakeStreamsQueue(t, fake, time.Minute)
+
+	var handled []string
+	err := streams.claimAbandoned(context.Background(), "wa_jobs", func(_ context.Context, message domain.QueueMessage) error {
+		handled = append(handled, message.JobID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("claim abandoned: %v", err)
+	}
+	if len(handled) != 2 || handled[0] != "job-1" || handled[1] != "job-3" {
+		t.Fatalf("expected both claimed jobs handled, got %v", handled)
+	}
+
+	autoClaims := fake.commands("xautoclaim")
+	if len(autoClaims) != 2 {
+		t.Fatalf("expected one XAUTOCLAIM per page, got %v", autoClaims)
+	}
+	// xautoclaim stream group consumer min-idle-ms start
+	if autoClaims[0][4] != int64(time.Minute/time.Millisecond) || autoClaims[0][5] != "0-0" || autoClaims[1][5] != "1-2" {
+		t.Fatalf("expected claims of entries idle for ClaimIdle, resuming at the cursor, got %v", autoClaims)
+	}
+	var acked []string
+	for _, args := range fake.commands("xack") {
+		acked = append(acked, fmt.Sprint(args[3]))
+	}
+	if len(acked) != 3 || acked[0] != "1-1" || acked[1] != "1-2" || acked[2] != "1-3" {
+		t.Fatalf("expected every claimed entry acked, got %v", acked)
+	}
+}
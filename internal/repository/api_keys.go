@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// APIKeysRepository persists hashed tenant API keys. Lookups by hash run before the
+// request is tenant scoped, so they are not filtered by tenant.
+type APIKeysRepository interface {
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) error
+	GetAPIKey(ctx context.Context, keyID string) (*domain.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, filter domain.APIKeyListFilter) ([]domain.APIKey, error)
+	// RevokeAPIKey marks an active key revoked; revoked or missing keys return ErrNotFound.
+	RevokeAPIKey(ctx context.Context, keyID string, revokedAt time.Time) error
+	TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error
+}
+
+// MemoryAPIKeysRepository keeps API keys in memory for local development.
+type MemoryAPIKeysRepository struct {
+	mu     sync.RWMutex
+	keys   map[string]domain.APIKey
+	byHash map[string]string
+}
+
+func NewMemoryAPIKeysRepository() *MemoryAPIKeysRepository {
+	return &MemoryAPIKeysRepository{
+		keys:   make(map[string]domain.APIKey),
+		byHash: make(map[string]string),
+	}
+}
+
+func (r *MemoryAPIKeysRepository) CreateAPIKey(_ context.Context, key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byHash[key.Hash]; exists {
+		return ErrConflict
+	}
+	r.keys[key.ID] = cloneAPIKey(*key)
+	r.byHash[key.Hash] = key.ID
+	return nil
+}
+
+func (r *MemoryAPIKeysRepository) GetAPIKey(_ context.Context, keyID string) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[keyID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	clone := cloneAPIKey(key)
+	return &clone, nil
+}
+
+func (r *MemoryAPIKeysRepository) GetAPIKeyByHash(_ context.Context, hash string) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keyID, ok := r.byHash[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	clone := cloneAPIKey(r.keys[keyID])
+	return &clone, nil
+}
+
+func (r *MemoryAPIKeysRepository) ListAPIKeys(_ context.Context, filter domain.APIKeyListFilter) ([]domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]domain.APIKey, 0)
+	for _, key := range r.keys {
+		if filter.TenantID != "" && key.TenantID != filter.TenantID {
+			continue
+		}
+		if !filter.IncludeRevoked && !key.Active() {
+			continue
+		}
+		keys = append(keys, cloneAPIKey(key))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+func (r *MemoryAPIKeysRepository) RevokeAPIKey(_ context.Context, keyID string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[keyID]
+	if !ok || !key.Active() {
+		return ErrNotFound
+	}
+	key.RevokedAt = &revokedAt
+	r.keys[keyID] = key
+	return nil
+}
+
+func (r *MemoryAPIKeysRepository) TouchAPIKey(_ context.Context, keyID string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[keyID]
+	if !ok {
+		return ErrNotFound
+	}
+	key.LastUsedAt = &usedAt
+	r.keys[keyID] = key
+	return nil
+}
+
+func cloneAPIKey(key domain.APIKey) domain.APIKey {
+	key.Scopes = append([]string(nil), key.Scopes...)
+	if key.LastUsedAt != nil {
+		usedAt := *key.LastUsedAt
+		key.LastUsedAt = &usedAt
+	}
+	if key.RevokedAt != nil {
+		revokedAt := *key.RevokedAt
+		key.RevokedAt = &revokedAt
+	}
+	return key
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresAPIKeysRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresAPIKeysRepository(pool *pgxpool.Pool) *PostgresAPIKeysRepository {
+	return &PostgresAPIKeysRepository{pool: pool}
+}
+
+const apiKeyColumns = `id, tenant_id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at, COALESCE(rotated_from::text, '')`
+
+func (r *PostgresAPIKeysRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	var rotatedFrom any
+	if key.RotatedFrom != "" {
+		rotatedFrom = key.RotatedFrom
+	}
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO api_keys (
+			id,
+			tenant_id,
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			created_at,
+			rotated_from
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	`,
+		key.ID,
+		key.TenantID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		key.Scopes,
+		key.CreatedAt,
+		rotatedFrom,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrConflict
+		}
+		return fmt.Errorf("insert api key: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresAPIKeysRepository) GetAPIKey(ctx context.Context, keyID string) (*domain.APIKey, error) {
+	return r.getOne(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, keyID)
+}
+
+func (r *PostgresAPIKeysRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	return r.getOne(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, hash)
+}
+
+func (r *PostgresAPIKeysRepository) getOne(ctx context.Context, query string, arg string) (*domain.APIKey, error) {
+	key, err := scanAPIKey(r.pool.QueryRow(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query api key: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *PostgresAPIKeysRepository) ListAPIKeys(ctx context.Context, filter domain.APIKeyListFilter) ([]domain.APIKey, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE ($1 = '' OR tenant_id = $1)
+		  AND ($2 OR revoked_at IS NULL)
+		ORDER BY created_at DESC, id
+	`, filter.TenantID, filter.IncludeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]domain.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *PostgresAPIKeysRepository) RevokeAPIKey(ctx context.Context, keyID string, revokedAt time.Time) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE api_keys
+		SET revoked_at = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`, keyID, revokedAt)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresAPIKeysRepository) TouchAPIKey(ctx context.Context, keyID string, usedAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, keyID, usedAt)
+	if err != nil {
+		return fmt.Errorf("touch api key: %w", err)
+	}
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (domain.APIKey, error) {
+	var key domain.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.TenantID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&key.Scopes,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.RotatedFrom,
+	)
+	return key, err
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// AuditRepository persists audit records for sensitive operations.
+type AuditRepository interface {
+	RecordAudit(ctx context.Context, record domain.AuditRecord) error
+}
+
+// MemoryAuditRepository keeps audit records in memory for local development.
+type MemoryAuditRepository struct {
+	mu      sync.RWMutex
+	records []domain.AuditRecord
+}
+
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{
+		records: make([]domain.AuditRecord, 0),
+	}
+}
+
+func (r *MemoryAuditRepository) RecordAudit(_ context.Context, record domain.AuditRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record.Metadata = append([]byte(nil), record.Metadata...)
+	r.records = append(r.records, record)
+	return nil
+}
+
+// Records returns a copy of the stored audit records in insertion order.
+func (r *MemoryAuditRepository) Records() []domain.AuditRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]domain.AuditRecord, 0, len(r.records))
+	for _, record := range r.records {
+		record.Metadata = append([]byte(nil), record.Metadata...)
+		result = append(result, record)
+	}
+	return result
+}
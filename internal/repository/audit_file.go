@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// FileAuditRepository appends audit records as JSON lines to a file opened in append-only
+// mode, for deployments that ship the trail to external log storage instead of Postgres.
+type FileAuditRepository struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileAuditRepository(path string) (*FileAuditRepository, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return &FileAuditRepository{file: file}, nil
+}
+
+type fileAuditLine struct {
+	ID           string          `json:"id"`
+	TenantID     string          `json:"tenant_id"`
+	Actor        string          `json:"actor"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	RequestID    string          `json:"request_id"`
+	Metadata     json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+func (r *FileAuditRepository) RecordAudit(_ context.Context, record domain.AuditRecord) error {
+	line, err := json.Marshal(fileAuditLine{
+		ID:           record.ID,
+		TenantID:     record.TenantID,
+		Actor:        record.Actor,
+		Action:       record.Action,
+		ResourceType: record.ResourceType,
+		ResourceID:   record.ResourceID,
+		RequestID:    record.RequestID,
+		Metadata:     record.Metadata,
+		CreatedAt:    record.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("encode audit record: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// One write per record keeps lines whole even with other writers appending.
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("append audit record: %w", err)
+	}
+	return nil
+}
+
+func (r *FileAuditRepository) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresAuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresAuditRepository(pool *pgxpool.Pool) *PostgresAuditRepository {
+	return &PostgresAuditRepository{pool: pool}
+}
+
+func (r *PostgresAuditRepository) RecordAudit(ctx context.Context, record domain.AuditRecord) error {
+	metadata := record.Metadata
+	if len(metadata) == 0 {
+		metadata = []byte(`{}`)
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO audit_log (
+			id,
+			tenant_id,
+			actor,
+			action,
+			resource_type,
+			resource_id,
+			request_id,
+			metadata,
+			created_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+	`,
+		record.ID,
+		record.TenantID,
+		record.Actor,
+		record.Action,
+		record.ResourceType,
+		record.ResourceID,
+		record.RequestID,
+		metadata,
+		record.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert audit record: %w", err)
+	}
+	return nil
+}
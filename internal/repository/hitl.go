@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// HITLRepository persists human review decisions for compliance audits.
+type HITLRepository interface {
+	RecordDecision(ctx context.Context, decision domain.HITLDecision) error
+}
+
+// MemoryHITLRepository keeps decisions in memory for local development.
+type MemoryHITLRepository struct {
+	mu        sync.RWMutex
+	decisions []domain.HITLDecision
+}
+
+func NewMemoryHITLRepository() *MemoryHITLRepository {
+	return &MemoryHITLRepository{
+		decisions: make([]domain.HITLDecision, 0),
+	}
+}
+
+func (r *MemoryHITLRepository) RecordDecision(_ context.Context, decision domain.HITLDecision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.decisions = append(r.decisions, decision)
+	return nil
+}
+
+// Decisions returns a copy of the stored decisions in insertion order.
+func (r *MemoryHITLRepository) Decisions() []domain.HITLDecision {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]domain.HITLDecision(nil), r.decisions...)
+}
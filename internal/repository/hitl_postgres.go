@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresHITLRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresHITLRepository(pool *pgxpool.Pool) *PostgresHITLRepository {
+	return &PostgresHITLRepository{pool: pool}
+}
+
+func (r *PostgresHITLRepository) RecordDecision(ctx context.Context, decision domain.HITLDecision) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO hitl_decisions (
+			id,
+			tenant_id,
+			conversation_id,
+			job_id,
+			suggestion_request_id,
+			suggestion_rank,
+			action,
+			reviewer_id,
+			final_text_checksum,
+			actor,
+			request_id,
+			decided_at,
+			created_at
+		) VALUES ($1,$2,$3,NULLIF($4,'')::uuid,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+	`,
+		decision.ID,
+		decision.TenantID,
+		decision.ConversationID,
+		decision.JobID,
+		decision.SuggestionRequestID,
+		decision.SuggestionRank,
+		string(decision.Action),
+		decision.ReviewerID,
+		decision.FinalTextChecksum,
+		decision.Actor,
+		decision.RequestID,
+		decision.DecidedAt,
+		decision.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert hitl decision: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+// IdempotencyRepository stores replayable responses per tenant and Idempotency-Key.
+// Expired records count as absent.
+type IdempotencyRepository interface {
+	// ReserveIdempotencyKey stores record as pending and returns nil, or returns the live
+	// record already holding the tenant and key.
+	ReserveIdempotencyKey(ctx context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error)
+	// CompleteIdempotencyKey saves the response of a reserved key.
+	CompleteIdempotencyKey(ctx context.Context, record domain.IdempotencyRecord) error
+	// ReleaseIdempotencyKey drops a reservation so the request can be retried.
+	ReleaseIdempotencyKey(ctx context.Context, tenantID, key string) error
+	// DeleteConversationIdempotencyKeys drops the records of a conversation's requests.
+	DeleteConversationIdempotencyKeys(ctx context.Context, tenantID, conversationID string) (int, error)
+}
+
+// MemoryIdempotencyRepository keeps records in memory for local development.
+type MemoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+	now     func() time.Time
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{
+		records: make(map[string]domain.IdempotencyRecord),
+		now:     time.Now,
+	}
+}
+
+func idempotencyMapKey(tenantID, key string) string {
+	return tenantID + "\x00" + key
+}
+
+func (r *MemoryIdempotencyRepository) ReserveIdempotencyKey(
+	_ context.Context,
+	record domain.IdempotencyRecord,
+) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for mapKey, existing := range r.records {
+		if !existing.ExpiresAt.After(now) {
+			delete(r.records, mapKey)
+		}
+	}
+	mapKey := idempotencyMapKey(record.TenantID, record.Key)
+	if existing, ok := r.records[mapKey]; ok {
+		existing = cloneIdempotencyRecord(existing)
+		return &existing, nil
+	}
+	r.records[mapKey] = cloneIdempotencyRecord(record)
+	return nil, nil
+}
+
+func (r *MemoryIdempotencyRepository) CompleteIdempotencyKey(_ context.Context, record domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mapKey := idempotencyMapKey(record.TenantID, record.Key)
+	if _, ok := r.records[mapKey]; !ok {
+		return ErrNotFound
+	}
+	r.records[mapKey] = cloneIdempotencyRecord(record)
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) ReleaseIdempotencyKey(_ context.Context, tenantID, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, idempotencyMapKey(tenantID, key))
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) DeleteConversationIdempotencyKeys(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) (int, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for mapKey, record := range r.records {
+		if record.TenantID == tenantID && record.ConversationID == conversationID {
+			delete(r.records, mapKey)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func cloneIdempotencyRecord(record domain.IdempotencyRecord) domain.IdempotencyRecord {
+	record.Body = append([]byte(nil), record.Body...)
+	if record.Header != nil {
+		header := make(map[string]string, len(record.Header))
+		for name, value := range record.Header {
+			header[name] = value
+		}
+		record.Header = header
+	}
+	return record
+}
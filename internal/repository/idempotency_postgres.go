@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresIdempotencyRepository struct {
+	pool   *pgxpool.Pool
+	cipher PayloadCipher
+}
+
+func NewPostgresIdempotencyRepository(pool *pgxpool.Pool) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{pool: pool}
+}
+
+// UseCipher seals the stored response bodies at rest, like job payloads. Rows written
+// before it was enabled are still replayed as plaintext.
+func (r *PostgresIdempotencyRepository) UseCipher(cipher PayloadCipher) {
+	r.cipher = cipher
+}
+
+// ReserveIdempotencyKey inserts the pending row, taking over an expired one. When a live
+// row holds the key the insert does nothing and that row is read back.
+func (r *PostgresIdempotencyRepository) ReserveIdempotencyKey(
+	ctx context.Context,
+	record domain.IdempotencyRecord,
+) (*domain.IdempotencyRecord, error) {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO idempotency_keys (
+			tenant_id,
+			idempotency_key,
+			request_hash,
+			conversation_id,
+			status_code,
+			headers,
+			body,
+			created_at,
+			expires_at
+		) VALUES ($1,$2,$3,$4,0,'{}'::jsonb,NULL,$5,$6)
+		ON CONFLICT (tenant_id, idempotency_key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			conversation_id = EXCLUDED.conversation_id,
+			status_code = 0,
+			headers = '{}'::jsonb,
+			body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+	`,
+		record.TenantID,
+		record.Key,
+		record.RequestHash,
+		record.ConversationID,
+		record.CreatedAt,
+		record.ExpiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	var (
+		existing = domain.IdempotencyRecord{TenantID: record.TenantID, Key: record.Key}
+		headers  []byte
+	)
+	err = r.pool.QueryRow(ctx, `
+		SELECT request_hash, conversation_id, status_code, headers, COALESCE(body, ''::bytea), created_at, expires_at
+		FROM idempotency_keys
+		WHERE tenant_id = $1 AND idempotency_key = $2
+	`, record.TenantID, record.Key).Scan(
+		&existing.RequestHash,
+		&existing.ConversationID,
+		&existing.StatusCode,
+		&headers,
+		&existing.Body,
+		&existing.CreatedAt,
+		&existing.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("reserve idempotency key: row vanished")
+		}
+		return nil, fmt.Errorf("read idempotency key: %w", err)
+	}
+	if err := json.Unmarshal(headers, &existing.Header); err != nil {
+		return nil, fmt.Errorf("decode idempotency headers: %w", err)
+	}
+	if existing.Body, err = r.open(ctx, existing, existing.Body); err != nil {
+		return nil, fmt.Errorf("decrypt idempotency body: %w", err)
+	}
+	return &existing, nil
+}
+
+func (r *PostgresIdempotencyRepository) CompleteIdempotencyKey(ctx context.Context, record domain.IdempotencyRecord) error {
+	headers, err := json.Marshal(record.Header)
+	if err != nil {
+		return fmt.Errorf("encode idempotency headers: %w", err)
+	}
+	body, err := r.seal(ctx, record)
+	if err != nil {
+		return fmt.Errorf("encrypt idempotency body: %w", err)
+	}
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $3, headers = $4, body = $5
+		WHERE tenant_id = $1 AND idempotency_key = $2
+	`, record.TenantID, record.Key, record.StatusCode, headers, body)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, tenantID, key string) error {
+	if _, err := r.pool.Exec(ctx, `
+		DELETE FROM idempotency_keys WHERE tenant_id = $1 AND idempotency_key = $2 AND status_code = 0
+	`, tenantID, key); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) DeleteConversationIdempotencyKeys(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) (int, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return 0, nil
+	}
+
+	command, err := r.pool.Exec(ctx, `
+		DELETE FROM idempotency_keys WHERE tenant_id = $1 AND conversation_id = $2
+	`, tenantID, conversationID)
+	if err != nil {
+		return 0, fmt.Errorf("delete conversation idempotency keys: %w", err)
+	}
+	return int(command.RowsAffected()), nil
+}
+
+func (r *PostgresIdempotencyRepository) seal(ctx context.Context, record domain.IdempotencyRecord) ([]byte, error) {
+	if r.cipher == nil || len(record.Body) == 0 {
+		return record.Body, nil
+	}
+	return r.cipher.Seal(ctx, record.Body, idempotencyAAD(record))
+}
+
+func (r *PostgresIdempotencyRepository) open(ctx context.Context, record domain.IdempotencyRecord, body []byte) ([]byte, error) {
+	if r.cipher == nil || len(body) == 0 {
+		return body, nil
+	}
+	return r.cipher.Open(ctx, body, idempotencyAAD(record))
+}
+
+// idempotencyAAD binds ciphertexts to their tenant and key, so a body cannot be replayed
+// under another key.
+func idempotencyAAD(record domain.IdempotencyRecord) []byte {
+	return []byte("idempotency_keys:" + record.TenantID + "\x00" + record.Key)
+}
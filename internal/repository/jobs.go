@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"sort"
 	"strings"
@@ -9,9 +10,13 @@ import (
 	"time"
 
 	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
 )
 
-var ErrNotFound = errors.New("resource not found")
+var (
+	ErrNotFound = errors.New("resource not found")
+	ErrConflict = errors.New("resource already exists")
+)
 
 // JobsRepository abstracts job persistence and query operations.
 type JobsRepository interface {
@@ -19,6 +24,17 @@ type JobsRepository interface {
 	UpdateJob(ctx context.Context, job *domain.Job) error
 	GetJob(ctx context.Context, jobID string) (*domain.Job, error)
 	ListReports(ctx context.Context, filter domain.ReportListFilter) ([]domain.ReportListItem, int, error)
+	ListSummaries(ctx context.Context, filter domain.SummaryListFilter) ([]domain.SummaryListItem, int, error)
+	ListDigests(ctx context.Context, filter domain.DigestListFilter) ([]domain.DigestListItem, int, error)
+	JobStats(ctx context.Context, filter domain.JobStatsFilter) ([]domain.JobStatsBucket, error)
+	DeleteConversationJobs(ctx context.Context, tenantID, conversationID string) (int, error)
+	ListArchivableJobs(ctx context.Context, updatedBefore time.Time, limit int) ([]domain.Job, error)
+	MarkJobArchived(ctx context.Context, jobID string, archiveKey string, archivedAt time.Time) error
+	SetJobTags(ctx context.Context, jobID string, tags []string) error
+	// ListDependentJobs returns the jobs waiting on jobID, oldest first.
+	ListDependentJobs(ctx context.Context, jobID string) ([]domain.Job, error)
+	// SaveJobCheckpoint replaces the partial output of a running job; nil clears it.
+	SaveJobCheckpoint(ctx context.Context, jobID string, checkpoint json.RawMessage) error
 }
 
 // MemoryJobsRepository stores jobs in memory for local development.
@@ -33,7 +49,11 @@ func NewMemoryJobsRepository() *MemoryJobsRepository {
 	}
 }
 
-func (r *MemoryJobsRepository) CreateJob(_ context.Context, job *domain.Job) error {
+func (r *MemoryJobsRepository) CreateJob(ctx context.Context, job *domain.Job) error {
+	if !tenant.Allows(ctx, job.TenantID) {
+		return tenant.ErrMismatch
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -42,32 +62,58 @@ func (r *MemoryJobsRepository) CreateJob(_ context.Context, job *domain.Job) err
 	return nil
 }
 
-func (r *MemoryJobsRepository) UpdateJob(_ context.Context, job *domain.Job) error {
+func (r *MemoryJobsRepository) UpdateJob(ctx context.Context, job *domain.Job) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.jobs[job.ID]; !ok {
+	current, ok := r.jobs[job.ID]
+	if !ok || !tenant.Allows(ctx, current.TenantID) {
 		return ErrNotFound
 	}
-	r.jobs[job.ID] = cloneJob(job)
+	clone := cloneJob(job)
+	clone.Tags = current.Tags
+	clone.Checkpoint = current.Checkpoint
+	r.jobs[job.ID] = clone
 	return nil
 }
 
-func (r *MemoryJobsRepository) GetJob(_ context.Context, jobID string) (*domain.Job, error) {
+func (r *MemoryJobsRepository) GetJob(ctx context.Context, jobID string) (*domain.Job, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	job, ok := r.jobs[jobID]
-	if !ok {
+	if !ok || !tenant.Allows(ctx, job.TenantID) {
 		return nil, ErrNotFound
 	}
 	return cloneJob(job), nil
 }
 
+func (r *MemoryJobsRepository) ListDependentJobs(ctx context.Context, jobID string) ([]domain.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := make([]domain.Job, 0)
+	for _, job := range r.jobs {
+		if job.DependsOn == jobID && tenant.Allows(ctx, job.TenantID) {
+			jobs = append(jobs, *cloneJob(job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs, nil
+}
+
 func (r *MemoryJobsRepository) ListReports(
-	_ context.Context,
+	ctx context.Context,
 	filter domain.ReportListFilter,
 ) ([]domain.ReportListItem, int, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.TenantID = tenantID
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -95,6 +141,12 @@ func (r *MemoryJobsRepository) ListReports(
 		if filter.Topic != "" && !strings.Contains(strings.ToLower(string(job.Payload)), strings.ToLower(filter.Topic)) {
 			continue
 		}
+		if filter.Query != "" && !matchesReportQuery(job.Result, filter.Query) {
+			continue
+		}
+		if !containsAllTags(job.Tags, filter.Tags) {
+			continue
+		}
 
 		title := "Relatorio"
 		if job.Status == domain.JobStatusDone {
@@ -107,6 +159,7 @@ func (r *MemoryJobsRepository) ListReports(
 			Status:         job.Status,
 			CreatedAt:      job.CreatedAt,
 			Title:          title,
+			Tags:           append([]string(nil), job.Tags...),
 		})
 	}
 
@@ -127,6 +180,409 @@ func (r *MemoryJobsRepository) ListReports(
 	return items[start:end], total, nil
 }
 
+func (r *MemoryJobsRepository) ListSummaries(
+	ctx context.Context,
+	filter domain.SummaryListFilter,
+) ([]domain.SummaryListItem, int, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.TenantID = tenantID
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	items := make([]domain.SummaryListItem, 0)
+	for _, job := range r.jobs {
+		if job.Kind != domain.JobKindSummary {
+			continue
+		}
+		if filter.TenantID != "" && job.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.ConversationID != "" && job.ConversationID != filter.ConversationID {
+			continue
+		}
+		if filter.From != nil && job.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && job.CreatedAt.After(*filter.To) {
+			continue
+		}
+
+		items = append(items, domain.SummaryListItem{
+			SummaryID:      job.ID,
+			ConversationID: job.ConversationID,
+			Status:         job.Status,
+			CreatedAt:      job.CreatedAt,
+			Preview:        summaryPreview(job.Result),
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+
+	total := len(items)
+	start := (filter.Page - 1) * filter.PageSize
+	if start >= total {
+		return []domain.SummaryListItem{}, total, nil
+	}
+	end := start + filter.PageSize
+	if end > total {
+		end = total
+	}
+
+	return items[start:end], total, nil
+}
+
+func (r *MemoryJobsRepository) ListDigests(
+	ctx context.Context,
+	filter domain.DigestListFilter,
+) ([]domain.DigestListItem, int, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.TenantID = tenantID
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	items := make([]domain.DigestListItem, 0)
+	for _, job := range r.jobs {
+		if job.Kind != domain.JobKindDigest {
+			continue
+		}
+		if filter.TenantID != "" && job.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.From != nil && job.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && job.CreatedAt.After(*filter.To) {
+			continue
+		}
+
+		items = append(items, domain.DigestListItem{
+			DigestID:  job.ID,
+			Status:    job.Status,
+			CreatedAt: job.CreatedAt,
+			Title:     digestTitle(job.Result),
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+
+	total := len(items)
+	start := (filter.Page - 1) * filter.PageSize
+	if start >= total {
+		return []domain.DigestListItem{}, total, nil
+	}
+	end := start + filter.PageSize
+	if end > total {
+		end = total
+	}
+
+	return items[start:end], total, nil
+}
+
+func (r *MemoryJobsRepository) JobStats(
+	ctx context.Context,
+	filter domain.JobStatsFilter,
+) ([]domain.JobStatsBucket, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	filter.TenantID = tenantID
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type bucketKey struct {
+		day    time.Time
+		kind   domain.JobKind
+		status domain.JobStatus
+	}
+	type bucketAcc struct {
+		count        int
+		finished     int
+		latencySumMS int64
+		latencyMaxMS int64
+	}
+
+	buckets := make(map[bucketKey]*bucketAcc)
+	for _, job := range r.jobs {
+		if filter.TenantID != "" && job.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.From != nil && job.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && job.CreatedAt.After(*filter.To) {
+			continue
+		}
+
+		key := bucketKey{
+			day:    job.CreatedAt.UTC().Truncate(24 * time.Hour),
+			kind:   job.Kind,
+			status: job.Status,
+		}
+		acc, ok := buckets[key]
+		if !ok {
+			acc = &bucketAcc{}
+			buckets[key] = acc
+		}
+		acc.count++
+		if job.Status == domain.JobStatusDone || job.Status == domain.JobStatusFailed {
+			latencyMS := job.UpdatedAt.Sub(job.CreatedAt).Milliseconds()
+			acc.finished++
+			acc.latencySumMS += latencyMS
+			if latencyMS > acc.latencyMaxMS {
+				acc.latencyMaxMS = latencyMS
+			}
+		}
+	}
+
+	items := make([]domain.JobStatsBucket, 0, len(buckets))
+	for key, acc := range buckets {
+		item := domain.JobStatsBucket{
+			Day:          key.day,
+			Kind:         key.kind,
+			Status:       key.status,
+			Count:        acc.count,
+			MaxLatencyMS: acc.latencyMaxMS,
+		}
+		if acc.finished > 0 {
+			item.AvgLatencyMS = float64(acc.latencySumMS) / float64(acc.finished)
+		}
+		items = append(items, item)
+	}
+
+	sortJobStats(items)
+	return items, nil
+}
+
+func (r *MemoryJobsRepository) DeleteConversationJobs(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) (int, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return 0, tenant.ErrMismatch
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for jobID, job := range r.jobs {
+		if job.TenantID != tenantID || job.ConversationID != conversationID {
+			continue
+		}
+		delete(r.jobs, jobID)
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (r *MemoryJobsRepository) ListArchivableJobs(
+	ctx context.Context,
+	updatedBefore time.Time,
+	limit int,
+) ([]domain.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]domain.Job, 0)
+	for _, job := range r.jobs {
+		if job.Status != domain.JobStatusDone || job.ResultArchiveKey != "" || len(job.Result) == 0 {
+			continue
+		}
+		if !tenant.Allows(ctx, job.TenantID) {
+			continue
+		}
+		if !job.UpdatedAt.Before(updatedBefore) {
+			continue
+		}
+		items = append(items, *cloneJob(job))
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].UpdatedAt.Before(items[j].UpdatedAt)
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func (r *MemoryJobsRepository) MarkJobArchived(
+	_ context.Context,
+	jobID string,
+	archiveKey string,
+	archivedAt time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return ErrNotFound
+	}
+	job.Result = nil
+	job.ResultArchiveKey = archiveKey
+	archivedAtCopy := archivedAt
+	job.ArchivedAt = &archivedAtCopy
+	return nil
+}
+
+// matchesReportQuery approximates the Postgres full-text filter: every query term
+// must appear in the report title or section text.
+func (r *MemoryJobsRepository) SetJobTags(ctx context.Context, jobID string, tags []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok || !tenant.Allows(ctx, job.TenantID) {
+		return ErrNotFound
+	}
+	job.Tags = append([]string(nil), tags...)
+	return nil
+}
+
+func (r *MemoryJobsRepository) SaveJobCheckpoint(ctx context.Context, jobID string, checkpoint json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok || !tenant.Allows(ctx, job.TenantID) {
+		return ErrNotFound
+	}
+	if len(checkpoint) == 0 {
+		job.Checkpoint = nil
+		return nil
+	}
+	job.Checkpoint = append([]byte(nil), checkpoint...)
+	return nil
+}
+
+func containsAllTags(tags, required []string) bool {
+	for _, want := range required {
+		found := false
+		for _, tag := range tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesReportQuery(result json.RawMessage, query string) bool {
+	if len(result) == 0 {
+		return false
+	}
+	var report struct {
+		Title    string `json:"title"`
+		Sections []struct {
+			Heading string `json:"heading"`
+			Content string `json:"content"`
+		} `json:"sections"`
+	}
+	if err := json.Unmarshal(result, &report); err != nil {
+		return false
+	}
+
+	text := strings.Builder{}
+	text.WriteString(strings.ToLower(report.Title))
+	for _, section := range report.Sections {
+		text.WriteString(" ")
+		text.WriteString(strings.ToLower(section.Heading))
+		text.WriteString(" ")
+		text.WriteString(strings.ToLower(section.Content))
+	}
+	haystack := text.String()
+
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return true
+	}
+	for _, term := range terms {
+		if !strings.Contains(haystack, strings.Trim(term, `"'`)) {
+			return false
+		}
+	}
+	return true
+}
+
+const summaryPreviewMaxRunes = 160
+
+// summaryPreview extracts the first characters of a summary result for list views.
+func summaryPreview(result json.RawMessage) string {
+	if len(result) == 0 {
+		return ""
+	}
+	var payload struct {
+		Summary string `json:"summary"`
+	}
+	if err := json.Unmarshal(result, &payload); err != nil {
+		return ""
+	}
+	preview := []rune(strings.TrimSpace(payload.Summary))
+	if len(preview) <= summaryPreviewMaxRunes {
+		return string(preview)
+	}
+	return strings.TrimSpace(string(preview[:summaryPreviewMaxRunes])) + "..."
+}
+
+func digestTitle(result json.RawMessage) string {
+	if len(result) == 0 {
+		return ""
+	}
+	var payload struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(result, &payload); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(payload.Title)
+}
+
+func sortJobStats(items []domain.JobStatsBucket) {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].Day.Equal(items[j].Day) {
+			return items[i].Day.Before(items[j].Day)
+		}
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].Status < items[j].Status
+	})
+}
+
 func cloneJob(job *domain.Job) *domain.Job {
 	if job == nil {
 		return nil
@@ -134,9 +590,24 @@ func cloneJob(job *domain.Job) *domain.Job {
 	clone := *job
 	clone.Payload = append([]byte(nil), job.Payload...)
 	clone.Result = append([]byte(nil), job.Result...)
+	clone.Tags = append([]string(nil), job.Tags...)
+	if job.Checkpoint != nil {
+		clone.Checkpoint = append([]byte(nil), job.Checkpoint...)
+	}
+	clone.ArchivedAt = cloneTime(job.ArchivedAt)
+	clone.StartedAt = cloneTime(job.StartedAt)
+	clone.FinishedAt = cloneTime(job.FinishedAt)
 	return &clone
 }
 
+func cloneTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
 func parseDateTime(value string) (*time.Time, error) {
 	if value == "" {
 		return nil, nil
@@ -3,21 +3,36 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// PayloadCipher seals sensitive job columns (payload and result) at rest.
+type PayloadCipher interface {
+	Seal(ctx context.Context, plaintext []byte, aad []byte) ([]byte, error)
+	Open(ctx context.Context, stored []byte, aad []byte) ([]byte, error)
+}
+
 type PostgresJobsRepository struct {
-	pool *pgxpool.Pool
+	pool   *pgxpool.Pool
+	cipher PayloadCipher
 }
 
 func NewPostgresJobsRepository(ctx context.Context, databaseURL string) (*PostgresJobsRepository, error) {
-	pool, err := pgxpool.New(ctx, databaseURL)
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse pg config: %w", err)
+	}
+	installTenantGUC(config)
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("create pg pool: %w", err)
 	}
@@ -32,8 +47,28 @@ func (r *PostgresJobsRepository) Close() {
 	r.pool.Close()
 }
 
+// UseCipher enables transparent encryption of payload and result columns. Rows written
+// before it was enabled are still read as plaintext.
+func (r *PostgresJobsRepository) UseCipher(cipher PayloadCipher) {
+	r.cipher = cipher
+}
+
+// Pool exposes the underlying connection pool so sibling repositories can share it.
+func (r *PostgresJobsRepository) Pool() *pgxpool.Pool {
+	return r.pool
+}
+
 func (r *PostgresJobsRepository) CreateJob(ctx context.Context, job *domain.Job) error {
-	_, err := r.pool.Exec(ctx, `
+	payload, err := r.seal(ctx, job.ID, job.Payload)
+	if err != nil {
+		return fmt.Errorf("encrypt job payload: %w", err)
+	}
+	result, err := r.seal(ctx, job.ID, job.Result)
+	if err != nil {
+		return fmt.Errorf("encrypt job result: %w", err)
+	}
+
+	_, err = r.pool.Exec(ctx, `
 		INSERT INTO jobs (
 			id,
 			kind,
@@ -45,20 +80,36 @@ func (r *PostgresJobsRepository) CreateJob(ctx context.Context, job *domain.Job)
 			error_message,
 			attempts,
 			created_at,
-			updated_at
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+			updated_at,
+			started_at,
+			finished_at,
+			tags,
+			approval,
+			depends_on,
+			priority,
+			trace_parent,
+			request_id
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,COALESCE($14::text[], '{}'),$15,$16,$17,$18,$19)
 	`,
 		job.ID,
 		string(job.Kind),
 		job.TenantID,
 		job.ConversationID,
-		job.Payload,
+		payload,
 		string(job.Status),
-		job.Result,
+		result,
 		job.ErrorMessage,
 		job.Attempts,
 		job.CreatedAt,
 		job.UpdatedAt,
+		job.StartedAt,
+		job.FinishedAt,
+		job.Tags,
+		string(job.Approval),
+		job.DependsOn,
+		string(job.Priority),
+		job.TraceParent,
+		job.RequestID,
 	)
 	if err != nil {
 		return fmt.Errorf("insert job: %w", err)
@@ -67,15 +118,35 @@ func (r *PostgresJobsRepository) CreateJob(ctx context.Context, job *domain.Job)
 }
 
 func (r *PostgresJobsRepository) UpdateJob(ctx context.Context, job *domain.Job) error {
+	result, err := r.seal(ctx, job.ID, job.Result)
+	if err != nil {
+		return fmt.Errorf("encrypt job result: %w", err)
+	}
+	// The search vector holds the report text in clear, so it is not built for sealed
+	// results.
+	searchSource := job.Result
+	if r.cipher != nil {
+		searchSource = nil
+	}
+
 	command, err := r.pool.Exec(ctx, `
 		UPDATE jobs
 		SET status = $2,
 			result = $3,
 			error_message = $4,
 			attempts = $5,
-			updated_at = $6
+			updated_at = $6,
+			started_at = $8,
+			finished_at = $9,
+			approval = $10,
+			error_code = $11,
+			result_search = CASE
+				WHEN kind = 'report' AND $7::jsonb IS NOT NULL THEN `+reportSearchVectorSQL+`
+				ELSE result_search
+			END
 		WHERE id = $1
-	`, job.ID, string(job.Status), job.Result, job.ErrorMessage, job.Attempts, job.UpdatedAt)
+	`, job.ID, string(job.Status), result, job.ErrorMessage, job.Attempts, job.UpdatedAt, searchSource,
+		job.StartedAt, job.FinishedAt, string(job.Approval), job.ErrorCode)
 	if err != nil {
 		return fmt.Errorf("update job: %w", err)
 	}
@@ -87,17 +158,22 @@ func (r *PostgresJobsRepository) UpdateJob(ctx context.Context, job *domain.Job)
 
 func (r *PostgresJobsRepository) GetJob(ctx context.Context, jobID string) (*domain.Job, error) {
 	var (
-		job       domain.Job
-		kind      string
-		status    string
-		payload   []byte
-		result    []byte
-		createdAt time.Time
-		updatedAt time.Time
+		job        domain.Job
+		kind       string
+		status     string
+		payload    []byte
+		result     []byte
+		checkpoint []byte
+		approval   string
+		priority   string
+		createdAt  time.Time
+		updatedAt  time.Time
 	)
 
 	err := r.pool.QueryRow(ctx, `
-		SELECT id, kind, tenant_id, conversation_id, payload, status, result, error_message, attempts, created_at, updated_at
+		SELECT id, kind, tenant_id, conversation_id, payload, status, result, error_message, attempts, created_at, updated_at,
+			COALESCE(result_archive_key, ''), archived_at, started_at, finished_at, tags, approval, error_code, depends_on, priority,
+			checkpoint, trace_parent, request_id
 		FROM jobs
 		WHERE id = $1
 	`, jobID).Scan(
@@ -112,6 +188,18 @@ func (r *PostgresJobsRepository) GetJob(ctx context.Context, jobID string) (*dom
 		&job.Attempts,
 		&createdAt,
 		&updatedAt,
+		&job.ResultArchiveKey,
+		&job.ArchivedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.Tags,
+		&approval,
+		&job.ErrorCode,
+		&job.DependsOn,
+		&priority,
+		&checkpoint,
+		&job.TraceParent,
+		&job.RequestID,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -120,19 +208,71 @@ func (r *PostgresJobsRepository) GetJob(ctx context.Context, jobID string) (*dom
 		return nil, fmt.Errorf("query job: %w", err)
 	}
 
+	if payload, err = r.open(ctx, job.ID, payload); err != nil {
+		return nil, fmt.Errorf("decrypt job payload: %w", err)
+	}
+	if result, err = r.open(ctx, job.ID, result); err != nil {
+		return nil, fmt.Errorf("decrypt job result: %w", err)
+	}
+	if checkpoint, err = r.open(ctx, job.ID, checkpoint); err != nil {
+		return nil, fmt.Errorf("decrypt job checkpoint: %w", err)
+	}
+
+	// Defense in depth on top of row-level security.
+	if !tenant.Allows(ctx, job.TenantID) {
+		return nil, ErrNotFound
+	}
+
 	job.Kind = domain.JobKind(kind)
 	job.Status = domain.JobStatus(status)
+	job.Approval = domain.JobApproval(approval)
+	job.Priority = domain.JobPriority(priority)
 	job.Payload = json.RawMessage(payload)
 	job.Result = json.RawMessage(result)
+	if len(checkpoint) > 0 {
+		job.Checkpoint = json.RawMessage(checkpoint)
+	}
 	job.CreatedAt = createdAt
 	job.UpdatedAt = updatedAt
 	return &job, nil
 }
 
+func (r *PostgresJobsRepository) ListDependentJobs(ctx context.Context, jobID string) ([]domain.Job, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id FROM jobs WHERE depends_on = $1 ORDER BY created_at ASC
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("query dependent jobs: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scan dependent jobs: %w", err)
+	}
+
+	jobs := make([]domain.Job, 0, len(ids))
+	for _, id := range ids {
+		job, err := r.GetJob(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, nil
+}
+
 func (r *PostgresJobsRepository) ListReports(
 	ctx context.Context,
 	filter domain.ReportListFilter,
 ) ([]domain.ReportListItem, int, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.TenantID = tenantID
+
 	if filter.Page <= 0 {
 		filter.Page = 1
 	}
@@ -149,7 +289,7 @@ func (r *PostgresJobsRepository) ListReports(
 	}
 
 	listQuery := fmt.Sprintf(
-		`SELECT id, conversation_id, status, created_at
+		`SELECT id, conversation_id, status, created_at, tags
 		%s
 		ORDER BY created_at DESC
 		LIMIT $%d OFFSET $%d`,
@@ -171,7 +311,7 @@ func (r *PostgresJobsRepository) ListReports(
 			status    string
 			createdAt time.Time
 		)
-		if err := rows.Scan(&item.ReportID, &item.ConversationID, &status, &createdAt); err != nil {
+		if err := rows.Scan(&item.ReportID, &item.ConversationID, &status, &createdAt, &item.Tags); err != nil {
 			return nil, 0, fmt.Errorf("scan report item: %w", err)
 		}
 		item.Status = domain.JobStatus(status)
@@ -191,6 +331,440 @@ func (r *PostgresJobsRepository) ListReports(
 	return items, total, nil
 }
 
+func (r *PostgresJobsRepository) ListSummaries(
+	ctx context.Context,
+	filter domain.SummaryListFilter,
+) ([]domain.SummaryListItem, int, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.TenantID = tenantID
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	baseQuery, args := buildSummaryFilters(filter)
+
+	var total int
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) "+baseQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count summaries: %w", err)
+	}
+
+	listQuery := fmt.Sprintf(
+		`SELECT id, conversation_id, status, result, created_at
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`,
+		baseQuery,
+		len(args)+1,
+		len(args)+2,
+	)
+	listArgs := append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)
+	rows, err := r.pool.Query(ctx, listQuery, listArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list summaries: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.SummaryListItem, 0)
+	for rows.Next() {
+		var (
+			item   domain.SummaryListItem
+			status string
+			result []byte
+		)
+		if err := rows.Scan(&item.SummaryID, &item.ConversationID, &status, &result, &item.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("scan summary item: %w", err)
+		}
+		if result, err = r.open(ctx, item.SummaryID, result); err != nil {
+			return nil, 0, fmt.Errorf("decrypt summary item: %w", err)
+		}
+		item.Status = domain.JobStatus(status)
+		item.Preview = summaryPreview(result)
+		items = append(items, item)
+	}
+
+	if rows.Err() != nil {
+		return nil, 0, fmt.Errorf("iterate summary items: %w", rows.Err())
+	}
+
+	return items, total, nil
+}
+
+func (r *PostgresJobsRepository) ListDigests(
+	ctx context.Context,
+	filter domain.DigestListFilter,
+) ([]domain.DigestListItem, int, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter.TenantID = tenantID
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	baseQuery, args := buildDigestFilters(filter)
+
+	var total int
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) "+baseQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count digests: %w", err)
+	}
+
+	listQuery := fmt.Sprintf(
+		`SELECT id, status, result, created_at
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`,
+		baseQuery,
+		len(args)+1,
+		len(args)+2,
+	)
+	listArgs := append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)
+	rows, err := r.pool.Query(ctx, listQuery, listArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list digests: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.DigestListItem, 0)
+	for rows.Next() {
+		var (
+			item   domain.DigestListItem
+			status string
+			result []byte
+		)
+		if err := rows.Scan(&item.DigestID, &status, &result, &item.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("scan digest item: %w", err)
+		}
+		if result, err = r.open(ctx, item.DigestID, result); err != nil {
+			return nil, 0, fmt.Errorf("decrypt digest item: %w", err)
+		}
+		item.Status = domain.JobStatus(status)
+		item.Title = digestTitle(result)
+		items = append(items, item)
+	}
+
+	if rows.Err() != nil {
+		return nil, 0, fmt.Errorf("iterate digest items: %w", rows.Err())
+	}
+
+	return items, total, nil
+}
+
+func (r *PostgresJobsRepository) JobStats(
+	ctx context.Context,
+	filter domain.JobStatsFilter,
+) ([]domain.JobStatsBucket, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	filter.TenantID = tenantID
+
+	conditions := []string{"1=1"}
+	args := make([]any, 0, 3)
+	if filter.TenantID != "" {
+		args = append(args, filter.TenantID)
+		conditions = append(conditions, fmt.Sprintf("tenant_id = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			date_trunc('day', created_at AT TIME ZONE 'UTC') AS day,
+			kind,
+			status,
+			COUNT(*),
+			COALESCE(AVG(EXTRACT(EPOCH FROM (updated_at - created_at)) * 1000)
+				FILTER (WHERE status IN ('done', 'failed')), 0)::float8,
+			COALESCE(MAX(EXTRACT(EPOCH FROM (updated_at - created_at)) * 1000)
+				FILTER (WHERE status IN ('done', 'failed')), 0)::bigint
+		FROM jobs
+		WHERE %s
+		GROUP BY day, kind, status
+		ORDER BY day ASC, kind ASC, status ASC
+	`, strings.Join(conditions, " AND "))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate job stats: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.JobStatsBucket, 0)
+	for rows.Next() {
+		var (
+			item   domain.JobStatsBucket
+			kind   string
+			status string
+		)
+		if err := rows.Scan(&item.Day, &kind, &status, &item.Count, &item.AvgLatencyMS, &item.MaxLatencyMS); err != nil {
+			return nil, fmt.Errorf("scan job stats: %w", err)
+		}
+		item.Day = time.Date(item.Day.Year(), item.Day.Month(), item.Day.Day(), 0, 0, 0, 0, time.UTC)
+		item.Kind = domain.JobKind(kind)
+		item.Status = domain.JobStatus(status)
+		items = append(items, item)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("iterate job stats: %w", rows.Err())
+	}
+	return items, nil
+}
+
+func (r *PostgresJobsRepository) DeleteConversationJobs(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) (int, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return 0, tenant.ErrMismatch
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin erase tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, table := range []string{"summaries", "reports"} {
+		if _, err := tx.Exec(ctx,
+			"DELETE FROM "+table+" WHERE tenant_id = $1 AND conversation_id = $2",
+			tenantID,
+			conversationID,
+		); err != nil {
+			return 0, fmt.Errorf("delete %s: %w", table, err)
+		}
+	}
+
+	command, err := tx.Exec(ctx, `
+		DELETE FROM jobs
+		WHERE tenant_id = $1 AND conversation_id = $2
+	`, tenantID, conversationID)
+	if err != nil {
+		return 0, fmt.Errorf("delete jobs: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit erase tx: %w", err)
+	}
+	return int(command.RowsAffected()), nil
+}
+
+func (r *PostgresJobsRepository) ListArchivableJobs(
+	ctx context.Context,
+	updatedBefore time.Time,
+	limit int,
+) ([]domain.Job, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, kind, tenant_id, conversation_id, result, created_at, updated_at
+		FROM jobs
+		WHERE status = 'done'
+			AND result IS NOT NULL
+			AND result_archive_key IS NULL
+			AND updated_at < $1
+		ORDER BY updated_at ASC
+		LIMIT $2
+	`, updatedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list archivable jobs: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.Job, 0)
+	for rows.Next() {
+		var (
+			job    domain.Job
+			kind   string
+			result []byte
+		)
+		if err := rows.Scan(&job.ID, &kind, &job.TenantID, &job.ConversationID, &result, &job.CreatedAt, &job.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan archivable job: %w", err)
+		}
+		if result, err = r.open(ctx, job.ID, result); err != nil {
+			return nil, fmt.Errorf("decrypt archivable result job_id=%s: %w", job.ID, err)
+		}
+		job.Kind = domain.JobKind(kind)
+		job.Status = domain.JobStatusDone
+		job.Result = json.RawMessage(result)
+		items = append(items, job)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("iterate archivable jobs: %w", rows.Err())
+	}
+	return items, nil
+}
+
+func (r *PostgresJobsRepository) MarkJobArchived(
+	ctx context.Context,
+	jobID string,
+	archiveKey string,
+	archivedAt time.Time,
+) error {
+	command, err := r.pool.Exec(ctx, `
+		UPDATE jobs
+		SET result = NULL,
+			result_archive_key = $2,
+			archived_at = $3
+		WHERE id = $1
+	`, jobID, archiveKey, archivedAt)
+	if err != nil {
+		return fmt.Errorf("mark job archived: %w", err)
+	}
+	if command.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresJobsRepository) SetJobTags(ctx context.Context, jobID string, tags []string) error {
+	command, err := r.pool.Exec(ctx, `
+		UPDATE jobs
+		SET tags = COALESCE($2::text[], '{}')
+		WHERE id = $1
+	`, jobID, tags)
+	if err != nil {
+		return fmt.Errorf("set job tags: %w", err)
+	}
+	if command.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresJobsRepository) SaveJobCheckpoint(ctx context.Context, jobID string, checkpoint json.RawMessage) error {
+	sealed, err := r.seal(ctx, jobID, checkpoint)
+	if err != nil {
+		return fmt.Errorf("encrypt job checkpoint: %w", err)
+	}
+	command, err := r.pool.Exec(ctx, `
+		UPDATE jobs
+		SET checkpoint = $2
+		WHERE id = $1
+	`, jobID, sealed)
+	if err != nil {
+		return fmt.Errorf("save job checkpoint: %w", err)
+	}
+	if command.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// reportSearchVectorSQL indexes the report title (weight A) and section text (weight B)
+// of the plaintext result bound as $7, which is NULL when results are encrypted. It is kept
+// in sync with 0007_report_search.sql.
+const reportSearchVectorSQL = `setweight(to_tsvector('portuguese', COALESCE($7::jsonb->>'title', '')), 'A') ||
+					setweight(jsonb_to_tsvector('portuguese', COALESCE($7::jsonb->'sections', '[]'::jsonb), '["string"]'), 'B')`
+
+func (r *PostgresJobsRepository) seal(ctx context.Context, jobID string, value json.RawMessage) (json.RawMessage, error) {
+	if r.cipher == nil || len(value) == 0 {
+		return value, nil
+	}
+	sealed, err := r.cipher.Seal(ctx, value, jobAAD(jobID))
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(sealed), nil
+}
+
+func (r *PostgresJobsRepository) open(ctx context.Context, jobID string, value []byte) ([]byte, error) {
+	if r.cipher == nil || len(value) == 0 {
+		return value, nil
+	}
+	return r.cipher.Open(ctx, value, jobAAD(jobID))
+}
+
+// jobAAD binds ciphertexts to their row so values cannot be swapped between jobs.
+func jobAAD(jobID string) []byte {
+	return []byte("jobs:" + jobID)
+}
+
+func buildSummaryFilters(filter domain.SummaryListFilter) (string, []any) {
+	query := strings.Builder{}
+	query.WriteString("FROM jobs WHERE kind = 'summary'")
+
+	args := make([]any, 0, 4)
+	argIndex := 1
+
+	if tenantID := strings.TrimSpace(filter.TenantID); tenantID != "" {
+		query.WriteString(fmt.Sprintf(" AND tenant_id = $%d", argIndex))
+		args = append(args, tenantID)
+		argIndex++
+	}
+
+	if conversationID := strings.TrimSpace(filter.ConversationID); conversationID != "" {
+		query.WriteString(fmt.Sprintf(" AND conversation_id = $%d", argIndex))
+		args = append(args, conversationID)
+		argIndex++
+	}
+
+	if filter.From != nil {
+		query.WriteString(fmt.Sprintf(" AND created_at >= $%d", argIndex))
+		args = append(args, *filter.From)
+		argIndex++
+	}
+
+	if filter.To != nil {
+		query.WriteString(fmt.Sprintf(" AND created_at <= $%d", argIndex))
+		args = append(args, *filter.To)
+		argIndex++
+	}
+
+	return query.String(), args
+}
+
+func buildDigestFilters(filter domain.DigestListFilter) (string, []any) {
+	query := strings.Builder{}
+	query.WriteString("FROM jobs WHERE kind = 'digest'")
+
+	args := make([]any, 0, 3)
+	argIndex := 1
+
+	if tenantID := strings.TrimSpace(filter.TenantID); tenantID != "" {
+		query.WriteString(fmt.Sprintf(" AND tenant_id = $%d", argIndex))
+		args = append(args, tenantID)
+		argIndex++
+	}
+
+	if filter.From != nil {
+		query.WriteString(fmt.Sprintf(" AND created_at >= $%d", argIndex))
+		args = append(args, *filter.From)
+		argIndex++
+	}
+
+	if filter.To != nil {
+		query.WriteString(fmt.Sprintf(" AND created_at <= $%d", argIndex))
+		args = append(args, *filter.To)
+		argIndex++
+	}
+
+	return query.String(), args
+}
+
 func buildReportFilters(filter domain.ReportListFilter) (string, []any) {
 	query := strings.Builder{}
 	query.WriteString("FROM jobs WHERE kind = 'report'")
@@ -222,5 +796,17 @@ func buildReportFilters(filter domain.ReportListFilter) (string, []any) {
 		argIndex++
 	}
 
+	if search := strings.TrimSpace(filter.Query); search != "" {
+		query.WriteString(fmt.Sprintf(" AND result_search @@ websearch_to_tsquery('portuguese', $%d)", argIndex))
+		args = append(args, search)
+		argIndex++
+	}
+
+	if len(filter.Tags) > 0 {
+		query.WriteString(fmt.Sprintf(" AND tags @> $%d::text[]", argIndex))
+		args = append(args, filter.Tags)
+		argIndex++
+	}
+
 	return query.String(), args
 }
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LeasesRepository elects a single holder per lease name among replicas.
+type LeasesRepository interface {
+	// AcquireLease takes or renews the lease for ttl and reports whether holder has it.
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	// ReleaseLease gives the lease up early when holder has it.
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
+
+// MemoryLeasesRepository keeps leases in memory; every holder shares one process.
+type MemoryLeasesRepository struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+	now    func() time.Time
+}
+
+type memoryLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+func NewMemoryLeasesRepository() *MemoryLeasesRepository {
+	return &MemoryLeasesRepository{leases: make(map[string]memoryLease), now: time.Now}
+}
+
+func (r *MemoryLeasesRepository) AcquireLease(_ context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if lease, ok := r.leases[name]; ok && lease.holder != holder && lease.expiresAt.After(now) {
+		return false, nil
+	}
+	r.leases[name] = memoryLease{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (r *MemoryLeasesRepository) ReleaseLease(_ context.Context, name, holder string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if lease, ok := r.leases[name]; ok && lease.holder == holder {
+		delete(r.leases, name)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresLeasesRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresLeasesRepository(pool *pgxpool.Pool) *PostgresLeasesRepository {
+	return &PostgresLeasesRepository{pool: pool}
+}
+
+// AcquireLease inserts the lease, renewing it for its holder or taking it over once
+// expired. The database clock decides expiry so replicas with skewed clocks agree.
+func (r *PostgresLeasesRepository) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO scheduler_leases (name, holder, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (name) DO UPDATE SET
+			holder = EXCLUDED.holder,
+			expires_at = EXCLUDED.expires_at
+		WHERE scheduler_leases.holder = EXCLUDED.holder
+			OR scheduler_leases.expires_at <= NOW()
+	`, name, holder, ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("acquire lease: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *PostgresLeasesRepository) ReleaseLease(ctx context.Context, name, holder string) error {
+	if _, err := r.pool.Exec(ctx, `
+		DELETE FROM scheduler_leases WHERE name = $1 AND holder = $2
+	`, name, holder); err != nil {
+		return fmt.Errorf("release lease: %w", err)
+	}
+	return nil
+}
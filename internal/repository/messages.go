@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+// MessagesRepository stores conversation history used by context retrieval.
+type MessagesRepository interface {
+	// AppendMessages stores messages not seen before (by SourceMessageID) and returns how many were inserted.
+	AppendMessages(ctx context.Context, messages []domain.Message) (int, error)
+	// ListRecentMessages returns up to limit latest messages in chronological order.
+	ListRecentMessages(ctx context.Context, tenantID, conversationID string, limit int) ([]domain.Message, error)
+	// ListTenantMessages returns up to limit latest messages of every tenant conversation
+	// created in [from, to), in chronological order.
+	ListTenantMessages(ctx context.Context, tenantID string, from, to time.Time, limit int) ([]domain.Message, error)
+	DeleteConversationMessages(ctx context.Context, tenantID, conversationID string) (int, error)
+}
+
+type MemoryMessagesRepository struct {
+	mu            sync.RWMutex
+	conversations map[string][]domain.Message
+	seen          map[string]struct{}
+}
+
+func NewMemoryMessagesRepository() *MemoryMessagesRepository {
+	return &MemoryMessagesRepository{
+		conversations: make(map[string][]domain.Message),
+		seen:          make(map[string]struct{}),
+	}
+}
+
+func (r *MemoryMessagesRepository) AppendMessages(ctx context.Context, messages []domain.Message) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inserted := 0
+	touched := make(map[string]struct{})
+	for _, message := range messages {
+		if !tenant.Allows(ctx, message.TenantID) {
+			return inserted, tenant.ErrMismatch
+		}
+		key := conversationKey(message.TenantID, message.ConversationID)
+		dedupeKey := key + "\x00" + message.SourceMessageID
+		if _, exists := r.seen[dedupeKey]; exists {
+			continue
+		}
+		r.seen[dedupeKey] = struct{}{}
+		r.conversations[key] = append(r.conversations[key], message)
+		touched[key] = struct{}{}
+		inserted++
+	}
+
+	for key := range touched {
+		sortMessages(r.conversations[key])
+	}
+	return inserted, nil
+}
+
+func (r *MemoryMessagesRepository) ListRecentMessages(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+	limit int,
+) ([]domain.Message, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return []domain.Message{}, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored := r.conversations[conversationKey(tenantID, conversationID)]
+	start := 0
+	if limit > 0 && len(stored) > limit {
+		start = len(stored) - limit
+	}
+	return append([]domain.Message(nil), stored[start:]...), nil
+}
+
+func (r *MemoryMessagesRepository) ListTenantMessages(
+	ctx context.Context,
+	tenantID string,
+	from time.Time,
+	to time.Time,
+	limit int,
+) ([]domain.Message, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return []domain.Message{}, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := make([]domain.Message, 0)
+	for _, stored := range r.conversations {
+		for _, message := range stored {
+			if message.TenantID != tenantID || message.CreatedAt.Before(from) || !message.CreatedAt.Before(to) {
+				continue
+			}
+			matched = append(matched, message)
+		}
+	}
+	sortMessages(matched)
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+	return matched, nil
+}
+
+func (r *MemoryMessagesRepository) DeleteConversationMessages(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) (int, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return 0, tenant.ErrMismatch
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := conversationKey(tenantID, conversationID)
+	deleted := len(r.conversations[key])
+	for _, message := range r.conversations[key] {
+		delete(r.seen, key+"\x00"+message.SourceMessageID)
+	}
+	delete(r.conversations, key)
+	return deleted, nil
+}
+
+func conversationKey(tenantID, conversationID string) string {
+	return tenantID + "\x00" + conversationID
+}
+
+func sortMessages(messages []domain.Message) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresMessagesRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresMessagesRepository(pool *pgxpool.Pool) *PostgresMessagesRepository {
+	return &PostgresMessagesRepository{pool: pool}
+}
+
+func (r *PostgresMessagesRepository) AppendMessages(ctx context.Context, messages []domain.Message) (int, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	for _, message := range messages {
+		if !tenant.Allows(ctx, message.TenantID) {
+			return 0, tenant.ErrMismatch
+		}
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin messages tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// The partitioned table cannot carry a unique dedupe constraint across date
+	// partitions, so batches of the same conversation are serialized instead.
+	locked := make(map[string]struct{})
+	for _, message := range messages {
+		key := conversationKey(message.TenantID, message.ConversationID)
+		if _, ok := locked[key]; ok {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, key); err != nil {
+			return 0, fmt.Errorf("lock conversation messages: %w", err)
+		}
+		locked[key] = struct{}{}
+	}
+
+	inserted := 0
+	for _, message := range messages {
+		command, err := tx.Exec(ctx, `
+			INSERT INTO messages (
+				tenant_id,
+				conversation_id,
+				source_message_id,
+				author_role,
+				message_text,
+				dedupe_key,
+				checksum,
+				created_at,
+				ingested_at
+			)
+			SELECT $1,$2,$3,$4,$5,$3,$6,$7,$8
+			WHERE NOT EXISTS (
+				SELECT 1 FROM messages
+				WHERE tenant_id = $1 AND conversation_id = $2 AND dedupe_key = $3
+			)
+		`,
+			message.TenantID,
+			message.ConversationID,
+			message.SourceMessageID,
+			string(message.AuthorRole),
+			message.Text,
+			message.Checksum,
+			message.CreatedAt,
+			message.IngestedAt,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("insert message: %w", err)
+		}
+		inserted += int(command.RowsAffected())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit messages tx: %w", err)
+	}
+	return inserted, nil
+}
+
+func (r *PostgresMessagesRepository) ListRecentMessages(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+	limit int,
+) ([]domain.Message, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return []domain.Message{}, nil
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, conversation_id, COALESCE(source_message_id, ''), author_role, message_text, checksum, created_at, ingested_at
+		FROM (
+			SELECT *
+			FROM messages
+			WHERE tenant_id = $1 AND conversation_id = $2
+			ORDER BY created_at DESC, ingested_at DESC
+			LIMIT $3
+		) recent
+		ORDER BY created_at ASC, ingested_at ASC
+	`, tenantID, conversationID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list messages: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.Message, 0, limit)
+	for rows.Next() {
+		var (
+			message domain.Message
+			role    string
+		)
+		if err := rows.Scan(
+			&message.ID,
+			&message.TenantID,
+			&message.ConversationID,
+			&message.SourceMessageID,
+			&role,
+			&message.Text,
+			&message.Checksum,
+			&message.CreatedAt,
+			&message.IngestedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		message.AuthorRole = domain.MessageAuthorRole(role)
+		items = append(items, message)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("iterate messages: %w", rows.Err())
+	}
+	return items, nil
+}
+
+func (r *PostgresMessagesRepository) ListTenantMessages(
+	ctx context.Context,
+	tenantID string,
+	from time.Time,
+	to time.Time,
+	limit int,
+) ([]domain.Message, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return []domain.Message{}, nil
+	}
+	if limit <= 0 {
+		limit = 500
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, conversation_id, COALESCE(source_message_id, ''), author_role, message_text, checksum, created_at, ingested_at
+		FROM (
+			SELECT *
+			FROM messages
+			WHERE tenant_id = $1 AND created_at >= $2 AND created_at < $3
+			ORDER BY created_at DESC, ingested_at DESC
+			LIMIT $4
+		) recent
+		ORDER BY created_at ASC, ingested_at ASC
+	`, tenantID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list tenant messages: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.Message, 0)
+	for rows.Next() {
+		var (
+			message domain.Message
+			role    string
+		)
+		if err := rows.Scan(
+			&message.ID,
+			&message.TenantID,
+			&message.ConversationID,
+			&message.SourceMessageID,
+			&role,
+			&message.Text,
+			&message.Checksum,
+			&message.CreatedAt,
+			&message.IngestedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan tenant message: %w", err)
+		}
+		message.AuthorRole = domain.MessageAuthorRole(role)
+		items = append(items, message)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("iterate tenant messages: %w", rows.Err())
+	}
+	return items, nil
+}
+
+func (r *PostgresMessagesRepository) DeleteConversationMessages(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) (int, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return 0, tenant.ErrMismatch
+	}
+
+	command, err := r.pool.Exec(ctx, `
+		DELETE FROM messages WHERE tenant_id = $1 AND conversation_id = $2
+	`, tenantID, conversationID)
+	if err != nil {
+		return 0, fmt.Errorf("delete conversation messages: %w", err)
+	}
+	return int(command.RowsAffected()), nil
+}
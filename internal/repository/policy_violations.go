@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// PolicyViolationsRepository keeps the requests blocked by the content policy.
+type PolicyViolationsRepository interface {
+	RecordPolicyViolation(ctx context.Context, record domain.PolicyViolationRecord) error
+	// PolicyViolationCounts aggregates by tenant, code and rule, most frequent first.
+	PolicyViolationCounts(ctx context.Context, filter domain.PolicyViolationFilter) ([]domain.PolicyViolationCount, error)
+}
+
+type MemoryPolicyViolationsRepository struct {
+	mu      sync.RWMutex
+	records []domain.PolicyViolationRecord
+}
+
+func NewMemoryPolicyViolationsRepository() *MemoryPolicyViolationsRepository {
+	return &MemoryPolicyViolationsRepository{records: make([]domain.PolicyViolationRecord, 0)}
+}
+
+func (r *MemoryPolicyViolationsRepository) RecordPolicyViolation(_ context.Context, record domain.PolicyViolationRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record.Codes = append([]string(nil), record.Codes...)
+	r.records = append(r.records, record)
+	return nil
+}
+
+func (r *MemoryPolicyViolationsRepository) PolicyViolationCounts(
+	_ context.Context,
+	filter domain.PolicyViolationFilter,
+) ([]domain.PolicyViolationCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type countKey struct {
+		tenantID string
+		code     string
+		rule     string
+	}
+	counts := make(map[countKey]*domain.PolicyViolationCount)
+	for _, record := range r.records {
+		if filter.TenantID != "" && record.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.From != nil && record.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && record.CreatedAt.After(*filter.To) {
+			continue
+		}
+		for _, code := range record.Codes {
+			key := countKey{tenantID: record.TenantID, code: code, rule: record.Rule}
+			count, ok := counts[key]
+			if !ok {
+				count = &domain.PolicyViolationCount{TenantID: record.TenantID, Code: code, Rule: record.Rule}
+				counts[key] = count
+			}
+			count.Count++
+			if record.CreatedAt.After(count.LastSeenAt) {
+				count.LastSeenAt = record.CreatedAt
+			}
+		}
+	}
+
+	result := make([]domain.PolicyViolationCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	sortPolicyViolationCounts(result)
+	return result, nil
+}
+
+func sortPolicyViolationCounts(counts []domain.PolicyViolationCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		if counts[i].TenantID != counts[j].TenantID {
+			return counts[i].TenantID < counts[j].TenantID
+		}
+		if counts[i].Code != counts[j].Code {
+			return counts[i].Code < counts[j].Code
+		}
+		return counts[i].Rule < counts[j].Rule
+	})
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresPolicyViolationsRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresPolicyViolationsRepository(pool *pgxpool.Pool) *PostgresPolicyViolationsRepository {
+	return &PostgresPolicyViolationsRepository{pool: pool}
+}
+
+func (r *PostgresPolicyViolationsRepository) RecordPolicyViolation(ctx context.Context, record domain.PolicyViolationRecord) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO policy_violations (
+			id,
+			tenant_id,
+			conversation_id,
+			route,
+			codes,
+			rule,
+			request_id,
+			created_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	`,
+		record.ID,
+		record.TenantID,
+		record.ConversationID,
+		record.Route,
+		record.Codes,
+		record.Rule,
+		record.RequestID,
+		record.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert policy violation: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresPolicyViolationsRepository) PolicyViolationCounts(
+	ctx context.Context,
+	filter domain.PolicyViolationFilter,
+) ([]domain.PolicyViolationCount, error) {
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 3)
+	if filter.TenantID != "" {
+		args = append(args, filter.TenantID)
+		conditions = append(conditions, fmt.Sprintf("tenant_id = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT tenant_id, code, rule, COUNT(*), MAX(created_at)
+		FROM policy_violations, UNNEST(codes) AS code
+		`+where+`
+		GROUP BY tenant_id, code, rule
+		ORDER BY COUNT(*) DESC, tenant_id, code, rule
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("count policy violations: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make([]domain.PolicyViolationCount, 0)
+	for rows.Next() {
+		var count domain.PolicyViolationCount
+		if err := rows.Scan(&count.TenantID, &count.Code, &count.Rule, &count.Count, &count.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("scan policy violation count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate policy violation counts: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+// PseudonymsRepository stores the reversible PII tokens of each conversation.
+type PseudonymsRepository interface {
+	// AssignPseudonym returns the pseudonym already holding the category and value hash in
+	// the conversation, or stores pseudonym under the next token of label ("[TEL_2]").
+	AssignPseudonym(ctx context.Context, pseudonym domain.Pseudonym, label string) (domain.Pseudonym, error)
+	// ListPseudonyms returns the conversation tokens in creation order.
+	ListPseudonyms(ctx context.Context, tenantID, conversationID string) ([]domain.Pseudonym, error)
+	DeleteConversationPseudonyms(ctx context.Context, tenantID, conversationID string) (int, error)
+}
+
+func pseudonymToken(label string, sequence int) string {
+	return fmt.Sprintf("[%s_%d]", label, sequence)
+}
+
+type MemoryPseudonymsRepository struct {
+	mu            sync.Mutex
+	conversations map[string][]domain.Pseudonym
+}
+
+func NewMemoryPseudonymsRepository() *MemoryPseudonymsRepository {
+	return &MemoryPseudonymsRepository{conversations: make(map[string][]domain.Pseudonym)}
+}
+
+func (r *MemoryPseudonymsRepository) AssignPseudonym(
+	ctx context.Context,
+	pseudonym domain.Pseudonym,
+	label string,
+) (domain.Pseudonym, error) {
+	if !tenant.Allows(ctx, pseudonym.TenantID) {
+		return domain.Pseudonym{}, tenant.ErrMismatch
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := conversationKey(pseudonym.TenantID, pseudonym.ConversationID)
+	sequence := 1
+	for _, existing := range r.conversations[key] {
+		if existing.Category != pseudonym.Category {
+			continue
+		}
+		if existing.ValueHash == pseudonym.ValueHash {
+			return existing, nil
+		}
+		sequence++
+	}
+	pseudonym.Token = pseudonymToken(label, sequence)
+	r.conversations[key] = append(r.conversations[key], pseudonym)
+	return pseudonym, nil
+}
+
+func (r *MemoryPseudonymsRepository) ListPseudonyms(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) ([]domain.Pseudonym, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return []domain.Pseudonym{}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.conversations[conversationKey(tenantID, conversationID)]
+	return append(make([]domain.Pseudonym, 0, len(stored)), stored...), nil
+}
+
+func (r *MemoryPseudonymsRepository) DeleteConversationPseudonyms(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) (int, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := conversationKey(tenantID, conversationID)
+	deleted := len(r.conversations[key])
+	delete(r.conversations, key)
+	return deleted, nil
+}
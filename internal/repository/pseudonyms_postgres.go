@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresPseudonymsRepository struct {
+	pool   *pgxpool.Pool
+	cipher PayloadCipher
+}
+
+func NewPostgresPseudonymsRepository(pool *pgxpool.Pool) *PostgresPseudonymsRepository {
+	return &PostgresPseudonymsRepository{pool: pool}
+}
+
+// UseCipher seals the original values at rest, like job payloads.
+func (r *PostgresPseudonymsRepository) UseCipher(cipher PayloadCipher) {
+	r.cipher = cipher
+}
+
+// AssignPseudonym serializes assignments per conversation so two values never get the
+// same sequence number.
+func (r *PostgresPseudonymsRepository) AssignPseudonym(
+	ctx context.Context,
+	pseudonym domain.Pseudonym,
+	label string,
+) (domain.Pseudonym, error) {
+	if !tenant.Allows(ctx, pseudonym.TenantID) {
+		return domain.Pseudonym{}, tenant.ErrMismatch
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return domain.Pseudonym{}, fmt.Errorf("begin pseudonyms tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	key := "pii_pseudonyms:" + conversationKey(pseudonym.TenantID, pseudonym.ConversationID)
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, key); err != nil {
+		return domain.Pseudonym{}, fmt.Errorf("lock conversation pseudonyms: %w", err)
+	}
+
+	existing := pseudonym
+	var value []byte
+	err = tx.QueryRow(ctx, `
+		SELECT token, value, created_at
+		FROM pii_pseudonyms
+		WHERE tenant_id = $1 AND conversation_id = $2 AND category = $3 AND value_hash = $4
+	`, pseudonym.TenantID, pseudonym.ConversationID, pseudonym.Category, pseudonym.ValueHash).Scan(
+		&existing.Token,
+		&value,
+		&existing.CreatedAt,
+	)
+	switch {
+	case err == nil:
+		opened, err := r.open(ctx, existing, value)
+		if err != nil {
+			return domain.Pseudonym{}, fmt.Errorf("open pseudonym value: %w", err)
+		}
+		existing.Value = string(opened)
+		return existing, nil
+	case !errors.Is(err, pgx.ErrNoRows):
+		return domain.Pseudonym{}, fmt.Errorf("find pseudonym: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM pii_pseudonyms
+		WHERE tenant_id = $1 AND conversation_id = $2 AND category = $3
+	`, pseudonym.TenantID, pseudonym.ConversationID, pseudonym.Category).Scan(&count); err != nil {
+		return domain.Pseudonym{}, fmt.Errorf("count pseudonyms: %w", err)
+	}
+	pseudonym.Token = pseudonymToken(label, count+1)
+
+	sealed, err := r.seal(ctx, pseudonym)
+	if err != nil {
+		return domain.Pseudonym{}, fmt.Errorf("seal pseudonym value: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO pii_pseudonyms (
+			tenant_id,
+			conversation_id,
+			token,
+			category,
+			value_hash,
+			value,
+			created_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7)
+	`,
+		pseudonym.TenantID,
+		pseudonym.ConversationID,
+		pseudonym.Token,
+		pseudonym.Category,
+		pseudonym.ValueHash,
+		sealed,
+		pseudonym.CreatedAt,
+	); err != nil {
+		return domain.Pseudonym{}, fmt.Errorf("insert pseudonym: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return domain.Pseudonym{}, fmt.Errorf("commit pseudonyms tx: %w", err)
+	}
+	return pseudonym, nil
+}
+
+func (r *PostgresPseudonymsRepository) ListPseudonyms(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) ([]domain.Pseudonym, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return []domain.Pseudonym{}, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT token, category, value_hash, value, created_at
+		FROM pii_pseudonyms
+		WHERE tenant_id = $1 AND conversation_id = $2
+		ORDER BY created_at ASC, token ASC
+	`, tenantID, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("list pseudonyms: %w", err)
+	}
+	defer rows.Close()
+
+	pseudonyms := make([]domain.Pseudonym, 0)
+	for rows.Next() {
+		pseudonym := domain.Pseudonym{TenantID: tenantID, ConversationID: conversationID}
+		var value []byte
+		if err := rows.Scan(&pseudonym.Token, &pseudonym.Category, &pseudonym.ValueHash, &value, &pseudonym.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan pseudonym: %w", err)
+		}
+		opened, err := r.open(ctx, pseudonym, value)
+		if err != nil {
+			return nil, fmt.Errorf("open pseudonym value: %w", err)
+		}
+		pseudonym.Value = string(opened)
+		pseudonyms = append(pseudonyms, pseudonym)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pseudonyms: %w", err)
+	}
+	return pseudonyms, nil
+}
+
+func (r *PostgresPseudonymsRepository) DeleteConversationPseudonyms(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+) (int, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return 0, nil
+	}
+
+	command, err := r.pool.Exec(ctx, `
+		DELETE FROM pii_pseudonyms WHERE tenant_id = $1 AND conversation_id = $2
+	`, tenantID, conversationID)
+	if err != nil {
+		return 0, fmt.Errorf("delete conversation pseudonyms: %w", err)
+	}
+	return int(command.RowsAffected()), nil
+}
+
+func (r *PostgresPseudonymsRepository) seal(ctx context.Context, pseudonym domain.Pseudonym) ([]byte, error) {
+	if r.cipher == nil {
+		return []byte(pseudonym.Value), nil
+	}
+	return r.cipher.Seal(ctx, []byte(pseudonym.Value), pseudonymAAD(pseudonym))
+}
+
+func (r *PostgresPseudonymsRepository) open(ctx context.Context, pseudonym domain.Pseudonym, value []byte) ([]byte, error) {
+	if r.cipher == nil || len(value) == 0 {
+		return value, nil
+	}
+	return r.cipher.Open(ctx, value, pseudonymAAD(pseudonym))
+}
+
+// pseudonymAAD binds ciphertexts to their token.
+func pseudonymAAD(pseudonym domain.Pseudonym) []byte {
+	return []byte("pii_pseudonyms:" + conversationKey(pseudonym.TenantID, pseudonym.ConversationID) + "\x00" + pseudonym.Token)
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+// TemplatesRepository persists tenant reply templates. Names are unique per tenant
+// (case-insensitive); violations return ErrConflict.
+type TemplatesRepository interface {
+	CreateTemplate(ctx context.Context, template *domain.ReplyTemplate) error
+	UpdateTemplate(ctx context.Context, template *domain.ReplyTemplate) error
+	GetTemplate(ctx context.Context, templateID string) (*domain.ReplyTemplate, error)
+	ListTemplates(ctx context.Context, filter domain.TemplateListFilter) ([]domain.ReplyTemplate, int, error)
+	DeleteTemplate(ctx context.Context, templateID string) error
+}
+
+// MemoryTemplatesRepository keeps templates in memory for local development.
+type MemoryTemplatesRepository struct {
+	mu        sync.RWMutex
+	templates map[string]domain.ReplyTemplate
+}
+
+func NewMemoryTemplatesRepository() *MemoryTemplatesRepository {
+	return &MemoryTemplatesRepository{
+		templates: make(map[string]domain.ReplyTemplate),
+	}
+}
+
+func (r *MemoryTemplatesRepository) CreateTemplate(ctx context.Context, template *domain.ReplyTemplate) error {
+	if !tenant.Allows(ctx, template.TenantID) {
+		return tenant.ErrMismatch
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTakenLocked(template.TenantID, template.Name, template.ID) {
+		return ErrConflict
+	}
+	r.templates[template.ID] = cloneTemplate(*template)
+	return nil
+}
+
+func (r *MemoryTemplatesRepository) UpdateTemplate(ctx context.Context, template *domain.ReplyTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.templates[template.ID]
+	if !ok || current.TenantID != template.TenantID || !tenant.Allows(ctx, current.TenantID) {
+		return ErrNotFound
+	}
+	if r.nameTakenLocked(template.TenantID, template.Name, template.ID) {
+		return ErrConflict
+	}
+	template.CreatedAt = current.CreatedAt
+	r.templates[template.ID] = cloneTemplate(*template)
+	return nil
+}
+
+func (r *MemoryTemplatesRepository) GetTemplate(ctx context.Context, templateID string) (*domain.ReplyTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, ok := r.templates[templateID]
+	if !ok || !tenant.Allows(ctx, template.TenantID) {
+		return nil, ErrNotFound
+	}
+	clone := cloneTemplate(template)
+	return &clone, nil
+}
+
+func (r *MemoryTemplatesRepository) ListTemplates(
+	ctx context.Context,
+	filter domain.TemplateListFilter,
+) ([]domain.ReplyTemplate, int, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	items := make([]domain.ReplyTemplate, 0)
+	for _, template := range r.templates {
+		if tenantID != "" && template.TenantID != tenantID {
+			continue
+		}
+		items = append(items, cloneTemplate(template))
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return strings.ToLower(items[i].Name) < strings.ToLower(items[j].Name)
+	})
+
+	total := len(items)
+	start := (filter.Page - 1) * filter.PageSize
+	if start >= total {
+		return []domain.ReplyTemplate{}, total, nil
+	}
+	end := start + filter.PageSize
+	if end > total {
+		end = total
+	}
+	return items[start:end], total, nil
+}
+
+func (r *MemoryTemplatesRepository) DeleteTemplate(ctx context.Context, templateID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, ok := r.templates[templateID]
+	if !ok || !tenant.Allows(ctx, template.TenantID) {
+		return ErrNotFound
+	}
+	delete(r.templates, templateID)
+	return nil
+}
+
+func (r *MemoryTemplatesRepository) nameTakenLocked(tenantID, name, exceptID string) bool {
+	for id, existing := range r.templates {
+		if id != exceptID && existing.TenantID == tenantID && strings.EqualFold(existing.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneTemplate(template domain.ReplyTemplate) domain.ReplyTemplate {
+	template.Placeholders = append([]string(nil), template.Placeholders...)
+	return template
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const pgUniqueViolation = "23505"
+
+type PostgresTemplatesRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresTemplatesRepository(pool *pgxpool.Pool) *PostgresTemplatesRepository {
+	return &PostgresTemplatesRepository{pool: pool}
+}
+
+func (r *PostgresTemplatesRepository) CreateTemplate(ctx context.Context, template *domain.ReplyTemplate) error {
+	if !tenant.Allows(ctx, template.TenantID) {
+		return tenant.ErrMismatch
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO reply_templates (
+			id,
+			tenant_id,
+			name,
+			content,
+			locale,
+			placeholders,
+			created_at,
+			updated_at
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	`,
+		template.ID,
+		template.TenantID,
+		template.Name,
+		template.Content,
+		template.Locale,
+		template.Placeholders,
+		template.CreatedAt,
+		template.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrConflict
+		}
+		return fmt.Errorf("insert template: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTemplatesRepository) UpdateTemplate(ctx context.Context, template *domain.ReplyTemplate) error {
+	if !tenant.Allows(ctx, template.TenantID) {
+		return ErrNotFound
+	}
+
+	err := r.pool.QueryRow(ctx, `
+		UPDATE reply_templates
+		SET name = $3,
+			content = $4,
+			locale = $5,
+			placeholders = $6,
+			updated_at = $7
+		WHERE id = $1 AND tenant_id = $2
+		RETURNING created_at
+	`,
+		template.ID,
+		template.TenantID,
+		template.Name,
+		template.Content,
+		template.Locale,
+		template.Placeholders,
+		template.UpdatedAt,
+	).Scan(&template.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return ErrConflict
+		}
+		return fmt.Errorf("update template: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTemplatesRepository) GetTemplate(ctx context.Context, templateID string) (*domain.ReplyTemplate, error) {
+	var template domain.ReplyTemplate
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, tenant_id, name, content, locale, placeholders, created_at, updated_at
+		FROM reply_templates
+		WHERE id = $1
+	`, templateID).Scan(
+		&template.ID,
+		&template.TenantID,
+		&template.Name,
+		&template.Content,
+		&template.Locale,
+		&template.Placeholders,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query template: %w", err)
+	}
+
+	// Defense in depth on top of row-level security.
+	if !tenant.Allows(ctx, template.TenantID) {
+		return nil, ErrNotFound
+	}
+	return &template, nil
+}
+
+func (r *PostgresTemplatesRepository) ListTemplates(
+	ctx context.Context,
+	filter domain.TemplateListFilter,
+) ([]domain.ReplyTemplate, int, error) {
+	tenantID, err := tenant.Resolve(ctx, filter.TenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM reply_templates WHERE ($1 = '' OR tenant_id = $1)
+	`, tenantID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count templates: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, name, content, locale, placeholders, created_at, updated_at
+		FROM reply_templates
+		WHERE ($1 = '' OR tenant_id = $1)
+		ORDER BY lower(name) ASC, id ASC
+		LIMIT $2 OFFSET $3
+	`, tenantID, filter.PageSize, (filter.Page-1)*filter.PageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query templates: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.ReplyTemplate, 0)
+	for rows.Next() {
+		var template domain.ReplyTemplate
+		if err := rows.Scan(
+			&template.ID,
+			&template.TenantID,
+			&template.Name,
+			&template.Content,
+			&template.Locale,
+			&template.Placeholders,
+			&template.CreatedAt,
+			&template.UpdatedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("scan template: %w", err)
+		}
+		items = append(items, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate templates: %w", err)
+	}
+	return items, total, nil
+}
+
+func (r *PostgresTemplatesRepository) DeleteTemplate(ctx context.Context, templateID string) error {
+	scopeTenant, _ := tenant.FromContext(ctx)
+	command, err := r.pool.Exec(ctx, `
+		DELETE FROM reply_templates
+		WHERE id = $1 AND ($2 = '' OR tenant_id = $2)
+	`, templateID, scopeTenant)
+	if err != nil {
+		return fmt.Errorf("delete template: %w", err)
+	}
+	if command.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// tenantGUC keeps the app.tenant_id and app.rls_bypass settings of each pooled connection
+// aligned with the context acquiring it. Row-level security policies (migrations 0008 and
+// 0029) read them: a scoped context sees its tenant, a context marked with
+// tenant.WithBypass (workers, archiver, scheduler, admin routes) sees every tenant and any
+// other context sees nothing.
+type tenantGUC struct {
+	current sync.Map // *pgx.Conn -> gucState
+}
+
+type gucState struct {
+	tenantID string
+	bypass   bool
+}
+
+func installTenantGUC(config *pgxpool.Config) {
+	guc := &tenantGUC{}
+	config.BeforeAcquire = guc.beforeAcquire
+	config.BeforeClose = guc.beforeClose
+}
+
+func (g *tenantGUC) beforeAcquire(ctx context.Context, conn *pgx.Conn) bool {
+	tenantID, _ := tenant.FromContext(ctx)
+	state := gucState{tenantID: tenantID, bypass: tenant.Bypassed(ctx)}
+	if current, ok := g.current.Load(conn); ok && current.(gucState) == state {
+		return true
+	}
+	bypass := "off"
+	if state.bypass {
+		bypass = "on"
+	}
+	if _, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false), set_config('app.rls_bypass', $2, false)", tenantID, bypass); err != nil {
+		// Returning false discards the connection; the pool acquires another one.
+		g.current.Delete(conn)
+		return false
+	}
+	g.current.Store(conn, state)
+	return true
+}
+
+func (g *tenantGUC) beforeClose(conn *pgx.Conn) {
+	g.current.Delete(conn)
+}
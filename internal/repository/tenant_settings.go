@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+// TenantSettingsRepository persists one settings record per tenant. Missing records
+// return ErrNotFound.
+type TenantSettingsRepository interface {
+	GetTenantSettings(ctx context.Context, tenantID string) (*domain.TenantSettings, error)
+	ListTenantSettings(ctx context.Context) ([]domain.TenantSettings, error)
+	// SaveTenantSettings creates or replaces the settings of settings.TenantID.
+	SaveTenantSettings(ctx context.Context, settings *domain.TenantSettings) error
+	DeleteTenantSettings(ctx context.Context, tenantID string) error
+}
+
+// MemoryTenantSettingsRepository keeps tenant settings in memory for local development.
+type MemoryTenantSettingsRepository struct {
+	mu       sync.RWMutex
+	settings map[string]domain.TenantSettings
+}
+
+func NewMemoryTenantSettingsRepository() *MemoryTenantSettingsRepository {
+	return &MemoryTenantSettingsRepository{
+		settings: make(map[string]domain.TenantSettings),
+	}
+}
+
+func (r *MemoryTenantSettingsRepository) GetTenantSettings(ctx context.Context, tenantID string) (*domain.TenantSettings, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return nil, ErrNotFound
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	settings, ok := r.settings[tenantID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	clone := cloneTenantSettings(settings)
+	return &clone, nil
+}
+
+func (r *MemoryTenantSettingsRepository) ListTenantSettings(ctx context.Context) ([]domain.TenantSettings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]domain.TenantSettings, 0, len(r.settings))
+	for _, settings := range r.settings {
+		if tenant.Allows(ctx, settings.TenantID) {
+			items = append(items, cloneTenantSettings(settings))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].TenantID < items[j].TenantID
+	})
+	return items, nil
+}
+
+func (r *MemoryTenantSettingsRepository) SaveTenantSettings(ctx context.Context, settings *domain.TenantSettings) error {
+	if !tenant.Allows(ctx, settings.TenantID) {
+		return tenant.ErrMismatch
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settings[settings.TenantID] = cloneTenantSettings(*settings)
+	return nil
+}
+
+func (r *MemoryTenantSettingsRepository) DeleteTenantSettings(ctx context.Context, tenantID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.settings[tenantID]; !ok || !tenant.Allows(ctx, tenantID) {
+		return ErrNotFound
+	}
+	delete(r.settings, tenantID)
+	return nil
+}
+
+func cloneTenantSettings(settings domain.TenantSettings) domain.TenantSettings {
+	models := make(map[string]string, len(settings.Models))
+	for task, model := range settings.Models {
+		models[task] = model
+	}
+	settings.Models = models
+	return settings
+}
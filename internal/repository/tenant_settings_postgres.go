@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresTenantSettingsRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresTenantSettingsRepository(pool *pgxpool.Pool) *PostgresTenantSettingsRepository {
+	return &PostgresTenantSettingsRepository{pool: pool}
+}
+
+const tenantSettingsColumns = `tenant_id, locale, tone, models, quotas, policy_profile, updated_at`
+
+func (r *PostgresTenantSettingsRepository) GetTenantSettings(ctx context.Context, tenantID string) (*domain.TenantSettings, error) {
+	if !tenant.Allows(ctx, tenantID) {
+		return nil, ErrNotFound
+	}
+
+	settings, err := scanTenantSettings(r.pool.QueryRow(ctx, `
+		SELECT `+tenantSettingsColumns+`
+		FROM tenant_settings
+		WHERE tenant_id = $1
+	`, tenantID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query tenant settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (r *PostgresTenantSettingsRepository) ListTenantSettings(ctx context.Context) ([]domain.TenantSettings, error) {
+	scopeTenant, _ := tenant.FromContext(ctx)
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+tenantSettingsColumns+`
+		FROM tenant_settings
+		WHERE ($1 = '' OR tenant_id = $1)
+		ORDER BY tenant_id ASC
+	`, scopeTenant)
+	if err != nil {
+		return nil, fmt.Errorf("query tenant settings: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.TenantSettings, 0)
+	for rows.Next() {
+		settings, err := scanTenantSettings(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan tenant settings: %w", err)
+		}
+		items = append(items, *settings)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tenant settings: %w", err)
+	}
+	return items, nil
+}
+
+func (r *PostgresTenantSettingsRepository) SaveTenantSettings(ctx context.Context, settings *domain.TenantSettings) error {
+	if !tenant.Allows(ctx, settings.TenantID) {
+		return tenant.ErrMismatch
+	}
+	models, err := json.Marshal(settings.Models)
+	if err != nil {
+		return fmt.Errorf("encode tenant models: %w", err)
+	}
+	quotas, err := json.Marshal(settings.Quotas)
+	if err != nil {
+		return fmt.Errorf("encode tenant quotas: %w", err)
+	}
+
+	_, err = r.pool.Exec(ctx, `
+		INSERT INTO tenant_settings (`+tenantSettingsColumns+`)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			locale = EXCLUDED.locale,
+			tone = EXCLUDED.tone,
+			models = EXCLUDED.models,
+			quotas = EXCLUDED.quotas,
+			policy_profile = EXCLUDED.policy_profile,
+			updated_at = EXCLUDED.updated_at
+	`,
+		settings.TenantID,
+		settings.Locale,
+		settings.Tone,
+		models,
+		quotas,
+		settings.PolicyProfile,
+		settings.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("save tenant settings: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTenantSettingsRepository) DeleteTenantSettings(ctx context.Context, tenantID string) error {
+	if !tenant.Allows(ctx, tenantID) {
+		return ErrNotFound
+	}
+	command, err := r.pool.Exec(ctx, `DELETE FROM tenant_settings WHERE tenant_id = $1`, tenantID)
+	if err != nil {
+		return fmt.Errorf("delete tenant settings: %w", err)
+	}
+	if command.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanTenantSettings(row pgx.Row) (*domain.TenantSettings, error) {
+	var (
+		settings domain.TenantSettings
+		models   []byte
+		quotas   []byte
+	)
+	if err := row.Scan(
+		&settings.TenantID,
+		&settings.Locale,
+		&settings.Tone,
+		&models,
+		&quotas,
+		&settings.PolicyProfile,
+		&settings.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(models, &settings.Models); err != nil {
+		return nil, fmt.Errorf("decode tenant models: %w", err)
+	}
+	if err := json.Unmarshal(quotas, &settings.Quotas); err != nil {
+		return nil, fmt.Errorf("decode tenant quotas: %w", err)
+	}
+	return &settings, nil
+}
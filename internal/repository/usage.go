@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+// UsageRepository accumulates the metered usage per tenant and day.
+type UsageRepository interface {
+	// AddUsage adds each delta to the totals of its tenant and day.
+	AddUsage(ctx context.Context, deltas []domain.TenantUsage) error
+	// ListUsage returns the daily totals, by tenant and then oldest day first.
+	ListUsage(ctx context.Context, filter domain.TenantUsageFilter) ([]domain.TenantUsage, error)
+}
+
+type usageKey struct {
+	tenantID string
+	day      time.Time
+}
+
+type MemoryUsageRepository struct {
+	mu    sync.RWMutex
+	usage map[usageKey]domain.TenantUsage
+}
+
+func NewMemoryUsageRepository() *MemoryUsageRepository {
+	return &MemoryUsageRepository{usage: make(map[usageKey]domain.TenantUsage)}
+}
+
+func (r *MemoryUsageRepository) AddUsage(_ context.Context, deltas []domain.TenantUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delta := range deltas {
+		key := usageKey{tenantID: delta.TenantID, day: domain.UsageDay(delta.Day)}
+		total := r.usage[key]
+		total.TenantID, total.Day = key.tenantID, key.day
+		total.Add(delta)
+		r.usage[key] = total
+	}
+	return nil
+}
+
+func (r *MemoryUsageRepository) ListUsage(ctx context.Context, filter domain.TenantUsageFilter) ([]domain.TenantUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]domain.TenantUsage, 0)
+	for _, usage := range r.usage {
+		if filter.Matches(usage) && tenant.Allows(ctx, usage.TenantID) {
+			items = append(items, usage)
+		}
+	}
+	sortUsage(items)
+	return items, nil
+}
+
+func sortUsage(items []domain.TenantUsage) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].TenantID != items[j].TenantID {
+			return items[i].TenantID < items[j].TenantID
+		}
+		return items[i].Day.Before(items[j].Day)
+	})
+}
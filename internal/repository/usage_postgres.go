@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresUsageRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresUsageRepository(pool *pgxpool.Pool) *PostgresUsageRepository {
+	return &PostgresUsageRepository{pool: pool}
+}
+
+func (r *PostgresUsageRepository) AddUsage(ctx context.Context, deltas []domain.TenantUsage) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin usage tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, delta := range deltas {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO tenant_usage (
+				tenant_id,
+				day,
+				requests,
+				cache_hits,
+				input_tokens,
+				output_tokens,
+				cost_usd,
+				updated_at
+			) VALUES ($1,$2,$3,$4,$5,$6,$7,NOW())
+			ON CONFLICT (tenant_id, day) DO UPDATE SET
+				requests = tenant_usage.requests + EXCLUDED.requests,
+				cache_hits = tenant_usage.cache_hits + EXCLUDED.cache_hits,
+				input_tokens = tenant_usage.input_tokens + EXCLUDED.input_tokens,
+				output_tokens = tenant_usage.output_tokens + EXCLUDED.output_tokens,
+				cost_usd = tenant_usage.cost_usd + EXCLUDED.cost_usd,
+				updated_at = NOW()
+		`,
+			delta.TenantID,
+			domain.UsageDay(delta.Day),
+			delta.Requests,
+			delta.CacheHits,
+			delta.InputTokens,
+			delta.OutputTokens,
+			delta.CostUSD,
+		)
+		if err != nil {
+			return fmt.Errorf("upsert tenant usage: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit usage tx: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresUsageRepository) ListUsage(ctx context.Context, filter domain.TenantUsageFilter) ([]domain.TenantUsage, error) {
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 3)
+	if filter.TenantID != "" {
+		args = append(args, filter.TenantID)
+		conditions = append(conditions, fmt.Sprintf("tenant_id = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, domain.UsageDay(*filter.From))
+		conditions = append(conditions, fmt.Sprintf("day >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, domain.UsageDay(*filter.To))
+		conditions = append(conditions, fmt.Sprintf("day <= $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT tenant_id, day, requests, cache_hits, input_tokens, output_tokens, cost_usd
+		FROM tenant_usage
+		`+where+`
+		ORDER BY tenant_id, day
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query tenant usage: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.TenantUsage, 0)
+	for rows.Next() {
+		var usage domain.TenantUsage
+		if err := rows.Scan(
+			&usage.TenantID,
+			&usage.Day,
+			&usage.Requests,
+			&usage.CacheHits,
+			&usage.InputTokens,
+			&usage.OutputTokens,
+			&usage.CostUSD,
+		); err != nil {
+			return nil, fmt.Errorf("scan tenant usage: %w", err)
+		}
+		usage.Day = domain.UsageDay(usage.Day)
+		items = append(items, usage)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tenant usage: %w", err)
+	}
+	return items, nil
+}
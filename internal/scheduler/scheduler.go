@@ -0,0 +1,309 @@
+// Package scheduler enqueues recurring jobs from cron expressions. Replicas compete for a
+// lease and only the holder fires schedules, so each run happens once per deployment.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+// DefaultWindow is the history covered by a run when its entry sets no window.
+const DefaultWindow = 24 * time.Hour
+
+// Entry is a recurring job. Tasks that act per tenant run once for each of Tenants.
+type Entry struct {
+	Name    string   `json:"name"`
+	Cron    string   `json:"cron"`
+	Task    string   `json:"task"`
+	Tenants []string `json:"tenants"`
+	// Timezone is the IANA zone Cron is read in; empty means UTC.
+	Timezone string `json:"timezone"`
+	// Window is the history a run covers, ending at the run ("24h", "168h").
+	Window string `json:"window"`
+	Locale string `json:"locale"`
+}
+
+// File is the on-disk format of SCHEDULES_FILE.
+type File struct {
+	Schedules []Entry `json:"schedules"`
+}
+
+// Run is one firing of an entry for one tenant (empty for tasks without tenants).
+type Run struct {
+	Name     string
+	TenantID string
+	Locale   string
+	From     time.Time
+	At       time.Time
+}
+
+// TaskFunc executes a run, usually by enqueuing a job.
+type TaskFunc func(ctx context.Context, run Run) error
+
+// LoadFile reads and validates a JSON schedules file.
+func LoadFile(path string) ([]Entry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("schedules: %w", err)
+	}
+	var file File
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("schedules: invalid JSON: %w", err)
+	}
+	names := make(map[string]struct{}, len(file.Schedules))
+	for _, entry := range file.Schedules {
+		if _, err := compileEntry(entry); err != nil {
+			return nil, fmt.Errorf("schedules: %w", err)
+		}
+		if _, duplicate := names[entry.Name]; duplicate {
+			return nil, fmt.Errorf("schedules: duplicate name %q", entry.Name)
+		}
+		names[entry.Name] = struct{}{}
+	}
+	return file.Schedules, nil
+}
+
+type scheduledEntry struct {
+	entry    Entry
+	schedule *Schedule
+	location *time.Location
+	window   time.Duration
+	next     time.Time
+}
+
+func compileEntry(entry Entry) (*scheduledEntry, error) {
+	if strings.TrimSpace(entry.Name) == "" {
+		return nil, errors.New("schedule name is required")
+	}
+	if strings.TrimSpace(entry.Task) == "" {
+		return nil, fmt.Errorf("schedule %s: task is required", entry.Name)
+	}
+	schedule, err := ParseCron(entry.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("schedule %s: %w", entry.Name, err)
+	}
+	location := time.UTC
+	if entry.Timezone != "" {
+		if location, err = time.LoadLocation(entry.Timezone); err != nil {
+			return nil, fmt.Errorf("schedule %s: invalid timezone %q", entry.Name, entry.Timezone)
+		}
+	}
+	window := DefaultWindow
+	if entry.Window != "" {
+		if window, err = time.ParseDuration(entry.Window); err != nil || window <= 0 {
+			return nil, fmt.Errorf("schedule %s: invalid window %q: expected a positive duration such as 24h", entry.Name, entry.Window)
+		}
+	}
+	return &scheduledEntry{entry: entry, schedule: schedule, location: location, window: window}, nil
+}
+
+type Config struct {
+	// LeaseName identifies the lease replicas compete for.
+	LeaseName string
+	// LeaseTTL is how long a silent leader keeps the lease before another replica takes it.
+	LeaseTTL time.Duration
+	// Interval is how often due schedules are checked and the lease renewed; keep it well
+	// under LeaseTTL.
+	Interval time.Duration
+}
+
+// Scheduler fires entries while its replica holds the lease. A replica that becomes leader
+// starts from the next firing: runs missed during a failover are skipped, not replayed.
+type Scheduler struct {
+	leases repository.LeasesRepository
+	holder string
+	config Config
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	tasks   map[string]TaskFunc
+	entries []*scheduledEntry
+	leading bool
+}
+
+func NewScheduler(leases repository.LeasesRepository, holder string, cfg Config, logger *slog.Logger) *Scheduler {
+	if cfg.LeaseName == "" {
+		cfg.LeaseName = "scheduler"
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = 60 * time.Second
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15 * time.Second
+	}
+	return &Scheduler{
+		leases: leases,
+		holder: holder,
+		config: cfg,
+		logger: logger,
+		now:    time.Now,
+		tasks:  make(map[string]TaskFunc),
+	}
+}
+
+// RegisterTask makes entries with task run fn.
+func (s *Scheduler) RegisterTask(task string, fn TaskFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[task] = fn
+}
+
+// Add schedules entry; its task must already be registered.
+func (s *Scheduler) Add(entry Entry) error {
+	compiled, err := compileEntry(entry)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[entry.Task]; !ok {
+		return fmt.Errorf("schedule %s: unknown task %q (expected one of %s)", entry.Name, entry.Task, strings.Join(s.taskNames(), ", "))
+	}
+	for _, existing := range s.entries {
+		if existing.entry.Name == entry.Name {
+			return fmt.Errorf("schedule %s: duplicate name", entry.Name)
+		}
+	}
+	if s.leading {
+		compiled.next = compiled.schedule.Next(s.now().In(compiled.location))
+	}
+	s.entries = append(s.entries, compiled)
+	return nil
+}
+
+func (s *Scheduler) taskNames() []string {
+	names := make([]string, 0, len(s.tasks))
+	for name := range s.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Leading reports whether this replica holds the lease.
+func (s *Scheduler) Leading() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leading
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	// Schedules enqueue jobs for every tenant.
+	ctx = tenant.WithBypass(ctx)
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick renews the lease and, while leading, runs the entries that are due.
+func (s *Scheduler) tick(ctx context.Context) {
+	leading, err := s.leases.AcquireLease(ctx, s.config.LeaseName, s.holder, s.config.LeaseTTL)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log(ctx, slog.LevelError, "scheduler lease renewal failed", slog.Any("error", err))
+		}
+		leading = false
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	if leading != s.leading {
+		s.leading = leading
+		if leading {
+			for _, entry := range s.entries {
+				entry.next = entry.schedule.Next(now.In(entry.location))
+			}
+			s.log(ctx, slog.LevelInfo, "scheduler leadership acquired", slog.String("holder", s.holder))
+		} else {
+			s.log(ctx, slog.LevelWarn, "scheduler leadership lost", slog.String("holder", s.holder))
+		}
+	}
+	var due []*scheduledEntry
+	if leading {
+		for _, entry := range s.entries {
+			if !entry.next.IsZero() && !entry.next.After(now) {
+				due = append(due, entry)
+				entry.next = entry.schedule.Next(now.In(entry.location))
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	for _, entry := range due {
+		s.fire(ctx, entry, now)
+	}
+}
+
+func (s *Scheduler) fire(ctx context.Context, entry *scheduledEntry, now time.Time) {
+	s.mu.Lock()
+	task := s.tasks[entry.entry.Task]
+	s.mu.Unlock()
+
+	tenants := entry.entry.Tenants
+	if len(tenants) == 0 {
+		tenants = []string{""}
+	}
+	at := now.UTC()
+	for _, tenantID := range tenants {
+		run := Run{
+			Name:     entry.entry.Name,
+			TenantID: tenantID,
+			Locale:   entry.entry.Locale,
+			From:     at.Add(-entry.window),
+			At:       at,
+		}
+		if err := task(ctx, run); err != nil {
+			s.log(ctx, slog.LevelError, "scheduled run failed",
+				slog.String("schedule", run.Name),
+				slog.String("tenant_id", tenantID),
+				slog.Any("error", err),
+			)
+			continue
+		}
+		s.log(ctx, slog.LevelInfo, "scheduled run fired", slog.String("schedule", run.Name), slog.String("tenant_id", tenantID))
+	}
+}
+
+// release hands the lease over on shutdown so another replica takes it without waiting for
+// it to expire.
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	leading := s.leading
+	s.leading = false
+	s.mu.Unlock()
+	if !leading {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.leases.ReleaseLease(ctx, s.config.LeaseName, s.holder)
+}
+
+func (s *Scheduler) log(ctx context.Context, level slog.Level, message string, attrs ...slog.Attr) {
+	if s.logger == nil {
+		return
+	}
+	s.logger.LogAttrs(ctx, level, message, attrs...)
+}
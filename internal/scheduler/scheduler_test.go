@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+func TestCronNextFiring(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	from := time.Date(2026, 3, 13, 22, 47, 30, 0, time.UTC) // Friday
+	cases := []struct {
+		spec     string
+		from     time.Time
+		expected time.Time
+	}{
+		{"*/15 * * * *", from, time.Date(2026, 3, 13, 23, 0, 0, 0, time.UTC)},
+		{"@nightly", from, time.Date(2026, 3, 14, 2, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", from, time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 8 1,15 * *", from, time.Date(2026, 3, 15, 8, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", from, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 2 * * *", from.In(saoPaulo), time.Date(2026, 3, 14, 5, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		schedule, err := ParseCron(tc.spec)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.spec, err)
+		}
+		if next := schedule.Next(tc.from); !next.Equal(tc.expected) {
+			t.Fatalf("%q: expected %s, got %s", tc.spec, tc.expected, next.UTC())
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "0 0 * 13 *", "*/0 * * * *", "5-1 * * * *", "@often"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestOnlyTheLeaseHolderFiresSchedules(t *testing.T) {
+	ctx := context.Background()
+	leases := repository.NewMemoryLeasesRepository()
+	clock := time.Date(2026, 3, 13, 1, 59, 0, 0, time.UTC)
+
+	var runs []Run
+	newReplica := func(holder string) *Scheduler {
+		replica := NewScheduler(leases, holder, Config{}, nil)
+		replica.now = func() time.Time { return clock }
+		replica.RegisterTask("digest", func(_ context.Context, run Run) error {
+			runs = append(runs, run)
+			return nil
+		})
+		entry := Entry{Name: "nightly-digest", Cron: "0 2 * * *", Task: "digest", Tenants: []string{"tenant-a", "tenant-b"}, Window: "12h"}
+		if err := replica.Add(entry); err != nil {
+			t.Fatalf("add entry: %v", err)
+		}
+		return replica
+	}
+	first, second := newReplica("replica-1"), newReplica("replica-2")
+
+	first.tick(ctx)
+	second.tick(ctx)
+	if !first.Leading() || second.Leading() {
+		t.Fatalf("expected only the first replica to lead, got %v and %v", first.Leading(), second.Leading())
+	}
+
+	clock = clock.Add(90 * time.Second)
+	first.tick(ctx)
+	second.tick(ctx)
+	if len(runs) != 2 || runs[0].TenantID != "tenant-a" || runs[1].TenantID != "tenant-b" {
+		t.Fatalf("expected one run per tenant from the leader, got %+v", runs)
+	}
+	if runs[0].At.Sub(runs[0].From) != 12*time.Hour {
+		t.Fatalf("expected a 12h window, got %s", runs[0].At.Sub(runs[0].From))
+	}
+	first.tick(ctx)
+	if len(runs) != 2 {
+		t.Fatalf("expected the entry to wait for its next firing, got %d runs", len(runs))
+	}
+
+	first.release()
+	second.tick(ctx)
+	if !second.Leading() {
+		t.Fatal("expected the second replica to take over the released lease")
+	}
+	clock = clock.Add(24 * time.Hour)
+	second.tick(ctx)
+	if len(runs) != 4 {
+		t.Fatalf("expected the new leader to fire the next night, got %d runs", len(runs))
+	}
+
+	if err := second.Add(Entry{Name: "weekly", Cron: "@weekly", Task: "cleanup"}); err == nil {
+		t.Fatal("expected entries with unknown tasks to be rejected")
+	}
+}
@@ -0,0 +1,202 @@
+// Package secrets resolves setting values kept in a secrets backend. A reference is
+// "vault:<mount>/<path>#<key>" for a HashiCorp Vault KV v2 secret or
+// "awssm:<secret-id>[#<key>]" for an AWS Secrets Manager secret; without a key the whole
+// SecretString is the value.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/awsauth"
+)
+
+const (
+	vaultPrefix = "vault:"
+	awsPrefix   = "awssm:"
+)
+
+// ErrNotConfigured is returned for a reference to a backend the resolver has no settings for.
+var ErrNotConfigured = errors.New("secrets backend not configured")
+
+type Config struct {
+	VaultAddr      string
+	VaultToken     string
+	VaultNamespace string
+
+	AWSRegion    string
+	AWSEndpoint  string
+	AWSAccessKey string
+	AWSSecretKey string
+
+	HTTPClient *http.Client
+}
+
+// Resolver reads references from Vault and AWS Secrets Manager.
+type Resolver struct {
+	config      Config
+	credentials awsauth.Credentials
+	httpClient  *http.Client
+}
+
+func NewResolver(cfg Config) *Resolver {
+	cfg.VaultAddr = strings.TrimSuffix(strings.TrimSpace(cfg.VaultAddr), "/")
+	if strings.TrimSpace(cfg.AWSRegion) == "" {
+		cfg.AWSRegion = "us-east-1"
+	}
+	if strings.TrimSpace(cfg.AWSEndpoint) == "" {
+		cfg.AWSEndpoint = "https://secretsmanager." + cfg.AWSRegion + ".amazonaws.com"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Resolver{
+		config: cfg,
+		credentials: awsauth.Credentials{
+			AccessKeyID:     cfg.AWSAccessKey,
+			SecretAccessKey: cfg.AWSSecretKey,
+		},
+		httpClient: cfg.HTTPClient,
+	}
+}
+
+// IsReference reports whether value points to a secrets backend instead of being the
+// value itself.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, vaultPrefix) || strings.HasPrefix(value, awsPrefix)
+}
+
+// Resolve returns the value a reference points to; any other value is returned as it is.
+func (r *Resolver) Resolve(ctx context.Context, reference string) (string, error) {
+	switch {
+	case strings.HasPrefix(reference, vaultPrefix):
+		return r.resolveVault(ctx, strings.TrimPrefix(reference, vaultPrefix))
+	case strings.HasPrefix(reference, awsPrefix):
+		return r.resolveAWS(ctx, strings.TrimPrefix(reference, awsPrefix))
+	default:
+		return reference, nil
+	}
+}
+
+// splitKey separates "<location>#<key>"; the key is empty when there is no '#'.
+func splitKey(reference string) (string, string) {
+	index := strings.LastIndex(reference, "#")
+	if index < 0 {
+		return strings.TrimSpace(reference), ""
+	}
+	return strings.TrimSpace(reference[:index]), strings.TrimSpace(reference[index+1:])
+}
+
+func (r *Resolver) resolveVault(ctx context.Context, reference string) (string, error) {
+	if r.config.VaultAddr == "" {
+		return "", fmt.Errorf("vault:%s: %w (VAULT_ADDR)", reference, ErrNotConfigured)
+	}
+	location, key := splitKey(reference)
+	mount, path, found := strings.Cut(strings.Trim(location, "/"), "/")
+	if !found || mount == "" || path == "" || key == "" {
+		return "", fmt.Errorf("vault:%s: expected vault:<mount>/<path>#<key>", reference)
+	}
+
+	endpoint := r.config.VaultAddr + "/v1/" + url.PathEscape(mount) + "/data/" + escapePath(path)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("secrets: build vault request: %w", err)
+	}
+	request.Header.Set("X-Vault-Token", r.config.VaultToken)
+	if r.config.VaultNamespace != "" {
+		request.Header.Set("X-Vault-Namespace", r.config.VaultNamespace)
+	}
+
+	var response struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := r.do(request, "vault", &response); err != nil {
+		return "", fmt.Errorf("vault:%s: %w", location, err)
+	}
+	value, ok := response.Data.Data[key]
+	if !ok {
+		return "", fmt.Errorf("vault:%s: key %q not found", location, key)
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault:%s: key %q is not a string", location, key)
+	}
+	return text, nil
+}
+
+func (r *Resolver) resolveAWS(ctx context.Context, reference string) (string, error) {
+	if r.config.AWSAccessKey == "" || r.config.AWSSecretKey == "" {
+		return "", fmt.Errorf("awssm:%s: %w (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY)", reference, ErrNotConfigured)
+	}
+	secretID, key := splitKey(reference)
+	if secretID == "" {
+		return "", fmt.Errorf("awssm:%s: expected awssm:<secret-id>[#<key>]", reference)
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", fmt.Errorf("secrets: encode secrets manager request: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.AWSEndpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("secrets: build secrets manager request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	awsauth.SignRequest(request, body, r.credentials, r.config.AWSRegion, "secretsmanager", time.Now().UTC())
+
+	var response struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := r.do(request, "secrets manager", &response); err != nil {
+		return "", fmt.Errorf("awssm:%s: %w", secretID, err)
+	}
+	if key == "" {
+		return response.SecretString, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(response.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("awssm:%s: secret is not a JSON object, drop #%s to use it whole", secretID, key)
+	}
+	text, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("awssm:%s: key %q not found or not a string", secretID, key)
+	}
+	return text, nil
+}
+
+func (r *Resolver) do(request *http.Request, backend string, output any) error {
+	response, err := r.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%s request: %w", backend, err)
+	}
+	defer response.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		// The body of an error response never carries the secret.
+		return fmt.Errorf("%s status=%d body=%s", backend, response.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	if err := json.Unmarshal(raw, output); err != nil {
+		return fmt.Errorf("decode %s response: %w", backend, err)
+	}
+	return nil
+}
+
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for index, segment := range segments {
+		segments[index] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
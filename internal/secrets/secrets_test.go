@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveVaultAndSecretsManager(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/wa/api" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"openrouter_key":"sk-vault"},"metadata":{"version":3}}}`))
+	}))
+	defer vault.Close()
+
+	manager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var input struct {
+			SecretId string
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		switch input.SecretId {
+		case "prod/db":
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"url":"postgres://app:pw@db/app"}`})
+		case "prod/token":
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": "plain-token"})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException"}`))
+		}
+	}))
+	defer manager.Close()
+
+	resolver := NewResolver(Config{
+		VaultAddr:    vault.URL + "/",
+		VaultToken:   "root",
+		AWSEndpoint:  manager.URL,
+		AWSAccessKey: "AKID",
+		AWSSecretKey: "secret",
+	})
+	ctx := context.Background()
+	for _, tc := range []struct {
+		reference string
+		want      string
+	}{
+		{"vault:kv/wa/api#openrouter_key", "sk-vault"},
+		{"awssm:prod/db#url", "postgres://app:pw@db/app"},
+		{"awssm:prod/token", "plain-token"},
+		{"dev-token", "dev-token"},
+	} {
+		got, err := resolver.Resolve(ctx, tc.reference)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.reference, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.reference, tc.want, got)
+		}
+	}
+
+	for _, reference := range []string{
+		"vault:kv/wa/api#missing",
+		"vault:kv/other#openrouter_key",
+		"vault:kv#openrouter_key",
+		"awssm:prod/token#url",
+		"awssm:prod/unknown",
+	} {
+		if _, err := resolver.Resolve(ctx, reference); err == nil {
+			t.Fatalf("%s: expected an error", reference)
+		}
+	}
+
+	if _, err := NewResolver(Config{}).Resolve(ctx, "vault:kv/wa/api#key"); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("expected ErrNotConfigured without VAULT_ADDR, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type ActionItemsInput struct {
+	TenantID       string
+	ConversationID string
+	Locale         string
+	Payload        json.RawMessage
+}
+
+type ActionItem struct {
+	Description   string `json:"description"`
+	Owner         string `json:"owner"`
+	DueHint       string `json:"due_hint"`
+	SourceMessage string `json:"source_message"`
+}
+
+type ActionItemsOutput struct {
+	ActionItems   []ActionItem `json:"action_items"`
+	ModelID       string       `json:"model_id"`
+	PromptVersion string       `json:"prompt_version"`
+	QualityScore  float64      `json:"quality_score"`
+}
+
+// ActionItemsService extracts pending tasks from a conversation for the extension's
+// checklist panel, without generating a summary.
+type ActionItemsService struct {
+	generator *AIGenerationService
+}
+
+func NewActionItemsService(generator *AIGenerationService) *ActionItemsService {
+	return &ActionItemsService{generator: generator}
+}
+
+func (s *ActionItemsService) Extract(ctx context.Context, input ActionItemsInput) (ActionItemsOutput, error) {
+	if s.generator == nil {
+		return ActionItemsOutput{
+			ActionItems:   []ActionItem{},
+			ModelID:       "fallback-local",
+			PromptVersion: "action_items_v1",
+			QualityScore:  0.55,
+		}, nil
+	}
+
+	generated, err := s.generator.GenerateActionItems(ctx, JobGenerationInput{
+		TenantID:       input.TenantID,
+		ConversationID: input.ConversationID,
+		Locale:         input.Locale,
+		Payload:        input.Payload,
+	})
+	if err != nil {
+		return ActionItemsOutput{}, err
+	}
+
+	var output ActionItemsOutput
+	if err := json.Unmarshal(generated.Body, &output); err != nil {
+		return ActionItemsOutput{}, fmt.Errorf("decode action items output: %w", err)
+	}
+	output.ModelID = firstNonEmpty(output.ModelID, generated.ModelID)
+	output.PromptVersion = firstNonEmpty(output.PromptVersion, generated.PromptVersion)
+	if output.ActionItems == nil {
+		output.ActionItems = []ActionItem{}
+	}
+	return output, nil
+}
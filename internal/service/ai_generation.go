@@ -6,41 +6,65 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/iago/extensao-whatsapp-back/internal/ai"
 	"github.com/iago/extensao-whatsapp-back/internal/cache"
 	contextbuilder "github.com/iago/extensao-whatsapp-back/internal/context"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
 	"github.com/iago/extensao-whatsapp-back/internal/policy"
 	"github.com/iago/extensao-whatsapp-back/internal/quality"
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
 )
 
 type AIGenerationDependencies struct {
-	Router     *ai.ModelRouter
-	Client     ai.TextGenerator
-	Builder    *contextbuilder.Builder
-	Cache      *cache.SemanticCache
-	Validator  *quality.OutputValidator
+	Router         *ai.ModelRouter
+	Client         ai.TextGenerator
+	Builder        *contextbuilder.Builder
+	Cache          *cache.SemanticCache
+	Validator      *quality.OutputValidator
+	QualityMetrics *quality.Metrics
+	// AIMetrics and CacheMetrics record provider calls and cache lookups; nil skips them.
+	AIMetrics     *ai.Metrics
+	CacheMetrics  *cache.Metrics
+	RecentReplies *quality.RecentReplies
+	// TenantSettings supplies the default locale, tone and model overrides per tenant.
+	TenantSettings *TenantSettingsService
+	// Metering counts requests, cache hits, tokens and cost per tenant; nil skips it.
+	Metering *MeteringService
+	// CallLogger writes a structured event per provider call and cache hit; nil skips them.
+	CallLogger *ai.CallLogger
+	Prices     ai.PriceTable
 	PromptsDir string
-	Logger     *log.Logger
+	Logger     *slog.Logger
 }
 
 type AIGenerationService struct {
-	router     *ai.ModelRouter
-	client     ai.TextGenerator
-	builder    *contextbuilder.Builder
-	cache      *cache.SemanticCache
-	validator  *quality.OutputValidator
+	router    *ai.ModelRouter
+	client    ai.TextGenerator
+	builder   *contextbuilder.Builder
+	cache     *cache.SemanticCache
+	validator *quality.OutputValidator
+	metrics   *quality.Metrics
+	calls     *ai.Metrics
+	lookups   *cache.Metrics
+	recent    *quality.RecentReplies
+	settings  *TenantSettingsService
+	metering  *MeteringService
+	callLog   *ai.CallLogger
+	prices    ai.PriceTable
+	logger    *slog.Logger
+
+	// tmplMu guards promptsDir and the templates parsed from it.
+	tmplMu     sync.RWMutex
 	promptsDir string
-	logger     *log.Logger
-
-	tmplMu    sync.RWMutex
-	templates map[string]*template.Template
+	templates  map[string]*template.Template
 }
 
 type JobGenerationInput struct {
@@ -49,6 +73,12 @@ type JobGenerationInput struct {
 	Locale         string
 	Tone           string
 	Payload        json.RawMessage
+	// Draft is the agent's partial message for compose; it is rendered in the prompt
+	// and is part of the cache key.
+	Draft string
+	// Upstream is the result of the previous pipeline step, if any; it is rendered in the
+	// prompt and is part of the cache key.
+	Upstream string
 }
 
 type JobGenerationOutput struct {
@@ -57,6 +87,22 @@ type JobGenerationOutput struct {
 	PromptVersion string
 	CacheHit      bool
 	UsedFallback  bool
+	Usage         ai.TokenUsage
+	// CostUSD is only meaningful when Priced is set (the model has a configured price).
+	CostUSD float64
+	Priced  bool
+	// Quality is how the validator judged the model output; nil for cache hits and for
+	// fallbacks served before validation.
+	Quality *JobQuality
+}
+
+// JobQuality is the per-job validation outcome persisted with the result. Corrected means
+// the validator fixed or penalized the output; Rejected that it failed validation and the
+// fallback was served.
+type JobQuality struct {
+	Score     float64
+	Corrected bool
+	Rejected  bool
 }
 
 func NewAIGenerationService(deps AIGenerationDependencies) *AIGenerationService {
@@ -80,6 +126,14 @@ func NewAIGenerationService(deps AIGenerationDependencies) *AIGenerationService
 		builder:    deps.Builder,
 		cache:      deps.Cache,
 		validator:  deps.Validator,
+		metrics:    deps.QualityMetrics,
+		calls:      deps.AIMetrics,
+		lookups:    deps.CacheMetrics,
+		recent:     deps.RecentReplies,
+		settings:   deps.TenantSettings,
+		metering:   deps.Metering,
+		callLog:    deps.CallLogger,
+		prices:     deps.Prices,
 		promptsDir: promptsDir,
 		logger:     deps.Logger,
 		templates:  make(map[string]*template.Template),
@@ -87,11 +141,11 @@ func NewAIGenerationService(deps AIGenerationDependencies) *AIGenerationService
 }
 
 func (s *AIGenerationService) GenerateSuggestions(ctx context.Context, input SuggestionsInput) (SuggestionsOutput, error) {
-	locale := normalizeLocale(input.Locale)
-	tone := normalizeTone(input.Tone)
-	profile := s.router.Select(ai.TaskSuggestion)
-	promptVersion := "reply_v1"
-	promptFile := "reply_v1.tmpl"
+	s.metering.Request(input.TenantID)
+	locale, tone, profile := s.tenantGeneration(ctx, ai.TaskSuggestion, input.TenantID, input.Locale, input.Tone)
+	mode := normalizeSuggestionMode(input.Mode)
+	promptVersion := suggestionPromptVersion(mode)
+	promptFile := promptVersion + ".tmpl"
 
 	contextOut, err := s.builder.Build(ctx, contextbuilder.BuildInput{
 		Task:           string(ai.TaskSuggestion),
@@ -103,8 +157,8 @@ func (s *AIGenerationService) GenerateSuggestions(ctx context.Context, input Sug
 		ContextWindow:  input.ContextWindow,
 	})
 	if err != nil {
-		s.logf("context build failed for suggestions: %v", err)
-		return s.fallbackSuggestions(locale, tone, promptVersion), nil
+		s.warn(ctx, "context build failed for suggestions, using fallback", slog.Any("error", err))
+		return s.fallbackSuggestions(ctx, locale, tone, mode, promptVersion), nil
 	}
 
 	signature := s.cache.BuildSignature(
@@ -116,9 +170,12 @@ func (s *AIGenerationService) GenerateSuggestions(ctx context.Context, input Sug
 		promptVersion,
 		contextOut.ContextText,
 	)
-	if cached, ok := s.cache.Get(signature); ok {
+	recent := s.recent.List(input.TenantID, input.ConversationID)
+	if cached, ok := s.cacheLookup(ctx, string(ai.TaskSuggestion), signature); ok {
 		parsed, cachedScore, parseErr := parseSuggestionsPayload(cached.Value)
-		if parseErr == nil {
+		// Cached suggestions may predate a reply the agent has since sent.
+		if parseErr == nil && !repeatsRecentReply(parsed, recent) {
+			s.cacheHit(ctx, generationCall{tenantID: input.TenantID, task: ai.TaskSuggestion, promptVersion: promptVersion}, cached)
 			return SuggestionsOutput{
 				ModelID:       firstNonEmpty(cached.ModelID, "cache-hit"),
 				PromptVersion: firstNonEmpty(cached.PromptVersion, promptVersion),
@@ -128,33 +185,42 @@ func (s *AIGenerationService) GenerateSuggestions(ctx context.Context, input Sug
 		}
 	}
 
+	guidance := policy.TenantToneGuidance(input.TenantID, tone)
 	renderedPrompt, err := s.renderPrompt(promptFile, map[string]any{
-		"Locale":  locale,
-		"Tone":    tone,
-		"Context": contextOut.ContextText,
+		"Locale":           locale,
+		"Tone":             tone,
+		"Context":          contextOut.ContextText,
+		"Avoid":            guidance.Avoid,
+		"Required":         guidance.Required,
+		"ToneInstructions": guidance.Instructions,
 	})
 	if err != nil {
-		s.logf("render prompt failed for suggestions: %v", err)
-		return s.fallbackSuggestions(locale, tone, promptVersion), nil
+		s.warn(ctx, "render prompt failed for suggestions, using fallback", slog.Any("error", err))
+		return s.fallbackSuggestions(ctx, locale, tone, mode, promptVersion), nil
 	}
 
-	text, modelID, callErr := s.generateText(ctx, profile, renderedPrompt)
+	generated, callErr := s.generateText(ctx, generationCall{tenantID: input.TenantID, task: ai.TaskSuggestion, promptVersion: promptVersion}, profile, renderedPrompt)
 	if callErr != nil {
-		s.logf("openai generate suggestion failed, using fallback: %v", callErr)
-		return s.fallbackSuggestions(locale, tone, promptVersion), nil
+		s.warn(ctx, "generate suggestions failed, using fallback", slog.Any("error", callErr))
+		return s.fallbackSuggestions(ctx, locale, tone, mode, promptVersion), nil
 	}
+	text, modelID := generated.Text, generated.ModelID
 
-	suggestions, parseErr := parseSuggestionsFromModel(text, locale, tone)
+	suggestions, parseErr := parseSuggestionsFromModel(text, locale, tone, mode)
 	if parseErr != nil {
-		s.logf("parse suggestions failed, using fallback: %v", parseErr)
-		return s.fallbackSuggestions(locale, tone, promptVersion), nil
+		s.warn(ctx, "parse suggestions failed, using fallback", slog.Any("error", parseErr))
+		return s.fallbackSuggestions(ctx, locale, tone, mode, promptVersion), nil
 	}
 
-	validatedSuggestions, qualityScore, validationErr := s.validateSuggestions(locale, tone, suggestions)
+	toxicity := s.scoreToxicity(ctx, suggestions)
+	validatedSuggestions, qualityScore, corrected, validationErr := s.validateSuggestions(input.TenantID, locale, tone, mode, suggestions, toxicity, recent)
 	if validationErr != nil {
-		s.logf("validate suggestions failed, using fallback: %v", validationErr)
-		return s.fallbackSuggestions(locale, tone, promptVersion), nil
+		s.warn(ctx, "validate suggestions failed, using fallback", slog.Any("error", validationErr))
+		s.metrics.Rejected(string(ai.TaskSuggestion), promptVersion)
+		return s.fallbackSuggestions(ctx, locale, tone, mode, promptVersion), nil
 	}
+	qualityScore = s.judgeSuggestions(ctx, contextOut.ContextText, validatedSuggestions, qualityScore)
+	s.metrics.Accepted(string(ai.TaskSuggestion), promptVersion, qualityScore, corrected)
 
 	cacheBody, _ := json.Marshal(map[string]any{
 		"suggestions":   validatedSuggestions,
@@ -164,6 +230,7 @@ func (s *AIGenerationService) GenerateSuggestions(ctx context.Context, input Sug
 		Value:         cacheBody,
 		ModelID:       modelID,
 		PromptVersion: promptVersion,
+		Scope:         cache.ConversationScope(input.TenantID, input.ConversationID),
 	})
 
 	return SuggestionsOutput{
@@ -182,6 +249,30 @@ func (s *AIGenerationService) GenerateReport(ctx context.Context, input JobGener
 	return s.generateStructuredJob(ctx, ai.TaskReport, input, "report_v1", "report_v1.tmpl", 5200)
 }
 
+func (s *AIGenerationService) GenerateAnalysis(ctx context.Context, input JobGenerationInput) (JobGenerationOutput, error) {
+	return s.generateStructuredJob(ctx, ai.TaskAnalysis, input, "analysis_v1", "analysis_v1.tmpl", 2400)
+}
+
+func (s *AIGenerationService) GenerateQuestions(ctx context.Context, input JobGenerationInput) (JobGenerationOutput, error) {
+	return s.generateStructuredJob(ctx, ai.TaskQuestions, input, "questions_v1", "questions_v1.tmpl", 2400)
+}
+
+func (s *AIGenerationService) GenerateActionItems(ctx context.Context, input JobGenerationInput) (JobGenerationOutput, error) {
+	return s.generateStructuredJob(ctx, ai.TaskActions, input, "action_items_v1", "action_items_v1.tmpl", 3200)
+}
+
+func (s *AIGenerationService) GenerateCompletions(ctx context.Context, input JobGenerationInput) (JobGenerationOutput, error) {
+	return s.generateStructuredJob(ctx, ai.TaskCompose, input, "compose_v1", "compose_v1.tmpl", 2400)
+}
+
+func (s *AIGenerationService) GenerateDigest(ctx context.Context, input JobGenerationInput) (JobGenerationOutput, error) {
+	return s.generateStructuredJob(ctx, ai.TaskDigest, input, "digest_v1", "digest_v1.tmpl", 6000)
+}
+
+func (s *AIGenerationService) GenerateInsights(ctx context.Context, input JobGenerationInput) (JobGenerationOutput, error) {
+	return s.generateStructuredJob(ctx, ai.TaskInsights, input, "insights_v1", "insights_v1.tmpl", 4000)
+}
+
 func (s *AIGenerationService) generateStructuredJob(
 	ctx context.Context,
 	task ai.TaskKind,
@@ -190,9 +281,8 @@ func (s *AIGenerationService) generateStructuredJob(
 	promptFile string,
 	maxInputTokens int,
 ) (JobGenerationOutput, error) {
-	locale := normalizeLocale(input.Locale)
-	tone := normalizeTone(input.Tone)
-	profile := s.router.Select(task)
+	s.metering.Request(input.TenantID)
+	locale, tone, profile := s.tenantGeneration(ctx, task, input.TenantID, input.Locale, input.Tone)
 
 	contextOut, err := s.builder.Build(ctx, contextbuilder.BuildInput{
 		Task:           string(task),
@@ -204,7 +294,7 @@ func (s *AIGenerationService) generateStructuredJob(
 		ContextWindow:  20,
 	})
 	if err != nil {
-		s.logf("context build failed for task=%s: %v", task, err)
+		s.warn(ctx, "context build failed, using fallback", slog.String("task", string(task)), slog.Any("error", err))
 		return s.fallbackJob(task, promptVersion), nil
 	}
 
@@ -216,10 +306,13 @@ func (s *AIGenerationService) generateStructuredJob(
 		tone,
 		promptVersion,
 		contextOut.ContextText,
+		input.Draft,
+		input.Upstream,
 	)
-	if cached, ok := s.cache.Get(signature); ok {
+	if cached, ok := s.cacheLookup(ctx, string(task), signature); ok {
 		body := append([]byte(nil), cached.Value...)
 		if len(body) > 0 {
+			s.cacheHit(ctx, generationCall{tenantID: input.TenantID, task: task, promptVersion: promptVersion}, cached)
 			return JobGenerationOutput{
 				Body:          body,
 				ModelID:       firstNonEmpty(cached.ModelID, "cache-hit"),
@@ -229,58 +322,84 @@ func (s *AIGenerationService) generateStructuredJob(
 		}
 	}
 
+	guidance := policy.TenantToneGuidance(input.TenantID, tone)
 	renderedPrompt, err := s.renderPrompt(promptFile, map[string]any{
-		"Locale":  locale,
-		"Tone":    tone,
-		"Context": contextOut.ContextText,
+		"Locale":           locale,
+		"Tone":             tone,
+		"Context":          contextOut.ContextText,
+		"Draft":            input.Draft,
+		"Upstream":         input.Upstream,
+		"Avoid":            guidance.Avoid,
+		"ToneInstructions": guidance.Instructions,
 	})
 	if err != nil {
-		s.logf("render prompt failed for task=%s: %v", task, err)
+		s.warn(ctx, "render prompt failed, using fallback", slog.String("task", string(task)), slog.Any("error", err))
 		return s.fallbackJob(task, promptVersion), nil
 	}
 
-	text, modelID, callErr := s.generateText(ctx, profile, renderedPrompt)
+	generated, callErr := s.generateText(ctx, generationCall{tenantID: input.TenantID, task: task, promptVersion: promptVersion}, profile, renderedPrompt)
 	if callErr != nil {
-		s.logf("openai generate failed for task=%s, fallback enabled: %v", task, callErr)
+		s.warn(ctx, "generate failed, using fallback", slog.String("task", string(task)), slog.Any("error", callErr))
 		return s.fallbackJob(task, promptVersion), nil
 	}
+	text, modelID := generated.Text, generated.ModelID
 
 	body, parseErr := parseJobPayload(task, text, promptVersion, modelID)
 	if parseErr != nil {
-		s.logf("parse model payload failed for task=%s, fallback enabled: %v", task, parseErr)
+		s.warn(ctx, "parse model payload failed, using fallback", slog.String("task", string(task)), slog.Any("error", parseErr))
 		return s.fallbackJob(task, promptVersion), nil
 	}
 
-	validatedBody, _, validationErr := s.validator.ValidateTaskPayload(task, body, locale, tone)
+	validatedBody, ruleScore, validationErr := s.validator.ValidateTaskPayload(input.TenantID, task, body, locale, tone)
 	if validationErr != nil {
-		s.logf("validate payload failed for task=%s, fallback enabled: %v", task, validationErr)
-		return s.fallbackJob(task, promptVersion), nil
+		s.warn(ctx, "validate payload failed, using fallback", slog.String("task", string(task)), slog.Any("error", validationErr))
+		s.metrics.Rejected(string(task), promptVersion)
+		fallback := s.fallbackJob(task, promptVersion)
+		fallback.Quality = &JobQuality{Score: payloadQualityScore(fallback.Body, 0), Rejected: true}
+		return fallback, nil
 	}
 	body = validatedBody
+	if judged, judgeErr := s.validator.JudgeTaskPayload(ctx, task, contextOut.ContextText, body); judgeErr != nil {
+		s.warn(ctx, "judge payload failed, keeping rule-based score", slog.String("task", string(task)), slog.Any("error", judgeErr))
+	} else {
+		body = judged
+	}
+	jobQuality := &JobQuality{Score: payloadQualityScore(body, ruleScore), Corrected: ruleScore < 1}
+	s.metrics.Accepted(string(task), promptVersion, jobQuality.Score, jobQuality.Corrected)
 
 	s.cache.Set(signature, cache.Entry{
 		Value:         body,
 		ModelID:       modelID,
 		PromptVersion: promptVersion,
+		Scope:         cache.ConversationScope(input.TenantID, input.ConversationID),
 	})
 
-	return JobGenerationOutput{
+	output := JobGenerationOutput{
 		Body:          body,
 		ModelID:       modelID,
 		PromptVersion: promptVersion,
-	}, nil
+		Usage:         generated.Usage,
+		Quality:       jobQuality,
+	}
+	output.CostUSD, output.Priced = s.prices.Cost(modelID, generated.Usage)
+	return output, nil
 }
 
-func (s *AIGenerationService) fallbackSuggestions(locale, tone, promptVersion string) SuggestionsOutput {
-	candidates := buildENSuggestions(tone)
-	isPortuguese := strings.HasPrefix(strings.ToLower(locale), "pt")
-	if isPortuguese {
-		candidates = buildPTSuggestions(tone)
-	}
+// PurgeConversation drops every cached generation, context build and remembered reply
+// derived from a conversation.
+func (s *AIGenerationService) PurgeConversation(tenantID, conversationID string) int {
+	purged := s.cache.DeleteScope(cache.ConversationScope(tenantID, conversationID))
+	purged += s.builder.Invalidate(tenantID, conversationID)
+	purged += s.recent.Forget(tenantID, conversationID)
+	return purged
+}
+
+func (s *AIGenerationService) fallbackSuggestions(ctx context.Context, locale, tone, mode, promptVersion string) SuggestionsOutput {
+	candidates := fallbackCandidates(locale, tone, mode)
 
-	validated, score, err := s.validateSuggestions(locale, tone, candidates)
+	validated, score, _, err := s.validateSuggestions("", locale, tone, mode, candidates, nil, nil)
 	if err != nil {
-		s.logf("fallback suggestions validation failed: %v", err)
+		s.warn(ctx, "fallback suggestions validation failed", slog.Any("error", err))
 		score = 0.55
 		for index := range candidates {
 			candidates[index].Content = policy.MaskPIIString(candidates[index].Content)
@@ -331,6 +450,62 @@ func (s *AIGenerationService) fallbackJob(task ai.TaskKind, promptVersion string
 			"model_id":       fallbackModelID,
 			"quality_score":  0.55,
 		})
+	case ai.TaskAnalysis:
+		payload, err = json.Marshal(map[string]any{
+			"sentiment":         "neutro",
+			"sentiment_score":   0,
+			"urgency":           "media",
+			"intent":            "outro",
+			"intent_confidence": 0,
+			"labels":            []string{},
+			"rationale":         "Analise indisponivel no momento (modo degradado).",
+			"prompt_version":    promptVersion,
+			"model_id":          fallbackModelID,
+			"quality_score":     0.55,
+		})
+	case ai.TaskQuestions:
+		payload, err = json.Marshal(map[string]any{
+			"questions": []string{
+				"Pode me dar mais detalhes sobre o que voce precisa?",
+				"Qual e o numero do pedido ou protocolo relacionado?",
+			},
+			"rationale":      "Perguntas genericas geradas em modo degradado.",
+			"prompt_version": promptVersion,
+			"model_id":       fallbackModelID,
+			"quality_score":  0.55,
+		})
+	case ai.TaskInsights:
+		payload, err = json.Marshal(map[string]any{
+			"preferred_tone":  "neutro",
+			"commitments":     []map[string]string{},
+			"open_complaints": []map[string]string{},
+			"prompt_version":  promptVersion,
+			"model_id":        fallbackModelID,
+			"quality_score":   0.55,
+		})
+	case ai.TaskDigest:
+		payload, err = json.Marshal(map[string]any{
+			"title":          "Digest do periodo (modo degradado)",
+			"open_items":     []map[string]string{},
+			"highlights":     []string{"Digest gerado em modo degradado; revise as conversas com pendencias manualmente."},
+			"prompt_version": promptVersion,
+			"model_id":       fallbackModelID,
+			"quality_score":  0.55,
+		})
+	case ai.TaskCompose:
+		payload, err = json.Marshal(map[string]any{
+			"completions":    []string{},
+			"prompt_version": promptVersion,
+			"model_id":       fallbackModelID,
+			"quality_score":  0.55,
+		})
+	case ai.TaskActions:
+		payload, err = json.Marshal(map[string]any{
+			"action_items":   []map[string]string{},
+			"prompt_version": promptVersion,
+			"model_id":       fallbackModelID,
+			"quality_score":  0.55,
+		})
 	default:
 		payload, err = json.Marshal(map[string]any{
 			"model_id":       fallbackModelID,
@@ -352,45 +527,58 @@ func (s *AIGenerationService) fallbackJob(task ai.TaskKind, promptVersion string
 }
 
 func (s *AIGenerationService) validateSuggestions(
+	tenantID string,
 	locale string,
 	tone string,
+	mode string,
 	suggestions []SuggestionCandidate,
-) ([]SuggestionCandidate, float64, error) {
+	toxicity []float64,
+	recent []string,
+) ([]SuggestionCandidate, float64, bool, error) {
 	if len(suggestions) == 0 {
-		return nil, 0, errors.New("empty suggestions for validation")
+		return nil, 0, false, errors.New("empty suggestions for validation")
 	}
 
 	if s.validator == nil {
 		for index := range suggestions {
 			suggestions[index].Rank = index + 1
-			suggestions[index].Content = policy.MaskPIIString(strings.TrimSpace(suggestions[index].Content))
-			suggestions[index].Rationale = policy.MaskPIIString(strings.TrimSpace(suggestions[index].Rationale))
+			suggestions[index].Content = policy.MaskTenantPIIString(tenantID, strings.TrimSpace(suggestions[index].Content))
+			suggestions[index].Rationale = policy.MaskTenantPIIString(tenantID, strings.TrimSpace(suggestions[index].Rationale))
 		}
-		return suggestions, 0.5, nil
+		return suggestions, 0.5, false, nil
 	}
 
 	input := quality.SuggestionValidationInput{
-		Locale:      locale,
-		Tone:        tone,
-		Suggestions: make([]quality.SuggestionCandidate, 0, len(suggestions)),
+		TenantID:      tenantID,
+		Locale:        locale,
+		Tone:          tone,
+		Suggestions:   make([]quality.SuggestionCandidate, 0, len(suggestions)),
+		RecentReplies: recent,
+	}
+	if mode == SuggestionModeQuick {
+		input.MaxContentLen = QuickReplyMaxChars
 	}
-	for _, candidate := range suggestions {
-		input.Suggestions = append(input.Suggestions, quality.SuggestionCandidate{
+	for index, candidate := range suggestions {
+		item := quality.SuggestionCandidate{
 			Rank:      candidate.Rank,
 			Content:   candidate.Content,
 			Rationale: candidate.Rationale,
-		})
+		}
+		if index < len(toxicity) {
+			item.Toxicity = toxicity[index]
+		}
+		input.Suggestions = append(input.Suggestions, item)
 	}
 
 	validation, err := s.validator.ValidateSuggestions(input)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
 	}
 
 	result := make([]SuggestionCandidate, 0, 3)
 	seen := make(map[string]struct{}, len(validation.Suggestions))
 	for _, candidate := range validation.Suggestions {
-		content := strings.TrimSpace(policy.MaskPIIString(candidate.Content))
+		content := strings.TrimSpace(policy.MaskTenantPIIString(tenantID, candidate.Content))
 		if content == "" {
 			continue
 		}
@@ -402,7 +590,7 @@ func (s *AIGenerationService) validateSuggestions(
 		result = append(result, SuggestionCandidate{
 			Rank:      len(result) + 1,
 			Content:   content,
-			Rationale: strings.TrimSpace(policy.MaskPIIString(candidate.Rationale)),
+			Rationale: strings.TrimSpace(policy.MaskTenantPIIString(tenantID, candidate.Rationale)),
 		})
 		if len(result) >= 3 {
 			break
@@ -410,15 +598,11 @@ func (s *AIGenerationService) validateSuggestions(
 	}
 
 	if len(result) < 3 {
-		pool := buildENSuggestions(tone)
-		if strings.HasPrefix(strings.ToLower(locale), "pt") {
-			pool = buildPTSuggestions(tone)
-		}
-		for _, fallback := range pool {
+		for _, fallback := range fallbackCandidates(locale, tone, mode) {
 			if len(result) >= 3 {
 				break
 			}
-			content := strings.TrimSpace(policy.MaskPIIString(fallback.Content))
+			content := strings.TrimSpace(policy.MaskTenantPIIString(tenantID, fallback.Content))
 			key := strings.ToLower(content)
 			if content == "" {
 				continue
@@ -430,13 +614,13 @@ func (s *AIGenerationService) validateSuggestions(
 			result = append(result, SuggestionCandidate{
 				Rank:      len(result) + 1,
 				Content:   content,
-				Rationale: strings.TrimSpace(policy.MaskPIIString(fallback.Rationale)),
+				Rationale: strings.TrimSpace(policy.MaskTenantPIIString(tenantID, fallback.Rationale)),
 			})
 		}
 	}
 
 	if len(result) == 0 {
-		return nil, 0, errors.New("no suggestions available after validation")
+		return nil, 0, false, errors.New("no suggestions available after validation")
 	}
 
 	score := validation.Score
@@ -446,19 +630,91 @@ func (s *AIGenerationService) validateSuggestions(
 	if score < 0 {
 		score = 0
 	}
-	return result, score, nil
+	return result, score, validation.Corrected || score < 1, nil
+}
+
+// payloadQualityScore reads the quality_score of a validated payload, or returns fallback.
+func payloadQualityScore(body json.RawMessage, fallback float64) float64 {
+	var payload struct {
+		QualityScore *float64 `json:"quality_score"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.QualityScore == nil {
+		return fallback
+	}
+	return *payload.QualityScore
+}
+
+func repeatsRecentReply(suggestions []SuggestionCandidate, recent []string) bool {
+	for _, suggestion := range suggestions {
+		if quality.RepeatsRecentReply(suggestion.Content, recent) {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreToxicity rates the raw suggestions with the toxicity model, when configured. A
+// failure leaves the wordlists alone in charge.
+func (s *AIGenerationService) scoreToxicity(ctx context.Context, suggestions []SuggestionCandidate) []float64 {
+	if s.validator == nil {
+		return nil
+	}
+	texts := make([]string, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		texts = append(texts, suggestion.Content)
+	}
+	scores, err := s.validator.ScoreToxicity(ctx, texts)
+	if err != nil {
+		s.warn(ctx, "toxicity model failed, using wordlists only", slog.Any("error", err))
+		return nil
+	}
+	return scores
+}
+
+// judgeSuggestions blends the LLM-as-judge grade into score when the validator has a judge.
+func (s *AIGenerationService) judgeSuggestions(
+	ctx context.Context,
+	contextText string,
+	suggestions []SuggestionCandidate,
+	score float64,
+) float64 {
+	if s.validator == nil {
+		return score
+	}
+	candidates := make([]quality.SuggestionCandidate, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		candidates = append(candidates, quality.SuggestionCandidate{
+			Rank:      suggestion.Rank,
+			Content:   suggestion.Content,
+			Rationale: suggestion.Rationale,
+		})
+	}
+	judged, err := s.validator.JudgeSuggestions(ctx, contextText, candidates, score)
+	if err != nil {
+		s.warn(ctx, "judge suggestions failed, keeping rule-based score", slog.Any("error", err))
+	}
+	return judged
+}
+
+// generationCall identifies the generation a provider call serves, for usage metering
+// and call events.
+type generationCall struct {
+	tenantID      string
+	task          ai.TaskKind
+	promptVersion string
 }
 
 func (s *AIGenerationService) generateText(
 	ctx context.Context,
+	call generationCall,
 	profile ai.ModelProfile,
 	prompt string,
-) (string, string, error) {
+) (ai.GenerateResult, error) {
 	if s.client == nil || !s.client.Available() {
-		return "", "", ai.ErrOpenAIUnavailable
+		return ai.GenerateResult{}, ai.ErrOpenAIUnavailable
 	}
 
-	primaryResult, err := s.client.Generate(ctx, ai.GenerateRequest{
+	primaryResult, err := s.callModel(ctx, call, false, ai.GenerateRequest{
 		Model:           profile.PrimaryModel,
 		Instructions:    "Return only valid JSON. Do not use markdown code fences.",
 		Input:           prompt,
@@ -466,14 +722,16 @@ func (s *AIGenerationService) generateText(
 		MaxOutputTokens: profile.MaxOutputTokens,
 	})
 	if err == nil {
-		return primaryResult.Text, firstNonEmpty(primaryResult.ModelID, profile.PrimaryModel), nil
+		primaryResult.ModelID = firstNonEmpty(primaryResult.ModelID, profile.PrimaryModel)
+		logging.Set(ctx, slog.String("model_id", primaryResult.ModelID))
+		return primaryResult, nil
 	}
 
 	if strings.TrimSpace(profile.FallbackModel) == "" || profile.FallbackModel == profile.PrimaryModel {
-		return "", "", err
+		return ai.GenerateResult{}, err
 	}
 
-	fallbackResult, fallbackErr := s.client.Generate(ctx, ai.GenerateRequest{
+	fallbackResult, fallbackErr := s.callModel(ctx, call, true, ai.GenerateRequest{
 		Model:           profile.FallbackModel,
 		Instructions:    "Return only valid JSON. Do not use markdown code fences.",
 		Input:           prompt,
@@ -481,9 +739,73 @@ func (s *AIGenerationService) generateText(
 		MaxOutputTokens: profile.MaxOutputTokens,
 	})
 	if fallbackErr != nil {
-		return "", "", fmt.Errorf("primary model failed: %v; fallback failed: %w", err, fallbackErr)
+		return ai.GenerateResult{}, fmt.Errorf("primary model failed: %v; fallback failed: %w", err, fallbackErr)
 	}
-	return fallbackResult.Text, firstNonEmpty(fallbackResult.ModelID, profile.FallbackModel), nil
+	fallbackResult.ModelID = firstNonEmpty(fallbackResult.ModelID, profile.FallbackModel)
+	logging.Set(ctx, slog.String("model_id", fallbackResult.ModelID))
+	return fallbackResult, nil
+}
+
+// callModel wraps one provider call in a client span, so primary and fallback attempts
+// show up separately in the trace. The tokens are metered to the tenant even when the
+// output is later rejected.
+func (s *AIGenerationService) callModel(
+	ctx context.Context,
+	call generationCall,
+	fallback bool,
+	request ai.GenerateRequest,
+) (ai.GenerateResult, error) {
+	ctx, span := tracing.Start(ctx, "ai.generate", tracing.KindClient)
+	defer span.End()
+	span.SetAttribute("ai.model", request.Model)
+
+	start := time.Now()
+	result, err := s.client.Generate(ctx, request)
+	elapsed := time.Since(start)
+	modelID := firstNonEmpty(result.ModelID, request.Model)
+	cost, priced := s.prices.Cost(modelID, result.Usage)
+	s.calls.Call(modelID, elapsed, result.Usage, cost, priced, err)
+	s.metering.ModelCall(call.tenantID, result.Usage, cost, priced)
+	s.callLog.Log(ctx, ai.CallEvent{
+		Task:          string(call.task),
+		TenantID:      call.tenantID,
+		Model:         modelID,
+		PromptVersion: call.promptVersion,
+		Usage:         result.Usage,
+		Latency:       elapsed,
+		Fallback:      fallback,
+		Err:           err,
+	})
+	if err != nil {
+		span.RecordError(err)
+		return result, err
+	}
+	span.SetAttribute("ai.response_model", result.ModelID)
+	span.SetAttribute("ai.input_tokens", result.Usage.InputTokens)
+	span.SetAttribute("ai.output_tokens", result.Usage.OutputTokens)
+	return result, nil
+}
+
+// cacheHit accounts for a generation served from the cache.
+func (s *AIGenerationService) cacheHit(ctx context.Context, call generationCall, entry cache.Entry) {
+	s.metering.CacheHit(call.tenantID)
+	s.callLog.Log(ctx, ai.CallEvent{
+		Task:          string(call.task),
+		TenantID:      call.tenantID,
+		Model:         entry.ModelID,
+		PromptVersion: firstNonEmpty(entry.PromptVersion, call.promptVersion),
+		CacheHit:      true,
+	})
+}
+
+func (s *AIGenerationService) cacheLookup(ctx context.Context, task string, signature string) (cache.Entry, bool) {
+	_, span := tracing.Start(ctx, "cache.lookup", tracing.KindInternal)
+	defer span.End()
+	entry, ok := s.cache.Get(signature)
+	s.lookups.Lookup(task, ok)
+	span.SetAttribute("cache.task", task)
+	span.SetAttribute("cache.hit", ok)
+	return entry, ok
 }
 
 func (s *AIGenerationService) renderPrompt(fileName string, data any) (string, error) {
@@ -505,9 +827,10 @@ func (s *AIGenerationService) loadTemplate(fileName string) (*template.Template,
 		s.tmplMu.RUnlock()
 		return tmpl, nil
 	}
+	promptsDir := s.promptsDir
 	s.tmplMu.RUnlock()
 
-	absolute := filepath.Join(s.promptsDir, fileName)
+	absolute := filepath.Join(promptsDir, fileName)
 	content, err := os.ReadFile(absolute)
 	if err != nil {
 		return nil, fmt.Errorf("read prompt template %s: %w", absolute, err)
@@ -519,13 +842,38 @@ func (s *AIGenerationService) loadTemplate(fileName string) (*template.Template,
 	}
 
 	s.tmplMu.Lock()
-	s.templates[fileName] = tmpl
+	// A template read while UsePromptsDir switched directories is not kept.
+	if s.promptsDir == promptsDir {
+		s.templates[fileName] = tmpl
+	}
 	s.tmplMu.Unlock()
 
 	return tmpl, nil
 }
 
-func parseSuggestionsFromModel(text, locale, tone string) ([]SuggestionCandidate, error) {
+// UsePromptsDir switches the prompt templates directory and drops the parsed templates,
+// so edited files are read again on their next use.
+func (s *AIGenerationService) UsePromptsDir(dir string) error {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		dir = "prompts"
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("prompts dir: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("prompts dir %s is not a directory", dir)
+	}
+
+	s.tmplMu.Lock()
+	s.promptsDir = dir
+	s.templates = make(map[string]*template.Template)
+	s.tmplMu.Unlock()
+	return nil
+}
+
+func parseSuggestionsFromModel(text, locale, tone, mode string) ([]SuggestionCandidate, error) {
 	rawJSON, err := extractJSON(text)
 	if err != nil {
 		return nil, err
@@ -564,11 +912,7 @@ func parseSuggestionsFromModel(text, locale, tone string) ([]SuggestionCandidate
 	}
 
 	if len(result) < 3 {
-		fallback := buildENSuggestions(tone)
-		if strings.HasPrefix(strings.ToLower(locale), "pt") {
-			fallback = buildPTSuggestions(tone)
-		}
-		for _, item := range fallback {
+		for _, item := range fallbackCandidates(locale, tone, mode) {
 			if len(result) >= 3 {
 				break
 			}
@@ -672,6 +1016,142 @@ func parseJobPayload(task ai.TaskKind, text string, promptVersion string, modelI
 			return nil, err
 		}
 		return encoded, nil
+	case ai.TaskAnalysis:
+		var payload struct {
+			Sentiment        string   `json:"sentiment"`
+			SentimentScore   float64  `json:"sentiment_score"`
+			Urgency          string   `json:"urgency"`
+			Intent           string   `json:"intent"`
+			IntentConfidence float64  `json:"intent_confidence"`
+			Labels           []string `json:"labels"`
+			Rationale        string   `json:"rationale"`
+		}
+		if err := json.Unmarshal(rawJSON, &payload); err != nil {
+			return nil, fmt.Errorf("decode analysis json: %w", err)
+		}
+		if strings.TrimSpace(payload.Sentiment) == "" || strings.TrimSpace(payload.Intent) == "" {
+			return nil, errors.New("analysis sentiment or intent is empty")
+		}
+		encoded, err := json.Marshal(map[string]any{
+			"sentiment":         payload.Sentiment,
+			"sentiment_score":   payload.SentimentScore,
+			"urgency":           payload.Urgency,
+			"intent":            payload.Intent,
+			"intent_confidence": payload.IntentConfidence,
+			"labels":            payload.Labels,
+			"rationale":         payload.Rationale,
+			"prompt_version":    promptVersion,
+			"model_id":          modelID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return encoded, nil
+	case ai.TaskQuestions:
+		var payload struct {
+			Questions []string `json:"questions"`
+			Rationale string   `json:"rationale"`
+		}
+		if err := json.Unmarshal(rawJSON, &payload); err != nil {
+			return nil, fmt.Errorf("decode questions json: %w", err)
+		}
+		if len(payload.Questions) == 0 {
+			return nil, errors.New("questions are empty")
+		}
+		encoded, err := json.Marshal(map[string]any{
+			"questions":      payload.Questions,
+			"rationale":      payload.Rationale,
+			"prompt_version": promptVersion,
+			"model_id":       modelID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return encoded, nil
+	case ai.TaskInsights:
+		var payload struct {
+			PreferredTone  string              `json:"preferred_tone"`
+			Commitments    []map[string]string `json:"commitments"`
+			OpenComplaints []map[string]string `json:"open_complaints"`
+		}
+		if err := json.Unmarshal(rawJSON, &payload); err != nil {
+			return nil, fmt.Errorf("decode insights json: %w", err)
+		}
+		if payload.Commitments == nil && payload.OpenComplaints == nil && strings.TrimSpace(payload.PreferredTone) == "" {
+			return nil, errors.New("insights are empty")
+		}
+		encoded, err := json.Marshal(map[string]any{
+			"preferred_tone":  payload.PreferredTone,
+			"commitments":     payload.Commitments,
+			"open_complaints": payload.OpenComplaints,
+			"prompt_version":  promptVersion,
+			"model_id":        modelID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return encoded, nil
+	case ai.TaskDigest:
+		var payload struct {
+			Title      string              `json:"title"`
+			OpenItems  []map[string]string `json:"open_items"`
+			Highlights []string            `json:"highlights"`
+		}
+		if err := json.Unmarshal(rawJSON, &payload); err != nil {
+			return nil, fmt.Errorf("decode digest json: %w", err)
+		}
+		if len(payload.OpenItems) == 0 && len(payload.Highlights) == 0 {
+			return nil, errors.New("digest is empty")
+		}
+		encoded, err := json.Marshal(map[string]any{
+			"title":          strings.TrimSpace(payload.Title),
+			"open_items":     payload.OpenItems,
+			"highlights":     payload.Highlights,
+			"prompt_version": promptVersion,
+			"model_id":       modelID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return encoded, nil
+	case ai.TaskCompose:
+		var payload struct {
+			Completions []string `json:"completions"`
+		}
+		if err := json.Unmarshal(rawJSON, &payload); err != nil {
+			return nil, fmt.Errorf("decode compose json: %w", err)
+		}
+		if len(payload.Completions) == 0 {
+			return nil, errors.New("completions are empty")
+		}
+		encoded, err := json.Marshal(map[string]any{
+			"completions":    payload.Completions,
+			"prompt_version": promptVersion,
+			"model_id":       modelID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return encoded, nil
+	case ai.TaskActions:
+		var payload struct {
+			ActionItems []map[string]string `json:"action_items"`
+		}
+		if err := json.Unmarshal(rawJSON, &payload); err != nil {
+			return nil, fmt.Errorf("decode action items json: %w", err)
+		}
+		if payload.ActionItems == nil {
+			return nil, errors.New("action_items is missing")
+		}
+		encoded, err := json.Marshal(map[string]any{
+			"action_items":   payload.ActionItems,
+			"prompt_version": promptVersion,
+			"model_id":       modelID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return encoded, nil
 	default:
 		return nil, fmt.Errorf("unsupported task for parse payload: %s", task)
 	}
@@ -712,6 +1192,27 @@ func stripCodeFence(text string) string {
 	return strings.TrimSpace(trimmed)
 }
 
+// tenantGeneration resolves the locale, tone and models of a task: the request values
+// first, then the tenant settings, then the built-in defaults.
+func (s *AIGenerationService) tenantGeneration(
+	ctx context.Context,
+	task ai.TaskKind,
+	tenantID, locale, tone string,
+) (string, string, ai.ModelProfile) {
+	settings := s.settings.Resolve(ctx, tenantID)
+	if strings.TrimSpace(locale) == "" {
+		locale = settings.Locale
+	}
+	if strings.TrimSpace(tone) == "" {
+		tone = settings.Tone
+	}
+	profile := s.router.Select(task)
+	if model := settings.Models[string(task)]; model != "" {
+		profile.PrimaryModel = model
+	}
+	return normalizeLocale(locale), normalizeTone(tenantID, tone), profile
+}
+
 func normalizeLocale(locale string) string {
 	trimmed := strings.TrimSpace(locale)
 	if trimmed == "" {
@@ -723,14 +1224,13 @@ func normalizeLocale(locale string) string {
 	return trimmed
 }
 
-func normalizeTone(tone string) string {
+// normalizeTone keeps the built-in and tenant-registered tones; anything else is neutro.
+func normalizeTone(tenantID, tone string) string {
 	normalized := strings.ToLower(strings.TrimSpace(tone))
-	switch normalized {
-	case "formal", "neutro", "amigavel":
+	if policy.ToneRegistered(tenantID, normalized) {
 		return normalized
-	default:
-		return "neutro"
 	}
+	return "neutro"
 }
 
 func suggestionTokenBudget(contextWindow int) int {
@@ -772,10 +1272,16 @@ func suggestionChunkLimit(contextWindow int) int {
 
 func maxChunkLimitByTask(task ai.TaskKind) int {
 	switch task {
-	case ai.TaskSummary:
+	case ai.TaskSummary, ai.TaskActions:
 		return 10
+	case ai.TaskInsights:
+		return 24
 	case ai.TaskReport:
 		return 12
+	case ai.TaskDigest:
+		return 20
+	case ai.TaskAnalysis, ai.TaskQuestions, ai.TaskCompose:
+		return 6
 	default:
 		return 8
 	}
@@ -791,9 +1297,9 @@ func firstNonEmpty(values ...string) string {
 	return ""
 }
 
-func (s *AIGenerationService) logf(format string, args ...any) {
+func (s *AIGenerationService) warn(ctx context.Context, message string, attrs ...slog.Attr) {
 	if s.logger == nil {
 		return
 	}
-	s.logger.Printf(format, args...)
+	s.logger.LogAttrs(ctx, slog.LevelWarn, message, attrs...)
 }
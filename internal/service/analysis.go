@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type AnalysisInput struct {
+	TenantID       string
+	ConversationID string
+	Locale         string
+	Payload        json.RawMessage
+}
+
+type AnalysisOutput struct {
+	Sentiment        string   `json:"sentiment"`
+	SentimentScore   float64  `json:"sentiment_score"`
+	Urgency          string   `json:"urgency"`
+	Intent           string   `json:"intent"`
+	IntentConfidence float64  `json:"intent_confidence"`
+	Labels           []string `json:"labels"`
+	Rationale        string   `json:"rationale,omitempty"`
+	ModelID          string   `json:"model_id"`
+	PromptVersion    string   `json:"prompt_version"`
+	QualityScore     float64  `json:"quality_score"`
+}
+
+// AnalysisService classifies the recent conversation window (sentiment, urgency, intent)
+// so the extension can badge conversations.
+type AnalysisService struct {
+	generator *AIGenerationService
+}
+
+func NewAnalysisService(generator *AIGenerationService) *AnalysisService {
+	return &AnalysisService{generator: generator}
+}
+
+func (s *AnalysisService) Analyze(ctx context.Context, input AnalysisInput) (AnalysisOutput, error) {
+	if s.generator == nil {
+		return AnalysisOutput{
+			Sentiment:     "neutro",
+			Urgency:       "media",
+			Intent:        "outro",
+			Labels:        []string{},
+			ModelID:       "fallback-local",
+			PromptVersion: "analysis_v1",
+			QualityScore:  0.55,
+		}, nil
+	}
+
+	generated, err := s.generator.GenerateAnalysis(ctx, JobGenerationInput{
+		TenantID:       input.TenantID,
+		ConversationID: input.ConversationID,
+		Locale:         input.Locale,
+		Payload:        input.Payload,
+	})
+	if err != nil {
+		return AnalysisOutput{}, err
+	}
+
+	var output AnalysisOutput
+	if err := json.Unmarshal(generated.Body, &output); err != nil {
+		return AnalysisOutput{}, fmt.Errorf("decode analysis output: %w", err)
+	}
+	output.ModelID = firstNonEmpty(output.ModelID, generated.ModelID)
+	output.PromptVersion = firstNonEmpty(output.PromptVersion, generated.PromptVersion)
+	if output.Labels == nil {
+		output.Labels = []string{}
+	}
+	return output, nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+const (
+	AuditActionAPIKeyCreated = "api_key.created"
+	AuditActionAPIKeyRevoked = "api_key.revoked"
+	AuditActionAPIKeyRotated = "api_key.rotated"
+
+	apiKeySecretBytes   = 24
+	apiKeyDisplayLength = len(domain.APIKeyPrefix) + 8
+	// apiKeyTouchInterval throttles last_used_at writes to one per key per interval.
+	apiKeyTouchInterval = time.Minute
+)
+
+// ErrInvalidAPIKey is returned for unknown, malformed or revoked keys.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyScopes lists the scopes a key may be granted.
+var APIKeyScopes = []string{domain.APIKeyScopeSuggestions, domain.APIKeyScopeReports, domain.APIKeyScopeAdmin, domain.APIKeyScopeReadOnly}
+
+type CreateAPIKeyInput struct {
+	TenantID  string
+	Name      string
+	Scopes    []string
+	Actor     string
+	RequestID string
+}
+
+// IssuedAPIKey carries the plaintext secret, which is only available when a key is
+// created or rotated.
+type IssuedAPIKey struct {
+	Key    domain.APIKey
+	Secret string
+}
+
+// APIKeysService issues, rotates, revokes and authenticates per-tenant API keys.
+type APIKeysService struct {
+	repo  repository.APIKeysRepository
+	audit repository.AuditRepository
+}
+
+func NewAPIKeysService(repo repository.APIKeysRepository, audit repository.AuditRepository) *APIKeysService {
+	return &APIKeysService{repo: repo, audit: audit}
+}
+
+func (s *APIKeysService) Create(ctx context.Context, input CreateAPIKeyInput) (IssuedAPIKey, error) {
+	tenantID := strings.TrimSpace(input.TenantID)
+	if tenantID == "" || len(input.Scopes) == 0 {
+		return IssuedAPIKey{}, errors.New("tenant_id and scopes are required")
+	}
+
+	issued, err := s.issue(ctx, domain.APIKey{
+		TenantID: tenantID,
+		Name:     strings.TrimSpace(input.Name),
+		Scopes:   input.Scopes,
+	})
+	if err != nil {
+		return IssuedAPIKey{}, err
+	}
+	s.recordAudit(ctx, issued.Key, AuditActionAPIKeyCreated, input.Actor, input.RequestID, map[string]any{
+		"scopes": issued.Key.Scopes,
+	})
+	return issued, nil
+}
+
+// Rotate issues a replacement with the same tenant, name and scopes and revokes the old
+// key immediately.
+func (s *APIKeysService) Rotate(ctx context.Context, keyID, actor, requestID string) (IssuedAPIKey, error) {
+	current, err := s.repo.GetAPIKey(ctx, keyID)
+	if err != nil {
+		return IssuedAPIKey{}, err
+	}
+	if !current.Active() {
+		return IssuedAPIKey{}, repository.ErrNotFound
+	}
+
+	issued, err := s.issue(ctx, domain.APIKey{
+		TenantID:    current.TenantID,
+		Name:        current.Name,
+		Scopes:      current.Scopes,
+		RotatedFrom: current.ID,
+	})
+	if err != nil {
+		return IssuedAPIKey{}, err
+	}
+	if err := s.repo.RevokeAPIKey(ctx, current.ID, issued.Key.CreatedAt); err != nil {
+		return IssuedAPIKey{}, fmt.Errorf("revoke rotated api key: %w", err)
+	}
+	s.recordAudit(ctx, issued.Key, AuditActionAPIKeyRotated, actor, requestID, map[string]any{
+		"rotated_from": current.ID,
+	})
+	return issued, nil
+}
+
+func (s *APIKeysService) Revoke(ctx context.Context, keyID, actor, requestID string) (*domain.APIKey, error) {
+	key, err := s.repo.GetAPIKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	revokedAt := time.Now().UTC()
+	if err := s.repo.RevokeAPIKey(ctx, keyID, revokedAt); err != nil {
+		return nil, err
+	}
+	key.RevokedAt = &revokedAt
+	s.recordAudit(ctx, *key, AuditActionAPIKeyRevoked, actor, requestID, nil)
+	return key, nil
+}
+
+func (s *APIKeysService) Get(ctx context.Context, keyID string) (*domain.APIKey, error) {
+	return s.repo.GetAPIKey(ctx, keyID)
+}
+
+func (s *APIKeysService) List(ctx context.Context, filter domain.APIKeyListFilter) ([]domain.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx, filter)
+}
+
+// Authenticate resolves an active key from its plaintext secret.
+func (s *APIKeysService) Authenticate(ctx context.Context, secret string) (domain.APIKey, error) {
+	secret = strings.TrimSpace(secret)
+	if !strings.HasPrefix(secret, domain.APIKeyPrefix) || len(secret) <= apiKeyDisplayLength {
+		return domain.APIKey{}, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKeySecret(secret))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.APIKey{}, ErrInvalidAPIKey
+		}
+		return domain.APIKey{}, err
+	}
+	if !key.Active() {
+		return domain.APIKey{}, ErrInvalidAPIKey
+	}
+
+	now := time.Now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// Usage tracking is best effort and must not fail the request.
+		_ = s.repo.TouchAPIKey(ctx, key.ID, now)
+		key.LastUsedAt = &now
+	}
+	return *key, nil
+}
+
+func (s *APIKeysService) issue(ctx context.Context, key domain.APIKey) (IssuedAPIKey, error) {
+	raw := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return IssuedAPIKey{}, fmt.Errorf("generate api key: %w", err)
+	}
+	secret := domain.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	key.ID = uuid.NewString()
+	key.Prefix = secret[:apiKeyDisplayLength]
+	key.Hash = hashAPIKeySecret(secret)
+	key.Scopes = append([]string(nil), key.Scopes...)
+	key.CreatedAt = time.Now().UTC()
+	if err := s.repo.CreateAPIKey(ctx, &key); err != nil {
+		return IssuedAPIKey{}, fmt.Errorf("create api key: %w", err)
+	}
+	return IssuedAPIKey{Key: key, Secret: secret}, nil
+}
+
+func (s *APIKeysService) recordAudit(
+	ctx context.Context,
+	key domain.APIKey,
+	action string,
+	actor string,
+	requestID string,
+	details map[string]any,
+) {
+	if s.audit == nil {
+		return
+	}
+	metadata, _ := json.Marshal(details)
+	// Key management already succeeded; a failed audit write is not reported to the caller.
+	_ = s.audit.RecordAudit(ctx, domain.AuditRecord{
+		ID:           uuid.NewString(),
+		TenantID:     key.TenantID,
+		Actor:        strings.TrimSpace(actor),
+		Action:       action,
+		ResourceType: "api_key",
+		ResourceID:   key.ID,
+		RequestID:    requestID,
+		Metadata:     metadata,
+		CreatedAt:    time.Now().UTC(),
+	})
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
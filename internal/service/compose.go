@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type ComposeInput struct {
+	TenantID       string
+	ConversationID string
+	Locale         string
+	Tone           string
+	Draft          string
+	Payload        json.RawMessage
+}
+
+type ComposeCompletion struct {
+	Rank int `json:"rank"`
+	// Completion is only the continuation; Text is the draft with it appended.
+	Completion string `json:"completion"`
+	Text       string `json:"text"`
+}
+
+type ComposeOutput struct {
+	Completions   []ComposeCompletion `json:"completions"`
+	ModelID       string              `json:"model_id"`
+	PromptVersion string              `json:"prompt_version"`
+	QualityScore  float64             `json:"quality_score"`
+}
+
+// ComposeService completes the agent's partially typed draft. It never replaces the
+// draft: model output that restates it is trimmed down to the continuation.
+type ComposeService struct {
+	generator *AIGenerationService
+}
+
+func NewComposeService(generator *AIGenerationService) *ComposeService {
+	return &ComposeService{generator: generator}
+}
+
+func (s *ComposeService) Complete(ctx context.Context, input ComposeInput) (ComposeOutput, error) {
+	if s.generator == nil {
+		return ComposeOutput{
+			Completions:   []ComposeCompletion{},
+			ModelID:       "fallback-local",
+			PromptVersion: "compose_v1",
+			QualityScore:  0.55,
+		}, nil
+	}
+
+	generated, err := s.generator.GenerateCompletions(ctx, JobGenerationInput{
+		TenantID:       input.TenantID,
+		ConversationID: input.ConversationID,
+		Locale:         input.Locale,
+		Tone:           input.Tone,
+		Draft:          input.Draft,
+		Payload:        input.Payload,
+	})
+	if err != nil {
+		return ComposeOutput{}, err
+	}
+
+	var decoded struct {
+		Completions   []string `json:"completions"`
+		ModelID       string   `json:"model_id"`
+		PromptVersion string   `json:"prompt_version"`
+		QualityScore  float64  `json:"quality_score"`
+	}
+	if err := json.Unmarshal(generated.Body, &decoded); err != nil {
+		return ComposeOutput{}, fmt.Errorf("decode compose output: %w", err)
+	}
+
+	output := ComposeOutput{
+		Completions:   make([]ComposeCompletion, 0, len(decoded.Completions)),
+		ModelID:       firstNonEmpty(decoded.ModelID, generated.ModelID),
+		PromptVersion: firstNonEmpty(decoded.PromptVersion, generated.PromptVersion),
+		QualityScore:  decoded.QualityScore,
+	}
+	for _, raw := range decoded.Completions {
+		continuation := stripDraftPrefix(input.Draft, raw)
+		if continuation == "" {
+			continue
+		}
+		output.Completions = append(output.Completions, ComposeCompletion{
+			Rank:       len(output.Completions) + 1,
+			Completion: continuation,
+			Text:       joinDraft(input.Draft, continuation),
+		})
+	}
+	return output, nil
+}
+
+// stripDraftPrefix drops a restated draft from the start of a model completion.
+func stripDraftPrefix(draft, completion string) string {
+	trimmedDraft := strings.TrimSpace(draft)
+	completion = strings.TrimSpace(completion)
+	if trimmedDraft != "" && len(completion) >= len(trimmedDraft) &&
+		strings.EqualFold(completion[:len(trimmedDraft)], trimmedDraft) {
+		completion = strings.TrimSpace(completion[len(trimmedDraft):])
+	}
+	return completion
+}
+
+// joinDraft appends continuation to draft, adding a space unless the draft already ends
+// with one or the continuation starts with punctuation.
+func joinDraft(draft, continuation string) string {
+	if draft == "" || strings.HasSuffix(draft, " ") {
+		return draft + continuation
+	}
+	first := []rune(continuation)[0]
+	if unicode.IsPunct(first) {
+		return draft + continuation
+	}
+	return draft + " " + continuation
+}
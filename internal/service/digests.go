@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+const (
+	digestMessageLimit      = 2000
+	digestConversationLimit = 40
+	digestLineMaxChars      = 480
+	digestLineMessages      = 6
+)
+
+type DigestInput struct {
+	TenantID string
+	From     time.Time
+	To       time.Time
+	Locale   string
+}
+
+// DigestsService builds the tenant-wide digest payload from the stored history and
+// queues it for generation.
+type DigestsService struct {
+	jobs     *JobsService
+	messages repository.MessagesRepository
+}
+
+func NewDigestsService(jobs *JobsService, messages repository.MessagesRepository) *DigestsService {
+	return &DigestsService{jobs: jobs, messages: messages}
+}
+
+// Request snapshots the conversations active in [From, To) and enqueues a digest job.
+// Conversations waiting on the customer are computed here so they do not depend on
+// the model.
+func (s *DigestsService) Request(ctx context.Context, input DigestInput) (*domain.Job, error) {
+	tenantID := strings.TrimSpace(input.TenantID)
+	if tenantID == "" {
+		return nil, errors.New("tenant_id is required")
+	}
+
+	messages, err := s.messages.ListTenantMessages(ctx, tenantID, input.From, input.To, digestMessageLimit)
+	if err != nil {
+		return nil, fmt.Errorf("load tenant messages: %w", err)
+	}
+
+	threads := groupDigestThreads(messages)
+	waitingOnCustomer := make([]string, 0)
+	waitingOnAgent := make([]string, 0)
+	lines := make([]string, 0, len(threads))
+	for _, thread := range threads {
+		last := thread.messages[len(thread.messages)-1]
+		if last.AuthorRole == domain.MessageAuthorAgent {
+			waitingOnCustomer = append(waitingOnCustomer, thread.conversationID)
+		} else {
+			waitingOnAgent = append(waitingOnAgent, thread.conversationID)
+		}
+		lines = append(lines, digestThreadLine(thread))
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"tenant_id":           tenantID,
+		"from":                input.From.UTC().Format(time.RFC3339),
+		"to":                  input.To.UTC().Format(time.RFC3339),
+		"locale":              input.Locale,
+		"conversation_count":  len(threads),
+		"waiting_on_customer": waitingOnCustomer,
+		"waiting_on_agent":    waitingOnAgent,
+		"messages":            lines,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode digest payload: %w", err)
+	}
+	return s.jobs.EnqueueDigest(ctx, tenantID, payload)
+}
+
+func (s *DigestsService) ListDigests(
+	ctx context.Context,
+	filter domain.DigestListFilter,
+) ([]domain.DigestListItem, int, error) {
+	return s.jobs.ListDigests(ctx, filter)
+}
+
+type digestThread struct {
+	conversationID string
+	messages       []domain.Message
+}
+
+// groupDigestThreads groups messages by conversation, most recently active first, keeping
+// at most digestConversationLimit conversations.
+func groupDigestThreads(messages []domain.Message) []digestThread {
+	index := make(map[string]int)
+	threads := make([]digestThread, 0)
+	for _, message := range messages {
+		position, ok := index[message.ConversationID]
+		if !ok {
+			position = len(threads)
+			index[message.ConversationID] = position
+			threads = append(threads, digestThread{conversationID: message.ConversationID})
+		}
+		threads[position].messages = append(threads[position].messages, message)
+	}
+
+	sort.SliceStable(threads, func(i, j int) bool {
+		left := threads[i].messages[len(threads[i].messages)-1].CreatedAt
+		right := threads[j].messages[len(threads[j].messages)-1].CreatedAt
+		if left.Equal(right) {
+			return threads[i].conversationID < threads[j].conversationID
+		}
+		return left.After(right)
+	})
+	if len(threads) > digestConversationLimit {
+		threads = threads[:digestConversationLimit]
+	}
+	return threads
+}
+
+// digestThreadLine condenses the latest messages of a conversation into one context line.
+func digestThreadLine(thread digestThread) string {
+	recent := thread.messages
+	if len(recent) > digestLineMessages {
+		recent = recent[len(recent)-digestLineMessages:]
+	}
+
+	lastAuthor := "cliente"
+	if recent[len(recent)-1].AuthorRole == domain.MessageAuthorAgent {
+		lastAuthor = "atendente"
+	}
+
+	parts := make([]string, 0, len(recent))
+	for _, message := range recent {
+		author := "cliente"
+		if message.AuthorRole == domain.MessageAuthorAgent {
+			author = "atendente"
+		}
+		text := strings.Join(strings.Fields(message.Text), " ")
+		if text == "" {
+			continue
+		}
+		parts = append(parts, author+": "+text)
+	}
+
+	line := fmt.Sprintf("conversa %s | ultima: %s | %s", thread.conversationID, lastAuthor, strings.Join(parts, "; "))
+	runes := []rune(line)
+	if len(runes) > digestLineMaxChars {
+		line = string(runes[:digestLineMaxChars])
+	}
+	return line
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+const AuditActionConversationErased = "conversation.data_erased"
+
+// ConversationPurger drops derived in-memory state (caches) for a conversation.
+type ConversationPurger interface {
+	PurgeConversation(tenantID, conversationID string) int
+}
+
+type EraseConversationInput struct {
+	TenantID       string
+	ConversationID string
+	Actor          string
+	RequestID      string
+}
+
+type EraseConversationOutput struct {
+	TenantID          string `json:"tenant_id"`
+	ConversationID    string `json:"conversation_id"`
+	DeletedJobs       int    `json:"deleted_jobs"`
+	DeletedMessages   int    `json:"deleted_messages"`
+	DeletedPseudonyms int    `json:"deleted_pseudonyms"`
+	// DeletedIdempotencyKeys counts the cached responses to the conversation's requests.
+	DeletedIdempotencyKeys int       `json:"deleted_idempotency_keys"`
+	PurgedCacheEntries     int       `json:"purged_cache_entries"`
+	ErasedAt               time.Time `json:"erased_at"`
+}
+
+// ErasureService hard-deletes every artifact derived from a conversation (GDPR/LGPD erasure).
+type ErasureService struct {
+	jobs        repository.JobsRepository
+	messages    repository.MessagesRepository
+	pseudonyms  repository.PseudonymsRepository
+	idempotency repository.IdempotencyRepository
+	audit       repository.AuditRepository
+	purger      ConversationPurger
+}
+
+func NewErasureService(
+	jobs repository.JobsRepository,
+	messages repository.MessagesRepository,
+	pseudonyms repository.PseudonymsRepository,
+	idempotency repository.IdempotencyRepository,
+	audit repository.AuditRepository,
+	purger ConversationPurger,
+) *ErasureService {
+	return &ErasureService{
+		jobs:        jobs,
+		messages:    messages,
+		pseudonyms:  pseudonyms,
+		idempotency: idempotency,
+		audit:       audit,
+		purger:      purger,
+	}
+}
+
+func (s *ErasureService) EraseConversation(
+	ctx context.Context,
+	input EraseConversationInput,
+) (EraseConversationOutput, error) {
+	tenantID := strings.TrimSpace(input.TenantID)
+	conversationID := strings.TrimSpace(input.ConversationID)
+	if tenantID == "" || conversationID == "" {
+		return EraseConversationOutput{}, errors.New("tenant_id and conversation_id are required")
+	}
+
+	deletedJobs, err := s.jobs.DeleteConversationJobs(ctx, tenantID, conversationID)
+	if err != nil {
+		return EraseConversationOutput{}, fmt.Errorf("delete conversation jobs: %w", err)
+	}
+
+	deletedMessages := 0
+	if s.messages != nil {
+		deletedMessages, err = s.messages.DeleteConversationMessages(ctx, tenantID, conversationID)
+		if err != nil {
+			return EraseConversationOutput{}, fmt.Errorf("delete conversation messages: %w", err)
+		}
+	}
+
+	deletedPseudonyms := 0
+	if s.pseudonyms != nil {
+		deletedPseudonyms, err = s.pseudonyms.DeleteConversationPseudonyms(ctx, tenantID, conversationID)
+		if err != nil {
+			return EraseConversationOutput{}, fmt.Errorf("delete conversation pseudonyms: %w", err)
+		}
+	}
+
+	deletedIdempotencyKeys := 0
+	if s.idempotency != nil {
+		deletedIdempotencyKeys, err = s.idempotency.DeleteConversationIdempotencyKeys(ctx, tenantID, conversationID)
+		if err != nil {
+			return EraseConversationOutput{}, fmt.Errorf("delete conversation idempotency keys: %w", err)
+		}
+	}
+
+	purged := 0
+	if s.purger != nil {
+		purged = s.purger.PurgeConversation(tenantID, conversationID)
+	}
+
+	output := EraseConversationOutput{
+		TenantID:               tenantID,
+		ConversationID:         conversationID,
+		DeletedJobs:            deletedJobs,
+		DeletedMessages:        deletedMessages,
+		DeletedPseudonyms:      deletedPseudonyms,
+		DeletedIdempotencyKeys: deletedIdempotencyKeys,
+		PurgedCacheEntries:     purged,
+		ErasedAt:               time.Now().UTC(),
+	}
+
+	if s.audit != nil {
+		metadata, _ := json.Marshal(map[string]any{
+			"deleted_jobs":             deletedJobs,
+			"deleted_messages":         deletedMessages,
+			"deleted_pseudonyms":       deletedPseudonyms,
+			"deleted_idempotency_keys": deletedIdempotencyKeys,
+			"purged_cache_entries":     purged,
+		})
+		record := domain.AuditRecord{
+			ID:           uuid.NewString(),
+			TenantID:     tenantID,
+			Actor:        strings.TrimSpace(input.Actor),
+			Action:       AuditActionConversationErased,
+			ResourceType: "conversation",
+			ResourceID:   conversationID,
+			RequestID:    input.RequestID,
+			Metadata:     metadata,
+			CreatedAt:    output.ErasedAt,
+		}
+		if err := s.audit.RecordAudit(ctx, record); err != nil {
+			return output, fmt.Errorf("record erasure audit: %w", err)
+		}
+	}
+
+	return output, nil
+}
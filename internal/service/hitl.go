@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/quality"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+type RecordHITLDecisionInput struct {
+	TenantID            string
+	ConversationID      string
+	JobID               string
+	SuggestionRequestID string
+	SuggestionRank      int
+	Action              domain.HITLDecisionAction
+	ReviewerID          string
+	FinalText           string
+	Actor               string
+	RequestID           string
+	DecidedAt           time.Time
+}
+
+type RecordHITLDecisionOutput struct {
+	DecisionID string                    `json:"decision_id"`
+	Action     domain.HITLDecisionAction `json:"action"`
+	DecidedAt  time.Time                 `json:"decided_at"`
+	RecordedAt time.Time                 `json:"recorded_at"`
+}
+
+// HITLService records human review decisions over generated content.
+type HITLService struct {
+	repo   repository.HITLRepository
+	jobs   repository.JobsRepository
+	recent *quality.RecentReplies
+}
+
+func NewHITLService(repo repository.HITLRepository, jobs repository.JobsRepository) *HITLService {
+	return &HITLService{repo: repo, jobs: jobs}
+}
+
+// UseRecentReplies remembers the final text of approved and edited suggestions, so new
+// suggestions do not repeat what the agent just sent.
+func (s *HITLService) UseRecentReplies(recent *quality.RecentReplies) {
+	s.recent = recent
+}
+
+// RecordDecision persists a decision. A referenced job must exist and belong to the same
+// tenant and conversation, otherwise repository.ErrNotFound is returned.
+func (s *HITLService) RecordDecision(
+	ctx context.Context,
+	input RecordHITLDecisionInput,
+) (RecordHITLDecisionOutput, error) {
+	tenantID := strings.TrimSpace(input.TenantID)
+	conversationID := strings.TrimSpace(input.ConversationID)
+	jobID := strings.TrimSpace(input.JobID)
+	suggestionRequestID := strings.TrimSpace(input.SuggestionRequestID)
+	if tenantID == "" || conversationID == "" {
+		return RecordHITLDecisionOutput{}, errors.New("tenant_id and conversation_id are required")
+	}
+	if jobID == "" && suggestionRequestID == "" {
+		return RecordHITLDecisionOutput{}, errors.New("job_id or suggestion_request_id is required")
+	}
+
+	if jobID != "" {
+		job, err := s.jobs.GetJob(ctx, jobID)
+		if err != nil {
+			return RecordHITLDecisionOutput{}, err
+		}
+		if job.TenantID != tenantID || job.ConversationID != conversationID {
+			return RecordHITLDecisionOutput{}, repository.ErrNotFound
+		}
+	}
+
+	now := time.Now().UTC()
+	decidedAt := input.DecidedAt.UTC()
+	if input.DecidedAt.IsZero() {
+		decidedAt = now
+	}
+
+	checksum := ""
+	if text := strings.TrimSpace(input.FinalText); text != "" {
+		sum := sha256.Sum256([]byte(text))
+		checksum = hex.EncodeToString(sum[:])
+	}
+
+	decision := domain.HITLDecision{
+		ID:                  uuid.NewString(),
+		TenantID:            tenantID,
+		ConversationID:      conversationID,
+		JobID:               jobID,
+		SuggestionRequestID: suggestionRequestID,
+		SuggestionRank:      input.SuggestionRank,
+		Action:              input.Action,
+		ReviewerID:          strings.TrimSpace(input.ReviewerID),
+		FinalTextChecksum:   checksum,
+		Actor:               strings.TrimSpace(input.Actor),
+		RequestID:           input.RequestID,
+		DecidedAt:           decidedAt,
+		CreatedAt:           now,
+	}
+	if err := s.repo.RecordDecision(ctx, decision); err != nil {
+		return RecordHITLDecisionOutput{}, fmt.Errorf("record hitl decision: %w", err)
+	}
+	if suggestionRequestID != "" && decision.Action != domain.HITLDecisionRejected {
+		s.recent.Add(tenantID, conversationID, policy.MaskTenantPIIString(tenantID, input.FinalText))
+	}
+
+	return RecordHITLDecisionOutput{
+		DecisionID: decision.ID,
+		Action:     decision.Action,
+		DecidedAt:  decision.DecidedAt,
+		RecordedAt: decision.CreatedAt,
+	}, nil
+}
+
+// ErrApprovalNotPending is returned for decisions on jobs that are not done or whose result
+// is not awaiting approval.
+var ErrApprovalNotPending = errors.New("job is not awaiting approval")
+
+// ErrInvalidEditedResult is returned when an edited result is not a JSON object.
+var ErrInvalidEditedResult = errors.New("edited result must be a JSON object")
+
+type DecideJobInput struct {
+	JobID      string
+	Action     domain.HITLDecisionAction
+	ReviewerID string
+	// EditedResult holds the fields the reviewer rewrote; they replace the generated ones
+	// in edited decisions.
+	EditedResult json.RawMessage
+	Actor        string
+	RequestID    string
+}
+
+// DecideJob settles the approval of a job result. The decision is recorded before the job
+// moves to approved (approved and edited decisions) or rejected, so every approved result
+// has a reviewer on file.
+func (s *HITLService) DecideJob(ctx context.Context, input DecideJobInput) (*domain.Job, RecordHITLDecisionOutput, error) {
+	job, err := s.jobs.GetJob(ctx, strings.TrimSpace(input.JobID))
+	if err != nil {
+		return nil, RecordHITLDecisionOutput{}, err
+	}
+	if job.Status != domain.JobStatusDone || job.Approval != domain.JobApprovalPending {
+		return nil, RecordHITLDecisionOutput{}, ErrApprovalNotPending
+	}
+
+	finalResult := job.Result
+	if input.Action == domain.HITLDecisionEdited {
+		finalResult, err = mergeEditedResult(job.Result, policy.MaskTenantPIIJSON(job.TenantID, input.EditedResult))
+		if err != nil {
+			return nil, RecordHITLDecisionOutput{}, err
+		}
+	}
+
+	now := time.Now().UTC()
+	sum := sha256.Sum256(finalResult)
+	decision := domain.HITLDecision{
+		ID:                uuid.NewString(),
+		TenantID:          job.TenantID,
+		ConversationID:    job.ConversationID,
+		JobID:             job.ID,
+		Action:            input.Action,
+		ReviewerID:        strings.TrimSpace(input.ReviewerID),
+		FinalTextChecksum: hex.EncodeToString(sum[:]),
+		Actor:             strings.TrimSpace(input.Actor),
+		RequestID:         input.RequestID,
+		DecidedAt:         now,
+		CreatedAt:         now,
+	}
+	if err := s.repo.RecordDecision(ctx, decision); err != nil {
+		return nil, RecordHITLDecisionOutput{}, fmt.Errorf("record hitl decision: %w", err)
+	}
+
+	job.Approval = domain.JobApprovalApproved
+	if input.Action == domain.HITLDecisionRejected {
+		job.Approval = domain.JobApprovalRejected
+	}
+	job.Result = finalResult
+	job.UpdatedAt = now
+	if err := s.jobs.UpdateJob(ctx, job); err != nil {
+		return nil, RecordHITLDecisionOutput{}, fmt.Errorf("update job approval: %w", err)
+	}
+
+	return job, RecordHITLDecisionOutput{
+		DecisionID: decision.ID,
+		Action:     decision.Action,
+		DecidedAt:  decision.DecidedAt,
+		RecordedAt: decision.CreatedAt,
+	}, nil
+}
+
+// mergeEditedResult overlays the edited fields on the generated result, keeping the
+// provenance the worker recorded (model, usage, quality).
+func mergeEditedResult(result, edited json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(edited, &fields); err != nil || len(fields) == 0 {
+		return nil, ErrInvalidEditedResult
+	}
+	merged := map[string]json.RawMessage{}
+	if err := json.Unmarshal(result, &merged); err != nil || merged == nil {
+		merged = map[string]json.RawMessage{}
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	encoded, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("encode edited result: %w", err)
+	}
+	return encoded, nil
+}
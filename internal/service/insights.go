@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+const insightsHistoryLimit = 500
+
+type InsightsInput struct {
+	TenantID       string
+	ConversationID string
+	Locale         string
+}
+
+type ContactCommitment struct {
+	Description string `json:"description"`
+	MadeBy      string `json:"made_by"`
+	DueHint     string `json:"due_hint"`
+}
+
+type ContactComplaint struct {
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+}
+
+type InsightsOutput struct {
+	MessageCount     int                 `json:"message_count"`
+	CustomerMessages int                 `json:"customer_messages"`
+	AgentMessages    int                 `json:"agent_messages"`
+	FirstMessageAt   time.Time           `json:"first_message_at"`
+	LastMessageAt    time.Time           `json:"last_message_at"`
+	LastAuthor       string              `json:"last_author"`
+	PreferredTone    string              `json:"preferred_tone"`
+	Commitments      []ContactCommitment `json:"commitments"`
+	OpenComplaints   []ContactComplaint  `json:"open_complaints"`
+	ModelID          string              `json:"model_id"`
+	PromptVersion    string              `json:"prompt_version"`
+	QualityScore     float64             `json:"quality_score"`
+}
+
+// InsightsService builds the contact profile shown in the extension sidebar from the
+// stored conversation history. Counters are computed from the store; commitments, tone
+// and complaints come from the model.
+type InsightsService struct {
+	generator *AIGenerationService
+	messages  repository.MessagesRepository
+}
+
+func NewInsightsService(generator *AIGenerationService, messages repository.MessagesRepository) *InsightsService {
+	return &InsightsService{generator: generator, messages: messages}
+}
+
+// Insights returns repository.ErrNotFound when the conversation has no stored history.
+func (s *InsightsService) Insights(ctx context.Context, input InsightsInput) (InsightsOutput, error) {
+	tenantID := strings.TrimSpace(input.TenantID)
+	conversationID := strings.TrimSpace(input.ConversationID)
+	if tenantID == "" || conversationID == "" {
+		return InsightsOutput{}, errors.New("tenant_id and conversation_id are required")
+	}
+
+	history, err := s.messages.ListRecentMessages(ctx, tenantID, conversationID, insightsHistoryLimit)
+	if err != nil {
+		return InsightsOutput{}, fmt.Errorf("load conversation history: %w", err)
+	}
+	if len(history) == 0 {
+		return InsightsOutput{}, repository.ErrNotFound
+	}
+
+	output := InsightsOutput{
+		MessageCount:   len(history),
+		FirstMessageAt: history[0].CreatedAt,
+		LastMessageAt:  history[len(history)-1].CreatedAt,
+		LastAuthor:     string(history[len(history)-1].AuthorRole),
+		PreferredTone:  "neutro",
+		Commitments:    []ContactCommitment{},
+		OpenComplaints: []ContactComplaint{},
+		ModelID:        "fallback-local",
+		PromptVersion:  "insights_v1",
+		QualityScore:   0.55,
+	}
+	for _, message := range history {
+		switch message.AuthorRole {
+		case domain.MessageAuthorCustomer:
+			output.CustomerMessages++
+		case domain.MessageAuthorAgent:
+			output.AgentMessages++
+		}
+	}
+	if s.generator == nil {
+		return output, nil
+	}
+
+	// The history retriever reads the stored messages itself; the payload only carries
+	// the request metadata.
+	payload, err := json.Marshal(map[string]any{
+		"conversation_id": conversationID,
+		"locale":          input.Locale,
+		"message_count":   output.MessageCount,
+	})
+	if err != nil {
+		return InsightsOutput{}, fmt.Errorf("encode insights payload: %w", err)
+	}
+	generated, err := s.generator.GenerateInsights(ctx, JobGenerationInput{
+		TenantID:       tenantID,
+		ConversationID: conversationID,
+		Locale:         input.Locale,
+		Payload:        payload,
+	})
+	if err != nil {
+		return InsightsOutput{}, err
+	}
+
+	var profile struct {
+		PreferredTone  string              `json:"preferred_tone"`
+		Commitments    []ContactCommitment `json:"commitments"`
+		OpenComplaints []ContactComplaint  `json:"open_complaints"`
+		ModelID        string              `json:"model_id"`
+		PromptVersion  string              `json:"prompt_version"`
+		QualityScore   float64             `json:"quality_score"`
+	}
+	if err := json.Unmarshal(generated.Body, &profile); err != nil {
+		return InsightsOutput{}, fmt.Errorf("decode insights output: %w", err)
+	}
+	output.PreferredTone = firstNonEmpty(profile.PreferredTone, output.PreferredTone)
+	if profile.Commitments != nil {
+		output.Commitments = profile.Commitments
+	}
+	if profile.OpenComplaints != nil {
+		output.OpenComplaints = profile.OpenComplaints
+	}
+	output.ModelID = firstNonEmpty(profile.ModelID, generated.ModelID)
+	output.PromptVersion = firstNonEmpty(profile.PromptVersion, generated.PromptVersion)
+	output.QualityScore = profile.QualityScore
+	return output, nil
+}
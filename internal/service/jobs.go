@@ -3,32 +3,60 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/iago/extensao-whatsapp-back/internal/audit"
 	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
 	"github.com/iago/extensao-whatsapp-back/internal/policy"
 	"github.com/iago/extensao-whatsapp-back/internal/queue"
 	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
 )
 
 type JobsService struct {
 	repo     repository.JobsRepository
 	producer queue.Producer
+	// approvalTenants need a human decision on every generated result.
+	approvalTenants map[string]bool
+	settings        *TenantSettingsService
 }
 
+// ErrQuotaExceeded is returned when a tenant already created its daily job quota.
+var ErrQuotaExceeded = errors.New("tenant daily job quota exceeded")
+
 func NewJobsService(repo repository.JobsRepository, producer queue.Producer) *JobsService {
 	return &JobsService{repo: repo, producer: producer}
 }
 
+// RequireApproval makes jobs of tenantIDs wait for an approve, edit or reject decision
+// (see HITLService.DecideJob) once generated.
+func (s *JobsService) RequireApproval(tenantIDs []string) {
+	s.approvalTenants = make(map[string]bool, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		if tenantID = strings.TrimSpace(tenantID); tenantID != "" {
+			s.approvalTenants[tenantID] = true
+		}
+	}
+}
+
+// UseTenantSettings enforces the daily job quota of each tenant's settings.
+func (s *JobsService) UseTenantSettings(settings *TenantSettingsService) {
+	s.settings = settings
+}
+
 func (s *JobsService) EnqueueSummary(
 	ctx context.Context,
 	tenantID string,
 	conversationID string,
 	payload json.RawMessage,
+	priority domain.JobPriority,
 ) (*domain.Job, error) {
-	return s.enqueue(ctx, domain.JobKindSummary, tenantID, conversationID, payload)
+	return s.enqueue(ctx, domain.JobKindSummary, tenantID, conversationID, payload, nil, priority)
 }
 
 func (s *JobsService) EnqueueReport(
@@ -36,14 +64,111 @@ func (s *JobsService) EnqueueReport(
 	tenantID string,
 	conversationID string,
 	payload json.RawMessage,
+	tags []string,
+	priority domain.JobPriority,
+) (*domain.Job, error) {
+	return s.enqueue(ctx, domain.JobKindReport, tenantID, conversationID, payload, tags, priority)
+}
+
+// EnqueueReportWithSummary queues a fresh summary of the conversation and a report that
+// runs on it once it is done. It returns the report, then the summary.
+func (s *JobsService) EnqueueReportWithSummary(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+	payload json.RawMessage,
+	tags []string,
+	priority domain.JobPriority,
+) (*domain.Job, *domain.Job, error) {
+	jobs, err := s.EnqueuePipeline(ctx, tenantID, conversationID, []PipelineStep{
+		{Kind: domain.JobKindSummary, Payload: payload, Priority: priority},
+		{Kind: domain.JobKindReport, Payload: payload, Tags: tags, Priority: priority},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return jobs[1], jobs[0], nil
+}
+
+// EnqueueDigest queues a cross-conversation digest. Digests cover the whole tenant, so the
+// job carries no conversation.
+func (s *JobsService) EnqueueDigest(
+	ctx context.Context,
+	tenantID string,
+	payload json.RawMessage,
 ) (*domain.Job, error) {
-	return s.enqueue(ctx, domain.JobKindReport, tenantID, conversationID, payload)
+	return s.enqueue(ctx, domain.JobKindDigest, tenantID, "", payload, nil, "")
+}
+
+// SetReportTags replaces the tags of a report. Jobs of other kinds are reported as
+// repository.ErrNotFound.
+func (s *JobsService) SetReportTags(ctx context.Context, reportID string, tags []string) (*domain.Job, error) {
+	job, err := s.repo.GetJob(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Kind != domain.JobKindReport {
+		return nil, repository.ErrNotFound
+	}
+	if err := s.repo.SetJobTags(ctx, reportID, tags); err != nil {
+		return nil, err
+	}
+	job.Tags = tags
+	return job, nil
 }
 
 func (s *JobsService) GetJob(ctx context.Context, jobID string) (*domain.Job, error) {
 	return s.repo.GetJob(ctx, jobID)
 }
 
+const (
+	jobWaitInitialInterval = 100 * time.Millisecond
+	jobWaitMaxInterval     = time.Second
+)
+
+// WaitForJob long-polls a job until it reaches a terminal status, wait elapses or ctx is
+// done. It returns the latest known state of the job; timing out is not an error.
+func (s *JobsService) WaitForJob(ctx context.Context, jobID string, wait time.Duration) (*domain.Job, error) {
+	job, err := s.repo.GetJob(ctx, jobID)
+	if err != nil || wait <= 0 || job.Status.Terminal() {
+		return job, err
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
+	interval := jobWaitInitialInterval
+	for {
+		ticker := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			ticker.Stop()
+			return job, nil
+		case <-deadline.C:
+			ticker.Stop()
+			return job, nil
+		case <-ticker.C:
+		}
+
+		latest, err := s.repo.GetJob(ctx, jobID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return job, nil
+			}
+			return nil, err
+		}
+		job = latest
+		if job.Status.Terminal() {
+			return job, nil
+		}
+
+		interval *= 2
+		if interval > jobWaitMaxInterval {
+			interval = jobWaitMaxInterval
+		}
+	}
+}
+
 func (s *JobsService) ListReports(
 	ctx context.Context,
 	filter domain.ReportListFilter,
@@ -51,49 +176,196 @@ func (s *JobsService) ListReports(
 	return s.repo.ListReports(ctx, filter)
 }
 
+func (s *JobsService) ListSummaries(
+	ctx context.Context,
+	filter domain.SummaryListFilter,
+) ([]domain.SummaryListItem, int, error) {
+	return s.repo.ListSummaries(ctx, filter)
+}
+
+func (s *JobsService) ListDigests(
+	ctx context.Context,
+	filter domain.DigestListFilter,
+) ([]domain.DigestListItem, int, error) {
+	return s.repo.ListDigests(ctx, filter)
+}
+
+func (s *JobsService) JobStats(
+	ctx context.Context,
+	filter domain.JobStatsFilter,
+) ([]domain.JobStatsBucket, error) {
+	return s.repo.JobStats(ctx, filter)
+}
+
+// PipelineStep is one job of a pipeline; every step runs on the result of the previous
+// one.
+type PipelineStep struct {
+	Kind    domain.JobKind
+	Payload json.RawMessage
+	Tags    []string
+	// Priority defaults to the kind's (domain.DefaultJobPriority).
+	Priority domain.JobPriority
+}
+
+// EnqueuePipeline creates one job per step, each depending on the previous one, and
+// enqueues the first. The worker enqueues each following step once its predecessor is
+// done. Jobs are returned in step order.
+func (s *JobsService) EnqueuePipeline(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+	steps []PipelineStep,
+) ([]*domain.Job, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("pipeline has no steps")
+	}
+	if err := s.checkQuota(ctx, tenantID, len(steps)); err != nil {
+		return nil, err
+	}
+	ctx, span := startEnqueueSpan(ctx, steps[0].Kind)
+	defer span.End()
+	jobs := make([]*domain.Job, 0, len(steps))
+	for index, step := range steps {
+		job := s.newJob(ctx, step.Kind, tenantID, conversationID, step.Payload, step.Tags, step.Priority)
+		if index > 0 {
+			job.DependsOn = jobs[index-1].ID
+			// Keep creation order so dependents are listed in step order.
+			job.CreatedAt = jobs[index-1].CreatedAt.Add(time.Microsecond)
+			job.UpdatedAt = job.CreatedAt
+		}
+		if err := s.repo.CreateJob(ctx, job); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("create job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	span.SetAttribute("job.id", jobs[0].ID)
+	if err := s.send(ctx, jobs[0]); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return jobs, nil
+}
+
 func (s *JobsService) enqueue(
 	ctx context.Context,
 	kind domain.JobKind,
 	tenantID string,
 	conversationID string,
 	payload json.RawMessage,
+	tags []string,
+	priority domain.JobPriority,
 ) (*domain.Job, error) {
-	sanitizedPayload := policy.MaskPIIJSON(payload)
+	if err := s.checkQuota(ctx, tenantID, 1); err != nil {
+		return nil, err
+	}
+	ctx, span := startEnqueueSpan(ctx, kind)
+	defer span.End()
+	job := s.newJob(ctx, kind, tenantID, conversationID, payload, tags, priority)
+	span.SetAttribute("job.id", job.ID)
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("create job: %w", err)
+	}
+	if err := s.send(ctx, job); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return job, nil
+}
 
+// checkQuota fails with ErrQuotaExceeded when creating count more jobs would take
+// tenantID past its daily quota. Concurrent requests may overshoot it slightly.
+func (s *JobsService) checkQuota(ctx context.Context, tenantID string, count int) error {
+	if s.settings == nil {
+		return nil
+	}
+	limit := s.settings.Resolve(ctx, tenantID).Quotas.DailyJobs
+	if limit <= 0 {
+		return nil
+	}
+	dayStart := time.Now().UTC().Truncate(24 * time.Hour)
+	buckets, err := s.repo.JobStats(ctx, domain.JobStatsFilter{TenantID: tenantID, From: &dayStart})
+	if err != nil {
+		return fmt.Errorf("count tenant jobs: %w", err)
+	}
+	created := 0
+	for _, bucket := range buckets {
+		created += bucket.Count
+	}
+	if created+count > limit {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (s *JobsService) newJob(
+	ctx context.Context,
+	kind domain.JobKind,
+	tenantID string,
+	conversationID string,
+	payload json.RawMessage,
+	tags []string,
+	priority domain.JobPriority,
+) *domain.Job {
+	if priority == "" {
+		priority = domain.DefaultJobPriority(kind)
+	}
 	now := time.Now().UTC()
 	job := &domain.Job{
 		ID:             uuid.NewString(),
 		Kind:           kind,
 		TenantID:       tenantID,
 		ConversationID: conversationID,
-		Payload:        sanitizedPayload,
+		Payload:        policy.MaskTenantPIIJSON(tenantID, payload),
 		Status:         domain.JobStatusPending,
 		Attempts:       0,
+		Tags:           tags,
+		Priority:       priority,
+		TraceParent:    tracing.TraceParent(ctx),
+		RequestID:      logging.RequestID(ctx),
 		CreatedAt:      now,
 		UpdatedAt:      now,
 	}
-
-	if err := s.repo.CreateJob(ctx, job); err != nil {
-		return nil, fmt.Errorf("create job: %w", err)
+	if s.approvalTenants[tenantID] {
+		job.Approval = domain.JobApprovalPending
 	}
+	return job
+}
+
+// startEnqueueSpan opens the producer span covering the creation of the job rows and the
+// queue send; the rows and the message carry its trace context to the worker.
+func startEnqueueSpan(ctx context.Context, kind domain.JobKind) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, "queue.enqueue", tracing.KindProducer)
+	span.SetAttribute("job.kind", string(kind))
+	return ctx, span
+}
 
+// send enqueues a created job; a job that cannot be enqueued is marked failed.
+func (s *JobsService) send(ctx context.Context, job *domain.Job) error {
 	message := domain.QueueMessage{
 		JobID:          job.ID,
 		Kind:           job.Kind,
-		TenantID:       tenantID,
-		ConversationID: conversationID,
-		Payload:        sanitizedPayload,
+		TenantID:       job.TenantID,
+		ConversationID: job.ConversationID,
+		Payload:        job.Payload,
 		Attempt:        0,
-		RequestedAt:    now,
+		RequestedAt:    job.CreatedAt,
+		TraceParent:    job.TraceParent,
+		RequestID:      job.RequestID,
+		Priority:       job.Priority,
 	}
-
-	if err := s.producer.Enqueue(ctx, message); err != nil {
+	err := s.producer.Enqueue(ctx, message)
+	if err != nil {
+		finishedAt := time.Now().UTC()
 		job.Status = domain.JobStatusFailed
 		job.ErrorMessage = err.Error()
-		job.UpdatedAt = time.Now().UTC()
+		job.FinishedAt = &finishedAt
+		job.UpdatedAt = finishedAt
 		_ = s.repo.UpdateJob(ctx, job)
-		return nil, fmt.Errorf("enqueue job: %w", err)
+		return fmt.Errorf("enqueue job: %w", err)
 	}
-
-	return job, nil
+	audit.Note(ctx, job.TenantID, job.ID)
+	audit.AddMetadata(ctx, "conversation_id", job.ConversationID)
+	return nil
 }
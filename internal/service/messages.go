@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+// ContextInvalidator drops cached context builds so new history is picked up immediately.
+type ContextInvalidator interface {
+	Invalidate(tenantID, conversationID string) int
+}
+
+type IngestMessage struct {
+	MessageID  string
+	AuthorRole domain.MessageAuthorRole
+	Text       string
+	SentAt     time.Time
+}
+
+type IngestMessagesInput struct {
+	TenantID       string
+	ConversationID string
+	Messages       []IngestMessage
+}
+
+type IngestMessagesOutput struct {
+	ConversationID string `json:"conversation_id"`
+	Received       int    `json:"received"`
+	Accepted       int    `json:"accepted"`
+	Duplicates     int    `json:"duplicates"`
+}
+
+type MessagesService struct {
+	repo        repository.MessagesRepository
+	invalidator ContextInvalidator
+	pseudonyms  *PseudonymsService
+}
+
+func NewMessagesService(
+	repo repository.MessagesRepository,
+	invalidator ContextInvalidator,
+	pseudonyms *PseudonymsService,
+) *MessagesService {
+	return &MessagesService{repo: repo, invalidator: invalidator, pseudonyms: pseudonyms}
+}
+
+// Ingest stores a batch in sent order, dropping repeats of the same message_id.
+// Text is PII-masked (or pseudonymized) before it is persisted.
+func (s *MessagesService) Ingest(ctx context.Context, input IngestMessagesInput) (IngestMessagesOutput, error) {
+	tenantID := strings.TrimSpace(input.TenantID)
+	conversationID := strings.TrimSpace(input.ConversationID)
+	if tenantID == "" || conversationID == "" {
+		return IngestMessagesOutput{}, errors.New("tenant_id and conversation_id are required")
+	}
+
+	now := time.Now().UTC()
+	batchSeen := make(map[string]struct{}, len(input.Messages))
+	messages := make([]domain.Message, 0, len(input.Messages))
+	for _, item := range input.Messages {
+		if _, duplicated := batchSeen[item.MessageID]; duplicated {
+			continue
+		}
+		batchSeen[item.MessageID] = struct{}{}
+
+		text, err := s.pseudonyms.ProtectString(ctx, tenantID, conversationID, strings.TrimSpace(item.Text))
+		if err != nil {
+			return IngestMessagesOutput{}, err
+		}
+		checksum := sha256.Sum256([]byte(text))
+		messages = append(messages, domain.Message{
+			TenantID:        tenantID,
+			ConversationID:  conversationID,
+			SourceMessageID: item.MessageID,
+			AuthorRole:      item.AuthorRole,
+			Text:            text,
+			Checksum:        hex.EncodeToString(checksum[:]),
+			CreatedAt:       item.SentAt.UTC(),
+			IngestedAt:      now,
+		})
+	}
+
+	accepted, err := s.repo.AppendMessages(ctx, messages)
+	if err != nil {
+		return IngestMessagesOutput{}, fmt.Errorf("append messages: %w", err)
+	}
+	if accepted > 0 && s.invalidator != nil {
+		s.invalidator.Invalidate(tenantID, conversationID)
+	}
+
+	return IngestMessagesOutput{
+		ConversationID: conversationID,
+		Received:       len(input.Messages),
+		Accepted:       accepted,
+		Duplicates:     len(input.Messages) - accepted,
+	}, nil
+}
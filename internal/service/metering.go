@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/ai"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+)
+
+const defaultMeteringFlushInterval = 30 * time.Second
+
+// MeteringService counts requests, cache hits, tokens and estimated cost per tenant and
+// day. Counts are kept in memory and added to storage on every flush, so the hot path
+// never waits on the database; reads include what is not flushed yet.
+type MeteringService struct {
+	repo   repository.UsageRepository
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pending map[meteringKey]*domain.TenantUsage
+}
+
+type meteringKey struct {
+	tenantID string
+	day      time.Time
+}
+
+func NewMeteringService(repo repository.UsageRepository, logger *slog.Logger) *MeteringService {
+	return &MeteringService{
+		repo:    repo,
+		logger:  logger,
+		now:     time.Now,
+		pending: make(map[meteringKey]*domain.TenantUsage),
+	}
+}
+
+// Request counts one generation served to tenantID, cache hits included.
+func (s *MeteringService) Request(tenantID string) {
+	s.add(tenantID, domain.TenantUsage{Requests: 1})
+}
+
+// CacheHit counts one generation of tenantID answered from the semantic cache.
+func (s *MeteringService) CacheHit(tenantID string) {
+	s.add(tenantID, domain.TenantUsage{CacheHits: 1})
+}
+
+// ModelCall adds the tokens of one provider call of tenantID; cost counts only when the
+// model is priced.
+func (s *MeteringService) ModelCall(tenantID string, usage ai.TokenUsage, cost float64, priced bool) {
+	delta := domain.TenantUsage{
+		InputTokens:  int64(usage.InputTokens),
+		OutputTokens: int64(usage.OutputTokens),
+	}
+	if priced {
+		delta.CostUSD = cost
+	}
+	s.add(tenantID, delta)
+}
+
+func (s *MeteringService) add(tenantID string, delta domain.TenantUsage) {
+	tenantID = strings.TrimSpace(tenantID)
+	if s == nil || tenantID == "" {
+		return
+	}
+	key := meteringKey{tenantID: tenantID, day: domain.UsageDay(s.now())}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage, ok := s.pending[key]
+	if !ok {
+		usage = &domain.TenantUsage{TenantID: key.tenantID, Day: key.day}
+		s.pending[key] = usage
+	}
+	usage.Add(delta)
+}
+
+// Flush adds the pending counts to storage. On failure they are kept for the next flush.
+func (s *MeteringService) Flush(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[meteringKey]*domain.TenantUsage)
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	deltas := make([]domain.TenantUsage, 0, len(pending))
+	for _, usage := range pending {
+		deltas = append(deltas, *usage)
+	}
+	// The pending counts span tenants, so the write is system work.
+	if err := s.repo.AddUsage(tenant.WithBypass(ctx), deltas); err != nil {
+		s.mu.Lock()
+		for key, usage := range pending {
+			if current, ok := s.pending[key]; ok {
+				current.Add(*usage)
+				continue
+			}
+			s.pending[key] = usage
+		}
+		s.mu.Unlock()
+		return fmt.Errorf("flush tenant usage: %w", err)
+	}
+	return nil
+}
+
+// Start flushes every interval until ctx is done. The final flush is left to the caller,
+// which must run it before closing the repository.
+func (s *MeteringService) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultMeteringFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Flush(ctx); err != nil && ctx.Err() == nil && s.logger != nil {
+			s.logger.LogAttrs(ctx, slog.LevelError, "usage flush failed", slog.Any("error", err))
+		}
+	}
+}
+
+// Usage returns the daily totals selected by filter, including the counts of this
+// replica that are not flushed yet.
+func (s *MeteringService) Usage(ctx context.Context, filter domain.TenantUsageFilter) ([]domain.TenantUsage, error) {
+	stored, err := s.repo.ListUsage(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("list tenant usage: %w", err)
+	}
+
+	totals := make(map[meteringKey]*domain.TenantUsage, len(stored))
+	items := make([]*domain.TenantUsage, 0, len(stored))
+	for index := range stored {
+		usage := &stored[index]
+		totals[meteringKey{tenantID: usage.TenantID, day: usage.Day}] = usage
+		items = append(items, usage)
+	}
+	s.mu.Lock()
+	for key, pending := range s.pending {
+		if !filter.Matches(*pending) {
+			continue
+		}
+		if total, ok := totals[key]; ok {
+			total.Add(*pending)
+			continue
+		}
+		usage := *pending
+		totals[key] = &usage
+		items = append(items, &usage)
+	}
+	s.mu.Unlock()
+
+	result := make([]domain.TenantUsage, 0, len(items))
+	for _, usage := range items {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TenantID != result[j].TenantID {
+			return result[i].TenantID < result[j].TenantID
+		}
+		return result[i].Day.Before(result[j].Day)
+	})
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+// PolicyViolationCodeAutoSend is recorded when the manual-only guard blocks a request.
+const PolicyViolationCodeAutoSend = "auto_send"
+
+type RecordPolicyViolationInput struct {
+	TenantID       string
+	ConversationID string
+	Route          string
+	RequestID      string
+	// Err is the error returned by the policy check.
+	Err error
+}
+
+// PolicyViolationsService keeps the trail of blocked requests and its aggregates.
+type PolicyViolationsService struct {
+	repo    repository.PolicyViolationsRepository
+	metrics *policy.Metrics
+}
+
+func NewPolicyViolationsService(repo repository.PolicyViolationsRepository) *PolicyViolationsService {
+	return &PolicyViolationsService{repo: repo}
+}
+
+// UseMetrics counts every recorded block in m, even when storing it fails.
+func (s *PolicyViolationsService) UseMetrics(m *policy.Metrics) {
+	s.metrics = m
+}
+
+// Record stores one block. Errors other than policy violations are ignored.
+func (s *PolicyViolationsService) Record(ctx context.Context, input RecordPolicyViolationInput) error {
+	record := domain.PolicyViolationRecord{
+		ID:             uuid.NewString(),
+		TenantID:       strings.TrimSpace(input.TenantID),
+		ConversationID: strings.TrimSpace(input.ConversationID),
+		Route:          input.Route,
+		RequestID:      input.RequestID,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	var violation *policy.PolicyViolationError
+	switch {
+	case errors.Is(input.Err, policy.ErrAutoSendNotAllowed):
+		record.Codes = []string{PolicyViolationCodeAutoSend}
+	case errors.As(input.Err, &violation):
+		for _, item := range violation.Violations {
+			record.Codes = append(record.Codes, item.Code)
+			if record.Rule == "" {
+				record.Rule = item.Rule
+			}
+		}
+	default:
+		return nil
+	}
+	if len(record.Codes) == 0 {
+		return nil
+	}
+	s.metrics.Blocked(record.Codes)
+
+	if err := s.repo.RecordPolicyViolation(ctx, record); err != nil {
+		return fmt.Errorf("record policy violation: %w", err)
+	}
+	return nil
+}
+
+func (s *PolicyViolationsService) Counts(
+	ctx context.Context,
+	filter domain.PolicyViolationFilter,
+) ([]domain.PolicyViolationCount, error) {
+	return s.repo.PolicyViolationCounts(ctx, filter)
+}
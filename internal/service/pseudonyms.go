@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+// pseudonymLabels names the tokens of each PII category ("[TEL_1]").
+var pseudonymLabels = map[string]string{
+	policy.PIIEmail: "EMAIL",
+	policy.PIIPhone: "TEL",
+	policy.PIICPF:   "CPF",
+	policy.PIICNPJ:  "CNPJ",
+	policy.PIICard:  "CARTAO",
+	policy.PIIIBAN:  "IBAN",
+	policy.PIIRG:    "RG",
+	policy.PIICEP:   "CEP",
+	policy.PIIPlate: "PLACA",
+	policy.PIIPix:   "PIX",
+}
+
+// PseudonymsService protects conversation text before it reaches storage or a model. It
+// masks PII, or, for tenants whose PII rules pseudonymize, swaps each value for a token
+// that stays the same within the conversation and keeps the mapping so the extension can
+// re-hydrate generated replies before the agent sends them. A nil service only masks.
+type PseudonymsService struct {
+	repo repository.PseudonymsRepository
+}
+
+func NewPseudonymsService(repo repository.PseudonymsRepository) *PseudonymsService {
+	return &PseudonymsService{repo: repo}
+}
+
+// ProtectString masks or pseudonymizes value.
+func (s *PseudonymsService) ProtectString(ctx context.Context, tenantID, conversationID, value string) (string, error) {
+	if !s.pseudonymizes(tenantID, conversationID) {
+		return policy.MaskTenantPIIString(tenantID, value), nil
+	}
+	assigner := s.assigner(ctx, tenantID, conversationID)
+	protected := policy.ReplaceTenantPIIString(tenantID, value, assigner.token)
+	if assigner.err != nil {
+		return "", assigner.err
+	}
+	return protected, nil
+}
+
+// ProtectJSON masks or pseudonymizes every string in payload.
+func (s *PseudonymsService) ProtectJSON(
+	ctx context.Context,
+	tenantID string,
+	conversationID string,
+	payload json.RawMessage,
+) (json.RawMessage, error) {
+	if !s.pseudonymizes(tenantID, conversationID) {
+		return policy.MaskTenantPIIJSON(tenantID, payload), nil
+	}
+	assigner := s.assigner(ctx, tenantID, conversationID)
+	protected := policy.ReplaceTenantPIIJSON(tenantID, payload, assigner.token)
+	if assigner.err != nil {
+		return nil, assigner.err
+	}
+	return protected, nil
+}
+
+// ListPseudonyms returns the token mapping of a conversation.
+func (s *PseudonymsService) ListPseudonyms(ctx context.Context, tenantID, conversationID string) ([]domain.Pseudonym, error) {
+	return s.repo.ListPseudonyms(ctx, strings.TrimSpace(tenantID), strings.TrimSpace(conversationID))
+}
+
+func (s *PseudonymsService) pseudonymizes(tenantID, conversationID string) bool {
+	return s != nil && s.repo != nil && strings.TrimSpace(conversationID) != "" && policy.PseudonymizesPII(tenantID)
+}
+
+func (s *PseudonymsService) assigner(ctx context.Context, tenantID, conversationID string) *pseudonymAssigner {
+	return &pseudonymAssigner{
+		ctx:            ctx,
+		repo:           s.repo,
+		tenantID:       tenantID,
+		conversationID: strings.TrimSpace(conversationID),
+		tokens:         make(map[string]string),
+	}
+}
+
+// pseudonymAssigner resolves the tokens of one text. After the first store error every
+// match is masked and the error is reported once the replacement finishes.
+type pseudonymAssigner struct {
+	ctx            context.Context
+	repo           repository.PseudonymsRepository
+	tenantID       string
+	conversationID string
+	tokens         map[string]string
+	err            error
+}
+
+func (a *pseudonymAssigner) token(category, match string) string {
+	if a.err != nil {
+		return policy.RedactPII(category, match)
+	}
+	hash := pseudonymValueHash(category, match)
+	if token, ok := a.tokens[category+":"+hash]; ok {
+		return token
+	}
+	pseudonym, err := a.repo.AssignPseudonym(a.ctx, domain.Pseudonym{
+		TenantID:       a.tenantID,
+		ConversationID: a.conversationID,
+		Category:       category,
+		ValueHash:      hash,
+		Value:          strings.TrimSpace(match),
+		CreatedAt:      time.Now().UTC(),
+	}, pseudonymLabels[category])
+	if err != nil {
+		a.err = fmt.Errorf("assign pseudonym: %w", err)
+		return policy.RedactPII(category, match)
+	}
+	a.tokens[category+":"+hash] = pseudonym.Token
+	return pseudonym.Token
+}
+
+// pseudonymValueHash ignores case and, except for emails, punctuation and spacing. Phones
+// compare by their last 10 digits, so a missing country code does not make a new token.
+func pseudonymValueHash(category, value string) string {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	if category != policy.PIIEmail {
+		normalized = strings.Map(func(char rune) rune {
+			if unicode.IsLetter(char) || unicode.IsDigit(char) {
+				return char
+			}
+			return -1
+		}, normalized)
+	}
+	if category == policy.PIIPhone && len(normalized) > 10 {
+		normalized = normalized[len(normalized)-10:]
+	}
+	sum := sha256.Sum256([]byte(category + ":" + normalized))
+	return hex.EncodeToString(sum[:])
+}
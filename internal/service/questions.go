@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type QuestionsInput struct {
+	TenantID       string
+	ConversationID string
+	Locale         string
+	Payload        json.RawMessage
+}
+
+type QuestionsOutput struct {
+	Questions     []string `json:"questions"`
+	Rationale     string   `json:"rationale,omitempty"`
+	ModelID       string   `json:"model_id"`
+	PromptVersion string   `json:"prompt_version"`
+	QualityScore  float64  `json:"quality_score"`
+}
+
+// QuestionsService suggests clarifying questions the agent can ask when the conversation
+// lacks the information needed to answer.
+type QuestionsService struct {
+	generator *AIGenerationService
+}
+
+func NewQuestionsService(generator *AIGenerationService) *QuestionsService {
+	return &QuestionsService{generator: generator}
+}
+
+func (s *QuestionsService) Suggest(ctx context.Context, input QuestionsInput) (QuestionsOutput, error) {
+	if s.generator == nil {
+		return QuestionsOutput{
+			Questions: []string{
+				"Pode me dar mais detalhes sobre o que voce precisa?",
+				"Qual e o numero do pedido ou protocolo relacionado?",
+			},
+			ModelID:       "fallback-local",
+			PromptVersion: "questions_v1",
+			QualityScore:  0.55,
+		}, nil
+	}
+
+	generated, err := s.generator.GenerateQuestions(ctx, JobGenerationInput{
+		TenantID:       input.TenantID,
+		ConversationID: input.ConversationID,
+		Locale:         input.Locale,
+		Payload:        input.Payload,
+	})
+	if err != nil {
+		return QuestionsOutput{}, err
+	}
+
+	var output QuestionsOutput
+	if err := json.Unmarshal(generated.Body, &output); err != nil {
+		return QuestionsOutput{}, fmt.Errorf("decode questions output: %w", err)
+	}
+	output.ModelID = firstNonEmpty(output.ModelID, generated.ModelID)
+	output.PromptVersion = firstNonEmpty(output.PromptVersion, generated.PromptVersion)
+	if output.Questions == nil {
+		output.Questions = []string{}
+	}
+	return output, nil
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/iago/extensao-whatsapp-back/internal/ai"
+	contextbuilder "github.com/iago/extensao-whatsapp-back/internal/context"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+)
+
+const (
+	sectionedReportPromptVersion = "report_sections_v1"
+	sectionedReportTitle         = "Relatorio da conversa"
+)
+
+// ReportSection is one section of a generated report.
+type ReportSection struct {
+	Heading string `json:"heading"`
+	Content string `json:"content"`
+}
+
+// SectionedReportInput asks for a report with the given headings, one model call per
+// section. Done holds the sections an earlier attempt already generated; they are kept
+// as they are. Save, when set, receives every section done so far after each new one.
+type SectionedReportInput struct {
+	JobGenerationInput
+	Headings []string
+	Done     []ReportSection
+	Save     func(ctx context.Context, sections []ReportSection) error
+}
+
+// GenerateSectionedReport builds a report section by section. A section that fails to
+// generate fails the whole call, so the job is retried and resumes from the sections
+// saved; without a model the missing sections are filled in degraded mode.
+func (s *AIGenerationService) GenerateSectionedReport(ctx context.Context, input SectionedReportInput) (JobGenerationOutput, error) {
+	s.metering.Request(input.TenantID)
+	locale, tone, profile := s.tenantGeneration(ctx, ai.TaskReport, input.TenantID, input.Locale, input.Tone)
+
+	sections := resumeSections(input.Headings, input.Done)
+	if len(sections) == len(input.Headings) {
+		return s.assembleSectionedReport(ctx, input, locale, tone, sections, "", ai.TokenUsage{}, false)
+	}
+	if s.client == nil || !s.client.Available() {
+		return s.degradedSectionedReport(input, sections), nil
+	}
+
+	contextOut, err := s.builder.Build(ctx, contextbuilder.BuildInput{
+		Task:           string(ai.TaskReport),
+		TenantID:       input.TenantID,
+		ConversationID: input.ConversationID,
+		Payload:        input.Payload,
+		MaxInputTokens: 5200,
+		MaxChunks:      maxChunkLimitByTask(ai.TaskReport),
+		ContextWindow:  20,
+	})
+	if err != nil {
+		return JobGenerationOutput{}, fmt.Errorf("build report context: %w", err)
+	}
+
+	guidance := policy.TenantToneGuidance(input.TenantID, tone)
+	call := generationCall{tenantID: input.TenantID, task: ai.TaskReport, promptVersion: sectionedReportPromptVersion}
+	var (
+		usage   ai.TokenUsage
+		modelID string
+	)
+	for _, heading := range input.Headings[len(sections):] {
+		prompt, err := s.renderPrompt("report_section_v1.tmpl", map[string]any{
+			"Locale":           locale,
+			"Tone":             tone,
+			"Context":          contextOut.ContextText,
+			"Upstream":         input.Upstream,
+			"Heading":          heading,
+			"Previous":         sections,
+			"Avoid":            guidance.Avoid,
+			"ToneInstructions": guidance.Instructions,
+		})
+		if err != nil {
+			return JobGenerationOutput{}, fmt.Errorf("render report section prompt: %w", err)
+		}
+		generated, err := s.generateText(ctx, call, profile, prompt)
+		if err != nil {
+			return JobGenerationOutput{}, fmt.Errorf("generate report section %q: %w", heading, err)
+		}
+		content, err := parseSectionContent(generated.Text)
+		if err != nil {
+			return JobGenerationOutput{}, fmt.Errorf("parse report section %q: %w", heading, err)
+		}
+		usage.InputTokens += generated.Usage.InputTokens
+		usage.OutputTokens += generated.Usage.OutputTokens
+		usage.TotalTokens += generated.Usage.TotalTokens
+		modelID = generated.ModelID
+
+		sections = append(sections, ReportSection{Heading: heading, Content: content})
+		if input.Save != nil {
+			if err := input.Save(ctx, sections); err != nil {
+				s.warn(ctx, "save report sections failed", slog.Int("sections", len(sections)), slog.Any("error", err))
+			}
+		}
+	}
+	return s.assembleSectionedReport(ctx, input, locale, tone, sections, modelID, usage, true)
+}
+
+// resumeSections keeps the saved sections that still match the requested headings, in
+// order, up to the first one that does not.
+func resumeSections(headings []string, done []ReportSection) []ReportSection {
+	sections := make([]ReportSection, 0, len(headings))
+	for index, heading := range headings {
+		if index >= len(done) || done[index].Heading != heading || strings.TrimSpace(done[index].Content) == "" {
+			break
+		}
+		sections = append(sections, done[index])
+	}
+	return sections
+}
+
+func parseSectionContent(text string) (string, error) {
+	rawJSON, err := extractJSON(text)
+	if err != nil {
+		return "", err
+	}
+	var payload struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(rawJSON, &payload); err != nil {
+		return "", fmt.Errorf("decode report section json: %w", err)
+	}
+	content := strings.TrimSpace(payload.Content)
+	if content == "" {
+		return "", errors.New("report section is empty")
+	}
+	return content, nil
+}
+
+func (s *AIGenerationService) assembleSectionedReport(
+	ctx context.Context,
+	input SectionedReportInput,
+	locale string,
+	tone string,
+	sections []ReportSection,
+	modelID string,
+	usage ai.TokenUsage,
+	generated bool,
+) (JobGenerationOutput, error) {
+	modelID = firstNonEmpty(modelID, "resumed")
+	body, err := json.Marshal(map[string]any{
+		"title":          sectionedReportTitle,
+		"sections":       sections,
+		"prompt_version": sectionedReportPromptVersion,
+		"model_id":       modelID,
+	})
+	if err != nil {
+		return JobGenerationOutput{}, fmt.Errorf("encode report: %w", err)
+	}
+
+	validatedBody, ruleScore, validationErr := s.validator.ValidateTaskPayload(input.TenantID, ai.TaskReport, body, locale, tone)
+	if validationErr != nil {
+		s.warn(ctx, "validate sectioned report failed, using fallback", slog.Any("error", validationErr))
+		s.metrics.Rejected(string(ai.TaskReport), sectionedReportPromptVersion)
+		fallback := s.degradedSectionedReport(input, nil)
+		fallback.Quality = &JobQuality{Score: payloadQualityScore(fallback.Body, 0), Rejected: true}
+		return fallback, nil
+	}
+	jobQuality := &JobQuality{Score: payloadQualityScore(validatedBody, ruleScore), Corrected: ruleScore < 1}
+	if generated {
+		s.metrics.Accepted(string(ai.TaskReport), sectionedReportPromptVersion, jobQuality.Score, jobQuality.Corrected)
+	}
+
+	output := JobGenerationOutput{
+		Body:          validatedBody,
+		ModelID:       modelID,
+		PromptVersion: sectionedReportPromptVersion,
+		Usage:         usage,
+		Quality:       jobQuality,
+	}
+	output.CostUSD, output.Priced = s.prices.Cost(modelID, usage)
+	return output, nil
+}
+
+// degradedSectionedReport keeps the sections done and fills the missing headings with a
+// placeholder.
+func (s *AIGenerationService) degradedSectionedReport(input SectionedReportInput, done []ReportSection) JobGenerationOutput {
+	const fallbackModelID = "fallback-local"
+
+	sections := append([]ReportSection(nil), done...)
+	for _, heading := range input.Headings[len(sections):] {
+		sections = append(sections, ReportSection{
+			Heading: heading,
+			Content: "Secao gerada em modo degradado devido a indisponibilidade temporaria do modelo.",
+		})
+	}
+	payload, err := json.Marshal(map[string]any{
+		"title":          "Relatorio (modo degradado)",
+		"sections":       sections,
+		"prompt_version": sectionedReportPromptVersion,
+		"model_id":       fallbackModelID,
+		"quality_score":  0.55,
+	})
+	if err != nil {
+		payload = json.RawMessage(`{"model_id":"fallback-local","quality_score":0.55}`)
+	}
+	return JobGenerationOutput{
+		Body:          payload,
+		ModelID:       fallbackModelID,
+		PromptVersion: sectionedReportPromptVersion,
+		UsedFallback:  true,
+	}
+}
@@ -6,11 +6,21 @@ import (
 	"strings"
 )
 
+// Suggestion modes: full replies (default) or ultra-short replies for one-tap
+// acknowledgements.
+const (
+	SuggestionModeStandard = "standard"
+	SuggestionModeQuick    = "quick"
+
+	QuickReplyMaxChars = 80
+)
+
 type SuggestionsInput struct {
 	TenantID       string
 	ConversationID string
 	Locale         string
 	Tone           string
+	Mode           string
 	ContextWindow  int
 	Payload        json.RawMessage
 }
@@ -45,29 +55,54 @@ func (s *SuggestionsService) Generate(
 	}
 
 	locale := strings.ToLower(input.Locale)
-	isPortuguese := strings.HasPrefix(locale, "pt") || locale == ""
+	if locale == "" {
+		locale = "pt"
+	}
 	tone := strings.ToLower(strings.TrimSpace(input.Tone))
 	if tone == "" {
 		tone = "neutro"
 	}
-
-	if isPortuguese {
-		return SuggestionsOutput{
-			ModelID:       "fallback-local",
-			PromptVersion: "reply_v1",
-			Suggestions:   buildPTSuggestions(tone),
-			QualityScore:  0.55,
-		}, nil
-	}
+	mode := normalizeSuggestionMode(input.Mode)
 
 	return SuggestionsOutput{
 		ModelID:       "fallback-local",
-		PromptVersion: "reply_v1",
-		Suggestions:   buildENSuggestions(tone),
+		PromptVersion: suggestionPromptVersion(mode),
+		Suggestions:   fallbackCandidates(locale, tone, mode),
 		QualityScore:  0.55,
 	}, nil
 }
 
+func normalizeSuggestionMode(mode string) string {
+	if strings.ToLower(strings.TrimSpace(mode)) == SuggestionModeQuick {
+		return SuggestionModeQuick
+	}
+	return SuggestionModeStandard
+}
+
+// suggestionPromptVersion names the prompt template (without .tmpl) used by mode.
+func suggestionPromptVersion(mode string) string {
+	if mode == SuggestionModeQuick {
+		return "quick_reply_v1"
+	}
+	return "reply_v1"
+}
+
+// fallbackCandidates returns the canned replies used when the model is unavailable or
+// returns fewer than 3 candidates.
+func fallbackCandidates(locale, tone, mode string) []SuggestionCandidate {
+	portuguese := strings.HasPrefix(strings.ToLower(locale), "pt")
+	switch {
+	case mode == SuggestionModeQuick && portuguese:
+		return buildPTQuickSuggestions(tone)
+	case mode == SuggestionModeQuick:
+		return buildENQuickSuggestions(tone)
+	case portuguese:
+		return buildPTSuggestions(tone)
+	default:
+		return buildENSuggestions(tone)
+	}
+}
+
 func buildPTSuggestions(tone string) []SuggestionCandidate {
 	switch tone {
 	case "formal":
@@ -113,3 +148,49 @@ func buildENSuggestions(tone string) []SuggestionCandidate {
 		}
 	}
 }
+
+func buildPTQuickSuggestions(tone string) []SuggestionCandidate {
+	switch tone {
+	case "formal":
+		return []SuggestionCandidate{
+			{Rank: 1, Content: "Recebido, obrigado.", Rationale: "Confirmacao formal."},
+			{Rank: 2, Content: "Perfeito, vou verificar.", Rationale: "Formal com proximo passo."},
+			{Rank: 3, Content: "Retorno em breve.", Rationale: "Compromisso de retorno."},
+		}
+	case "amigavel":
+		return []SuggestionCandidate{
+			{Rank: 1, Content: "Valeu, recebi!", Rationale: "Confirmacao leve."},
+			{Rank: 2, Content: "Deixa comigo!", Rationale: "Tom colaborativo."},
+			{Rank: 3, Content: "Ja te respondo!", Rationale: "Amigavel com retorno."},
+		}
+	default:
+		return []SuggestionCandidate{
+			{Rank: 1, Content: "Recebi, obrigado.", Rationale: "Confirmacao neutra."},
+			{Rank: 2, Content: "Vou verificar e te aviso.", Rationale: "Neutro com proximo passo."},
+			{Rank: 3, Content: "Certo, um momento.", Rationale: "Pedido de espera curto."},
+		}
+	}
+}
+
+func buildENQuickSuggestions(tone string) []SuggestionCandidate {
+	switch tone {
+	case "formal":
+		return []SuggestionCandidate{
+			{Rank: 1, Content: "Received, thank you.", Rationale: "Formal acknowledgement."},
+			{Rank: 2, Content: "Noted, I will check.", Rationale: "Formal with next step."},
+			{Rank: 3, Content: "I will follow up shortly.", Rationale: "Follow-up commitment."},
+		}
+	case "amigavel":
+		return []SuggestionCandidate{
+			{Rank: 1, Content: "Got it, thanks!", Rationale: "Light acknowledgement."},
+			{Rank: 2, Content: "On it!", Rationale: "Collaborative tone."},
+			{Rank: 3, Content: "Back to you soon!", Rationale: "Friendly follow-up."},
+		}
+	default:
+		return []SuggestionCandidate{
+			{Rank: 1, Content: "Received, thanks.", Rationale: "Neutral acknowledgement."},
+			{Rank: 2, Content: "I will check and let you know.", Rationale: "Neutral with next step."},
+			{Rank: 3, Content: "Sure, one moment.", Rationale: "Short hold request."},
+		}
+	}
+}
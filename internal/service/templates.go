@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+const defaultTemplateLocale = "pt-BR"
+
+var templatePlaceholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+
+type SaveTemplateInput struct {
+	TenantID string
+	Name     string
+	Content  string
+	Locale   string
+}
+
+// TemplatesService manages tenant reply templates.
+type TemplatesService struct {
+	repo repository.TemplatesRepository
+}
+
+func NewTemplatesService(repo repository.TemplatesRepository) *TemplatesService {
+	return &TemplatesService{repo: repo}
+}
+
+func (s *TemplatesService) Create(ctx context.Context, input SaveTemplateInput) (*domain.ReplyTemplate, error) {
+	template, err := newTemplateFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	template.ID = uuid.NewString()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	if err := s.repo.CreateTemplate(ctx, template); err != nil {
+		return nil, fmt.Errorf("create template: %w", err)
+	}
+	return template, nil
+}
+
+// Update replaces name, content and locale of an existing template of the same tenant.
+func (s *TemplatesService) Update(
+	ctx context.Context,
+	templateID string,
+	input SaveTemplateInput,
+) (*domain.ReplyTemplate, error) {
+	template, err := newTemplateFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	template.ID = strings.TrimSpace(templateID)
+	template.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.UpdateTemplate(ctx, template); err != nil {
+		return nil, fmt.Errorf("update template: %w", err)
+	}
+	return template, nil
+}
+
+func (s *TemplatesService) Get(ctx context.Context, templateID string) (*domain.ReplyTemplate, error) {
+	return s.repo.GetTemplate(ctx, strings.TrimSpace(templateID))
+}
+
+func (s *TemplatesService) List(
+	ctx context.Context,
+	filter domain.TemplateListFilter,
+) ([]domain.ReplyTemplate, int, error) {
+	return s.repo.ListTemplates(ctx, filter)
+}
+
+func (s *TemplatesService) Delete(ctx context.Context, templateID string) error {
+	return s.repo.DeleteTemplate(ctx, strings.TrimSpace(templateID))
+}
+
+func newTemplateFromInput(input SaveTemplateInput) (*domain.ReplyTemplate, error) {
+	tenantID := strings.TrimSpace(input.TenantID)
+	name := strings.TrimSpace(input.Name)
+	content := strings.TrimSpace(input.Content)
+	if tenantID == "" || name == "" || content == "" {
+		return nil, errors.New("tenant_id, name and content are required")
+	}
+	locale := strings.TrimSpace(input.Locale)
+	if locale == "" {
+		locale = defaultTemplateLocale
+	}
+	return &domain.ReplyTemplate{
+		TenantID:     tenantID,
+		Name:         name,
+		Content:      content,
+		Locale:       locale,
+		Placeholders: ExtractTemplatePlaceholders(content),
+	}, nil
+}
+
+// ExtractTemplatePlaceholders returns the {{name}} variables of content, unique and in
+// order of first appearance.
+func ExtractTemplatePlaceholders(content string) []string {
+	matches := templatePlaceholderPattern.FindAllStringSubmatch(content, -1)
+	placeholders := make([]string, 0, len(matches))
+	seen := make(map[string]struct{}, len(matches))
+	for _, match := range matches {
+		name := match[1]
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		placeholders = append(placeholders, name)
+	}
+	return placeholders
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+const defaultTenantSettingsTTL = time.Minute
+
+type SaveTenantSettingsInput struct {
+	TenantID      string
+	Locale        string
+	Tone          string
+	Models        map[string]string
+	Quotas        domain.TenantQuotas
+	PolicyProfile string
+}
+
+// TenantSettingsService serves tenant settings through a read-through cache, so the hot
+// paths (generation, enqueue) do not query storage on every call. Saves and deletes
+// through the service update the cache at once; other replicas see them within the TTL.
+type TenantSettingsService struct {
+	repo repository.TenantSettingsRepository
+	ttl  time.Duration
+
+	mu     sync.Mutex
+	cached map[string]cachedTenantSettings
+}
+
+type cachedTenantSettings struct {
+	settings  domain.TenantSettings
+	expiresAt time.Time
+}
+
+func NewTenantSettingsService(repo repository.TenantSettingsRepository, ttl time.Duration) *TenantSettingsService {
+	if ttl <= 0 {
+		ttl = defaultTenantSettingsTTL
+	}
+	return &TenantSettingsService{
+		repo:   repo,
+		ttl:    ttl,
+		cached: make(map[string]cachedTenantSettings),
+	}
+}
+
+// Preload caches every stored record and assigns the policy profiles, so policy checks
+// use them before a tenant's first request.
+func (s *TenantSettingsService) Preload(ctx context.Context) (int, error) {
+	items, err := s.repo.ListTenantSettings(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list tenant settings: %w", err)
+	}
+	for _, settings := range items {
+		s.store(settings)
+	}
+	return len(items), nil
+}
+
+// Resolve returns the settings of tenantID, or empty settings (the process-wide
+// defaults) when it has none. Storage errors also resolve to the defaults and are not
+// cached, so the next call tries again.
+func (s *TenantSettingsService) Resolve(ctx context.Context, tenantID string) domain.TenantSettings {
+	tenantID = strings.TrimSpace(tenantID)
+	if s == nil || tenantID == "" {
+		return domain.TenantSettings{TenantID: tenantID}
+	}
+
+	s.mu.Lock()
+	entry, ok := s.cached[tenantID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.settings
+	}
+
+	settings, err := s.repo.GetTenantSettings(ctx, tenantID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		s.store(domain.TenantSettings{TenantID: tenantID})
+		return domain.TenantSettings{TenantID: tenantID}
+	case err != nil:
+		if ok {
+			return entry.settings
+		}
+		return domain.TenantSettings{TenantID: tenantID}
+	}
+	s.store(*settings)
+	return *settings
+}
+
+// Get reads the stored settings of tenantID, bypassing the cache.
+func (s *TenantSettingsService) Get(ctx context.Context, tenantID string) (*domain.TenantSettings, error) {
+	return s.repo.GetTenantSettings(ctx, strings.TrimSpace(tenantID))
+}
+
+func (s *TenantSettingsService) List(ctx context.Context) ([]domain.TenantSettings, error) {
+	return s.repo.ListTenantSettings(ctx)
+}
+
+// Save replaces the settings of input.TenantID.
+func (s *TenantSettingsService) Save(ctx context.Context, input SaveTenantSettingsInput) (*domain.TenantSettings, error) {
+	tenantID := strings.TrimSpace(input.TenantID)
+	if tenantID == "" {
+		return nil, errors.New("tenant_id is required")
+	}
+	models := make(map[string]string, len(input.Models))
+	for task, model := range input.Models {
+		if task, model = strings.TrimSpace(task), strings.TrimSpace(model); task != "" && model != "" {
+			models[task] = model
+		}
+	}
+	settings := &domain.TenantSettings{
+		TenantID:      tenantID,
+		Locale:        strings.TrimSpace(input.Locale),
+		Tone:          strings.ToLower(strings.TrimSpace(input.Tone)),
+		Models:        models,
+		Quotas:        input.Quotas,
+		PolicyProfile: strings.TrimSpace(input.PolicyProfile),
+		UpdatedAt:     time.Now().UTC(),
+	}
+	if err := s.repo.SaveTenantSettings(ctx, settings); err != nil {
+		return nil, fmt.Errorf("save tenant settings: %w", err)
+	}
+	s.store(*settings)
+	return settings, nil
+}
+
+// Delete drops the settings of tenantID, which goes back to the defaults.
+func (s *TenantSettingsService) Delete(ctx context.Context, tenantID string) error {
+	tenantID = strings.TrimSpace(tenantID)
+	if err := s.repo.DeleteTenantSettings(ctx, tenantID); err != nil {
+		return err
+	}
+	s.store(domain.TenantSettings{TenantID: tenantID})
+	return nil
+}
+
+func (s *TenantSettingsService) store(settings domain.TenantSettings) {
+	policy.SetTenantProfile(settings.TenantID, settings.PolicyProfile)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cached[settings.TenantID] = cachedTenantSettings{settings: settings, expiresAt: time.Now().Add(s.ttl)}
+}
@@ -0,0 +1,36 @@
+package slo
+
+import "github.com/iago/extensao-whatsapp-back/internal/metrics"
+
+// RegisterMetrics publishes the objectives of tracker, computed at scrape time:
+// slo_burn_rate per short window, for multiwindow burn-rate alerts, the remaining error
+// budget, the compliance ratio and the estimated p95. A nil registry registers nothing.
+func RegisterMetrics(registry *metrics.Registry, tracker *Tracker) {
+	if registry == nil || tracker == nil {
+		return
+	}
+	registry.GaugeVecFunc("slo_burn_rate", "Error budget burn rate by objective and window; 1 spends the budget exactly over the SLO window.", []string{"slo", "window"}, func(emit func(float64, ...string)) {
+		for _, status := range tracker.Report() {
+			for _, window := range burnWindows {
+				if rate, ok := status.BurnRates[window.label]; ok {
+					emit(rate, status.Name, window.label)
+				}
+			}
+		}
+	})
+	registry.GaugeVecFunc("slo_error_budget_remaining", "Unspent fraction of the error budget over the SLO window.", []string{"slo"}, func(emit func(float64, ...string)) {
+		for _, status := range tracker.Report() {
+			emit(status.ErrorBudget.Remaining, status.Name)
+		}
+	})
+	registry.GaugeVecFunc("slo_compliance_ratio", "Fraction of requests within the objective over the SLO window.", []string{"slo"}, func(emit func(float64, ...string)) {
+		for _, status := range tracker.Report() {
+			emit(status.Compliance, status.Name)
+		}
+	})
+	registry.GaugeVecFunc("slo_latency_p95_seconds", "Estimated p95 latency over the SLO window.", []string{"slo"}, func(emit func(float64, ...string)) {
+		for _, status := range tracker.Report() {
+			emit(status.P95MS/1000, status.Name)
+		}
+	})
+}
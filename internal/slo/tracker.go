@@ -0,0 +1,271 @@
+// Package slo tracks the latency objectives of the API in process, over a rolling
+// window of one-minute buckets, with their error budgets and burn rates.
+package slo
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultWindow is the rolling window of the error budget.
+const DefaultWindow = 24 * time.Hour
+
+// latencyBounds are the upper bounds, in seconds, of the histogram p95 is estimated from.
+var latencyBounds = []float64{0.05, 0.1, 0.25, 0.5, 0.75, 1, 1.5, 2, 2.5, 3, 4, 5, 7.5, 10, 15, 30}
+
+// burnWindows are the short windows burn rates are reported for, as in multiwindow
+// burn-rate alerts.
+var burnWindows = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+	{"6h", 6 * time.Hour},
+}
+
+// Objective is a latency target: at least Target of the requests to Routes answer
+// within Threshold without a 5xx.
+type Objective struct {
+	Name        string
+	Description string
+	Method      string
+	Routes      []string
+	Threshold   time.Duration
+	Target      float64
+}
+
+// DefaultObjectives are the QT-001 and QT-002 quality targets.
+func DefaultObjectives() []Objective {
+	return []Objective{
+		{
+			Name:        "QT-001",
+			Description: "summary endpoint p95 <= 5s",
+			Method:      "POST",
+			Routes:      []string{"/v1/summaries"},
+			Threshold:   5 * time.Second,
+			Target:      0.95,
+		},
+		{
+			Name:        "QT-002",
+			Description: "suggestion endpoint p95 <= 2s",
+			Method:      "POST",
+			Routes:      []string{"/v1/suggestions", "/v2/suggestions"},
+			Threshold:   2 * time.Second,
+			Target:      0.95,
+		},
+	}
+}
+
+// Tracker keeps one ring of minute buckets per objective.
+type Tracker struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	objectives []*objectiveState
+}
+
+type objectiveState struct {
+	Objective
+	buckets []bucket
+}
+
+type bucket struct {
+	minute  int64
+	total   uint64
+	good    uint64
+	latency []uint64 // one count per latencyBounds entry, plus one above the last
+}
+
+func NewTracker(objectives []Objective, window time.Duration) *Tracker {
+	if window < time.Minute {
+		window = DefaultWindow
+	}
+	tracker := &Tracker{window: window, now: time.Now}
+	size := int(window / time.Minute)
+	for _, objective := range objectives {
+		tracker.objectives = append(tracker.objectives, &objectiveState{
+			Objective: objective,
+			buckets:   make([]bucket, size),
+		})
+	}
+	return tracker
+}
+
+// Observe records a request served by route, the mux pattern, for the objectives that
+// cover it. A nil tracker records nothing.
+func (t *Tracker) Observe(method, route string, status int, elapsed time.Duration) {
+	if t == nil {
+		return
+	}
+	minute := t.now().Unix() / 60
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, state := range t.objectives {
+		if !state.covers(method, route) {
+			continue
+		}
+		slot := &state.buckets[minute%int64(len(state.buckets))]
+		if slot.minute != minute {
+			*slot = bucket{minute: minute, latency: make([]uint64, len(latencyBounds)+1)}
+		}
+		slot.total++
+		if status < 500 && elapsed <= state.Threshold {
+			slot.good++
+		}
+		slot.latency[sort.SearchFloat64s(latencyBounds, elapsed.Seconds())]++
+	}
+}
+
+func (s *objectiveState) covers(method, route string) bool {
+	if s.Method != "" && s.Method != method {
+		return false
+	}
+	for _, candidate := range s.Routes {
+		if candidate == route {
+			return true
+		}
+	}
+	return false
+}
+
+// Status is the state of one objective over the window.
+type Status struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Method      string   `json:"method"`
+	Routes      []string `json:"routes"`
+	ThresholdMS int64    `json:"threshold_ms"`
+	Target      float64  `json:"target"`
+	Window      string   `json:"window"`
+	Total       uint64   `json:"total"`
+	Good        uint64   `json:"good"`
+	Bad         uint64   `json:"bad"`
+	// Compliance is Good/Total; 1 without traffic.
+	Compliance float64 `json:"compliance"`
+	// P95MS is estimated from a histogram, so it is the upper bound of a bucket at worst;
+	// 0 without traffic.
+	P95MS       float64     `json:"p95_ms"`
+	Met         bool        `json:"met"`
+	ErrorBudget ErrorBudget `json:"error_budget"`
+	// BurnRates is how fast the budget is being spent over each short window: 1 spends
+	// it exactly over the whole window, 0 without traffic.
+	BurnRates map[string]float64 `json:"burn_rates"`
+}
+
+// ErrorBudget counts the requests allowed to miss the objective over the window.
+type ErrorBudget struct {
+	Allowed float64 `json:"allowed"`
+	Spent   uint64  `json:"spent"`
+	// Remaining is the unspent fraction, negative once the budget is exhausted.
+	Remaining float64 `json:"remaining"`
+}
+
+// Report returns the status of every objective, in the order they were given.
+func (t *Tracker) Report() []Status {
+	if t == nil {
+		return nil
+	}
+	now := t.now().Unix() / 60
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := make([]Status, 0, len(t.objectives))
+	for _, state := range t.objectives {
+		total, good, latency := state.sum(now, len(state.buckets))
+		status := Status{
+			Name:        state.Name,
+			Description: state.Description,
+			Method:      state.Method,
+			Routes:      append([]string(nil), state.Routes...),
+			ThresholdMS: state.Threshold.Milliseconds(),
+			Target:      state.Target,
+			Window:      t.window.String(),
+			Total:       total,
+			Good:        good,
+			Bad:         total - good,
+			Compliance:  1,
+			P95MS:       quantile(latency, total, 0.95) * 1000,
+			BurnRates:   make(map[string]float64, len(burnWindows)),
+		}
+		if total > 0 {
+			status.Compliance = float64(good) / float64(total)
+		}
+		status.Met = status.Compliance >= state.Target
+		status.ErrorBudget = ErrorBudget{
+			Allowed:   float64(total) * (1 - state.Target),
+			Spent:     status.Bad,
+			Remaining: 1,
+		}
+		if status.ErrorBudget.Allowed > 0 {
+			status.ErrorBudget.Remaining = 1 - float64(status.Bad)/status.ErrorBudget.Allowed
+		} else if status.Bad > 0 {
+			status.ErrorBudget.Remaining = 0
+		}
+		for _, window := range burnWindows {
+			minutes := int(window.duration / time.Minute)
+			if minutes > len(state.buckets) {
+				continue
+			}
+			status.BurnRates[window.label] = state.burnRate(now, minutes)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// sum adds up the buckets of the last minutes, the current one included.
+func (s *objectiveState) sum(now int64, minutes int) (total, good uint64, latency []uint64) {
+	latency = make([]uint64, len(latencyBounds)+1)
+	for _, slot := range s.buckets {
+		if slot.total == 0 || slot.minute > now || slot.minute <= now-int64(minutes) {
+			continue
+		}
+		total += slot.total
+		good += slot.good
+		for index, count := range slot.latency {
+			latency[index] += count
+		}
+	}
+	return total, good, latency
+}
+
+func (s *objectiveState) burnRate(now int64, minutes int) float64 {
+	total, good, _ := s.sum(now, minutes)
+	if total == 0 || s.Target >= 1 {
+		return 0
+	}
+	return (float64(total-good) / float64(total)) / (1 - s.Target)
+}
+
+// quantile interpolates q within the histogram bucket holding it, like
+// histogram_quantile; above the last bound it returns that bound.
+func quantile(counts []uint64, total uint64, q float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var cumulative uint64
+	for index, count := range counts {
+		if float64(cumulative+count) < rank {
+			cumulative += count
+			continue
+		}
+		if index == len(latencyBounds) {
+			return latencyBounds[len(latencyBounds)-1]
+		}
+		lower := 0.0
+		if index > 0 {
+			lower = latencyBounds[index-1]
+		}
+		upper := latencyBounds[index]
+		if count == 0 {
+			return upper
+		}
+		return lower + (upper-lower)*(rank-float64(cumulative))/float64(count)
+	}
+	return latencyBounds[len(latencyBounds)-1]
+}
@@ -0,0 +1,71 @@
+package slo
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTrackerComputesBudgetAndBurnRates(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker(DefaultObjectives(), 24*time.Hour)
+	tracker.now = func() time.Time { return now }
+
+	// Two hours ago: 100 fast suggestions.
+	now = now.Add(-2 * time.Hour)
+	for i := 0; i < 100; i++ {
+		tracker.Observe("POST", "/v1/suggestions", 200, 300*time.Millisecond)
+	}
+	// Now: 10 suggestions, 4 slow and 1 failing; other routes and methods are ignored.
+	now = now.Add(2 * time.Hour)
+	for i := 0; i < 5; i++ {
+		tracker.Observe("POST", "/v2/suggestions", 200, 500*time.Millisecond)
+	}
+	for i := 0; i < 4; i++ {
+		tracker.Observe("POST", "/v1/suggestions", 200, 3*time.Second)
+	}
+	tracker.Observe("POST", "/v1/suggestions", 502, 100*time.Millisecond)
+	tracker.Observe("GET", "/v1/suggestions", 200, time.Minute)
+	tracker.Observe("POST", "/v1/reports", 200, time.Minute)
+
+	statuses := tracker.Report()
+	if len(statuses) != 2 || statuses[0].Name != "QT-001" || statuses[1].Name != "QT-002" {
+		t.Fatalf("expected both objectives, got %+v", statuses)
+	}
+	summary := statuses[0]
+	if summary.Total != 0 || summary.Compliance != 1 || !summary.Met || summary.ErrorBudget.Remaining != 1 || summary.BurnRates["5m"] != 0 {
+		t.Fatalf("expected an untouched summary objective, got %+v", summary)
+	}
+
+	suggestion := statuses[1]
+	if suggestion.Total != 110 || suggestion.Good != 105 || suggestion.Bad != 5 {
+		t.Fatalf("expected 105 of 110 good suggestions, got %+v", suggestion)
+	}
+	if !suggestion.Met || math.Abs(suggestion.ErrorBudget.Allowed-5.5) > 1e-9 {
+		t.Fatalf("expected the objective met with 5.5 allowed misses, got %+v", suggestion)
+	}
+	if math.Abs(suggestion.ErrorBudget.Remaining-(1-5/5.5)) > 1e-9 {
+		t.Fatalf("unexpected remaining budget %v", suggestion.ErrorBudget.Remaining)
+	}
+	// Half of the last 10 requests missed: 0.5 / 0.05 = 10x the sustainable rate.
+	if math.Abs(suggestion.BurnRates["5m"]-10) > 1e-9 || math.Abs(suggestion.BurnRates["1h"]-10) > 1e-9 {
+		t.Fatalf("expected a 10x burn rate on the short windows, got %+v", suggestion.BurnRates)
+	}
+	if math.Abs(suggestion.BurnRates["6h"]-(5.0/110)/0.05) > 1e-9 {
+		t.Fatalf("expected the 6h window to include the older requests, got %+v", suggestion.BurnRates)
+	}
+	// 105 of 110 requests took at most 500ms.
+	if suggestion.P95MS <= 250 || suggestion.P95MS > 500 {
+		t.Fatalf("expected p95 in the 250-500ms bucket, got %v", suggestion.P95MS)
+	}
+
+	// Buckets older than the window fall out.
+	now = now.Add(23 * time.Hour)
+	if status := tracker.Report()[1]; status.Total != 10 {
+		t.Fatalf("expected only the recent requests in the window, got %+v", status)
+	}
+	now = now.Add(2 * time.Hour)
+	if status := tracker.Report()[1]; status.Total != 0 || status.P95MS != 0 {
+		t.Fatalf("expected an empty window, got %+v", status)
+	}
+}
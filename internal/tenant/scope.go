@@ -0,0 +1,67 @@
+// Package tenant carries the tenant a request is scoped to, so storage layers can
+// enforce isolation independently of how each query is built.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrMismatch is returned when an operation targets a tenant other than the scoped one.
+var ErrMismatch = errors.New("tenant scope mismatch")
+
+type contextKey struct{}
+
+type bypassKey struct{}
+
+// WithScope returns a context restricted to tenantID. Empty ids leave ctx unscoped.
+func WithScope(ctx context.Context, tenantID string) context.Context {
+	tenantID = strings.TrimSpace(tenantID)
+	if tenantID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// FromContext returns the scoped tenant, if any. Background jobs run unscoped.
+func FromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(contextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// WithBypass marks ctx as system work (workers, archiver, scheduler, admin routes) allowed
+// to read every tenant's rows. Storage layers enforcing isolation on their own, like the
+// row-level security of Postgres, deny unscoped contexts that are not marked.
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// Bypassed reports whether ctx was marked by WithBypass and is not scoped to a tenant; a
+// scope always wins over the bypass.
+func Bypassed(ctx context.Context) bool {
+	if _, scoped := FromContext(ctx); scoped {
+		return false
+	}
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// Allows reports whether ctx may access data owned by tenantID.
+func Allows(ctx context.Context, tenantID string) bool {
+	scoped, ok := FromContext(ctx)
+	return !ok || scoped == tenantID
+}
+
+// Resolve applies the scope to an optional tenant filter: an empty filter becomes the
+// scoped tenant and a different one is rejected.
+func Resolve(ctx context.Context, filterTenantID string) (string, error) {
+	scoped, ok := FromContext(ctx)
+	if !ok {
+		return filterTenantID, nil
+	}
+	if filterTenantID == "" || filterTenantID == scoped {
+		return scoped, nil
+	}
+	return "", ErrMismatch
+}
@@ -0,0 +1,246 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultServiceName   = "wa-copilot-api"
+	defaultQueueSize     = 2048
+	defaultBatchSize     = 256
+	defaultFlushInterval = 5 * time.Second
+	exportTimeout        = 10 * time.Second
+	instrumentationScope = "github.com/iago/extensao-whatsapp-back"
+)
+
+type Config struct {
+	// Endpoint is the OTLP/HTTP base URL (e.g. http://otel-collector:4318); spans are
+	// posted to Endpoint + "/v1/traces".
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded, 0..1; zero means 1.
+	SampleRatio   float64
+	FlushInterval time.Duration
+	HTTPClient    *http.Client
+	Logger        *slog.Logger
+}
+
+// Tracer batches finished spans and exports them as OTLP JSON. Spans are dropped when
+// the export queue is full rather than blocking requests.
+type Tracer struct {
+	endpoint    string
+	serviceName string
+	sampleBound uint64
+	interval    time.Duration
+	client      *http.Client
+	logger      *slog.Logger
+
+	queue    chan *Span
+	flush    chan chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+var droppedSpans = expvar.NewInt("tracing_spans_dropped_total")
+
+func NewTracer(cfg Config) *Tracer {
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	serviceName := strings.TrimSpace(cfg.ServiceName)
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: exportTimeout}
+	}
+
+	tracer := &Tracer{
+		endpoint:    strings.TrimSuffix(strings.TrimSpace(cfg.Endpoint), "/") + "/v1/traces",
+		serviceName: serviceName,
+		sampleBound: uint64(ratio * float64(math.MaxInt64)),
+		interval:    interval,
+		client:      client,
+		logger:      cfg.Logger,
+		queue:       make(chan *Span, defaultQueueSize),
+		flush:       make(chan chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go tracer.run()
+	return tracer
+}
+
+// Shutdown exports pending spans and stops the background exporter.
+func (t *Tracer) Shutdown(ctx context.Context) {
+	t.stopOnce.Do(func() {
+		flushed := make(chan struct{})
+		select {
+		case t.flush <- flushed:
+			select {
+			case <-flushed:
+			case <-ctx.Done():
+			}
+		case <-ctx.Done():
+		}
+		close(t.done)
+	})
+}
+
+func (t *Tracer) sample(traceID [16]byte) bool {
+	return traceIDBound(traceID) <= t.sampleBound
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	select {
+	case t.queue <- span:
+	default:
+		droppedSpans.Add(1)
+	}
+}
+
+func (t *Tracer) run() {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, defaultBatchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.export(batch); err != nil && t.logger != nil {
+			t.logger.Warn("trace export failed", slog.Int("spans", len(batch)), slog.Any("error", err))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) >= defaultBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case flushed := <-t.flush:
+			for drained := false; !drained; {
+				select {
+				case span := <-t.queue:
+					batch = append(batch, span)
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(flushed)
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func (t *Tracer) export(spans []*Span) error {
+	encoded, err := json.Marshal(t.otlpPayload(spans))
+	if err != nil {
+		return fmt.Errorf("encode spans: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(encoded))
+	if err != nil {
+		return fmt.Errorf("create export request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := t.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("post spans: %w", err)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("collector answered %d", response.StatusCode)
+	}
+	return nil
+}
+
+// otlpPayload builds an ExportTraceServiceRequest in the OTLP/JSON encoding (hex IDs,
+// 64-bit integers as strings).
+func (t *Tracer) otlpPayload(spans []*Span) map[string]any {
+	encoded := make([]map[string]any, 0, len(spans))
+	for _, span := range spans {
+		span.mu.Lock()
+		item := map[string]any{
+			"traceId":           hex.EncodeToString(span.context.TraceID[:]),
+			"spanId":            hex.EncodeToString(span.context.SpanID[:]),
+			"name":              span.name,
+			"kind":              int(span.kind),
+			"startTimeUnixNano": strconv.FormatInt(span.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.end.UnixNano(), 10),
+			"attributes":        otlpAttributes(span.attributes),
+		}
+		if span.parent != [8]byte{} {
+			item["parentSpanId"] = hex.EncodeToString(span.parent[:])
+		}
+		if span.errMessage != "" {
+			item["status"] = map[string]any{"code": 2, "message": span.errMessage}
+		}
+		span.mu.Unlock()
+		encoded = append(encoded, item)
+	}
+
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": t.serviceName}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": instrumentationScope},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attributes map[string]any) []any {
+	encoded := make([]any, 0, len(attributes))
+	for key, value := range attributes {
+		var otlpValue map[string]any
+		switch typed := value.(type) {
+		case string:
+			otlpValue = map[string]any{"stringValue": typed}
+		case bool:
+			otlpValue = map[string]any{"boolValue": typed}
+		case int:
+			otlpValue = map[string]any{"intValue": strconv.Itoa(typed)}
+		case int64:
+			otlpValue = map[string]any{"intValue": strconv.FormatInt(typed, 10)}
+		case float64:
+			otlpValue = map[string]any{"doubleValue": typed}
+		default:
+			otlpValue = map[string]any{"stringValue": fmt.Sprint(typed)}
+		}
+		encoded = append(encoded, map[string]any{"key": key, "value": otlpValue})
+	}
+	return encoded
+}
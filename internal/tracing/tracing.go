@@ -0,0 +1,237 @@
+// Package tracing records spans compatible with OpenTelemetry: W3C traceparent
+// propagation and OTLP/HTTP (JSON) export, without pulling in the OTel SDK.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SpanKind follows the OTLP enum.
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+	KindProducer SpanKind = 4
+	KindConsumer SpanKind = 5
+)
+
+// SpanContext identifies a span across process boundaries.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent formats the W3C traceparent header value.
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceParent reads a W3C traceparent header value.
+func ParseTraceParent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	var sc SpanContext
+	flags := make([]byte, 1)
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(flags, []byte(parts[3])); err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+// Span is one timed operation. A nil *Span is valid and records nothing, so call sites do
+// not need to check whether tracing is enabled.
+type Span struct {
+	tracer  *Tracer
+	name    string
+	kind    SpanKind
+	context SpanContext
+	parent  [8]byte
+	start   time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes map[string]any
+	errMessage string
+	ended      bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+// SetAttribute records a string, bool, int, int64 or float64 value.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]any)
+	}
+	s.attributes[key] = value
+}
+
+// RecordError marks the span as failed; nil errors are ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMessage = err.Error()
+}
+
+// End finishes the span and hands it to the exporter when sampled. Later calls are no-ops.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	if s.context.Sampled && s.tracer != nil {
+		s.tracer.enqueue(s)
+	}
+}
+
+type spanContextKey struct{}
+type remoteContextKey struct{}
+
+var global atomic.Pointer[Tracer]
+
+// SetTracer installs the process-wide tracer; nil disables tracing.
+func SetTracer(tracer *Tracer) {
+	global.Store(tracer)
+}
+
+// Start opens a span as a child of the span (or remote parent) in ctx. Without a tracer
+// it returns ctx unchanged and a nil span.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	return StartAt(ctx, name, kind, time.Now())
+}
+
+// StartAt is Start for an operation that began at start, such as the time a job waited in
+// the queue before a worker took it.
+func StartAt(ctx context.Context, name string, kind SpanKind, start time.Time) (context.Context, *Span) {
+	tracer := global.Load()
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	parent, hasParent := parentContext(ctx)
+	span := &Span{tracer: tracer, name: name, kind: kind, start: start}
+	if hasParent {
+		span.context.TraceID = parent.TraceID
+		span.context.Sampled = parent.Sampled
+		span.parent = parent.SpanID
+	} else {
+		span.context.TraceID = newTraceID()
+		span.context.Sampled = tracer.sample(span.context.TraceID)
+	}
+	span.context.SpanID = newSpanID()
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// ContextWithRemoteParent makes spans started from ctx children of a span in another
+// process (an incoming traceparent header or queue message).
+func ContextWithRemoteParent(ctx context.Context, parent SpanContext) context.Context {
+	if !parent.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteContextKey{}, parent)
+}
+
+// TraceParent returns the traceparent to propagate from ctx, or "" when there is none.
+func TraceParent(ctx context.Context) string {
+	parent, ok := parentContext(ctx)
+	if !ok {
+		return ""
+	}
+	return parent.TraceParent()
+}
+
+// TraceID returns the hex trace ID in ctx, for correlating logs.
+func TraceID(ctx context.Context) string {
+	parent, ok := parentContext(ctx)
+	if !ok {
+		return ""
+	}
+	return hex.EncodeToString(parent.TraceID[:])
+}
+
+func parentContext(ctx context.Context) (SpanContext, bool) {
+	if span, ok := ctx.Value(spanContextKey{}).(*Span); ok && span != nil {
+		return span.context, true
+	}
+	if remote, ok := ctx.Value(remoteContextKey{}).(SpanContext); ok && remote.IsValid() {
+		return remote, true
+	}
+	return SpanContext{}, false
+}
+
+func newTraceID() [16]byte {
+	var id [16]byte
+	for id == [16]byte{} {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	for id == [8]byte{} {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// traceIDBound maps a trace ID to [0, 2^63) for ratio sampling, so every service
+// sampling the same trace agrees.
+func traceIDBound(id [16]byte) uint64 {
+	return binary.BigEndian.Uint64(id[8:]) >> 1
+}
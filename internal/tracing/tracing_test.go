@@ -0,0 +1,153 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	parsed, ok := ParseTraceParent(header)
+	if !ok || !parsed.Sampled {
+		t.Fatalf("expected sampled traceparent to parse, got %+v ok=%v", parsed, ok)
+	}
+	if got := parsed.TraceParent(); got != header {
+		t.Fatalf("expected %s, got %s", header, got)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok := ParseTraceParent(invalid); ok {
+			t.Fatalf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestStartWithoutTracerIsNoop(t *testing.T) {
+	SetTracer(nil)
+	ctx, span := Start(context.Background(), "noop", KindInternal)
+	if span != nil {
+		t.Fatalf("expected nil span without tracer")
+	}
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("ignored"))
+	span.End()
+	if TraceParent(ctx) != "" {
+		t.Fatalf("expected no traceparent without tracer")
+	}
+}
+
+func TestSpansExportAsOneTrace(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads []map[string]any
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("unexpected export path %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid export payload: %v", err)
+		}
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	tracer := NewTracer(Config{Endpoint: collector.URL, ServiceName: "test-service", FlushInterval: time.Hour})
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	remote, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteParent(context.Background(), remote)
+	ctx, server := Start(ctx, "HTTP POST", KindServer)
+	_, child := Start(ctx, "queue.enqueue", KindProducer)
+	child.SetAttribute("job.attempt", 2)
+	child.RecordError(errors.New("redis down"))
+	child.End()
+	server.End()
+
+	// A worker picks up the propagated traceparent.
+	propagated, ok := ParseTraceParent(TraceParent(ctx))
+	if !ok || propagated.SpanID != server.SpanContext().SpanID {
+		t.Fatalf("expected traceparent to carry the server span, got %+v", propagated)
+	}
+	_, consumer := Start(ContextWithRemoteParent(context.Background(), propagated), "worker.process", KindConsumer)
+	consumer.End()
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracer.Shutdown(flushCtx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 1 {
+		t.Fatalf("expected one export batch, got %d", len(payloads))
+	}
+	resourceSpans := payloads[0]["resourceSpans"].([]any)[0].(map[string]any)
+	resourceAttributes := resourceSpans["resource"].(map[string]any)["attributes"].([]any)
+	serviceName := resourceAttributes[0].(map[string]any)["value"].(map[string]any)["stringValue"]
+	if serviceName != "test-service" {
+		t.Fatalf("expected service.name test-service, got %v", serviceName)
+	}
+
+	spans := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	byName := make(map[string]map[string]any)
+	for _, raw := range spans {
+		span := raw.(map[string]any)
+		if span["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("expected spans in the remote trace, got %v", span["traceId"])
+		}
+		byName[span["name"].(string)] = span
+	}
+	if byName["HTTP POST"]["parentSpanId"] != "00f067aa0ba902b7" {
+		t.Fatalf("expected server span to continue remote parent, got %v", byName["HTTP POST"]["parentSpanId"])
+	}
+	if byName["queue.enqueue"]["parentSpanId"] != byName["HTTP POST"]["spanId"] {
+		t.Fatalf("expected enqueue span under server span")
+	}
+	if byName["worker.process"]["parentSpanId"] != byName["HTTP POST"]["spanId"] {
+		t.Fatalf("expected worker span under server span")
+	}
+	status, _ := byName["queue.enqueue"]["status"].(map[string]any)
+	if status["code"] != float64(2) || status["message"] != "redis down" {
+		t.Fatalf("expected error status on enqueue span, got %v", status)
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	tracer := NewTracer(Config{Endpoint: "http://127.0.0.1:1", SampleRatio: 0})
+	defer tracer.Shutdown(context.Background())
+	for i := 0; i < 32; i++ {
+		if !tracer.sample(newTraceID()) {
+			t.Fatalf("expected every trace sampled at ratio 1")
+		}
+	}
+
+	halved := NewTracer(Config{Endpoint: "http://127.0.0.1:1", SampleRatio: 0.5})
+	defer halved.Shutdown(context.Background())
+	low := [16]byte{8: 0x10}
+	high := [16]byte{8: 0xf0}
+	if !halved.sample(low) || halved.sample(high) {
+		t.Fatalf("expected ratio sampling to split on the trace ID")
+	}
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+)
+
+// ErrNoCheckpoint is returned by SaveCheckpoint outside a job run by the processor.
+var ErrNoCheckpoint = errors.New("no job to checkpoint in context")
+
+type checkpointKey struct{}
+
+type checkpointState struct {
+	repo  repository.JobsRepository
+	jobID string
+	saved atomic.Bool
+}
+
+func withCheckpoint(ctx context.Context, repo repository.JobsRepository, jobID string) (context.Context, *checkpointState) {
+	state := &checkpointState{repo: repo, jobID: jobID}
+	return context.WithValue(ctx, checkpointKey{}, state), state
+}
+
+// SaveCheckpoint stores the partial output of the job handled under ctx. If the attempt
+// fails, the retry receives the latest checkpoint in QueueMessage.Checkpoint; it is
+// cleared once the job is done.
+func SaveCheckpoint(ctx context.Context, value any) error {
+	state, ok := ctx.Value(checkpointKey{}).(*checkpointState)
+	if !ok {
+		return ErrNoCheckpoint
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	if err := state.repo.SaveJobCheckpoint(ctx, state.jobID, encoded); err != nil {
+		return err
+	}
+	state.saved.Store(true)
+	return nil
+}
+
+// clearCheckpoint drops the checkpoint of a job that is done, if it has one.
+func (p *Processor) clearCheckpoint(ctx context.Context, job *domain.Job, state *checkpointState) {
+	if job.Checkpoint == nil && !state.saved.Load() {
+		return
+	}
+	if err := p.repo.SaveJobCheckpoint(ctx, job.ID, nil); err != nil && p.logger != nil {
+		p.logger.WarnContext(ctx, "clear job checkpoint failed", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/queue"
+)
+
+// ConcurrencyConfig bounds how many jobs the processor runs at once. With Min equal to
+// Max the pool has a fixed size; otherwise it is resized every Interval.
+type ConcurrencyConfig struct {
+	Min      int
+	Max      int
+	Interval time.Duration
+	// DepthPerWorker is the backlog per running worker above which the pool grows.
+	DepthPerWorker int64
+	// TargetLatency is the average job duration above which the pool shrinks, easing the
+	// load on a slow AI provider.
+	TargetLatency time.Duration
+}
+
+// Concurrency defaults; a single worker keeps jobs in queue order.
+const (
+	DefaultConcurrencyInterval       = 5 * time.Second
+	DefaultConcurrencyDepthPerWorker = 10
+	DefaultConcurrencyTargetLatency  = 20 * time.Second
+)
+
+// ConcurrencySnapshot is the current pool size and the signals that set it.
+type ConcurrencySnapshot struct {
+	Adaptive   bool      `json:"adaptive"`
+	Current    int       `json:"current"`
+	Min        int       `json:"min"`
+	Max        int       `json:"max"`
+	Busy       int       `json:"busy"`
+	QueueDepth int64     `json:"queue_depth"`
+	LatencyMS  int64     `json:"recent_latency_ms"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// concurrency tracks the pool size and the jobs that finished since the last resize.
+type concurrency struct {
+	config ConcurrencyConfig
+
+	mu       sync.Mutex
+	current  int
+	busy     int
+	finished int
+	elapsed  time.Duration
+	depth    int64
+	latency  time.Duration
+	updated  time.Time
+}
+
+func newConcurrency(config ConcurrencyConfig) *concurrency {
+	if config.Min <= 0 {
+		config.Min = 1
+	}
+	if config.Max < config.Min {
+		config.Max = config.Min
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultConcurrencyInterval
+	}
+	if config.DepthPerWorker <= 0 {
+		config.DepthPerWorker = DefaultConcurrencyDepthPerWorker
+	}
+	if config.TargetLatency <= 0 {
+		config.TargetLatency = DefaultConcurrencyTargetLatency
+	}
+	return &concurrency{config: config, current: config.Min, updated: time.Now().UTC()}
+}
+
+func (c *concurrency) adaptive() bool {
+	return c.config.Max > c.config.Min
+}
+
+func (c *concurrency) started() {
+	c.mu.Lock()
+	c.busy++
+	c.mu.Unlock()
+}
+
+func (c *concurrency) done(elapsed time.Duration) {
+	c.mu.Lock()
+	c.busy--
+	c.finished++
+	c.elapsed += elapsed
+	c.mu.Unlock()
+}
+
+// resize picks the pool size for the next interval: one worker less while jobs run
+// slower than the target latency or nothing is waiting, one more while the backlog
+// exceeds DepthPerWorker for each running worker.
+func (c *concurrency) resize(depth int64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.finished > 0 {
+		c.latency = c.elapsed / time.Duration(c.finished)
+	}
+	c.finished, c.elapsed = 0, 0
+	c.depth = depth
+
+	next := c.current
+	switch {
+	case c.latency > c.config.TargetLatency:
+		next--
+	case depth > c.config.DepthPerWorker*int64(c.current):
+		next++
+	case depth == 0 && c.busy < c.current:
+		next--
+	}
+	next = max(c.config.Min, min(c.config.Max, next))
+	if next != c.current {
+		c.current = next
+		c.updated = time.Now().UTC()
+	}
+	return c.current
+}
+
+func (c *concurrency) snapshot() ConcurrencySnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ConcurrencySnapshot{
+		Adaptive:   c.adaptive(),
+		Current:    c.current,
+		Min:        c.config.Min,
+		Max:        c.config.Max,
+		Busy:       c.busy,
+		QueueDepth: c.depth,
+		LatencyMS:  c.latency.Milliseconds(),
+		UpdatedAt:  c.updated,
+	}
+}
+
+// UseConcurrency sets the worker pool bounds; call it before Start.
+func (p *Processor) UseConcurrency(config ConcurrencyConfig) {
+	p.concurrency = newConcurrency(config)
+}
+
+// Concurrency reports the current pool size.
+func (p *Processor) Concurrency() ConcurrencySnapshot {
+	return p.concurrency.snapshot()
+}
+
+// runPool keeps as many consume loops running as the pool size, resizing it every
+// interval when the bounds allow. A loop taken out of the pool stops fetching; the job
+// it is running finishes normally.
+func (p *Processor) runPool(ctx context.Context) {
+	var (
+		wg    sync.WaitGroup
+		loops []context.CancelFunc
+	)
+	scale := func(size int) {
+		for len(loops) < size {
+			loopCtx, cancel := context.WithCancel(ctx)
+			loops = append(loops, cancel)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.consumeLoop(loopCtx)
+			}()
+		}
+		for len(loops) > size {
+			last := len(loops) - 1
+			loops[last]()
+			loops = loops[:last]
+		}
+		p.metrics.concurrency(size)
+	}
+	defer wg.Wait()
+	scale(p.concurrency.snapshot().Current)
+
+	if !p.concurrency.adaptive() {
+		<-ctx.Done()
+		return
+	}
+	depth, _ := p.consumer.(queue.DepthReporter)
+	ticker := time.NewTicker(p.concurrency.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var backlog int64
+		if depth != nil {
+			var err error
+			if backlog, err = depth.Depth(ctx); err != nil && p.logger != nil {
+				p.logger.WarnContext(ctx, "read queue depth failed", slog.Any("error", err))
+			}
+		}
+		previous := len(loops)
+		if size := p.concurrency.resize(backlog); size != previous {
+			scale(size)
+			if p.logger != nil {
+				p.logger.InfoContext(ctx, "worker concurrency changed",
+					slog.Int("from", previous),
+					slog.Int("to", size),
+					slog.Int64("queue_depth", backlog),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+)
+
+// registerBuiltinHandlers wires the job kinds the API enqueues. Each one tries the AI
+// generation service and falls back to a static result; sectioned reports fail instead,
+// so the retry resumes from the sections saved.
+func (p *Processor) registerBuiltinHandlers() {
+	p.RegisterHandler(domain.JobKindSummary, p.handleSummary)
+	p.RegisterHandler(domain.JobKindReport, p.handleReport)
+	p.RegisterHandler(domain.JobKindDigest, p.handleDigest)
+}
+
+// generationInput leaves locale and tone to the tenant settings, then pt-BR and neutro.
+func generationInput(message domain.QueueMessage) service.JobGenerationInput {
+	return service.JobGenerationInput{
+		TenantID:       message.TenantID,
+		ConversationID: message.ConversationID,
+		Payload:        message.Payload,
+		Upstream:       string(message.Upstream),
+	}
+}
+
+func (p *Processor) warnFallback(ctx context.Context, kind domain.JobKind, err error) {
+	if p.logger != nil {
+		p.logger.WarnContext(ctx, fmt.Sprintf("ai %s generation failed, fallback to static result", kind), slog.Any("error", err))
+	}
+}
+
+func (p *Processor) handleSummary(ctx context.Context, message domain.QueueMessage) (service.JobGenerationOutput, error) {
+	if p.ai != nil {
+		output, err := p.ai.GenerateSummary(ctx, generationInput(message))
+		if err == nil {
+			return output, nil
+		}
+		p.warnFallback(ctx, domain.JobKindSummary, err)
+	}
+
+	result := map[string]any{
+		"summary":        "Resumo gerado automaticamente para a conversa atual.",
+		"action_items":   []string{"Confirmar pendencias em aberto", "Responder contato com proximo passo"},
+		"prompt_version": "summary_v1",
+		"model_id":       "summary-fast-v1",
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return service.JobGenerationOutput{}, fmt.Errorf("encode summary result: %w", err)
+	}
+	return service.JobGenerationOutput{Body: encoded, ModelID: "summary-fast-v1", UsedFallback: true}, nil
+}
+
+func (p *Processor) handleReport(ctx context.Context, message domain.QueueMessage) (service.JobGenerationOutput, error) {
+	if headings := reportHeadings(message.Payload); len(headings) > 0 && p.ai != nil {
+		return p.ai.GenerateSectionedReport(ctx, sectionedReportInput(message, headings))
+	}
+	if p.ai != nil {
+		output, err := p.ai.GenerateReport(ctx, generationInput(message))
+		if err == nil {
+			return output, nil
+		}
+		p.warnFallback(ctx, domain.JobKindReport, err)
+	}
+
+	result := map[string]any{
+		"title": "Relatorio da conversa",
+		"sections": []map[string]string{
+			{"heading": "Visao geral", "content": "Conversa processada com sucesso e principais pontos consolidados."},
+			{"heading": "Pendencias", "content": "Nenhuma pendencia critica identificada no processamento inicial."},
+		},
+		"prompt_version": "report_v1",
+		"model_id":       "report-fast-v1",
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return service.JobGenerationOutput{}, fmt.Errorf("encode report result: %w", err)
+	}
+	return service.JobGenerationOutput{Body: encoded, ModelID: "report-fast-v1", UsedFallback: true}, nil
+}
+
+// reportCheckpoint is what a sectioned report saves after each section.
+type reportCheckpoint struct {
+	Sections []service.ReportSection `json:"sections"`
+}
+
+func reportHeadings(payload json.RawMessage) []string {
+	var request struct {
+		Sections []string `json:"sections"`
+	}
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil
+	}
+	return request.Sections
+}
+
+// sectionedReportInput resumes from the sections saved by an earlier attempt and saves
+// each new one, so a failed attempt does not lose them.
+func sectionedReportInput(message domain.QueueMessage, headings []string) service.SectionedReportInput {
+	var saved reportCheckpoint
+	if len(message.Checkpoint) > 0 {
+		_ = json.Unmarshal(message.Checkpoint, &saved)
+	}
+	return service.SectionedReportInput{
+		JobGenerationInput: generationInput(message),
+		Headings:           headings,
+		Done:               saved.Sections,
+		Save: func(ctx context.Context, sections []service.ReportSection) error {
+			return SaveCheckpoint(ctx, reportCheckpoint{Sections: sections})
+		},
+	}
+}
+
+func (p *Processor) handleDigest(ctx context.Context, message domain.QueueMessage) (service.JobGenerationOutput, error) {
+	if p.ai != nil {
+		input := generationInput(message)
+		input.Locale = digestLocale(message.Payload)
+		output, err := p.ai.GenerateDigest(ctx, input)
+		if err == nil {
+			return withDigestContext(output, message.Payload), nil
+		}
+		p.warnFallback(ctx, domain.JobKindDigest, err)
+	}
+
+	result := map[string]any{
+		"title":          "Digest do periodo",
+		"open_items":     []map[string]string{},
+		"highlights":     []string{"Digest gerado sem IA; revise as conversas aguardando resposta."},
+		"prompt_version": "digest_v1",
+		"model_id":       "digest-fast-v1",
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return service.JobGenerationOutput{}, fmt.Errorf("encode digest result: %w", err)
+	}
+	output := service.JobGenerationOutput{Body: encoded, ModelID: "digest-fast-v1", UsedFallback: true}
+	return withDigestContext(output, message.Payload), nil
+}
+
+// digestContext holds the deterministic digest facts computed when the job was requested.
+type digestContext struct {
+	From              string          `json:"from"`
+	To                string          `json:"to"`
+	Locale            string          `json:"locale"`
+	ConversationCount int             `json:"conversation_count"`
+	WaitingOnCustomer json.RawMessage `json:"waiting_on_customer"`
+}
+
+func digestLocale(payload json.RawMessage) string {
+	var digest digestContext
+	if err := json.Unmarshal(payload, &digest); err != nil || digest.Locale == "" {
+		return "pt-BR"
+	}
+	return digest.Locale
+}
+
+// withDigestContext copies the period and the conversations waiting on the customer from
+// the job payload into the generated digest.
+func withDigestContext(output service.JobGenerationOutput, payload json.RawMessage) service.JobGenerationOutput {
+	var digest digestContext
+	if err := json.Unmarshal(payload, &digest); err != nil {
+		return output
+	}
+	var body map[string]any
+	if err := json.Unmarshal(output.Body, &body); err != nil || body == nil {
+		return output
+	}
+
+	body["period"] = map[string]string{"from": digest.From, "to": digest.To}
+	body["conversation_count"] = digest.ConversationCount
+	waiting := digest.WaitingOnCustomer
+	if len(waiting) == 0 || string(waiting) == "null" {
+		waiting = json.RawMessage("[]")
+	}
+	body["waiting_on_customer"] = waiting
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return output
+	}
+	output.Body = encoded
+	return output
+}
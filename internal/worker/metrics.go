@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
+	"github.com/iago/extensao-whatsapp-back/internal/queue"
+)
+
+// durationBuckets cover job attempts from cache hits to the longest default deadline.
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Metrics records worker throughput, failures and durations per job kind for capacity
+// planning. A nil *Metrics records nothing.
+type Metrics struct {
+	consumed     *metrics.CounterVec
+	processed    *metrics.CounterVec
+	failed       *metrics.CounterVec
+	retried      *metrics.CounterVec
+	deadLettered *metrics.CounterVec
+	duration     *metrics.HistogramVec
+	inFlight     *metrics.GaugeVec
+	poolSize     *metrics.GaugeVec
+}
+
+// NewMetrics registers the worker metrics; a nil registry disables them.
+func NewMetrics(registry *metrics.Registry) *Metrics {
+	if registry == nil {
+		return nil
+	}
+	return &Metrics{
+		consumed:     registry.Counter("worker_jobs_consumed_total", "Job attempts run by the worker, by queue priority.", "priority"),
+		processed:    registry.Counter("worker_jobs_processed_total", "Job attempts that completed, by kind.", "kind"),
+		failed:       registry.Counter("worker_jobs_failed_total", "Job attempts that failed, by kind.", "kind"),
+		retried:      registry.Counter("worker_jobs_retried_total", "Failed job attempts sent back to the queue, by kind.", "kind"),
+		deadLettered: registry.Counter("worker_jobs_dead_lettered_total", "Jobs moved to the dead-letter queue after their last attempt failed, by kind.", "kind"),
+		duration:     registry.Histogram("worker_job_duration_seconds", "Duration of job attempts by kind and outcome.", durationBuckets, "kind", "status"),
+		inFlight:     registry.Gauge("worker_jobs_in_flight", "Job attempts currently running."),
+		poolSize:     registry.Gauge("worker_concurrency", "Jobs the worker may run at once."),
+	}
+}
+
+func (m *Metrics) started() {
+	if m == nil {
+		return
+	}
+	m.inFlight.Add(1)
+}
+
+// finished records an attempt that ran to completion or failure; released attempts only
+// leave the in-flight gauge.
+func (m *Metrics) finished(message domain.QueueMessage, elapsed time.Duration, err error, released bool) {
+	if m == nil {
+		return
+	}
+	m.inFlight.Add(-1)
+	if released {
+		return
+	}
+	priority := message.Priority
+	if priority == "" {
+		priority = domain.JobPriorityHigh
+	}
+	m.consumed.Inc(string(priority))
+	kind := string(message.Kind)
+	status := string(domain.JobStatusDone)
+	if err != nil {
+		status = string(domain.JobStatusFailed)
+		m.failed.Inc(kind)
+	} else {
+		m.processed.Inc(kind)
+	}
+	m.duration.Observe(elapsed.Seconds(), kind, status)
+}
+
+func (m *Metrics) concurrency(size int) {
+	if m == nil {
+		return
+	}
+	m.poolSize.Set(float64(size))
+}
+
+func (m *Metrics) settledFailure(message domain.QueueMessage, retried bool) {
+	if m == nil {
+		return
+	}
+	if retried {
+		m.retried.Inc(string(message.Kind))
+		return
+	}
+	m.deadLettered.Inc(string(message.Kind))
+}
+
+// UseMetrics makes the processor record its throughput in m, along with the retries and
+// dead letters of its consumer when the consumer reports them. Call it before Start.
+func (p *Processor) UseMetrics(m *Metrics) {
+	p.metrics = m
+	if reporter, ok := p.consumer.(queue.FailureReporter); ok && m != nil {
+		reporter.OnFailure(m.settledFailure)
+	}
+}
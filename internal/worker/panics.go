@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// ErrJobPanic is returned when a handler, or the processing around it, panics. The job
+// fails with the job_panic code and the queue retries it like any other failure; the
+// consume loop keeps running.
+var ErrJobPanic = errors.New("job panicked")
+
+// panicError keeps the value and stack of a recovered panic.
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func newPanicError(value any) *panicError {
+	return &panicError{value: value, stack: debug.Stack()}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrJobPanic, e.value)
+}
+
+func (e *panicError) Unwrap() error {
+	return ErrJobPanic
+}
+
+func (p *Processor) logPanic(ctx context.Context, err error) {
+	var panicked *panicError
+	if p.logger == nil || !errors.As(err, &panicked) {
+		return
+	}
+	p.logger.ErrorContext(ctx, "job panicked", slog.Any("panic", panicked.value), slog.String("stack", string(panicked.stack)))
+}
+
+// runJobSafely runs the job and turns a panic outside the handler (persisting or
+// annotating the result) into a failed job.
+func (p *Processor) runJobSafely(ctx context.Context, message domain.QueueMessage) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		panicked := newPanicError(recovered)
+		p.logPanic(ctx, panicked)
+		p.failPanickedJob(ctx, message.JobID, panicked)
+		err = panicked
+	}()
+	return p.runJob(ctx, message)
+}
+
+func (p *Processor) failPanickedJob(ctx context.Context, jobID string, panicked *panicError) {
+	job, err := p.repo.GetJob(ctx, jobID)
+	if err != nil {
+		return
+	}
+	finishedAt := time.Now().UTC()
+	job.Status = domain.JobStatusFailed
+	job.ErrorMessage = panicked.Error()
+	job.ErrorCode = domain.JobErrorPanic
+	job.FinishedAt = &finishedAt
+	job.UpdatedAt = finishedAt
+	_ = p.repo.UpdateJob(ctx, job)
+}
@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+	"github.com/iago/extensao-whatsapp-back/internal/queue"
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
+)
+
+// maxPipelineDepth bounds the ancestors walked when linking a pipeline result.
+const maxPipelineDepth = 8
+
+var errUpstreamNotDone = errors.New("upstream job is not done")
+
+// UseProducer lets the processor enqueue the next steps of a pipeline once a step is done.
+// Without a producer, steps that depend on another job fail when it completes.
+func (p *Processor) UseProducer(producer queue.Producer) {
+	p.producer = producer
+}
+
+// loadUpstream returns the result of the job a pipeline step depends on.
+func (p *Processor) loadUpstream(ctx context.Context, job *domain.Job) (json.RawMessage, error) {
+	upstream, err := p.repo.GetJob(ctx, job.DependsOn)
+	if err != nil {
+		return nil, fmt.Errorf("load upstream job %s: %w", job.DependsOn, err)
+	}
+	if upstream.Status != domain.JobStatusDone {
+		return nil, fmt.Errorf("%w: %s is %s", errUpstreamNotDone, upstream.ID, upstream.Status)
+	}
+	return upstream.Result, nil
+}
+
+// linkAncestors records the earlier steps of a pipeline in its result, nearest first.
+func (p *Processor) linkAncestors(ctx context.Context, job *domain.Job, result json.RawMessage) json.RawMessage {
+	if job.DependsOn == "" {
+		return result
+	}
+	var body map[string]any
+	if err := json.Unmarshal(result, &body); err != nil || body == nil {
+		return result
+	}
+
+	ancestors := make([]map[string]any, 0, 2)
+	for parentID := job.DependsOn; parentID != "" && len(ancestors) < maxPipelineDepth; {
+		parent, err := p.repo.GetJob(ctx, parentID)
+		if err != nil {
+			break
+		}
+		ancestors = append(ancestors, map[string]any{"job_id": parent.ID, "kind": parent.Kind})
+		parentID = parent.DependsOn
+	}
+	body["pipeline"] = map[string]any{"ancestors": ancestors}
+
+	linked, err := json.Marshal(body)
+	if err != nil {
+		return result
+	}
+	return linked
+}
+
+// enqueueDependents starts the pipeline steps waiting on job. Steps failed by an earlier
+// failed attempt of job are restarted.
+func (p *Processor) enqueueDependents(ctx context.Context, job *domain.Job) {
+	dependents, err := p.repo.ListDependentJobs(ctx, job.ID)
+	if err != nil {
+		p.logPipelineError(ctx, "list dependent jobs failed", job.ID, err)
+		return
+	}
+	for index := range dependents {
+		dependent := &dependents[index]
+		if dependent.Status == domain.JobStatusFailed && dependent.ErrorCode == domain.JobErrorDependency {
+			dependent.Status = domain.JobStatusPending
+			dependent.ErrorMessage = ""
+			dependent.ErrorCode = ""
+			dependent.FinishedAt = nil
+			dependent.UpdatedAt = time.Now().UTC()
+			if err := p.repo.UpdateJob(ctx, dependent); err != nil {
+				p.logPipelineError(ctx, "restart dependent job failed", dependent.ID, err)
+				continue
+			}
+		}
+		if dependent.Status != domain.JobStatusPending {
+			continue
+		}
+		if p.producer == nil {
+			p.failDependent(ctx, dependent, "no queue producer configured to run pipeline steps")
+			continue
+		}
+		message := domain.QueueMessage{
+			JobID:          dependent.ID,
+			Kind:           dependent.Kind,
+			TenantID:       dependent.TenantID,
+			ConversationID: dependent.ConversationID,
+			Payload:        dependent.Payload,
+			RequestedAt:    time.Now().UTC(),
+			TraceParent:    tracing.TraceParent(ctx),
+			RequestID:      logging.RequestID(ctx),
+			Priority:       dependent.Priority,
+		}
+		if err := p.producer.Enqueue(ctx, message); err != nil {
+			p.failDependent(ctx, dependent, fmt.Sprintf("enqueue job: %v", err))
+		}
+	}
+}
+
+// failDependents fails the pipeline steps waiting on job, recursively.
+func (p *Processor) failDependents(ctx context.Context, job *domain.Job) {
+	dependents, err := p.repo.ListDependentJobs(ctx, job.ID)
+	if err != nil {
+		p.logPipelineError(ctx, "list dependent jobs failed", job.ID, err)
+		return
+	}
+	for index := range dependents {
+		dependent := &dependents[index]
+		if dependent.Status != domain.JobStatusPending {
+			continue
+		}
+		p.failDependent(ctx, dependent, fmt.Sprintf("upstream job %s failed", job.ID))
+		p.failDependents(ctx, dependent)
+	}
+}
+
+func (p *Processor) failDependent(ctx context.Context, job *domain.Job, reason string) {
+	finishedAt := time.Now().UTC()
+	job.Status = domain.JobStatusFailed
+	job.ErrorMessage = reason
+	job.ErrorCode = domain.JobErrorDependency
+	job.FinishedAt = &finishedAt
+	job.UpdatedAt = finishedAt
+	if err := p.repo.UpdateJob(ctx, job); err != nil {
+		p.logPipelineError(ctx, "fail dependent job failed", job.ID, err)
+	}
+}
+
+func (p *Processor) logPipelineError(ctx context.Context, message string, jobID string, err error) {
+	if p.logger != nil {
+		p.logger.ErrorContext(ctx, message, slog.String("pipeline_job_id", jobID), slog.Any("error", err))
+	}
+}
@@ -3,15 +3,21 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/errorreport"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
 	"github.com/iago/extensao-whatsapp-back/internal/policy"
 	"github.com/iago/extensao-whatsapp-back/internal/queue"
 	"github.com/iago/extensao-whatsapp-back/internal/repository"
 	"github.com/iago/extensao-whatsapp-back/internal/service"
+	"github.com/iago/extensao-whatsapp-back/internal/tenant"
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
 )
 
 // Processor consumes queue jobs and persists status transitions.
@@ -19,24 +25,72 @@ type Processor struct {
 	consumer queue.Consumer
 	repo     repository.JobsRepository
 	ai       *service.AIGenerationService
-	logger   *log.Logger
+	producer queue.Producer
+	metrics  *Metrics
+	reporter errorreport.Reporter
+	logger   *slog.Logger
+
+	concurrency *concurrency
+
+	handlersMu sync.RWMutex
+	handlers   map[domain.JobKind]HandlerFunc
+	timeouts   map[domain.JobKind]time.Duration
+	retries    map[domain.JobKind]RetryPolicy
+
+	pauseMu sync.Mutex
+	// resume is open while the processor is paused and closed by Resume.
+	resume chan struct{}
+
+	drainMu  sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+	// abort is closed when the shutdown grace period ends, cancelling running jobs.
+	abort     chan struct{}
+	abortOnce sync.Once
 }
 
+// HandlerFunc builds the result of a job from its queue message. The processor masks PII
+// in the result and records the status transitions.
+type HandlerFunc func(ctx context.Context, message domain.QueueMessage) (service.JobGenerationOutput, error)
+
 func NewProcessor(
 	consumer queue.Consumer,
 	repo repository.JobsRepository,
 	ai *service.AIGenerationService,
-	logger *log.Logger,
+	logger *slog.Logger,
 ) *Processor {
-	return &Processor{
+	processor := &Processor{
 		consumer: consumer,
 		repo:     repo,
 		ai:       ai,
 		logger:   logger,
+		handlers: make(map[domain.JobKind]HandlerFunc),
+		timeouts: make(map[domain.JobKind]time.Duration, len(defaultJobTimeouts)),
+		retries:  make(map[domain.JobKind]RetryPolicy, len(defaultRetryPolicies)),
+		abort:    make(chan struct{}),
 	}
+	processor.UseConcurrency(ConcurrencyConfig{})
+	processor.UseTimeouts(defaultJobTimeouts)
+	processor.UseRetryPolicies(defaultRetryPolicies)
+	processor.registerBuiltinHandlers()
+	return processor
+}
+
+// RegisterHandler makes the processor run handler for jobs of kind, replacing the current
+// handler of that kind, built-in ones included.
+func (p *Processor) RegisterHandler(kind domain.JobKind, handler HandlerFunc) {
+	p.handlersMu.Lock()
+	defer p.handlersMu.Unlock()
+	p.handlers[kind] = handler
 }
 
 func (p *Processor) Start(ctx context.Context) {
+	// Jobs of every tenant go through the same workers.
+	p.runPool(tenant.WithBypass(ctx))
+}
+
+// consumeLoop consumes until ctx ends, restarting the consumer after errors.
+func (p *Processor) consumeLoop(ctx context.Context) {
 	for {
 		if ctx.Err() != nil {
 			return
@@ -47,7 +101,7 @@ func (p *Processor) Start(ctx context.Context) {
 			return
 		}
 		if p.logger != nil {
-			p.logger.Printf("worker consume loop error: %v", err)
+			p.logger.ErrorContext(ctx, "worker consume loop error", slog.Any("error", err))
 		}
 
 		timer := time.NewTimer(2 * time.Second)
@@ -60,106 +114,260 @@ func (p *Processor) Start(ctx context.Context) {
 	}
 }
 
+// Pause stops picking up new jobs; jobs already running finish normally.
+func (p *Processor) Pause() {
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	if p.resume == nil {
+		p.resume = make(chan struct{})
+	}
+}
+
+func (p *Processor) Resume() {
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	if p.resume != nil {
+		close(p.resume)
+		p.resume = nil
+	}
+}
+
+func (p *Processor) Paused() bool {
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	return p.resume != nil
+}
+
+func (p *Processor) waitWhilePaused(ctx context.Context) error {
+	p.pauseMu.Lock()
+	resume := p.resume
+	p.pauseMu.Unlock()
+	if resume == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resume:
+		return nil
+	}
+}
+
 func (p *Processor) processMessage(ctx context.Context, message domain.QueueMessage) error {
+	// A cancelled consumer context means stop fetching: messages still delivered go back.
+	if ctx.Err() != nil || !p.track() {
+		return queue.Release(errShuttingDown)
+	}
+	defer p.inFlight.Done()
+	if err := p.waitWhilePaused(ctx); err != nil {
+		return queue.Release(err)
+	}
+	// Running jobs outlive the consumer context and are only cancelled once the
+	// shutdown grace period ends.
+	ctx, cancel := p.jobContext(ctx)
+	defer cancel()
+
+	ctx = logging.WithScope(ctx,
+		slog.String("job_id", message.JobID),
+		slog.String("job_kind", string(message.Kind)),
+		slog.String("tenant_id", message.TenantID),
+		slog.String("conversation_id", message.ConversationID),
+		slog.Int("attempt", message.Attempt),
+	)
+	if message.RequestID != "" {
+		ctx = logging.WithRequestID(ctx, message.RequestID)
+		logging.Set(ctx, slog.String("request_id", message.RequestID))
+	}
+	// Continue the trace of the request that enqueued the job.
+	if parent, ok := tracing.ParseTraceParent(message.TraceParent); ok {
+		ctx = tracing.ContextWithRemoteParent(ctx, parent)
+	}
+	// Retries keep the time of the first request, so only first attempts show the wait.
+	if message.Attempt == 0 && !message.RequestedAt.IsZero() {
+		_, wait := tracing.StartAt(ctx, "queue.wait", tracing.KindInternal, message.RequestedAt)
+		wait.SetAttribute("job.id", message.JobID)
+		wait.End()
+	}
+	ctx, span := tracing.Start(ctx, "worker.process", tracing.KindConsumer)
+	span.SetAttribute("job.id", message.JobID)
+	span.SetAttribute("job.kind", string(message.Kind))
+	span.SetAttribute("job.attempt", message.Attempt)
+	if message.RequestID != "" {
+		span.SetAttribute("request_id", message.RequestID)
+	}
+	p.metrics.started()
+	p.concurrency.started()
+	startedAt := time.Now()
+	err := p.runJobSafely(ctx, message)
+	elapsed := time.Since(startedAt)
+	p.concurrency.done(elapsed)
+	p.metrics.finished(message, elapsed, err, errors.Is(err, queue.ErrReleased))
+	span.RecordError(err)
+	span.End()
+	if err != nil && ctx.Err() == nil && !errors.Is(err, queue.ErrReleased) {
+		p.reportFailure(ctx, message, err)
+		return queue.RetryAfter(err, p.retryDelay(message.Kind, message.Attempt))
+	}
+	return err
+}
+
+func (p *Processor) runJob(ctx context.Context, message domain.QueueMessage) error {
 	job, err := p.repo.GetJob(ctx, message.JobID)
 	if err != nil {
 		return fmt.Errorf("load job %s: %w", message.JobID, err)
 	}
+	if job.DependsOn != "" {
+		if message.Upstream, err = p.loadUpstream(ctx, job); err != nil {
+			return err
+		}
+	}
+	message.Checkpoint = job.Checkpoint
+	ctx, checkpoint := withCheckpoint(ctx, p.repo, job.ID)
 
+	startedAt := time.Now().UTC()
 	job.Status = domain.JobStatusProcessing
 	job.Attempts = message.Attempt + 1
-	job.UpdatedAt = time.Now().UTC()
+	job.StartedAt = &startedAt
+	job.FinishedAt = nil
+	job.UpdatedAt = startedAt
 	if err := p.repo.UpdateJob(ctx, job); err != nil {
 		return fmt.Errorf("mark processing: %w", err)
 	}
 
-	result, processErr := p.buildResult(ctx, job.Kind, message)
+	output, processErr := p.buildResult(ctx, job.Kind, message)
+	if processErr != nil && p.aborted() {
+		p.releaseJob(ctx, job)
+		return queue.Release(processErr)
+	}
 	if processErr != nil {
+		p.logPanic(ctx, processErr)
+		finishedAt := time.Now().UTC()
 		job.Status = domain.JobStatusFailed
 		job.ErrorMessage = processErr.Error()
-		job.UpdatedAt = time.Now().UTC()
-		_ = p.repo.UpdateJob(ctx, job)
+		job.ErrorCode = jobErrorCode(processErr)
+		job.FinishedAt = &finishedAt
+		job.UpdatedAt = finishedAt
+		_ = p.persistResult(ctx, job)
+		p.failDependents(ctx, job)
 		return processErr
 	}
-	result = policy.MaskPIIJSON(result)
+	// Ancestors are linked after masking: job IDs can look like phone numbers.
+	result := p.linkAncestors(ctx, job, policy.MaskTenantPIIJSON(job.TenantID, annotateResult(output)))
 
+	finishedAt := time.Now().UTC()
 	job.Status = domain.JobStatusDone
 	job.ErrorMessage = ""
+	job.ErrorCode = ""
 	job.Result = result
-	job.UpdatedAt = time.Now().UTC()
-	if err := p.repo.UpdateJob(ctx, job); err != nil {
+	job.FinishedAt = &finishedAt
+	job.UpdatedAt = finishedAt
+	if err := p.persistResult(ctx, job); err != nil {
 		return fmt.Errorf("mark done: %w", err)
 	}
+	p.clearCheckpoint(ctx, job, checkpoint)
+	p.enqueueDependents(ctx, job)
 
 	if p.logger != nil {
-		p.logger.Printf("job processed kind=%s job_id=%s", job.Kind, job.ID)
+		p.logger.InfoContext(ctx, "job processed", slog.Int64("latency_ms", finishedAt.Sub(startedAt).Milliseconds()))
 	}
 
 	return nil
 }
 
+// persistResult stores the outcome of an attempt in its own span, the last step of the
+// job's trace.
+func (p *Processor) persistResult(ctx context.Context, job *domain.Job) error {
+	ctx, span := tracing.Start(ctx, "job.persist", tracing.KindInternal)
+	defer span.End()
+	span.SetAttribute("job.status", string(job.Status))
+	err := p.repo.UpdateJob(ctx, job)
+	span.RecordError(err)
+	return err
+}
+
+// annotateResult records generation provenance (cache hit, degraded mode, token usage,
+// cost and validation outcome) next to the generated content so job status and report
+// endpoints can surface it.
+func annotateResult(output service.JobGenerationOutput) json.RawMessage {
+	var body map[string]any
+	if err := json.Unmarshal(output.Body, &body); err != nil || body == nil {
+		return output.Body
+	}
+
+	if _, exists := body["model_id"]; !exists && output.ModelID != "" {
+		body["model_id"] = output.ModelID
+	}
+	body["cache_hit"] = output.CacheHit
+	body["degraded"] = output.UsedFallback
+
+	usage := map[string]any{
+		"input_tokens":  output.Usage.InputTokens,
+		"output_tokens": output.Usage.OutputTokens,
+		"total_tokens":  output.Usage.TotalTokens,
+	}
+	if output.Priced {
+		usage["cost_usd"] = output.CostUSD
+	}
+	body["usage"] = usage
+	if output.Quality != nil {
+		body["quality"] = map[string]any{
+			"score":          output.Quality.Score,
+			"corrected":      output.Quality.Corrected,
+			"rejected":       output.Quality.Rejected,
+			"prompt_version": output.PromptVersion,
+		}
+	}
+
+	annotated, err := json.Marshal(body)
+	if err != nil {
+		return output.Body
+	}
+	return annotated
+}
+
 func (p *Processor) buildResult(
 	ctx context.Context,
 	kind domain.JobKind,
 	message domain.QueueMessage,
-) (json.RawMessage, error) {
-	if p.ai != nil {
-		input := service.JobGenerationInput{
-			TenantID:       message.TenantID,
-			ConversationID: message.ConversationID,
-			Locale:         "pt-BR",
-			Tone:           "neutro",
-			Payload:        message.Payload,
-		}
-		switch kind {
-		case domain.JobKindSummary:
-			output, err := p.ai.GenerateSummary(ctx, input)
-			if err == nil {
-				return output.Body, nil
-			}
-			if p.logger != nil {
-				p.logger.Printf("ai summary generation failed, fallback to static result: %v", err)
-			}
-		case domain.JobKindReport:
-			output, err := p.ai.GenerateReport(ctx, input)
-			if err == nil {
-				return output.Body, nil
-			}
-			if p.logger != nil {
-				p.logger.Printf("ai report generation failed, fallback to static result: %v", err)
-			}
-		}
+) (service.JobGenerationOutput, error) {
+	p.handlersMu.RLock()
+	handler, ok := p.handlers[kind]
+	p.handlersMu.RUnlock()
+	if !ok {
+		return service.JobGenerationOutput{}, fmt.Errorf("unsupported job kind: %s", kind)
 	}
 
-	switch kind {
-	case domain.JobKindSummary:
-		result := map[string]any{
-			"summary":        "Resumo gerado automaticamente para a conversa atual.",
-			"action_items":   []string{"Confirmar pendencias em aberto", "Responder contato com proximo passo"},
-			"prompt_version": "summary_v1",
-			"model_id":       "summary-fast-v1",
-		}
-		encoded, err := json.Marshal(result)
-		if err != nil {
-			return nil, fmt.Errorf("encode summary result: %w", err)
-		}
-		return encoded, nil
-	case domain.JobKindReport:
-		result := map[string]any{
-			"title": "Relatorio da conversa",
-			"sections": []map[string]string{
-				{"heading": "Visao geral", "content": "Conversa processada com sucesso e principais pontos consolidados."},
-				{"heading": "Pendencias", "content": "Nenhuma pendencia critica identificada no processamento inicial."},
-			},
-			"prompt_version": "report_v1",
-			"model_id":       "report-fast-v1",
-		}
-		encoded, err := json.Marshal(result)
-		if err != nil {
-			return nil, fmt.Errorf("encode report result: %w", err)
+	// The handler runs apart so one that ignores its context still frees the consumer
+	// slot at the deadline.
+	timeout := p.timeout(kind)
+	jobCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	type handlerResult struct {
+		output service.JobGenerationOutput
+		err    error
+	}
+	done := make(chan handlerResult, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- handlerResult{err: newPanicError(recovered)}
+			}
+		}()
+		output, err := handler(jobCtx, message)
+		done <- handlerResult{output: output, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		// A result produced after the deadline is usually a fallback for a cancelled
+		// provider call; the job is retried instead.
+		if jobCtx.Err() == nil {
+			return result.output, result.err
 		}
-		return encoded, nil
-	default:
-		return nil, fmt.Errorf("unsupported job kind: %s", kind)
+	case <-jobCtx.Done():
+	}
+	if ctx.Err() != nil {
+		return service.JobGenerationOutput{}, ctx.Err()
 	}
+	return service.JobGenerationOutput{}, fmt.Errorf("%w after %s", ErrJobTimeout, timeout)
 }
@@ -0,0 +1,610 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/ai"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/errorreport"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
+	"github.com/iago/extensao-whatsapp-back/internal/queue"
+	"github.com/iago/extensao-whatsapp-back/internal/repository"
+	"github.com/iago/extensao-whatsapp-back/internal/service"
+	"github.com/iago/extensao-whatsapp-back/internal/tracing"
+)
+
+func TestRegisteredHandlersBuildJobResults(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryJobsRepository()
+	processor := NewProcessor(nil, repo, nil, logging.Discard())
+
+	const transcription domain.JobKind = "transcription"
+	processor.RegisterHandler(transcription, func(_ context.Context, message domain.QueueMessage) (service.JobGenerationOutput, error) {
+		body, _ := json.Marshal(map[string]string{"transcript": "Cliente ligou de +55 11 99999-8888 pedindo o boleto."})
+		return service.JobGenerationOutput{Body: body, ModelID: "whisper-test"}, nil
+	})
+
+	run := func(id string, kind domain.JobKind) *domain.Job {
+		t.Helper()
+		job := &domain.Job{ID: id, Kind: kind, TenantID: "tenant-a", ConversationID: "chat-1", Status: domain.JobStatusPending}
+		if err := repo.CreateJob(ctx, job); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		_ = processor.processMessage(ctx, domain.QueueMessage{JobID: id, Kind: kind, TenantID: "tenant-a", ConversationID: "chat-1"})
+		stored, err := repo.GetJob(ctx, id)
+		if err != nil {
+			t.Fatalf("get job: %v", err)
+		}
+		return stored
+	}
+
+	job := run("job-transcription", transcription)
+	if job.Status != domain.JobStatusDone || strings.Contains(string(job.Result), "99999-8888") {
+		t.Fatalf("expected a done job with masked result, got %s %s", job.Status, job.Result)
+	}
+	if !strings.Contains(string(job.Result), `"model_id":"whisper-test"`) {
+		t.Fatalf("expected provenance annotations, got %s", job.Result)
+	}
+
+	if job := run("job-summary", domain.JobKindSummary); job.Status != domain.JobStatusDone {
+		t.Fatalf("expected the built-in summary handler, got %s %s", job.Status, job.ErrorMessage)
+	}
+	if job := run("job-unknown", "analytics"); job.Status != domain.JobStatusFailed || job.ErrorMessage != "unsupported job kind: analytics" {
+		t.Fatalf("expected unknown kinds to fail, got %s %q", job.Status, job.ErrorMessage)
+	}
+}
+
+func TestJobsOutlivingTheirDeadlineTimeOut(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryJobsRepository()
+	processor := NewProcessor(nil, repo, nil, logging.Discard())
+
+	release := make(chan struct{})
+	defer close(release)
+	const analytics domain.JobKind = "analytics"
+	processor.RegisterHandler(analytics, func(context.Context, domain.QueueMessage) (service.JobGenerationOutput, error) {
+		<-release // ignores its context, like a hung provider call
+		return service.JobGenerationOutput{}, nil
+	})
+	processor.UseTimeouts(map[domain.JobKind]time.Duration{analytics: 20 * time.Millisecond})
+
+	job := &domain.Job{ID: "job-hung", Kind: analytics, TenantID: "tenant-a", Status: domain.JobStatusPending}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	started := time.Now()
+	err := processor.processMessage(ctx, domain.QueueMessage{JobID: job.ID, Kind: analytics, TenantID: "tenant-a"})
+	if !errors.Is(err, ErrJobTimeout) || time.Since(started) > time.Second {
+		t.Fatalf("expected the attempt to time out at the deadline, got %v after %s", err, time.Since(started))
+	}
+	stored, _ := repo.GetJob(ctx, job.ID)
+	if stored.Status != domain.JobStatusFailed || stored.ErrorCode != domain.JobErrorTimeout {
+		t.Fatalf("expected a failed job with job_timeout, got %s %q", stored.Status, stored.ErrorCode)
+	}
+
+	timeouts, err := ParseJobTimeouts([]string{"report=2m", " digest = 45s "})
+	if err != nil || timeouts[domain.JobKindReport] != 2*time.Minute || timeouts[domain.JobKindDigest] != 45*time.Second {
+		t.Fatalf("unexpected parsed timeouts %v err=%v", timeouts, err)
+	}
+	if _, err := ParseJobTimeouts([]string{"report=soon"}); err == nil {
+		t.Fatal("expected an invalid duration to be rejected")
+	}
+}
+
+func TestFailedJobsAreRetriedWithExponentialBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	cases := []struct {
+		attempt  int
+		jitter   float64
+		expected time.Duration
+	}{
+		{0, 0, 500 * time.Millisecond},
+		{0, 0.999999, time.Second},
+		{2, 0, 2 * time.Second},
+		{2, 0.5, 3 * time.Second},
+		{5, 0, 5 * time.Second},
+		{60, 0.999999, 10 * time.Second},
+	}
+	for _, tc := range cases {
+		if delay := policy.Delay(tc.attempt, tc.jitter); delay.Round(time.Millisecond) != tc.expected {
+			t.Fatalf("attempt %d jitter %.2f: expected %s, got %s", tc.attempt, tc.jitter, tc.expected, delay)
+		}
+	}
+
+	ctx := context.Background()
+	repo := repository.NewMemoryJobsRepository()
+	processor := NewProcessor(nil, repo, nil, logging.Discard())
+	const analytics domain.JobKind = "analytics"
+	processor.RegisterHandler(analytics, func(context.Context, domain.QueueMessage) (service.JobGenerationOutput, error) {
+		return service.JobGenerationOutput{}, errors.New("provider unavailable")
+	})
+	policies, err := ParseRetryPolicies([]string{"analytics = 4s/8s"})
+	if err != nil {
+		t.Fatalf("parse retry policies: %v", err)
+	}
+	processor.UseRetryPolicies(policies)
+
+	if err := repo.CreateJob(ctx, &domain.Job{ID: "job-flaky", Kind: analytics, TenantID: "tenant-a", Status: domain.JobStatusPending}); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	err = processor.processMessage(ctx, domain.QueueMessage{JobID: "job-flaky", Kind: analytics, TenantID: "tenant-a", Attempt: 1})
+	var retry *queue.RetryError
+	if !errors.As(err, &retry) || retry.Delay < 4*time.Second || retry.Delay > 8*time.Second {
+		t.Fatalf("expected a retry within the analytics policy, got %v", err)
+	}
+
+	for _, entry := range []string{"digest=2s", "digest=soon/1m", "digest=1m/2s"} {
+		if _, err := ParseRetryPolicies([]string{entry}); err == nil {
+			t.Fatalf("expected %q to be rejected", entry)
+		}
+	}
+}
+
+func TestDrainWaitsForRunningJobsAndReleasesTheRest(t *testing.T) {
+	repo := repository.NewMemoryJobsRepository()
+	processor := NewProcessor(nil, repo, nil, logging.Discard())
+
+	finish := make(chan struct{})
+	const analytics, transcription domain.JobKind = "analytics", "transcription"
+	processor.RegisterHandler(analytics, func(context.Context, domain.QueueMessage) (service.JobGenerationOutput, error) {
+		<-finish
+		return service.JobGenerationOutput{Body: json.RawMessage(`{"ok":true}`)}, nil
+	})
+	processor.RegisterHandler(transcription, func(ctx context.Context, _ domain.QueueMessage) (service.JobGenerationOutput, error) {
+		<-ctx.Done()
+		return service.JobGenerationOutput{}, ctx.Err()
+	})
+
+	start := func(id string, kind domain.JobKind) (context.CancelFunc, chan error) {
+		t.Helper()
+		if err := repo.CreateJob(context.Background(), &domain.Job{ID: id, Kind: kind, TenantID: "tenant-a", Status: domain.JobStatusPending}); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		consumerCtx, stopFetching := context.WithCancel(context.Background())
+		result := make(chan error, 1)
+		go func() {
+			result <- processor.processMessage(consumerCtx, domain.QueueMessage{JobID: id, Kind: kind, TenantID: "tenant-a"})
+		}()
+		for {
+			if job, _ := repo.GetJob(context.Background(), id); job.Status == domain.JobStatusProcessing {
+				return stopFetching, result
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	stopFirst, first := start("job-finishing", analytics)
+	stopSecond, second := start("job-hanging", transcription)
+	stopFirst()
+	stopSecond()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(finish)
+	}()
+
+	if processor.Drain(200 * time.Millisecond) {
+		t.Fatal("expected the hanging job to outlive the grace period")
+	}
+	if err := <-first; err != nil {
+		t.Fatalf("expected the job finishing within the grace period to succeed, got %v", err)
+	}
+	if job, _ := repo.GetJob(context.Background(), "job-finishing"); job.Status != domain.JobStatusDone {
+		t.Fatalf("expected the finished job to be done, got %s", job.Status)
+	}
+	if err := <-second; !errors.Is(err, queue.ErrReleased) {
+		t.Fatalf("expected the interrupted job to be released, got %v", err)
+	}
+	if job, _ := repo.GetJob(context.Background(), "job-hanging"); job.Status != domain.JobStatusPending || job.Attempts != 0 {
+		t.Fatalf("expected the interrupted job back to pending, got %s attempts=%d", job.Status, job.Attempts)
+	}
+
+	err := processor.processMessage(context.Background(), domain.QueueMessage{JobID: "job-late", Kind: analytics})
+	if !errors.Is(err, queue.ErrReleased) {
+		t.Fatalf("expected a draining processor to release new messages, got %v", err)
+	}
+}
+
+type recordingProducer struct {
+	messages []domain.QueueMessage
+}
+
+func (p *recordingProducer) Enqueue(_ context.Context, message domain.QueueMessage) error {
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func TestJobSpansJoinTheTraceOfTheEnqueuingRequest(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		spans []map[string]any
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]any `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		_ = json.Unmarshal(body, &payload)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, resource := range payload.ResourceSpans {
+			for _, scope := range resource.ScopeSpans {
+				spans = append(spans, scope.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+	tracer := tracing.NewTracer(tracing.Config{Endpoint: collector.URL, ServiceName: "worker-test", FlushInterval: time.Hour})
+	tracing.SetTracer(tracer)
+	defer tracing.SetTracer(nil)
+
+	repo := repository.NewMemoryJobsRepository()
+	producer := &recordingProducer{}
+	jobs := service.NewJobsService(repo, producer)
+	processor := NewProcessor(nil, repo, nil, logging.Discard())
+	const transcription domain.JobKind = "transcription"
+	processor.RegisterHandler(transcription, func(context.Context, domain.QueueMessage) (service.JobGenerationOutput, error) {
+		return service.JobGenerationOutput{Body: json.RawMessage(`{"transcript":"ok"}`)}, nil
+	})
+
+	ctx, request := tracing.Start(context.Background(), "HTTP POST", tracing.KindServer)
+	steps, err := jobs.EnqueuePipeline(ctx, "tenant-a", "chat-1", []service.PipelineStep{{Kind: transcription, Payload: json.RawMessage(`{}`)}})
+	request.End()
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := processor.processMessage(context.Background(), producer.messages[0]); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracer.Shutdown(flushCtx)
+
+	stored, _ := repo.GetJob(context.Background(), steps[0].ID)
+	row, ok := tracing.ParseTraceParent(stored.TraceParent)
+	if !ok || row.TraceID != request.SpanContext().TraceID || producer.messages[0].TraceParent != stored.TraceParent {
+		t.Fatalf("expected the job row and message to carry the request trace, got %q and %q", stored.TraceParent, producer.messages[0].TraceParent)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	byName := make(map[string]map[string]any)
+	traceID := tracing.TraceID(ctx)
+	for _, span := range spans {
+		if span["traceId"] != traceID {
+			t.Fatalf("expected every span in trace %s, got %+v", traceID, span)
+		}
+		byName[span["name"].(string)] = span
+	}
+	for name, parent := range map[string]string{
+		"queue.enqueue":  "HTTP POST",
+		"queue.wait":     "queue.enqueue",
+		"worker.process": "queue.enqueue",
+		"job.persist":    "worker.process",
+	} {
+		if byName[name] == nil || byName[parent] == nil || byName[name]["parentSpanId"] != byName[parent]["spanId"] {
+			t.Fatalf("expected %s under %s, got %+v", name, parent, byName)
+		}
+	}
+}
+
+func TestPipelineStepsRunInOrderOnTheirUpstreamResult(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryJobsRepository()
+	producer := &recordingProducer{}
+	jobs := service.NewJobsService(repo, producer)
+	processor := NewProcessor(nil, repo, nil, logging.Discard())
+	processor.UseProducer(producer)
+
+	const transcription domain.JobKind = "transcription"
+	failTranscription := true
+	processor.RegisterHandler(transcription, func(context.Context, domain.QueueMessage) (service.JobGenerationOutput, error) {
+		if failTranscription {
+			return service.JobGenerationOutput{}, errors.New("audio unavailable")
+		}
+		return service.JobGenerationOutput{Body: json.RawMessage(`{"transcript":"cliente pediu o boleto"}`)}, nil
+	})
+	var upstream string
+	processor.RegisterHandler(domain.JobKindSummary, func(_ context.Context, message domain.QueueMessage) (service.JobGenerationOutput, error) {
+		upstream = string(message.Upstream)
+		return service.JobGenerationOutput{Body: json.RawMessage(`{"summary":"boleto"}`)}, nil
+	})
+
+	steps, err := jobs.EnqueuePipeline(ctx, "tenant-a", "chat-1", []service.PipelineStep{
+		{Kind: transcription, Payload: json.RawMessage(`{}`)},
+		{Kind: domain.JobKindSummary, Payload: json.RawMessage(`{}`)},
+	})
+	if err != nil {
+		t.Fatalf("enqueue pipeline: %v", err)
+	}
+	if len(producer.messages) != 1 || producer.messages[0].JobID != steps[0].ID || steps[1].DependsOn != steps[0].ID {
+		t.Fatalf("expected only the first step to be enqueued, got %+v", producer.messages)
+	}
+
+	_ = processor.processMessage(ctx, producer.messages[0])
+	summary, _ := repo.GetJob(ctx, steps[1].ID)
+	if summary.Status != domain.JobStatusFailed || summary.ErrorCode != domain.JobErrorDependency {
+		t.Fatalf("expected the summary to fail with its upstream, got %s %q", summary.Status, summary.ErrorCode)
+	}
+
+	failTranscription = false
+	_ = processor.processMessage(ctx, domain.QueueMessage{JobID: steps[0].ID, Kind: transcription, TenantID: "tenant-a", Attempt: 1})
+	if len(producer.messages) != 2 || producer.messages[1].JobID != steps[1].ID {
+		t.Fatalf("expected the retried upstream to enqueue the summary, got %+v", producer.messages)
+	}
+	if err := processor.processMessage(ctx, producer.messages[1]); err != nil {
+		t.Fatalf("process summary: %v", err)
+	}
+	if !strings.Contains(upstream, "cliente pediu o boleto") {
+		t.Fatalf("expected the summary to receive the transcription, got %q", upstream)
+	}
+	summary, _ = repo.GetJob(ctx, steps[1].ID)
+	ancestors := `"pipeline":{"ancestors":[{"job_id":"` + steps[0].ID + `","kind":"transcription"}]}`
+	if summary.Status != domain.JobStatusDone || !strings.Contains(string(summary.Result), ancestors) {
+		t.Fatalf("expected a done summary linking its ancestor, got %s %s", summary.Status, summary.Result)
+	}
+}
+
+func TestWorkerMetricsCountOutcomesPerKind(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	repo := repository.NewMemoryJobsRepository()
+	local := queue.NewLocalQueue(8, 2, nil)
+	processor := NewProcessor(local, repo, nil, logging.Discard())
+	registry := metrics.NewRegistry()
+	processor.UseMetrics(NewMetrics(registry))
+
+	const analytics domain.JobKind = "analytics"
+	processor.RegisterHandler(analytics, func(context.Context, domain.QueueMessage) (service.JobGenerationOutput, error) {
+		return service.JobGenerationOutput{}, errors.New("provider unavailable")
+	})
+	processor.UseRetryPolicies(map[domain.JobKind]RetryPolicy{analytics: {BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}})
+	for _, job := range []*domain.Job{
+		{ID: "job-ok", Kind: domain.JobKindSummary, TenantID: "tenant-a", Status: domain.JobStatusPending},
+		{ID: "job-broken", Kind: analytics, TenantID: "tenant-a", Status: domain.JobStatusPending},
+	} {
+		if err := repo.CreateJob(ctx, job); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		_ = local.Enqueue(ctx, domain.QueueMessage{JobID: job.ID, Kind: job.Kind, TenantID: job.TenantID})
+	}
+	go processor.Start(ctx)
+
+	for local.DLQSize() == 0 && ctx.Err() == nil {
+		time.Sleep(5 * time.Millisecond)
+	}
+	var exposition strings.Builder
+	_ = registry.WriteText(&exposition)
+	for _, series := range []string{
+		`worker_jobs_processed_total{kind="summary"} 1`,
+		`worker_jobs_failed_total{kind="analytics"} 2`,
+		`worker_jobs_retried_total{kind="analytics"} 1`,
+		`worker_jobs_dead_lettered_total{kind="analytics"} 1`,
+		`worker_job_duration_seconds_count{kind="analytics",status="failed"} 2`,
+		`worker_jobs_consumed_total{priority="high"} 3`,
+		`worker_jobs_in_flight 0`,
+	} {
+		if !strings.Contains(exposition.String(), series) {
+			t.Fatalf("expected %q in:\n%s", series, exposition.String())
+		}
+	}
+}
+
+func TestPanickingJobsFailWithoutStoppingTheWorker(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	repo := repository.NewMemoryJobsRepository()
+	local := queue.NewLocalQueue(8, 1, nil)
+	processor := NewProcessor(local, repo, nil, logging.Discard())
+	reporter := &recordingReporter{}
+	processor.UseErrorReporter(reporter)
+
+	const analytics domain.JobKind = "analytics"
+	processor.RegisterHandler(analytics, func(context.Context, domain.QueueMessage) (service.JobGenerationOutput, error) {
+		var sections map[string]string
+		sections["overview"] = "boom" // assignment to a nil map
+		return service.JobGenerationOutput{}, nil
+	})
+	for _, job := range []*domain.Job{
+		{ID: "job-panics", Kind: analytics, TenantID: "tenant-a", Status: domain.JobStatusPending},
+		{ID: "job-after", Kind: domain.JobKindSummary, TenantID: "tenant-a", Status: domain.JobStatusPending},
+	} {
+		if err := repo.CreateJob(ctx, job); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		_ = local.Enqueue(ctx, domain.QueueMessage{JobID: job.ID, Kind: job.Kind, TenantID: job.TenantID})
+	}
+	go processor.Start(ctx)
+
+	for ctx.Err() == nil {
+		if job, _ := repo.GetJob(ctx, "job-after"); job != nil && job.Status == domain.JobStatusDone && len(reporter.reported()) > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	panicked, _ := repo.GetJob(ctx, "job-panics")
+	if panicked.Status != domain.JobStatusFailed || panicked.ErrorCode != domain.JobErrorPanic {
+		t.Fatalf("expected the panicking job to fail with job_panic, got %s %q", panicked.Status, panicked.ErrorCode)
+	}
+	if after, _ := repo.GetJob(ctx, "job-after"); after.Status != domain.JobStatusDone {
+		t.Fatalf("expected the worker to keep consuming after a panic, got %s", after.Status)
+	}
+	event := reporter.reported()[0]
+	if event.Level != errorreport.LevelFatal || event.Type != "panic" || event.Tags["job_kind"] != "analytics" ||
+		!errors.Is(event.Err, ErrJobPanic) || !strings.Contains(string(event.Stack), "processor_test.go") {
+		t.Fatalf("expected the panic reported with its stack, got %+v", event)
+	}
+}
+
+type recordingReporter struct {
+	mu     sync.Mutex
+	events []errorreport.Event
+}
+
+func (r *recordingReporter) Report(_ context.Context, event errorreport.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingReporter) reported() []errorreport.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]errorreport.Event(nil), r.events...)
+}
+
+// sectionWriter answers report section prompts, failing the ones for the headings in down.
+type sectionWriter struct {
+	down    map[string]bool
+	prompts []string
+}
+
+func (w *sectionWriter) Generate(_ context.Context, request ai.GenerateRequest) (ai.GenerateResult, error) {
+	for heading := range w.down {
+		if strings.Contains(request.Input, `secao "`+heading+`"`) {
+			return ai.GenerateResult{}, errors.New("provider timeout")
+		}
+	}
+	w.prompts = append(w.prompts, request.Input)
+	return ai.GenerateResult{Text: `{"content":"Conteudo gerado para a secao."}`, ModelID: "report-test"}, nil
+}
+
+func (w *sectionWriter) Available() bool { return true }
+
+func TestSectionedReportsResumeFromSavedSections(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryJobsRepository()
+	writer := &sectionWriter{down: map[string]bool{"Riscos": true}}
+	generation := service.NewAIGenerationService(service.AIGenerationDependencies{
+		Router:     ai.NewModelRouter(ai.ModelRouterConfig{}),
+		Client:     writer,
+		PromptsDir: "../../prompts",
+		Logger:     logging.Discard(),
+	})
+	processor := NewProcessor(nil, repo, generation, logging.Discard())
+
+	payload := json.RawMessage(`{"report_type":"timeline","sections":["Visao geral","Pendencias","Riscos","Proximos passos"]}`)
+	job := &domain.Job{ID: "job-sections", Kind: domain.JobKindReport, TenantID: "tenant-a", ConversationID: "chat-1", Payload: payload, Status: domain.JobStatusPending}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	message := domain.QueueMessage{JobID: job.ID, Kind: job.Kind, TenantID: job.TenantID, ConversationID: job.ConversationID, Payload: payload}
+
+	var retry *queue.RetryError
+	if err := processor.processMessage(ctx, message); !errors.As(err, &retry) {
+		t.Fatalf("expected the failed section to retry the job, got %v", err)
+	}
+	failed, _ := repo.GetJob(ctx, job.ID)
+	var saved struct {
+		Sections []service.ReportSection `json:"sections"`
+	}
+	if err := json.Unmarshal(failed.Checkpoint, &saved); err != nil || len(saved.Sections) != 2 {
+		t.Fatalf("expected the two sections before the failure to be saved, got %s", failed.Checkpoint)
+	}
+
+	delete(writer.down, "Riscos")
+	writer.prompts = nil
+	message.Attempt = 1
+	if err := processor.processMessage(ctx, message); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if len(writer.prompts) != 2 || strings.Contains(writer.prompts[0], `secao "Pendencias"`) {
+		t.Fatalf("expected only the missing sections to be generated, got %d calls", len(writer.prompts))
+	}
+	done, _ := repo.GetJob(ctx, job.ID)
+	if done.Status != domain.JobStatusDone || done.Checkpoint != nil {
+		t.Fatalf("expected a done job without checkpoint, got %s %s", done.Status, done.Checkpoint)
+	}
+	var report struct {
+		Sections []service.ReportSection `json:"sections"`
+	}
+	if err := json.Unmarshal(done.Result, &report); err != nil || len(report.Sections) != 4 || report.Sections[3].Heading != "Proximos passos" {
+		t.Fatalf("expected the four sections in order, got %s", done.Result)
+	}
+}
+
+func TestConcurrencyFollowsQueueDepthAndLatency(t *testing.T) {
+	pool := newConcurrency(ConcurrencyConfig{Min: 1, Max: 3, DepthPerWorker: 5, TargetLatency: time.Second})
+	steps := []struct {
+		depth    int64
+		busy     int
+		latency  time.Duration
+		expected int
+	}{
+		{depth: 6, expected: 2},
+		{depth: 11, busy: 2, expected: 3},
+		{depth: 40, busy: 3, expected: 3},
+		{depth: 40, busy: 3, latency: 2 * time.Second, expected: 2},
+		{depth: 3, busy: 2, expected: 2},
+		{depth: 0, busy: 1, expected: 1},
+		{depth: 0, expected: 1},
+	}
+	for index, step := range steps {
+		pool.busy = step.busy
+		if step.latency > 0 {
+			pool.finished, pool.elapsed = 1, step.latency
+		} else {
+			pool.finished, pool.elapsed = 1, 10*time.Millisecond
+		}
+		if size := pool.resize(step.depth); size != step.expected {
+			t.Fatalf("step %d: expected %d workers, got %d", index, step.expected, size)
+		}
+	}
+}
+
+func TestAdaptivePoolRunsJobsConcurrently(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	repo := repository.NewMemoryJobsRepository()
+	local := queue.NewLocalQueue(64, 1, nil)
+	processor := NewProcessor(local, repo, nil, logging.Discard())
+	processor.UseConcurrency(ConcurrencyConfig{Min: 1, Max: 4, Interval: 10 * time.Millisecond, DepthPerWorker: 1})
+
+	const analytics domain.JobKind = "analytics"
+	release := make(chan struct{})
+	processor.RegisterHandler(analytics, func(context.Context, domain.QueueMessage) (service.JobGenerationOutput, error) {
+		<-release
+		return service.JobGenerationOutput{Body: json.RawMessage(`{}`)}, nil
+	})
+	for index := 0; index < 12; index++ {
+		job := &domain.Job{ID: "job-pool-" + string(rune('a'+index)), Kind: analytics, TenantID: "tenant-a", Status: domain.JobStatusPending}
+		if err := repo.CreateJob(ctx, job); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		_ = local.Enqueue(ctx, domain.QueueMessage{JobID: job.ID, Kind: job.Kind, TenantID: job.TenantID})
+	}
+	go processor.Start(ctx)
+
+	for processor.Concurrency().Busy < 4 && ctx.Err() == nil {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if snapshot := processor.Concurrency(); snapshot.Current != 4 || !snapshot.Adaptive {
+		t.Fatalf("expected the pool to grow to its maximum, got %+v", snapshot)
+	}
+	close(release)
+
+	for ctx.Err() == nil {
+		if snapshot := processor.Concurrency(); snapshot.Current == 1 && snapshot.Busy == 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if snapshot := processor.Concurrency(); snapshot.Current != 1 {
+		t.Fatalf("expected the pool to shrink back once the queue drained, got %+v", snapshot)
+	}
+	if depth, _ := local.Depth(ctx); depth != 0 {
+		t.Fatalf("expected every job to run, %d left", depth)
+	}
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/errorreport"
+)
+
+// UseErrorReporter makes the processor report failed jobs and panics to reporter; the job
+// scope fields of the context travel with every event. Call it before Start.
+func (p *Processor) UseErrorReporter(reporter errorreport.Reporter) {
+	p.reporter = reporter
+}
+
+// reportFailure sends a failed attempt to the error reporter. Released messages and
+// cancellations by shutdown are not failures of the job.
+func (p *Processor) reportFailure(ctx context.Context, message domain.QueueMessage, err error) {
+	if p.reporter == nil || err == nil {
+		return
+	}
+	event := errorreport.Event{
+		Err: err,
+		Tags: map[string]string{
+			"component": "worker",
+			"job_kind":  string(message.Kind),
+			"attempt":   strconv.Itoa(message.Attempt),
+		},
+	}
+	var panicked *panicError
+	if errors.As(err, &panicked) {
+		event.Level, event.Type, event.Stack = errorreport.LevelFatal, "panic", panicked.stack
+	}
+	p.reporter.Report(ctx, event)
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// RetryPolicy spaces the retries of a failed job: the delay doubles from BaseDelay at each
+// attempt, up to MaxDelay, and a random jitter keeps failed jobs from coming back in bursts.
+type RetryPolicy struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy applies to job kinds without a policy of their own.
+var DefaultRetryPolicy = RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+// defaultRetryPolicies wait longer before retrying digests, which are expensive to rebuild.
+var defaultRetryPolicies = map[domain.JobKind]RetryPolicy{
+	domain.JobKindDigest: {BaseDelay: 2 * time.Second, MaxDelay: 2 * time.Minute},
+}
+
+// Delay returns how long to wait after the failed attempt (0 for the first one). jitter,
+// in [0, 1), picks the delay between half and all of the exponential step.
+func (r RetryPolicy) Delay(attempt int, jitter float64) time.Duration {
+	step := r.BaseDelay
+	for i := 0; i < attempt && step < r.MaxDelay; i++ {
+		step *= 2
+	}
+	if step > r.MaxDelay {
+		step = r.MaxDelay
+	}
+	return step/2 + time.Duration(jitter*float64(step/2))
+}
+
+// ParseRetryPolicies reads "kind=base/max" entries, such as "digest=2s/2m". The "default"
+// kind replaces DefaultRetryPolicy.
+func ParseRetryPolicies(entries []string) (map[domain.JobKind]RetryPolicy, error) {
+	policies := make(map[domain.JobKind]RetryPolicy, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, raw, ok := strings.Cut(entry, "=")
+		kind = strings.TrimSpace(kind)
+		rawBase, rawMax, hasMax := strings.Cut(raw, "/")
+		if !ok || kind == "" || !hasMax {
+			return nil, fmt.Errorf("invalid job retry policy %q: expected kind=base/max", entry)
+		}
+		baseDelay, baseErr := time.ParseDuration(strings.TrimSpace(rawBase))
+		maxDelay, maxErr := time.ParseDuration(strings.TrimSpace(rawMax))
+		if baseErr != nil || maxErr != nil || baseDelay <= 0 || maxDelay < baseDelay {
+			return nil, fmt.Errorf("invalid job retry policy for %s: expected positive durations with base <= max, such as 2s/2m", kind)
+		}
+		policies[domain.JobKind(kind)] = RetryPolicy{BaseDelay: baseDelay, MaxDelay: maxDelay}
+	}
+	return policies, nil
+}
+
+// UseRetryPolicies overrides the retry policy of the kinds in policies; other kinds keep
+// theirs.
+func (p *Processor) UseRetryPolicies(policies map[domain.JobKind]RetryPolicy) {
+	p.handlersMu.Lock()
+	defer p.handlersMu.Unlock()
+	for kind, policy := range policies {
+		p.retries[kind] = policy
+	}
+}
+
+// retryDelay is the wait before the queue redelivers a message whose attempt failed.
+func (p *Processor) retryDelay(kind domain.JobKind, attempt int) time.Duration {
+	p.handlersMu.RLock()
+	policy, ok := p.retries[kind]
+	if !ok {
+		policy, ok = p.retries["default"]
+	}
+	p.handlersMu.RUnlock()
+	if !ok {
+		policy = DefaultRetryPolicy
+	}
+	return policy.Delay(attempt, rand.Float64())
+}
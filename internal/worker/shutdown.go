@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// releaseTimeout bounds the wait for cancelled jobs to hand themselves back.
+const releaseTimeout = 5 * time.Second
+
+var errShuttingDown = errors.New("worker shutting down")
+
+// Drain stops taking jobs and waits up to grace for the running ones. Jobs still running
+// then are cancelled, set back to pending and released to the queue, which redelivers
+// them without counting an attempt. It reports whether every job finished in time.
+// Cancel the context given to Start first so the consumer stops fetching.
+func (p *Processor) Drain(grace time.Duration) bool {
+	p.drainMu.Lock()
+	p.draining = true
+	p.drainMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.inFlight.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+	}
+
+	p.abortOnce.Do(func() { close(p.abort) })
+	select {
+	case <-done:
+	case <-time.After(releaseTimeout):
+	}
+	return false
+}
+
+// track counts a job as in flight unless the processor is draining.
+func (p *Processor) track() bool {
+	p.drainMu.Lock()
+	defer p.drainMu.Unlock()
+	if p.draining {
+		return false
+	}
+	p.inFlight.Add(1)
+	return true
+}
+
+func (p *Processor) aborted() bool {
+	select {
+	case <-p.abort:
+		return true
+	default:
+		return false
+	}
+}
+
+// jobContext detaches a job from the consumer context, keeping its values, and cancels it
+// when the grace period ends.
+func (p *Processor) jobContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		select {
+		case <-p.abort:
+			cancel()
+		case <-jobCtx.Done():
+		}
+	}()
+	return jobCtx, cancel
+}
+
+// releaseJob puts an interrupted job back to pending so the status endpoint does not
+// report a failure for a job that will run again.
+func (p *Processor) releaseJob(ctx context.Context, job *domain.Job) {
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+	job.Status = domain.JobStatusPending
+	job.Attempts--
+	job.StartedAt = nil
+	job.UpdatedAt = time.Now().UTC()
+	if err := p.repo.UpdateJob(releaseCtx, job); err != nil && p.logger != nil {
+		p.logger.WarnContext(ctx, "failed to release interrupted job", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+)
+
+// DefaultJobTimeout bounds job kinds without a deadline of their own.
+const DefaultJobTimeout = 60 * time.Second
+
+// ErrJobTimeout is returned when a handler outlives the deadline of its job kind. The
+// queue retries the job like any other failure.
+var ErrJobTimeout = errors.New("job timed out")
+
+// defaultJobTimeouts leave digests, which span many conversations, the most time.
+var defaultJobTimeouts = map[domain.JobKind]time.Duration{
+	domain.JobKindSummary: 30 * time.Second,
+	domain.JobKindReport:  60 * time.Second,
+	domain.JobKindDigest:  90 * time.Second,
+}
+
+// ParseJobTimeouts reads "kind=duration" entries, such as "report=60s".
+func ParseJobTimeouts(entries []string) (map[domain.JobKind]time.Duration, error) {
+	timeouts := make(map[domain.JobKind]time.Duration, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, raw, ok := strings.Cut(entry, "=")
+		kind = strings.TrimSpace(kind)
+		if !ok || kind == "" {
+			return nil, fmt.Errorf("invalid job timeout %q: expected kind=duration", entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid job timeout for %s: expected a positive duration such as 60s", kind)
+		}
+		timeouts[domain.JobKind(kind)] = timeout
+	}
+	return timeouts, nil
+}
+
+// UseTimeouts overrides the deadline of the kinds in timeouts; other kinds keep theirs.
+func (p *Processor) UseTimeouts(timeouts map[domain.JobKind]time.Duration) {
+	p.handlersMu.Lock()
+	defer p.handlersMu.Unlock()
+	for kind, timeout := range timeouts {
+		p.timeouts[kind] = timeout
+	}
+}
+
+func (p *Processor) timeout(kind domain.JobKind) time.Duration {
+	p.handlersMu.RLock()
+	defer p.handlersMu.RUnlock()
+	if timeout, ok := p.timeouts[kind]; ok {
+		return timeout
+	}
+	return DefaultJobTimeout
+}
+
+// jobErrorCode classifies a handler failure for the job status endpoint.
+func jobErrorCode(err error) string {
+	if errors.Is(err, ErrJobTimeout) {
+		return domain.JobErrorTimeout
+	}
+	if errors.Is(err, ErrJobPanic) {
+		return domain.JobErrorPanic
+	}
+	return domain.JobErrorProcessing
+}
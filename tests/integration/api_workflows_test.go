@@ -4,9 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -14,60 +15,165 @@ import (
 	"time"
 
 	"github.com/iago/extensao-whatsapp-back/internal/ai"
+	"github.com/iago/extensao-whatsapp-back/internal/bootstrap"
 	"github.com/iago/extensao-whatsapp-back/internal/cache"
+	"github.com/iago/extensao-whatsapp-back/internal/config"
 	contextbuilder "github.com/iago/extensao-whatsapp-back/internal/context"
+	"github.com/iago/extensao-whatsapp-back/internal/domain"
+	"github.com/iago/extensao-whatsapp-back/internal/health"
 	httpserver "github.com/iago/extensao-whatsapp-back/internal/http"
 	"github.com/iago/extensao-whatsapp-back/internal/http/handlers"
+	"github.com/iago/extensao-whatsapp-back/internal/http/middleware"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
+	"github.com/iago/extensao-whatsapp-back/internal/policy"
+	"github.com/iago/extensao-whatsapp-back/internal/quality"
 	"github.com/iago/extensao-whatsapp-back/internal/queue"
 	"github.com/iago/extensao-whatsapp-back/internal/repository"
 	"github.com/iago/extensao-whatsapp-back/internal/service"
+	"github.com/iago/extensao-whatsapp-back/internal/slo"
 	"github.com/iago/extensao-whatsapp-back/internal/worker"
 )
 
+const integrationAdminToken = "integration-admin-token"
+
 type integrationRuntime struct {
-	server *httptest.Server
-	cancel context.CancelFunc
+	server    *httptest.Server
+	readiness *health.Readiness
+	audit     *repository.MemoryAuditRepository
+	metering  *service.MeteringService
+	cancel    context.CancelFunc
+}
+
+// integrationOptions change the runtime of a test; the zero value runs the fallback path.
+type integrationOptions struct {
+	// Generator answers the model calls; nil leaves the service without a client.
+	Generator ai.TextGenerator
 }
 
 func startIntegrationRuntime(t *testing.T) integrationRuntime {
 	t.Helper()
+	return startIntegrationRuntimeWith(t, integrationOptions{})
+}
+
+func startIntegrationRuntimeWith(t *testing.T, options integrationOptions) integrationRuntime {
+	t.Helper()
 
 	ctx, cancel := context.WithCancel(context.Background())
-	logger := log.New(io.Discard, "", 0)
+	logger := logging.Discard()
 	repo := repository.NewMemoryJobsRepository()
+	messagesRepo := repository.NewMemoryMessagesRepository()
 	localQueue := queue.NewLocalQueue(2048, 3, logger)
 
 	modelRouter := ai.NewModelRouter(ai.ModelRouterConfig{})
-	contextBuilder := contextbuilder.NewBuilder(contextbuilder.NewBasicRetriever())
+	contextBuilder := contextbuilder.NewBuilder(
+		contextbuilder.NewHistoryRetriever(messagesRepo, contextbuilder.NewBasicRetriever()),
+	)
 	semanticCache := cache.NewSemanticCache(cache.Config{
 		TTL:        10 * time.Minute,
 		MaxEntries: 4000,
 	})
+	registry := metrics.NewRegistry()
+	recentReplies := quality.NewRecentReplies(5)
+	tenantSettings := service.NewTenantSettingsService(repository.NewMemoryTenantSettingsRepository(), time.Minute)
+	metering := service.NewMeteringService(repository.NewMemoryUsageRepository(), logger)
 	aiGeneration := service.NewAIGenerationService(service.AIGenerationDependencies{
-		Router:  modelRouter,
-		Client:  nil, // fallback path for deterministic local integration tests.
-		Builder: contextBuilder,
-		Cache:   semanticCache,
-		Logger:  logger,
+		Router:         modelRouter,
+		Client:         options.Generator, // nil keeps the deterministic fallback path.
+		Builder:        contextBuilder,
+		Cache:          semanticCache,
+		QualityMetrics: quality.NewMetrics(registry),
+		AIMetrics:      ai.NewMetrics(registry),
+		CacheMetrics:   cache.NewMetrics(registry, semanticCache),
+		RecentReplies:  recentReplies,
+		TenantSettings: tenantSettings,
+		Metering:       metering,
+		PromptsDir:     "../../prompts",
+		Logger:         logger,
 	})
 
 	jobsService := service.NewJobsService(repo, localQueue)
+	jobsService.RequireApproval([]string{"tenant-regulated"})
+	jobsService.UseTenantSettings(tenantSettings)
 	suggestionsService := service.NewSuggestionsService(aiGeneration)
-	api := handlers.NewAPI(jobsService, suggestionsService)
+	hitlService := service.NewHITLService(repository.NewMemoryHITLRepository(), repo)
+	hitlService.UseRecentReplies(recentReplies)
+	readiness := health.NewReadiness()
+	processor := worker.NewProcessor(localQueue, repo, aiGeneration, logger)
+	processor.UseProducer(localQueue)
+	processor.UseMetrics(worker.NewMetrics(registry))
+	rateLimiter := middleware.NewRateLimiter(20000, 20000)
+	ipRateLimiter := middleware.NewIPRateLimiter(20000, 20000)
+	reloader := config.NewReloader(logger)
+	bootstrap.HandleRateLimitReload(reloader, rateLimiter, ipRateLimiter)
+	auditRepo := repository.NewMemoryAuditRepository()
+	pseudonymsRepo := repository.NewMemoryPseudonymsRepository()
+	idempotencyRepo := repository.NewMemoryIdempotencyRepository()
+	pseudonymsService := service.NewPseudonymsService(pseudonymsRepo)
+	apiKeysService := service.NewAPIKeysService(repository.NewMemoryAPIKeysRepository(), auditRepo)
+	policyViolationsService := service.NewPolicyViolationsService(repository.NewMemoryPolicyViolationsRepository())
+	policyViolationsService.UseMetrics(policy.NewMetrics(registry))
+	queue.RegisterDepthGauge(registry, localQueue)
+	sloTracker := slo.NewTracker(slo.DefaultObjectives(), slo.DefaultWindow)
+	slo.RegisterMetrics(registry, sloTracker)
+	api := handlers.NewAPI(handlers.APIDependencies{
+		Jobs:             jobsService,
+		Suggestions:      suggestionsService,
+		Erasure:          service.NewErasureService(repo, messagesRepo, pseudonymsRepo, idempotencyRepo, auditRepo, aiGeneration),
+		Analysis:         service.NewAnalysisService(aiGeneration),
+		Questions:        service.NewQuestionsService(aiGeneration),
+		ActionItems:      service.NewActionItemsService(aiGeneration),
+		Compose:          service.NewComposeService(aiGeneration),
+		Digests:          service.NewDigestsService(jobsService, messagesRepo),
+		Insights:         service.NewInsightsService(aiGeneration, messagesRepo),
+		Messages:         service.NewMessagesService(messagesRepo, contextBuilder, pseudonymsService),
+		Pseudonyms:       pseudonymsService,
+		PolicyViolations: policyViolationsService,
+		HITL:             hitlService,
+		Templates:        service.NewTemplatesService(repository.NewMemoryTemplatesRepository()),
+		TenantSettings:   tenantSettings,
+		Metering:         metering,
+		Health: health.NewChecker(health.CheckerConfig{},
+			health.Disabled("postgres", "in-memory repository"),
+			health.QueueDepth("queue", localQueue.Depth, 1000),
+		),
+		Readiness: readiness,
+		Admin: handlers.AdminDependencies{
+			Cache:         semanticCache,
+			Config:        map[string]any{"auth_token": "[redacted]", "port": "8080"},
+			ConfigSources: map[string]string{"API_AUTH_TOKEN": "env", "PORT": "default"},
+			RateLimits:    rateLimiter,
+			Worker:        processor,
+			APIKeys:       apiKeysService,
+			Reloader:      reloader,
+			SLO:           sloTracker,
+			LogLevels:     logging.NewLevels(slog.LevelInfo),
+		},
+	})
 	router := httpserver.NewRouter(httpserver.RouterDependencies{
 		API:            api,
 		Logger:         logger,
 		AuthToken:      "",
-		RateLimitRPS:   20000,
-		RateLimitBurst: 20000,
+		APIKeys:        apiKeysService,
+		AdminToken:     integrationAdminToken,
+		RateLimiter:    rateLimiter,
+		IPRateLimiter:  ipRateLimiter,
+		Idempotency:    middleware.IdempotencyConfig{Store: idempotencyRepo},
+		Audit:          auditRepo,
+		SLO:            sloTracker,
+		Metrics:        middleware.NewHTTPMetrics(registry),
+		MetricsHandler: registry.Handler(),
 	})
 
-	processor := worker.NewProcessor(localQueue, repo, aiGeneration, logger)
 	go processor.Start(ctx)
 
 	server := httptest.NewServer(router)
+	readiness.MarkReady()
 	return integrationRuntime{
-		server: server,
+		server:    server,
+		readiness: readiness,
+		audit:     auditRepo,
+		metering:  metering,
 		cancel: func() {
 			cancel()
 			server.Close()
@@ -83,12 +189,24 @@ func postJSON(
 	headers map[string]string,
 ) (int, map[string]any) {
 	t.Helper()
+	return sendJSON(t, client, http.MethodPost, url, payload, headers)
+}
+
+func sendJSON(
+	t *testing.T,
+	client *http.Client,
+	method string,
+	url string,
+	payload any,
+	headers map[string]string,
+) (int, map[string]any) {
+	t.Helper()
 
 	encoded, err := json.Marshal(payload)
 	if err != nil {
 		t.Fatalf("marshal payload: %v", err)
 	}
-	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(encoded))
+	request, err := http.NewRequest(method, url, bytes.NewReader(encoded))
 	if err != nil {
 		t.Fatalf("build request: %v", err)
 	}
@@ -97,6 +215,7 @@ func postJSON(
 	for key, value := range headers {
 		request.Header.Set(key, value)
 	}
+	setTenantHeader(request, encoded)
 
 	response, err := client.Do(request)
 	if err != nil {
@@ -117,13 +236,59 @@ func postJSON(
 	return response.StatusCode, decoded
 }
 
+// setTenantHeader announces the tenant of a /v1 or /v2 request that neither sets
+// X-Tenant-ID nor carries an API key or token naming its tenant, as the extension does: the
+// tenant_id query parameter, then the tenant_id of the payload or its conversation, then
+// "default".
+func setTenantHeader(request *http.Request, payload []byte) {
+	path := request.URL.Path
+	if request.Header.Get(middleware.TenantHeader) != "" || request.Header.Get("X-API-Key") != "" ||
+		request.Header.Get("Authorization") != "" ||
+		!(strings.HasPrefix(path, "/v1/") || strings.HasPrefix(path, "/v2/")) {
+		return
+	}
+	tenantID := request.URL.Query().Get("tenant_id")
+	if tenantID == "" && len(payload) > 0 {
+		var body struct {
+			TenantID     string `json:"tenant_id"`
+			Conversation struct {
+				TenantID string `json:"tenant_id"`
+			} `json:"conversation"`
+		}
+		if json.Unmarshal(payload, &body) == nil {
+			tenantID = body.TenantID
+			if tenantID == "" {
+				tenantID = body.Conversation.TenantID
+			}
+		}
+	}
+	if tenantID == "" {
+		tenantID = "default"
+	}
+	request.Header.Set(middleware.TenantHeader, tenantID)
+}
+
 func getJSON(t *testing.T, client *http.Client, url string) (int, map[string]any) {
+	t.Helper()
+	return getJSONWithHeaders(t, client, url, nil)
+}
+
+func getJSONWithHeaders(
+	t *testing.T,
+	client *http.Client,
+	url string,
+	headers map[string]string,
+) (int, map[string]any) {
 	t.Helper()
 	request, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		t.Fatalf("build get request: %v", err)
 	}
 	request.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	setTenantHeader(request, nil)
 
 	response, err := client.Do(request)
 	if err != nil {
@@ -152,10 +317,24 @@ func waitForJobDone(
 	timeout time.Duration,
 ) map[string]any {
 	t.Helper()
+	return waitForTenantJobDone(t, client, baseURL, "default", jobID, timeout)
+}
+
+// waitForTenantJobDone polls a job of tenantID; jobs of other tenants are not visible.
+func waitForTenantJobDone(
+	t *testing.T,
+	client *http.Client,
+	baseURL string,
+	tenantID string,
+	jobID string,
+	timeout time.Duration,
+) map[string]any {
+	t.Helper()
 
+	headers := map[string]string{middleware.TenantHeader: tenantID}
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
-		status, body := getJSON(t, client, fmt.Sprintf("%s/v1/jobs/%s", baseURL, jobID))
+		status, body := getJSONWithHeaders(t, client, fmt.Sprintf("%s/v1/jobs/%s", baseURL, jobID), headers)
 		if status != http.StatusOK {
 			time.Sleep(20 * time.Millisecond)
 			continue
@@ -236,6 +415,7 @@ func TestCriticalFlowsSummarySuggestionReport(t *testing.T) {
 		summaryPayload,
 		map[string]string{
 			"Idempotency-Key": "summary-e2e-flow-0001",
+			"X-Request-Id":    "req-summary-e2e",
 		},
 	)
 	if summaryStatus != http.StatusAccepted {
@@ -247,6 +427,9 @@ func TestCriticalFlowsSummarySuggestionReport(t *testing.T) {
 	}
 
 	summaryJob := waitForJobDone(t, client, baseURL, summaryJobID, 4*time.Second)
+	if summaryJob["request_id"] != "req-summary-e2e" {
+		t.Fatalf("expected the job to keep the creating request id, got %+v", summaryJob["request_id"])
+	}
 	summaryResult, ok := summaryJob["result"].(map[string]any)
 	if !ok {
 		t.Fatalf("expected summary result payload in job status, got %+v", summaryJob)
@@ -311,6 +494,43 @@ func TestCriticalFlowsSummarySuggestionReport(t *testing.T) {
 	if !ok || len(items) == 0 {
 		t.Fatalf("expected non-empty report list items, got %+v", listBody)
 	}
+
+	_, searchBody := getJSON(t, client, baseURL+"/v1/reports?tenant_id=default&q=relatorio")
+	if searchItems, _ := searchBody["items"].([]any); len(searchItems) != 1 {
+		t.Fatalf("expected report matching full-text query, got %+v", searchBody)
+	}
+	_, missBody := getJSON(t, client, baseURL+"/v1/reports?tenant_id=default&q=reembolso")
+	if missItems, _ := missBody["items"].([]any); len(missItems) != 0 {
+		t.Fatalf("expected no report matching unrelated query, got %+v", missBody)
+	}
+
+	detailStatus, detailBody := getJSON(t, client, baseURL+"/v1/reports/"+reportJobID+"?tenant_id=default")
+	if detailStatus != http.StatusOK {
+		t.Fatalf("expected 200 from report detail, got %d body=%+v", detailStatus, detailBody)
+	}
+	if detailSections, _ := detailBody["sections"].([]any); len(detailSections) == 0 {
+		t.Fatalf("expected report sections in detail: %+v", detailBody)
+	}
+	if _, ok := detailBody["metadata"].(map[string]any); !ok {
+		t.Fatalf("expected report metadata in detail: %+v", detailBody)
+	}
+	foreignStatus, _ := getJSON(t, client, baseURL+"/v1/reports/"+reportJobID+"?tenant_id=other")
+	if foreignStatus != http.StatusNotFound {
+		t.Fatalf("expected 404 for report of another tenant, got %d", foreignStatus)
+	}
+	for _, headers := range []map[string]string{
+		{middleware.TenantHeader: "default"},
+		{middleware.TenantHeader: "other"},
+	} {
+		status, body := getJSONWithHeaders(t, client, baseURL+"/v1/reports/"+reportJobID+"?tenant_id=other", headers)
+		if status != http.StatusNotFound {
+			t.Fatalf("expected 404 for report outside the scope %v, got %d body=%+v", headers, status, body)
+		}
+	}
+	missingStatus, _ := getJSON(t, client, baseURL+"/v1/reports/00000000-0000-0000-0000-000000000000")
+	if missingStatus != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown report, got %d", missingStatus)
+	}
 }
 
 func TestPolicyBlocksAndHITLMetadata(t *testing.T) {
@@ -411,3 +631,2283 @@ func TestPolicyBlocksAndHITLMetadata(t *testing.T) {
 		t.Fatalf("expected allowed_actions to include copy, got %+v", allowedActions)
 	}
 }
+
+func deleteJSON(t *testing.T, client *http.Client, url string) (int, map[string]any) {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		t.Fatalf("build delete request: %v", err)
+	}
+	request.Header.Set("Accept", "application/json")
+	setTenantHeader(request, nil)
+
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("execute delete request: %v", err)
+	}
+	defer response.Body.Close()
+
+	raw, _ := io.ReadAll(response.Body)
+	if len(raw) == 0 {
+		return response.StatusCode, map[string]any{}
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("decode delete response body (%d): %s", response.StatusCode, string(raw))
+	}
+
+	return response.StatusCode, decoded
+}
+
+func TestConversationDataErasureRemovesJobs(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	summaryStatus, summaryBody := postJSON(
+		t,
+		client,
+		baseURL+"/v1/summaries",
+		map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "default",
+				"conversation_id": "chat-erase-1",
+				"channel":         "whatsapp_web",
+			},
+			"summary_type": "short",
+		},
+		map[string]string{
+			"Idempotency-Key": "summary-erase-flow-0001",
+		},
+	)
+	if summaryStatus != http.StatusAccepted {
+		t.Fatalf("expected 202 from summaries, got %d body=%+v", summaryStatus, summaryBody)
+	}
+	jobID, _ := summaryBody["job_id"].(string)
+	waitForJobDone(t, client, baseURL, jobID, 4*time.Second)
+
+	missingTenantStatus, _ := deleteJSON(t, client, baseURL+"/v1/conversations/chat-erase-1/data")
+	if missingTenantStatus != http.StatusBadRequest {
+		t.Fatalf("expected 400 without tenant_id, got %d", missingTenantStatus)
+	}
+
+	eraseStatus, eraseBody := deleteJSON(t, client, baseURL+"/v1/conversations/chat-erase-1/data?tenant_id=default")
+	if eraseStatus != http.StatusOK {
+		t.Fatalf("expected 200 from erasure, got %d body=%+v", eraseStatus, eraseBody)
+	}
+	if deleted, _ := eraseBody["deleted_jobs"].(float64); deleted != 1 {
+		t.Fatalf("expected one deleted job, got %+v", eraseBody)
+	}
+	if deleted, _ := eraseBody["deleted_idempotency_keys"].(float64); deleted != 1 {
+		t.Fatalf("expected the cached summary response to be erased, got %+v", eraseBody)
+	}
+
+	jobStatus, _ := getJSON(t, client, baseURL+"/v1/jobs/"+jobID)
+	if jobStatus != http.StatusNotFound {
+		t.Fatalf("expected erased job to return 404, got %d", jobStatus)
+	}
+}
+
+func TestJobStatsAggregatesByKindAndStatus(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	summaryStatus, summaryBody := postJSON(
+		t,
+		client,
+		baseURL+"/v1/summaries",
+		map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "tenant-stats",
+				"conversation_id": "chat-stats-1",
+				"channel":         "whatsapp_web",
+			},
+			"summary_type": "short",
+		},
+		map[string]string{
+			"Idempotency-Key": "summary-stats-flow-0001",
+		},
+	)
+	if summaryStatus != http.StatusAccepted {
+		t.Fatalf("expected 202 from summaries, got %d body=%+v", summaryStatus, summaryBody)
+	}
+	jobID, _ := summaryBody["job_id"].(string)
+	waitForTenantJobDone(t, client, baseURL, "tenant-stats", jobID, 4*time.Second)
+
+	listStatus, listBody := getJSON(t, client, baseURL+"/v1/summaries?tenant_id=tenant-stats&conversation_id=chat-stats-1")
+	if listStatus != http.StatusOK {
+		t.Fatalf("expected 200 from summary list, got %d body=%+v", listStatus, listBody)
+	}
+	summaryItems, _ := listBody["items"].([]any)
+	if len(summaryItems) != 1 {
+		t.Fatalf("expected one listed summary, got %+v", listBody)
+	}
+	if item, _ := summaryItems[0].(map[string]any); item["summary_id"] != jobID || item["preview"] == "" {
+		t.Fatalf("unexpected summary list item: %+v", item)
+	}
+
+	missingTenantStatus, _ := getJSON(t, client, baseURL+"/v1/stats/jobs")
+	if missingTenantStatus != http.StatusBadRequest {
+		t.Fatalf("expected 400 without tenant_id, got %d", missingTenantStatus)
+	}
+
+	statsStatus, statsBody := getJSON(t, client, baseURL+"/v1/stats/jobs?tenant_id=tenant-stats")
+	if statsStatus != http.StatusOK {
+		t.Fatalf("expected 200 from stats, got %d body=%+v", statsStatus, statsBody)
+	}
+	totals, _ := statsBody["totals"].(map[string]any)
+	if jobs, _ := totals["jobs"].(float64); jobs != 1 {
+		t.Fatalf("expected one job in totals, got %+v", statsBody)
+	}
+	items, _ := statsBody["items"].([]any)
+	if len(items) != 1 {
+		t.Fatalf("expected one stats bucket, got %+v", statsBody)
+	}
+	bucket, _ := items[0].(map[string]any)
+	if bucket["kind"] != "summary" || bucket["status"] != "done" {
+		t.Fatalf("unexpected stats bucket: %+v", bucket)
+	}
+}
+
+func TestSummaryListPaginatesAndFilters(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	enqueue := func(tenantID, conversationID, key string) {
+		status, body := postJSON(t, client, baseURL+"/v1/summaries", map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       tenantID,
+				"conversation_id": conversationID,
+				"channel":         "whatsapp_web",
+			},
+			"summary_type": "short",
+		}, map[string]string{"Idempotency-Key": key})
+		if status != http.StatusAccepted {
+			t.Fatalf("expected 202 from summaries, got %d body=%+v", status, body)
+		}
+	}
+	enqueue("tenant-list", "chat-list-1", "summary-list-flow-0001")
+	enqueue("tenant-list", "chat-list-1", "summary-list-flow-0002")
+	enqueue("tenant-list", "chat-list-2", "summary-list-flow-0003")
+	enqueue("default", "chat-list-1", "summary-list-flow-0004")
+
+	list := func(query string) map[string]any {
+		t.Helper()
+		status, body := getJSON(t, client, baseURL+"/v1/summaries?tenant_id=tenant-list"+query)
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 from summary list %q, got %d body=%+v", query, status, body)
+		}
+		return body
+	}
+	expectPage := func(query string, items int, total float64, hasNext bool) map[string]any {
+		t.Helper()
+		body := list(query)
+		listed, _ := body["items"].([]any)
+		if len(listed) != items || body["total"] != total || body["has_next"] != hasNext {
+			t.Fatalf("%q: expected %d items of %v with has_next=%v, got %+v", query, items, total, hasNext, body)
+		}
+		return body
+	}
+
+	first := expectPage("&page=1&page_size=2", 2, 3, true)
+	if first["page"] != float64(1) || first["page_size"] != float64(2) {
+		t.Fatalf("expected the requested page echoed, got %+v", first)
+	}
+	expectPage("&page=2&page_size=2", 1, 3, false)
+	expectPage("&page=3&page_size=2", 0, 3, false)
+	if body := list("&page_size=500"); body["page_size"] != float64(20) {
+		t.Fatalf("expected an oversized page_size to fall back to 20, got %+v", body)
+	}
+
+	byConversation := expectPage("&conversation_id=chat-list-1", 2, 2, false)
+	for _, raw := range byConversation["items"].([]any) {
+		if item, _ := raw.(map[string]any); item["conversation_id"] != "chat-list-1" {
+			t.Fatalf("expected only chat-list-1 summaries, got %+v", item)
+		}
+	}
+	expectPage("&conversation_id=chat-list-9", 0, 0, false)
+
+	now := time.Now().UTC()
+	hourAgo := now.Add(-time.Hour).Format(time.RFC3339)
+	inAnHour := now.Add(time.Hour).Format(time.RFC3339)
+	expectPage("&from="+hourAgo+"&to="+inAnHour, 3, 3, false)
+	expectPage("&from="+inAnHour, 0, 0, false)
+	expectPage("&to="+hourAgo, 0, 0, false)
+	expectPage("&from="+hourAgo+"&conversation_id=chat-list-2", 1, 1, false)
+
+	for _, query := range []string{"&from=yesterday", "&to=2026-13-01T00:00:00Z", "&from=2026-03-01"} {
+		status, body := getJSON(t, client, baseURL+"/v1/summaries?tenant_id=tenant-list"+query)
+		if status != http.StatusBadRequest {
+			t.Fatalf("expected 400 for invalid date %q, got %d body=%+v", query, status, body)
+		}
+	}
+
+	status, body := getJSON(t, client, baseURL+"/v1/summaries")
+	envelope, _ := body["error"].(map[string]any)
+	if status != http.StatusBadRequest || envelope["message"] != "tenant_id is required" {
+		t.Fatalf("expected 400 without tenant_id, got %d body=%+v", status, body)
+	}
+}
+
+func TestTenantScopeIsolatesJobs(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	payload := map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "tenant-a",
+			"conversation_id": "chat-scope-1",
+			"channel":         "whatsapp_web",
+		},
+		"summary_type": "short",
+	}
+
+	mismatchStatus, _ := postJSON(t, client, baseURL+"/v1/summaries", payload, map[string]string{
+		"Idempotency-Key": "summary-scope-flow-0000",
+		"X-Tenant-ID":     "tenant-b",
+	})
+	if mismatchStatus != http.StatusForbidden {
+		t.Fatalf("expected 403 for tenant mismatch, got %d", mismatchStatus)
+	}
+
+	summaryStatus, summaryBody := postJSON(t, client, baseURL+"/v1/summaries", payload, map[string]string{
+		"Idempotency-Key": "summary-scope-flow-0001",
+		"X-Tenant-ID":     "tenant-a",
+	})
+	if summaryStatus != http.StatusAccepted {
+		t.Fatalf("expected 202 from summaries, got %d body=%+v", summaryStatus, summaryBody)
+	}
+	jobID, _ := summaryBody["job_id"].(string)
+	waitForTenantJobDone(t, client, baseURL, "tenant-a", jobID, 4*time.Second)
+
+	ownStatus, _ := getJSONWithHeaders(t, client, baseURL+"/v1/jobs/"+jobID, map[string]string{"X-Tenant-ID": "tenant-a"})
+	if ownStatus != http.StatusOK {
+		t.Fatalf("expected owner tenant to read job, got %d", ownStatus)
+	}
+	otherStatus, _ := getJSONWithHeaders(t, client, baseURL+"/v1/jobs/"+jobID, map[string]string{"X-Tenant-ID": "tenant-b"})
+	if otherStatus != http.StatusNotFound {
+		t.Fatalf("expected other tenant to get 404, got %d", otherStatus)
+	}
+}
+
+func TestAnalysisReturnsClassification(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	status, body := postJSON(
+		t,
+		client,
+		baseURL+"/v1/analysis",
+		map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "default",
+				"conversation_id": "chat-analysis-1",
+				"channel":         "whatsapp_web",
+			},
+			"locale":         "pt-BR",
+			"context_window": 10,
+			"messages":       []string{"Quero cancelar minha assinatura agora"},
+		},
+		nil,
+	)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from analysis, got %d body=%+v", status, body)
+	}
+	for _, field := range []string{"sentiment", "urgency", "intent"} {
+		if value, _ := body[field].(string); strings.TrimSpace(value) == "" {
+			t.Fatalf("expected %s in analysis response: %+v", field, body)
+		}
+	}
+	if _, ok := body["labels"].([]any); !ok {
+		t.Fatalf("expected labels array in analysis response: %+v", body)
+	}
+}
+
+func TestMessageIngestionDeduplicatesAndIsErased(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	url := baseURL + "/v1/conversations/chat-history-1/messages"
+
+	batch := map[string]any{
+		"tenant_id": "default",
+		"messages": []map[string]any{
+			{"message_id": "wa-1", "author_role": "customer", "text": "Oi, meu pedido atrasou", "sent_at": "2026-03-01T10:00:00Z"},
+			{"message_id": "wa-2", "author_role": "agent", "text": "Vou verificar agora", "sent_at": "2026-03-01T10:01:00Z"},
+			{"message_id": "wa-2", "author_role": "agent", "text": "Vou verificar agora", "sent_at": "2026-03-01T10:01:00Z"},
+		},
+	}
+
+	status, body := postJSON(t, client, url, batch, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from ingestion, got %d body=%+v", status, body)
+	}
+	if accepted, _ := body["accepted"].(float64); accepted != 2 {
+		t.Fatalf("expected two accepted messages, got %+v", body)
+	}
+
+	status, body = postJSON(t, client, url, batch, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from repeated ingestion, got %d body=%+v", status, body)
+	}
+	if accepted, _ := body["accepted"].(float64); accepted != 0 {
+		t.Fatalf("expected repeated batch to be deduplicated, got %+v", body)
+	}
+
+	invalidStatus, _ := postJSON(t, client, url, map[string]any{
+		"tenant_id": "default",
+		"messages":  []map[string]any{{"message_id": "wa-3", "author_role": "bot", "text": "x", "sent_at": "2026-03-01T10:02:00Z"}},
+	}, nil)
+	if invalidStatus != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid author_role, got %d", invalidStatus)
+	}
+
+	eraseStatus, eraseBody := deleteJSON(t, client, baseURL+"/v1/conversations/chat-history-1/data?tenant_id=default")
+	if eraseStatus != http.StatusOK {
+		t.Fatalf("expected 200 from erasure, got %d body=%+v", eraseStatus, eraseBody)
+	}
+	if deleted, _ := eraseBody["deleted_messages"].(float64); deleted != 2 {
+		t.Fatalf("expected two erased messages, got %+v", eraseBody)
+	}
+}
+
+func TestV2SuggestionsAcceptStructuredMessages(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	payload := map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-v2-1",
+			"channel":         "whatsapp_web",
+		},
+		"locale":         "pt-BR",
+		"tone":           "neutro",
+		"context_window": 10,
+		"messages": []map[string]any{
+			{"author": "customer", "timestamp": "2026-03-01T10:00:00Z", "type": "text", "text": "Meu pedido ainda nao chegou"},
+			{"author": "customer", "timestamp": "2026-03-01T10:00:30Z", "type": "image"},
+			{"author": "agent", "timestamp": "2026-03-01T10:01:00-03:00", "text": "Vou verificar o rastreio"},
+		},
+	}
+	status, body := postJSON(t, client, baseURL+"/v2/suggestions", payload, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from v2 suggestions, got %d body=%+v", status, body)
+	}
+	if suggestions, ok := body["suggestions"].([]any); !ok || len(suggestions) == 0 {
+		t.Fatalf("expected non-empty suggestions payload, got %+v", body)
+	}
+
+	payload["messages"] = []map[string]any{
+		{"author": "bot", "timestamp": "2026-03-01T10:00:00Z", "text": "Oi"},
+	}
+	status, body = postJSON(t, client, baseURL+"/v2/suggestions", payload, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown author, got %d body=%+v", status, body)
+	}
+
+	payload["messages"] = []string{"Mensagem livre"}
+	status, _ = postJSON(t, client, baseURL+"/v2/suggestions", payload, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for free-form messages on v2, got %d", status)
+	}
+}
+
+func TestLocaleFallsBackToAcceptLanguage(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	payload := map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-locale-1",
+			"channel":         "whatsapp_web",
+		},
+		"tone":           "neutro",
+		"context_window": 10,
+		"messages":       []string{"Where is my order?"},
+	}
+	cases := []struct {
+		name           string
+		locale         string
+		acceptLanguage string
+		expected       string
+	}{
+		{name: "omitted", acceptLanguage: "fr-FR, en-GB;q=0.9, pt;q=0.8", expected: "en-GB"},
+		{name: "invalid", locale: "klingon", acceptLanguage: "de;q=0.2, pt-PT;q=0.5", expected: "pt-PT"},
+		{name: "language only", locale: "en", acceptLanguage: "pt-BR", expected: "en-US"},
+		{name: "no header", expected: "pt-BR"},
+	}
+	for _, tc := range cases {
+		if tc.locale != "" {
+			payload["locale"] = tc.locale
+		} else {
+			delete(payload, "locale")
+		}
+		headers := map[string]string{}
+		if tc.acceptLanguage != "" {
+			headers["Accept-Language"] = tc.acceptLanguage
+		}
+		status, body := postJSON(t, client, baseURL+"/v1/suggestions", payload, headers)
+		if status != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d body=%+v", tc.name, status, body)
+		}
+		if locale, _ := body["locale"].(string); locale != tc.expected {
+			t.Fatalf("%s: expected locale %s, got %q", tc.name, tc.expected, locale)
+		}
+	}
+}
+
+func TestJobPollingHonorsIfNoneMatch(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	status, body := postJSON(t, client, baseURL+"/v1/summaries", map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-etag-1",
+			"channel":         "whatsapp_web",
+		},
+		"summary_type": "short",
+	}, map[string]string{"Idempotency-Key": "summary-etag-flow-0001"})
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202 from summaries, got %d body=%+v", status, body)
+	}
+	jobID, _ := body["job_id"].(string)
+	waitForJobDone(t, client, baseURL, jobID, 4*time.Second)
+
+	poll := func(ifNoneMatch string) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, baseURL+"/v1/jobs/"+jobID, nil)
+		if err != nil {
+			t.Fatalf("build poll request: %v", err)
+		}
+		request.Header.Set(middleware.TenantHeader, "default")
+		if ifNoneMatch != "" {
+			request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatalf("execute poll request: %v", err)
+		}
+		return response
+	}
+
+	first := poll("")
+	first.Body.Close()
+	etag := first.Header.Get("ETag")
+	if first.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with ETag, got %d etag=%q", first.StatusCode, etag)
+	}
+
+	second := poll(etag)
+	raw, _ := io.ReadAll(second.Body)
+	second.Body.Close()
+	if second.StatusCode != http.StatusNotModified || len(raw) != 0 {
+		t.Fatalf("expected empty 304 for matching ETag, got %d body=%q", second.StatusCode, string(raw))
+	}
+
+	third := poll(`"stale"`)
+	third.Body.Close()
+	if third.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for stale ETag, got %d", third.StatusCode)
+	}
+}
+
+func TestJobLongPollWaitsForTerminalStatus(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	status, body := postJSON(t, client, baseURL+"/v1/summaries", map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-wait-1",
+			"channel":         "whatsapp_web",
+		},
+		"summary_type": "short",
+	}, map[string]string{"Idempotency-Key": "summary-wait-flow-0001"})
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202 from summaries, got %d body=%+v", status, body)
+	}
+	jobID, _ := body["job_id"].(string)
+
+	status, body = getJSON(t, client, baseURL+"/v1/jobs/"+jobID+"?wait=5s")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from long poll, got %d body=%+v", status, body)
+	}
+	if jobStatus, _ := body["status"].(string); jobStatus != "done" {
+		t.Fatalf("expected long poll to return the terminal status, got %+v", body)
+	}
+	for _, field := range []string{"created_at", "started_at", "finished_at", "model_id"} {
+		if value, _ := body[field].(string); value == "" {
+			t.Fatalf("expected %s in job status, got %+v", field, body)
+		}
+	}
+	if attempts, _ := body["attempts"].(float64); attempts != 1 {
+		t.Fatalf("expected one attempt, got %+v", body["attempts"])
+	}
+	if progress, _ := body["progress"].(float64); progress != 1 {
+		t.Fatalf("expected progress 1 for a finished job, got %+v", body["progress"])
+	}
+	if degraded, _ := body["degraded"].(bool); !degraded {
+		t.Fatalf("expected degraded flag without a model client, got %+v", body)
+	}
+	if _, ok := body["usage"].(map[string]any); !ok {
+		t.Fatalf("expected usage in job status, got %+v", body)
+	}
+
+	invalidStatus, _ := getJSON(t, client, baseURL+"/v1/jobs/"+jobID+"?wait=2m")
+	if invalidStatus != http.StatusBadRequest {
+		t.Fatalf("expected 400 for wait above the limit, got %d", invalidStatus)
+	}
+}
+
+func TestValidationErrorsListEveryInvalidField(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	status, body := postJSON(t, runtime.server.Client(), runtime.server.URL+"/v1/suggestions", map[string]any{
+		"conversation": map[string]any{
+			"tenant_id": "default",
+			"channel":   "whatsapp_web",
+		},
+		"locale":         "pt-BR",
+		"tone":           "sarcastico",
+		"context_window": 2,
+	}, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid suggestion request, got %d body=%+v", status, body)
+	}
+
+	items, _ := body["errors"].([]any)
+	fields := make(map[string]string, len(items))
+	for _, item := range items {
+		entry, _ := item.(map[string]any)
+		field, _ := entry["field"].(string)
+		code, _ := entry["code"].(string)
+		fields[field] = code
+	}
+	expected := map[string]string{
+		"conversation.conversation_id": "required",
+		"tone":                         "invalid_value",
+		"context_window":               "out_of_range",
+	}
+	for field, code := range expected {
+		if fields[field] != code {
+			t.Fatalf("expected %s=%s in field errors, got %+v", field, code, body["errors"])
+		}
+	}
+	if len(fields) != len(expected) {
+		t.Fatalf("expected exactly %d field errors, got %+v", len(expected), body["errors"])
+	}
+}
+
+func TestHITLDecisionsAreRecorded(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	status, body := postJSON(t, client, baseURL+"/v1/summaries", map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-hitl-1",
+			"channel":         "whatsapp_web",
+		},
+		"summary_type": "short",
+	}, map[string]string{"Idempotency-Key": "summary-hitl-flow-0001"})
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202 from summaries, got %d body=%+v", status, body)
+	}
+	jobID, _ := body["job_id"].(string)
+	waitForJobDone(t, client, baseURL, jobID, 4*time.Second)
+
+	url := baseURL + "/v1/hitl/decisions"
+	status, body = postJSON(t, client, url, map[string]any{
+		"tenant_id":       "default",
+		"conversation_id": "chat-hitl-1",
+		"job_id":          jobID,
+		"decision":        "approved",
+		"reviewer_id":     "agent-7",
+	}, nil)
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 for job decision, got %d body=%+v", status, body)
+	}
+	if decisionID, _ := body["decision_id"].(string); decisionID == "" {
+		t.Fatalf("expected decision_id in response, got %+v", body)
+	}
+
+	status, body = postJSON(t, client, url, map[string]any{
+		"tenant_id":             "default",
+		"conversation_id":       "chat-hitl-1",
+		"suggestion_request_id": "req-123",
+		"suggestion_rank":       2,
+		"decision":              "edited",
+		"reviewer_id":           "agent-7",
+		"final_text":            "Ola! Seu pedido chega amanha.",
+	}, nil)
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 for suggestion decision, got %d body=%+v", status, body)
+	}
+
+	status, _ = postJSON(t, client, url, map[string]any{
+		"tenant_id":             "default",
+		"conversation_id":       "chat-hitl-1",
+		"suggestion_request_id": "req-123",
+		"decision":              "edited",
+		"reviewer_id":           "agent-7",
+	}, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for edited decision without final_text, got %d", status)
+	}
+
+	status, _ = postJSON(t, client, url, map[string]any{
+		"tenant_id":       "default",
+		"conversation_id": "chat-other",
+		"job_id":          jobID,
+		"decision":        "approved",
+		"reviewer_id":     "agent-7",
+	}, nil)
+	if status != http.StatusNotFound {
+		t.Fatalf("expected 404 for job from another conversation, got %d", status)
+	}
+}
+
+func TestRegulatedTenantJobsWaitForApproval(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	enqueue := func(conversationID, key string) string {
+		status, body := postJSON(t, client, baseURL+"/v1/summaries", map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "tenant-regulated",
+				"conversation_id": conversationID,
+				"channel":         "whatsapp_web",
+			},
+			"summary_type": "short",
+		}, map[string]string{"Idempotency-Key": key})
+		if status != http.StatusAccepted {
+			t.Fatalf("expected 202 from summaries, got %d body=%+v", status, body)
+		}
+		jobID, _ := body["job_id"].(string)
+		return jobID
+	}
+
+	jobID := enqueue("chat-approval-1", "summary-approval-flow-0001")
+	done := waitForTenantJobDone(t, client, baseURL, "tenant-regulated", jobID, 4*time.Second)
+	if done["approval"] != "pending" {
+		t.Fatalf("expected regulated job to await approval, got %+v", done)
+	}
+
+	regulated := map[string]string{middleware.TenantHeader: "tenant-regulated"}
+	decisionURL := baseURL + "/v1/jobs/" + jobID
+	status, body := postJSON(t, client, decisionURL+"/edit", map[string]any{"reviewer_id": "agent-7"}, regulated)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for edit without result, got %d body=%+v", status, body)
+	}
+	status, body = postJSON(t, client, decisionURL+"/edit", map[string]any{
+		"reviewer_id": "agent-7",
+		"result": map[string]any{
+			"summary":    "Cliente pediu a segunda via do boleto.",
+			"next_steps": "Enviar o boleto para cliente@gmail.com.",
+		},
+	}, regulated)
+	if status != http.StatusOK || body["approval"] != "approved" || body["action"] != "edited" {
+		t.Fatalf("expected edited result to be approved, got %d body=%+v", status, body)
+	}
+	masking, _ := body["masking"].(map[string]any)
+	counts, _ := masking["counts"].(map[string]any)
+	if fields, _ := masking["fields"].([]any); counts["email"] != float64(1) || len(fields) != 1 || fields[0] != "result.next_steps" {
+		t.Fatalf("expected masking report for the edited email, got %+v", body["masking"])
+	}
+
+	status, body = getJSONWithHeaders(t, client, decisionURL, regulated)
+	result, _ := body["result"].(map[string]any)
+	if status != http.StatusOK || body["approval"] != "approved" || result["summary"] != "Cliente pediu a segunda via do boleto." {
+		t.Fatalf("expected approved edited result on job status, got %d body=%+v", status, body)
+	}
+	if _, ok := result["model_id"]; !ok {
+		t.Fatalf("expected provenance to survive the edit, got %+v", result)
+	}
+
+	status, body = postJSON(t, client, decisionURL+"/approve", map[string]any{"reviewer_id": "agent-7"}, regulated)
+	if status != http.StatusConflict {
+		t.Fatalf("expected 409 for a decided job, got %d body=%+v", status, body)
+	}
+
+	rejectedID := enqueue("chat-approval-2", "summary-approval-flow-0002")
+	waitForTenantJobDone(t, client, baseURL, "tenant-regulated", rejectedID, 4*time.Second)
+	status, body = postJSON(t, client, baseURL+"/v1/jobs/"+rejectedID+"/reject", map[string]any{"reviewer_id": "agent-8"}, regulated)
+	if status != http.StatusOK || body["approval"] != "rejected" {
+		t.Fatalf("expected rejected approval, got %d body=%+v", status, body)
+	}
+
+	status, body = postJSON(t, client, baseURL+"/v1/summaries", map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-approval-3",
+			"channel":         "whatsapp_web",
+		},
+	}, map[string]string{"Idempotency-Key": "summary-approval-flow-0003"})
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202 from summaries, got %d body=%+v", status, body)
+	}
+	otherID, _ := body["job_id"].(string)
+	if done := waitForJobDone(t, client, baseURL, otherID, 4*time.Second); done["approval"] != nil {
+		t.Fatalf("expected no approval for unregulated tenants, got %+v", done)
+	}
+	status, _ = postJSON(t, client, baseURL+"/v1/jobs/"+otherID+"/approve", map[string]any{"reviewer_id": "agent-7"}, nil)
+	if status != http.StatusConflict {
+		t.Fatalf("expected 409 approving a job without approval, got %d", status)
+	}
+}
+
+func TestIdempotencyKeyReplaysAnyPost(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	template := map[string]any{
+		"tenant_id": "default",
+		"name":      "Boas vindas",
+		"content":   "Ola {{nome}}!",
+	}
+	headers := map[string]string{"Idempotency-Key": "template-create-0001"}
+
+	status, first := postJSON(t, client, baseURL+"/v1/templates", template, headers)
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 creating template, got %d body=%+v", status, first)
+	}
+	status, replay := postJSON(t, client, baseURL+"/v1/templates", template, headers)
+	if status != http.StatusCreated || replay["template_id"] != first["template_id"] {
+		t.Fatalf("expected replayed creation, got %d body=%+v", status, replay)
+	}
+
+	template["content"] = "Outro texto"
+	status, body := postJSON(t, client, baseURL+"/v1/templates", template, headers)
+	errorBody, _ := body["error"].(map[string]any)
+	if status != http.StatusConflict || errorBody["code"] != "idempotency_conflict" {
+		t.Fatalf("expected idempotency_conflict, got %d body=%+v", status, body)
+	}
+}
+
+func TestReplyTemplatesCRUD(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	status, body := postJSON(t, client, baseURL+"/v1/templates", map[string]any{
+		"tenant_id": "default",
+		"name":      "Prazo de entrega",
+		"content":   "Ola {{nome}}, seu pedido {{pedido}} chega em {{ prazo }}. Obrigado, {{nome}}!",
+	}, nil)
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201 creating template, got %d body=%+v", status, body)
+	}
+	templateID, _ := body["template_id"].(string)
+	if templateID == "" {
+		t.Fatalf("expected template_id in response, got %+v", body)
+	}
+	placeholders, _ := body["placeholders"].([]any)
+	if len(placeholders) != 3 || placeholders[0] != "nome" || placeholders[2] != "prazo" {
+		t.Fatalf("expected placeholders [nome pedido prazo], got %+v", body["placeholders"])
+	}
+	if locale, _ := body["locale"].(string); locale != "pt-BR" {
+		t.Fatalf("expected default locale pt-BR, got %q", locale)
+	}
+
+	status, body = postJSON(t, client, baseURL+"/v1/templates", map[string]any{
+		"tenant_id": "default",
+		"name":      "PRAZO DE ENTREGA",
+		"content":   "Outro texto",
+	}, nil)
+	if status != http.StatusConflict {
+		t.Fatalf("expected 409 for duplicated name, got %d body=%+v", status, body)
+	}
+
+	status, body = sendJSON(t, client, http.MethodPut, baseURL+"/v1/templates/"+templateID, map[string]any{
+		"tenant_id": "default",
+		"name":      "Prazo de entrega",
+		"content":   "Oi {{nome}}, tudo certo com o pedido.",
+		"locale":    "pt-PT",
+	}, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 updating template, got %d body=%+v", status, body)
+	}
+	if placeholders, _ := body["placeholders"].([]any); len(placeholders) != 1 {
+		t.Fatalf("expected placeholders to be recomputed, got %+v", body["placeholders"])
+	}
+
+	status, body = getJSON(t, client, baseURL+"/v1/templates?tenant_id=default")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 listing templates, got %d body=%+v", status, body)
+	}
+	if total, _ := body["total"].(float64); total != 1 {
+		t.Fatalf("expected one template, got %+v", body)
+	}
+
+	status, _ = getJSON(t, client, baseURL+"/v1/templates/"+templateID+"?tenant_id=other")
+	if status != http.StatusNotFound {
+		t.Fatalf("expected 404 for template of another tenant, got %d", status)
+	}
+
+	status, _ = deleteJSON(t, client, baseURL+"/v1/templates/"+templateID)
+	if status != http.StatusNoContent {
+		t.Fatalf("expected 204 deleting template, got %d", status)
+	}
+	status, _ = getJSON(t, client, baseURL+"/v1/templates/"+templateID)
+	if status != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", status)
+	}
+}
+
+func TestReportTagsFilterAndPatch(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	createReport := func(conversationID, key string, tags []string) string {
+		t.Helper()
+		status, body := postJSON(t, client, baseURL+"/v1/reports", map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "default",
+				"conversation_id": conversationID,
+				"channel":         "whatsapp_web",
+			},
+			"report_type": "timeline",
+			"tags":        tags,
+		}, map[string]string{"Idempotency-Key": key})
+		if status != http.StatusAccepted {
+			t.Fatalf("expected 202 creating report, got %d body=%+v", status, body)
+		}
+		jobID, _ := body["job_id"].(string)
+		return jobID
+	}
+
+	acmeID := createReport("chat-tags-1", "report-tags-flow-0001", []string{"Cliente:ACME", "campanha-natal", "cliente:acme"})
+	createReport("chat-tags-2", "report-tags-flow-0002", []string{"cliente:globex"})
+
+	status, body := getJSON(t, client, baseURL+"/v1/reports?tenant_id=default&tags=cliente:acme,campanha-natal")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 filtering by tags, got %d body=%+v", status, body)
+	}
+	items, _ := body["items"].([]any)
+	if len(items) != 1 {
+		t.Fatalf("expected one report tagged cliente:acme, got %+v", body)
+	}
+	item, _ := items[0].(map[string]any)
+	if tags, _ := item["tags"].([]any); len(tags) != 2 || tags[0] != "cliente:acme" {
+		t.Fatalf("expected normalized deduplicated tags, got %+v", item["tags"])
+	}
+
+	status, body = sendJSON(t, client, http.MethodPatch, baseURL+"/v1/reports/"+acmeID, map[string]any{
+		"tags": []string{"cliente:globex"},
+	}, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 patching tags, got %d body=%+v", status, body)
+	}
+
+	_, body = getJSON(t, client, baseURL+"/v1/reports?tenant_id=default&tags=cliente:globex")
+	if total, _ := body["total"].(float64); total != 2 {
+		t.Fatalf("expected two reports tagged cliente:globex after patch, got %+v", body)
+	}
+
+	for _, target := range []struct {
+		url     string
+		headers map[string]string
+	}{
+		{baseURL + "/v1/reports/" + acmeID, map[string]string{middleware.TenantHeader: "other"}},
+		{baseURL + "/v1/reports/" + acmeID + "?tenant_id=other", map[string]string{middleware.TenantHeader: "default"}},
+	} {
+		status, body = sendJSON(t, client, http.MethodPatch, target.url, map[string]any{
+			"tags": []string{"cliente:outro"},
+		}, target.headers)
+		if status != http.StatusNotFound {
+			t.Fatalf("expected 404 patching a report outside the scope %v, got %d body=%+v", target.headers, status, body)
+		}
+	}
+	_, body = getJSON(t, client, baseURL+"/v1/reports?tenant_id=default&tags=cliente:outro")
+	if total, _ := body["total"].(float64); total != 0 {
+		t.Fatalf("expected tags untouched by foreign patches, got %+v", body)
+	}
+
+	status, _ = sendJSON(t, client, http.MethodPatch, baseURL+"/v1/reports/"+acmeID, map[string]any{
+		"tags": []string{"com espaco"},
+	}, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid tag, got %d", status)
+	}
+}
+
+func TestReportWithSummaryRunsAsPipeline(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	status, body := postJSON(t, client, baseURL+"/v1/reports", map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-pipeline-1",
+			"channel":         "whatsapp_web",
+		},
+		"report_type":  "timeline",
+		"with_summary": true,
+	}, map[string]string{"Idempotency-Key": "report-pipeline-0001"})
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202 creating report, got %d body=%+v", status, body)
+	}
+	reportID, _ := body["job_id"].(string)
+	summaryID, _ := body["depends_on"].(string)
+	if summaryID == "" || summaryID == reportID {
+		t.Fatalf("expected the report to depend on a summary job, got %+v", body)
+	}
+
+	summary := waitForJobDone(t, client, baseURL, summaryID, 3*time.Second)
+	if summary["kind"] != "summary" {
+		t.Fatalf("expected the first step to be a summary, got %+v", summary)
+	}
+	report := waitForJobDone(t, client, baseURL, reportID, 3*time.Second)
+	if report["depends_on"] != summaryID {
+		t.Fatalf("expected the report status to expose its upstream, got %+v", report)
+	}
+	result, _ := report["result"].(map[string]any)
+	pipeline, _ := result["pipeline"].(map[string]any)
+	ancestors, _ := pipeline["ancestors"].([]any)
+	if len(ancestors) != 1 {
+		t.Fatalf("expected the report result to link the summary, got %+v", result)
+	}
+	if ancestor, _ := ancestors[0].(map[string]any); ancestor["job_id"] != summaryID || ancestor["kind"] != "summary" {
+		t.Fatalf("expected the summary as ancestor, got %+v", ancestor)
+	}
+}
+
+func TestSectionedReportKeepsRequestedHeadings(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	status, body := postJSON(t, client, baseURL+"/v1/reports", map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-sections-1",
+			"channel":         "whatsapp_web",
+		},
+		"sections": []string{"", strings.Repeat("x", 81)},
+	}, map[string]string{"Idempotency-Key": "report-sections-0001"})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid sections, got %d body=%+v", status, body)
+	}
+
+	status, body = postJSON(t, client, baseURL+"/v1/reports", map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-sections-1",
+			"channel":         "whatsapp_web",
+		},
+		"sections": []string{"Visao geral", "Riscos", "Proximos passos"},
+	}, map[string]string{"Idempotency-Key": "report-sections-0002"})
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202 creating report, got %d body=%+v", status, body)
+	}
+	reportID, _ := body["job_id"].(string)
+
+	report := waitForJobDone(t, client, baseURL, reportID, 3*time.Second)
+	result, _ := report["result"].(map[string]any)
+	sections, _ := result["sections"].([]any)
+	if len(sections) != 3 {
+		t.Fatalf("expected the three requested sections, got %+v", result)
+	}
+	if section, _ := sections[1].(map[string]any); section["heading"] != "Riscos" {
+		t.Fatalf("expected the requested headings in order, got %+v", sections)
+	}
+	if result["degraded"] != true {
+		t.Fatalf("expected a degraded report without a model, got %+v", result)
+	}
+}
+
+func TestHealthReportsDependencies(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	status, body := getJSON(t, runtime.server.Client(), runtime.server.URL+"/healthz")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from healthz, got %d", status)
+	}
+	if overall, _ := body["status"].(string); overall != "ok" {
+		t.Fatalf("expected overall status ok, got %+v", body)
+	}
+	checks, _ := body["checks"].(map[string]any)
+	postgres, _ := checks["postgres"].(map[string]any)
+	if state, _ := postgres["status"].(string); state != "disabled" {
+		t.Fatalf("expected postgres disabled, got %+v", checks)
+	}
+	queueCheck, _ := checks["queue"].(map[string]any)
+	if state, _ := queueCheck["status"].(string); state != "ok" {
+		t.Fatalf("expected queue ok, got %+v", checks)
+	}
+}
+
+func TestReadinessFlipsWhileDraining(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	status, body := getJSON(t, client, runtime.server.URL+"/readyz")
+	if status != http.StatusOK || body["status"] != "ready" {
+		t.Fatalf("expected ready after startup, got %d body=%+v", status, body)
+	}
+
+	runtime.readiness.MarkNotReady("draining")
+	status, body = getJSON(t, client, runtime.server.URL+"/readyz")
+	if status != http.StatusServiceUnavailable || body["reason"] != "draining" {
+		t.Fatalf("expected 503 draining, got %d body=%+v", status, body)
+	}
+
+	status, _ = getJSON(t, client, runtime.server.URL+"/healthz")
+	if status != http.StatusOK {
+		t.Fatalf("expected liveness to stay 200 while draining, got %d", status)
+	}
+}
+
+func TestSuggestionsQuickMode(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	payload := map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-quick-1",
+			"channel":         "whatsapp_web",
+		},
+		"tone":           "neutro",
+		"context_window": 10,
+		"messages":       []string{"Oi, consegue ver meu pedido?"},
+	}
+
+	status, body := postJSON(t, client, runtime.server.URL+"/v1/suggestions?mode=quick", payload, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from quick suggestions, got %d body=%+v", status, body)
+	}
+	if body["mode"] != "quick" || body["prompt_version"] != "quick_reply_v1" {
+		t.Fatalf("expected quick mode response, got %+v", body)
+	}
+	suggestions, _ := body["suggestions"].([]any)
+	if len(suggestions) != 3 {
+		t.Fatalf("expected 3 quick suggestions, got %+v", body["suggestions"])
+	}
+	for _, raw := range suggestions {
+		suggestion, _ := raw.(map[string]any)
+		if content, _ := suggestion["content"].(string); content == "" || len([]rune(content)) > 80 {
+			t.Fatalf("expected quick reply up to 80 chars, got %q", content)
+		}
+	}
+
+	payload["mode"] = "long"
+	status, _ = postJSON(t, client, runtime.server.URL+"/v1/suggestions", payload, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown mode, got %d", status)
+	}
+}
+
+func TestTenantRegisteredTonesAreAccepted(t *testing.T) {
+	lexicons, err := policy.CompileToneLexicons(policy.ToneLexiconsFile{
+		Tenants: map[string]policy.ToneLexicon{"tenant-tones": {Tones: map[string]policy.ToneDefinition{
+			"tecnico": {Prompt: "Use termos tecnicos precisos."},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("compile lexicons: %v", err)
+	}
+	policy.SetToneLexicons(lexicons)
+	t.Cleanup(func() {
+		defaults, _ := policy.CompileToneLexicons(policy.ToneLexiconsFile{})
+		policy.SetToneLexicons(defaults)
+	})
+
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	suggest := func(tenantID string) (int, map[string]any) {
+		return postJSON(t, client, runtime.server.URL+"/v1/suggestions", map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       tenantID,
+				"conversation_id": "chat-tones-1",
+				"channel":         "whatsapp_web",
+			},
+			"tone":           "tecnico",
+			"context_window": 10,
+			"messages":       []string{"O webhook retorna 502 desde ontem."},
+		}, nil)
+	}
+
+	if status, body := suggest("tenant-tones"); status != http.StatusOK {
+		t.Fatalf("expected registered tone to be accepted, got %d body=%+v", status, body)
+	}
+	status, body := suggest("default")
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a tone the tenant did not register, got %d body=%+v", status, body)
+	}
+	errorBody, _ := body["error"].(map[string]any)
+	if message, _ := errorBody["message"].(string); message != "tone must be one of formal, neutro, amigavel" {
+		t.Fatalf("expected the tenant tones in the error, got %+v", body)
+	}
+}
+
+func TestQuestionsSuggestsClarifyingQuestions(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	status, body := postJSON(
+		t,
+		runtime.server.Client(),
+		runtime.server.URL+"/v1/questions",
+		map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "default",
+				"conversation_id": "chat-questions-1",
+				"channel":         "whatsapp_web",
+			},
+			"messages": []string{"Meu pedido deu problema"},
+		},
+		nil,
+	)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from questions, got %d body=%+v", status, body)
+	}
+	questions, _ := body["questions"].([]any)
+	if len(questions) < 2 || len(questions) > 3 {
+		t.Fatalf("expected 2-3 questions, got %+v", body)
+	}
+	if body["locale"] != "pt-BR" {
+		t.Fatalf("expected default locale, got %+v", body["locale"])
+	}
+
+	status, _ = postJSON(
+		t,
+		runtime.server.Client(),
+		runtime.server.URL+"/v1/questions",
+		map[string]any{
+			"conversation":   map[string]any{"tenant_id": "default", "conversation_id": "chat-questions-1"},
+			"context_window": 200,
+		},
+		nil,
+	)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for out of range context_window, got %d", status)
+	}
+}
+
+func TestActionItemsReturnsChecklist(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	status, body := postJSON(
+		t,
+		runtime.server.Client(),
+		runtime.server.URL+"/v1/action-items",
+		map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "default",
+				"conversation_id": "chat-actions-1",
+				"channel":         "whatsapp_web",
+			},
+			"locale":   "en-US",
+			"messages": []string{"Vou te enviar o boleto amanha"},
+		},
+		nil,
+	)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from action items, got %d body=%+v", status, body)
+	}
+	if _, ok := body["action_items"].([]any); !ok {
+		t.Fatalf("expected action_items array, got %+v", body)
+	}
+	if body["locale"] != "en-US" {
+		t.Fatalf("expected requested locale, got %+v", body["locale"])
+	}
+}
+
+func TestOversizedBodyRejectedWithEnvelope(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	payload := map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-oversized",
+			"channel":         "whatsapp_web",
+		},
+		"tone":     "neutro",
+		"messages": []string{strings.Repeat("a", 2<<20)},
+	}
+
+	status, body := postJSON(t, client, runtime.server.URL+"/v1/suggestions", payload, nil)
+	errorBody, _ := body["error"].(map[string]any)
+	if status != http.StatusRequestEntityTooLarge || errorBody["code"] != "payload_too_large" || body["request_id"] == "" {
+		t.Fatalf("expected 413 envelope, got %d body=%+v", status, body)
+	}
+}
+
+func TestComposeValidatesDraft(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	payload := map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "default",
+			"conversation_id": "chat-compose-1",
+			"channel":         "whatsapp_web",
+		},
+		"tone":     "formal",
+		"messages": []string{"Qual o prazo de entrega?"},
+		"draft":    "Bom dia! O prazo de entrega",
+	}
+
+	status, body := postJSON(t, client, runtime.server.URL+"/v1/compose", payload, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from compose, got %d body=%+v", status, body)
+	}
+	if _, ok := body["completions"].([]any); !ok || body["hitl_required"] != true {
+		t.Fatalf("expected completions with hitl_required, got %+v", body)
+	}
+
+	payload["draft"] = "   "
+	status, body = postJSON(t, client, runtime.server.URL+"/v1/compose", payload, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for blank draft, got %d body=%+v", status, body)
+	}
+}
+
+func TestAdminNamespaceRequiresAdminToken(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	admin := map[string]string{"Authorization": "Bearer " + integrationAdminToken}
+
+	status, _ := getJSON(t, client, runtime.server.URL+"/admin/config")
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without admin token, got %d", status)
+	}
+	status, _ = getJSONWithHeaders(t, client, runtime.server.URL+"/admin/config", map[string]string{"Authorization": "Bearer wrong"})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with wrong admin token, got %d", status)
+	}
+
+	status, body := getJSONWithHeaders(t, client, runtime.server.URL+"/admin/config", admin)
+	config, _ := body["config"].(map[string]any)
+	if status != http.StatusOK || config["auth_token"] != "[redacted]" {
+		t.Fatalf("expected redacted config snapshot, got %d body=%+v", status, body)
+	}
+	if sources, _ := body["sources"].(map[string]any); sources["PORT"] != "default" {
+		t.Fatalf("expected config sources, got %+v", body["sources"])
+	}
+
+	status, body = getJSONWithHeaders(t, client, runtime.server.URL+"/admin/rate-limits", admin)
+	if status != http.StatusOK || body["burst"] != float64(20000) {
+		t.Fatalf("expected rate limit snapshot, got %d body=%+v", status, body)
+	}
+
+	status, body = sendJSON(t, client, http.MethodPost, runtime.server.URL+"/admin/cache/flush", map[string]any{}, admin)
+	if _, ok := body["flushed"].(float64); status != http.StatusOK || !ok {
+		t.Fatalf("expected cache flush count, got %d body=%+v", status, body)
+	}
+
+	status, body = sendJSON(t, client, http.MethodPut, runtime.server.URL+"/admin/worker", map[string]any{"enabled": false}, admin)
+	if status != http.StatusOK || body["enabled"] != false {
+		t.Fatalf("expected worker paused, got %d body=%+v", status, body)
+	}
+	status, body = sendJSON(t, client, http.MethodPut, runtime.server.URL+"/admin/worker", map[string]any{"enabled": true}, admin)
+	if status != http.StatusOK || body["enabled"] != true {
+		t.Fatalf("expected worker resumed, got %d body=%+v", status, body)
+	}
+	if concurrency, _ := body["concurrency"].(map[string]any); concurrency["current"] != float64(1) || concurrency["adaptive"] != false {
+		t.Fatalf("expected a fixed pool of one worker, got %+v", body["concurrency"])
+	}
+	status, _ = sendJSON(t, client, http.MethodPut, runtime.server.URL+"/admin/worker", map[string]any{}, admin)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 without enabled, got %d", status)
+	}
+}
+
+func TestAdminReloadAppliesRateLimits(t *testing.T) {
+	t.Setenv("RATE_LIMIT_RPS", "20000")
+	t.Setenv("RATE_LIMIT_BURST", "20000")
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	admin := map[string]string{"Authorization": "Bearer " + integrationAdminToken}
+
+	status, _ := getJSONWithHeaders(t, client, runtime.server.URL+"/admin/reload", admin)
+	if status != http.StatusNotFound {
+		t.Fatalf("expected 404 before any reload, got %d", status)
+	}
+
+	t.Setenv("RATE_LIMIT_BURST", "30000")
+	t.Setenv("PORT", "9090")
+	status, body := sendJSON(t, client, http.MethodPost, runtime.server.URL+"/admin/reload", map[string]any{}, admin)
+	if status != http.StatusOK || body["ok"] != true || body["trigger"] != "admin" {
+		t.Fatalf("expected reload applied, got %d body=%+v", status, body)
+	}
+	results, _ := body["results"].([]any)
+	if len(results) != 1 {
+		t.Fatalf("expected one reload result, got %+v", body["results"])
+	}
+	if result, _ := results[0].(map[string]any); result["name"] != "rate_limits" || result["changed"] != true {
+		t.Fatalf("expected rate limits changed, got %+v", result)
+	}
+	if restart, _ := body["restart_required"].([]any); len(restart) != 1 || restart[0] != "Port" {
+		t.Fatalf("expected PORT to need a restart, got %+v", body["restart_required"])
+	}
+	status, body = getJSONWithHeaders(t, client, runtime.server.URL+"/admin/rate-limits", admin)
+	if status != http.StatusOK || body["burst"] != float64(30000) {
+		t.Fatalf("expected reloaded burst, got %d body=%+v", status, body)
+	}
+
+	t.Setenv("RATE_LIMIT_RPS", "-1")
+	status, body = sendJSON(t, client, http.MethodPost, runtime.server.URL+"/admin/reload", map[string]any{}, admin)
+	if status != http.StatusUnprocessableEntity || body["ok"] != false || !strings.Contains(fmt.Sprint(body["error"]), "RATE_LIMIT_RPS") {
+		t.Fatalf("expected invalid reload rejected, got %d body=%+v", status, body)
+	}
+	status, body = getJSONWithHeaders(t, client, runtime.server.URL+"/admin/rate-limits", admin)
+	if status != http.StatusOK || body["rps"] != float64(20000) {
+		t.Fatalf("expected limits kept after rejected reload, got %d body=%+v", status, body)
+	}
+	status, body = getJSONWithHeaders(t, client, runtime.server.URL+"/admin/reload", admin)
+	if status != http.StatusOK || body["ok"] != false {
+		t.Fatalf("expected last reload report, got %d body=%+v", status, body)
+	}
+}
+
+func TestAuditTrailRecordsSensitiveOperations(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	admin := map[string]string{"Authorization": "Bearer " + integrationAdminToken}
+
+	status, body := postJSON(t, client, baseURL+"/admin/api-keys", map[string]any{
+		"tenant_id": "tenant-audit",
+		"name":      "auditoria",
+		"scopes":    []string{"reports"},
+	}, admin)
+	secret, _ := body["api_key"].(string)
+	keyID, _ := body["key_id"].(string)
+	if status != http.StatusCreated {
+		t.Fatalf("expected issued api key, got %d body=%+v", status, body)
+	}
+
+	report := map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "tenant-audit",
+			"conversation_id": "chat-audit",
+			"channel":         "whatsapp_web",
+		},
+		"report_type": "timeline",
+	}
+	status, body = postJSON(t, client, baseURL+"/v1/reports", report, map[string]string{
+		"X-API-Key":       secret,
+		"X-Request-Id":    "req-audit-report",
+		"Idempotency-Key": "audit-report-0001",
+	})
+	jobID, _ := body["job_id"].(string)
+	if status != http.StatusAccepted || jobID == "" {
+		t.Fatalf("expected report accepted, got %d body=%+v", status, body)
+	}
+	status, _ = postJSON(t, client, baseURL+"/v1/reports", map[string]any{"report_type": "timeline"}, map[string]string{"X-API-Key": secret})
+	if status != http.StatusBadRequest && status != http.StatusUnprocessableEntity {
+		t.Fatalf("expected invalid report to be rejected, got %d", status)
+	}
+	status, _ = sendJSON(t, client, http.MethodPut, baseURL+"/admin/worker", map[string]any{"enabled": false}, admin)
+	if status != http.StatusOK {
+		t.Fatalf("expected worker paused, got %d", status)
+	}
+	_, _ = sendJSON(t, client, http.MethodPut, baseURL+"/admin/worker", map[string]any{"enabled": true}, admin)
+
+	byAction := make(map[string][]domain.AuditRecord)
+	for _, record := range runtime.audit.Records() {
+		byAction[record.Action] = append(byAction[record.Action], record)
+	}
+	if len(byAction[service.AuditActionAPIKeyCreated]) != 1 {
+		t.Fatalf("expected api key creation audited, got %+v", byAction)
+	}
+	reports := byAction["report.requested"]
+	if len(reports) != 1 {
+		t.Fatalf("expected only the accepted report audited, got %+v", reports)
+	}
+	if got := reports[0]; got.Actor != "api_key:"+keyID || got.TenantID != "tenant-audit" || got.ResourceID != jobID || got.RequestID != "req-audit-report" {
+		t.Fatalf("unexpected report audit record: %+v", got)
+	}
+	toggles := byAction["worker.toggled"]
+	if len(toggles) != 2 || toggles[0].Actor != "admin_token" || !strings.Contains(string(toggles[0].Metadata), `"enabled":false`) {
+		t.Fatalf("unexpected worker audit records: %+v", toggles)
+	}
+}
+
+func TestAPIKeyScopesAndRotation(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	admin := map[string]string{"Authorization": "Bearer " + integrationAdminToken}
+
+	status, body := postJSON(t, client, baseURL+"/admin/api-keys", map[string]any{
+		"tenant_id": "tenant-keys",
+		"name":      "extensao chrome",
+		"scopes":    []string{"suggestions", "bogus"},
+	}, admin)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown scope, got %d body=%+v", status, body)
+	}
+
+	status, body = postJSON(t, client, baseURL+"/admin/api-keys", map[string]any{
+		"tenant_id": "tenant-keys",
+		"name":      "extensao chrome",
+		"scopes":    []string{"suggestions"},
+	}, admin)
+	secret, _ := body["api_key"].(string)
+	keyID, _ := body["key_id"].(string)
+	if status != http.StatusCreated || !strings.HasPrefix(secret, "wak_") || keyID == "" {
+		t.Fatalf("expected issued api key, got %d body=%+v", status, body)
+	}
+	if prefix, _ := body["prefix"].(string); !strings.HasPrefix(secret, prefix) {
+		t.Fatalf("expected prefix to match secret head, got %q", prefix)
+	}
+
+	suggestion := map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "tenant-keys",
+			"conversation_id": "chat-keys",
+			"channel":         "whatsapp_web",
+		},
+		"tone":           "neutro",
+		"context_window": 10,
+		"max_candidates": 1,
+		"messages":       []string{"Oi, tudo bem?"},
+	}
+	withKey := map[string]string{"X-API-Key": secret}
+	status, body = postJSON(t, client, baseURL+"/v1/suggestions", suggestion, withKey)
+	if status != http.StatusOK {
+		t.Fatalf("expected suggestions with api key, got %d body=%+v", status, body)
+	}
+
+	status, body = getJSONWithHeaders(t, client, baseURL+"/v1/reports?tenant_id=tenant-keys", withKey)
+	errorBody, _ := body["error"].(map[string]any)
+	if status != http.StatusForbidden || errorBody["code"] != "insufficient_scope" {
+		t.Fatalf("expected insufficient_scope on reports, got %d body=%+v", status, body)
+	}
+
+	foreign := map[string]string{"X-API-Key": secret, "X-Tenant-ID": "tenant-other"}
+	status, _ = postJSON(t, client, baseURL+"/v1/suggestions", suggestion, foreign)
+	if status != http.StatusForbidden {
+		t.Fatalf("expected 403 for tenant outside the key, got %d", status)
+	}
+
+	status, body = postJSON(t, client, baseURL+"/admin/api-keys/"+keyID+"/rotate", map[string]any{}, admin)
+	rotated, _ := body["api_key"].(string)
+	rotatedID, _ := body["key_id"].(string)
+	if status != http.StatusCreated || rotated == "" || body["rotated_from"] != keyID {
+		t.Fatalf("expected rotated key, got %d body=%+v", status, body)
+	}
+	status, _ = postJSON(t, client, baseURL+"/v1/suggestions", suggestion, withKey)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for rotated-out key, got %d", status)
+	}
+	status, _ = postJSON(t, client, baseURL+"/v1/suggestions", suggestion, map[string]string{"Authorization": "Bearer " + rotated})
+	if status != http.StatusOK {
+		t.Fatalf("expected rotated key to work as bearer token, got %d", status)
+	}
+
+	status, body = getJSONWithHeaders(t, client, baseURL+"/admin/api-keys?tenant_id=tenant-keys", admin)
+	items, _ := body["items"].([]any)
+	if status != http.StatusOK || len(items) != 1 {
+		t.Fatalf("expected one active key listed, got %d body=%+v", status, body)
+	}
+	if listed, _ := items[0].(map[string]any); listed["api_key"] != nil {
+		t.Fatalf("expected listing to omit secrets, got %+v", listed)
+	}
+
+	status, body = sendJSON(t, client, http.MethodDelete, baseURL+"/admin/api-keys/"+rotatedID, nil, admin)
+	if status != http.StatusOK || body["active"] != false {
+		t.Fatalf("expected revoked key, got %d body=%+v", status, body)
+	}
+	status, _ = sendJSON(t, client, http.MethodDelete, baseURL+"/admin/api-keys/"+rotatedID, nil, admin)
+	if status != http.StatusNotFound {
+		t.Fatalf("expected 404 revoking twice, got %d", status)
+	}
+	status, _ = postJSON(t, client, baseURL+"/v1/suggestions", suggestion, map[string]string{"X-API-Key": rotated})
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for revoked key, got %d", status)
+	}
+}
+
+func TestReadOnlyAPIKeyOnlyReadsReports(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	admin := map[string]string{"Authorization": "Bearer " + integrationAdminToken}
+
+	status, body := postJSON(t, client, baseURL+"/admin/api-keys", map[string]any{
+		"tenant_id": "tenant-readonly",
+		"name":      "painel",
+		"scopes":    []string{"readonly"},
+	}, admin)
+	secret, _ := body["api_key"].(string)
+	if status != http.StatusCreated || secret == "" {
+		t.Fatalf("expected issued readonly key, got %d body=%+v", status, body)
+	}
+	withKey := map[string]string{"X-API-Key": secret}
+
+	status, body = getJSONWithHeaders(t, client, baseURL+"/v1/reports?tenant_id=tenant-readonly", withKey)
+	if status != http.StatusOK {
+		t.Fatalf("expected readonly key to list reports, got %d body=%+v", status, body)
+	}
+
+	status, body = postJSON(t, client, baseURL+"/v1/reports", map[string]any{
+		"tenant_id": "tenant-readonly",
+	}, withKey)
+	errorBody, _ := body["error"].(map[string]any)
+	if status != http.StatusForbidden || errorBody["code"] != "insufficient_role" {
+		t.Fatalf("expected insufficient_role creating a report, got %d body=%+v", status, body)
+	}
+
+	status, _ = getJSONWithHeaders(t, client, baseURL+"/admin/config", withKey)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("expected api keys to stay out of /admin, got %d", status)
+	}
+}
+
+func TestDigestCoversTenantConversations(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	for conversationID, messages := range map[string][]map[string]any{
+		"chat-digest-1": {
+			{"message_id": "dg-1", "author_role": "customer", "text": "Meu pedido ainda nao chegou", "sent_at": "2026-03-01T10:00:00Z"},
+			{"message_id": "dg-2", "author_role": "agent", "text": "Pode confirmar o numero do pedido?", "sent_at": "2026-03-01T10:05:00Z"},
+		},
+		"chat-digest-2": {
+			{"message_id": "dg-3", "author_role": "customer", "text": "Quero trocar o tamanho da camisa", "sent_at": "2026-03-01T11:00:00Z"},
+		},
+	} {
+		status, body := postJSON(t, client, baseURL+"/v1/conversations/"+conversationID+"/messages", map[string]any{
+			"tenant_id": "default",
+			"messages":  messages,
+		}, nil)
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 from ingestion, got %d body=%+v", status, body)
+		}
+	}
+
+	payload := map[string]any{
+		"tenant_id": "default",
+		"from":      "2026-03-01T00:00:00Z",
+		"to":        "2026-03-02T00:00:00Z",
+	}
+	status, _ := postJSON(t, client, baseURL+"/v1/digests", payload, nil)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 without Idempotency-Key, got %d", status)
+	}
+	status, _ = postJSON(t, client, baseURL+"/v1/digests", map[string]any{
+		"tenant_id": "default",
+		"from":      "2026-03-02T00:00:00Z",
+		"to":        "2026-03-01T00:00:00Z",
+	}, map[string]string{"Idempotency-Key": "digest-invalid-range-1"})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for inverted period, got %d", status)
+	}
+
+	status, body := postJSON(t, client, baseURL+"/v1/digests", payload, map[string]string{"Idempotency-Key": "digest-daily-000001"})
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202 from digest, got %d body=%+v", status, body)
+	}
+	digestID, _ := body["digest_id"].(string)
+	if digestID == "" {
+		t.Fatalf("expected digest_id in response: %+v", body)
+	}
+	waitForJobDone(t, client, baseURL, digestID, 5*time.Second)
+
+	status, body = getJSON(t, client, baseURL+"/v1/digests/"+digestID)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from digest detail, got %d body=%+v", status, body)
+	}
+	if count, _ := body["conversation_count"].(float64); count != 2 {
+		t.Fatalf("expected two conversations in digest, got %+v", body)
+	}
+	waiting, _ := body["waiting_on_customer"].([]any)
+	if len(waiting) != 1 || waiting[0] != "chat-digest-1" {
+		t.Fatalf("expected chat-digest-1 waiting on customer, got %+v", body["waiting_on_customer"])
+	}
+	if _, ok := body["highlights"].([]any); !ok {
+		t.Fatalf("expected highlights array in digest: %+v", body)
+	}
+
+	status, body = getJSON(t, client, baseURL+"/v1/digests?tenant_id=default")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from digest list, got %d body=%+v", status, body)
+	}
+	if total, _ := body["total"].(float64); total != 1 {
+		t.Fatalf("expected one digest listed, got %+v", body)
+	}
+
+	status, _ = getJSON(t, client, baseURL+"/v1/reports/"+digestID)
+	if status != http.StatusNotFound {
+		t.Fatalf("expected digest to be hidden from reports, got %d", status)
+	}
+}
+
+func TestConversationInsightsFromStoredHistory(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	status, _ := getJSON(t, client, baseURL+"/v1/conversations/chat-insights-1/insights?tenant_id=default")
+	if status != http.StatusNotFound {
+		t.Fatalf("expected 404 without stored history, got %d", status)
+	}
+
+	status, body := postJSON(t, client, baseURL+"/v1/conversations/chat-insights-1/messages", map[string]any{
+		"tenant_id": "default",
+		"messages": []map[string]any{
+			{"message_id": "in-1", "author_role": "customer", "text": "Boa tarde, o produto veio com defeito", "sent_at": "2026-03-01T14:00:00Z"},
+			{"message_id": "in-2", "author_role": "agent", "text": "Vamos enviar a troca ate sexta", "sent_at": "2026-03-01T14:03:00Z"},
+			{"message_id": "in-3", "author_role": "customer", "text": "Obrigado, aguardo", "sent_at": "2026-03-01T14:05:00Z"},
+		},
+	}, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from ingestion, got %d body=%+v", status, body)
+	}
+
+	status, _ = getJSON(t, client, baseURL+"/v1/conversations/chat-insights-1/insights")
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 without tenant_id, got %d", status)
+	}
+
+	status, body = getJSON(t, client, baseURL+"/v1/conversations/chat-insights-1/insights?tenant_id=default")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from insights, got %d body=%+v", status, body)
+	}
+	if count, _ := body["message_count"].(float64); count != 3 {
+		t.Fatalf("expected three messages counted, got %+v", body)
+	}
+	if body["last_author"] != "customer" || body["first_message_at"] != "2026-03-01T14:00:00Z" {
+		t.Fatalf("expected history bounds in insights, got %+v", body)
+	}
+	for _, field := range []string{"commitments", "open_complaints"} {
+		if _, ok := body[field].([]any); !ok {
+			t.Fatalf("expected %s array in insights: %+v", field, body)
+		}
+	}
+	if body["preferred_tone"] == "" {
+		t.Fatalf("expected preferred_tone in insights: %+v", body)
+	}
+}
+
+func TestPseudonymizedMessagesCanBeRehydrated(t *testing.T) {
+	rules, err := policy.CompilePIIRules(policy.PIIRulesFile{
+		Tenants: map[string]policy.PIIRules{"default": {Mode: policy.PIIModePseudonymize}},
+	})
+	if err != nil {
+		t.Fatalf("compile pii rules: %v", err)
+	}
+	policy.SetPIIRules(rules)
+	t.Cleanup(func() {
+		defaults, _ := policy.CompilePIIRules(policy.PIIRulesFile{})
+		policy.SetPIIRules(defaults)
+	})
+
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+
+	status, body := postJSON(t, client, baseURL+"/v1/conversations/chat-pseudo-1/messages", map[string]any{
+		"tenant_id": "default",
+		"messages": []map[string]any{
+			{"message_id": "ps-1", "author_role": "customer", "text": "Me liga no +55 11 99999-8888 ou ana@gmail.com", "sent_at": "2026-03-01T09:00:00Z"},
+			{"message_id": "ps-2", "author_role": "customer", "text": "De novo: (11) 99999-8888", "sent_at": "2026-03-01T09:01:00Z"},
+		},
+	}, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from ingestion, got %d body=%+v", status, body)
+	}
+
+	status, body = getJSON(t, client, baseURL+"/v1/conversations/chat-pseudo-1/pseudonyms?tenant_id=default")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from pseudonyms, got %d body=%+v", status, body)
+	}
+	items, _ := body["pseudonyms"].([]any)
+	values := make(map[string]string, len(items))
+	for _, item := range items {
+		entry, _ := item.(map[string]any)
+		token, _ := entry["token"].(string)
+		values[token], _ = entry["value"].(string)
+	}
+	if len(values) != 2 || values["[TEL_1]"] != "+55 11 99999-8888" || values["[EMAIL_1]"] != "ana@gmail.com" {
+		t.Fatalf("expected one token per distinct value, got %+v", body)
+	}
+
+	eraseStatus, eraseBody := deleteJSON(t, client, baseURL+"/v1/conversations/chat-pseudo-1/data?tenant_id=default")
+	if eraseStatus != http.StatusOK {
+		t.Fatalf("expected 200 from erasure, got %d body=%+v", eraseStatus, eraseBody)
+	}
+	if deleted, _ := eraseBody["deleted_pseudonyms"].(float64); deleted != 2 {
+		t.Fatalf("expected pseudonyms to be erased, got %+v", eraseBody)
+	}
+}
+
+func TestPolicyViolationsAreAggregatedForAdmins(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	admin := map[string]string{"Authorization": "Bearer " + integrationAdminToken}
+
+	for _, conversationID := range []string{"chat-violation-1", "chat-violation-2"} {
+		status, body := postJSON(t, client, baseURL+"/v1/compose", map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "default",
+				"conversation_id": conversationID,
+				"channel":         "whatsapp_web",
+			},
+			"draft": "isso parece um golpe",
+		}, nil)
+		if status != http.StatusUnprocessableEntity {
+			t.Fatalf("expected 422 from blocked compose, got %d body=%+v", status, body)
+		}
+	}
+
+	status, body := getJSONWithHeaders(t, client, baseURL+"/admin/policy-violations?tenant_id=default", admin)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from policy violations, got %d body=%+v", status, body)
+	}
+	items, _ := body["items"].([]any)
+	if len(items) != 1 {
+		t.Fatalf("expected one aggregated violation, got %+v", body)
+	}
+	item, _ := items[0].(map[string]any)
+	if item["code"] != "blocked_operation" || item["rule"] != "golpe" || item["count"] != float64(2) {
+		t.Fatalf("expected blocked_operation counted twice for golpe, got %+v", item)
+	}
+
+	status, body = getJSONWithHeaders(t, client, baseURL+"/admin/policy-violations?tenant_id=other", admin)
+	if items, _ := body["items"].([]any); status != http.StatusOK || len(items) != 0 {
+		t.Fatalf("expected no violations for another tenant, got %d body=%+v", status, body)
+	}
+}
+
+func TestLowPriorityJobsAreAcceptedAndCounted(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	summary := func(key, priority string) (int, map[string]any) {
+		return postJSON(t, client, baseURL+"/v1/summaries", map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "default",
+				"conversation_id": "chat-backfill-1",
+				"channel":         "whatsapp_web",
+			},
+			"summary_type": "short",
+			"priority":     priority,
+		}, map[string]string{"Idempotency-Key": key})
+	}
+
+	status, body := summary("summary-priority-0001", "urgent")
+	if status != http.StatusBadRequest || !strings.Contains(fmt.Sprint(body["errors"]), "priority") {
+		t.Fatalf("expected a validation error on priority, got %d body=%+v", status, body)
+	}
+	status, body = summary("summary-priority-0002", "low")
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202 for a low priority summary, got %d body=%+v", status, body)
+	}
+	jobID, _ := body["job_id"].(string)
+	job := waitForJobDone(t, client, baseURL, jobID, 3*time.Second)
+	if job["priority"] != "low" {
+		t.Fatalf("expected the job to keep its priority, got %+v", job)
+	}
+
+	response, err := client.Get(baseURL + "/metrics")
+	if err != nil {
+		t.Fatalf("scrape metrics: %v", err)
+	}
+	defer response.Body.Close()
+	raw, _ := io.ReadAll(response.Body)
+	if !strings.Contains(string(raw), `worker_jobs_consumed_total{priority="low"} 1`) {
+		t.Fatalf("expected the low priority job in worker throughput:\n%s", raw)
+	}
+}
+
+func TestTenantSettingsApplyDefaultsAndQuotas(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	admin := map[string]string{"Authorization": "Bearer " + integrationAdminToken}
+	settingsURL := baseURL + "/admin/tenant-settings/tenant-settings"
+
+	status, body := sendJSON(t, client, http.MethodPut, settingsURL, map[string]any{
+		"locale": "klingon",
+		"models": map[string]any{"poetry": "gpt-4o"},
+		"quotas": map[string]any{"daily_jobs": -1},
+	}, admin)
+	if status != http.StatusBadRequest || len(body["errors"].([]any)) != 3 {
+		t.Fatalf("expected three validation errors, got %d body=%+v", status, body)
+	}
+
+	status, body = sendJSON(t, client, http.MethodPut, settingsURL, map[string]any{
+		"locale": "en-us",
+		"tone":   "formal",
+		"models": map[string]any{"summary": "gpt-4o-mini"},
+		"quotas": map[string]any{"daily_jobs": 1},
+	}, admin)
+	if status != http.StatusOK || body["locale"] != "en-US" || body["tone"] != "formal" {
+		t.Fatalf("expected settings saved, got %d body=%+v", status, body)
+	}
+
+	status, body = postJSON(t, client, baseURL+"/v1/suggestions", map[string]any{
+		"conversation": map[string]any{
+			"tenant_id":       "tenant-settings",
+			"conversation_id": "chat-settings-1",
+			"channel":         "whatsapp_web",
+		},
+		"context_window": 10,
+		"messages":       []string{"Can you check my order?"},
+	}, nil)
+	if status != http.StatusOK || body["locale"] != "en-US" {
+		t.Fatalf("expected the tenant locale and tone to apply, got %d body=%+v", status, body)
+	}
+
+	summary := func(key string) (int, map[string]any) {
+		return postJSON(t, client, baseURL+"/v1/summaries", map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "tenant-settings",
+				"conversation_id": "chat-settings-1",
+				"channel":         "whatsapp_web",
+			},
+			"summary_type": "short",
+		}, map[string]string{"Idempotency-Key": key})
+	}
+	if status, body = summary("summary-quota-0001"); status != http.StatusAccepted {
+		t.Fatalf("expected the first job within quota, got %d body=%+v", status, body)
+	}
+	status, body = summary("summary-quota-0002")
+	errorBody, _ := body["error"].(map[string]any)
+	if status != http.StatusTooManyRequests || errorBody["code"] != "quota_exceeded" {
+		t.Fatalf("expected the daily quota to reject the second job, got %d body=%+v", status, body)
+	}
+
+	status, body = getJSONWithHeaders(t, client, baseURL+"/admin/tenant-settings", admin)
+	if items, _ := body["items"].([]any); status != http.StatusOK || len(items) != 1 {
+		t.Fatalf("expected one tenant with settings, got %d body=%+v", status, body)
+	}
+	if status, body = sendJSON(t, client, http.MethodDelete, settingsURL, nil, admin); status != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete, got %d body=%+v", status, body)
+	}
+	if status, body = getJSONWithHeaders(t, client, settingsURL, admin); status != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d body=%+v", status, body)
+	}
+	if status, body = summary("summary-quota-0003"); status != http.StatusAccepted {
+		t.Fatalf("expected the quota lifted after delete, got %d body=%+v", status, body)
+	}
+}
+
+func TestMetricsEndpointRecordsRoutes(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+	client := runtime.server.Client()
+
+	if status, _ := getJSON(t, client, runtime.server.URL+"/healthz"); status != http.StatusOK {
+		t.Fatalf("expected healthz 200, got %d", status)
+	}
+	if status, _ := getJSON(t, client, runtime.server.URL+"/v1/jobs/missing-job"); status != http.StatusNotFound {
+		t.Fatalf("expected missing job 404, got %d", status)
+	}
+	conversation := map[string]any{
+		"tenant_id":       "default",
+		"conversation_id": "chat-metrics-1",
+		"channel":         "whatsapp_web",
+	}
+	if status, body := postJSON(t, client, runtime.server.URL+"/v1/suggestions", map[string]any{
+		"conversation":   conversation,
+		"tone":           "neutro",
+		"context_window": 10,
+		"messages":       []string{"Oi, consegue ver meu pedido?"},
+	}, nil); status != http.StatusOK {
+		t.Fatalf("expected 200 from suggestions, got %d body=%+v", status, body)
+	}
+	if status, body := postJSON(t, client, runtime.server.URL+"/v1/compose", map[string]any{
+		"conversation": conversation,
+		"draft":        "isso parece um golpe",
+	}, nil); status != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 from blocked compose, got %d body=%+v", status, body)
+	}
+
+	response, err := client.Get(runtime.server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("scrape metrics: %v", err)
+	}
+	defer response.Body.Close()
+	raw, _ := io.ReadAll(response.Body)
+	body := string(raw)
+	if response.StatusCode != http.StatusOK || !strings.HasPrefix(response.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("expected text exposition, got %d %s", response.StatusCode, response.Header.Get("Content-Type"))
+	}
+	for _, series := range []string{
+		`http_requests_total{method="GET",route="/healthz",status="200"} 1`,
+		`http_requests_total{method="GET",route="/v1/jobs/",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/v1/jobs/"} 1`,
+		"# TYPE http_request_duration_seconds histogram",
+		`semantic_cache_lookups_total{task="suggestion",result="miss"} 1`,
+		"# TYPE semantic_cache_entries gauge",
+		`policy_blocks_total{code="blocked_operation"} 1`,
+		"queue_depth 0",
+		"# TYPE ai_request_duration_seconds histogram",
+		`slo_burn_rate{slo="QT-002",window="5m"} 0`,
+		`slo_compliance_ratio{slo="QT-002"} 1`,
+	} {
+		if !strings.Contains(body, series) {
+			t.Fatalf("expected %q in metrics output:\n%s", series, body)
+		}
+	}
+	if strings.Contains(body, "missing-job") {
+		t.Fatalf("expected route patterns instead of raw paths in metrics")
+	}
+}
+
+func TestOpenAPIDocumentListsEndpoints(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	status, body := getJSON(t, runtime.server.Client(), runtime.server.URL+"/openapi.json")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from openapi.json, got %d", status)
+	}
+	paths, ok := body["paths"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected paths object in openapi document: %+v", body)
+	}
+	for _, path := range []string{
+		"/healthz",
+		"/readyz",
+		"/metrics",
+		"/v1/suggestions",
+		"/v1/analysis",
+		"/v1/questions",
+		"/v1/action-items",
+		"/v1/compose",
+		"/v1/summaries",
+		"/v1/reports",
+		"/v1/reports/{id}",
+		"/v1/digests",
+		"/v1/digests/{id}",
+		"/v1/jobs/{id}",
+		"/v1/jobs/{id}/approve",
+		"/v1/jobs/{id}/edit",
+		"/v1/jobs/{id}/reject",
+		"/v1/conversations/{id}/messages",
+		"/v1/conversations/{id}/data",
+		"/v1/conversations/{id}/insights",
+		"/v1/conversations/{id}/pseudonyms",
+		"/v1/stats/jobs",
+		"/v1/hitl/decisions",
+		"/v1/templates",
+		"/v1/templates/{id}",
+		"/v2/suggestions",
+		"/v2/analysis",
+		"/admin/cache/flush",
+		"/admin/config",
+		"/admin/rate-limits",
+		"/admin/worker",
+		"/admin/policy-violations",
+		"/admin/vars",
+		"/admin/api-keys",
+		"/admin/api-keys/{id}",
+		"/admin/api-keys/{id}/rotate",
+	} {
+		if _, ok := paths[path]; !ok {
+			t.Fatalf("expected %s in openapi paths", path)
+		}
+	}
+	components, _ := body["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+	for _, name := range []string{"ErrorEnvelope", "HITLMetadata"} {
+		if _, ok := schemas[name]; !ok {
+			t.Fatalf("expected %s schema in openapi components", name)
+		}
+	}
+}
+
+func TestUsageIsMeteredPerTenantAndDay(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	suggest := func(tenantID string) {
+		t.Helper()
+		status, body := postJSON(t, client, baseURL+"/v1/suggestions", map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       tenantID,
+				"conversation_id": "chat-usage-1",
+				"channel":         "whatsapp_web",
+			},
+			"locale":         "pt-BR",
+			"tone":           "neutro",
+			"context_window": 10,
+			"messages":       []string{"Qual o prazo de entrega?"},
+		}, nil)
+		if status != http.StatusOK {
+			t.Fatalf("expected suggestions, got %d body=%+v", status, body)
+		}
+	}
+
+	suggest("tenant-usage")
+	suggest("tenant-usage")
+	suggest("tenant-usage-other")
+	// Flushed and pending counts of the same day are added up.
+	if err := runtime.metering.Flush(context.Background()); err != nil {
+		t.Fatalf("flush usage: %v", err)
+	}
+	suggest("tenant-usage")
+
+	status, body := getJSON(t, client, baseURL+"/v1/usage?tenant_id=tenant-usage")
+	if status != http.StatusOK {
+		t.Fatalf("expected usage, got %d body=%+v", status, body)
+	}
+	items, _ := body["items"].([]any)
+	if len(items) != 1 {
+		t.Fatalf("expected one day of usage, got %+v", body)
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	day := items[0].(map[string]any)
+	if day["day"] != today || day["requests"] != float64(3) || day["cache_hits"] != float64(0) {
+		t.Fatalf("expected three requests today, got %+v", day)
+	}
+	totals := body["totals"].(map[string]any)
+	if totals["requests"] != float64(3) || body["to"] != today {
+		t.Fatalf("expected totals over the default window, got %+v", body)
+	}
+
+	status, body = getJSON(t, client, baseURL+"/v1/usage?tenant_id=tenant-usage&to=2020-01-01")
+	if status != http.StatusOK || len(body["items"].([]any)) != 0 {
+		t.Fatalf("expected no usage before today, got %d body=%+v", status, body)
+	}
+	status, body = getJSON(t, client, baseURL+"/v1/usage?tenant_id=tenant-usage&from=yesterday")
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected an invalid from date to be rejected, got %d body=%+v", status, body)
+	}
+	status, body = getJSON(t, client, baseURL+"/v1/usage")
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected tenant_id to be required, got %d body=%+v", status, body)
+	}
+}
+
+func TestAdminSLOReportsObjectives(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+	client := runtime.server.Client()
+	admin := map[string]string{"Authorization": "Bearer " + integrationAdminToken}
+
+	for i := 0; i < 3; i++ {
+		if status, body := postJSON(t, client, runtime.server.URL+"/v1/suggestions", map[string]any{
+			"conversation": map[string]any{
+				"tenant_id":       "default",
+				"conversation_id": "chat-slo-1",
+				"channel":         "whatsapp_web",
+			},
+			"tone":           "neutro",
+			"context_window": 10,
+			"messages":       []string{"Oi, consegue ver meu pedido?"},
+		}, nil); status != http.StatusOK {
+			t.Fatalf("expected 200 from suggestions, got %d body=%+v", status, body)
+		}
+	}
+
+	if status, _ := getJSON(t, client, runtime.server.URL+"/admin/slo"); status != http.StatusUnauthorized {
+		t.Fatalf("expected the admin token to be required, got %d", status)
+	}
+	status, body := getJSONWithHeaders(t, client, runtime.server.URL+"/admin/slo", admin)
+	if status != http.StatusOK {
+		t.Fatalf("expected slo report, got %d body=%+v", status, body)
+	}
+	objectives, _ := body["objectives"].([]any)
+	if len(objectives) != 2 {
+		t.Fatalf("expected QT-001 and QT-002, got %+v", body)
+	}
+	summary := objectives[0].(map[string]any)
+	if summary["name"] != "QT-001" || summary["threshold_ms"] != float64(5000) || summary["total"] != float64(0) {
+		t.Fatalf("expected an idle summary objective, got %+v", summary)
+	}
+	suggestion := objectives[1].(map[string]any)
+	budget := suggestion["error_budget"].(map[string]any)
+	burnRates := suggestion["burn_rates"].(map[string]any)
+	if suggestion["name"] != "QT-002" || suggestion["total"] != float64(3) || suggestion["met"] != true ||
+		budget["remaining"] != float64(1) || burnRates["5m"] != float64(0) {
+		t.Fatalf("expected three good suggestions, got %+v", suggestion)
+	}
+}
+
+func TestAdminLogLevelFiltersDebugByTenant(t *testing.T) {
+	runtime := startIntegrationRuntime(t)
+	defer runtime.cancel()
+	client := runtime.server.Client()
+	admin := map[string]string{"Authorization": "Bearer " + integrationAdminToken}
+	url := runtime.server.URL + "/admin/log-level"
+
+	status, body := getJSONWithHeaders(t, client, url, admin)
+	if status != http.StatusOK || body["level"] != "info" || body["configured_level"] != "info" {
+		t.Fatalf("expected the configured info level, got %d body=%+v", status, body)
+	}
+
+	status, body = sendJSON(t, client, http.MethodPut, url, map[string]any{"level": "verbose"}, admin)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected an unknown level to be rejected, got %d body=%+v", status, body)
+	}
+
+	status, body = sendJSON(t, client, http.MethodPut, url, map[string]any{
+		"level":     "debug",
+		"tenant_id": "tenant-incident",
+	}, admin)
+	if status != http.StatusOK {
+		t.Fatalf("expected the debug filter to be set, got %d body=%+v", status, body)
+	}
+	filter, _ := body["filter"].(map[string]any)
+	if body["level"] != "info" || filter["level"] != "debug" || filter["tenant_id"] != "tenant-incident" || filter["expires_at"] == nil {
+		t.Fatalf("expected debug only for the tenant, with an expiry, got %+v", body)
+	}
+
+	status, body = sendJSON(t, client, http.MethodPut, url, map[string]any{"level": "warn", "ttl_seconds": 60}, admin)
+	if status != http.StatusOK || body["level"] != "warn" || body["expires_at"] == nil || body["filter"] == nil {
+		t.Fatalf("expected a temporary global warn level next to the filter, got %d body=%+v", status, body)
+	}
+
+	status, body = sendJSON(t, client, http.MethodDelete, url, nil, admin)
+	if status != http.StatusOK || body["level"] != "info" || body["filter"] != nil || body["expires_at"] != nil {
+		t.Fatalf("expected the configured level back without filter, got %d body=%+v", status, body)
+	}
+}
+
+func TestMockGeneratorDrivesTheGenerationPath(t *testing.T) {
+	generator := ai.NewMockGenerator(ai.MockConfig{
+		Responses: append([]ai.MockResponse{
+			// The first summary call fails, so the job falls back once and then succeeds.
+			{Match: "sintetizar os pontos principais", Err: errors.New("provider overloaded"), Times: 1},
+		}, ai.DefaultMockResponses()...),
+		ModelID: "mock/scripted",
+		Seed:    1,
+	})
+	runtime := startIntegrationRuntimeWith(t, integrationOptions{Generator: generator})
+	defer runtime.cancel()
+
+	client := runtime.server.Client()
+	baseURL := runtime.server.URL
+	conversation := map[string]any{
+		"tenant_id":       "tenant-mock",
+		"conversation_id": "chat-mock-1",
+		"channel":         "whatsapp_web",
+	}
+
+	status, body := postJSON(t, client, baseURL+"/v1/suggestions", map[string]any{
+		"conversation":   conversation,
+		"locale":         "pt-BR",
+		"tone":           "neutro",
+		"context_window": 10,
+		"messages":       []string{"Qual o prazo de entrega do meu pedido?"},
+	}, nil)
+	if status != http.StatusOK || body["model_id"] != "mock/scripted" {
+		t.Fatalf("expected suggestions from the mock model, got %d body=%+v", status, body)
+	}
+	if suggestions, _ := body["suggestions"].([]any); len(suggestions) != 3 {
+		t.Fatalf("expected the three scripted suggestions, got %+v", body)
+	}
+
+	summaryJob := func(conversationID string) map[string]any {
+		t.Helper()
+		status, body := postJSON(t, client, baseURL+"/v1/summaries", map[string]any{
+			"conversation": map[string]any{"tenant_id": "tenant-mock", "conversation_id": conversationID, "channel": "whatsapp_web"},
+			"summary_type": "short",
+		}, map[string]string{"Idempotency-Key": "mock-" + conversationID})
+		if status != http.StatusAccepted {
+			t.Fatalf("expected summary job, got %d body=%+v", status, body)
+		}
+		return waitForTenantJobDone(t, client, baseURL, "tenant-mock", body["job_id"].(string), 5*time.Second)
+	}
+	if job := summaryJob("chat-mock-2"); job["model_id"] == "mock/scripted" {
+		t.Fatalf("expected the scripted failure to fall back, got %+v", job)
+	}
+	job := summaryJob("chat-mock-3")
+	result, _ := job["result"].(map[string]any)
+	if job["model_id"] != "mock/scripted" || !strings.Contains(fmt.Sprint(result["summary"]), "status da entrega") {
+		t.Fatalf("expected the scripted summary, got %+v", job)
+	}
+
+	prompts := 0
+	for _, call := range generator.Calls() {
+		if strings.Contains(call.Input, "sintetizar os pontos principais") {
+			prompts++
+		}
+	}
+	if prompts != 2 {
+		t.Fatalf("expected two summary prompts, got %d", prompts)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// baselineOptions gate a run against a saved report: a scenario regresses when its p95
+// grows by more than maxP95IncreasePct and at least minP95DeltaMS, or when its error rate
+// grows by more than maxErrorRateIncrease percentage points.
+type baselineOptions struct {
+	path                 string
+	maxP95IncreasePct    float64
+	minP95DeltaMS        float64
+	maxErrorRateIncrease float64
+}
+
+type scenarioComparison struct {
+	Name                string   `json:"name"`
+	BaselineP95MS       float64  `json:"baseline_p95_ms"`
+	P95MS               float64  `json:"p95_ms"`
+	P95ChangePct        float64  `json:"p95_change_pct"`
+	BaselineErrorRate   float64  `json:"baseline_error_rate_pct"`
+	ErrorRate           float64  `json:"error_rate_pct"`
+	Regressions         []string `json:"regressions,omitempty"`
+	MissingFromBaseline bool     `json:"missing_from_baseline,omitempty"`
+}
+
+type baselineComparison struct {
+	Baseline            string               `json:"baseline"`
+	BaselineGeneratedAt string               `json:"baseline_generated_at_utc"`
+	BaselineEnvironment string               `json:"baseline_environment"`
+	Scenarios           []scenarioComparison `json:"scenarios"`
+	Regressed           bool                 `json:"regressed"`
+}
+
+func loadBaseline(path string) (runResult, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return runResult{}, fmt.Errorf("read baseline: %w", err)
+	}
+	var baseline runResult
+	if err := json.Unmarshal(raw, &baseline); err != nil {
+		return runResult{}, fmt.Errorf("decode baseline %s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+// compareWithBaseline checks every scenario of the run that also ran in the baseline.
+func compareWithBaseline(baseline runResult, results []scenarioResult, opts baselineOptions) *baselineComparison {
+	comparison := &baselineComparison{
+		Baseline:            opts.path,
+		BaselineGeneratedAt: baseline.GeneratedAtUTC,
+		BaselineEnvironment: baseline.Environment,
+	}
+	for _, current := range results {
+		if current.Total == 0 {
+			continue
+		}
+		item := scenarioComparison{Name: current.Name, P95MS: current.P95MS, ErrorRate: errorRate(current)}
+		previous, found := baselineResult(baseline, current.Name)
+		if !found {
+			item.MissingFromBaseline = true
+			comparison.Scenarios = append(comparison.Scenarios, item)
+			continue
+		}
+		item.BaselineP95MS = previous.P95MS
+		item.BaselineErrorRate = errorRate(previous)
+		if previous.P95MS > 0 {
+			item.P95ChangePct = round2((current.P95MS - previous.P95MS) / previous.P95MS * 100)
+		}
+		if delta := current.P95MS - previous.P95MS; item.P95ChangePct > opts.maxP95IncreasePct && delta >= opts.minP95DeltaMS {
+			item.Regressions = append(item.Regressions, fmt.Sprintf("p95 %.2fms -> %.2fms (+%.1f%%, limit %.1f%%)",
+				previous.P95MS, current.P95MS, item.P95ChangePct, opts.maxP95IncreasePct))
+		}
+		if increase := item.ErrorRate - item.BaselineErrorRate; increase > opts.maxErrorRateIncrease {
+			item.Regressions = append(item.Regressions, fmt.Sprintf("error rate %.2f%% -> %.2f%% (+%.2f points, limit %.2f)",
+				item.BaselineErrorRate, item.ErrorRate, increase, opts.maxErrorRateIncrease))
+		}
+		comparison.Regressed = comparison.Regressed || len(item.Regressions) > 0
+		comparison.Scenarios = append(comparison.Scenarios, item)
+	}
+	return comparison
+}
+
+func baselineResult(baseline runResult, name string) (scenarioResult, bool) {
+	for _, result := range baseline.Results {
+		if result.Name == name && result.Total > 0 {
+			return result, true
+		}
+	}
+	return scenarioResult{}, false
+}
+
+// errorRate is the percentage of failed requests.
+func errorRate(result scenarioResult) float64 {
+	if result.Total == 0 {
+		return 0
+	}
+	return round2(float64(result.Errors) / float64(result.Total) * 100)
+}
+
+// summary renders the comparison as the lines printed to stderr.
+func (c *baselineComparison) summary() string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "baseline %s (%s, %s)\n", c.Baseline, c.BaselineEnvironment, c.BaselineGeneratedAt)
+	for _, item := range c.Scenarios {
+		switch {
+		case item.MissingFromBaseline:
+			fmt.Fprintf(&text, "  %-20s not in the baseline\n", item.Name)
+		case len(item.Regressions) > 0:
+			fmt.Fprintf(&text, "  %-20s REGRESSED: %s\n", item.Name, strings.Join(item.Regressions, "; "))
+		default:
+			fmt.Fprintf(&text, "  %-20s ok: p95 %.2fms -> %.2fms (%+.1f%%), errors %.2f%% -> %.2f%%\n",
+				item.Name, item.BaselineP95MS, item.P95MS, item.P95ChangePct, item.BaselineErrorRate, item.ErrorRate)
+		}
+	}
+	return text.String()
+}
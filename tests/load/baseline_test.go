@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareWithBaselineThresholds(t *testing.T) {
+	opts := baselineOptions{path: "baseline.json", maxP95IncreasePct: 20, minP95DeltaMS: 5, maxErrorRateIncrease: 1}
+	result := func(name string, total, errors int, p95 float64) scenarioResult {
+		return scenarioResult{Name: name, Total: total, Success: total - errors, Errors: errors, P95MS: p95}
+	}
+
+	for _, tc := range []struct {
+		name        string
+		baseline    []scenarioResult
+		current     scenarioResult
+		regressions []string
+		missing     bool
+	}{
+		{
+			name:     "within limits",
+			baseline: []scenarioResult{result("reports", 100, 0, 100)},
+			current:  result("reports", 100, 0, 110),
+		},
+		{
+			name:        "p95 over the percentage and the minimum delta",
+			baseline:    []scenarioResult{result("reports", 100, 0, 100)},
+			current:     result("reports", 100, 0, 130),
+			regressions: []string{"p95 100.00ms -> 130.00ms"},
+		},
+		{
+			name:     "p95 over the percentage under the minimum delta",
+			baseline: []scenarioResult{result("reports", 100, 0, 2)},
+			current:  result("reports", 100, 0, 4),
+		},
+		{
+			name:     "p95 over the minimum delta under the percentage",
+			baseline: []scenarioResult{result("reports", 100, 0, 1000)},
+			current:  result("reports", 100, 0, 1100),
+		},
+		{
+			name:        "error rate over the allowed points",
+			baseline:    []scenarioResult{result("reports", 100, 0, 100)},
+			current:     result("reports", 100, 3, 100),
+			regressions: []string{"error rate 0.00% -> 3.00%"},
+		},
+		{
+			name:     "error rate at the allowed points",
+			baseline: []scenarioResult{result("reports", 100, 1, 100)},
+			current:  result("reports", 200, 4, 100),
+		},
+		{
+			name:        "p95 and error rate",
+			baseline:    []scenarioResult{result("reports", 100, 0, 100)},
+			current:     result("reports", 100, 5, 200),
+			regressions: []string{"p95 ", "error rate "},
+		},
+		{
+			name:     "scenario missing from the baseline",
+			baseline: []scenarioResult{result("summaries", 100, 0, 100)},
+			current:  result("reports", 100, 50, 900),
+			missing:  true,
+		},
+		{
+			name:     "scenario that did not run in the baseline",
+			baseline: []scenarioResult{result("reports", 0, 0, 0)},
+			current:  result("reports", 100, 50, 900),
+			missing:  true,
+		},
+	} {
+		comparison := compareWithBaseline(runResult{Results: tc.baseline}, []scenarioResult{tc.current}, opts)
+		if len(comparison.Scenarios) != 1 {
+			t.Fatalf("%s: expected one compared scenario, got %+v", tc.name, comparison.Scenarios)
+		}
+		item := comparison.Scenarios[0]
+		if item.MissingFromBaseline != tc.missing {
+			t.Fatalf("%s: expected missing=%v, got %+v", tc.name, tc.missing, item)
+		}
+		if comparison.Regressed != (len(tc.regressions) > 0) || len(item.Regressions) != len(tc.regressions) {
+			t.Fatalf("%s: expected regressions %q, got regressed=%v %q", tc.name, tc.regressions, comparison.Regressed, item.Regressions)
+		}
+		for index, prefix := range tc.regressions {
+			if !strings.HasPrefix(item.Regressions[index], prefix) {
+				t.Fatalf("%s: expected regression %q, got %q", tc.name, prefix, item.Regressions[index])
+			}
+		}
+	}
+}
+
+func TestCompareWithBaselineSkipsScenariosThatDidNotRun(t *testing.T) {
+	baseline := runResult{Results: []scenarioResult{{Name: "reports", Total: 100, P95MS: 100}}}
+	comparison := compareWithBaseline(baseline, []scenarioResult{{Name: "reports"}}, baselineOptions{})
+	if len(comparison.Scenarios) != 0 || comparison.Regressed {
+		t.Fatalf("expected a scenario without requests to be left out, got %+v", comparison)
+	}
+}
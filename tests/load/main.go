@@ -1,8 +1,9 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -11,12 +12,12 @@ import (
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/iago/extensao-whatsapp-back/internal/ai"
@@ -24,6 +25,8 @@ import (
 	contextbuilder "github.com/iago/extensao-whatsapp-back/internal/context"
 	httpserver "github.com/iago/extensao-whatsapp-back/internal/http"
 	"github.com/iago/extensao-whatsapp-back/internal/http/handlers"
+	"github.com/iago/extensao-whatsapp-back/internal/logging"
+	"github.com/iago/extensao-whatsapp-back/internal/metrics"
 	"github.com/iago/extensao-whatsapp-back/internal/queue"
 	"github.com/iago/extensao-whatsapp-back/internal/repository"
 	"github.com/iago/extensao-whatsapp-back/internal/service"
@@ -41,6 +44,12 @@ type scenarioResult struct {
 	MaxMS         float64  `json:"max_ms"`
 	ThroughputRPS float64  `json:"throughput_rps"`
 	ErrorSamples  []string `json:"error_samples,omitempty"`
+	// WarmupRequests and WarmupErrors count the requests started during -warmup, which are
+	// left out of every other field.
+	WarmupRequests int `json:"warmup_requests,omitempty"`
+	WarmupErrors   int `json:"warmup_errors,omitempty"`
+	// samples are the raw measurements, warmup included, written by -csv and charted by -html.
+	samples []sample
 }
 
 type tokenResult struct {
@@ -52,118 +61,193 @@ type tokenResult struct {
 type runResult struct {
 	GeneratedAtUTC string           `json:"generated_at_utc"`
 	Environment    string           `json:"environment"`
+	WarmupSeconds  float64          `json:"warmup_seconds,omitempty"`
 	Results        []scenarioResult `json:"results"`
 	TokenTuning    tokenResult      `json:"token_tuning"`
 	SLOEvaluation  map[string]bool  `json:"slo_evaluation"`
+	// Soak is set by -duration runs, with the per-interval results and target samples.
+	Soak *soakResult `json:"soak,omitempty"`
+	// Comparison is set by -baseline runs.
+	Comparison *baselineComparison `json:"comparison,omitempty"`
+}
+
+// scenario is one kind of request, built from a scenarioSpec; Total, Concurrency and Rate
+// only apply to fixed-count runs.
+type scenario struct {
+	Name        string
+	Total       int
+	Concurrency int
+	Rate        float64
+	Request     func(index int) error
+}
+
+type sample struct {
+	startedAt  time.Time
+	durationMS float64
+	err        string
+	// warmup marks requests started during -warmup.
+	warmup bool
+}
+
+// sloTarget is a latency objective checked against the p95 of a scenario.
+type sloTarget struct {
+	ID       string
+	Scenario string
+	P95MaxMS float64
+}
+
+var sloTargets = []sloTarget{
+	{ID: "QT-001_summary_endpoint_p95_le_5000ms", Scenario: "summaries_enqueue", P95MaxMS: 5000},
+	{ID: "QT-002_suggestion_endpoint_p95_le_2000ms", Scenario: "suggestions_sync", P95MaxMS: 2000},
 }
 
 type benchmarkEnv struct {
-	server *httptest.Server
-	cancel context.CancelFunc
+	baseURL string
+	name    string
+	close   func()
+}
+
+// targetOptions point the scenarios at a deployed API instead of the in-process server.
+type targetOptions struct {
+	url                string
+	authToken          string
+	tenantID           string
+	caCert             string
+	clientCert         string
+	clientKey          string
+	insecureSkipVerify bool
+	timeout            time.Duration
 }
 
 func main() {
-	suggestionsTotal := flag.Int("suggestions-total", 260, "total suggestion requests")
-	suggestionsConcurrency := flag.Int("suggestions-concurrency", 24, "concurrency for suggestion requests")
-	summariesTotal := flag.Int("summaries-total", 180, "total summary enqueue requests")
-	summariesConcurrency := flag.Int("summaries-concurrency", 28, "concurrency for summary enqueue requests")
-	reportsTotal := flag.Int("reports-total", 180, "total report enqueue requests")
-	reportsConcurrency := flag.Int("reports-concurrency", 28, "concurrency for report enqueue requests")
-	reportsListTotal := flag.Int("reports-list-total", 120, "total report list requests")
-	reportsListConcurrency := flag.Int("reports-list-concurrency", 20, "concurrency for report list requests")
+	scenariosPath := flag.String("scenarios", "", "JSON file with the scenarios to run (default: the built-in tests/load/scenarios.json)")
+	totalOverride := flag.Int("total", 0, "when positive, replaces the total of every scenario")
+	concurrencyOverride := flag.Int("concurrency", 0, "when positive, replaces the concurrency of every scenario")
+	warmup := flag.Duration("warmup", 0, "requests started this long after each scenario (or a soak test) begins are left out of the results")
 	outputPath := flag.String("output", "", "optional path to persist benchmark results JSON")
+	htmlPath := flag.String("html", "", "optional path of a self-contained HTML report with latency charts and the SLO table")
+	csvPath := flag.String("csv", "", "optional path of a CSV with every request measured")
+	var target targetOptions
+	flag.StringVar(&target.url, "target-url", "", "base URL of a deployed API (e.g. https://staging.example.com); empty runs an in-process server")
+	flag.StringVar(&target.authToken, "auth-token", os.Getenv("LOAD_AUTH_TOKEN"), "bearer token sent to the target: API token, JWT or API key (default $LOAD_AUTH_TOKEN)")
+	flag.StringVar(&target.tenantID, "tenant-id", "default", "tenant used in payloads and sent as X-Tenant-ID")
+	flag.StringVar(&target.caCert, "ca-cert", "", "PEM file with the CA that signed the target certificate")
+	flag.StringVar(&target.clientCert, "client-cert", "", "PEM client certificate for targets requiring mTLS")
+	flag.StringVar(&target.clientKey, "client-key", "", "PEM key of -client-cert")
+	flag.BoolVar(&target.insecureSkipVerify, "insecure-skip-verify", false, "skip verification of the target certificate")
+	flag.DurationVar(&target.timeout, "timeout", 10*time.Second, "timeout of each request")
+	mockAI := flag.Bool("mock-ai", false, "answer model calls of the in-process server with ai.MockGenerator instead of the local fallback")
+	var mockConfig ai.MockConfig
+	flag.DurationVar(&mockConfig.Latency.Median, "mock-latency", 800*time.Millisecond, "median latency of mocked model calls")
+	flag.Float64Var(&mockConfig.Latency.Spread, "mock-latency-spread", 0.5, "log-normal spread of mocked model latency (0 = constant)")
+	flag.Float64Var(&mockConfig.ErrorRate, "mock-error-rate", 0, "fraction of mocked model calls that fail, 0..1")
+	var soak soakOptions
+	flag.DurationVar(&soak.duration, "duration", 0, "run a soak test for this long at -rps instead of the fixed request counts")
+	flag.Float64Var(&soak.rps, "rps", 50, "requests per second of a soak test, spread over the scenarios")
+	flag.IntVar(&soak.concurrency, "soak-concurrency", 64, "most requests in flight during a soak test; ticks beyond it are skipped")
+	flag.DurationVar(&soak.interval, "report-interval", time.Minute, "how often a soak test reports and samples the target")
+	flag.StringVar(&soak.metricsURL, "metrics-url", "", "Prometheus endpoint sampled for memory and goroutines (default <target>/metrics; \"off\" disables)")
+	var gate baselineOptions
+	flag.StringVar(&gate.path, "baseline", "", "results JSON of a previous run; exit 1 when a scenario regresses against it")
+	flag.Float64Var(&gate.maxP95IncreasePct, "max-p95-regression", 20, "largest p95 increase over the baseline, in percent")
+	flag.Float64Var(&gate.minP95DeltaMS, "min-p95-regression-ms", 5, "p95 increases smaller than this many milliseconds are noise, whatever the percentage")
+	flag.Float64Var(&gate.maxErrorRateIncrease, "max-error-rate-increase", 1, "largest error rate increase over the baseline, in percentage points")
 	flag.Parse()
 
-	env, err := startBenchmarkEnvironment()
+	specs, err := loadScenarios(*scenariosPath)
 	if err != nil {
-		log.Fatalf("failed to start local benchmark environment: %v", err)
+		log.Fatalf("failed to load scenarios: %v", err)
 	}
-	defer env.cancel()
-	defer env.server.Close()
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	var idCounter int64
-
-	suggestionsScenario := runScenario("suggestions_sync", *suggestionsTotal, *suggestionsConcurrency, func(index int) error {
-		payload := map[string]any{
-			"conversation": map[string]any{
-				"tenant_id":       "default",
-				"conversation_id": fmt.Sprintf("chat-%d", index%32),
-				"channel":         "whatsapp_web",
-			},
-			"locale":                    "pt-BR",
-			"tone":                      "neutro",
-			"context_window":            20 + (index % 6),
-			"max_candidates":            3,
-			"include_last_user_message": true,
+	var baseline runResult
+	if gate.path != "" {
+		loaded, err := loadBaseline(gate.path)
+		if err != nil {
+			log.Fatalf("failed to load baseline: %v", err)
 		}
-		return postJSON(client, env.server.URL+"/v1/suggestions", payload, nil, http.StatusOK)
-	})
+		baseline = loaded
+	}
 
-	summariesScenario := runScenario("summaries_enqueue", *summariesTotal, *summariesConcurrency, func(index int) error {
-		requestID := atomic.AddInt64(&idCounter, 1)
-		payload := map[string]any{
-			"conversation": map[string]any{
-				"tenant_id":       "default",
-				"conversation_id": fmt.Sprintf("summary-chat-%d", index%40),
-				"channel":         "whatsapp_web",
-			},
-			"summary_type":    "short",
-			"include_actions": true,
+	var env *benchmarkEnv
+	if target.url != "" {
+		if *mockAI {
+			log.Printf("warning: -mock-ai only applies to the in-process server; %s uses its own provider", target.url)
 		}
-		headers := map[string]string{
-			"Idempotency-Key": fmt.Sprintf("summary-%d-%d", requestID, time.Now().UnixNano()),
+		env, err = remoteEnvironment(target)
+	} else {
+		var generator ai.TextGenerator
+		if *mockAI {
+			mockConfig.Responses = ai.DefaultMockResponses()
+			mockConfig.ModelID = "mock-generator"
+			generator = ai.NewMockGenerator(mockConfig)
 		}
-		return postJSON(client, env.server.URL+"/v1/summaries", payload, headers, http.StatusAccepted)
-	})
+		env, err = startBenchmarkEnvironment(generator)
+	}
+	if err != nil {
+		log.Fatalf("failed to prepare benchmark target: %v", err)
+	}
+	defer env.close()
 
-	reportsScenario := runScenario("reports_enqueue", *reportsTotal, *reportsConcurrency, func(index int) error {
-		requestID := atomic.AddInt64(&idCounter, 1)
-		payload := map[string]any{
-			"conversation": map[string]any{
-				"tenant_id":       "default",
-				"conversation_id": fmt.Sprintf("report-chat-%d", index%40),
-				"channel":         "whatsapp_web",
-			},
-			"report_type":  "timeline",
-			"topic_filter": "prazo",
-			"page":         1,
-			"page_size":    20,
+	client, err := newTargetClient(target)
+	if err != nil {
+		log.Fatalf("failed to configure HTTP client: %v", err)
+	}
+	var idCounter int64
+	scenarios := make([]scenario, 0, len(specs))
+	for _, spec := range specs {
+		if *totalOverride > 0 {
+			spec.Total = *totalOverride
 		}
-		headers := map[string]string{
-			"Idempotency-Key": fmt.Sprintf("report-%d-%d", requestID, time.Now().UnixNano()),
+		if *concurrencyOverride > 0 {
+			spec.Concurrency = *concurrencyOverride
 		}
-		return postJSON(client, env.server.URL+"/v1/reports", payload, headers, http.StatusAccepted)
-	})
-
-	reportsListScenario := runScenario("reports_list", *reportsListTotal, *reportsListConcurrency, func(index int) error {
-		query := fmt.Sprintf(
-			"%s/v1/reports?tenant_id=default&page=%d&page_size=20&topic=prazo",
-			env.server.URL,
-			(index%6)+1,
-		)
-		return getJSON(client, query, http.StatusOK)
-	})
+		item, err := buildScenario(spec, client, env.baseURL, target.tenantID, &idCounter)
+		if err != nil {
+			env.close()
+			log.Fatalf("invalid scenario: %v", err)
+		}
+		scenarios = append(scenarios, item)
+	}
 
-	tokenTuning := runTokenReductionScenario()
-	results := []scenarioResult{
-		suggestionsScenario,
-		summariesScenario,
-		reportsScenario,
-		reportsListScenario,
+	var (
+		results    []scenarioResult
+		soakReport *soakResult
+	)
+	if soak.duration > 0 {
+		if soak.metricsURL == "" {
+			soak.metricsURL = env.baseURL + "/metrics"
+		}
+		soak.warmup = *warmup
+		if soak.warmup >= soak.duration {
+			log.Fatalf("-warmup (%s) must be shorter than -duration (%s)", soak.warmup, soak.duration)
+		}
+		results, soakReport = runSoak(scenarios, soak, client)
+	} else {
+		for _, item := range scenarios {
+			results = append(results, runScenario(item, *warmup))
+		}
 	}
 
-	slo := map[string]bool{
-		"QT-001_summary_endpoint_p95_le_5000ms":    summariesScenario.P95MS <= 5000,
-		"QT-002_suggestion_endpoint_p95_le_2000ms": suggestionsScenario.P95MS <= 2000,
+	tokenTuning := runTokenReductionScenario()
+	slo := make(map[string]bool, len(sloTargets))
+	for _, target := range sloTargets {
+		// An objective is only evaluated when its scenario ran.
+		if result := findResult(results, target.Scenario); result.Total > 0 {
+			slo[target.ID] = result.P95MS <= target.P95MaxMS
+		}
 	}
 
 	report := runResult{
 		GeneratedAtUTC: time.Now().UTC().Format(time.RFC3339Nano),
-		Environment:    "local-httptest",
+		Environment:    env.name,
+		WarmupSeconds:  warmup.Seconds(),
 		Results:        results,
 		TokenTuning:    tokenTuning,
 		SLOEvaluation:  slo,
+		Soak:           soakReport,
+	}
+	if gate.path != "" {
+		report.Comparison = compareWithBaseline(baseline, results, gate)
+		log.Print(report.Comparison.summary())
 	}
 
 	encoded, err := json.MarshalIndent(report, "", "  ")
@@ -177,25 +261,45 @@ func main() {
 		}
 	}
 
+	if *csvPath != "" {
+		if err := writeSamplesCSV(*csvPath, results); err != nil {
+			log.Fatalf("failed to write CSV samples: %v", err)
+		}
+	}
+	if *htmlPath != "" {
+		if err := writeHTMLReport(*htmlPath, report); err != nil {
+			log.Fatalf("failed to write HTML report: %v", err)
+		}
+	}
+
 	_, _ = fmt.Fprintln(os.Stdout, string(encoded))
+	if report.Comparison != nil && report.Comparison.Regressed {
+		env.close()
+		log.Fatalf("performance regressed against %s", gate.path)
+	}
 }
 
-func startBenchmarkEnvironment() (*benchmarkEnv, error) {
+// startBenchmarkEnvironment serves the API in process; a nil generator leaves the
+// generation service without a client, so every task answers with its local fallback.
+func startBenchmarkEnvironment(generator ai.TextGenerator) (*benchmarkEnv, error) {
 	ctx, cancel := context.WithCancel(context.Background())
-	logger := log.New(io.Discard, "", 0)
+	logger := logging.Discard()
 
 	repo := repository.NewMemoryJobsRepository()
+	messagesRepo := repository.NewMemoryMessagesRepository()
 	localQueue := queue.NewLocalQueue(4096, 3, logger)
 
 	modelRouter := ai.NewModelRouter(ai.ModelRouterConfig{})
-	contextBuilder := contextbuilder.NewBuilder(contextbuilder.NewBasicRetriever())
+	contextBuilder := contextbuilder.NewBuilder(
+		contextbuilder.NewHistoryRetriever(messagesRepo, contextbuilder.NewBasicRetriever()),
+	)
 	semanticCache := cache.NewSemanticCache(cache.Config{
 		TTL:        10 * time.Minute,
 		MaxEntries: 4000,
 	})
 	aiGeneration := service.NewAIGenerationService(service.AIGenerationDependencies{
 		Router:  modelRouter,
-		Client:  nil,
+		Client:  generator,
 		Builder: contextBuilder,
 		Cache:   semanticCache,
 		Logger:  logger,
@@ -203,44 +307,149 @@ func startBenchmarkEnvironment() (*benchmarkEnv, error) {
 
 	jobsService := service.NewJobsService(repo, localQueue)
 	suggestionsService := service.NewSuggestionsService(aiGeneration)
-	api := handlers.NewAPI(jobsService, suggestionsService)
+	api := handlers.NewAPI(handlers.APIDependencies{
+		Jobs:        jobsService,
+		Suggestions: suggestionsService,
+		Erasure:     service.NewErasureService(repo, messagesRepo, nil, nil, repository.NewMemoryAuditRepository(), aiGeneration),
+		Analysis:    service.NewAnalysisService(aiGeneration),
+		Questions:   service.NewQuestionsService(aiGeneration),
+		ActionItems: service.NewActionItemsService(aiGeneration),
+		Compose:     service.NewComposeService(aiGeneration),
+		Digests:     service.NewDigestsService(jobsService, messagesRepo),
+		Insights:    service.NewInsightsService(aiGeneration, messagesRepo),
+		Messages:    service.NewMessagesService(messagesRepo, contextBuilder, nil),
+		HITL:        service.NewHITLService(repository.NewMemoryHITLRepository(), repo),
+		Templates:   service.NewTemplatesService(repository.NewMemoryTemplatesRepository()),
+	})
+	// Served at /metrics for the memory and goroutine samples of soak runs.
+	registry := metrics.NewRegistry()
+	metrics.RegisterProcessMetrics(registry)
 	router := httpserver.NewRouter(httpserver.RouterDependencies{
-		API:            api,
-		Logger:         logger,
-		AuthToken:      "",
-		RateLimitRPS:   20000,
-		RateLimitBurst: 20000,
+		API:              api,
+		Logger:           logger,
+		AuthToken:        "",
+		RateLimitRPS:     20000,
+		RateLimitBurst:   20000,
+		RateLimitIPRPS:   20000,
+		RateLimitIPBurst: 20000,
+		MetricsHandler:   registry.Handler(),
 	})
 
 	processor := worker.NewProcessor(localQueue, repo, aiGeneration, logger)
 	go processor.Start(ctx)
 
 	server := httptest.NewServer(router)
+	name := "local-httptest"
+	if generator != nil {
+		name = "local-httptest-mock-ai"
+	}
 	return &benchmarkEnv{
-		server: server,
-		cancel: cancel,
+		baseURL: server.URL,
+		name:    name,
+		close: func() {
+			server.Close()
+			cancel()
+		},
+	}, nil
+}
+
+// remoteEnvironment checks the target URL; the report names the environment after its host.
+func remoteEnvironment(target targetOptions) (*benchmarkEnv, error) {
+	parsed, err := url.Parse(target.url)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("-target-url must be an absolute http(s) URL, got %q", target.url)
+	}
+	if parsed.Scheme == "http" && target.authToken != "" {
+		log.Printf("warning: sending the auth token over plain HTTP to %s", parsed.Host)
+	}
+	return &benchmarkEnv{
+		baseURL: strings.TrimSuffix(parsed.String(), "/"),
+		name:    "remote:" + parsed.Host,
+		close:   func() {},
 	}, nil
 }
 
-func runScenario(
-	name string,
-	total int,
-	concurrency int,
-	requestFn func(index int) error,
-) scenarioResult {
+// newTargetClient builds the client of every scenario, with the TLS options and the auth
+// and tenant headers of the target.
+func newTargetClient(target targetOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: target.insecureSkipVerify,
+	}
+	if target.caCert != "" {
+		pem, err := os.ReadFile(target.caCert)
+		if err != nil {
+			return nil, fmt.Errorf("read -ca-cert: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("-ca-cert %s holds no PEM certificate", target.caCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if (target.clientCert == "") != (target.clientKey == "") {
+		return nil, fmt.Errorf("-client-cert and -client-key must be set together")
+	}
+	if target.clientCert != "" {
+		certificate, err := tls.LoadX509KeyPair(target.clientCert, target.clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	// Keep a connection per concurrent worker instead of reopening TLS sessions.
+	transport.MaxIdleConnsPerHost = 256
+	return &http.Client{
+		Timeout: target.timeout,
+		Transport: &targetTransport{
+			next:      transport,
+			authToken: target.authToken,
+			tenantID:  target.tenantID,
+		},
+	}, nil
+}
+
+// targetTransport adds the target's auth and tenant headers to every request.
+type targetTransport struct {
+	next      http.RoundTripper
+	authToken string
+	tenantID  string
+}
+
+func (t *targetTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	if t.authToken != "" {
+		request.Header.Set("Authorization", "Bearer "+t.authToken)
+	}
+	if t.tenantID != "" {
+		request.Header.Set("X-Tenant-ID", t.tenantID)
+	}
+	return t.next.RoundTrip(request)
+}
+
+// runScenario sends item.Total requests from item.Concurrency workers. Requests started
+// within warmup of the first one only count as warmup, so cold caches and lazily loaded
+// templates do not skew the percentiles.
+func runScenario(item scenario, warmup time.Duration) scenarioResult {
+	total, concurrency := item.Total, item.Concurrency
 	if total <= 0 {
-		return scenarioResult{Name: name}
+		return scenarioResult{Name: item.Name}
 	}
 	if concurrency <= 0 {
 		concurrency = 1
 	}
-
-	startedAt := time.Now()
-	type sample struct {
-		durationMS float64
-		err        string
+	// pace releases one request per tick when the scenario has a rate.
+	var pace <-chan time.Time
+	if item.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / item.Rate))
+		defer ticker.Stop()
+		pace = ticker.C
 	}
 
+	startedAt := time.Now()
 	jobs := make(chan int, total)
 	results := make(chan sample, total)
 	for i := 0; i < total; i++ {
@@ -254,26 +463,71 @@ func runScenario(
 		go func() {
 			defer wg.Done()
 			for index := range jobs {
-				requestStart := time.Now()
-				err := requestFn(index)
-				s := sample{
-					durationMS: float64(time.Since(requestStart).Microseconds()) / 1000.0,
+				if pace != nil {
+					<-pace
 				}
-				if err != nil {
-					s.err = err.Error()
-				}
-				results <- s
+				results <- measure(item.Request, index)
 			}
 		}()
 	}
 	wg.Wait()
 	close(results)
 
-	durations := make([]float64, 0, total)
+	finishedAt := time.Now()
+	warmupEnd := startedAt.Add(warmup)
+	samples := make([]sample, 0, total)
+	for measured := range results {
+		measured.warmup = measured.startedAt.Before(warmupEnd)
+		samples = append(samples, measured)
+	}
+	result := summarize(item.Name, samples, measuredElapsed(startedAt, finishedAt, warmupEnd))
+	if result.Total == 0 {
+		log.Printf("warning: scenario %s finished within the %s warmup; raise its total or lower -warmup", item.Name, warmup)
+	}
+	return result
+}
+
+// measuredElapsed is the part of [start, end] after the warmup.
+func measuredElapsed(start, end, warmupEnd time.Time) time.Duration {
+	if warmupEnd.After(start) {
+		start = warmupEnd
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// measure runs one request and records its latency and error.
+func measure(requestFn func(index int) error, index int) sample {
+	requestStart := time.Now()
+	err := requestFn(index)
+	s := sample{
+		startedAt:  requestStart,
+		durationMS: float64(time.Since(requestStart).Microseconds()) / 1000.0,
+	}
+	if err != nil {
+		s.err = err.Error()
+	}
+	return s
+}
+
+// summarize computes the result of samples, leaving the warmup ones out; elapsed is the
+// time the measured samples took.
+func summarize(name string, samples []sample, elapsed time.Duration) scenarioResult {
+	durations := make([]float64, 0, len(samples))
 	errorSamples := make([]string, 0, 5)
 	success := 0
 	errorsCount := 0
-	for item := range results {
+	warmupRequests, warmupErrors := 0, 0
+	for _, item := range samples {
+		if item.warmup {
+			warmupRequests++
+			if item.err != "" {
+				warmupErrors++
+			}
+			continue
+		}
 		durations = append(durations, item.durationMS)
 		if item.err == "" {
 			success++
@@ -286,70 +540,39 @@ func runScenario(
 	}
 
 	sort.Float64s(durations)
-	elapsedSeconds := time.Since(startedAt).Seconds()
+	elapsedSeconds := elapsed.Seconds()
 	throughput := 0.0
 	if elapsedSeconds > 0 {
-		throughput = float64(total) / elapsedSeconds
-	}
-
-	result := scenarioResult{
-		Name:          name,
-		Total:         total,
-		Success:       success,
-		Errors:        errorsCount,
-		P50MS:         percentile(durations, 0.50),
-		P95MS:         percentile(durations, 0.95),
-		P99MS:         percentile(durations, 0.99),
-		MaxMS:         percentile(durations, 1.00),
-		ThroughputRPS: round2(throughput),
-		ErrorSamples:  errorSamples,
+		throughput = float64(len(durations)) / elapsedSeconds
+	}
+
+	return scenarioResult{
+		Name:           name,
+		Total:          len(durations),
+		Success:        success,
+		Errors:         errorsCount,
+		P50MS:          percentile(durations, 0.50),
+		P95MS:          percentile(durations, 0.95),
+		P99MS:          percentile(durations, 0.99),
+		MaxMS:          percentile(durations, 1.00),
+		ThroughputRPS:  round2(throughput),
+		ErrorSamples:   errorSamples,
+		WarmupRequests: warmupRequests,
+		WarmupErrors:   warmupErrors,
+		samples:        samples,
 	}
-	return result
 }
 
-func postJSON(
-	client *http.Client,
-	url string,
-	payload any,
-	headers map[string]string,
-	expectedStatus int,
-) error {
-	encoded, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("marshal payload: %w", err)
-	}
-
-	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(encoded))
-	if err != nil {
-		return fmt.Errorf("new request: %w", err)
-	}
-	request.Header.Set("Content-Type", "application/json")
-	request.Header.Set("Accept", "application/json")
-	for key, value := range headers {
-		request.Header.Set(key, value)
-	}
-
-	response, err := client.Do(request)
-	if err != nil {
-		return err
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode != expectedStatus {
-		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
-		return fmt.Errorf("unexpected status %d (expected %d): %s", response.StatusCode, expectedStatus, string(body))
+func findResult(results []scenarioResult, name string) scenarioResult {
+	for _, result := range results {
+		if result.Name == name {
+			return result
+		}
 	}
-	_, _ = io.Copy(io.Discard, response.Body)
-	return nil
+	return scenarioResult{Name: name}
 }
 
-func getJSON(client *http.Client, url string, expectedStatus int) error {
-	request, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return fmt.Errorf("new request: %w", err)
-	}
-	request.Header.Set("Accept", "application/json")
-
+func doRequest(client *http.Client, request *http.Request, expectedStatus int) error {
 	response, err := client.Do(request)
 	if err != nil {
 		return err
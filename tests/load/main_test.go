@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMeasuredElapsed(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name      string
+		end       time.Time
+		warmupEnd time.Time
+		want      time.Duration
+	}{
+		{"no warmup", start.Add(10 * time.Second), time.Time{}, 10 * time.Second},
+		{"warmup ending at the start", start.Add(10 * time.Second), start, 10 * time.Second},
+		{"warmup ending before the start", start.Add(10 * time.Second), start.Add(-time.Second), 10 * time.Second},
+		{"warmup inside the run", start.Add(10 * time.Second), start.Add(4 * time.Second), 6 * time.Second},
+		{"warmup ending with the run", start.Add(10 * time.Second), start.Add(10 * time.Second), 0},
+		{"warmup longer than the run", start.Add(10 * time.Second), start.Add(15 * time.Second), 0},
+		{"empty run", start, time.Time{}, 0},
+	} {
+		if got := measuredElapsed(start, tc.end, tc.warmupEnd); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
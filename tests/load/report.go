@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	chartWidth  = 720
+	chartHeight = 240
+	chartLeft   = 60
+	chartRight  = 16
+	chartTop    = 12
+	chartBottom = 34
+	// chartMaxPoints bounds the points drawn per scenario so soak reports stay small;
+	// failures are always drawn.
+	chartMaxPoints = 2000
+)
+
+// writeSamplesCSV writes one row per measured request, in start order, for spreadsheets and
+// plotting tools. elapsed_ms counts from the first request of the run.
+func writeSamplesCSV(path string, results []scenarioResult) error {
+	type row struct {
+		scenario string
+		sample
+	}
+	var rows []row
+	for _, result := range results {
+		for _, item := range result.samples {
+			rows = append(rows, row{scenario: result.Name, sample: item})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].startedAt.Before(rows[j].startedAt) })
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	_ = writer.Write([]string{"scenario", "started_at_utc", "elapsed_ms", "duration_ms", "ok", "warmup", "error"})
+	var first time.Time
+	if len(rows) > 0 {
+		first = rows[0].startedAt
+	}
+	for _, item := range rows {
+		_ = writer.Write([]string{
+			item.scenario,
+			item.startedAt.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(float64(item.startedAt.Sub(first).Microseconds())/1000, 'f', 3, 64),
+			strconv.FormatFloat(item.durationMS, 'f', 3, 64),
+			strconv.FormatBool(item.err == ""),
+			strconv.FormatBool(item.warmup),
+			item.err,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0o644)
+}
+
+type chartPoint struct {
+	X, Y   float64
+	Failed bool
+	Warmup bool
+}
+
+type chartTick struct {
+	Position float64
+	Label    string
+}
+
+// latencyChart is the latency of each request of a scenario over the run, already scaled
+// to SVG coordinates.
+type latencyChart struct {
+	Name      string
+	Points    []chartPoint
+	Drawn     int
+	Total     int
+	P95Y      float64
+	YTicks    []chartTick
+	XTicks    []chartTick
+	PlotLeft  float64
+	PlotRight float64
+	PlotTop   float64
+	PlotBase  float64
+}
+
+type sloRow struct {
+	ID       string
+	Scenario string
+	P95MS    float64
+	TargetMS float64
+	Met      bool
+}
+
+type htmlReport struct {
+	runResult
+	SLOs   []sloRow
+	Charts []latencyChart
+	Width  int
+	Height int
+}
+
+// writeHTMLReport renders report as a single HTML file with inline CSS and SVG, so it can be
+// mailed or attached to a ticket and opened without network access.
+func writeHTMLReport(path string, report runResult) error {
+	view := htmlReport{runResult: report, Width: chartWidth, Height: chartHeight}
+	for _, target := range sloTargets {
+		met, evaluated := report.SLOEvaluation[target.ID]
+		if !evaluated {
+			continue
+		}
+		result := findResult(report.Results, target.Scenario)
+		view.SLOs = append(view.SLOs, sloRow{
+			ID:       target.ID,
+			Scenario: target.Scenario,
+			P95MS:    result.P95MS,
+			TargetMS: target.P95MaxMS,
+			Met:      met,
+		})
+	}
+	for _, result := range report.Results {
+		if len(result.samples) > 0 {
+			view.Charts = append(view.Charts, newLatencyChart(result))
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := reportTemplate.Execute(&buffer, view); err != nil {
+		return fmt.Errorf("render HTML report: %w", err)
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0o644)
+}
+
+func newLatencyChart(result scenarioResult) latencyChart {
+	samples := append([]sample(nil), result.samples...)
+	sort.Slice(samples, func(i, j int) bool { return samples[i].startedAt.Before(samples[j].startedAt) })
+
+	chart := latencyChart{
+		Name:      result.Name,
+		Total:     len(samples),
+		PlotLeft:  chartLeft,
+		PlotRight: chartWidth - chartRight,
+		PlotTop:   chartTop,
+		PlotBase:  chartHeight - chartBottom,
+	}
+	first := samples[0].startedAt
+	spanSeconds := samples[len(samples)-1].startedAt.Sub(first).Seconds()
+	maxMS := 0.0
+	for _, item := range samples {
+		maxMS = math.Max(maxMS, item.durationMS)
+	}
+	yMax := niceCeil(maxMS)
+	xMax := niceCeil(spanSeconds)
+
+	x := func(seconds float64) float64 {
+		return chart.PlotLeft + seconds/xMax*(chart.PlotRight-chart.PlotLeft)
+	}
+	y := func(ms float64) float64 {
+		return chart.PlotBase - ms/yMax*(chart.PlotBase-chart.PlotTop)
+	}
+
+	stride := (len(samples) + chartMaxPoints - 1) / chartMaxPoints
+	for index, item := range samples {
+		failed := item.err != ""
+		if index%stride != 0 && !failed {
+			continue
+		}
+		chart.Points = append(chart.Points, chartPoint{
+			X:      round2(x(item.startedAt.Sub(first).Seconds())),
+			Y:      round2(y(item.durationMS)),
+			Failed: failed,
+			Warmup: item.warmup,
+		})
+	}
+	chart.Drawn = len(chart.Points)
+	chart.P95Y = round2(y(result.P95MS))
+	for step := 0; step <= 4; step++ {
+		fraction := float64(step) / 4
+		chart.YTicks = append(chart.YTicks, chartTick{Position: round2(y(yMax * fraction)), Label: formatTick(yMax*fraction, "ms")})
+		xLabel := formatTick(xMax*fraction, "s")
+		if xMax < 1 {
+			xLabel = formatTick(xMax*fraction*1000, "ms")
+		}
+		chart.XTicks = append(chart.XTicks, chartTick{Position: round2(x(xMax * fraction)), Label: xLabel})
+	}
+	return chart
+}
+
+// niceCeil rounds value up to 1, 2 or 5 times a power of ten, for readable axes.
+func niceCeil(value float64) float64 {
+	if value <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(value)))
+	for _, factor := range []float64{1, 2, 5, 10} {
+		if value <= factor*magnitude {
+			return factor * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func formatTick(value float64, unit string) string {
+	if value == math.Trunc(value) {
+		return strconv.FormatFloat(value, 'f', 0, 64) + unit
+	}
+	return strconv.FormatFloat(value, 'g', 3, 64) + unit
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms": func(value float64) string { return strconv.FormatFloat(value, 'f', 2, 64) },
+	"pct": func(result scenarioResult) string {
+		return strconv.FormatFloat(errorRate(result), 'f', 2, 64) + "%"
+	},
+	"bytes": func(value float64) string { return strconv.FormatFloat(value/(1<<20), 'f', 1, 64) + " MiB" },
+}).Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>Teste de carga - {{.Environment}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2rem auto; max-width: 780px; color: #1f2933; }
+h1 { font-size: 1.5rem; margin-bottom: 0.2rem; }
+h2 { font-size: 1.15rem; margin-top: 2rem; border-bottom: 1px solid #d9e2ec; padding-bottom: 0.3rem; }
+.meta { color: #616e7c; margin-top: 0; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9rem; }
+th, td { text-align: right; padding: 0.35rem 0.5rem; border-bottom: 1px solid #e4e7eb; }
+th:first-child, td:first-child { text-align: left; }
+.ok { color: #1f7a3a; font-weight: 600; }
+.fail { color: #b42318; font-weight: 600; }
+svg { display: block; margin: 0.5rem 0 0.2rem; }
+svg text { font-size: 11px; fill: #616e7c; }
+.note { color: #616e7c; font-size: 0.85rem; }
+</style>
+</head>
+<body>
+<h1>Teste de carga</h1>
+<p class="meta">Ambiente <strong>{{.Environment}}</strong>, gerado em {{.GeneratedAtUTC}}{{if .WarmupSeconds}}; os primeiros {{ms .WarmupSeconds}} s de cada cenario (ou do soak test) foram de aquecimento{{end}}</p>
+
+<h2>Objetivos de latencia (SLO)</h2>
+<table>
+<tr><th>Objetivo</th><th>Cenario</th><th>p95 medido</th><th>Limite</th><th>Resultado</th></tr>
+{{range .SLOs}}<tr><td>{{.ID}}</td><td>{{.Scenario}}</td><td>{{ms .P95MS}} ms</td><td>{{ms .TargetMS}} ms</td><td>{{if .Met}}<span class="ok">atendido</span>{{else}}<span class="fail">violado</span>{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Cenarios</h2>
+<table>
+<tr><th>Cenario</th><th>Requisicoes</th><th>Erros</th><th>p50</th><th>p95</th><th>p99</th><th>Max</th><th>req/s</th></tr>
+{{range .Results}}<tr><td>{{.Name}}</td><td>{{.Total}}</td><td>{{pct .}}</td><td>{{ms .P50MS}} ms</td><td>{{ms .P95MS}} ms</td><td>{{ms .P99MS}} ms</td><td>{{ms .MaxMS}} ms</td><td>{{ms .ThroughputRPS}}</td></tr>
+{{end}}</table>
+{{with .Comparison}}
+<h2>Comparacao com o baseline</h2>
+<p class="meta">{{.Baseline}} ({{.BaselineEnvironment}}, {{.BaselineGeneratedAt}})</p>
+<table>
+<tr><th>Cenario</th><th>p95 baseline</th><th>p95 atual</th><th>Variacao</th><th>Erros baseline</th><th>Erros atual</th><th>Resultado</th></tr>
+{{range .Scenarios}}<tr><td>{{.Name}}</td>{{if .MissingFromBaseline}}<td colspan="5">fora do baseline</td><td>-</td>{{else}}<td>{{ms .BaselineP95MS}} ms</td><td>{{ms .P95MS}} ms</td><td>{{ms .P95ChangePct}}%</td><td>{{ms .BaselineErrorRate}}%</td><td>{{ms .ErrorRate}}%</td><td>{{if .Regressions}}<span class="fail">regrediu</span>{{else}}<span class="ok">ok</span>{{end}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}
+<h2>Latencia por requisicao</h2>
+<p class="note">Cada ponto e uma requisicao, na ordem em que comecou; os vermelhos falharam e os cinza sao do aquecimento, fora dos percentis. A linha tracejada marca o p95.</p>
+{{range .Charts}}
+<h3>{{.Name}}</h3>
+<svg width="{{$.Width}}" height="{{$.Height}}" viewBox="0 0 {{$.Width}} {{$.Height}}" role="img" aria-label="Latencia de {{.Name}}">
+{{$chart := .}}{{range .YTicks}}<line x1="{{$chart.PlotLeft}}" x2="{{$chart.PlotRight}}" y1="{{.Position}}" y2="{{.Position}}" stroke="#e4e7eb"/>
+<text x="{{$chart.PlotLeft}}" y="{{.Position}}" dx="-6" dy="4" text-anchor="end">{{.Label}}</text>
+{{end}}{{range .XTicks}}<text x="{{.Position}}" y="{{$chart.PlotBase}}" dy="18" text-anchor="middle">{{.Label}}</text>
+{{end}}{{range .Points}}<circle cx="{{.X}}" cy="{{.Y}}" r="2" fill="{{if .Failed}}#d64545{{else if .Warmup}}#9aa5b1{{else}}#3e7bfa{{end}}" fill-opacity="0.6"/>
+{{end}}<line x1="{{.PlotLeft}}" x2="{{.PlotRight}}" y1="{{.P95Y}}" y2="{{.P95Y}}" stroke="#f0b429" stroke-width="1.5" stroke-dasharray="6 4"/>
+</svg>
+{{if lt .Drawn .Total}}<p class="note">{{.Drawn}} de {{.Total}} requisicoes desenhadas; o CSV (-csv) traz todas.</p>{{end}}
+{{end}}
+{{with .Soak}}
+<h2>Soak test</h2>
+<p class="meta">{{ms .DurationSeconds}} s a {{ms .TargetRPS}} req/s; {{.Skipped}} disparos descartados. Crescimento: heap {{bytes .HeapGrowthBytes}}, RSS {{bytes .ResidentGrowthBytes}}, {{.GoroutineGrowth}} goroutines.</p>
+<table>
+<tr><th>Tempo</th><th>Requisicoes</th><th>Erros</th><th>p50</th><th>p95</th><th>p99</th><th>Heap</th><th>Goroutines</th></tr>
+{{range .Intervals}}<tr><td>{{ms .ElapsedSeconds}} s</td><td>{{.Requests}}</td><td>{{.Errors}}</td><td>{{ms .P50MS}} ms</td><td>{{ms .P95MS}} ms</td><td>{{ms .P99MS}} ms</td>{{with .Target}}<td>{{bytes .HeapAllocBytes}}</td><td>{{.Goroutines}}</td>{{else}}<td>-</td><td>-</td>{{end}}</tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// defaultScenarios is used when -scenarios is empty; it doubles as the reference example of
+// the file format.
+//
+//go:embed scenarios.json
+var defaultScenarios []byte
+
+// scenarioFile is the format of -scenarios.
+type scenarioFile struct {
+	Scenarios []scenarioSpec `json:"scenarios"`
+}
+
+// scenarioSpec describes one endpoint to load. Path, header values and the string values of
+// Payload are text/template templates rendered for every request with requestVars.
+type scenarioSpec struct {
+	Name    string            `json:"name"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Payload json.RawMessage   `json:"payload"`
+	Total   int               `json:"total"`
+	// Concurrency is the number of workers of a fixed-count run.
+	Concurrency int `json:"concurrency"`
+	// Rate caps the requests started per second in a fixed-count run; zero sends as fast as
+	// the workers allow. Soak runs use -rps instead.
+	Rate           float64 `json:"rate"`
+	ExpectedStatus int     `json:"expected_status"`
+}
+
+// requestVars are the fields available to the templates of a scenario.
+type requestVars struct {
+	// Index is the position of the request in its scenario, from zero.
+	Index    int
+	TenantID string
+	// Unique differs on every request of the run, for idempotency keys.
+	Unique string
+}
+
+var templateFuncs = template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+	"mod": func(a, b int) int { return a % b },
+	// int marks a payload string rendered as a JSON number: "{{int .Index}}".
+	"int": func(value int) int { return value },
+}
+
+// loadScenarios reads the scenario file at path, or the built-in scenarios when path is
+// empty.
+func loadScenarios(path string) ([]scenarioSpec, error) {
+	raw, source := defaultScenarios, "built-in scenarios"
+	if path != "" {
+		source = path
+		read, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read scenarios: %w", err)
+		}
+		raw = read
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var file scenarioFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", source, err)
+	}
+	if len(file.Scenarios) == 0 {
+		return nil, fmt.Errorf("%s: no scenario defined", source)
+	}
+	seen := make(map[string]bool, len(file.Scenarios))
+	for index := range file.Scenarios {
+		spec := &file.Scenarios[index]
+		if spec.Name == "" {
+			return nil, fmt.Errorf("scenario %d: name is required", index+1)
+		}
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("scenario %s: name is repeated", spec.Name)
+		}
+		seen[spec.Name] = true
+		if !strings.HasPrefix(spec.Path, "/") {
+			return nil, fmt.Errorf("scenario %s: path must start with /", spec.Name)
+		}
+		if spec.Method == "" {
+			spec.Method = http.MethodGet
+			if len(spec.Payload) > 0 {
+				spec.Method = http.MethodPost
+			}
+		}
+		spec.Method = strings.ToUpper(spec.Method)
+		if spec.ExpectedStatus == 0 {
+			spec.ExpectedStatus = http.StatusOK
+		}
+		if spec.Total < 0 || spec.Concurrency < 0 || spec.Rate < 0 {
+			return nil, fmt.Errorf("scenario %s: total, concurrency and rate must not be negative", spec.Name)
+		}
+	}
+	return file.Scenarios, nil
+}
+
+// buildScenario compiles the templates of spec into a request function against baseURL.
+// Rendering every template once up front reports template and payload mistakes before the
+// run starts.
+func buildScenario(spec scenarioSpec, client *http.Client, baseURL, tenantID string, counter *int64) (scenario, error) {
+	path, err := template.New("path").Funcs(templateFuncs).Parse(spec.Path)
+	if err != nil {
+		return scenario{}, fmt.Errorf("scenario %s: path: %w", spec.Name, err)
+	}
+	headers := make(map[string]*template.Template, len(spec.Headers))
+	for name, value := range spec.Headers {
+		compiled, err := template.New(name).Funcs(templateFuncs).Parse(value)
+		if err != nil {
+			return scenario{}, fmt.Errorf("scenario %s: header %s: %w", spec.Name, name, err)
+		}
+		headers[name] = compiled
+	}
+	var payload payloadNode
+	if len(spec.Payload) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(spec.Payload))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return scenario{}, fmt.Errorf("scenario %s: payload: %w", spec.Name, err)
+		}
+		if payload, err = compilePayload(value); err != nil {
+			return scenario{}, fmt.Errorf("scenario %s: payload: %w", spec.Name, err)
+		}
+	}
+
+	build := func(index int) (*http.Request, error) {
+		vars := requestVars{
+			Index:    index,
+			TenantID: tenantID,
+			Unique:   fmt.Sprintf("%d-%d", atomic.AddInt64(counter, 1), time.Now().UnixNano()),
+		}
+		target, err := render(path, vars)
+		if err != nil {
+			return nil, fmt.Errorf("render path: %w", err)
+		}
+		var body []byte
+		if payload != nil {
+			value, err := payload(vars)
+			if err != nil {
+				return nil, fmt.Errorf("render payload: %w", err)
+			}
+			if body, err = json.Marshal(value); err != nil {
+				return nil, fmt.Errorf("marshal payload: %w", err)
+			}
+		}
+		request, err := http.NewRequest(spec.Method, baseURL+target, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("new request: %w", err)
+		}
+		request.Header.Set("Accept", "application/json")
+		if body != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		for name, compiled := range headers {
+			value, err := render(compiled, vars)
+			if err != nil {
+				return nil, fmt.Errorf("render header %s: %w", name, err)
+			}
+			request.Header.Set(name, value)
+		}
+		return request, nil
+	}
+	if _, err := build(0); err != nil {
+		return scenario{}, fmt.Errorf("scenario %s: %w", spec.Name, err)
+	}
+
+	return scenario{
+		Name:        spec.Name,
+		Total:       spec.Total,
+		Concurrency: spec.Concurrency,
+		Rate:        spec.Rate,
+		Request: func(index int) error {
+			request, err := build(index)
+			if err != nil {
+				return err
+			}
+			return doRequest(client, request, spec.ExpectedStatus)
+		},
+	}, nil
+}
+
+// payloadNode renders one value of a payload.
+type payloadNode func(vars requestVars) (any, error)
+
+// compilePayload turns every string of value containing a template action into a template;
+// a string that is a single {{int ...}} action renders as a number.
+func compilePayload(value any) (payloadNode, error) {
+	switch typed := value.(type) {
+	case map[string]any:
+		fields := make(map[string]payloadNode, len(typed))
+		for key, item := range typed {
+			node, err := compilePayload(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			fields[key] = node
+		}
+		return func(vars requestVars) (any, error) {
+			rendered := make(map[string]any, len(fields))
+			for key, node := range fields {
+				value, err := node(vars)
+				if err != nil {
+					return nil, err
+				}
+				rendered[key] = value
+			}
+			return rendered, nil
+		}, nil
+	case []any:
+		items := make([]payloadNode, 0, len(typed))
+		for index, item := range typed {
+			node, err := compilePayload(item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", index, err)
+			}
+			items = append(items, node)
+		}
+		return func(vars requestVars) (any, error) {
+			rendered := make([]any, 0, len(items))
+			for _, node := range items {
+				value, err := node(vars)
+				if err != nil {
+					return nil, err
+				}
+				rendered = append(rendered, value)
+			}
+			return rendered, nil
+		}, nil
+	case string:
+		if !strings.Contains(typed, "{{") {
+			break
+		}
+		compiled, err := template.New("payload").Funcs(templateFuncs).Parse(typed)
+		if err != nil {
+			return nil, err
+		}
+		numeric := strings.HasPrefix(typed, "{{int ") && strings.HasSuffix(typed, "}}") && strings.Count(typed, "{{") == 1
+		return func(vars requestVars) (any, error) {
+			text, err := render(compiled, vars)
+			if err != nil || !numeric {
+				return text, err
+			}
+			return strconv.Atoi(text)
+		}, nil
+	}
+	return func(requestVars) (any, error) { return value, nil }, nil
+}
+
+func render(compiled *template.Template, vars requestVars) (string, error) {
+	var text strings.Builder
+	if err := compiled.Execute(&text, vars); err != nil {
+		return "", err
+	}
+	return text.String(), nil
+}
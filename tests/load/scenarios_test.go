@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildScenarioRendersTemplates(t *testing.T) {
+	type received struct {
+		method string
+		uri    string
+		key    string
+		body   map[string]any
+	}
+	requests := make(chan received, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(raw, &body)
+		requests <- received{method: r.Method, uri: r.URL.RequestURI(), key: r.Header.Get("Idempotency-Key"), body: body}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	spec := scenarioSpec{
+		Name:    "reports",
+		Method:  http.MethodPost,
+		Path:    "/v1/reports?tenant_id={{urlquery .TenantID}}&page={{add (mod .Index 3) 1}}",
+		Headers: map[string]string{"Idempotency-Key": "report-{{.Unique}}"},
+		Payload: json.RawMessage(`{
+			"conversation": {"tenant_id": "{{.TenantID}}", "conversation_id": "chat-{{mod .Index 2}}"},
+			"context_window": "{{int (add 20 .Index)}}",
+			"labels": ["{{.Index}}", 3, true],
+			"report_type": "timeline"
+		}`),
+		ExpectedStatus: http.StatusAccepted,
+	}
+	var counter int64
+	built, err := buildScenario(spec, server.Client(), server.URL, "tenant a", &counter)
+	if err != nil {
+		t.Fatalf("build scenario: %v", err)
+	}
+	for _, index := range []int{4, 4} {
+		if err := built.Request(index); err != nil {
+			t.Fatalf("request %d: %v", index, err)
+		}
+	}
+
+	first, second := <-requests, <-requests
+	if first.method != http.MethodPost || first.uri != "/v1/reports?tenant_id=tenant+a&page=2" {
+		t.Fatalf("expected the rendered path, got %s %s", first.method, first.uri)
+	}
+	if !strings.HasPrefix(first.key, "report-") || first.key == second.key {
+		t.Fatalf("expected a unique idempotency key per request, got %q and %q", first.key, second.key)
+	}
+	conversation, _ := first.body["conversation"].(map[string]any)
+	if conversation["tenant_id"] != "tenant a" || conversation["conversation_id"] != "chat-0" {
+		t.Fatalf("expected nested payload strings rendered, got %+v", first.body)
+	}
+	if first.body["context_window"] != float64(24) {
+		t.Fatalf("expected {{int ...}} rendered as a JSON number, got %#v", first.body["context_window"])
+	}
+	labels, _ := first.body["labels"].([]any)
+	if len(labels) != 3 || labels[0] != "4" || labels[1] != float64(3) || labels[2] != true {
+		t.Fatalf("expected array items rendered and literals kept, got %#v", first.body["labels"])
+	}
+	if first.body["report_type"] != "timeline" {
+		t.Fatalf("expected plain strings kept, got %+v", first.body)
+	}
+}
+
+func TestBuildScenarioRejectsBrokenTemplates(t *testing.T) {
+	var counter int64
+	for _, tc := range []struct {
+		name string
+		spec scenarioSpec
+		want string
+	}{
+		{"path syntax", scenarioSpec{Name: "bad", Path: "/v1/{{.Index"}, "path"},
+		{"unknown field", scenarioSpec{Name: "bad", Path: "/v1/{{.Missing}}"}, "render path"},
+		{"header syntax", scenarioSpec{Name: "bad", Path: "/v1", Headers: map[string]string{"X-Key": "{{"}}, "header X-Key"},
+		{"payload syntax", scenarioSpec{Name: "bad", Path: "/v1", Payload: json.RawMessage(`{"a": ["{{add 1}"]}`)}, "payload"},
+		{"int action with a suffix", scenarioSpec{Name: "bad", Path: "/v1", Payload: json.RawMessage(`{"a": "{{int .Index}}x"}`)}, ""},
+		{"non numeric int", scenarioSpec{Name: "bad", Path: "/v1", Payload: json.RawMessage(`{"a": "{{int .TenantID}}"}`)}, "render payload"},
+	} {
+		_, err := buildScenario(tc.spec, http.DefaultClient, "http://127.0.0.1", "tenant-a", &counter)
+		if tc.want == "" {
+			if err != nil {
+				t.Fatalf("%s: expected a string payload, got %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected an error about %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestLoadScenarios(t *testing.T) {
+	builtin, err := loadScenarios("")
+	if err != nil || len(builtin) == 0 {
+		t.Fatalf("expected the built-in scenarios to load, got %d %v", len(builtin), err)
+	}
+
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "scenarios.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write scenarios: %v", err)
+		}
+		return path
+	}
+	specs, err := loadScenarios(write(`{"scenarios": [
+		{"name": "list", "path": "/v1/reports"},
+		{"name": "create", "method": "put", "path": "/v1/templates", "payload": {"text": "oi"}},
+		{"name": "enqueue", "path": "/v1/summaries", "payload": {}, "expected_status": 202}
+	]}`))
+	if err != nil {
+		t.Fatalf("load scenarios: %v", err)
+	}
+	for index, want := range []struct {
+		method string
+		status int
+	}{
+		{http.MethodGet, http.StatusOK},
+		{http.MethodPut, http.StatusOK},
+		{http.MethodPost, http.StatusAccepted},
+	} {
+		if specs[index].Method != want.method || specs[index].ExpectedStatus != want.status {
+			t.Fatalf("scenario %s: expected %s %d, got %s %d", specs[index].Name, want.method, want.status, specs[index].Method, specs[index].ExpectedStatus)
+		}
+	}
+
+	for _, tc := range []struct {
+		content string
+		want    string
+	}{
+		{`{"scenarios": []}`, "no scenario defined"},
+		{`{"scenarios": [{"path": "/v1/reports"}]}`, "name is required"},
+		{`{"scenarios": [{"name": "a", "path": "/v1"}, {"name": "a", "path": "/v2"}]}`, "name is repeated"},
+		{`{"scenarios": [{"name": "a", "path": "v1/reports"}]}`, "path must start with /"},
+		{`{"scenarios": [{"name": "a", "path": "/v1", "total": -1}]}`, "must not be negative"},
+		{`{"scenarios": [{"name": "a", "path": "/v1", "paylod": {}}]}`, "unknown field"},
+	} {
+		if _, err := loadScenarios(write(tc.content)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected %q, got %v", tc.content, tc.want, err)
+		}
+	}
+}
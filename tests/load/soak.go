@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// soakOptions run the scenarios at a fixed rate for a duration, to find what degrades or
+// grows over time rather than peak latency.
+type soakOptions struct {
+	duration    time.Duration
+	rps         float64
+	concurrency int
+	interval    time.Duration
+	// metricsURL is the Prometheus endpoint of the target; "off" disables sampling.
+	metricsURL string
+	// warmup leaves the requests started at the beginning of the test out of the results.
+	warmup time.Duration
+}
+
+// targetSample is the process state of the target read from its /metrics.
+type targetSample struct {
+	HeapAllocBytes float64 `json:"heap_alloc_bytes"`
+	ResidentBytes  float64 `json:"resident_bytes,omitempty"`
+	Goroutines     float64 `json:"goroutines"`
+}
+
+type soakInterval struct {
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	Requests       int     `json:"requests"`
+	Errors         int     `json:"errors"`
+	// WarmupRequests counts the requests of the interval started during the warmup, which
+	// are left out of the other fields.
+	WarmupRequests int `json:"warmup_requests,omitempty"`
+	// Skipped counts the ticks dropped because -soak-concurrency requests were in flight.
+	Skipped       int           `json:"skipped"`
+	P50MS         float64       `json:"p50_ms"`
+	P95MS         float64       `json:"p95_ms"`
+	P99MS         float64       `json:"p99_ms"`
+	ThroughputRPS float64       `json:"throughput_rps"`
+	Target        *targetSample `json:"target,omitempty"`
+}
+
+type soakResult struct {
+	DurationSeconds float64        `json:"duration_seconds"`
+	TargetRPS       float64        `json:"target_rps"`
+	Skipped         int            `json:"skipped"`
+	Baseline        *targetSample  `json:"baseline,omitempty"`
+	Intervals       []soakInterval `json:"intervals"`
+	// The growth fields compare the last sample with the baseline taken before the load.
+	HeapGrowthBytes     float64 `json:"heap_growth_bytes"`
+	ResidentGrowthBytes float64 `json:"resident_growth_bytes"`
+	GoroutineGrowth     float64 `json:"goroutine_growth"`
+}
+
+// runSoak sends opts.rps requests per second, cycling through the scenarios with a
+// positive total, until opts.duration ends. Every opts.interval it logs the interval
+// percentiles and the memory and goroutines of the target.
+func runSoak(scenarios []scenario, opts soakOptions, client *http.Client) ([]scenarioResult, *soakResult) {
+	active := make([]scenario, 0, len(scenarios))
+	for _, item := range scenarios {
+		if item.Total > 0 {
+			active = append(active, item)
+		}
+	}
+	if len(active) == 0 || opts.rps <= 0 {
+		log.Fatalf("a soak test needs -rps > 0 and at least one scenario with a positive total")
+	}
+	if opts.concurrency <= 0 {
+		opts.concurrency = 1
+	}
+	if opts.interval <= 0 || opts.interval > opts.duration {
+		opts.interval = opts.duration
+	}
+
+	report := &soakResult{TargetRPS: opts.rps}
+	sampler := newTargetSampler(client, opts.metricsURL)
+	report.Baseline = sampler.sample()
+
+	var (
+		mu       sync.Mutex
+		totals   = make([][]sample, len(active))
+		interval []sample
+		skipped  int
+		inFlight sync.WaitGroup
+	)
+	slots := make(chan struct{}, opts.concurrency)
+	record := func(item sample, scenarioIndex int) {
+		mu.Lock()
+		defer mu.Unlock()
+		totals[scenarioIndex] = append(totals[scenarioIndex], item)
+		interval = append(interval, item)
+	}
+
+	startedAt := time.Now()
+	warmupEnd := startedAt.Add(opts.warmup)
+	intervalStart := startedAt
+	closeInterval := func(now time.Time) {
+		mu.Lock()
+		samples, skippedNow := interval, skipped
+		interval, skipped = nil, 0
+		mu.Unlock()
+
+		summary := summarize("interval", samples, measuredElapsed(intervalStart, now, warmupEnd))
+		current := soakInterval{
+			ElapsedSeconds: round2(now.Sub(startedAt).Seconds()),
+			Requests:       summary.Total,
+			Errors:         summary.Errors,
+			WarmupRequests: summary.WarmupRequests,
+			Skipped:        skippedNow,
+			P50MS:          summary.P50MS,
+			P95MS:          summary.P95MS,
+			P99MS:          summary.P99MS,
+			ThroughputRPS:  summary.ThroughputRPS,
+			Target:         sampler.sample(),
+		}
+		intervalStart = now
+		report.Skipped += skippedNow
+		report.Intervals = append(report.Intervals, current)
+		log.Print(formatInterval(current))
+	}
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.rps))
+	defer ticker.Stop()
+	reports := time.NewTicker(opts.interval)
+	defer reports.Stop()
+	deadline := time.NewTimer(opts.duration)
+	defer deadline.Stop()
+
+	counters := make([]int, len(active))
+	for tick := 0; ; {
+		select {
+		case <-ticker.C:
+			scenarioIndex := tick % len(active)
+			tick++
+			select {
+			case slots <- struct{}{}:
+			default:
+				mu.Lock()
+				skipped++
+				mu.Unlock()
+				continue
+			}
+			index := counters[scenarioIndex]
+			counters[scenarioIndex]++
+			inFlight.Add(1)
+			go func() {
+				defer inFlight.Done()
+				defer func() { <-slots }()
+				measured := measure(active[scenarioIndex].Request, index)
+				measured.warmup = measured.startedAt.Before(warmupEnd)
+				record(measured, scenarioIndex)
+			}()
+		case now := <-reports.C:
+			closeInterval(now)
+		case <-deadline.C:
+			inFlight.Wait()
+			now := time.Now()
+			if len(interval) > 0 || skipped > 0 || len(report.Intervals) == 0 {
+				closeInterval(now)
+			}
+			report.DurationSeconds = round2(now.Sub(startedAt).Seconds())
+			report.growth()
+
+			results := make([]scenarioResult, 0, len(active))
+			for scenarioIndex, item := range active {
+				results = append(results, summarize(item.Name, totals[scenarioIndex], measuredElapsed(startedAt, now, warmupEnd)))
+			}
+			return results, report
+		}
+	}
+}
+
+// growth compares the last target sample with the baseline.
+func (r *soakResult) growth() {
+	var last *targetSample
+	for _, current := range r.Intervals {
+		if current.Target != nil {
+			last = current.Target
+		}
+	}
+	if r.Baseline == nil || last == nil {
+		return
+	}
+	r.HeapGrowthBytes = last.HeapAllocBytes - r.Baseline.HeapAllocBytes
+	r.ResidentGrowthBytes = last.ResidentBytes - r.Baseline.ResidentBytes
+	r.GoroutineGrowth = last.Goroutines - r.Baseline.Goroutines
+}
+
+func formatInterval(current soakInterval) string {
+	line := fmt.Sprintf("soak t=%.0fs requests=%d errors=%d skipped=%d rps=%.1f p50=%.1fms p95=%.1fms p99=%.1fms",
+		current.ElapsedSeconds, current.Requests, current.Errors, current.Skipped,
+		current.ThroughputRPS, current.P50MS, current.P95MS, current.P99MS)
+	if current.WarmupRequests > 0 {
+		line += fmt.Sprintf(" warmup=%d", current.WarmupRequests)
+	}
+	if current.Target != nil {
+		line += fmt.Sprintf(" heap=%.1fMiB goroutines=%.0f", current.Target.HeapAllocBytes/(1<<20), current.Target.Goroutines)
+		if current.Target.ResidentBytes > 0 {
+			line += fmt.Sprintf(" rss=%.1fMiB", current.Target.ResidentBytes/(1<<20))
+		}
+	}
+	return line
+}
+
+// targetSampler reads the process metrics of the target. After the first failure it stops
+// trying, so a target without /metrics costs one warning.
+type targetSampler struct {
+	client   *http.Client
+	url      string
+	disabled bool
+}
+
+func newTargetSampler(client *http.Client, metricsURL string) *targetSampler {
+	return &targetSampler{client: client, url: metricsURL, disabled: metricsURL == "" || metricsURL == "off"}
+}
+
+func (s *targetSampler) sample() *targetSample {
+	if s.disabled {
+		return nil
+	}
+	current, err := scrapeTarget(s.client, s.url)
+	if err != nil {
+		log.Printf("warning: memory and goroutine sampling disabled: %v", err)
+		s.disabled = true
+		return nil
+	}
+	return current
+}
+
+// scrapeTarget reads the unlabeled process gauges of a Prometheus text exposition.
+func scrapeTarget(client *http.Client, metricsURL string) (*targetSample, error) {
+	response, err := client.Get(metricsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return nil, fmt.Errorf("%s answered %d", metricsURL, response.StatusCode)
+	}
+
+	values := make(map[string]float64, 3)
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		name, raw, found := strings.Cut(scanner.Text(), " ")
+		if !found || strings.HasPrefix(name, "#") {
+			continue
+		}
+		switch name {
+		case "go_goroutines", "go_memstats_heap_alloc_bytes", "process_resident_memory_bytes":
+			if value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+				values[name] = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", metricsURL, err)
+	}
+	if _, ok := values["go_goroutines"]; !ok {
+		return nil, fmt.Errorf("%s has no go_goroutines gauge", metricsURL)
+	}
+	return &targetSample{
+		HeapAllocBytes: values["go_memstats_heap_alloc_bytes"],
+		ResidentBytes:  values["process_resident_memory_bytes"],
+		Goroutines:     values["go_goroutines"],
+	}, nil
+}